@@ -0,0 +1,12 @@
+// Package hatchat embeds the HTML templates and static assets so the server
+// binary is self-contained and doesn't depend on the working directory.
+package hatchat
+
+import "embed"
+
+// Assets holds the embedded templates and static files. The server serves
+// from this by default; pass -assets-from-disk to read from disk instead
+// during development.
+//
+//go:embed template static
+var Assets embed.FS
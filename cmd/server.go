@@ -9,9 +9,10 @@ import (
 )
 
 var (
-	addr  = flag.String("addr", "localhost:8080", "address for hatchat to listen on")
-	level = flag.String("log-level", "", "log level to print logs at")
-	db    = flag.String("db", "file:chat.db", "location for the chat database. Must be a url like 'file:chat.db'")
+	addr       = flag.String("addr", "localhost:8080", "address for hatchat to listen on")
+	level      = flag.String("log-level", "", "log level to print logs at")
+	db         = flag.String("db", "file:chat.db", "location for the chat database. Must be a url like 'file:chat.db'")
+	diskAssets = flag.Bool("assets-from-disk", false, "serve templates and static files from disk instead of the embedded copies")
 )
 
 func main() {
@@ -31,5 +32,8 @@ func main() {
 		fmt.Printf("Unable to start chat server: %v\n", err)
 		os.Exit(1)
 	}
+	if *diskAssets {
+		server.UseDiskAssets()
+	}
 	server.Run(*addr)
 }
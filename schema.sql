@@ -8,10 +8,22 @@ CREATE TABLE IF NOT EXISTS users(
   avatar TEXT, -- the URL of an avatar image
   last_room TEXT NOT NULL, -- the id of last room the user was in
   created_at TEXT NOT NULL,
-  modified_at TEXT NOT NULL
+  modified_at TEXT NOT NULL,
+  last_active_at TEXT NOT NULL DEFAULT '' -- last connect or message send; updated at most once per minute
 ) STRICT;
 
 CREATE UNIQUE INDEX IF NOT EXISTS users_username ON users(username);
+-- Case-insensitive uniqueness: "Alice" and "alice" would both satisfy the
+-- index above while making username lookups ambiguous. Existing duplicates
+-- are renamed at startup before this index applies.
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_nocase ON users(username COLLATE NOCASE);
+
+-- Signup invite codes for invite-only registration (separate from room invites)
+CREATE TABLE IF NOT EXISTS signup_invites(
+  code TEXT PRIMARY KEY NOT NULL,
+  created_at TEXT NOT NULL,
+  used_at TEXT -- NULL until the code is consumed by a successful registration
+) STRICT;
 
 CREATE TABLE IF NOT EXISTS sessions(
   id TEXT PRIMARY KEY NOT NULL,
@@ -22,9 +34,15 @@ CREATE TABLE IF NOT EXISTS sessions(
 CREATE TABLE IF NOT EXISTS rooms_members(
   user_id TEXT REFERENCES users(id) NOT NULL,
   room_id TEXT REFERENCES rooms(id) NOT NULL,
+  role TEXT NOT NULL DEFAULT 'member', -- 'owner', 'admin', or 'member'
+  muted INTEGER NOT NULL DEFAULT 0, -- true to exclude the room from catch-up summaries
   PRIMARY KEY (user_id, room_id)
 ) STRICT;
 
+-- Room-side membership lookups (member lists, admin lookups); the primary
+-- key only covers lookups that lead with user_id
+CREATE INDEX IF NOT EXISTS rooms_members_room ON rooms_members(room_id);
+
 CREATE TABLE IF NOT EXISTS rooms(
   id TEXT PRIMARY KEY NOT NULL,
   name TEXT NOT NULL, -- empty for DMs; display name derived from members
@@ -32,25 +50,195 @@ CREATE TABLE IF NOT EXISTS rooms(
   is_private INTEGER NOT NULL,
   is_default INTEGER NOT NULL,
   created_at TEXT NOT NULL,
-  last_message_at TEXT -- for sorting DMs by most recent activity; NULL if no messages
+  last_message_at TEXT, -- for sorting DMs by most recent activity; NULL if no messages
+  slow_mode_seconds INTEGER NOT NULL DEFAULT 0, -- minimum interval between posts; 0 = disabled
+  retention_days INTEGER NOT NULL DEFAULT 0, -- messages older than this many days are purged; 0 = keep forever
+  topic TEXT NOT NULL DEFAULT '', -- short room description shown in the header; settable by owners and admins
+  join_policy TEXT NOT NULL DEFAULT 'open', -- 'open' (anyone joins), 'request' (joining needs admin approval), or 'invite' (members must be added)
+  post_policy TEXT NOT NULL DEFAULT 'all', -- 'all' (any member may post) or 'admins' (announcement mode: only owners and admins may post)
+  announce_pins INTEGER NOT NULL DEFAULT 1 -- post a system message into the room when a message is pinned
+) STRICT;
+
+-- Pending requests to join rooms whose join_policy is 'request'; rows are
+-- removed when an admin approves or denies them
+CREATE TABLE IF NOT EXISTS room_join_requests(
+  user_id TEXT REFERENCES users(id) NOT NULL,
+  room_id TEXT REFERENCES rooms(id) NOT NULL,
+  created_at TEXT NOT NULL,
+  PRIMARY KEY (user_id, room_id)
 ) STRICT;
 
 -- Unique room names, but only for channels (DMs can have empty names)
 CREATE UNIQUE INDEX IF NOT EXISTS rooms_name ON rooms(name) WHERE room_type = 'channel' AND name != '';
 
+-- Per-user read position in each room, used to compute unread boundaries
+CREATE TABLE IF NOT EXISTS room_reads(
+  user_id TEXT REFERENCES users(id) NOT NULL,
+  room_id TEXT REFERENCES rooms(id) NOT NULL,
+  last_read_message_id TEXT REFERENCES messages(id) NOT NULL,
+  updated_at TEXT NOT NULL,
+  PRIMARY KEY (user_id, room_id)
+) STRICT;
+
 CREATE TABLE IF NOT EXISTS messages(
   id TEXT PRIMARY KEY NOT NULL,
   room_id TEXT REFERENCES rooms(id) NOT NULL,
   user_id TEXT REFERENCES users(id) NOT NULL,
   body TEXT NOT NULL,
-  created_at TEXT NOT NULL,
+  seq INTEGER NOT NULL DEFAULT 0, -- monotonic ordering key assigned by trigger; authoritative order, robust to clock skew
+  created_at TEXT NOT NULL, -- display only; seq is the ordering key
   modified_at TEXT NOT NULL,
-  deleted_at TEXT -- NULL = not deleted, RFC3339 timestamp = soft-deleted
+  deleted_at TEXT, -- NULL = not deleted, RFC3339 timestamp = soft-deleted
+  enc_key_id TEXT NOT NULL DEFAULT '', -- ID of the key that encrypted body at rest; '' = stored as plaintext
+  is_system INTEGER NOT NULL DEFAULT 0 -- 1 = server-generated announcement (e.g. a pin notice); never editable or deletable by users
 ) STRICT;
 
--- Index for fetching messages by room, ordered by creation time (newest first for pagination)
+-- Assign each message the next sequence number on insert. created_at comes
+-- from the server clock, which can jump backward under NTP correction; seq
+-- only ever increases, so history order and cursors survive clock changes.
+CREATE TRIGGER IF NOT EXISTS messages_assign_seq AFTER INSERT ON messages
+WHEN NEW.seq = 0
+BEGIN
+  UPDATE messages SET seq = (SELECT IFNULL(MAX(seq), 0) + 1 FROM messages) WHERE id = NEW.id;
+END;
+
+-- Index for fetching messages by room, ordered by sequence (newest first for pagination)
+CREATE INDEX IF NOT EXISTS messages_room_seq ON messages(room_id, seq DESC);
+
+-- Index for retention scans by creation time
 CREATE INDEX IF NOT EXISTS messages_room_created ON messages(room_id, created_at DESC);
 
+-- Files uploaded by users; message_id is NULL until the upload is linked to
+-- a sent message, and only the uploader may link it
+CREATE TABLE IF NOT EXISTS attachments(
+  id TEXT PRIMARY KEY NOT NULL,
+  message_id TEXT REFERENCES messages(id),
+  user_id TEXT REFERENCES users(id) NOT NULL, -- the uploader
+  url TEXT NOT NULL,
+  filename TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size INTEGER NOT NULL, -- size in bytes
+  created_at TEXT NOT NULL,
+  thumbnail_url TEXT NOT NULL DEFAULT '' -- downscaled preview for image attachments; empty if none
+) STRICT;
+
+CREATE INDEX IF NOT EXISTS attachments_message ON attachments(message_id);
+
+-- Attribution for forwarded messages: maps a forwarded copy back to the
+-- message it was forwarded from
+CREATE TABLE IF NOT EXISTS message_forwards(
+  message_id TEXT PRIMARY KEY REFERENCES messages(id) NOT NULL,
+  source_message_id TEXT REFERENCES messages(id) NOT NULL,
+  source_room_id TEXT REFERENCES rooms(id) NOT NULL,
+  source_user_id TEXT REFERENCES users(id) NOT NULL
+) STRICT;
+
+-- Inline quote snapshots: the quoted author, excerpt, and timestamp are
+-- copied at send time so the quote survives the original message being
+-- deleted or purged; quoted_message_id deliberately has no foreign key for
+-- the same reason.
+CREATE TABLE IF NOT EXISTS message_quotes(
+  message_id TEXT PRIMARY KEY REFERENCES messages(id) NOT NULL,
+  quoted_message_id TEXT NOT NULL,
+  quoted_user_id TEXT NOT NULL,
+  quoted_username TEXT NOT NULL,
+  excerpt TEXT NOT NULL,
+  quoted_created_at TEXT NOT NULL
+) STRICT;
+
+-- Privately saved ("starred") messages; visible only to the user who
+-- starred them.
+CREATE TABLE IF NOT EXISTS starred_messages(
+  user_id TEXT REFERENCES users(id) NOT NULL,
+  message_id TEXT REFERENCES messages(id) NOT NULL,
+  created_at TEXT NOT NULL,
+  PRIMARY KEY (user_id, message_id)
+) STRICT;
+
+-- Messages pinned in a room; pinning is idempotent per (room, message)
+CREATE TABLE IF NOT EXISTS pinned_messages(
+  room_id TEXT REFERENCES rooms(id) NOT NULL,
+  message_id TEXT REFERENCES messages(id) NOT NULL,
+  pinned_by TEXT REFERENCES users(id) NOT NULL,
+  created_at TEXT NOT NULL,
+  PRIMARY KEY (room_id, message_id)
+) STRICT;
+
+-- Rooms a user has pinned to the top of their sidebar, ordered by position
+-- (lowest first); per-user, independent of activity ordering
+CREATE TABLE IF NOT EXISTS favorite_rooms(
+  user_id TEXT REFERENCES users(id) NOT NULL,
+  room_id TEXT REFERENCES rooms(id) NOT NULL,
+  position INTEGER NOT NULL,
+  PRIMARY KEY (user_id, room_id)
+) STRICT;
+
+-- DMs a user has removed from their sidebar. Hiding is per-user and never
+-- touches the shared room or its messages; a new message in the DM
+-- un-hides it for every participant.
+CREATE TABLE IF NOT EXISTS hidden_dms(
+  user_id TEXT REFERENCES users(id) NOT NULL,
+  room_id TEXT REFERENCES rooms(id) NOT NULL,
+  created_at TEXT NOT NULL,
+  PRIMARY KEY (user_id, room_id)
+) STRICT;
+
+-- Per-user display labels for DM rooms. Group DMs have no stored name, so
+-- clients derive one from the member list; a label overrides that for the
+-- user who set it without affecting other participants.
+CREATE TABLE IF NOT EXISTS dm_labels(
+  user_id TEXT REFERENCES users(id) NOT NULL,
+  room_id TEXT REFERENCES rooms(id) NOT NULL,
+  label TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  PRIMARY KEY (user_id, room_id)
+) STRICT;
+
+-- Member reports flagging a message for the room's moderators; one row per
+-- reporter per message. Resolved and dismissed reports are kept for audit,
+-- marked with who handled them and when
+CREATE TABLE IF NOT EXISTS message_reports(
+  message_id TEXT REFERENCES messages(id) NOT NULL,
+  reporter_id TEXT REFERENCES users(id) NOT NULL,
+  room_id TEXT REFERENCES rooms(id) NOT NULL, -- denormalized so a room's reports can be listed without joining messages
+  reason TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'open', -- 'open', 'resolved', or 'dismissed'
+  created_at TEXT NOT NULL,
+  resolved_by TEXT, -- the owner or admin who resolved or dismissed the report
+  resolved_at TEXT,
+  PRIMARY KEY (message_id, reporter_id)
+) STRICT;
+
+-- One row per DM room, keyed by a hash of the sorted member IDs. The
+-- primary key makes DM creation race-safe: when two requests for the same
+-- member set create rooms concurrently, only one can claim the key and the
+-- loser returns the winner's room. DMs from before this table have no row
+-- until a create_dm for the same members backfills one
+CREATE TABLE IF NOT EXISTS dm_keys(
+  dm_key TEXT PRIMARY KEY NOT NULL,
+  room_id TEXT REFERENCES rooms(id) NOT NULL,
+  created_at TEXT NOT NULL
+) STRICT;
+
+-- Per-user display preferences (theme, clock format, density), synced
+-- across devices. The server caps the size and checks top-level key names
+-- but otherwise treats the JSON blob as opaque.
+CREATE TABLE IF NOT EXISTS user_prefs(
+  user_id TEXT PRIMARY KEY REFERENCES users(id) NOT NULL,
+  prefs TEXT NOT NULL, -- JSON object keyed by preference name
+  modified_at TEXT NOT NULL
+) STRICT;
+
+-- Per-room content filter rules, managed by owners and admins. Patterns
+-- are matched case-insensitively on word boundaries.
+CREATE TABLE IF NOT EXISTS room_filters(
+  room_id TEXT REFERENCES rooms(id) NOT NULL,
+  pattern TEXT NOT NULL, -- the disallowed term
+  action TEXT NOT NULL DEFAULT 'reject', -- 'reject' blocks the message; 'mask' stores it with matches replaced by asterisks
+  created_at TEXT NOT NULL,
+  PRIMARY KEY (room_id, pattern)
+) STRICT;
+
 CREATE TABLE IF NOT EXISTS reactions(
   message_id TEXT REFERENCES messages(id) NOT NULL,
   user_id TEXT REFERENCES users(id) NOT NULL,
@@ -61,6 +249,19 @@ CREATE TABLE IF NOT EXISTS reactions(
 
 CREATE INDEX IF NOT EXISTS reactions_message ON reactions(message_id);
 
+-- Audit trail of moderation-relevant actions. Deliberately free of foreign
+-- keys so entries outlive the users and rooms they describe.
+CREATE TABLE IF NOT EXISTS audit_log(
+  id INTEGER PRIMARY KEY,
+  actor_id TEXT NOT NULL,
+  action TEXT NOT NULL,
+  target_id TEXT NOT NULL DEFAULT '', -- the message, room, or user acted on
+  room_id TEXT NOT NULL DEFAULT '', -- the room the action happened in, if any
+  created_at TEXT NOT NULL
+) STRICT;
+
+CREATE INDEX IF NOT EXISTS audit_log_created ON audit_log(created_at);
+
 -- FTS5 virtual table for full-text message search.
 -- Uses external content mode: stores only the index, not the text.
 -- The content='messages' links it to the messages table.
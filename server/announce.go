@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/llimllib/hatchat/server/api"
+	"github.com/llimllib/hatchat/server/middleware"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// announceRequest is the body of an admin announcement. With no rooms
+// listed the announcement goes to every channel room.
+type announceRequest struct {
+	Text  string   `json:"text"`
+	Rooms []string `json:"rooms,omitempty"`
+}
+
+// announceResponse reports how many rooms an announcement reached
+type announceResponse struct {
+	RoomsAnnounced int `json:"rooms_announced"`
+}
+
+// announceAPI handles POST /api/v1/admin/announce for users named in
+// ADMIN_USERS. It persists a system message in every target room and
+// broadcasts it live to each room's subscribers, so operators can notify
+// everyone about maintenance or policy changes.
+func (h *ChatServer) announceAPI(wsAPI *api.Api, hub *Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := models.UserByID(r.Context(), h.db, middleware.GetUserID(r.Context()))
+		if err != nil {
+			h.logger.Error("unable to find user for announce request", "err", err)
+			h.writeAuthJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+			return
+		}
+		if !h.adminUsers[user.Username] {
+			h.logger.Warn("non-admin attempted to post an announcement", "user", user.ID)
+			h.writeAuthJSON(w, http.StatusForbidden, map[string]string{"error": "Forbidden"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			h.writeAuthJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "POST required"})
+			return
+		}
+
+		var req announceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeAuthJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+
+		announcements, err := wsAPI.Announce(user, req.Text, req.Rooms)
+		if err != nil {
+			if errors.Is(err, api.ErrValidation) {
+				h.writeAuthJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			} else {
+				h.logger.Error("failed to post announcement", "error", err, "by", user.ID)
+				h.writeAuthJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to post announcement"})
+			}
+			return
+		}
+
+		for _, a := range announcements {
+			hub.broadcast <- RoomMessage{RoomID: a.RoomID, Message: a.Message}
+		}
+
+		h.logger.Info("announcement posted", "rooms", len(announcements), "by", user.ID)
+		h.writeAuthJSON(w, http.StatusOK, announceResponse{RoomsAnnounced: len(announcements)})
+	}
+}
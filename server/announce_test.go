@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/api"
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/middleware"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// TestAnnounceAPIAdminOnly tests that only users named in ADMIN_USERS can
+// post an announcement, and that an admin's announcement reaches every
+// channel room
+func TestAnnounceAPIAdminOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testDB, err := db.NewDB("file::memory:?cache=shared", logger)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() { _ = testDB.Close() }()
+	if err := testDB.RunSQLFile("../schema.sql"); err != nil {
+		t.Fatalf("Failed to run schema: %v", err)
+	}
+
+	chatServer := &ChatServer{
+		db:         testDB,
+		logger:     logger,
+		adminUsers: map[string]bool{"announceadmin": true},
+	}
+
+	hub := newHub(testDB, logger)
+	go hub.run()
+	wsAPI := api.NewApi(testDB, logger)
+
+	now := time.Now().Format(time.RFC3339)
+	admin := &models.User{ID: "usr_annc_admin01", Username: "announceadmin", Password: "x", CreatedAt: now, ModifiedAt: now}
+	regular := &models.User{ID: "usr_annc_plain01", Username: "announceplain", Password: "x", CreatedAt: now, ModifiedAt: now}
+	for _, u := range []*models.User{admin, regular} {
+		if err := u.Insert(context.Background(), testDB); err != nil {
+			t.Fatalf("Failed to insert user: %v", err)
+		}
+	}
+
+	for _, room := range []models.Room{
+		{ID: "roo_annc_one0001", Name: "annc-one", RoomType: "channel", CreatedAt: now},
+		{ID: "roo_annc_two0001", Name: "annc-two", RoomType: "channel", CreatedAt: now},
+	} {
+		if err := room.Insert(context.Background(), testDB); err != nil {
+			t.Fatalf("Failed to insert room: %v", err)
+		}
+	}
+
+	// announceAPI runs behind AuthMiddleware, which stores the user ID in
+	// the request context; inject it directly here
+	request := func(userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/announce", strings.NewReader(body))
+		ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
+		w := httptest.NewRecorder()
+		chatServer.announceAPI(wsAPI, hub)(w, req.WithContext(ctx))
+		return w
+	}
+
+	if w := request(regular.ID, `{"text":"nope"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin, got %d", w.Code)
+	}
+
+	w := request(admin.ID, `{"text":"upgrading the server tonight"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for admin, got %d: %s", w.Code, w.Body.String())
+	}
+	var res announceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if res.RoomsAnnounced != 2 {
+		t.Errorf("Expected the announcement to reach 2 rooms, got %d", res.RoomsAnnounced)
+	}
+
+	// The announcement is persisted as a system message in each room
+	for _, roomID := range []string{"roo_annc_one0001", "roo_annc_two0001"} {
+		messages, err := db.GetRoomMessages(context.Background(), testDB, roomID, "", 10)
+		if err != nil {
+			t.Fatalf("GetRoomMessages failed: %v", err)
+		}
+		if len(messages) != 1 || messages[0].IsSystem != 1 {
+			t.Errorf("Expected 1 system message in room %s, got %v", roomID, messages)
+		}
+	}
+
+	if w := request(admin.ID, `{"text":""}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for empty text, got %d", w.Code)
+	}
+}
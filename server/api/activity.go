@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// activityInterval is the minimum time between last_active_at writes for
+// one user, so a chatty user doesn't turn every message into an UPDATE
+const activityInterval = time.Minute
+
+// activityThrottle remembers when each user's last_active_at was last
+// written, so TouchUserActivity can skip redundant writes
+type activityThrottle struct {
+	mu      sync.Mutex
+	touched map[string]time.Time
+}
+
+func newActivityThrottle() *activityThrottle {
+	return &activityThrottle{touched: make(map[string]time.Time)}
+}
+
+// shouldTouch reports whether enough time has passed to write again for
+// this user, and records the write if so
+func (a *activityThrottle) shouldTouch(userID string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if last, ok := a.touched[userID]; ok && now.Sub(last) < activityInterval {
+		return false
+	}
+	a.touched[userID] = now
+	return true
+}
+
+// TouchUserActivity stamps the user's last_active_at, at most once per
+// activityInterval. It is called on connect and for every application
+// message, so failures are logged rather than surfaced to the caller.
+func (a *Api) TouchUserActivity(user *models.User) {
+	now := time.Now()
+	if !a.activity.shouldTouch(user.ID, now) {
+		return
+	}
+	if err := db.TouchUserActivity(context.Background(), a.db, user.ID, now.Format(time.RFC3339)); err != nil {
+		a.logger.Error("failed to update last_active_at", "error", err, "user", user.ID)
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// storedLastActive reads a user's last_active_at straight from the users
+// table
+func storedLastActive(t *testing.T, api *Api, userID string) string {
+	t.Helper()
+	var lastActive string
+	row := api.db.QueryRowContext(context.Background(),
+		"SELECT last_active_at FROM users WHERE id = ?", userID)
+	if err := row.Scan(&lastActive); err != nil {
+		t.Fatalf("Failed to read last_active_at: %v", err)
+	}
+	return lastActive
+}
+
+// TestActivity_UpdatesTimestamp tests that activity stamps
+// last_active_at
+func TestActivity_UpdatesTimestamp(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_act_00000001", "act-user")
+	if stamp := storedLastActive(t, api, user.ID); stamp != "" {
+		t.Fatalf("Expected no last_active_at before activity, got %q", stamp)
+	}
+
+	api.TouchUserActivity(user)
+
+	stamp := storedLastActive(t, api, user.ID)
+	if stamp == "" {
+		t.Fatal("Expected last_active_at to be set after activity")
+	}
+	if _, err := time.Parse(time.RFC3339, stamp); err != nil {
+		t.Errorf("Expected an RFC3339 timestamp, got %q: %v", stamp, err)
+	}
+}
+
+// TestActivity_RapidActivityThrottled tests that rapid activity does not
+// write on every call; once the interval has passed the next call writes
+// again
+func TestActivity_RapidActivityThrottled(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_act_00000002", "act-chatty")
+	api.TouchUserActivity(user)
+
+	// Plant a sentinel; a throttled call must not overwrite it
+	sentinel := "sentinel-value"
+	if _, err := database.ExecContext(context.Background(),
+		"UPDATE users SET last_active_at = ? WHERE id = ?", sentinel, user.ID); err != nil {
+		t.Fatalf("Failed to plant sentinel: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		api.TouchUserActivity(user)
+	}
+	if stamp := storedLastActive(t, api, user.ID); stamp != sentinel {
+		t.Errorf("Expected rapid activity to be throttled, but last_active_at was written: %q", stamp)
+	}
+
+	// Backdate the throttle record; the next call writes again
+	api.activity.mu.Lock()
+	api.activity.touched[user.ID] = time.Now().Add(-2 * activityInterval)
+	api.activity.mu.Unlock()
+
+	api.TouchUserActivity(user)
+	if stamp := storedLastActive(t, api, user.ID); stamp == sentinel {
+		t.Error("Expected a write once the throttle interval passed")
+	}
+}
+
+// TestActivity_AppearsInProfile tests that last_active_at is exposed in
+// get_profile responses
+func TestActivity_AppearsInProfile(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	target := createTestUser(t, database, "usr_act_00000003", "act-target")
+	viewer := createTestUser(t, database, "usr_act_00000004", "act-viewer")
+	api.TouchUserActivity(target)
+
+	reqJSON, _ := json.Marshal(protocol.GetProfileRequest{UserID: target.ID})
+	envelope, err := api.GetProfile(viewer, reqJSON)
+	if err != nil {
+		t.Fatalf("GetProfile failed: %v", err)
+	}
+	profile, ok := envelope.Data.(protocol.GetProfileResponse)
+	if !ok {
+		t.Fatalf("Expected a GetProfileResponse, got %T", envelope.Data)
+	}
+	if profile.LastActiveAt == "" {
+		t.Error("Expected last_active_at in the profile response")
+	}
+	if profile.LastActiveAt != storedLastActive(t, api, target.ID) {
+		t.Errorf("Expected the profile to match the stored timestamp")
+	}
+}
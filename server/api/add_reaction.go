@@ -20,6 +20,10 @@ type ReactionResponse struct {
 // AddReaction handles a request to add an emoji reaction to a message.
 // Any room member can react. Duplicate reactions are idempotent.
 func (a *Api) AddReaction(user *models.User, msg json.RawMessage) (*ReactionResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.AddReactionRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		a.logger.Error("invalid json for add_reaction", "error", err)
@@ -27,7 +31,7 @@ func (a *Api) AddReaction(user *models.User, msg json.RawMessage) (*ReactionResp
 	}
 
 	if req.MessageID == "" || req.Emoji == "" {
-		return nil, fmt.Errorf("message_id and emoji are required")
+		return nil, fmt.Errorf("message_id and emoji are required: %w", ErrValidation)
 	}
 
 	ctx := context.Background()
@@ -50,17 +54,13 @@ func (a *Api) AddReaction(user *models.User, msg json.RawMessage) (*ReactionResp
 		return nil, err
 	}
 	if !isMember {
-		return nil, fmt.Errorf("user is not a member of the room")
+		return nil, ErrNotMember
 	}
 
-	// Insert reaction (upsert to handle duplicates idempotently)
-	reaction := models.Reaction{
-		MessageID: req.MessageID,
-		UserID:    user.ID,
-		Emoji:     req.Emoji,
-		CreatedAt: time.Now().Format(time.RFC3339Nano),
-	}
-	if err = reaction.Upsert(ctx, a.db); err != nil {
+	// Insert the reaction and count in one transaction; duplicates are
+	// idempotent and the count is never stale under concurrent reactions
+	count, err := db.AddReaction(ctx, a.db, req.MessageID, user.ID, req.Emoji, time.Now().Format(time.RFC3339Nano))
+	if err != nil {
 		a.logger.Error("failed to add reaction", "error", err)
 		return nil, err
 	}
@@ -70,8 +70,10 @@ func (a *Api) AddReaction(user *models.User, msg json.RawMessage) (*ReactionResp
 		MessageID: req.MessageID,
 		RoomID:    message.RoomID,
 		UserID:    user.ID,
+		Username:  user.Username,
 		Emoji:     req.Emoji,
 		Action:    "add",
+		Count:     count,
 	}
 
 	msgBytes, err := json.Marshal(&Envelope{
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// AddedMember pairs an added user's ID with the member_changed envelope
+// announcing them, so the client layer can route it to the room and to
+// the new member's own connections
+type AddedMember struct {
+	UserID  string
+	Message []byte
+}
+
+// AddMembersResult contains the add_members response envelope and one
+// member_changed broadcast per added user
+type AddMembersResult struct {
+	Envelope *Envelope
+	RoomID   string
+	Added    []AddedMember
+}
+
+// AddMembers handles a request to add several users to a room at once.
+// Users who are already members are skipped and unknown user IDs are
+// reported in the response rather than failing the batch. For private
+// rooms only owners and admins may add members.
+func (a *Api) AddMembers(user *models.User, msg json.RawMessage) (*AddMembersResult, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.AddMembersRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid add_members json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required: %w", ErrValidation)
+	}
+	if len(req.UserIDs) == 0 {
+		return nil, fmt.Errorf("at least one user_id is required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found: %w", ErrValidation)
+	}
+	if room.RoomType == "dm" {
+		return nil, fmt.Errorf("cannot add members to a DM: %w", ErrValidation)
+	}
+
+	// The requester must be a member of the room; for private rooms they
+	// must also be an owner or admin
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, room.ID)
+	if err != nil {
+		a.logger.Error("failed to check room membership", "error", err, "user", user.ID, "room", room.ID)
+		return nil, err
+	}
+	if !isMember {
+		return nil, fmt.Errorf("you are not a member of this room: %w", ErrNotMember)
+	}
+	if room.IsPrivate != 0 {
+		role, err := db.GetMemberRole(ctx, a.db, user.ID, room.ID)
+		if err != nil {
+			a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", room.ID)
+			return nil, err
+		}
+		if role != db.RoleOwner && role != db.RoleAdmin {
+			a.logger.Warn("user attempted to add members without permission", "user", user.ID, "room", room.ID, "role", role)
+			return nil, fmt.Errorf("only room owners and admins can add members to a private room")
+		}
+	}
+
+	// Split the requested IDs into known users and unknown IDs; unknown
+	// IDs are reported back rather than failing the whole batch
+	known := []*models.User{}
+	knownIDs := []string{}
+	unknown := []string{}
+	seen := make(map[string]bool)
+	for _, uid := range req.UserIDs {
+		if uid == "" || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		u, err := models.UserByID(ctx, a.db, uid)
+		if err != nil {
+			unknown = append(unknown, uid)
+			continue
+		}
+		known = append(known, u)
+		knownIDs = append(knownIDs, uid)
+	}
+
+	addedIDs, err := db.AddRoomMembers(ctx, a.db, room.ID, knownIDs)
+	if err != nil {
+		a.logger.Error("failed to add room members", "error", err, "room", room.ID)
+		return nil, err
+	}
+	addedSet := make(map[string]bool)
+	for _, id := range addedIDs {
+		addedSet[id] = true
+	}
+
+	// Anyone known but not inserted was already a member
+	skipped := []string{}
+	added := []AddedMember{}
+	for _, u := range known {
+		if !addedSet[u.ID] {
+			skipped = append(skipped, u.ID)
+			continue
+		}
+		msgBytes, err := json.Marshal(&Envelope{
+			Type: "member_changed",
+			Data: protocol.MemberChanged{
+				RoomID: room.ID,
+				Member: protocol.RoomMember{
+					ID:          u.ID,
+					Username:    u.Username,
+					DisplayName: u.DisplayName,
+					Avatar:      u.Avatar.String,
+				},
+				Change: "added",
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, AddedMember{UserID: u.ID, Message: msgBytes})
+	}
+
+	a.logger.Info("members added", "room", room.ID, "by", user.ID,
+		"added", len(added), "skipped", len(skipped), "unknown", len(unknown))
+
+	return &AddMembersResult{
+		Envelope: &Envelope{
+			Type: "add_members",
+			Data: protocol.AddMembersResponse{
+				RoomID:         room.ID,
+				AddedUserIDs:   addedIDs,
+				SkippedUserIDs: skipped,
+				UnknownUserIDs: unknown,
+			},
+		},
+		RoomID: room.ID,
+		Added:  added,
+	}, nil
+}
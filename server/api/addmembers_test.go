@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// addMembers is a helper that runs AddMembers and returns the response data
+func addMembers(t *testing.T, api *Api, user *models.User, roomID string, userIDs []string) (*AddMembersResult, protocol.AddMembersResponse) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.AddMembersRequest{RoomID: roomID, UserIDs: userIDs})
+	res, err := api.AddMembers(user, reqJSON)
+	if err != nil {
+		t.Fatalf("AddMembers failed: %v", err)
+	}
+	resp, ok := res.Envelope.Data.(protocol.AddMembersResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.AddMembersResponse data type, got %T", res.Envelope.Data)
+	}
+	return res, resp
+}
+
+// TestAddMembers tests adding several users to a room in one request
+func TestAddMembers(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_addmem_own01", "addmemowner")
+	alice := createTestUser(t, database, "usr_addmem_ali01", "addmemalice")
+	bob := createTestUser(t, database, "usr_addmem_bob01", "addmembob")
+	room := createTestRoom(t, database, "roo_addmem_00001", "addmem-bulk", false)
+	addUserToRoom(t, database, owner.ID, room.ID)
+
+	res, resp := addMembers(t, api, owner, room.ID, []string{alice.ID, bob.ID})
+	if len(resp.AddedUserIDs) != 2 {
+		t.Fatalf("expected 2 added users, got %v", resp.AddedUserIDs)
+	}
+	if len(resp.SkippedUserIDs) != 0 || len(resp.UnknownUserIDs) != 0 {
+		t.Errorf("expected no skipped or unknown users, got %v / %v", resp.SkippedUserIDs, resp.UnknownUserIDs)
+	}
+	if len(res.Added) != 2 {
+		t.Fatalf("expected 2 member_changed broadcasts, got %d", len(res.Added))
+	}
+
+	// The broadcast carries the member's details
+	var env struct {
+		Type string                 `json:"type"`
+		Data protocol.MemberChanged `json:"data"`
+	}
+	if err := json.Unmarshal(res.Added[0].Message, &env); err != nil {
+		t.Fatalf("failed to unmarshal member_changed: %v", err)
+	}
+	if env.Type != "member_changed" {
+		t.Errorf("expected member_changed envelope, got %q", env.Type)
+	}
+	if env.Data.RoomID != room.ID || env.Data.Member.ID != alice.ID || env.Data.Change != "added" {
+		t.Errorf("unexpected member_changed payload: %+v", env.Data)
+	}
+
+	for _, u := range []*models.User{alice, bob} {
+		isMember, err := db.IsRoomMember(context.Background(), database, u.ID, room.ID)
+		if err != nil {
+			t.Fatalf("IsRoomMember failed: %v", err)
+		}
+		if !isMember {
+			t.Errorf("expected %s to be a member after AddMembers", u.Username)
+		}
+	}
+}
+
+// TestAddMembers_SkipsExisting tests that users who are already members
+// are skipped rather than failing the batch
+func TestAddMembers_SkipsExisting(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_addmem_own02", "addmemowner2")
+	already := createTestUser(t, database, "usr_addmem_alr01", "addmemalready")
+	fresh := createTestUser(t, database, "usr_addmem_frs01", "addmemfresh")
+	room := createTestRoom(t, database, "roo_addmem_00002", "addmem-skip", false)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	addUserToRoom(t, database, already.ID, room.ID)
+
+	res, resp := addMembers(t, api, owner, room.ID, []string{already.ID, fresh.ID})
+	if len(resp.AddedUserIDs) != 1 || resp.AddedUserIDs[0] != fresh.ID {
+		t.Errorf("expected only %s to be added, got %v", fresh.ID, resp.AddedUserIDs)
+	}
+	if len(resp.SkippedUserIDs) != 1 || resp.SkippedUserIDs[0] != already.ID {
+		t.Errorf("expected %s to be skipped, got %v", already.ID, resp.SkippedUserIDs)
+	}
+	// No member_changed broadcast for the user who was already a member
+	if len(res.Added) != 1 || res.Added[0].UserID != fresh.ID {
+		t.Errorf("expected one broadcast for %s, got %+v", fresh.ID, res.Added)
+	}
+}
+
+// TestAddMembers_ReportsUnknown tests that unknown user IDs are reported
+// in the response while the known users are still added
+func TestAddMembers_ReportsUnknown(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_addmem_own03", "addmemowner3")
+	known := createTestUser(t, database, "usr_addmem_kno01", "addmemknown")
+	room := createTestRoom(t, database, "roo_addmem_00003", "addmem-unknown", false)
+	addUserToRoom(t, database, owner.ID, room.ID)
+
+	_, resp := addMembers(t, api, owner, room.ID, []string{known.ID, "usr_nosuchuser01"})
+	if len(resp.AddedUserIDs) != 1 || resp.AddedUserIDs[0] != known.ID {
+		t.Errorf("expected only %s to be added, got %v", known.ID, resp.AddedUserIDs)
+	}
+	if len(resp.UnknownUserIDs) != 1 || resp.UnknownUserIDs[0] != "usr_nosuchuser01" {
+		t.Errorf("expected the unknown ID to be reported, got %v", resp.UnknownUserIDs)
+	}
+}
+
+// TestAddMembers_PrivateRoomPermissions tests that only owners and admins
+// may add members to a private room
+func TestAddMembers_PrivateRoomPermissions(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_addmem_own04", "addmemowner4")
+	member := createTestUser(t, database, "usr_addmem_mem01", "addmemmember")
+	invitee := createTestUser(t, database, "usr_addmem_inv01", "addmeminvitee")
+	room := createTestRoomWithPrivate(t, database, "roo_addmem_00004", "addmem-private", false, true)
+	if _, err := db.AddRoomMemberWithRole(context.Background(), database, owner.ID, room.ID, db.RoleOwner); err != nil {
+		t.Fatalf("failed to add owner: %v", err)
+	}
+	addUserToRoom(t, database, member.ID, room.ID)
+
+	// A plain member may not add members to a private room
+	reqJSON, _ := json.Marshal(protocol.AddMembersRequest{RoomID: room.ID, UserIDs: []string{invitee.ID}})
+	if _, err := api.AddMembers(member, reqJSON); err == nil {
+		t.Fatal("expected a plain member to be rejected for a private room")
+	}
+
+	// The owner may
+	if _, err := api.AddMembers(owner, reqJSON); err != nil {
+		t.Fatalf("expected the owner to be allowed to add members, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// maxAnnouncementLength caps announcement text, since the same body is
+// persisted once per room
+const maxAnnouncementLength = 2000
+
+// Announcement is one posted announcement message, ready for hub delivery
+// to its room
+type Announcement struct {
+	RoomID  string
+	Message []byte
+}
+
+// Announce persists a system message in each target room and returns one
+// broadcast per room for the caller to deliver through the hub. With an
+// empty room list the announcement reaches every channel room, public and
+// private; DMs are never announced into. The fan-out does one insert per
+// room and leaves member resolution to the hub's room routing, so its cost
+// is bounded by the room count. The admin gate is the caller's
+// responsibility.
+func (a *Api) Announce(user *models.User, text string, roomIDs []string) ([]Announcement, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("text is required: %w", ErrValidation)
+	}
+	if len([]rune(text)) > maxAnnouncementLength {
+		return nil, fmt.Errorf("text must be at most %d characters: %w", maxAnnouncementLength, ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	var rooms []*models.Room
+	if len(roomIDs) == 0 {
+		var err error
+		rooms, err = db.ListChannelRooms(ctx, a.db)
+		if err != nil {
+			a.logger.Error("failed to list rooms for announcement", "error", err)
+			return nil, err
+		}
+	} else {
+		for _, id := range roomIDs {
+			room, err := models.RoomByID(ctx, a.db, id)
+			if err != nil {
+				return nil, fmt.Errorf("room %s not found: %w", id, ErrValidation)
+			}
+			if room.RoomType != "channel" {
+				return nil, fmt.Errorf("announcements can only target channel rooms: %w", ErrValidation)
+			}
+			rooms = append(rooms, room)
+		}
+	}
+
+	// A failed room shouldn't stop the rest of the fan-out; skip it and
+	// keep going so the announcement reaches everyone it can
+	announcements := make([]Announcement, 0, len(rooms))
+	for _, room := range rooms {
+		msg, err := a.postSystemMessage(ctx, room, user, text)
+		if err != nil {
+			a.logger.Error("failed to post announcement", "error", err, "room", room.ID)
+			continue
+		}
+		announcements = append(announcements, Announcement{RoomID: room.ID, Message: msg})
+	}
+
+	// Audit failures must never fail the action itself
+	if err := db.InsertAuditEntry(ctx, a.db, user.ID, db.AuditAnnounce, "", ""); err != nil {
+		a.logger.Error("failed to write audit entry", "error", err, "action", db.AuditAnnounce, "actor", user.ID)
+	}
+
+	return announcements, nil
+}
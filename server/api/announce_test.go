@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestAnnounce_AllRooms tests that an announcement with no room list is
+// persisted in every channel room as a system message
+func TestAnnounce_AllRooms(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	operator := createTestUser(t, database, "usr_announce0001", "announceop")
+	roomA := createTestRoom(t, database, "roo_announce0001", "announce-a", true)
+	roomB := createTestRoom(t, database, "roo_announce0002", "announce-b", false)
+
+	announcements, err := api.Announce(operator, "scheduled maintenance at noon", nil)
+	if err != nil {
+		t.Fatalf("Announce failed: %v", err)
+	}
+	if len(announcements) != 2 {
+		t.Fatalf("Expected 2 announcements, got %d", len(announcements))
+	}
+
+	// Each broadcast is a regular message envelope flagged is_system
+	for _, a := range announcements {
+		var envelope struct {
+			Type string           `json:"type"`
+			Data protocol.Message `json:"data"`
+		}
+		if err := json.Unmarshal(a.Message, &envelope); err != nil {
+			t.Fatalf("Failed to unmarshal broadcast: %v", err)
+		}
+		if envelope.Type != "message" {
+			t.Errorf("Expected type 'message', got %q", envelope.Type)
+		}
+		if !envelope.Data.IsSystem {
+			t.Error("Expected the announcement to be flagged is_system")
+		}
+		if envelope.Data.Body != "scheduled maintenance at noon" {
+			t.Errorf("Unexpected announcement body %q", envelope.Data.Body)
+		}
+	}
+
+	// The message is persisted in both rooms so members see it in history
+	for _, roomID := range []string{roomA.ID, roomB.ID} {
+		messages, err := db.GetRoomMessages(context.Background(), database, roomID, "", 10)
+		if err != nil {
+			t.Fatalf("GetRoomMessages failed: %v", err)
+		}
+		if len(messages) != 1 {
+			t.Fatalf("Expected 1 message in room %s, got %d", roomID, len(messages))
+		}
+		if messages[0].IsSystem != 1 {
+			t.Error("Expected the stored announcement to be flagged is_system")
+		}
+		if messages[0].Body != "scheduled maintenance at noon" {
+			t.Errorf("Unexpected stored body %q", messages[0].Body)
+		}
+	}
+}
+
+// TestAnnounce_RoomSubset tests that an explicit room list limits the
+// fan-out to those rooms
+func TestAnnounce_RoomSubset(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	operator := createTestUser(t, database, "usr_announce0011", "announcesubop")
+	target := createTestRoom(t, database, "roo_announce0011", "announce-target", true)
+	other := createTestRoom(t, database, "roo_announce0012", "announce-other", false)
+
+	announcements, err := api.Announce(operator, "only for the target room", []string{target.ID})
+	if err != nil {
+		t.Fatalf("Announce failed: %v", err)
+	}
+	if len(announcements) != 1 || announcements[0].RoomID != target.ID {
+		t.Fatalf("Expected 1 announcement to %s, got %v", target.ID, announcements)
+	}
+
+	messages, err := db.GetRoomMessages(context.Background(), database, other.ID, "", 10)
+	if err != nil {
+		t.Fatalf("GetRoomMessages failed: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("Expected no messages in the untargeted room, got %d", len(messages))
+	}
+}
+
+// TestAnnounce_Validation tests that empty, oversized, and misaddressed
+// announcements are rejected
+func TestAnnounce_Validation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	operator := createTestUser(t, database, "usr_announce0021", "announcevalop")
+	createTestRoom(t, database, "roo_announce0021", "announce-val", true)
+
+	if _, err := api.Announce(operator, "", nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for empty text, got %v", err)
+	}
+	long := strings.Repeat("x", maxAnnouncementLength+1)
+	if _, err := api.Announce(operator, long, nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for oversized text, got %v", err)
+	}
+	if _, err := api.Announce(operator, "hello", []string{"roo_nosuchroom01"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an unknown room, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/protocol"
@@ -10,10 +12,59 @@ import (
 type Api struct {
 	db     *db.DB
 	logger *slog.Logger
+
+	// readOnly rejects write operations while set, so operators can run
+	// migrations and backups without killing connections
+	readOnly atomic.Bool
+
+	// maxRoomsPerUser caps channel memberships per user; DMs don't count
+	maxRoomsPerUser int
+
+	// dmPolicy controls who may open DMs with whom; one of the DMPolicy*
+	// constants
+	dmPolicy string
+
+	// avatarHosts restricts which hosts avatar URLs may point at; empty
+	// means any http(s) host
+	avatarHosts []string
+
+	// editWindow and deleteWindow bound how long after posting authors may
+	// edit or delete their messages; zero means unlimited and room owners
+	// and admins are always exempt
+	editWindow   time.Duration
+	deleteWindow time.Duration
+
+	// welcomeMessage posts a system message announcing the creator when a
+	// room is created
+	welcomeMessage bool
+
+	// roomInfoMembers caps how many members room_info responses list;
+	// member counts are always the full total
+	roomInfoMembers int
+
+	// dedupe remembers recent client message IDs so rapid resubmits don't
+	// persist duplicate messages
+	dedupe *msgDedupe
+
+	// activity throttles last_active_at writes to at most one per user
+	// per activityInterval
+	activity *activityThrottle
 }
 
 func NewApi(db *db.DB, logger *slog.Logger) *Api {
-	return &Api{db, logger}
+	return &Api{
+		db:              db,
+		logger:          logger,
+		maxRoomsPerUser: maxRoomsPerUserFromEnv(logger),
+		dmPolicy:        dmPolicyFromEnv(logger),
+		avatarHosts:     avatarHostsFromEnv(logger),
+		editWindow:      changeWindowFromEnv(logger, "EDIT_WINDOW_SECONDS"),
+		deleteWindow:    changeWindowFromEnv(logger, "DELETE_WINDOW_SECONDS"),
+		welcomeMessage:  welcomeMessageFromEnv(logger),
+		roomInfoMembers: roomInfoMembersFromEnv(logger),
+		dedupe:          newMsgDedupe(),
+		activity:        newActivityThrottle(),
+	}
 }
 
 // Envelope is an alias for protocol.Envelope for convenience within this package
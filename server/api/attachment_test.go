@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// createTestAttachment inserts an unlinked attachment owned by the given user
+func createTestAttachment(t *testing.T, database *db.DB, id, userID, filename string) *models.Attachment {
+	t.Helper()
+	att := &models.Attachment{
+		ID:          id,
+		UserID:      userID,
+		URL:         "/uploads/" + id + ".png",
+		Filename:    filename,
+		ContentType: "image/png",
+		Size:        1234,
+		CreatedAt:   time.Now().Format(time.RFC3339),
+	}
+	if err := att.Insert(context.Background(), database); err != nil {
+		t.Fatalf("Failed to insert attachment: %v", err)
+	}
+	return att
+}
+
+// TestMessageMessage_WithAttachments tests that a message referencing the
+// sender's uploads links them and returns their metadata in the broadcast
+func TestMessageMessage_WithAttachments(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	att := createTestAttachment(t, database, "att_test12345678", user.ID, "cat.png")
+
+	msgJSON, _ := json.Marshal(protocol.SendMessageRequest{
+		Body:          "look at this",
+		RoomID:        room.ID,
+		AttachmentIDs: []string{att.ID},
+	})
+	response, err := api.MessageMessage(user, msgJSON)
+	if err != nil {
+		t.Fatalf("MessageMessage failed: %v", err)
+	}
+
+	// The broadcast message carries the attachment metadata
+	var envelope struct {
+		Type string           `json:"type"`
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(response.Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal response envelope: %v", err)
+	}
+	if len(envelope.Data.Attachments) != 1 {
+		t.Fatalf("Expected 1 attachment in broadcast, got %d", len(envelope.Data.Attachments))
+	}
+	got := envelope.Data.Attachments[0]
+	if got.ID != att.ID || got.Filename != "cat.png" || got.ContentType != "image/png" || got.Size != 1234 {
+		t.Errorf("Unexpected attachment in broadcast: %+v", got)
+	}
+
+	// The attachment row is now linked to the message
+	reloaded, err := models.AttachmentByID(context.Background(), database, att.ID)
+	if err != nil {
+		t.Fatalf("Failed to reload attachment: %v", err)
+	}
+	if !reloaded.MessageID.Valid || reloaded.MessageID.String != envelope.Data.ID {
+		t.Errorf("Expected attachment linked to message %s, got %+v", envelope.Data.ID, reloaded.MessageID)
+	}
+
+	// And the batch loader returns it for history/search
+	attMap, err := db.GetAttachmentsForMessages(context.Background(), database, []string{envelope.Data.ID})
+	if err != nil {
+		t.Fatalf("GetAttachmentsForMessages failed: %v", err)
+	}
+	if len(attMap[envelope.Data.ID]) != 1 {
+		t.Errorf("Expected 1 attachment from batch loader, got %d", len(attMap[envelope.Data.ID]))
+	}
+}
+
+// TestMessageMessage_ForeignAttachmentRejected tests that referencing another
+// user's upload, an unknown ID, or an already-linked attachment fails
+func TestMessageMessage_ForeignAttachmentRejected(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	other := createTestUser(t, database, "usr_other12345678", "otheruser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+	addUserToRoom(t, database, other.ID, room.ID)
+
+	foreign := createTestAttachment(t, database, "att_foreign12345", other.ID, "secret.png")
+
+	msgJSON, _ := json.Marshal(protocol.SendMessageRequest{
+		Body:          "stealing your upload",
+		RoomID:        room.ID,
+		AttachmentIDs: []string{foreign.ID},
+	})
+	if _, err := api.MessageMessage(user, msgJSON); err == nil {
+		t.Error("Expected error when attaching someone else's upload, got nil")
+	}
+
+	// Unknown attachment IDs are rejected
+	msgJSON, _ = json.Marshal(protocol.SendMessageRequest{
+		Body:          "ghost attachment",
+		RoomID:        room.ID,
+		AttachmentIDs: []string{"att_doesnotexist"},
+	})
+	if _, err := api.MessageMessage(user, msgJSON); err == nil {
+		t.Error("Expected error for unknown attachment ID, got nil")
+	}
+
+	// An attachment already linked to a message cannot be reused
+	mine := createTestAttachment(t, database, "att_mine12345678", user.ID, "mine.png")
+	msgJSON, _ = json.Marshal(protocol.SendMessageRequest{
+		Body:          "first use",
+		RoomID:        room.ID,
+		AttachmentIDs: []string{mine.ID},
+	})
+	if _, err := api.MessageMessage(user, msgJSON); err != nil {
+		t.Fatalf("First use of attachment should succeed: %v", err)
+	}
+	msgJSON, _ = json.Marshal(protocol.SendMessageRequest{
+		Body:          "second use",
+		RoomID:        room.ID,
+		AttachmentIDs: []string{mine.ID},
+	})
+	if _, err := api.MessageMessage(user, msgJSON); err == nil {
+		t.Error("Expected error when reusing a linked attachment, got nil")
+	}
+}
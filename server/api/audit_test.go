@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestAudit_RoomCreate tests that creating a room writes an audit entry
+func TestAudit_RoomCreate(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_audit_create", "auditcreator")
+
+	reqJSON, _ := json.Marshal(protocol.CreateRoomRequest{Name: "audited-room"})
+	result, err := api.CreateRoom(user, reqJSON)
+	if err != nil {
+		t.Fatalf("CreateRoom failed: %v", err)
+	}
+
+	entries, err := db.ListAuditEntries(context.Background(), database, db.AuditFilter{ActorID: user.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	if entries[0].Action != db.AuditRoomCreate {
+		t.Errorf("expected action %s, got %s", db.AuditRoomCreate, entries[0].Action)
+	}
+	if entries[0].TargetID != result.RoomID || entries[0].RoomID != result.RoomID {
+		t.Errorf("expected audit entry for room %s, got %+v", result.RoomID, entries[0])
+	}
+}
+
+// TestAudit_MessageDelete tests that deleting a message writes an audit entry
+func TestAudit_MessageDelete(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_audit_delete", "auditdeleter")
+	room := createTestRoom(t, database, "roo_audit_del_01", "audit-del", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	msg := createTestMessage(t, database, "msg_audit_del_01", room.ID, user.ID, "doomed", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.DeleteMessageRequest{MessageID: msg.ID})
+	if _, err := api.DeleteMessage(user, reqJSON); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+
+	entries, err := db.ListAuditEntries(context.Background(), database, db.AuditFilter{ActorID: user.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	if entries[0].Action != db.AuditMessageDelete {
+		t.Errorf("expected action %s, got %s", db.AuditMessageDelete, entries[0].Action)
+	}
+	if entries[0].TargetID != msg.ID || entries[0].RoomID != room.ID {
+		t.Errorf("expected audit entry for message %s in room %s, got %+v", msg.ID, room.ID, entries[0])
+	}
+
+	// Deleting again is idempotent and must not add a second entry
+	if _, err := api.DeleteMessage(user, reqJSON); err != nil {
+		t.Fatalf("repeat DeleteMessage failed: %v", err)
+	}
+	entries, err = db.ListAuditEntries(context.Background(), database, db.AuditFilter{ActorID: user.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected idempotent delete to leave 1 audit entry, got %d", len(entries))
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxAutocompleteResults caps suggestions per request; inline menus only
+// have room for a handful anyway
+const maxAutocompleteResults = 10
+
+// emojiShortcodes are the registered emoji available to the : picker,
+// keyed by shortcode name
+var emojiShortcodes = map[string]string{
+	"+1":       "👍",
+	"-1":       "👎",
+	"clap":     "👏",
+	"cry":      "😢",
+	"eyes":     "👀",
+	"fire":     "🔥",
+	"heart":    "❤️",
+	"hundred":  "💯",
+	"joy":      "😂",
+	"laughing": "😆",
+	"party":    "🎉",
+	"pray":     "🙏",
+	"rocket":   "🚀",
+	"smile":    "😄",
+	"sob":      "😭",
+	"sparkles": "✨",
+	"tada":     "🎉",
+	"thinking": "🤔",
+	"thumbsup": "👍",
+	"wave":     "👋",
+}
+
+// Autocomplete handles a search-as-you-type request from the composer's
+// @/#/: pickers, returning a small ranked list of matches. Prefix matches
+// rank above substring matches; room results are scoped to the user's own
+// rooms.
+func (a *Api) Autocomplete(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.AutocompleteRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid autocomplete json", "error", err)
+		return nil, err
+	}
+
+	if req.Prefix == "" {
+		return nil, fmt.Errorf("prefix is required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	var items []protocol.AutocompleteItem
+	var err error
+	switch req.Kind {
+	case "user":
+		items, err = a.autocompleteUsers(ctx, user, req.Prefix)
+	case "room":
+		items, err = a.autocompleteRooms(ctx, user, req.Prefix)
+	case "emoji":
+		items = autocompleteEmoji(req.Prefix)
+	default:
+		return nil, fmt.Errorf("kind must be one of user, room, emoji: %w", ErrValidation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) > maxAutocompleteResults {
+		items = items[:maxAutocompleteResults]
+	}
+
+	return &Envelope{
+		Type: "autocomplete",
+		Data: protocol.AutocompleteResponse{
+			Kind:  req.Kind,
+			Items: items,
+		},
+	}, nil
+}
+
+// rankMatches orders candidates so prefix matches come before substring
+// matches, alphabetically within each group, dropping non-matches. The
+// match key for each candidate is keys[i].
+func rankMatches(prefix string, keys []string, items []protocol.AutocompleteItem) []protocol.AutocompleteItem {
+	prefix = strings.ToLower(prefix)
+	type ranked struct {
+		key      string
+		isPrefix bool
+		item     protocol.AutocompleteItem
+	}
+	matches := []ranked{}
+	for i, key := range keys {
+		key = strings.ToLower(key)
+		switch {
+		case strings.HasPrefix(key, prefix):
+			matches = append(matches, ranked{key, true, items[i]})
+		case strings.Contains(key, prefix):
+			matches = append(matches, ranked{key, false, items[i]})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].isPrefix != matches[j].isPrefix {
+			return matches[i].isPrefix
+		}
+		return matches[i].key < matches[j].key
+	})
+	result := make([]protocol.AutocompleteItem, len(matches))
+	for i, m := range matches {
+		result[i] = m.item
+	}
+	return result
+}
+
+// autocompleteUsers suggests users by username, excluding the requester
+func (a *Api) autocompleteUsers(ctx context.Context, user *models.User, prefix string) ([]protocol.AutocompleteItem, error) {
+	// Over-fetch so the ranking has substring matches to demote
+	dbUsers, err := models.ListUsersByQueryExcludeUserIDLimit(ctx, a.db, "%"+prefix+"%", user.ID, 50)
+	if err != nil {
+		a.logger.Error("failed to list users for autocomplete", "error", err, "prefix", prefix)
+		return nil, err
+	}
+	keys := make([]string, len(dbUsers))
+	items := make([]protocol.AutocompleteItem, len(dbUsers))
+	for i, u := range dbUsers {
+		keys[i] = u.Username
+		items[i] = protocol.AutocompleteItem{
+			ID:    u.ID,
+			Value: u.Username,
+			Label: u.DisplayName,
+		}
+	}
+	return rankMatches(prefix, keys, items), nil
+}
+
+// autocompleteRooms suggests channels from the user's own memberships
+func (a *Api) autocompleteRooms(ctx context.Context, user *models.User, prefix string) ([]protocol.AutocompleteItem, error) {
+	rooms, err := models.UserRoomDetailsByUserID(ctx, a.db, user.ID)
+	if err != nil {
+		a.logger.Error("failed to list rooms for autocomplete", "error", err, "user", user.ID)
+		return nil, err
+	}
+	keys := make([]string, len(rooms))
+	items := make([]protocol.AutocompleteItem, len(rooms))
+	for i, r := range rooms {
+		keys[i] = r.Name
+		items[i] = protocol.AutocompleteItem{
+			ID:    r.ID,
+			Value: r.Name,
+		}
+	}
+	return rankMatches(prefix, keys, items), nil
+}
+
+// autocompleteEmoji suggests registered emoji by shortcode
+func autocompleteEmoji(prefix string) []protocol.AutocompleteItem {
+	keys := make([]string, 0, len(emojiShortcodes))
+	items := make([]protocol.AutocompleteItem, 0, len(emojiShortcodes))
+	for name, emoji := range emojiShortcodes {
+		keys = append(keys, name)
+		items = append(items, protocol.AutocompleteItem{
+			Value: emoji,
+			Label: ":" + name + ":",
+		})
+	}
+	return rankMatches(prefix, keys, items)
+}
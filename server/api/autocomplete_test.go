@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// autocomplete is a helper that runs Autocomplete and returns the response data
+func autocomplete(t *testing.T, api *Api, user *models.User, prefix, kind string) protocol.AutocompleteResponse {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.AutocompleteRequest{Prefix: prefix, Kind: kind})
+	response, err := api.Autocomplete(user, reqJSON)
+	if err != nil {
+		t.Fatalf("Autocomplete failed: %v", err)
+	}
+	resp, ok := response.Data.(protocol.AutocompleteResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.AutocompleteResponse data type, got %T", response.Data)
+	}
+	return resp
+}
+
+// TestAutocomplete_PrefixRanksFirst tests that prefix matches rank above
+// substring matches
+func TestAutocomplete_PrefixRanksFirst(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	requester := createTestUser(t, database, "usr_auto_req0001", "autorequester")
+	createTestUser(t, database, "usr_auto_ali0001", "alicetype")
+	createTestUser(t, database, "usr_auto_mal0001", "malicetype")
+
+	resp := autocomplete(t, api, requester, "ali", "user")
+	if len(resp.Items) != 2 {
+		t.Fatalf("expected 2 suggestions, got %d", len(resp.Items))
+	}
+	// "alicetype" starts with the prefix; "malicetype" only contains it
+	if resp.Items[0].Value != "alicetype" || resp.Items[1].Value != "malicetype" {
+		t.Errorf("expected prefix match ranked first, got %q then %q", resp.Items[0].Value, resp.Items[1].Value)
+	}
+}
+
+// TestAutocomplete_RoomScoping tests that room suggestions only include the
+// user's own rooms
+func TestAutocomplete_RoomScoping(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_auto_room001", "autoroomuser")
+	mine := createTestRoom(t, database, "roo_auto_mine001", "devchat-mine", false)
+	createTestRoom(t, database, "roo_auto_other01", "devchat-other", false)
+	addUserToRoom(t, database, user.ID, mine.ID)
+
+	resp := autocomplete(t, api, user, "devchat", "room")
+	if len(resp.Items) != 1 {
+		t.Fatalf("expected only the user's own room, got %d items", len(resp.Items))
+	}
+	if resp.Items[0].ID != mine.ID || resp.Items[0].Value != "devchat-mine" {
+		t.Errorf("expected devchat-mine, got %+v", resp.Items[0])
+	}
+}
+
+// TestAutocomplete_Emoji tests emoji shortcode completion
+func TestAutocomplete_Emoji(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_auto_emo0001", "autoemojiuser")
+
+	resp := autocomplete(t, api, user, "smi", "emoji")
+	if len(resp.Items) != 1 {
+		t.Fatalf("expected 1 emoji suggestion, got %d", len(resp.Items))
+	}
+	if resp.Items[0].Label != ":smile:" || resp.Items[0].Value != "😄" {
+		t.Errorf("expected :smile:, got %+v", resp.Items[0])
+	}
+
+	// Unknown kinds are rejected
+	reqJSON, _ := json.Marshal(protocol.AutocompleteRequest{Prefix: "x", Kind: "bogus"})
+	if _, err := api.Autocomplete(user, reqJSON); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
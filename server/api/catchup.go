@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// catchUpPreviewLimit is how many recent messages are included per room
+const catchUpPreviewLimit = 3
+
+// CatchUp summarizes activity in the user's rooms since a given time: each
+// room with new messages, its unread count, and a few recent previews. Muted
+// rooms and rooms with nothing new are omitted.
+func (a *Api) CatchUp(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.CatchUpRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for catch_up request", "error", err)
+		return nil, err
+	}
+
+	if req.Since == "" {
+		a.logger.Error("missing since in catch_up request")
+		return nil, fmt.Errorf("since is required")
+	}
+	if _, err := time.Parse(time.RFC3339Nano, req.Since); err != nil {
+		a.logger.Error("invalid since in catch_up request", "since", req.Since, "error", err)
+		return nil, fmt.Errorf("since must be an RFC3339 timestamp")
+	}
+
+	ctx := context.Background()
+
+	// Membership and muting are enforced by the activity query itself
+	activity, err := db.GetRoomActivitySince(ctx, a.db, user.ID, req.Since)
+	if err != nil {
+		a.logger.Error("failed to get room activity", "error", err, "user", user.ID)
+		return nil, err
+	}
+
+	rooms := make([]protocol.RoomCatchUp, len(activity))
+	for i, room := range activity {
+		previews, err := db.GetRecentRoomMessagesSince(ctx, a.db, room.RoomID, req.Since, catchUpPreviewLimit)
+		if err != nil {
+			a.logger.Error("failed to get catch-up previews", "error", err, "room", room.RoomID)
+			return nil, err
+		}
+		rooms[i] = protocol.RoomCatchUp{
+			RoomID:      room.RoomID,
+			RoomName:    room.RoomName,
+			UnreadCount: room.Count,
+			Previews:    contextMessages(previews),
+		}
+	}
+
+	return &Envelope{
+		Type: "catch_up",
+		Data: protocol.CatchUpResponse{
+			Rooms: rooms,
+		},
+	}, nil
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestCatchUp_MessagesAfterSince tests that catch-up counts and previews only
+// reflect messages created after the given timestamp
+func TestCatchUp_MessagesAfterSince(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	now := time.Now()
+	since := now.Add(-5 * time.Minute)
+
+	// One message before the cutoff, three after
+	createTestMessage(t, database, "msg_catchold123", room.ID, user.ID, "Old message", now.Add(-10*time.Minute))
+	createTestMessage(t, database, "msg_catchnew001", room.ID, user.ID, "New 1", now.Add(-3*time.Minute))
+	createTestMessage(t, database, "msg_catchnew002", room.ID, user.ID, "New 2", now.Add(-2*time.Minute))
+	createTestMessage(t, database, "msg_catchnew003", room.ID, user.ID, "New 3", now.Add(-1*time.Minute))
+
+	reqJSON, _ := json.Marshal(protocol.CatchUpRequest{
+		Since: since.Format(time.RFC3339),
+	})
+
+	response, err := api.CatchUp(user, reqJSON)
+	if err != nil {
+		t.Fatalf("CatchUp failed: %v", err)
+	}
+	if response.Type != "catch_up" {
+		t.Errorf("Expected type 'catch_up', got '%s'", response.Type)
+	}
+
+	resp, ok := response.Data.(protocol.CatchUpResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.CatchUpResponse data type, got %T", response.Data)
+	}
+
+	if len(resp.Rooms) != 1 {
+		t.Fatalf("Expected 1 room, got %d", len(resp.Rooms))
+	}
+	entry := resp.Rooms[0]
+	if entry.RoomID != room.ID {
+		t.Errorf("Expected room %s, got %s", room.ID, entry.RoomID)
+	}
+	if entry.RoomName != "general" {
+		t.Errorf("Expected room name 'general', got '%s'", entry.RoomName)
+	}
+	if entry.UnreadCount != 3 {
+		t.Errorf("Expected unread count 3, got %d", entry.UnreadCount)
+	}
+
+	// Previews should be the most recent messages, in chronological order
+	if len(entry.Previews) != 3 {
+		t.Fatalf("Expected 3 previews, got %d", len(entry.Previews))
+	}
+	if entry.Previews[0].Body != "New 1" {
+		t.Errorf("Expected first preview 'New 1', got '%s'", entry.Previews[0].Body)
+	}
+	if entry.Previews[2].Body != "New 3" {
+		t.Errorf("Expected last preview 'New 3', got '%s'", entry.Previews[2].Body)
+	}
+}
+
+// TestCatchUp_SkipsMutedRooms tests that rooms the user has muted are
+// excluded from the catch-up summary
+func TestCatchUp_SkipsMutedRooms(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	loud := createTestRoom(t, database, "roo_loud12345678", "loud", true)
+	quiet := createTestRoom(t, database, "roo_quiet1234567", "quiet", false)
+	addUserToRoom(t, database, user.ID, loud.ID)
+	addUserToRoom(t, database, user.ID, quiet.ID)
+
+	// Mute the quiet room
+	_, err := database.ExecContext(context.Background(), "UPDATE rooms_members SET muted = 1 WHERE user_id = ? AND room_id = ?", user.ID, quiet.ID)
+	if err != nil {
+		t.Fatalf("Failed to mute room: %v", err)
+	}
+
+	now := time.Now()
+	createTestMessage(t, database, "msg_catchloud01", loud.ID, user.ID, "In loud", now.Add(-2*time.Minute))
+	createTestMessage(t, database, "msg_catchquiet1", quiet.ID, user.ID, "In quiet", now.Add(-1*time.Minute))
+
+	reqJSON, _ := json.Marshal(protocol.CatchUpRequest{
+		Since: now.Add(-5 * time.Minute).Format(time.RFC3339),
+	})
+
+	response, err := api.CatchUp(user, reqJSON)
+	if err != nil {
+		t.Fatalf("CatchUp failed: %v", err)
+	}
+
+	resp := response.Data.(protocol.CatchUpResponse)
+	if len(resp.Rooms) != 1 {
+		t.Fatalf("Expected 1 room, got %d", len(resp.Rooms))
+	}
+	if resp.Rooms[0].RoomID != loud.ID {
+		t.Errorf("Expected only the unmuted room %s, got %s", loud.ID, resp.Rooms[0].RoomID)
+	}
+}
+
+// TestCatchUp_NothingNew tests that rooms with no messages after the cutoff
+// are omitted entirely
+func TestCatchUp_NothingNew(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	now := time.Now()
+	createTestMessage(t, database, "msg_catchread01", room.ID, user.ID, "Already read", now.Add(-10*time.Minute))
+
+	reqJSON, _ := json.Marshal(protocol.CatchUpRequest{
+		Since: now.Format(time.RFC3339),
+	})
+
+	response, err := api.CatchUp(user, reqJSON)
+	if err != nil {
+		t.Fatalf("CatchUp failed: %v", err)
+	}
+
+	resp := response.Data.(protocol.CatchUpResponse)
+	if len(resp.Rooms) != 0 {
+		t.Errorf("Expected no rooms, got %d", len(resp.Rooms))
+	}
+}
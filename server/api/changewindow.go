@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// changeWindowFromEnv reads EDIT_WINDOW_SECONDS or DELETE_WINDOW_SECONDS,
+// how long after posting authors may still change a message. Unset,
+// invalid, or zero all mean unlimited.
+func changeWindowFromEnv(logger *slog.Logger, name string) time.Duration {
+	val := os.Getenv(name)
+	if val == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		logger.Warn("ignoring invalid change window", "variable", name, "value", val)
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// checkChangeWindow returns a validation error when more than window has
+// passed since the message was posted. Room owners and admins are exempt,
+// and a zero window means unlimited. verb names the action for the error
+// message ("edited" or "deleted").
+func (a *Api) checkChangeWindow(ctx context.Context, user *models.User, message *models.Message, window time.Duration, verb string) error {
+	if window <= 0 {
+		return nil
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, message.CreatedAt)
+	if err != nil {
+		// Don't lock authors out of their own messages over a bad timestamp
+		a.logger.Error("unparseable message created_at", "error", err, "message", message.ID)
+		return nil
+	}
+	if time.Since(createdAt) <= window {
+		return nil
+	}
+	role, err := db.GetMemberRole(ctx, a.db, user.ID, message.RoomID)
+	if err != nil {
+		a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", message.RoomID)
+		return err
+	}
+	if role == db.RoleOwner || role == db.RoleAdmin {
+		return nil
+	}
+	return fmt.Errorf("messages can only be %s within %s of posting: %w", verb, window, ErrValidation)
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestChangeWindow_EditWithinWindow tests that a fresh message can be
+// edited when a window is configured
+func TestChangeWindow_EditWithinWindow(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.editWindow = time.Hour
+
+	user := createTestUser(t, database, "usr_window000001", "windowfresh")
+	room := createTestRoom(t, database, "roo_window000001", "window-room", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	msg := createTestMessage(t, database, "msg_window000001", room.ID, user.ID, "fresh", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.EditMessageRequest{MessageID: msg.ID, Body: "edited"})
+	if _, err := api.EditMessage(user, reqJSON); err != nil {
+		t.Errorf("Expected an edit within the window to succeed, got %v", err)
+	}
+}
+
+// TestChangeWindow_EditPastWindow tests that a stale message can no longer
+// be edited or deleted by a regular author
+func TestChangeWindow_EditPastWindow(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.editWindow = time.Hour
+	api.deleteWindow = time.Hour
+
+	user := createTestUser(t, database, "usr_window000011", "windowstale")
+	room := createTestRoom(t, database, "roo_window000011", "window-room2", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	msg := createTestMessage(t, database, "msg_window000011", room.ID, user.ID, "stale", time.Now().Add(-2*time.Hour))
+
+	reqJSON, _ := json.Marshal(protocol.EditMessageRequest{MessageID: msg.ID, Body: "too late"})
+	if _, err := api.EditMessage(user, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation editing past the window, got %v", err)
+	}
+
+	delJSON, _ := json.Marshal(protocol.DeleteMessageRequest{MessageID: msg.ID})
+	if _, err := api.DeleteMessage(user, delJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation deleting past the window, got %v", err)
+	}
+}
+
+// TestChangeWindow_AdminExempt tests that room owners and admins may edit
+// their own messages regardless of the window
+func TestChangeWindow_AdminExempt(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.editWindow = time.Hour
+
+	owner := createTestUser(t, database, "usr_window000021", "windowowner")
+	room := createTestRoom(t, database, "roo_window000021", "window-room3", true)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+
+	msg := createTestMessage(t, database, "msg_window000021", room.ID, owner.ID, "old but mine", time.Now().Add(-2*time.Hour))
+
+	reqJSON, _ := json.Marshal(protocol.EditMessageRequest{MessageID: msg.ID, Body: "owners may"})
+	if _, err := api.EditMessage(owner, reqJSON); err != nil {
+		t.Errorf("Expected an owner to edit past the window, got %v", err)
+	}
+}
+
+// TestChangeWindow_ZeroMeansUnlimited tests that the default configuration
+// leaves old messages editable
+func TestChangeWindow_ZeroMeansUnlimited(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_window000031", "windowzero")
+	room := createTestRoom(t, database, "roo_window000031", "window-room4", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	msg := createTestMessage(t, database, "msg_window000031", room.ID, user.ID, "ancient", time.Now().Add(-30*24*time.Hour))
+
+	reqJSON, _ := json.Marshal(protocol.EditMessageRequest{MessageID: msg.ID, Body: "still editable"})
+	if _, err := api.EditMessage(user, reqJSON); err != nil {
+		t.Errorf("Expected an edit with no window configured to succeed, got %v", err)
+	}
+}
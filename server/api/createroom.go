@@ -19,11 +19,19 @@ var ErrRoomNameTaken = fmt.Errorf("a channel with that name already exists")
 type CreateRoomResult struct {
 	Envelope *Envelope
 	RoomID   string
+
+	// Broadcast is a marshaled welcome system message to deliver to the
+	// room, when the instance has ROOM_WELCOME_MESSAGE enabled
+	Broadcast []byte
 }
 
 // CreateRoom handles a request from the client to create a new room.
 // The user is automatically added as a member of the room.
 func (a *Api) CreateRoom(user *models.User, msg json.RawMessage) (*CreateRoomResult, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.CreateRoomRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		a.logger.Error("invalid create_room json", "error", err)
@@ -41,6 +49,12 @@ func (a *Api) CreateRoom(user *models.User, msg json.RawMessage) (*CreateRoomRes
 
 	ctx := context.Background()
 
+	// Creating a room makes the creator a member, so it must not put them
+	// over the room cap
+	if err := a.checkRoomLimit(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
 	// Check if a room with this name already exists
 	exists, err := db.RoomExistsByName(ctx, a.db, name)
 	if err != nil {
@@ -53,12 +67,15 @@ func (a *Api) CreateRoom(user *models.User, msg json.RawMessage) (*CreateRoomRes
 
 	// Create the room
 	room := &models.Room{
-		ID:        models.GenerateRoomID(),
-		Name:      name,
-		RoomType:  "channel",
-		IsPrivate: boolToInt(req.IsPrivate),
-		IsDefault: models.FALSE,
-		CreatedAt: time.Now().Format(time.RFC3339),
+		ID:           models.GenerateRoomID(),
+		Name:         name,
+		RoomType:     "channel",
+		IsPrivate:    boolToInt(req.IsPrivate),
+		IsDefault:    models.FALSE,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+		JoinPolicy:   db.JoinPolicyOpen,
+		PostPolicy:   db.PostPolicyAll,
+		AnnouncePins: models.TRUE,
 	}
 
 	if err := room.Insert(ctx, a.db); err != nil {
@@ -70,8 +87,8 @@ func (a *Api) CreateRoom(user *models.User, msg json.RawMessage) (*CreateRoomRes
 		return nil, err
 	}
 
-	// Add the creator as a member
-	_, err = db.AddRoomMember(ctx, a.db, user.ID, room.ID)
+	// Add the creator as the room's owner
+	_, err = db.AddRoomMemberWithRole(ctx, a.db, user.ID, room.ID, db.RoleOwner)
 	if err != nil {
 		a.logger.Error("failed to add room creator as member", "error", err, "user", user.ID, "room", room.ID)
 		// Try to clean up the room we just created
@@ -89,6 +106,22 @@ func (a *Api) CreateRoom(user *models.User, msg json.RawMessage) (*CreateRoomRes
 
 	a.logger.Info("room created", "room_id", room.ID, "name", room.Name, "creator", user.ID)
 
+	// Audit failures must never fail the action itself
+	if err := db.InsertAuditEntry(ctx, a.db, user.ID, db.AuditRoomCreate, room.ID, room.ID); err != nil {
+		a.logger.Error("failed to write audit entry", "error", err, "action", db.AuditRoomCreate, "actor", user.ID, "room", room.ID)
+	}
+
+	// Optionally announce the creator in the new room; a failure here must
+	// not fail the creation itself
+	var welcome []byte
+	if a.welcomeMessage {
+		welcome, err = a.postSystemMessage(ctx, room, user, fmt.Sprintf("Room created by %s", user.Username))
+		if err != nil {
+			a.logger.Error("failed to post welcome message", "error", err, "room", room.ID)
+			welcome = nil
+		}
+	}
+
 	return &CreateRoomResult{
 		Envelope: &Envelope{
 			Type: "create_room",
@@ -101,7 +134,8 @@ func (a *Api) CreateRoom(user *models.User, msg json.RawMessage) (*CreateRoomRes
 				},
 			},
 		},
-		RoomID: room.ID,
+		RoomID:    room.ID,
+		Broadcast: welcome,
 	}, nil
 }
 
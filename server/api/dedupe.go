@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dedupeTTL bounds how long a client_msg_id is remembered. It only needs to
+// cover network retries and double-clicks, so it is deliberately short.
+const dedupeTTL = 30 * time.Second
+
+// dedupeKey builds the cache key for one user's send of a client message ID
+// into a room
+func dedupeKey(userID, roomID, clientMsgID string) string {
+	return fmt.Sprintf("%s\x00%s\x00%s", userID, roomID, clientMsgID)
+}
+
+// msgDedupe is a small TTL cache mapping (user, room, client_msg_id) to the
+// response of the message it created, so rapid resubmits of the same message
+// return the original instead of persisting a duplicate
+type msgDedupe struct {
+	mu      sync.Mutex
+	entries map[string]dedupeEntry
+}
+
+type dedupeEntry struct {
+	response *MessageResponse
+	expires  time.Time
+}
+
+func newMsgDedupe() *msgDedupe {
+	return &msgDedupe{entries: make(map[string]dedupeEntry)}
+}
+
+// get returns the cached response for key, or nil if absent or expired
+func (d *msgDedupe) get(key string) *MessageResponse {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.response
+}
+
+// put caches the response for key, evicting any expired entries so the map
+// stays small without a background sweeper
+func (d *msgDedupe) put(key string, response *MessageResponse) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for k, entry := range d.entries {
+		if now.After(entry.expires) {
+			delete(d.entries, k)
+		}
+	}
+	d.entries[key] = dedupeEntry{response: response, expires: now.Add(dedupeTTL)}
+}
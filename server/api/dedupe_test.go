@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// sendWithClientMsgID is a helper that posts a message and returns the
+// broadcast message payload
+func sendWithClientMsgID(t *testing.T, api *Api, user *models.User, roomID, body, clientMsgID string) protocol.Message {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.SendMessageRequest{
+		Body:        body,
+		RoomID:      roomID,
+		ClientMsgID: clientMsgID,
+	})
+	res, err := api.MessageMessage(user, reqJSON)
+	if err != nil {
+		t.Fatalf("MessageMessage failed: %v", err)
+	}
+	var env struct {
+		Type string           `json:"type"`
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(res.Message, &env); err != nil {
+		t.Fatalf("failed to unmarshal broadcast: %v", err)
+	}
+	return env.Data
+}
+
+// TestDedupe_SameClientMsgID tests that resending with the same
+// client_msg_id returns the original message and persists only one row
+func TestDedupe_SameClientMsgID(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_dedupe_00001", "dedupeuser")
+	room := createTestRoom(t, database, "roo_dedupe_00001", "dedupe", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	first := sendWithClientMsgID(t, api, user, room.ID, "hello", "client-abc")
+	second := sendWithClientMsgID(t, api, user, room.ID, "hello", "client-abc")
+
+	if first.ID != second.ID {
+		t.Errorf("expected the resend to return the same message ID, got %s and %s", first.ID, second.ID)
+	}
+
+	messages, err := db.GetRoomMessages(context.Background(), database, room.ID, "", 10)
+	if err != nil {
+		t.Fatalf("GetRoomMessages failed: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("expected 1 persisted message after a deduplicated resend, got %d", len(messages))
+	}
+}
+
+// TestDedupe_DistinctClientMsgIDs tests that different client_msg_ids (or
+// none at all) create distinct messages
+func TestDedupe_DistinctClientMsgIDs(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_dedupe_00002", "dedupeuser2")
+	room := createTestRoom(t, database, "roo_dedupe_00002", "dedupe-two", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	first := sendWithClientMsgID(t, api, user, room.ID, "one", "client-1")
+	second := sendWithClientMsgID(t, api, user, room.ID, "two", "client-2")
+	third := sendWithClientMsgID(t, api, user, room.ID, "three", "")
+
+	if first.ID == second.ID || second.ID == third.ID || first.ID == third.ID {
+		t.Error("expected distinct client_msg_ids to create distinct messages")
+	}
+
+	messages, err := db.GetRoomMessages(context.Background(), database, room.ID, "", 10)
+	if err != nil {
+		t.Fatalf("GetRoomMessages failed: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Errorf("expected 3 persisted messages, got %d", len(messages))
+	}
+}
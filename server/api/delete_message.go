@@ -20,6 +20,10 @@ type DeleteMessageResponse struct {
 // DeleteMessage handles a request to soft-delete a message.
 // Only the message author can delete. Returns a broadcast message for the room.
 func (a *Api) DeleteMessage(user *models.User, msg json.RawMessage) (*DeleteMessageResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.DeleteMessageRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		a.logger.Error("invalid json for delete_message", "error", err)
@@ -39,6 +43,12 @@ func (a *Api) DeleteMessage(user *models.User, msg json.RawMessage) (*DeleteMess
 		return nil, fmt.Errorf("message not found")
 	}
 
+	// System messages are server-generated and can never be deleted, not
+	// even by the user they are attributed to
+	if message.IsSystem != 0 {
+		return nil, fmt.Errorf("system messages cannot be deleted: %w", ErrValidation)
+	}
+
 	// Check ownership
 	if message.UserID != user.ID {
 		a.logger.Warn("user attempted to delete another user's message", "user", user.ID, "message_owner", message.UserID)
@@ -70,12 +80,18 @@ func (a *Api) DeleteMessage(user *models.User, msg json.RawMessage) (*DeleteMess
 		return nil, err
 	}
 	if !isMember {
-		return nil, fmt.Errorf("user is not a member of the room")
+		return nil, ErrNotMember
+	}
+
+	// Enforce the delete window, when one is configured
+	if err := a.checkChangeWindow(ctx, user, message, a.deleteWindow, "deleted"); err != nil {
+		return nil, err
 	}
 
 	// Soft delete: clear body and set deleted_at
 	now := time.Now().Format(time.RFC3339Nano)
 	message.Body = ""
+	message.EncKeyID = ""
 	message.DeletedAt.String = now
 	message.DeletedAt.Valid = true
 	message.ModifiedAt = now
@@ -84,10 +100,26 @@ func (a *Api) DeleteMessage(user *models.User, msg json.RawMessage) (*DeleteMess
 		return nil, err
 	}
 
+	// Cascade: drop the message's reactions so reaction queries don't keep
+	// returning them for a message nobody can see. A failure here must not
+	// fail the delete itself; the message is already gone from view
+	var reactionsCleared bool
+	if n, err := db.ClearMessageReactions(ctx, a.db, message.ID); err != nil {
+		a.logger.Error("failed to clear reactions for deleted message", "error", err, "message_id", message.ID)
+	} else {
+		reactionsCleared = n > 0
+	}
+
+	// Audit failures must never fail the action itself
+	if err := db.InsertAuditEntry(ctx, a.db, user.ID, db.AuditMessageDelete, message.ID, message.RoomID); err != nil {
+		a.logger.Error("failed to write audit entry", "error", err, "action", db.AuditMessageDelete, "actor", user.ID, "message", message.ID)
+	}
+
 	// Build broadcast
 	broadcast := protocol.MessageDeleted{
-		MessageID: message.ID,
-		RoomID:    message.RoomID,
+		MessageID:        message.ID,
+		RoomID:           message.RoomID,
+		ReactionsCleared: reactionsCleared,
 	}
 
 	msgBytes, err := json.Marshal(&Envelope{
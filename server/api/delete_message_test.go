@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
 )
@@ -83,6 +84,57 @@ func TestDeleteMessage_NotOwner(t *testing.T) {
 	}
 }
 
+func TestDeleteMessage_ClearsReactions(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	author := createTestUser(t, database, "usr_delreact_auth", "delreactauthor")
+	reactor := createTestUser(t, database, "usr_delreact_rctr", "delreactreactor")
+	room := createTestRoom(t, database, "roo_delreact1234", "general", true)
+	addUserToRoom(t, database, author.ID, room.ID)
+	addUserToRoom(t, database, reactor.ID, room.ID)
+
+	msgID := createTestMessageSimple(t, api, author, room.ID, "soon to be deleted")
+
+	addJSON, _ := json.Marshal(protocol.AddReactionRequest{MessageID: msgID, Emoji: "👍"})
+	if _, err := api.AddReaction(reactor, addJSON); err != nil {
+		t.Fatalf("AddReaction failed: %v", err)
+	}
+
+	reqJSON, _ := json.Marshal(protocol.DeleteMessageRequest{MessageID: msgID})
+	res, err := api.DeleteMessage(author, reqJSON)
+	if err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+
+	// The broadcast tells clients to drop the reactions they are showing
+	var envelope struct {
+		Type string                  `json:"type"`
+		Data protocol.MessageDeleted `json:"data"`
+	}
+	if err := json.Unmarshal(res.Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast: %v", err)
+	}
+	if envelope.Type != "message_deleted" {
+		t.Errorf("expected type 'message_deleted', got %s", envelope.Type)
+	}
+	if !envelope.Data.ReactionsCleared {
+		t.Error("expected reactions_cleared to be true in the broadcast")
+	}
+
+	// Subsequent reaction queries no longer return the message's reactions
+	reactions, err := db.GetReactionsForMessages(context.Background(), database, []string{msgID})
+	if err != nil {
+		t.Fatalf("GetReactionsForMessages failed: %v", err)
+	}
+	if len(reactions[msgID]) != 0 {
+		t.Errorf("expected no reactions after delete, got %v", reactions[msgID])
+	}
+}
+
 func TestDeleteMessage_Idempotent(t *testing.T) {
 	database := testDB(t)
 	defer func() { _ = database.Close() }()
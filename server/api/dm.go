@@ -22,6 +22,10 @@ type CreateDMResult struct {
 // CreateDM handles a request to create (or find existing) a DM room with specified users.
 // If a DM with exactly these members already exists, it returns the existing room.
 func (a *Api) CreateDM(user *models.User, msg json.RawMessage) (*CreateDMResult, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.CreateDMRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		a.logger.Error("invalid create_dm json", "error", err)
@@ -61,12 +65,35 @@ func (a *Api) CreateDM(user *models.User, msg json.RawMessage) (*CreateDMResult,
 		return nil, fmt.Errorf("DM requires at least 2 members")
 	}
 
-	// Try to find an existing DM with exactly these members
-	existingRoom, err := a.findExistingDM(ctx, members)
+	// Enforce the instance's DM creation policy
+	if err := a.checkDMPolicy(ctx, user, members); err != nil {
+		a.logger.Warn("DM blocked by policy", "user", user.ID, "policy", a.dmPolicy)
+		return nil, err
+	}
+
+	// Try to find an existing DM with exactly these members: first by the
+	// deterministic member-set key, then by scanning for DMs created
+	// before keys existed
+	dmKey := db.DMKey(members)
+	existingRoom, err := a.findDMByKey(ctx, dmKey)
 	if err != nil {
-		a.logger.Error("failed to search for existing DM", "error", err)
+		a.logger.Error("failed to look up DM key", "error", err)
 		return nil, err
 	}
+	if existingRoom == nil {
+		existingRoom, err = a.findExistingDM(ctx, members)
+		if err != nil {
+			a.logger.Error("failed to search for existing DM", "error", err)
+			return nil, err
+		}
+		if existingRoom != nil {
+			// Backfill the key so the next lookup is direct; losing this
+			// claim just means another request backfilled it first
+			if _, err := db.ClaimDMKey(ctx, a.db, dmKey, existingRoom.ID); err != nil {
+				a.logger.Error("failed to backfill DM key", "error", err, "room", existingRoom.ID)
+			}
+		}
+	}
 
 	if existingRoom != nil {
 		// Found existing DM - return it with members populated
@@ -81,11 +108,12 @@ func (a *Api) CreateDM(user *models.User, msg json.RawMessage) (*CreateDMResult,
 				Type: "create_dm",
 				Data: protocol.CreateDMResponse{
 					Room: protocol.Room{
-						ID:        existingRoom.ID,
-						Name:      existingRoom.Name,
-						RoomType:  existingRoom.RoomType,
-						IsPrivate: existingRoom.IsPrivate != 0,
-						Members:   roomMembers,
+						ID:          existingRoom.ID,
+						Name:        existingRoom.Name,
+						DisplayName: dmDisplayName(user.ID, roomMembers),
+						RoomType:    existingRoom.RoomType,
+						IsPrivate:   existingRoom.IsPrivate != 0,
+						Members:     roomMembers,
 					},
 					Created: false,
 				},
@@ -97,12 +125,15 @@ func (a *Api) CreateDM(user *models.User, msg json.RawMessage) (*CreateDMResult,
 
 	// Create a new DM room
 	room := &models.Room{
-		ID:        models.GenerateRoomID(),
-		Name:      "", // DMs don't have names - display name derived from members
-		RoomType:  "dm",
-		IsPrivate: models.TRUE,
-		IsDefault: models.FALSE,
-		CreatedAt: time.Now().Format(time.RFC3339),
+		ID:           models.GenerateRoomID(),
+		Name:         "", // DMs don't have names - display name derived from members
+		RoomType:     "dm",
+		IsPrivate:    models.TRUE,
+		IsDefault:    models.FALSE,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+		JoinPolicy:   db.JoinPolicyOpen,
+		PostPolicy:   db.PostPolicyAll,
+		AnnouncePins: models.TRUE,
 	}
 
 	if err := room.Insert(ctx, a.db); err != nil {
@@ -110,6 +141,47 @@ func (a *Api) CreateDM(user *models.User, msg json.RawMessage) (*CreateDMResult,
 		return nil, err
 	}
 
+	// Claim the member-set key before adding members. When a concurrent
+	// create_dm for the same members wins the claim, our room is discarded
+	// and the winner's returned, so both callers end up in the same DM
+	claimed, err := db.ClaimDMKey(ctx, a.db, dmKey, room.ID)
+	if err != nil {
+		a.logger.Error("failed to claim DM key", "error", err, "room", room.ID)
+		_ = room.Delete(ctx, a.db)
+		return nil, err
+	}
+	if !claimed {
+		_ = room.Delete(ctx, a.db)
+		winner, err := a.findDMByKey(ctx, dmKey)
+		if err != nil || winner == nil {
+			a.logger.Error("failed to fetch winning DM after losing the key race", "error", err, "key", dmKey)
+			return nil, fmt.Errorf("failed to create DM")
+		}
+		roomMembers, err := a.getRoomMembers(ctx, winner.ID)
+		if err != nil {
+			a.logger.Error("failed to get DM members", "error", err, "room_id", winner.ID)
+			return nil, err
+		}
+		return &CreateDMResult{
+			Envelope: &Envelope{
+				Type: "create_dm",
+				Data: protocol.CreateDMResponse{
+					Room: protocol.Room{
+						ID:          winner.ID,
+						Name:        winner.Name,
+						DisplayName: dmDisplayName(user.ID, roomMembers),
+						RoomType:    winner.RoomType,
+						IsPrivate:   winner.IsPrivate != 0,
+						Members:     roomMembers,
+					},
+					Created: false,
+				},
+			},
+			RoomID:  winner.ID,
+			Created: false,
+		}, nil
+	}
+
 	// Add all members
 	for _, uid := range members {
 		_, err = db.AddRoomMember(ctx, a.db, uid, room.ID)
@@ -143,11 +215,12 @@ func (a *Api) CreateDM(user *models.User, msg json.RawMessage) (*CreateDMResult,
 			Type: "create_dm",
 			Data: protocol.CreateDMResponse{
 				Room: protocol.Room{
-					ID:        room.ID,
-					Name:      room.Name,
-					RoomType:  room.RoomType,
-					IsPrivate: room.IsPrivate != 0,
-					Members:   roomMembers,
+					ID:          room.ID,
+					Name:        room.Name,
+					DisplayName: dmDisplayName(user.ID, roomMembers),
+					RoomType:    room.RoomType,
+					IsPrivate:   room.IsPrivate != 0,
+					Members:     roomMembers,
 				},
 				Created: true,
 			},
@@ -157,6 +230,19 @@ func (a *Api) CreateDM(user *models.User, msg json.RawMessage) (*CreateDMResult,
 	}, nil
 }
 
+// findDMByKey returns the DM room that has claimed the given member-set
+// key, or nil when none has.
+func (a *Api) findDMByKey(ctx context.Context, dmKey string) (*models.Room, error) {
+	roomID, err := db.RoomIDByDMKey(ctx, a.db, dmKey)
+	if err != nil {
+		return nil, err
+	}
+	if roomID == "" {
+		return nil, nil
+	}
+	return models.RoomByID(ctx, a.db, roomID)
+}
+
 // findExistingDM searches for a DM room that has exactly the specified members.
 // Returns nil if no matching DM exists.
 func (a *Api) findExistingDM(ctx context.Context, wantMembers []string) (*models.Room, error) {
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestCreateDM_ConcurrentDuplicates tests that two simultaneous create_dm
+// calls for the same member set converge on a single room
+func TestCreateDM_ConcurrentDuplicates(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_dmrace000001", "dmracealice")
+	bob := createTestUser(t, database, "usr_dmrace000002", "dmracebob")
+	defaultRoom := createTestRoom(t, database, "roo_dmrace000001", "dmrace-default", true)
+	addUserToRoom(t, database, alice.ID, defaultRoom.ID)
+	addUserToRoom(t, database, bob.ID, defaultRoom.ID)
+
+	reqJSON, _ := json.Marshal(protocol.CreateDMRequest{UserIDs: []string{bob.ID}})
+
+	var wg sync.WaitGroup
+	results := make([]*CreateDMResult, 2)
+	errs := make([]error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Each goroutine gets its own copy of the user; CreateDM
+			// mutates last_room on it
+			u := *alice
+			results[i], errs[i] = api.CreateDM(&u, reqJSON)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 2; i++ {
+		if errs[i] != nil {
+			t.Fatalf("CreateDM call %d failed: %v", i, errs[i])
+		}
+	}
+
+	// Both callers get the same room
+	if results[0].RoomID != results[1].RoomID {
+		t.Errorf("Expected both calls to return the same room, got %s and %s", results[0].RoomID, results[1].RoomID)
+	}
+
+	// And exactly one DM room exists
+	var count int
+	row := database.QueryRowContext(context.Background(),
+		"SELECT count(*) FROM rooms WHERE room_type = 'dm'")
+	if err := row.Scan(&count); err != nil {
+		t.Fatalf("Failed to count DM rooms: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected exactly 1 DM room, got %d", count)
+	}
+}
+
+// TestCreateDM_KeyBackfilledForLegacyDM tests that a DM created before
+// dm_keys existed is found by member scan and gets a key backfilled
+func TestCreateDM_KeyBackfilledForLegacyDM(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_dmrace000011", "dmracelegacy")
+	bob := createTestUser(t, database, "usr_dmrace000012", "dmracelegacy2")
+
+	// A DM from before keys existed: room and members but no dm_keys row
+	legacy := createTestDM(t, database, "roo_dmrace000011")
+	addUserToRoom(t, database, alice.ID, legacy.ID)
+	addUserToRoom(t, database, bob.ID, legacy.ID)
+
+	reqJSON, _ := json.Marshal(protocol.CreateDMRequest{UserIDs: []string{bob.ID}})
+	res, err := api.CreateDM(alice, reqJSON)
+	if err != nil {
+		t.Fatalf("CreateDM failed: %v", err)
+	}
+	if res.Created {
+		t.Error("Expected the legacy DM to be found, not a new room created")
+	}
+	if res.RoomID != legacy.ID {
+		t.Errorf("Expected the legacy room %s, got %s", legacy.ID, res.RoomID)
+	}
+
+	// The key row now exists, so a repeat lookup is direct
+	var count int
+	row := database.QueryRowContext(context.Background(),
+		"SELECT count(*) FROM dm_keys WHERE room_id = ?", legacy.ID)
+	if err := row.Scan(&count); err != nil {
+		t.Fatalf("Failed to count dm_keys rows: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 backfilled dm_keys row, got %d", count)
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxDMLabelLength caps custom DM labels; they're sidebar text, not prose
+const maxDMLabelLength = 100
+
+// SetDMLabel handles a request to set or clear the user's custom display
+// label for a DM. Labels are per-user: other participants keep their own
+// view of the conversation, and an empty label reverts to the name derived
+// from the member list.
+func (a *Api) SetDMLabel(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.SetDMLabelRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for set_dm_label", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required: %w", ErrValidation)
+	}
+	if len(req.Label) > maxDMLabelLength {
+		return nil, fmt.Errorf("label exceeds the %d character limit: %w", maxDMLabelLength, ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	// Only DMs carry per-user labels; channels have real names
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		return nil, fmt.Errorf("room %s not found: %w", req.RoomID, ErrValidation)
+	}
+	if room.RoomType != "dm" {
+		return nil, fmt.Errorf("room %s is not a DM: %w", req.RoomID, ErrValidation)
+	}
+
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotMember
+	}
+
+	if req.Label == "" {
+		err = db.ClearDMLabel(ctx, a.db, user.ID, req.RoomID)
+	} else {
+		err = db.SetDMLabel(ctx, a.db, user.ID, req.RoomID, req.Label)
+	}
+	if err != nil {
+		a.logger.Error("failed to set DM label", "error", err, "user", user.ID, "room", req.RoomID)
+		return nil, err
+	}
+
+	return &Envelope{
+		Type: "set_dm_label",
+		Data: protocol.SetDMLabelResponse{RoomID: req.RoomID, Label: req.Label},
+	}, nil
+}
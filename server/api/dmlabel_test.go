@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// initDMName runs init for a user and returns the display name of the
+// given DM in their sidebar
+func initDMName(t *testing.T, api *Api, user *models.User, roomID string) string {
+	t.Helper()
+	result, err := api.InitMessage(user, nil)
+	if err != nil {
+		t.Fatalf("InitMessage failed: %v", err)
+	}
+	initResp, ok := result.Envelope.Data.(protocol.InitResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.InitResponse data type, got %T", result.Envelope.Data)
+	}
+	for _, dm := range initResp.DMs {
+		if dm.ID == roomID {
+			return dm.Name
+		}
+	}
+	t.Fatalf("DM %s not found in init response", roomID)
+	return ""
+}
+
+// setDMLabel marshals and sends a set_dm_label request
+func setDMLabel(t *testing.T, api *Api, user *models.User, roomID, label string) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.SetDMLabelRequest{RoomID: roomID, Label: label})
+	response, err := api.SetDMLabel(user, reqJSON)
+	if err != nil {
+		t.Fatalf("SetDMLabel failed: %v", err)
+	}
+	if response.Type != "set_dm_label" {
+		t.Errorf("Expected type 'set_dm_label', got '%s'", response.Type)
+	}
+}
+
+// TestSetDMLabel_PerUser tests that a label changes the DM's display name
+// for the setting user only
+func TestSetDMLabel_PerUser(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_dmlabel00001", "dmlabelalice")
+	bob := createTestUser(t, database, "usr_dmlabel00002", "dmlabelbob")
+	defaultRoom := createTestRoom(t, database, "roo_dmlabel00000", "dmlabel-default", true)
+	addUserToRoom(t, database, alice.ID, defaultRoom.ID)
+	addUserToRoom(t, database, bob.ID, defaultRoom.ID)
+
+	dm := createTestDM(t, database, "roo_dmlabel00001")
+	addUserToRoom(t, database, alice.ID, dm.ID)
+	addUserToRoom(t, database, bob.ID, dm.ID)
+
+	setDMLabel(t, api, alice, dm.ID, "work chat")
+
+	if name := initDMName(t, api, alice, dm.ID); name != "work chat" {
+		t.Errorf("Expected alice to see label 'work chat', got '%s'", name)
+	}
+	// Labels are per-user: bob keeps the derived (empty) name
+	if name := initDMName(t, api, bob, dm.ID); name != "" {
+		t.Errorf("Expected bob to see the derived name, got '%s'", name)
+	}
+}
+
+// TestSetDMLabel_ClearReverts tests that an empty label removes the custom
+// label and reverts to the derived name
+func TestSetDMLabel_ClearReverts(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_dmlabel00011", "dmlabelclear")
+	defaultRoom := createTestRoom(t, database, "roo_dmlabel00010", "dmlabel-default2", true)
+	addUserToRoom(t, database, alice.ID, defaultRoom.ID)
+
+	dm := createTestDM(t, database, "roo_dmlabel00011")
+	addUserToRoom(t, database, alice.ID, dm.ID)
+
+	setDMLabel(t, api, alice, dm.ID, "old label")
+	if name := initDMName(t, api, alice, dm.ID); name != "old label" {
+		t.Fatalf("Expected label 'old label', got '%s'", name)
+	}
+
+	setDMLabel(t, api, alice, dm.ID, "")
+	if name := initDMName(t, api, alice, dm.ID); name != "" {
+		t.Errorf("Expected clearing to revert to the derived name, got '%s'", name)
+	}
+}
+
+// TestSetDMLabel_Validation tests the length cap and the DM and membership
+// requirements
+func TestSetDMLabel_Validation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_dmlabel00021", "dmlabelvalid")
+	channel := createTestRoom(t, database, "roo_dmlabel00021", "dmlabel-channel", false)
+	addUserToRoom(t, database, alice.ID, channel.ID)
+	dm := createTestDM(t, database, "roo_dmlabel00022")
+	addUserToRoom(t, database, alice.ID, dm.ID)
+
+	// Over the length cap
+	long := strings.Repeat("x", maxDMLabelLength+1)
+	reqJSON, _ := json.Marshal(protocol.SetDMLabelRequest{RoomID: dm.ID, Label: long})
+	if _, err := api.SetDMLabel(alice, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an over-long label, got %v", err)
+	}
+
+	// Channels don't carry labels
+	reqJSON, _ = json.Marshal(protocol.SetDMLabelRequest{RoomID: channel.ID, Label: "nope"})
+	if _, err := api.SetDMLabel(alice, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation labeling a channel, got %v", err)
+	}
+
+	// Nor can a non-participant label someone else's DM
+	other := createTestDM(t, database, "roo_dmlabel00023")
+	reqJSON, _ = json.Marshal(protocol.SetDMLabelRequest{RoomID: other.ID, Label: "nope"})
+	if _, err := api.SetDMLabel(alice, reqJSON); !errors.Is(err, ErrNotMember) {
+		t.Errorf("Expected ErrNotMember labeling a DM alice isn't in, got %v", err)
+	}
+}
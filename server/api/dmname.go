@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxDMNameMembers is how many member names a DM display name lists before
+// collapsing the rest into "+N others"
+const maxDMNameMembers = 3
+
+// dmDisplayName derives a DM's display name from its members, relative to
+// the requesting user: the other members' display names (or usernames),
+// sorted, truncated for large group DMs. Every client gets the same name
+// for the same DM without computing it themselves.
+func dmDisplayName(requesterID string, members []protocol.RoomMember) string {
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		if m.ID == requesterID {
+			continue
+		}
+		name := m.DisplayName
+		if name == "" {
+			name = m.Username
+		}
+		names = append(names, name)
+	}
+	// A DM with only yourself in it shows your own name
+	if len(names) == 0 {
+		for _, m := range members {
+			if m.ID == requesterID {
+				if m.DisplayName != "" {
+					return m.DisplayName
+				}
+				return m.Username
+			}
+		}
+		return ""
+	}
+	sort.Strings(names)
+	if len(names) <= maxDMNameMembers {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s +%d others", strings.Join(names[:maxDMNameMembers], ", "), len(names)-maxDMNameMembers)
+}
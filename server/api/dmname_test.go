@@ -0,0 +1,112 @@
+package api
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// initDMDisplayName runs init for a user and returns the computed display
+// name of the given DM
+func initDMDisplayName(t *testing.T, api *Api, user *models.User, roomID string) string {
+	t.Helper()
+	result, err := api.InitMessage(user, nil)
+	if err != nil {
+		t.Fatalf("InitMessage failed: %v", err)
+	}
+	initResp, ok := result.Envelope.Data.(protocol.InitResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.InitResponse data type, got %T", result.Envelope.Data)
+	}
+	for _, dm := range initResp.DMs {
+		if dm.ID == roomID {
+			return dm.DisplayName
+		}
+	}
+	t.Fatalf("DM %s not found in init response", roomID)
+	return ""
+}
+
+// TestDMDisplayName_OneToOne tests that a 1:1 DM is named after the other
+// person, from each side
+func TestDMDisplayName_OneToOne(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_dmname000001", "dmnamealice")
+	bob := createTestUser(t, database, "usr_dmname000002", "dmnamebob")
+	defaultRoom := createTestRoom(t, database, "roo_dmname000001", "dmname-default", true)
+	addUserToRoom(t, database, alice.ID, defaultRoom.ID)
+	addUserToRoom(t, database, bob.ID, defaultRoom.ID)
+
+	dm := createTestDM(t, database, "roo_dmname000002")
+	addUserToRoom(t, database, alice.ID, dm.ID)
+	addUserToRoom(t, database, bob.ID, dm.ID)
+
+	if name := initDMDisplayName(t, api, alice, dm.ID); name != "dmnamebob" {
+		t.Errorf("Expected alice's DM to be named 'dmnamebob', got '%s'", name)
+	}
+	if name := initDMDisplayName(t, api, bob, dm.ID); name != "dmnamealice" {
+		t.Errorf("Expected bob's DM to be named 'dmnamealice', got '%s'", name)
+	}
+}
+
+// TestDMDisplayName_GroupTruncation tests sorting and +N truncation for
+// group DMs, and that display names take precedence over usernames
+func TestDMDisplayName_GroupTruncation(t *testing.T) {
+	members := []protocol.RoomMember{
+		{ID: "usr_1", Username: "requester"},
+		{ID: "usr_2", Username: "zeb"},
+		{ID: "usr_3", Username: "yara"},
+		{ID: "usr_4", Username: "xavier", DisplayName: "Xavier H"},
+	}
+
+	// Small groups list everyone but the requester, sorted
+	if name := dmDisplayName("usr_1", members); name != "Xavier H, yara, zeb" {
+		t.Errorf("Expected 'Xavier H, yara, zeb', got '%s'", name)
+	}
+
+	// A fifth and sixth member push the name over the cap
+	members = append(members,
+		protocol.RoomMember{ID: "usr_5", Username: "walt"},
+		protocol.RoomMember{ID: "usr_6", Username: "vera"},
+	)
+	if name := dmDisplayName("usr_1", members); name != "Xavier H, vera, walt +2 others" {
+		t.Errorf("Expected 'Xavier H, vera, walt +2 others', got '%s'", name)
+	}
+
+	// The name is relative to whoever is asking
+	if name := dmDisplayName("usr_2", members); name != "Xavier H, requester, vera +2 others" {
+		t.Errorf("Expected 'Xavier H, requester, vera +2 others', got '%s'", name)
+	}
+}
+
+// TestDMDisplayName_LabelWins tests that a user's custom label overrides
+// the derived display name
+func TestDMDisplayName_LabelWins(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_dmname000011", "dmnamelabel")
+	bob := createTestUser(t, database, "usr_dmname000012", "dmnamelabel2")
+	defaultRoom := createTestRoom(t, database, "roo_dmname000011", "dmname-default2", true)
+	addUserToRoom(t, database, alice.ID, defaultRoom.ID)
+
+	dm := createTestDM(t, database, "roo_dmname000012")
+	addUserToRoom(t, database, alice.ID, dm.ID)
+	addUserToRoom(t, database, bob.ID, dm.ID)
+
+	setDMLabel(t, api, alice, dm.ID, "the gang")
+	if name := initDMDisplayName(t, api, alice, dm.ID); name != "the gang" {
+		t.Errorf("Expected the label 'the gang' to win, got '%s'", name)
+	}
+}
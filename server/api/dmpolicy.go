@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// DM creation policies, set via the DM_POLICY environment variable
+const (
+	DMPolicyAnyone     = "anyone"      // any user may DM any other user
+	DMPolicySharedRoom = "shared_room" // both parties must share at least one channel
+	DMPolicyDisabled   = "disabled"    // DM creation is off entirely
+)
+
+// dmPolicyFromEnv reads DM_POLICY, falling back to anyone when it is unset
+// or invalid
+func dmPolicyFromEnv(logger *slog.Logger) string {
+	val := os.Getenv("DM_POLICY")
+	switch val {
+	case "":
+		return DMPolicyAnyone
+	case DMPolicyAnyone, DMPolicySharedRoom, DMPolicyDisabled:
+		return val
+	}
+	logger.Warn("invalid DM_POLICY, using default", "value", val, "default", DMPolicyAnyone)
+	return DMPolicyAnyone
+}
+
+// checkDMPolicy returns a validation error when the instance's DM policy
+// forbids the requesting user from opening a DM with the given members
+func (a *Api) checkDMPolicy(ctx context.Context, requester *models.User, members []string) error {
+	switch a.dmPolicy {
+	case DMPolicyDisabled:
+		return fmt.Errorf("direct messages are disabled on this server: %w", ErrValidation)
+	case DMPolicySharedRoom:
+		for _, uid := range members {
+			if uid == requester.ID {
+				continue
+			}
+			shares, err := db.UsersShareChannel(ctx, a.db, requester.ID, uid)
+			if err != nil {
+				a.logger.Error("failed to check shared channels", "error", err, "user", requester.ID, "other", uid)
+				return err
+			}
+			if !shares {
+				return fmt.Errorf("you can only DM users you share a room with: %w", ErrValidation)
+			}
+		}
+	}
+	return nil
+}
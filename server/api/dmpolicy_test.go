@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestDMPolicy_AnyoneAllowsStrangers tests that the default policy lets any
+// user DM any other user
+func TestDMPolicy_AnyoneAllowsStrangers(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.dmPolicy = DMPolicyAnyone
+
+	alice := createTestUser(t, database, "usr_dmpol_any001", "dmpol-alice")
+	bob := createTestUser(t, database, "usr_dmpol_any002", "dmpol-bob")
+
+	reqJSON, _ := json.Marshal(protocol.CreateDMRequest{UserIDs: []string{bob.ID}})
+	result, err := api.CreateDM(alice, reqJSON)
+	if err != nil {
+		t.Fatalf("Expected DM between strangers to succeed under 'anyone', got %v", err)
+	}
+	if !result.Created {
+		t.Error("Expected a new DM to be created")
+	}
+}
+
+// TestDMPolicy_SharedRoomAllowsCoMembers tests that users who share a
+// channel can DM each other under the shared_room policy
+func TestDMPolicy_SharedRoomAllowsCoMembers(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.dmPolicy = DMPolicySharedRoom
+
+	alice := createTestUser(t, database, "usr_dmpol_shr001", "dmpol-carol")
+	bob := createTestUser(t, database, "usr_dmpol_shr002", "dmpol-dave")
+	room := createTestRoom(t, database, "roo_dmpol_shr001", "dmpol-shared", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+	addUserToRoom(t, database, bob.ID, room.ID)
+
+	reqJSON, _ := json.Marshal(protocol.CreateDMRequest{UserIDs: []string{bob.ID}})
+	if _, err := api.CreateDM(alice, reqJSON); err != nil {
+		t.Fatalf("Expected DM between channel co-members to succeed under 'shared_room', got %v", err)
+	}
+}
+
+// TestDMPolicy_SharedRoomRejectsStrangers tests that users with no channel
+// in common cannot DM each other under the shared_room policy. A shared DM
+// doesn't count.
+func TestDMPolicy_SharedRoomRejectsStrangers(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.dmPolicy = DMPolicySharedRoom
+
+	alice := createTestUser(t, database, "usr_dmpol_str001", "dmpol-erin")
+	bob := createTestUser(t, database, "usr_dmpol_str002", "dmpol-frank")
+	// They share a DM but no channel; that must not satisfy the policy
+	dm := createTestDM(t, database, "roo_dmpol_dm0001")
+	addUserToRoom(t, database, alice.ID, dm.ID)
+	addUserToRoom(t, database, bob.ID, dm.ID)
+
+	reqJSON, _ := json.Marshal(protocol.CreateDMRequest{UserIDs: []string{bob.ID}})
+	_, err := api.CreateDM(alice, reqJSON)
+	if err == nil {
+		t.Fatal("Expected DM between strangers to be rejected under 'shared_room'")
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+}
+
+// TestDMPolicy_DisabledRejectsAll tests that no DMs can be created when the
+// policy is disabled, even between channel co-members
+func TestDMPolicy_DisabledRejectsAll(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.dmPolicy = DMPolicyDisabled
+
+	alice := createTestUser(t, database, "usr_dmpol_dis001", "dmpol-grace")
+	bob := createTestUser(t, database, "usr_dmpol_dis002", "dmpol-heidi")
+	room := createTestRoom(t, database, "roo_dmpol_dis001", "dmpol-disabled", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+	addUserToRoom(t, database, bob.ID, room.ID)
+
+	reqJSON, _ := json.Marshal(protocol.CreateDMRequest{UserIDs: []string{bob.ID}})
+	_, err := api.CreateDM(alice, reqJSON)
+	if err == nil {
+		t.Fatal("Expected DM creation to be rejected when DMs are disabled")
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+}
@@ -6,12 +6,18 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
 )
 
+// maxPatchedBodyLength caps the body a patch may produce. It matches the
+// transport's inbound frame limit so a patched edit can never create a body
+// larger than one a client could have sent whole.
+const maxPatchedBodyLength = 16384
+
 // EditMessageResponse contains the broadcast data and room ID for routing
 type EditMessageResponse struct {
 	RoomID  string
@@ -21,6 +27,10 @@ type EditMessageResponse struct {
 // EditMessage handles a request to edit a message's body.
 // Only the message author can edit. Returns a broadcast message for the room.
 func (a *Api) EditMessage(user *models.User, msg json.RawMessage) (*EditMessageResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.EditMessageRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		a.logger.Error("invalid json for edit_message", "error", err)
@@ -30,7 +40,14 @@ func (a *Api) EditMessage(user *models.User, msg json.RawMessage) (*EditMessageR
 	if req.MessageID == "" {
 		return nil, fmt.Errorf("message_id is required")
 	}
-	if len(strings.TrimSpace(req.Body)) == 0 {
+	if len(req.Patch) > 0 {
+		if req.Body != "" {
+			return nil, fmt.Errorf("body and patch are mutually exclusive: %w", ErrValidation)
+		}
+		if req.BaseModifiedAt == "" {
+			return nil, fmt.Errorf("base_modified_at is required with patch: %w", ErrValidation)
+		}
+	} else if len(strings.TrimSpace(req.Body)) == 0 {
 		return nil, fmt.Errorf("body must not be empty")
 	}
 
@@ -43,6 +60,12 @@ func (a *Api) EditMessage(user *models.User, msg json.RawMessage) (*EditMessageR
 		return nil, fmt.Errorf("message not found")
 	}
 
+	// System messages are server-generated and can never be edited, not
+	// even by the user they are attributed to
+	if message.IsSystem != 0 {
+		return nil, fmt.Errorf("system messages cannot be edited: %w", ErrValidation)
+	}
+
 	// Check ownership
 	if message.UserID != user.ID {
 		a.logger.Warn("user attempted to edit another user's message", "user", user.ID, "message_owner", message.UserID)
@@ -60,12 +83,50 @@ func (a *Api) EditMessage(user *models.User, msg json.RawMessage) (*EditMessageR
 		return nil, err
 	}
 	if !isMember {
-		return nil, fmt.Errorf("user is not a member of the room")
+		return nil, ErrNotMember
 	}
 
-	// Update the message
+	// Enforce the edit window, when one is configured
+	if err := a.checkChangeWindow(ctx, user, message, a.editWindow, "edited"); err != nil {
+		return nil, err
+	}
+
+	// Resolve the new body: either the full replacement from the request, or
+	// the stored body with the patch applied
+	newBody := req.Body
+	if len(req.Patch) > 0 {
+		// Reject patches computed against a version of the message the
+		// client no longer has; a concurrent edit has landed in between
+		if req.BaseModifiedAt != message.ModifiedAt {
+			return nil, fmt.Errorf("message was modified since base_modified_at: %w", ErrValidation)
+		}
+		baseBody, err := a.db.Cipher.DecryptBody(message.Body, message.EncKeyID)
+		if err != nil {
+			a.logger.Error("unable to decrypt message body", "error", err)
+			return nil, err
+		}
+		newBody, err = applyPatch(baseBody, req.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", err, ErrValidation)
+		}
+		if len(strings.TrimSpace(newBody)) == 0 {
+			return nil, fmt.Errorf("patched body must not be empty: %w", ErrValidation)
+		}
+		if len(newBody) > maxPatchedBodyLength {
+			return nil, fmt.Errorf("patched body exceeds the %d byte limit: %w", maxPatchedBodyLength, ErrValidation)
+		}
+	}
+
+	// Update the message, encrypting the new body at rest when a cipher is
+	// configured
+	storedBody, encKeyID, err := a.db.Cipher.EncryptBody(newBody)
+	if err != nil {
+		a.logger.Error("unable to encrypt message body", "error", err)
+		return nil, err
+	}
 	now := time.Now().Format(time.RFC3339Nano)
-	message.Body = req.Body
+	message.Body = storedBody
+	message.EncKeyID = encKeyID
 	message.ModifiedAt = now
 	if err = message.Update(ctx, a.db); err != nil {
 		a.logger.Error("failed to update message", "error", err)
@@ -75,7 +136,7 @@ func (a *Api) EditMessage(user *models.User, msg json.RawMessage) (*EditMessageR
 	// Build broadcast
 	broadcast := protocol.MessageEdited{
 		MessageID:  message.ID,
-		Body:       message.Body,
+		Body:       newBody,
 		RoomID:     message.RoomID,
 		ModifiedAt: now,
 	}
@@ -93,3 +154,52 @@ func (a *Api) EditMessage(user *models.User, msg json.RawMessage) (*EditMessageR
 		Message: msgBytes,
 	}, nil
 }
+
+// applyPatch applies an ordered list of patch ops to base and returns the
+// resulting body. Ops walk base from the start; any text past the final op is
+// retained unchanged. Errors describe the first invalid op.
+//
+// Counts are byte offsets, so an op computed against a mangled copy of the
+// message could land in the middle of a multi-byte rune; those patches are
+// rejected rather than letting invalid UTF-8 into the database.
+func applyPatch(base string, ops []protocol.PatchOp) (string, error) {
+	var out strings.Builder
+	pos := 0
+	for i, op := range ops {
+		switch op.Op {
+		case "retain":
+			if op.Count < 0 || pos+op.Count > len(base) {
+				return "", fmt.Errorf("patch op %d retains past the end of the message", i)
+			}
+			if !runeBoundary(base, pos+op.Count) {
+				return "", fmt.Errorf("patch op %d retains to the middle of a character", i)
+			}
+			out.WriteString(base[pos : pos+op.Count])
+			pos += op.Count
+		case "delete":
+			if op.Count < 0 || pos+op.Count > len(base) {
+				return "", fmt.Errorf("patch op %d deletes past the end of the message", i)
+			}
+			if !runeBoundary(base, pos+op.Count) {
+				return "", fmt.Errorf("patch op %d deletes to the middle of a character", i)
+			}
+			pos += op.Count
+		case "insert":
+			if !utf8.ValidString(op.Text) {
+				return "", fmt.Errorf("patch op %d inserts invalid UTF-8", i)
+			}
+			out.WriteString(op.Text)
+		default:
+			return "", fmt.Errorf("patch op %d has unknown op %q", i, op.Op)
+		}
+	}
+	out.WriteString(base[pos:])
+	return out.String(), nil
+}
+
+// runeBoundary reports whether pos is the start of a rune (or the end of the
+// string) in base. Ops always land on a boundary when their counts were
+// computed against the same bytes the server holds.
+func runeBoundary(base string, pos int) bool {
+	return pos == len(base) || utf8.RuneStart(base[pos])
+}
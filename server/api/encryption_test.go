@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+)
+
+// testCipher parses an encryption key spec or fails the test
+func testCipher(t *testing.T, spec string) *db.MessageCipher {
+	t.Helper()
+	cipher, err := db.ParseMessageCipher(spec)
+	if err != nil {
+		t.Fatalf("ParseMessageCipher failed: %v", err)
+	}
+	return cipher
+}
+
+// TestEncryption_StoredColumnIsCiphertext tests that with encryption enabled
+// the stored body column is ciphertext while reads return plaintext
+func TestEncryption_StoredColumnIsCiphertext(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+	database.Cipher = testCipher(t, "k1:"+strings.Repeat("0a", 32))
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_enc_user0001", "encuser")
+	room := createTestRoom(t, database, "roo_enc_room0001", "encroom", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	if _, err := sendMessage(t, api, user, room.ID, "the launch codes"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	// The raw column must not contain the plaintext
+	var storedBody, encKeyID string
+	row := database.QueryRowContext(context.Background(),
+		"SELECT body, enc_key_id FROM messages WHERE room_id = ?", room.ID)
+	if err := row.Scan(&storedBody, &encKeyID); err != nil {
+		t.Fatalf("failed to read raw message row: %v", err)
+	}
+	if strings.Contains(storedBody, "launch") {
+		t.Errorf("stored body contains plaintext: %q", storedBody)
+	}
+	if encKeyID != "k1" {
+		t.Errorf("expected enc_key_id k1, got %q", encKeyID)
+	}
+
+	// Reads through GetRoomMessages decrypt back to plaintext
+	messages, err := db.GetRoomMessages(context.Background(), database, room.ID, "", 10)
+	if err != nil {
+		t.Fatalf("GetRoomMessages failed: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Body != "the launch codes" {
+		t.Errorf("expected decrypted plaintext, got %q", messages[0].Body)
+	}
+}
+
+// TestEncryption_WrongKeyFailsLoudly tests that reading messages written
+// under a different key errors rather than returning garbage
+func TestEncryption_WrongKeyFailsLoudly(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+	database.Cipher = testCipher(t, "k1:"+strings.Repeat("0a", 32))
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_enc_user0002", "encuser2")
+	room := createTestRoom(t, database, "roo_enc_room0002", "encroom2", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	if _, err := sendMessage(t, api, user, room.ID, "written under k1"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	// Same key ID, different key material
+	database.Cipher = testCipher(t, "k1:"+strings.Repeat("0c", 32))
+	if _, err := db.GetRoomMessages(context.Background(), database, room.ID, "", 10); err == nil {
+		t.Error("expected reading with the wrong key to fail, got nil")
+	}
+}
+
+// TestEncryption_KeyRotation tests that rows written before a rotation still
+// decrypt while new rows use the new key
+func TestEncryption_KeyRotation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+	database.Cipher = testCipher(t, "k1:"+strings.Repeat("0a", 32))
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_enc_user0003", "encuser3")
+	room := createTestRoom(t, database, "roo_enc_room0003", "encroom3", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	if _, err := sendMessage(t, api, user, room.ID, "before rotation"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	// Rotate: k2 becomes active, k1 is retained for reads
+	database.Cipher = testCipher(t, "k2:"+strings.Repeat("0b", 32)+",k1:"+strings.Repeat("0a", 32))
+	if _, err := sendMessage(t, api, user, room.ID, "after rotation"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	messages, err := db.GetRoomMessages(context.Background(), database, room.ID, "", 10)
+	if err != nil {
+		t.Fatalf("GetRoomMessages failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	// Newest first
+	if messages[0].Body != "after rotation" || messages[1].Body != "before rotation" {
+		t.Errorf("expected both generations to decrypt, got %q and %q", messages[0].Body, messages[1].Body)
+	}
+
+	var keyIDs []string
+	rows, err := database.QueryContext(context.Background(),
+		"SELECT enc_key_id FROM messages WHERE room_id = ? ORDER BY seq", room.ID)
+	if err != nil {
+		t.Fatalf("failed to read key IDs: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		keyIDs = append(keyIDs, id)
+	}
+	if len(keyIDs) != 2 || keyIDs[0] != "k1" || keyIDs[1] != "k2" {
+		t.Errorf("expected key IDs [k1 k2], got %v", keyIDs)
+	}
+}
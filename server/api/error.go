@@ -1,16 +1,60 @@
 package api
 
-import "github.com/llimllib/hatchat/server/protocol"
+import (
+	"errors"
 
-// ErrorMessage returns a message struct to be sent to the client
-func (a *Api) ErrorMessage(message string) *Envelope {
-	return ErrorResponse(message)
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// Sentinel errors handlers wrap so errorCode can classify their failures
+var (
+	// ErrNotMember is returned when the user is not a member of the room
+	// they are operating on
+	ErrNotMember = errors.New("user is not a member of the room")
+
+	// ErrValidation is returned when a request is malformed or missing
+	// required fields
+	ErrValidation = errors.New("invalid request")
+
+	// ErrMaintenance is returned for write operations while the server is
+	// in read-only maintenance mode
+	ErrMaintenance = errors.New("the server is in read-only maintenance mode")
+
+	// ErrRateLimited is returned when a rate cap other than slow mode
+	// rejected the request, such as a room's aggregate message cap
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+// ErrorMessage returns an error envelope for a failed handler call, with a
+// code derived from the error and message as the display text
+func (a *Api) ErrorMessage(err error, message string) *Envelope {
+	return errorEnvelope(errorCode(err), message)
 }
 
-// ErrorResponse returns an error envelope without needing an Api instance
-func ErrorResponse(message string) *Envelope {
+// errorEnvelope builds the one true error envelope shape: Type "error" with a
+// *protocol.ErrorResponse carrying a stable code and a display message. Every
+// handler error path goes through here so clients always see the same shape.
+func errorEnvelope(code, message string) *Envelope {
 	return &Envelope{
 		Type: "error",
-		Data: &protocol.ErrorResponse{Message: message},
+		Data: &protocol.ErrorResponse{Code: code, Message: message},
+	}
+}
+
+// errorCode maps a handler error to a stable code clients can branch on
+func errorCode(err error) string {
+	var slowErr *SlowModeError
+	switch {
+	case errors.As(err, &slowErr):
+		return protocol.ErrCodeRateLimited
+	case errors.Is(err, ErrRateLimited):
+		return protocol.ErrCodeRateLimited
+	case errors.Is(err, ErrNotMember):
+		return protocol.ErrCodeNotMember
+	case errors.Is(err, ErrValidation):
+		return protocol.ErrCodeValidation
+	case errors.Is(err, ErrMaintenance):
+		return protocol.ErrCodeMaintenance
 	}
+	return protocol.ErrCodeInternal
 }
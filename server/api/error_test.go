@@ -0,0 +1,141 @@
+package api
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+func errorEnvelopeCode(t *testing.T, env *Envelope) string {
+	t.Helper()
+	if env.Type != "error" {
+		t.Fatalf("Expected error envelope, got '%s'", env.Type)
+	}
+	data, ok := env.Data.(*protocol.ErrorResponse)
+	if !ok {
+		t.Fatalf("Expected *protocol.ErrorResponse, got %T", env.Data)
+	}
+	return data.Code
+}
+
+func TestErrorCode_NonMemberSend(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	_ = createTestRoom(t, database, "roo_test12345678", "test-channel", false)
+	// Don't add user to room
+
+	_, err := api.MessageMessage(user, []byte(`{"room_id": "roo_test12345678", "body": "hello"}`))
+	if err == nil {
+		t.Fatal("Expected non-member send to fail")
+	}
+	if code := errorEnvelopeCode(t, api.ErrorMessage(err, "failed to handle message")); code != protocol.ErrCodeNotMember {
+		t.Errorf("Expected code %q, got %q", protocol.ErrCodeNotMember, code)
+	}
+}
+
+func TestErrorCode_MissingRoom(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+
+	response, err := api.LeaveRoom(user, []byte(`{"room_id": "roo_nosuchroom12"}`))
+	if err != nil {
+		t.Fatalf("LeaveRoom failed: %v", err)
+	}
+	if code := errorEnvelopeCode(t, response); code != protocol.ErrCodeNotFound {
+		t.Errorf("Expected code %q, got %q", protocol.ErrCodeNotFound, code)
+	}
+}
+
+func TestErrorCode_EmptyBody(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "test-channel", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	_, err := api.MessageMessage(user, []byte(`{"room_id": "roo_test12345678", "body": ""}`))
+	if err == nil {
+		t.Fatal("Expected empty message to fail")
+	}
+	if code := errorEnvelopeCode(t, api.ErrorMessage(err, "failed to handle message")); code != protocol.ErrCodeValidation {
+		t.Errorf("Expected code %q, got %q", protocol.ErrCodeValidation, code)
+	}
+}
+
+// TestErrorEnvelopeConsistency tests that every handler's error path
+// produces the same envelope shape: Type "error" with a pointer
+// *protocol.ErrorResponse carrying both a code and a message
+func TestErrorEnvelopeConsistency(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	empty := []byte(`{}`)
+
+	handlers := map[string]func() *Envelope{
+		"LeaveRoom": func() *Envelope {
+			env, _ := api.LeaveRoom(user, empty)
+			return env
+		},
+		"RoomInfo": func() *Envelope {
+			env, _ := api.RoomInfo(user, empty)
+			return env
+		},
+		"GetProfile": func() *Envelope {
+			env, _ := api.GetProfile(user, empty)
+			return env
+		},
+		"Search": func() *Envelope {
+			env, _ := api.Search(user, empty)
+			return &env
+		},
+		"GetMessageContext": func() *Envelope {
+			env, _ := api.GetMessageContext(user, empty)
+			return &env
+		},
+	}
+
+	for name, call := range handlers {
+		env := call()
+		if env.Type != "error" {
+			t.Errorf("%s: expected error envelope, got '%s'", name, env.Type)
+			continue
+		}
+		data, ok := env.Data.(*protocol.ErrorResponse)
+		if !ok {
+			t.Errorf("%s: expected *protocol.ErrorResponse, got %T", name, env.Data)
+			continue
+		}
+		if data.Code == "" {
+			t.Errorf("%s: expected a non-empty error code", name)
+		}
+		if data.Message == "" {
+			t.Errorf("%s: expected a non-empty error message", name)
+		}
+	}
+}
+
+func TestErrorCode_RateLimited(t *testing.T) {
+	if code := errorCode(&SlowModeError{RemainingSeconds: 5}); code != protocol.ErrCodeRateLimited {
+		t.Errorf("Expected code %q, got %q", protocol.ErrCodeRateLimited, code)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// FavoriteRoom handles a request to add or remove a room from the user's
+// sidebar favorites. Favorites are per-user; only rooms the user belongs to
+// may be favorited. Responds with the full ordered favorites list.
+func (a *Api) FavoriteRoom(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.FavoriteRoomRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for favorite_room", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required")
+	}
+
+	ctx := context.Background()
+
+	if req.Favorite {
+		// Only rooms the user belongs to may be favorited
+		isMember, err := db.IsRoomMember(ctx, a.db, user.ID, req.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, ErrNotMember
+		}
+		if _, err := db.AddFavoriteRoom(ctx, a.db, user.ID, req.RoomID); err != nil {
+			a.logger.Error("failed to add favorite", "error", err, "user", user.ID, "room", req.RoomID)
+			return nil, err
+		}
+	} else {
+		if _, err := db.RemoveFavoriteRoom(ctx, a.db, user.ID, req.RoomID); err != nil {
+			a.logger.Error("failed to remove favorite", "error", err, "user", user.ID, "room", req.RoomID)
+			return nil, err
+		}
+	}
+
+	return a.favoritesEnvelope(ctx, user)
+}
+
+// ReorderFavorites handles a request to rearrange the user's favorite
+// rooms. Every listed room must already be a favorite or the reorder is
+// rejected. Responds with the full ordered favorites list.
+func (a *Api) ReorderFavorites(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.ReorderFavoritesRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for reorder_favorites", "error", err)
+		return nil, err
+	}
+
+	if len(req.RoomIDs) == 0 {
+		return nil, fmt.Errorf("room_ids is required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	if err := db.ReorderFavoriteRooms(ctx, a.db, user.ID, req.RoomIDs); err != nil {
+		a.logger.Error("failed to reorder favorites", "error", err, "user", user.ID)
+		return nil, fmt.Errorf("%v: %w", err, ErrValidation)
+	}
+
+	return a.favoritesEnvelope(ctx, user)
+}
+
+// favoritesEnvelope builds a favorites_updated envelope with the user's
+// current favorites
+func (a *Api) favoritesEnvelope(ctx context.Context, user *models.User) (*Envelope, error) {
+	favorites, err := db.GetFavoriteRooms(ctx, a.db, user.ID)
+	if err != nil {
+		a.logger.Error("failed to get favorites", "error", err, "user", user.ID)
+		return nil, err
+	}
+	// Return empty array instead of nil for consistency
+	if favorites == nil {
+		favorites = []string{}
+	}
+	return &Envelope{
+		Type: "favorites_updated",
+		Data: protocol.FavoritesUpdated{Favorites: favorites},
+	}, nil
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// favoriteRoom marshals and sends a favorite_room request, returning the
+// updated favorites list
+func favoriteRoom(t *testing.T, api *Api, user *models.User, roomID string, favorite bool) ([]string, error) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.FavoriteRoomRequest{RoomID: roomID, Favorite: favorite})
+	res, err := api.FavoriteRoom(user, reqJSON)
+	if err != nil {
+		return nil, err
+	}
+	if res.Type != "favorites_updated" {
+		t.Fatalf("expected type 'favorites_updated', got %s", res.Type)
+	}
+	return res.Data.(protocol.FavoritesUpdated).Favorites, nil
+}
+
+// TestFavorites_AddAndInit tests that favorited rooms come back in order,
+// re-favoriting is idempotent, and init carries the favorites list
+func TestFavorites_AddAndInit(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_fav_00000001", "fav-alice")
+	room1 := createTestRoom(t, database, "roo_fav_00000001", "fav-one", true)
+	room2 := createTestRoom(t, database, "roo_fav_00000002", "fav-two", false)
+	other := createTestRoom(t, database, "roo_fav_00000003", "fav-other", false)
+	addUserToRoom(t, database, user.ID, room1.ID)
+	addUserToRoom(t, database, user.ID, room2.ID)
+
+	favorites, err := favoriteRoom(t, api, user, room1.ID, true)
+	if err != nil {
+		t.Fatalf("FavoriteRoom failed: %v", err)
+	}
+	if len(favorites) != 1 || favorites[0] != room1.ID {
+		t.Errorf("expected [%s], got %v", room1.ID, favorites)
+	}
+	favorites, err = favoriteRoom(t, api, user, room2.ID, true)
+	if err != nil {
+		t.Fatalf("FavoriteRoom failed: %v", err)
+	}
+	if len(favorites) != 2 || favorites[0] != room1.ID || favorites[1] != room2.ID {
+		t.Errorf("expected [%s %s], got %v", room1.ID, room2.ID, favorites)
+	}
+
+	// Re-favoriting doesn't duplicate or reorder
+	favorites, err = favoriteRoom(t, api, user, room1.ID, true)
+	if err != nil {
+		t.Fatalf("FavoriteRoom failed: %v", err)
+	}
+	if len(favorites) != 2 || favorites[0] != room1.ID {
+		t.Errorf("expected re-favoriting to be idempotent, got %v", favorites)
+	}
+
+	// Rooms the user doesn't belong to can't be favorited
+	if _, err := favoriteRoom(t, api, user, other.ID, true); !errors.Is(err, ErrNotMember) {
+		t.Errorf("expected ErrNotMember for a non-member room, got %v", err)
+	}
+
+	// Init carries the favorites in order
+	initRes, err := api.InitMessage(user, nil)
+	if err != nil {
+		t.Fatalf("InitMessage failed: %v", err)
+	}
+	initData := initRes.Envelope.Data.(protocol.InitResponse)
+	if len(initData.Favorites) != 2 || initData.Favorites[0] != room1.ID || initData.Favorites[1] != room2.ID {
+		t.Errorf("expected init favorites [%s %s], got %v", room1.ID, room2.ID, initData.Favorites)
+	}
+
+	// Unfavoriting removes just that room
+	favorites, err = favoriteRoom(t, api, user, room1.ID, false)
+	if err != nil {
+		t.Fatalf("FavoriteRoom failed: %v", err)
+	}
+	if len(favorites) != 1 || favorites[0] != room2.ID {
+		t.Errorf("expected [%s], got %v", room2.ID, favorites)
+	}
+}
+
+// TestFavorites_Reorder tests that reordering rewrites positions and that
+// listing a non-favorite room rejects the whole reorder
+func TestFavorites_Reorder(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_fav_00000002", "fav-bob")
+	// the first room doubles as the default room InitMessage falls back to
+	rooms := []string{"roo_fav_00000004", "roo_fav_00000005", "roo_fav_00000006"}
+	for i, id := range rooms {
+		room := createTestRoom(t, database, id, "fav-reorder-"+string(rune('a'+i)), i == 0)
+		addUserToRoom(t, database, user.ID, room.ID)
+		if _, err := favoriteRoom(t, api, user, room.ID, true); err != nil {
+			t.Fatalf("FavoriteRoom failed: %v", err)
+		}
+	}
+
+	reversed := []string{rooms[2], rooms[1], rooms[0]}
+	reqJSON, _ := json.Marshal(protocol.ReorderFavoritesRequest{RoomIDs: reversed})
+	res, err := api.ReorderFavorites(user, reqJSON)
+	if err != nil {
+		t.Fatalf("ReorderFavorites failed: %v", err)
+	}
+	favorites := res.Data.(protocol.FavoritesUpdated).Favorites
+	for i := range reversed {
+		if favorites[i] != reversed[i] {
+			t.Fatalf("expected order %v, got %v", reversed, favorites)
+		}
+	}
+
+	// A reorder naming a room that isn't a favorite is rejected
+	reqJSON, _ = json.Marshal(protocol.ReorderFavoritesRequest{RoomIDs: []string{rooms[0], "roo_fav_notafave1"}})
+	if _, err := api.ReorderFavorites(user, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	// ...and leaves the previous order intact
+	initRes, err := api.InitMessage(user, nil)
+	if err != nil {
+		t.Fatalf("InitMessage failed: %v", err)
+	}
+	after := initRes.Envelope.Data.(protocol.InitResponse).Favorites
+	for i := range reversed {
+		if after[i] != reversed[i] {
+			t.Fatalf("expected the failed reorder to roll back, got %v", after)
+		}
+	}
+}
+
+// TestFavorites_UserScoped tests that favorites belong to the user who set
+// them
+func TestFavorites_UserScoped(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_fav_00000003", "fav-carol")
+	bob := createTestUser(t, database, "usr_fav_00000004", "fav-dave")
+	room1 := createTestRoom(t, database, "roo_fav_00000007", "fav-scope-a", false)
+	room2 := createTestRoom(t, database, "roo_fav_00000008", "fav-scope-b", false)
+	addUserToRoom(t, database, alice.ID, room1.ID)
+	addUserToRoom(t, database, bob.ID, room1.ID)
+	addUserToRoom(t, database, bob.ID, room2.ID)
+
+	if _, err := favoriteRoom(t, api, alice, room1.ID, true); err != nil {
+		t.Fatalf("FavoriteRoom failed: %v", err)
+	}
+	bobFavorites, err := favoriteRoom(t, api, bob, room2.ID, true)
+	if err != nil {
+		t.Fatalf("FavoriteRoom failed: %v", err)
+	}
+	if len(bobFavorites) != 1 || bobFavorites[0] != room2.ID {
+		t.Errorf("expected bob's favorites to be [%s], got %v", room2.ID, bobFavorites)
+	}
+}
+
+// TestFavorites_LeaveRemoves tests that leaving a room removes it from the
+// leaver's favorites
+func TestFavorites_LeaveRemoves(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_fav_00000005", "fav-erin")
+	// InitMessage needs a default room to fall back to, and the default room
+	// can't be left, so the test leaves a second room
+	createTestRoom(t, database, "roo_fav_0000000a", "fav-leave-home", true)
+	room := createTestRoom(t, database, "roo_fav_00000009", "fav-leave", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	if _, err := favoriteRoom(t, api, user, room.ID, true); err != nil {
+		t.Fatalf("FavoriteRoom failed: %v", err)
+	}
+
+	leaveJSON, _ := json.Marshal(protocol.LeaveRoomRequest{RoomID: room.ID})
+	if _, err := api.LeaveRoom(user, leaveJSON); err != nil {
+		t.Fatalf("LeaveRoom failed: %v", err)
+	}
+
+	initRes, err := api.InitMessage(user, nil)
+	if err != nil {
+		t.Fatalf("InitMessage failed: %v", err)
+	}
+	if favorites := initRes.Envelope.Data.(protocol.InitResponse).Favorites; len(favorites) != 0 {
+		t.Errorf("expected no favorites after leaving, got %v", favorites)
+	}
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxFilterPatternLength caps filter rule patterns
+const maxFilterPatternLength = 80
+
+// applyRoomFilters runs a message body through the room's content filter
+// rules. Reject rules fail the send with a validation error; mask rules
+// replace each match with asterisks, so the original text is never stored.
+// Matching is case-insensitive and word-boundary aware.
+func (a *Api) applyRoomFilters(ctx context.Context, roomID, body string) (string, error) {
+	filters, err := db.ListRoomFilters(ctx, a.db, roomID)
+	if err != nil {
+		a.logger.Error("failed to list room filters", "error", err, "room", roomID)
+		return "", err
+	}
+
+	for _, f := range filters {
+		re, err := regexp.Compile(`(?i)\b` + regexp.QuoteMeta(f.Pattern) + `\b`)
+		if err != nil {
+			a.logger.Error("failed to compile filter pattern", "error", err, "room", roomID, "pattern", f.Pattern)
+			continue
+		}
+		if !re.MatchString(body) {
+			continue
+		}
+		if f.Action == db.FilterReject {
+			a.logger.Warn("message rejected by room filter", "room", roomID, "pattern", f.Pattern)
+			return "", fmt.Errorf("message contains a term this room does not allow: %w", ErrValidation)
+		}
+		body = re.ReplaceAllStringFunc(body, func(match string) string {
+			return strings.Repeat("*", len([]rune(match)))
+		})
+	}
+	return body, nil
+}
+
+// AddRoomFilter handles an owner or admin adding a content filter rule to
+// a room. Adding a rule for an existing pattern replaces its action.
+func (a *Api) AddRoomFilter(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.AddRoomFilterRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid add_room_filter json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required: %w", ErrValidation)
+	}
+	pattern := strings.TrimSpace(req.Pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required: %w", ErrValidation)
+	}
+	if len([]rune(pattern)) > maxFilterPatternLength {
+		return nil, fmt.Errorf("pattern must be at most %d characters: %w", maxFilterPatternLength, ErrValidation)
+	}
+	if req.Action != db.FilterReject && req.Action != db.FilterMask {
+		return nil, fmt.Errorf("action must be %q or %q: %w", db.FilterReject, db.FilterMask, ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found: %w", ErrValidation)
+	}
+	if err := a.requireRoomAdmin(ctx, user, room.ID); err != nil {
+		return nil, err
+	}
+
+	if err := db.InsertRoomFilter(ctx, a.db, room.ID, pattern, req.Action); err != nil {
+		a.logger.Error("failed to insert room filter", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	a.logger.Info("room filter added", "room", room.ID, "by", user.ID, "action", req.Action)
+
+	return &Envelope{
+		Type: "add_room_filter",
+		Data: protocol.AddRoomFilterResponse{
+			RoomID:  room.ID,
+			Pattern: pattern,
+			Action:  req.Action,
+		},
+	}, nil
+}
+
+// RemoveRoomFilter handles an owner or admin removing a content filter
+// rule from a room
+func (a *Api) RemoveRoomFilter(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.RemoveRoomFilterRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid remove_room_filter json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" || req.Pattern == "" {
+		return nil, fmt.Errorf("room_id and pattern are required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found: %w", ErrValidation)
+	}
+	if err := a.requireRoomAdmin(ctx, user, room.ID); err != nil {
+		return nil, err
+	}
+
+	removed, err := db.DeleteRoomFilter(ctx, a.db, room.ID, req.Pattern)
+	if err != nil {
+		a.logger.Error("failed to delete room filter", "error", err, "room", room.ID)
+		return nil, err
+	}
+	if !removed {
+		return nil, fmt.Errorf("no filter rule for this pattern: %w", ErrValidation)
+	}
+
+	a.logger.Info("room filter removed", "room", room.ID, "by", user.ID)
+
+	return &Envelope{
+		Type: "remove_room_filter",
+		Data: protocol.RemoveRoomFilterResponse{
+			RoomID:  room.ID,
+			Pattern: req.Pattern,
+		},
+	}, nil
+}
+
+// ListRoomFilters handles an owner or admin listing a room's content
+// filter rules
+func (a *Api) ListRoomFilters(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.ListRoomFiltersRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid list_room_filters json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found: %w", ErrValidation)
+	}
+	if err := a.requireRoomAdmin(ctx, user, room.ID); err != nil {
+		return nil, err
+	}
+
+	rules, err := db.ListRoomFilters(ctx, a.db, room.ID)
+	if err != nil {
+		a.logger.Error("failed to list room filters", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	filters := make([]protocol.RoomFilterRule, 0, len(rules))
+	for _, f := range rules {
+		filters = append(filters, protocol.RoomFilterRule{
+			Pattern:   f.Pattern,
+			Action:    f.Action,
+			CreatedAt: f.CreatedAt,
+		})
+	}
+
+	return &Envelope{
+		Type: "list_room_filters",
+		Data: protocol.ListRoomFiltersResponse{
+			RoomID:  room.ID,
+			Filters: filters,
+		},
+	}, nil
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// addFilter adds a content filter rule as the given admin user
+func addFilter(t *testing.T, api *Api, admin *models.User, roomID, pattern, action string) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.AddRoomFilterRequest{RoomID: roomID, Pattern: pattern, Action: action})
+	if _, err := api.AddRoomFilter(admin, reqJSON); err != nil {
+		t.Fatalf("Failed to add filter: %v", err)
+	}
+}
+
+// storedMessageBody reads a message's body straight from the messages table
+func storedMessageBody(t *testing.T, api *Api, messageID string) string {
+	t.Helper()
+	var body string
+	row := api.db.QueryRowContext(context.Background(),
+		"SELECT body FROM messages WHERE id = ?", messageID)
+	if err := row.Scan(&body); err != nil {
+		t.Fatalf("Failed to read stored message body: %v", err)
+	}
+	return body
+}
+
+// TestFilter_RejectMode tests that a reject rule blocks messages containing
+// the pattern, case-insensitively and on word boundaries
+func TestFilter_RejectMode(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	admin := createTestUser(t, database, "usr_filt_admin01", "filt-admin")
+	member := createTestUser(t, database, "usr_filt_memb001", "filt-member")
+	room := createTestRoom(t, database, "roo_filt_0000001", "filt-reject", false)
+	addUserToRoom(t, database, admin.ID, room.ID)
+	addUserToRoom(t, database, member.ID, room.ID)
+	setMemberRole(t, api, admin.ID, room.ID, db.RoleOwner)
+	addFilter(t, api, admin, room.ID, "spoiler", db.FilterReject)
+
+	// Matches are rejected regardless of case
+	_, err := sendMessage(t, api, member, room.ID, "big SPOILER ahead")
+	if err == nil {
+		t.Fatal("Expected a message containing the pattern to be rejected")
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+
+	// The pattern inside a longer word is not a match
+	if _, err := sendMessage(t, api, member, room.ID, "unspoilered opinions"); err != nil {
+		t.Errorf("Expected a partial-word match to be allowed, got %v", err)
+	}
+}
+
+// TestFilter_MaskMode tests that a mask rule stores and broadcasts the
+// message with matches replaced; the original text is never persisted
+func TestFilter_MaskMode(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	admin := createTestUser(t, database, "usr_filt_admin02", "filt-masker")
+	member := createTestUser(t, database, "usr_filt_memb002", "filt-potty")
+	room := createTestRoom(t, database, "roo_filt_0000002", "filt-mask", false)
+	addUserToRoom(t, database, admin.ID, room.ID)
+	addUserToRoom(t, database, member.ID, room.ID)
+	setMemberRole(t, api, admin.ID, room.ID, db.RoleOwner)
+	addFilter(t, api, admin, room.ID, "heck", db.FilterMask)
+
+	res, err := sendMessage(t, api, member, room.ID, "what the Heck is this")
+	if err != nil {
+		t.Fatalf("Expected a masked message to be accepted, got %v", err)
+	}
+	var envelope struct {
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(res.Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast: %v", err)
+	}
+	want := "what the **** is this"
+	if envelope.Data.Body != want {
+		t.Errorf("Expected broadcast body %q, got %q", want, envelope.Data.Body)
+	}
+	if stored := storedMessageBody(t, api, envelope.Data.ID); stored != want {
+		t.Errorf("Expected stored body %q, got %q", want, stored)
+	}
+}
+
+// TestFilter_ScopedPerRoom tests that a rule only applies to the room it
+// was added to
+func TestFilter_ScopedPerRoom(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	admin := createTestUser(t, database, "usr_filt_admin03", "filt-scoper")
+	room1 := createTestRoom(t, database, "roo_filt_0000003", "filt-strict", false)
+	room2 := createTestRoom(t, database, "roo_filt_0000004", "filt-lax", false)
+	addUserToRoom(t, database, admin.ID, room1.ID)
+	addUserToRoom(t, database, admin.ID, room2.ID)
+	setMemberRole(t, api, admin.ID, room1.ID, db.RoleOwner)
+	addFilter(t, api, admin, room1.ID, "verboten", db.FilterReject)
+
+	if _, err := sendMessage(t, api, admin, room1.ID, "this is verboten"); err == nil {
+		t.Error("Expected the rule to apply in its own room")
+	}
+	if _, err := sendMessage(t, api, admin, room2.ID, "this is verboten"); err != nil {
+		t.Errorf("Expected the rule not to apply in another room, got %v", err)
+	}
+}
+
+// TestFilter_AdminOnly tests that regular members cannot manage filter
+// rules
+func TestFilter_AdminOnly(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	member := createTestUser(t, database, "usr_filt_memb003", "filt-pleb")
+	room := createTestRoom(t, database, "roo_filt_0000005", "filt-locked", false)
+	addUserToRoom(t, database, member.ID, room.ID)
+
+	reqJSON, _ := json.Marshal(protocol.AddRoomFilterRequest{RoomID: room.ID, Pattern: "nope", Action: db.FilterReject})
+	if _, err := api.AddRoomFilter(member, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation adding a rule as a regular member, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// ForwardMessage posts a copy of an existing message into another room the
+// user is a member of, attributed to the forwarder and carrying
+// forwarded_from for the original. Returns a MessageResponse routed to the
+// target room.
+func (a *Api) ForwardMessage(user *models.User, msg json.RawMessage) (*MessageResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.ForwardMessageRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for forward_message request", "error", err)
+		return nil, err
+	}
+
+	if req.MessageID == "" || req.TargetRoomID == "" {
+		return nil, fmt.Errorf("message_id and target_room_id are required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	// The source message must exist, not be deleted, and be readable
+	source, err := models.MessageByID(ctx, a.db, req.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("message %s not found: %w", req.MessageID, ErrValidation)
+	}
+	if source.DeletedAt.Valid && source.DeletedAt.String != "" {
+		return nil, fmt.Errorf("cannot forward a deleted message: %w", ErrValidation)
+	}
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, source.RoomID)
+	if err != nil {
+		a.logger.Error("failed to check room membership", "error", err, "user", user.ID, "room", source.RoomID)
+		return nil, err
+	}
+	if !isMember {
+		return nil, fmt.Errorf("user is not a member of room %s: %w", source.RoomID, ErrNotMember)
+	}
+
+	// The user must also be able to post in the target room
+	isMember, err = db.IsRoomMember(ctx, a.db, user.ID, req.TargetRoomID)
+	if err != nil {
+		a.logger.Error("failed to check room membership", "error", err, "user", user.ID, "room", req.TargetRoomID)
+		return nil, err
+	}
+	if !isMember {
+		return nil, fmt.Errorf("user is not a member of room %s: %w", req.TargetRoomID, ErrNotMember)
+	}
+
+	author, err := models.UserByID(ctx, a.db, source.UserID)
+	if err != nil {
+		a.logger.Error("unable to find source author", "error", err, "user", source.UserID)
+		return nil, err
+	}
+
+	// The source body may be encrypted at rest; decrypt it and re-encrypt
+	// the copy with the active key
+	sourceBody, err := a.db.Cipher.DecryptBody(source.Body, source.EncKeyID)
+	if err != nil {
+		a.logger.Error("unable to decrypt source message body", "error", err, "message", source.ID)
+		return nil, err
+	}
+	storedBody, encKeyID, err := a.db.Cipher.EncryptBody(sourceBody)
+	if err != nil {
+		a.logger.Error("unable to encrypt message body", "error", err)
+		return nil, err
+	}
+
+	now := time.Now().Format(time.RFC3339Nano)
+	dbMessage := models.Message{
+		ID:         models.GenerateMessageID(),
+		RoomID:     req.TargetRoomID,
+		UserID:     user.ID,
+		Body:       storedBody,
+		EncKeyID:   encKeyID,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+	if err = dbMessage.Insert(ctx, a.db); err != nil {
+		a.logger.Error("unable to insert forwarded message", "error", err)
+		return nil, err
+	}
+	if err = db.InsertMessageForward(ctx, a.db, dbMessage.ID, source.ID, source.RoomID, source.UserID); err != nil {
+		a.logger.Error("unable to record forward attribution", "error", err)
+		return nil, err
+	}
+
+	broadcastMsg := protocol.Message{
+		ID:         dbMessage.ID,
+		Body:       sourceBody,
+		RoomID:     dbMessage.RoomID,
+		UserID:     dbMessage.UserID,
+		Username:   user.Username,
+		CreatedAt:  dbMessage.CreatedAt,
+		ModifiedAt: dbMessage.ModifiedAt,
+		ForwardedFrom: &protocol.ForwardedFrom{
+			MessageID: source.ID,
+			RoomID:    source.RoomID,
+			UserID:    source.UserID,
+			Username:  author.Username,
+		},
+	}
+
+	msgBytes, err := json.Marshal(&Envelope{
+		Type: "message",
+		Data: broadcastMsg,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageResponse{
+		RoomID:  req.TargetRoomID,
+		Message: msgBytes,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestForwardMessage_Attribution tests that forwarding copies the message into
+// the target room and carries forwarded_from attribution for the original
+func TestForwardMessage_Attribution(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	author := createTestUser(t, database, "usr_author123456", "author")
+	forwarder := createTestUser(t, database, "usr_forward12345", "forwarder")
+	source := createTestRoom(t, database, "roo_source123456", "source", false)
+	target := createTestRoom(t, database, "roo_target123456", "target", false)
+	addUserToRoom(t, database, author.ID, source.ID)
+	addUserToRoom(t, database, forwarder.ID, source.ID)
+	addUserToRoom(t, database, forwarder.ID, target.ID)
+	original := createTestMessage(t, database, "msg_fwdsrc123456", source.ID, author.ID, "worth sharing", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.ForwardMessageRequest{MessageID: original.ID, TargetRoomID: target.ID})
+	res, err := api.ForwardMessage(forwarder, reqJSON)
+	if err != nil {
+		t.Fatalf("ForwardMessage failed: %v", err)
+	}
+	if res.RoomID != target.ID {
+		t.Errorf("Expected response routed to room %s, got %s", target.ID, res.RoomID)
+	}
+
+	var envelope struct {
+		Type string           `json:"type"`
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(res.Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast message: %v", err)
+	}
+	if envelope.Type != "message" {
+		t.Errorf("Expected message envelope, got %s", envelope.Type)
+	}
+	if envelope.Data.RoomID != target.ID {
+		t.Errorf("Expected forwarded message in room %s, got %s", target.ID, envelope.Data.RoomID)
+	}
+	if envelope.Data.UserID != forwarder.ID {
+		t.Errorf("Expected forwarded message attributed to forwarder %s, got %s", forwarder.ID, envelope.Data.UserID)
+	}
+	if envelope.Data.Body != "worth sharing" {
+		t.Errorf("Expected forwarded body to match original, got %q", envelope.Data.Body)
+	}
+	if envelope.Data.ForwardedFrom == nil {
+		t.Fatal("Expected forwarded_from attribution on the broadcast message")
+	}
+	if envelope.Data.ForwardedFrom.MessageID != original.ID {
+		t.Errorf("Expected forwarded_from message %s, got %s", original.ID, envelope.Data.ForwardedFrom.MessageID)
+	}
+	if envelope.Data.ForwardedFrom.RoomID != source.ID {
+		t.Errorf("Expected forwarded_from room %s, got %s", source.ID, envelope.Data.ForwardedFrom.RoomID)
+	}
+	if envelope.Data.ForwardedFrom.Username != "author" {
+		t.Errorf("Expected forwarded_from username author, got %s", envelope.Data.ForwardedFrom.Username)
+	}
+}
+
+// TestForwardMessage_NotMemberOfTarget tests that a user can't forward into a
+// room they are not a member of
+func TestForwardMessage_NotMemberOfTarget(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	source := createTestRoom(t, database, "roo_source123456", "source", false)
+	target := createTestRoom(t, database, "roo_target123456", "target", false)
+	addUserToRoom(t, database, user.ID, source.ID)
+	original := createTestMessage(t, database, "msg_fwdsrc123456", source.ID, user.ID, "hello", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.ForwardMessageRequest{MessageID: original.ID, TargetRoomID: target.ID})
+	_, err := api.ForwardMessage(user, reqJSON)
+	if err == nil {
+		t.Fatal("Expected ForwardMessage to fail for non-member target room")
+	}
+	if !errors.Is(err, ErrNotMember) {
+		t.Errorf("Expected ErrNotMember, got %v", err)
+	}
+}
+
+// TestForwardMessage_DeletedSource tests that a deleted message can't be
+// forwarded
+func TestForwardMessage_DeletedSource(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	source := createTestRoom(t, database, "roo_source123456", "source", false)
+	target := createTestRoom(t, database, "roo_target123456", "target", false)
+	addUserToRoom(t, database, user.ID, source.ID)
+	addUserToRoom(t, database, user.ID, target.ID)
+	original := createTestMessage(t, database, "msg_fwdsrc123456", source.ID, user.ID, "hello", time.Now())
+
+	original.DeletedAt = sql.NullString{String: time.Now().Format(time.RFC3339Nano), Valid: true}
+	if err := original.Update(context.Background(), database); err != nil {
+		t.Fatalf("Failed to soft-delete message: %v", err)
+	}
+
+	reqJSON, _ := json.Marshal(protocol.ForwardMessageRequest{MessageID: original.ID, TargetRoomID: target.ID})
+	_, err := api.ForwardMessage(user, reqJSON)
+	if err == nil {
+		t.Fatal("Expected ForwardMessage to fail for a deleted source message")
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// GetReactions fetches the full reaction breakdown for one message, e.g.
+// for a reaction detail popover. Unlike the aggregates embedded in history,
+// the user ID lists are returned complete rather than capped; list_reactors
+// remains the paginated path for very large reactor lists.
+func (a *Api) GetReactions(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.GetReactionsRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for get_reactions request", "error", err)
+		return errorEnvelope(protocol.ErrCodeValidation, "invalid get_reactions request"), nil
+	}
+
+	if req.MessageID == "" {
+		return errorEnvelope(protocol.ErrCodeValidation, "message_id is required"), nil
+	}
+
+	ctx := context.Background()
+
+	// The caller must be able to read the message's room
+	message, err := models.MessageByID(ctx, a.db, req.MessageID)
+	if err != nil {
+		return errorEnvelope(protocol.ErrCodeNotFound, "message not found"), nil
+	}
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, message.RoomID)
+	if err != nil {
+		a.logger.Error("failed to check room membership", "error", err, "user", user.ID, "room", message.RoomID)
+		return errorEnvelope(protocol.ErrCodeInternal, "failed to check access"), nil
+	}
+	if !isMember {
+		return errorEnvelope(protocol.ErrCodeNotMember, "not a member of this room"), nil
+	}
+
+	reactionsMap, err := db.GetReactionsForMessages(ctx, a.db, []string{req.MessageID})
+	if err != nil {
+		a.logger.Error("failed to get reactions", "error", err, "message", req.MessageID)
+		return errorEnvelope(protocol.ErrCodeInternal, "failed to get reactions"), nil
+	}
+	markReactedByMe(reactionsMap, user.ID)
+
+	// Return an empty array instead of nil for a message with no reactions
+	reactions := reactionsMap[req.MessageID]
+	if reactions == nil {
+		reactions = []protocol.Reaction{}
+	}
+
+	return &Envelope{
+		Type: "get_reactions",
+		Data: protocol.GetReactionsResponse{
+			MessageID: req.MessageID,
+			Reactions: reactions,
+		},
+	}, nil
+}
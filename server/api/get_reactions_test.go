@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// addReaction marshals and sends an add_reaction request
+func addReaction(t *testing.T, api *Api, user *models.User, messageID, emoji string) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.AddReactionRequest{MessageID: messageID, Emoji: emoji})
+	if _, err := api.AddReaction(user, reqJSON); err != nil {
+		t.Fatalf("AddReaction failed: %v", err)
+	}
+}
+
+// TestGetReactions_Aggregation tests that get_reactions returns the full
+// aggregated breakdown for a message
+func TestGetReactions_Aggregation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_getreact0001", "getreactalice")
+	bob := createTestUser(t, database, "usr_getreact0002", "getreactbob")
+	carol := createTestUser(t, database, "usr_getreact0003", "getreactcarol")
+	room := createTestRoom(t, database, "roo_getreact0001", "getreact", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+	addUserToRoom(t, database, bob.ID, room.ID)
+	addUserToRoom(t, database, carol.ID, room.ID)
+
+	createTestMessage(t, database, "msg_getreact001", room.ID, alice.ID, "react to me", time.Now())
+
+	addReaction(t, api, alice, "msg_getreact001", "👍")
+	addReaction(t, api, bob, "msg_getreact001", "👍")
+	addReaction(t, api, carol, "msg_getreact001", "❤️")
+
+	reqJSON, _ := json.Marshal(protocol.GetReactionsRequest{MessageID: "msg_getreact001"})
+	response, err := api.GetReactions(alice, reqJSON)
+	if err != nil {
+		t.Fatalf("GetReactions failed: %v", err)
+	}
+	if response.Type != "get_reactions" {
+		t.Fatalf("Expected type 'get_reactions', got '%s'", response.Type)
+	}
+	resp, ok := response.Data.(protocol.GetReactionsResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.GetReactionsResponse data type, got %T", response.Data)
+	}
+
+	if resp.MessageID != "msg_getreact001" {
+		t.Errorf("Expected message ID 'msg_getreact001', got '%s'", resp.MessageID)
+	}
+	if len(resp.Reactions) != 2 {
+		t.Fatalf("Expected 2 reactions, got %d", len(resp.Reactions))
+	}
+
+	byEmoji := make(map[string]protocol.Reaction)
+	for _, r := range resp.Reactions {
+		byEmoji[r.Emoji] = r
+	}
+	thumbs := byEmoji["👍"]
+	if thumbs.Count != 2 || len(thumbs.UserIDs) != 2 {
+		t.Errorf("Expected 👍 with 2 reactors, got count %d and IDs %v", thumbs.Count, thumbs.UserIDs)
+	}
+	if !thumbs.ReactedByMe {
+		t.Error("Expected 👍 to be marked reacted-by-me for alice")
+	}
+	heart := byEmoji["❤️"]
+	if heart.Count != 1 || len(heart.UserIDs) != 1 || heart.UserIDs[0] != carol.ID {
+		t.Errorf("Expected ❤️ with carol as the only reactor, got count %d and IDs %v", heart.Count, heart.UserIDs)
+	}
+	if heart.ReactedByMe {
+		t.Error("Expected ❤️ to not be marked reacted-by-me for alice")
+	}
+}
+
+// TestGetReactions_NonMember tests that non-members can't fetch a
+// message's reactions
+func TestGetReactions_NonMember(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	member := createTestUser(t, database, "usr_getreact0011", "getreactmem")
+	outsider := createTestUser(t, database, "usr_getreact0012", "getreactout")
+	room := createTestRoom(t, database, "roo_getreact0011", "getreact-priv", false)
+	addUserToRoom(t, database, member.ID, room.ID)
+
+	createTestMessage(t, database, "msg_getreact011", room.ID, member.ID, "members only", time.Now())
+	addReaction(t, api, member, "msg_getreact011", "👍")
+
+	reqJSON, _ := json.Marshal(protocol.GetReactionsRequest{MessageID: "msg_getreact011"})
+	response, err := api.GetReactions(outsider, reqJSON)
+	if err != nil {
+		t.Fatalf("GetReactions failed: %v", err)
+	}
+	if code := errorEnvelopeCode(t, response); code != protocol.ErrCodeNotMember {
+		t.Errorf("Expected code %q, got %q", protocol.ErrCodeNotMember, code)
+	}
+}
+
+// TestGetReactions_NoReactions tests that a message without reactions
+// returns an empty slice rather than nil
+func TestGetReactions_NoReactions(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_getreact0021", "getreactnone")
+	room := createTestRoom(t, database, "roo_getreact0021", "getreact-none", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	createTestMessage(t, database, "msg_getreact021", room.ID, user.ID, "no reactions", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.GetReactionsRequest{MessageID: "msg_getreact021"})
+	response, err := api.GetReactions(user, reqJSON)
+	if err != nil {
+		t.Fatalf("GetReactions failed: %v", err)
+	}
+	resp, ok := response.Data.(protocol.GetReactionsResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.GetReactionsResponse data type, got %T", response.Data)
+	}
+	if resp.Reactions == nil {
+		t.Error("Expected an empty reactions slice, got nil")
+	}
+	if len(resp.Reactions) != 0 {
+		t.Errorf("Expected 0 reactions, got %d", len(resp.Reactions))
+	}
+}
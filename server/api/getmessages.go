@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxGetMessages caps how many messages one get_messages request may fetch
+const maxGetMessages = 50
+
+// GetMessages fetches a specific set of messages by ID in one batch, for
+// clients resolving forwarded, quoted, or pinned message references.
+// Messages come back in request order; IDs that don't exist or sit in
+// rooms the caller isn't a member of are silently omitted, and deleted
+// messages come back as placeholders with an empty body so clients can
+// render the reference as deleted.
+func (a *Api) GetMessages(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.GetMessagesRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for get_messages request", "error", err)
+		return errorEnvelope(protocol.ErrCodeValidation, "invalid get_messages request"), nil
+	}
+
+	if len(req.MessageIDs) == 0 {
+		return errorEnvelope(protocol.ErrCodeValidation, "message_ids is required"), nil
+	}
+	if len(req.MessageIDs) > maxGetMessages {
+		return errorEnvelope(protocol.ErrCodeValidation, fmt.Sprintf("at most %d messages may be requested", maxGetMessages)), nil
+	}
+
+	ctx := context.Background()
+
+	rows, err := db.GetMessagesByIDs(ctx, a.db, req.MessageIDs)
+	if err != nil {
+		a.logger.Error("failed to get messages", "error", err, "user", user.ID)
+		return errorEnvelope(protocol.ErrCodeInternal, "failed to get messages"), nil
+	}
+
+	// One membership check per distinct room, not per message
+	byID := make(map[string]*db.RoomMessage, len(rows))
+	memberOf := make(map[string]bool)
+	for _, m := range rows {
+		if _, checked := memberOf[m.RoomID]; !checked {
+			isMember, err := db.IsRoomMember(ctx, a.db, user.ID, m.RoomID)
+			if err != nil {
+				a.logger.Error("failed to check room membership", "error", err, "user", user.ID, "room", m.RoomID)
+				return errorEnvelope(protocol.ErrCodeInternal, "failed to check access"), nil
+			}
+			memberOf[m.RoomID] = isMember
+		}
+		if memberOf[m.RoomID] {
+			byID[m.ID] = m
+		}
+	}
+
+	// Reassemble in request order, dropping IDs that didn't resolve
+	messages := make([]*protocol.Message, 0, len(byID))
+	for _, id := range req.MessageIDs {
+		m, ok := byID[id]
+		if !ok {
+			continue
+		}
+		protoMessage := &protocol.Message{
+			ID:         m.ID,
+			RoomID:     m.RoomID,
+			UserID:     m.UserID,
+			Username:   m.Username,
+			Body:       m.Body,
+			CreatedAt:  m.CreatedAt,
+			ModifiedAt: m.ModifiedAt,
+			DeletedAt:  m.DeletedAt,
+			IsSystem:   m.IsSystem != 0,
+		}
+		// Deleted messages are placeholders: the reference survives but
+		// the content is gone
+		if m.DeletedAt != "" {
+			protoMessage.Body = ""
+		}
+		messages = append(messages, protoMessage)
+	}
+
+	return &Envelope{
+		Type: "get_messages",
+		Data: protocol.GetMessagesResponse{Messages: messages},
+	}, nil
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// getMessages sends a get_messages request and returns the response data
+func getMessages(t *testing.T, api *Api, user *models.User, messageIDs []string) protocol.GetMessagesResponse {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.GetMessagesRequest{MessageIDs: messageIDs})
+	response, err := api.GetMessages(user, reqJSON)
+	if err != nil {
+		t.Fatalf("GetMessages failed: %v", err)
+	}
+	if response.Type != "get_messages" {
+		t.Fatalf("Expected type 'get_messages', got '%s'", response.Type)
+	}
+	resp, ok := response.Data.(protocol.GetMessagesResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.GetMessagesResponse data type, got %T", response.Data)
+	}
+	return resp
+}
+
+// TestGetMessages_BatchAndOrder tests that a batch fetch returns the
+// requested messages in request order, not database order
+func TestGetMessages_BatchAndOrder(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_getmsgs00001", "getmsgsalice")
+	room := createTestRoom(t, database, "roo_getmsgs00001", "getmsgs", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+
+	createTestMessage(t, database, "msg_getmsgs0001", room.ID, alice.ID, "first", time.Now())
+	createTestMessage(t, database, "msg_getmsgs0002", room.ID, alice.ID, "second", time.Now())
+	createTestMessage(t, database, "msg_getmsgs0003", room.ID, alice.ID, "third", time.Now())
+
+	resp := getMessages(t, api, alice, []string{"msg_getmsgs0003", "msg_getmsgs0001", "msg_getmsgs0002"})
+	if len(resp.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(resp.Messages))
+	}
+	for i, want := range []string{"third", "first", "second"} {
+		if resp.Messages[i].Body != want {
+			t.Errorf("Expected message %d body %q, got %q", i, want, resp.Messages[i].Body)
+		}
+	}
+}
+
+// TestGetMessages_AccessFiltering tests that messages in rooms the caller
+// isn't a member of, and IDs that don't exist, are omitted
+func TestGetMessages_AccessFiltering(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_getmsgs00011", "getmsgsflt")
+	bob := createTestUser(t, database, "usr_getmsgs00012", "getmsgsbob")
+	shared := createTestRoom(t, database, "roo_getmsgs00011", "getmsgs-shared", false)
+	private := createTestRoom(t, database, "roo_getmsgs00012", "getmsgs-private", false)
+	addUserToRoom(t, database, alice.ID, shared.ID)
+	addUserToRoom(t, database, bob.ID, shared.ID)
+	addUserToRoom(t, database, bob.ID, private.ID)
+
+	createTestMessage(t, database, "msg_getmsgs0011", shared.ID, bob.ID, "visible", time.Now())
+	createTestMessage(t, database, "msg_getmsgs0012", private.ID, bob.ID, "hidden", time.Now())
+
+	resp := getMessages(t, api, alice, []string{"msg_getmsgs0011", "msg_getmsgs0012", "msg_nosuchmsg01"})
+	if len(resp.Messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(resp.Messages))
+	}
+	if resp.Messages[0].ID != "msg_getmsgs0011" {
+		t.Errorf("Expected the shared-room message, got %s", resp.Messages[0].ID)
+	}
+}
+
+// TestGetMessages_DeletedPlaceholder tests that a deleted message comes
+// back as a placeholder with an empty body and deleted_at set
+func TestGetMessages_DeletedPlaceholder(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_getmsgs00021", "getmsgsdel")
+	room := createTestRoom(t, database, "roo_getmsgs00021", "getmsgs-del", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+
+	msgID := createTestMessageSimple(t, api, alice, room.ID, "soon gone")
+	delJSON, _ := json.Marshal(protocol.DeleteMessageRequest{MessageID: msgID})
+	if _, err := api.DeleteMessage(alice, delJSON); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+
+	resp := getMessages(t, api, alice, []string{msgID})
+	if len(resp.Messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(resp.Messages))
+	}
+	if resp.Messages[0].Body != "" {
+		t.Errorf("Expected an empty placeholder body, got %q", resp.Messages[0].Body)
+	}
+	if resp.Messages[0].DeletedAt == "" {
+		t.Error("Expected deleted_at to be set on the placeholder")
+	}
+}
+
+// TestGetMessages_Validation tests that empty and oversized ID lists are
+// rejected
+func TestGetMessages_Validation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_getmsgs00031", "getmsgsval")
+
+	reqJSON, _ := json.Marshal(protocol.GetMessagesRequest{})
+	response, err := api.GetMessages(alice, reqJSON)
+	if err != nil {
+		t.Fatalf("GetMessages failed: %v", err)
+	}
+	if response.Type != "error" {
+		t.Errorf("Expected an error envelope for an empty ID list, got %q", response.Type)
+	}
+
+	ids := make([]string, maxGetMessages+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("msg_%012d", i)
+	}
+	reqJSON, _ = json.Marshal(protocol.GetMessagesRequest{MessageIDs: ids})
+	response, err = api.GetMessages(alice, reqJSON)
+	if err != nil {
+		t.Fatalf("GetMessages failed: %v", err)
+	}
+	if response.Type != "error" {
+		t.Errorf("Expected an error envelope for an oversized ID list, got %q", response.Type)
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// GetUnread handles a request to refresh unread counts without a full
+// re-sync. It reuses the sync aggregate, so the numbers match what a fresh
+// load would show: measured against read positions, with muted rooms and
+// fully-read rooms omitted.
+func (a *Api) GetUnread(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	activity, err := db.GetUnreadCounts(context.Background(), a.db, user.ID)
+	if err != nil {
+		a.logger.Error("failed to get unread counts", "error", err, "user", user.ID)
+		return nil, err
+	}
+
+	unread := make(map[string]int, len(activity))
+	total := 0
+	for _, room := range activity {
+		unread[room.RoomID] = room.Count
+		total += room.Count
+	}
+
+	return &Envelope{
+		Type: "get_unread",
+		Data: protocol.GetUnreadResponse{
+			Unread: unread,
+			Total:  total,
+		},
+	}, nil
+}
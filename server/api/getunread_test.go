@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// getUnread is a test helper to call GetUnread and unpack the response
+func getUnread(t *testing.T, api *Api, user *models.User) protocol.GetUnreadResponse {
+	t.Helper()
+	env, err := api.GetUnread(user, nil)
+	if err != nil {
+		t.Fatalf("GetUnread failed: %v", err)
+	}
+	resp, ok := env.Data.(protocol.GetUnreadResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.GetUnreadResponse data type, got %T", env.Data)
+	}
+	return resp
+}
+
+// TestGetUnread tests that the on-demand unread counts reflect messages
+// past the read position, respect mutes, and omit fully-read rooms
+func TestGetUnread(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	reader := createTestUser(t, database, "usr_unread000001", "unreadreader")
+	sender := createTestUser(t, database, "usr_unread000002", "unreadsender")
+	busy := createTestRoom(t, database, "roo_unread000001", "unread-busy", true)
+	muted := createTestRoom(t, database, "roo_unread000002", "unread-muted", false)
+	quiet := createTestRoom(t, database, "roo_unread000003", "unread-quiet", false)
+	for _, room := range []*models.Room{busy, muted, quiet} {
+		addUserToRoom(t, database, reader.ID, room.ID)
+		addUserToRoom(t, database, sender.ID, room.ID)
+	}
+
+	now := time.Now()
+	createTestMessage(t, database, "msg_unread000001", busy.ID, sender.ID, "one", now.Add(-3*time.Minute))
+	createTestMessage(t, database, "msg_unread000002", busy.ID, sender.ID, "two", now.Add(-2*time.Minute))
+	createTestMessage(t, database, "msg_unread000003", muted.ID, sender.ID, "three", now.Add(-2*time.Minute))
+	read := createTestMessage(t, database, "msg_unread000004", quiet.ID, sender.ID, "four", now.Add(-2*time.Minute))
+
+	// The quiet room is fully read; the muted room shouldn't count at all
+	markRead(t, api, reader, quiet.ID, read.ID)
+	_, err := database.ExecContext(context.Background(),
+		"UPDATE rooms_members SET muted = 1 WHERE user_id = ? AND room_id = ?", reader.ID, muted.ID)
+	if err != nil {
+		t.Fatalf("Failed to mute room: %v", err)
+	}
+
+	resp := getUnread(t, api, reader)
+	if got := resp.Unread[busy.ID]; got != 2 {
+		t.Errorf("Expected 2 unread in the busy room, got %d", got)
+	}
+	if _, ok := resp.Unread[muted.ID]; ok {
+		t.Error("Expected the muted room to be omitted from unread counts")
+	}
+	if _, ok := resp.Unread[quiet.ID]; ok {
+		t.Error("Expected the fully-read room to be omitted from unread counts")
+	}
+	if resp.Total != 2 {
+		t.Errorf("Expected total 2, got %d", resp.Total)
+	}
+
+	// A new message moves the count; reading up to it zeroes the room out
+	latest := createTestMessage(t, database, "msg_unread000005", busy.ID, sender.ID, "five", now.Add(-time.Minute))
+	if resp := getUnread(t, api, reader); resp.Unread[busy.ID] != 3 {
+		t.Errorf("Expected 3 unread after a new message, got %d", resp.Unread[busy.ID])
+	}
+	markRead(t, api, reader, busy.ID, latest.ID)
+	resp = getUnread(t, api, reader)
+	if len(resp.Unread) != 0 || resp.Total != 0 {
+		t.Errorf("Expected no unread rooms after reading everything, got %+v", resp)
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// HideDM handles a request to remove a DM conversation from the user's
+// sidebar. Hiding is per-user and never deletes the shared room or its
+// messages; a new message in the DM un-hides it for everyone.
+func (a *Api) HideDM(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.HideDMRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for hide_dm", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	// Only DMs can be hidden; channels leave the sidebar via leave_room
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		return nil, fmt.Errorf("room %s not found: %w", req.RoomID, ErrValidation)
+	}
+	if room.RoomType != "dm" {
+		return nil, fmt.Errorf("room %s is not a DM: %w", req.RoomID, ErrValidation)
+	}
+
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotMember
+	}
+
+	if _, err := db.HideDM(ctx, a.db, user.ID, req.RoomID); err != nil {
+		a.logger.Error("failed to hide DM", "error", err, "user", user.ID, "room", req.RoomID)
+		return nil, err
+	}
+
+	return &Envelope{
+		Type: "hide_dm",
+		Data: protocol.HideDMResponse{RoomID: req.RoomID},
+	}, nil
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// initDMIDs runs init for a user and returns the IDs of the DMs in their
+// sidebar
+func initDMIDs(t *testing.T, api *Api, user *models.User) []string {
+	t.Helper()
+	result, err := api.InitMessage(user, nil)
+	if err != nil {
+		t.Fatalf("InitMessage failed: %v", err)
+	}
+	initResp, ok := result.Envelope.Data.(protocol.InitResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.InitResponse data type, got %T", result.Envelope.Data)
+	}
+	ids := make([]string, len(initResp.DMs))
+	for i, dm := range initResp.DMs {
+		ids[i] = dm.ID
+	}
+	return ids
+}
+
+// hideDM marshals and sends a hide_dm request
+func hideDM(t *testing.T, api *Api, user *models.User, roomID string) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.HideDMRequest{RoomID: roomID})
+	response, err := api.HideDM(user, reqJSON)
+	if err != nil {
+		t.Fatalf("HideDM failed: %v", err)
+	}
+	if response.Type != "hide_dm" {
+		t.Errorf("Expected type 'hide_dm', got '%s'", response.Type)
+	}
+}
+
+// containsID reports whether ids contains id
+func containsID(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// TestHideDM_RemovedFromSidebar tests that a hidden DM disappears from the
+// hider's init DM list while the other participant still sees it
+func TestHideDM_RemovedFromSidebar(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_hidedm000001", "hidedmalice")
+	bob := createTestUser(t, database, "usr_hidedm000002", "hidedmbob")
+	defaultRoom := createTestRoom(t, database, "roo_hidedm000000", "hidedm-default", true)
+	addUserToRoom(t, database, alice.ID, defaultRoom.ID)
+	addUserToRoom(t, database, bob.ID, defaultRoom.ID)
+
+	dm := createTestDM(t, database, "roo_hidedm000001")
+	addUserToRoom(t, database, alice.ID, dm.ID)
+	addUserToRoom(t, database, bob.ID, dm.ID)
+
+	if !containsID(initDMIDs(t, api, alice), dm.ID) {
+		t.Fatal("Expected the DM in alice's sidebar before hiding")
+	}
+
+	hideDM(t, api, alice, dm.ID)
+
+	if containsID(initDMIDs(t, api, alice), dm.ID) {
+		t.Error("Expected the hidden DM to be excluded from alice's sidebar")
+	}
+	// Hiding is per-user: the other participant is unaffected
+	if !containsID(initDMIDs(t, api, bob), dm.ID) {
+		t.Error("Expected the DM to remain in bob's sidebar")
+	}
+
+	// The shared room and its membership are untouched
+	if _, err := models.RoomByID(context.Background(), database, dm.ID); err != nil {
+		t.Errorf("Expected the DM room to still exist: %v", err)
+	}
+}
+
+// TestHideDM_NewMessageUnhides tests that a message in a hidden DM brings
+// it back into the sidebar
+func TestHideDM_NewMessageUnhides(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_hidedm000011", "hidedmalice2")
+	bob := createTestUser(t, database, "usr_hidedm000012", "hidedmbob2")
+	defaultRoom := createTestRoom(t, database, "roo_hidedm000010", "hidedm-default2", true)
+	addUserToRoom(t, database, alice.ID, defaultRoom.ID)
+	addUserToRoom(t, database, bob.ID, defaultRoom.ID)
+
+	dm := createTestDM(t, database, "roo_hidedm000011")
+	addUserToRoom(t, database, alice.ID, dm.ID)
+	addUserToRoom(t, database, bob.ID, dm.ID)
+
+	hideDM(t, api, alice, dm.ID)
+	if containsID(initDMIDs(t, api, alice), dm.ID) {
+		t.Fatal("Expected the hidden DM to be excluded from alice's sidebar")
+	}
+
+	if _, err := sendMessage(t, api, bob, dm.ID, "hello again"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	if !containsID(initDMIDs(t, api, alice), dm.ID) {
+		t.Error("Expected a new message to un-hide the DM in alice's sidebar")
+	}
+}
+
+// TestHideDM_Validation tests that only DMs the user belongs to can be
+// hidden
+func TestHideDM_Validation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_hidedm000021", "hidedmalice3")
+	channel := createTestRoom(t, database, "roo_hidedm000021", "hidedm-channel", false)
+	addUserToRoom(t, database, alice.ID, channel.ID)
+
+	// Channels can't be hidden; leaving is the way out of those
+	reqJSON, _ := json.Marshal(protocol.HideDMRequest{RoomID: channel.ID})
+	if _, err := api.HideDM(alice, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation hiding a channel, got %v", err)
+	}
+
+	// Nor can DMs the user isn't a participant of
+	dm := createTestDM(t, database, "roo_hidedm000022")
+	reqJSON, _ = json.Marshal(protocol.HideDMRequest{RoomID: dm.ID})
+	if _, err := api.HideDM(alice, reqJSON); !errors.Is(err, ErrNotMember) {
+		t.Errorf("Expected ErrNotMember hiding a DM alice isn't in, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
@@ -48,13 +49,27 @@ func (a *Api) HistoryMessage(user *models.User, msg json.RawMessage) (*Envelope,
 	}
 	if !isMember {
 		a.logger.Warn("user attempted to fetch history for room they are not a member of", "user", user.ID, "room", req.RoomID)
-		return nil, fmt.Errorf("user is not a member of room %s", req.RoomID)
+		return nil, fmt.Errorf("user is not a member of room %s: %w", req.RoomID, ErrNotMember)
 	}
 
+	page, err := a.historyPage(ctx, user, req.RoomID, req.Cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Type: "history",
+		Data: *page,
+	}, nil
+}
+
+// historyPage fetches one enriched page of history for a room the caller
+// has already verified the user can access
+func (a *Api) historyPage(ctx context.Context, user *models.User, roomID, cursor string, limit int) (*protocol.HistoryResponse, error) {
 	// Fetch one extra message to determine if there are more
-	messages, err := db.GetRoomMessages(ctx, a.db, req.RoomID, req.Cursor, limit+1)
+	messages, err := db.GetRoomMessages(ctx, a.db, roomID, cursor, limit+1)
 	if err != nil {
-		a.logger.Error("failed to get room messages", "error", err, "room", req.RoomID)
+		a.logger.Error("failed to get room messages", "error", err, "room", roomID)
 		return nil, err
 	}
 
@@ -77,35 +92,116 @@ func (a *Api) HistoryMessage(user *models.User, msg json.RawMessage) (*Envelope,
 		// Don't fail the whole request — just continue without reactions
 		reactionsMap = make(map[string][]protocol.Reaction)
 	}
+	markReactedByMe(reactionsMap, user.ID)
+	capReactionUserIDs(reactionsMap)
+
+	// Batch-load attachment metadata for all messages
+	attachmentsMap, err := db.GetAttachmentsForMessages(ctx, a.db, messageIDs)
+	if err != nil {
+		a.logger.Error("failed to get attachments", "error", err)
+		// Don't fail the whole request — just continue without attachments
+		attachmentsMap = make(map[string][]protocol.Attachment)
+	}
+
+	// Batch-load forward attribution for all messages
+	forwardsMap, err := db.GetForwardsForMessages(ctx, a.db, messageIDs)
+	if err != nil {
+		a.logger.Error("failed to get forwards", "error", err)
+		// Don't fail the whole request — just continue without attribution
+		forwardsMap = make(map[string]*protocol.ForwardedFrom)
+	}
+
+	// Batch-load quote snapshots for all messages
+	quotesMap, err := db.GetQuotesForMessages(ctx, a.db, messageIDs)
+	if err != nil {
+		a.logger.Error("failed to get quotes", "error", err)
+		// Don't fail the whole request — just continue without quotes
+		quotesMap = make(map[string]*protocol.QuotedMessage)
+	}
+
+	// Batch-load seen-by lists; nil for rooms over the member threshold
+	seenByMap := a.seenByForMessages(ctx, roomID, messageIDs)
 
 	// Convert to protocol.Message format
 	historyMessages := make([]*protocol.Message, len(messages))
 	for i, m := range messages {
 		historyMessages[i] = &protocol.Message{
-			ID:         m.ID,
-			RoomID:     m.RoomID,
-			UserID:     m.UserID,
-			Username:   m.Username,
-			Body:       m.Body,
-			CreatedAt:  m.CreatedAt,
-			ModifiedAt: m.ModifiedAt,
-			DeletedAt:  m.DeletedAt,
-			Reactions:  reactionsMap[m.ID],
+			ID:            m.ID,
+			RoomID:        m.RoomID,
+			UserID:        m.UserID,
+			Username:      m.Username,
+			Body:          m.Body,
+			CreatedAt:     m.CreatedAt,
+			ModifiedAt:    m.ModifiedAt,
+			DeletedAt:     m.DeletedAt,
+			IsSystem:      m.IsSystem != 0,
+			Reactions:     reactionsMap[m.ID],
+			Attachments:   attachmentsMap[m.ID],
+			SeenBy:        seenByMap[m.ID],
+			ForwardedFrom: forwardsMap[m.ID],
+			Quote:         quotesMap[m.ID],
 		}
 	}
 
-	// Calculate next cursor (oldest message's created_at)
+	// Calculate next cursor (oldest message's sequence number)
 	var nextCursor string
 	if len(messages) > 0 && hasMore {
-		nextCursor = messages[len(messages)-1].CreatedAt
+		nextCursor = strconv.FormatInt(messages[len(messages)-1].Seq, 10)
 	}
 
-	return &Envelope{
-		Type: "history",
-		Data: protocol.HistoryResponse{
-			Messages:   historyMessages,
-			HasMore:    hasMore,
-			NextCursor: nextCursor,
-		},
+	// Surface the user's read position when it falls inside this page so the
+	// client can draw its new-messages divider. Messages are newest-first, so
+	// a boundary at index 0 means the whole page is read and there's nothing
+	// to mark.
+	unreadBoundaryID := ""
+	lastRead, err := db.GetLastRead(ctx, a.db, user.ID, roomID)
+	if err != nil {
+		a.logger.Error("failed to get read position", "error", err, "user", user.ID, "room", roomID)
+	} else if lastRead != "" {
+		for i := 1; i < len(messages); i++ {
+			if messages[i].ID == lastRead {
+				unreadBoundaryID = lastRead
+				break
+			}
+		}
+	}
+
+	return &protocol.HistoryResponse{
+		Messages:         historyMessages,
+		HasMore:          hasMore,
+		NextCursor:       nextCursor,
+		UnreadBoundaryID: unreadBoundaryID,
 	}, nil
 }
+
+// maxInlineReactors caps the user IDs embedded inline in a reaction
+// aggregate; the full list is available on demand via list_reactors
+const maxInlineReactors = 20
+
+// capReactionUserIDs truncates each aggregate's inline user ID list to
+// maxInlineReactors. Count stays the true total, and ReactedByMe must already
+// be set since the caller may not be among the retained IDs.
+func capReactionUserIDs(reactionsMap map[string][]protocol.Reaction) {
+	for _, reactions := range reactionsMap {
+		for i := range reactions {
+			if len(reactions[i].UserIDs) > maxInlineReactors {
+				reactions[i].UserIDs = reactions[i].UserIDs[:maxInlineReactors]
+			}
+		}
+	}
+}
+
+// markReactedByMe sets the ReactedByMe flag on every aggregated reaction the
+// requesting user participated in
+func markReactedByMe(reactionsMap map[string][]protocol.Reaction, userID string) {
+	for _, reactions := range reactionsMap {
+		for i := range reactions {
+			for _, id := range reactions[i].UserIDs {
+				if id == userID {
+					reactions[i].ReactedByMe = true
+					break
+				}
+			}
+		}
+	}
+}
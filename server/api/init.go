@@ -36,6 +36,7 @@ func (a *Api) InitMessage(user *models.User, msg json.RawMessage) (*InitResult,
 			Name:      r.Name,
 			RoomType:  r.RoomType,
 			IsPrivate: r.IsPrivate != 0,
+			Topic:     r.Topic,
 		}
 	}
 
@@ -46,9 +47,28 @@ func (a *Api) InitMessage(user *models.User, msg json.RawMessage) (*InitResult,
 		return nil, err
 	}
 
-	// Convert DMs to protocol types with members populated
-	dms := make([]*protocol.Room, len(dbDMs))
-	for i, r := range dbDMs {
+	// DMs the user has removed from their sidebar
+	hidden, err := db.GetHiddenDMs(ctx, a.db, user.ID)
+	if err != nil {
+		a.logger.Error("failed to get hidden DMs", "error", err)
+		return nil, err
+	}
+
+	// The user's custom display labels for DMs
+	labels, err := db.GetDMLabels(ctx, a.db, user.ID)
+	if err != nil {
+		a.logger.Error("failed to get DM labels", "error", err)
+		return nil, err
+	}
+
+	// Convert DMs to protocol types with members populated, skipping hidden
+	// ones
+	dms := make([]*protocol.Room, 0, len(dbDMs))
+	for _, r := range dbDMs {
+		if hidden[r.ID] {
+			continue
+		}
+
 		// Get members for this DM room
 		members, err := a.getRoomMembers(ctx, r.ID)
 		if err != nil {
@@ -56,13 +76,23 @@ func (a *Api) InitMessage(user *models.User, msg json.RawMessage) (*InitResult,
 			return nil, err
 		}
 
-		dms[i] = &protocol.Room{
-			ID:        r.ID,
-			Name:      r.Name,
-			RoomType:  r.RoomType,
-			IsPrivate: r.IsPrivate != 0,
-			Members:   members,
+		// The display name is derived from the other members, unless the
+		// user has set a custom label for this DM
+		name := r.Name
+		displayName := dmDisplayName(user.ID, members)
+		if label, ok := labels[r.ID]; ok {
+			name = label
+			displayName = label
 		}
+
+		dms = append(dms, &protocol.Room{
+			ID:          r.ID,
+			Name:        name,
+			DisplayName: displayName,
+			RoomType:    r.RoomType,
+			IsPrivate:   r.IsPrivate != 0,
+			Members:     members,
+		})
 	}
 
 	// Determine the user's current room - use last_room if valid, otherwise default room
@@ -87,6 +117,21 @@ func (a *Api) InitMessage(user *models.User, msg json.RawMessage) (*InitResult,
 		currentRoom = defaultRoom.ID
 	}
 
+	// The rooms the user pinned to the top of the sidebar, in their order
+	favorites, err := db.GetFavoriteRooms(ctx, a.db, user.ID)
+	if err != nil {
+		a.logger.Error("failed to get favorites", "error", err)
+		return nil, err
+	}
+
+	// The user's stored display preferences, so clients can apply them
+	// without a separate round trip
+	prefs, err := a.loadPrefs(ctx, user.ID)
+	if err != nil {
+		a.logger.Error("failed to load prefs", "error", err)
+		return nil, err
+	}
+
 	return &InitResult{
 		Envelope: &Envelope{
 			Type: "init",
@@ -101,6 +146,8 @@ func (a *Api) InitMessage(user *models.User, msg json.RawMessage) (*InitResult,
 				Rooms:       rooms,
 				DMs:         dms,
 				CurrentRoom: currentRoom,
+				Favorites:   favorites,
+				Prefs:       prefs,
 			},
 		},
 		CurrentRoom: currentRoom,
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// requestToJoin files a pending join request for a request-to-join room and
+// prepares a join_requested notice for each of the room's owners and admins
+func (a *Api) requestToJoin(ctx context.Context, user *models.User, room *models.Room) (*JoinRoomResult, error) {
+	filed, err := db.InsertJoinRequest(ctx, a.db, user.ID, room.ID)
+	if err != nil {
+		a.logger.Error("failed to insert join request", "error", err, "user", user.ID, "room", room.ID)
+		return nil, err
+	}
+
+	// Only notify admins the first time; resubmitting an already-pending
+	// request just re-confirms it to the requester
+	var notices []AddedMember
+	if filed {
+		admins, err := db.RoomAdmins(ctx, a.db, room.ID)
+		if err != nil {
+			a.logger.Error("failed to get room admins", "error", err, "room", room.ID)
+			return nil, err
+		}
+		noticeBytes, err := json.Marshal(&Envelope{
+			Type: "join_requested",
+			Data: protocol.JoinRequested{
+				RoomID:   room.ID,
+				RoomName: room.Name,
+				UserID:   user.ID,
+				Username: user.Username,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, adminID := range admins {
+			notices = append(notices, AddedMember{UserID: adminID, Message: noticeBytes})
+		}
+		a.logger.Info("join requested", "user", user.ID, "room", room.ID, "admins_notified", len(admins))
+	}
+
+	return &JoinRoomResult{
+		Envelope: &Envelope{
+			Type: "join_room",
+			Data: protocol.JoinRoomResponse{
+				Room: protocol.Room{
+					ID:        room.ID,
+					Name:      room.Name,
+					RoomType:  room.RoomType,
+					IsPrivate: room.IsPrivate != 0,
+				},
+				Joined:  false,
+				Pending: true,
+			},
+		},
+		RoomID:       room.ID,
+		Pending:      true,
+		AdminNotices: notices,
+	}, nil
+}
+
+// requireRoomAdmin verifies that a user is an owner or admin of a room
+func (a *Api) requireRoomAdmin(ctx context.Context, user *models.User, roomID string) error {
+	role, err := db.GetMemberRole(ctx, a.db, user.ID, roomID)
+	if err != nil {
+		a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", roomID)
+		return err
+	}
+	if role != db.RoleOwner && role != db.RoleAdmin {
+		a.logger.Warn("user attempted an admin-only action without permission", "user", user.ID, "room", roomID, "role", role)
+		return fmt.Errorf("this action requires a room owner or admin: %w", ErrValidation)
+	}
+	return nil
+}
+
+// ApproveJoinRequestResult contains the approve_join_request response
+// envelope and the member_changed announcement for the new member
+type ApproveJoinRequestResult struct {
+	Envelope *Envelope
+	RoomID   string
+	Added    AddedMember
+}
+
+// ApproveJoinRequest handles an owner or admin approving a pending join
+// request: the request is removed and the user added as a member
+func (a *Api) ApproveJoinRequest(user *models.User, msg json.RawMessage) (*ApproveJoinRequestResult, error) {
+	var req protocol.ApproveJoinRequestRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid approve_join_request json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" || req.UserID == "" {
+		return nil, fmt.Errorf("room_id and user_id are required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found: %w", ErrValidation)
+	}
+	if err := a.requireRoomAdmin(ctx, user, room.ID); err != nil {
+		return nil, err
+	}
+
+	requester, err := models.UserByID(ctx, a.db, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %s: %w", req.UserID, ErrValidation)
+	}
+
+	removed, err := db.DeleteJoinRequest(ctx, a.db, requester.ID, room.ID)
+	if err != nil {
+		a.logger.Error("failed to delete join request", "error", err, "user", requester.ID, "room", room.ID)
+		return nil, err
+	}
+	if !removed {
+		return nil, fmt.Errorf("no pending join request for this user: %w", ErrValidation)
+	}
+
+	if _, err := db.AddRoomMember(ctx, a.db, requester.ID, room.ID); err != nil {
+		a.logger.Error("failed to add room member", "error", err, "user", requester.ID, "room", room.ID)
+		return nil, err
+	}
+
+	msgBytes, err := json.Marshal(&Envelope{
+		Type: "member_changed",
+		Data: protocol.MemberChanged{
+			RoomID: room.ID,
+			Member: protocol.RoomMember{
+				ID:          requester.ID,
+				Username:    requester.Username,
+				DisplayName: requester.DisplayName,
+				Avatar:      requester.Avatar.String,
+			},
+			Change: "added",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Info("join request approved", "user", requester.ID, "room", room.ID, "by", user.ID)
+
+	return &ApproveJoinRequestResult{
+		Envelope: &Envelope{
+			Type: "approve_join_request",
+			Data: protocol.ApproveJoinRequestResponse{
+				RoomID: room.ID,
+				UserID: requester.ID,
+			},
+		},
+		RoomID: room.ID,
+		Added:  AddedMember{UserID: requester.ID, Message: msgBytes},
+	}, nil
+}
+
+// DenyJoinRequest handles an owner or admin denying a pending join
+// request, removing it without adding the user
+func (a *Api) DenyJoinRequest(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.DenyJoinRequestRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid deny_join_request json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" || req.UserID == "" {
+		return nil, fmt.Errorf("room_id and user_id are required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found: %w", ErrValidation)
+	}
+	if err := a.requireRoomAdmin(ctx, user, room.ID); err != nil {
+		return nil, err
+	}
+
+	removed, err := db.DeleteJoinRequest(ctx, a.db, req.UserID, room.ID)
+	if err != nil {
+		a.logger.Error("failed to delete join request", "error", err, "user", req.UserID, "room", room.ID)
+		return nil, err
+	}
+	if !removed {
+		return nil, fmt.Errorf("no pending join request for this user: %w", ErrValidation)
+	}
+
+	a.logger.Info("join request denied", "user", req.UserID, "room", room.ID, "by", user.ID)
+
+	return &Envelope{
+		Type: "deny_join_request",
+		Data: protocol.DenyJoinRequestResponse{
+			RoomID: room.ID,
+			UserID: req.UserID,
+		},
+	}, nil
+}
+
+// ListJoinRequests handles an owner or admin listing a room's pending
+// join requests
+func (a *Api) ListJoinRequests(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.ListJoinRequestsRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid list_join_requests json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found: %w", ErrValidation)
+	}
+	if err := a.requireRoomAdmin(ctx, user, room.ID); err != nil {
+		return nil, err
+	}
+
+	pending, err := db.ListJoinRequests(ctx, a.db, room.ID)
+	if err != nil {
+		a.logger.Error("failed to list join requests", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	requests := make([]protocol.JoinRequestInfo, 0, len(pending))
+	for _, p := range pending {
+		requests = append(requests, protocol.JoinRequestInfo{
+			UserID:      p.UserID,
+			Username:    p.Username,
+			DisplayName: p.DisplayName,
+			CreatedAt:   p.CreatedAt,
+		})
+	}
+
+	return &Envelope{
+		Type: "list_join_requests",
+		Data: protocol.ListJoinRequestsResponse{
+			RoomID:   room.ID,
+			Requests: requests,
+		},
+	}, nil
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// setJoinPolicy sets a room's join policy directly in the database
+func setJoinPolicy(t *testing.T, room *models.Room, api *Api, policy string) {
+	t.Helper()
+	room.JoinPolicy = policy
+	if err := room.Update(context.Background(), api.db); err != nil {
+		t.Fatalf("Failed to set join policy: %v", err)
+	}
+}
+
+// TestJoinRequest_PendingNotMembership tests that joining a request-to-join
+// room files a pending request instead of adding the user, and notifies the
+// room's admins
+func TestJoinRequest_PendingNotMembership(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_jreq_own0001", "jreq-owner")
+	joiner := createTestUser(t, database, "usr_jreq_join001", "jreq-joiner")
+	room := createTestRoom(t, database, "roo_jreq_0000001", "jreq-gated", false)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+	setJoinPolicy(t, room, api, db.JoinPolicyRequest)
+
+	reqJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: room.ID})
+	result, err := api.JoinRoom(joiner, reqJSON)
+	if err != nil {
+		t.Fatalf("Expected join request to be filed, got %v", err)
+	}
+	if !result.Pending || result.Joined {
+		t.Errorf("Expected pending=true joined=false, got pending=%v joined=%v", result.Pending, result.Joined)
+	}
+
+	ctx := context.Background()
+	isMember, err := db.IsRoomMember(ctx, database, joiner.ID, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to check membership: %v", err)
+	}
+	if isMember {
+		t.Error("Expected the user not to be a member while the request is pending")
+	}
+	pending, err := db.HasJoinRequest(ctx, database, joiner.ID, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to check join request: %v", err)
+	}
+	if !pending {
+		t.Error("Expected a pending join request to be recorded")
+	}
+
+	// The room's owner gets a join_requested notice
+	if len(result.AdminNotices) != 1 || result.AdminNotices[0].UserID != owner.ID {
+		t.Fatalf("Expected one admin notice for the owner, got %+v", result.AdminNotices)
+	}
+	var envelope struct {
+		Type string                 `json:"type"`
+		Data protocol.JoinRequested `json:"data"`
+	}
+	if err := json.Unmarshal(result.AdminNotices[0].Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal admin notice: %v", err)
+	}
+	if envelope.Type != "join_requested" || envelope.Data.UserID != joiner.ID {
+		t.Errorf("Expected a join_requested notice for %s, got %+v", joiner.ID, envelope)
+	}
+
+	// Resubmitting is idempotent and doesn't renotify the admins
+	result, err = api.JoinRoom(joiner, reqJSON)
+	if err != nil {
+		t.Fatalf("Expected resubmitting a join request to succeed, got %v", err)
+	}
+	if !result.Pending || len(result.AdminNotices) != 0 {
+		t.Errorf("Expected resubmission to stay pending with no new notices, got %+v", result)
+	}
+}
+
+// TestJoinRequest_ApproveGrantsMembership tests that approving a pending
+// request adds the user as a member and removes the request
+func TestJoinRequest_ApproveGrantsMembership(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_jreq_own0002", "jreq-approver")
+	joiner := createTestUser(t, database, "usr_jreq_join002", "jreq-hopeful")
+	room := createTestRoom(t, database, "roo_jreq_0000002", "jreq-approve", false)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+	setJoinPolicy(t, room, api, db.JoinPolicyRequest)
+
+	joinJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: room.ID})
+	if _, err := api.JoinRoom(joiner, joinJSON); err != nil {
+		t.Fatalf("Failed to file join request: %v", err)
+	}
+
+	approveJSON, _ := json.Marshal(protocol.ApproveJoinRequestRequest{RoomID: room.ID, UserID: joiner.ID})
+	result, err := api.ApproveJoinRequest(owner, approveJSON)
+	if err != nil {
+		t.Fatalf("Expected approval to succeed, got %v", err)
+	}
+	if result.Added.UserID != joiner.ID {
+		t.Errorf("Expected member_changed for %s, got %s", joiner.ID, result.Added.UserID)
+	}
+
+	ctx := context.Background()
+	isMember, err := db.IsRoomMember(ctx, database, joiner.ID, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to check membership: %v", err)
+	}
+	if !isMember {
+		t.Error("Expected the user to be a member after approval")
+	}
+	pending, err := db.HasJoinRequest(ctx, database, joiner.ID, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to check join request: %v", err)
+	}
+	if pending {
+		t.Error("Expected the join request to be removed after approval")
+	}
+
+	// Approving again fails: there is no longer a pending request
+	if _, err := api.ApproveJoinRequest(owner, approveJSON); err == nil {
+		t.Error("Expected approving an already-approved request to fail")
+	}
+}
+
+// TestJoinRequest_DenyRemovesRequest tests that denying a pending request
+// removes it without granting membership
+func TestJoinRequest_DenyRemovesRequest(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_jreq_own0003", "jreq-denier")
+	joiner := createTestUser(t, database, "usr_jreq_join003", "jreq-denied")
+	room := createTestRoom(t, database, "roo_jreq_0000003", "jreq-deny", false)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+	setJoinPolicy(t, room, api, db.JoinPolicyRequest)
+
+	joinJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: room.ID})
+	if _, err := api.JoinRoom(joiner, joinJSON); err != nil {
+		t.Fatalf("Failed to file join request: %v", err)
+	}
+
+	denyJSON, _ := json.Marshal(protocol.DenyJoinRequestRequest{RoomID: room.ID, UserID: joiner.ID})
+	if _, err := api.DenyJoinRequest(owner, denyJSON); err != nil {
+		t.Fatalf("Expected denial to succeed, got %v", err)
+	}
+
+	ctx := context.Background()
+	isMember, err := db.IsRoomMember(ctx, database, joiner.ID, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to check membership: %v", err)
+	}
+	if isMember {
+		t.Error("Expected the user not to be a member after denial")
+	}
+	pending, err := db.HasJoinRequest(ctx, database, joiner.ID, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to check join request: %v", err)
+	}
+	if pending {
+		t.Error("Expected the join request to be removed after denial")
+	}
+}
+
+// TestJoinRequest_AdminOnly tests that regular members cannot approve,
+// deny, or list join requests
+func TestJoinRequest_AdminOnly(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	member := createTestUser(t, database, "usr_jreq_mem0001", "jreq-member")
+	joiner := createTestUser(t, database, "usr_jreq_join004", "jreq-waiting")
+	room := createTestRoom(t, database, "roo_jreq_0000004", "jreq-adminonly", false)
+	addUserToRoom(t, database, member.ID, room.ID)
+	setJoinPolicy(t, room, api, db.JoinPolicyRequest)
+
+	joinJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: room.ID})
+	if _, err := api.JoinRoom(joiner, joinJSON); err != nil {
+		t.Fatalf("Failed to file join request: %v", err)
+	}
+
+	approveJSON, _ := json.Marshal(protocol.ApproveJoinRequestRequest{RoomID: room.ID, UserID: joiner.ID})
+	if _, err := api.ApproveJoinRequest(member, approveJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation approving as a regular member, got %v", err)
+	}
+	denyJSON, _ := json.Marshal(protocol.DenyJoinRequestRequest{RoomID: room.ID, UserID: joiner.ID})
+	if _, err := api.DenyJoinRequest(member, denyJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation denying as a regular member, got %v", err)
+	}
+	listJSON, _ := json.Marshal(protocol.ListJoinRequestsRequest{RoomID: room.ID})
+	if _, err := api.ListJoinRequests(member, listJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation listing as a regular member, got %v", err)
+	}
+
+	// The pending request is untouched
+	pending, err := db.HasJoinRequest(context.Background(), database, joiner.ID, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to check join request: %v", err)
+	}
+	if !pending {
+		t.Error("Expected the join request to remain pending")
+	}
+}
@@ -17,12 +17,22 @@ type JoinRoomResult struct {
 	RoomID   string
 	// Joined is true if the user was added as a new member
 	Joined bool
+	// Pending is true when the room requires approval and a join request
+	// was filed instead of adding the user
+	Pending bool
+	// AdminNotices carries one join_requested envelope per room owner and
+	// admin, for the client layer to deliver via sendToUser
+	AdminNotices []AddedMember
 }
 
 // JoinRoom handles a request from the client to switch to a different room.
 // If the user is not a member of a public room, they will be added as a member.
 // Private rooms require existing membership.
 func (a *Api) JoinRoom(user *models.User, msg json.RawMessage) (*JoinRoomResult, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.JoinRoomRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		a.logger.Error("invalid join_room json", "error", err)
@@ -57,6 +67,23 @@ func (a *Api) JoinRoom(user *models.User, msg json.RawMessage) (*JoinRoomResult,
 			return nil, fmt.Errorf("cannot join private room without an invite")
 		}
 
+		// Invite-only rooms can't be self-joined even when public
+		if room.JoinPolicy == db.JoinPolicyInvite {
+			a.logger.Warn("user attempted to join invite-only room", "user", user.ID, "room", req.RoomID)
+			return nil, fmt.Errorf("members of this room must be invited: %w", ErrValidation)
+		}
+
+		// Request-to-join rooms file a pending request for an admin to
+		// approve instead of adding the user
+		if room.JoinPolicy == db.JoinPolicyRequest {
+			return a.requestToJoin(ctx, user, room)
+		}
+
+		// Joining must not put the user over the room cap
+		if err := a.checkRoomLimit(ctx, user.ID); err != nil {
+			return nil, err
+		}
+
 		// Public room - add the user as a member
 		joined, err = db.AddRoomMember(ctx, a.db, user.ID, req.RoomID)
 		if err != nil {
@@ -74,6 +101,17 @@ func (a *Api) JoinRoom(user *models.User, msg json.RawMessage) (*JoinRoomResult,
 		return nil, err
 	}
 
+	// Preload the first page of history when requested, so the client can
+	// render the room without a separate history round trip
+	var history *protocol.HistoryResponse
+	if req.WithHistory {
+		history, err = a.historyPage(ctx, user, room.ID, "", defaultHistoryLimit)
+		if err != nil {
+			a.logger.Error("failed to preload room history", "error", err, "room", room.ID)
+			return nil, err
+		}
+	}
+
 	// For DMs, include member info
 	var members []protocol.RoomMember
 	if room.RoomType == "dm" {
@@ -95,7 +133,8 @@ func (a *Api) JoinRoom(user *models.User, msg json.RawMessage) (*JoinRoomResult,
 					IsPrivate: room.IsPrivate != 0,
 					Members:   members,
 				},
-				Joined: joined,
+				Joined:  joined,
+				History: history,
 			},
 		},
 		RoomID: room.ID,
@@ -3,9 +3,11 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
@@ -252,3 +254,80 @@ func TestJoinRoom_InvalidJSON(t *testing.T) {
 		t.Fatal("Expected error for invalid JSON")
 	}
 }
+
+// TestJoinRoom_WithHistory tests that with_history preloads a correctly
+// ordered first page of history with a pagination cursor
+func TestJoinRoom_WithHistory(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_jrhist_00001", "jrhistuser")
+	room := createTestRoom(t, database, "roo_jrhist_00001", "jrhist", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	// More messages than one page so the cursor is exercised
+	base := time.Now().Add(-time.Hour)
+	total := defaultHistoryLimit + 5
+	for i := 0; i < total; i++ {
+		createTestMessage(t, database,
+			fmt.Sprintf("msg_jrhist_%05d", i), room.ID, user.ID,
+			fmt.Sprintf("message %d", i), base.Add(time.Duration(i)*time.Second))
+	}
+
+	reqJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: room.ID, WithHistory: true})
+	response, err := api.JoinRoom(user, reqJSON)
+	if err != nil {
+		t.Fatalf("JoinRoom failed: %v", err)
+	}
+	joinResp, ok := response.Envelope.Data.(protocol.JoinRoomResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.JoinRoomResponse data type, got %T", response.Envelope.Data)
+	}
+	if joinResp.History == nil {
+		t.Fatal("Expected history to be preloaded")
+	}
+	if len(joinResp.History.Messages) != defaultHistoryLimit {
+		t.Fatalf("Expected %d messages, got %d", defaultHistoryLimit, len(joinResp.History.Messages))
+	}
+	// Newest first, same as a history response
+	if got := joinResp.History.Messages[0].Body; got != fmt.Sprintf("message %d", total-1) {
+		t.Errorf("Expected the newest message first, got %q", got)
+	}
+	if !joinResp.History.HasMore {
+		t.Error("Expected has_more to be set with older messages remaining")
+	}
+	if joinResp.History.NextCursor == "" {
+		t.Error("Expected a pagination cursor for the older messages")
+	}
+}
+
+// TestJoinRoom_WithoutHistory tests that omitting with_history keeps the
+// lean response
+func TestJoinRoom_WithoutHistory(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_jrhist_00002", "jrleanuser")
+	room := createTestRoom(t, database, "roo_jrhist_00002", "jrlean", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	createTestMessage(t, database, "msg_jrhist_lean1", room.ID, user.ID, "hello", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: room.ID})
+	response, err := api.JoinRoom(user, reqJSON)
+	if err != nil {
+		t.Fatalf("JoinRoom failed: %v", err)
+	}
+	joinResp, ok := response.Envelope.Data.(protocol.JoinRoomResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.JoinRoomResponse data type, got %T", response.Envelope.Data)
+	}
+	if joinResp.History != nil {
+		t.Error("Expected no history without the with_history flag")
+	}
+}
@@ -16,25 +16,24 @@ var ErrCannotLeave1on1DM = errors.New("cannot leave a 1:1 direct message")
 // LeaveRoom handles a request from the client to leave a room.
 // Users cannot leave the default room or 1:1 DMs.
 func (a *Api) LeaveRoom(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.LeaveRoomRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		return nil, err
 	}
 
 	if req.RoomID == "" {
-		return ErrorResponse("room_id is required"), nil
+		return errorEnvelope(protocol.ErrCodeValidation, "room_id is required"), nil
 	}
 
 	ctx := context.Background()
 
-	// Check if this is the default room
 	room, err := models.RoomByID(ctx, a.db, req.RoomID)
 	if err != nil {
-		return ErrorResponse("room not found"), nil
-	}
-
-	if room.IsDefault != 0 {
-		return ErrorResponse("cannot leave the default room"), nil
+		return errorEnvelope(protocol.ErrCodeNotFound, "room not found"), nil
 	}
 
 	// Check if this is a 1:1 DM (cannot leave those)
@@ -46,19 +45,22 @@ func (a *Api) LeaveRoom(user *models.User, msg json.RawMessage) (*Envelope, erro
 		}
 		// Count comes back as a string from SQLite
 		if memberCount.Count == "1" || memberCount.Count == "2" {
-			return ErrorResponse("cannot leave a 1:1 direct message"), nil
+			return errorEnvelope(protocol.ErrCodeValidation, "cannot leave a 1:1 direct message"), nil
 		}
 	}
 
-	// Try to leave the room
+	// Try to leave the room; the db layer owns the default-room guard
 	left, err := db.LeaveRoom(ctx, a.db, user.ID, req.RoomID)
+	if errors.Is(err, db.ErrDefaultRoom) {
+		return errorEnvelope(protocol.ErrCodeValidation, "cannot leave the default room"), nil
+	}
 	if err != nil {
 		a.logger.Error("failed to leave room", "error", err, "room_id", req.RoomID)
 		return nil, err
 	}
 
 	if !left {
-		return ErrorResponse("not a member of this room"), nil
+		return errorEnvelope(protocol.ErrCodeNotMember, "not a member of this room"), nil
 	}
 
 	return &Envelope{
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// awayThreshold is how long a connected user may go without activity
+// before list_members flags them as away instead of online
+const awayThreshold = 5 * time.Minute
+
+// ListMembers handles a request for one page of a room's member list with
+// presence flags. Private rooms and DMs are members-only; public rooms may
+// be listed by anyone. onlineIDs is the hub's set of currently connected
+// users.
+func (a *Api) ListMembers(user *models.User, msg json.RawMessage, onlineIDs []string) (*Envelope, error) {
+	var req protocol.ListMembersRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return errorEnvelope(protocol.ErrCodeValidation, "room_id is required"), nil
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room_id", req.RoomID)
+		return errorEnvelope(protocol.ErrCodeNotFound, "room not found"), nil
+	}
+
+	if room.IsPrivate != 0 {
+		isMember, err := db.IsRoomMember(ctx, a.db, user.ID, room.ID)
+		if err != nil {
+			a.logger.Error("failed to check room membership", "error", err)
+			return nil, err
+		}
+		if !isMember {
+			return errorEnvelope(protocol.ErrCodeNotMember, "not a member of this room"), nil
+		}
+	}
+
+	listings, nextCursor, err := db.ListRoomMembers(ctx, a.db, room.ID, req.Cursor, req.Limit)
+	if err != nil {
+		a.logger.Error("failed to list room members", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	online := make(map[string]bool, len(onlineIDs))
+	for _, id := range onlineIDs {
+		online[id] = true
+	}
+
+	members := make([]protocol.RoomMember, len(listings))
+	for i, m := range listings {
+		members[i] = protocol.RoomMember{
+			ID:          m.ID,
+			Username:    m.Username,
+			DisplayName: m.DisplayName,
+			Avatar:      m.Avatar,
+			Presence:    presenceFor(online[m.ID], m.LastActiveAt),
+		}
+	}
+
+	return &Envelope{
+		Type: "list_members",
+		Data: protocol.ListMembersResponse{
+			RoomID:     room.ID,
+			Members:    members,
+			NextCursor: nextCursor,
+		},
+	}, nil
+}
+
+// presenceFor derives a member's presence flag from whether they have an
+// open connection and when they were last active
+func presenceFor(connected bool, lastActiveAt string) string {
+	if !connected {
+		return "offline"
+	}
+	// last_active_at is throttled and may be empty for a fresh account; a
+	// connected user with no parseable activity time counts as online
+	active, err := time.Parse(time.RFC3339, lastActiveAt)
+	if err != nil {
+		return "online"
+	}
+	if time.Since(active) > awayThreshold {
+		return "away"
+	}
+	return "online"
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// listMembers marshals and sends a list_members request, returning the
+// response data
+func listMembers(t *testing.T, api *Api, user *models.User, roomID, cursor string, limit int, onlineIDs []string) protocol.ListMembersResponse {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.ListMembersRequest{RoomID: roomID, Cursor: cursor, Limit: limit})
+	response, err := api.ListMembers(user, reqJSON, onlineIDs)
+	if err != nil {
+		t.Fatalf("ListMembers failed: %v", err)
+	}
+	resp, ok := response.Data.(protocol.ListMembersResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.ListMembersResponse data type, got %T", response.Data)
+	}
+	return resp
+}
+
+// setLastActive sets a user's last_active_at directly
+func setLastActive(t *testing.T, api *Api, userID string, at time.Time) {
+	t.Helper()
+	_, err := api.db.ExecContext(context.Background(),
+		"UPDATE users SET last_active_at = ? WHERE id = ?", at.Format(time.RFC3339), userID)
+	if err != nil {
+		t.Fatalf("Failed to set last_active_at: %v", err)
+	}
+}
+
+// TestListMembers_Pagination tests walking a member list in pages
+func TestListMembers_Pagination(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	room := createTestRoom(t, database, "roo_lm0000000001", "lm-page-room", true)
+	users := make([]*models.User, 5)
+	for i := range users {
+		users[i] = createTestUser(t, database, fmt.Sprintf("usr_lmpage%06d", i), fmt.Sprintf("lmpager%02d", i))
+		addUserToRoom(t, database, users[i].ID, room.ID)
+	}
+
+	var got []string
+	cursor := ""
+	pages := 0
+	for {
+		resp := listMembers(t, api, users[0], room.ID, cursor, 2, nil)
+		pages++
+		for _, m := range resp.Members {
+			got = append(got, m.Username)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+
+	if pages != 3 {
+		t.Errorf("Expected 3 pages of 2, got %d", pages)
+	}
+	if len(got) != len(users) {
+		t.Fatalf("Expected %d members across pages, got %d", len(users), len(got))
+	}
+	for i, username := range got {
+		if want := fmt.Sprintf("lmpager%02d", i); username != want {
+			t.Errorf("Expected member %d to be %s, got %s", i, want, username)
+		}
+	}
+}
+
+// TestListMembers_Presence tests the online, away, and offline flags
+func TestListMembers_Presence(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	room := createTestRoom(t, database, "roo_lm0000000011", "lm-presence-room", true)
+	active := createTestUser(t, database, "usr_lmpres000001", "lmpresactive")
+	idle := createTestUser(t, database, "usr_lmpres000002", "lmpresidle")
+	gone := createTestUser(t, database, "usr_lmpres000003", "lmpresgone")
+	for _, u := range []*models.User{active, idle, gone} {
+		addUserToRoom(t, database, u.ID, room.ID)
+	}
+	setLastActive(t, api, active.ID, time.Now())
+	setLastActive(t, api, idle.ID, time.Now().Add(-2*awayThreshold))
+
+	resp := listMembers(t, api, active, room.ID, "", 0, []string{active.ID, idle.ID})
+
+	presence := make(map[string]string)
+	for _, m := range resp.Members {
+		presence[m.ID] = m.Presence
+	}
+	if presence[active.ID] != "online" {
+		t.Errorf("Expected a recently active connected user to be online, got %s", presence[active.ID])
+	}
+	if presence[idle.ID] != "away" {
+		t.Errorf("Expected an idle connected user to be away, got %s", presence[idle.ID])
+	}
+	if presence[gone.ID] != "offline" {
+		t.Errorf("Expected a disconnected user to be offline, got %s", presence[gone.ID])
+	}
+}
+
+// TestListMembers_PrivateRoomAccess tests that private rooms are
+// members-only while public rooms may be listed by anyone
+func TestListMembers_PrivateRoomAccess(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	member := createTestUser(t, database, "usr_lmpriv000001", "lmprivmember")
+	outsider := createTestUser(t, database, "usr_lmpriv000002", "lmprivoutsider")
+	private := createTestRoomWithPrivate(t, database, "roo_lm0000000021", "lm-private-room", false, true)
+	public := createTestRoom(t, database, "roo_lm0000000022", "lm-public-room", false)
+	addUserToRoom(t, database, member.ID, private.ID)
+	addUserToRoom(t, database, member.ID, public.ID)
+
+	reqJSON, _ := json.Marshal(protocol.ListMembersRequest{RoomID: private.ID})
+	response, err := api.ListMembers(outsider, reqJSON, nil)
+	if err != nil {
+		t.Fatalf("ListMembers failed: %v", err)
+	}
+	if code := errorEnvelopeCode(t, response); code != protocol.ErrCodeNotMember {
+		t.Errorf("Expected %s for an outsider listing a private room, got %s", protocol.ErrCodeNotMember, code)
+	}
+
+	// A member may list the private room, and anyone the public one
+	if resp := listMembers(t, api, member, private.ID, "", 0, nil); len(resp.Members) != 1 {
+		t.Errorf("Expected 1 member in the private room, got %d", len(resp.Members))
+	}
+	if resp := listMembers(t, api, outsider, public.ID, "", 0, nil); len(resp.Members) != 1 {
+		t.Errorf("Expected 1 member in the public room, got %d", len(resp.Members))
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// reactorsPageSize is how many reactor user IDs a list_reactors page holds
+const reactorsPageSize = 50
+
+// ListReactors pages through the full list of users who reacted to a message
+// with an emoji; reaction aggregates only carry the first few IDs inline
+func (a *Api) ListReactors(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.ListReactorsRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for list_reactors request", "error", err)
+		return errorEnvelope(protocol.ErrCodeValidation, "invalid list_reactors request"), nil
+	}
+
+	if req.MessageID == "" || req.Emoji == "" {
+		return errorEnvelope(protocol.ErrCodeValidation, "message_id and emoji are required"), nil
+	}
+
+	ctx := context.Background()
+
+	// The caller must be able to read the message's room
+	message, err := models.MessageByID(ctx, a.db, req.MessageID)
+	if err != nil {
+		return errorEnvelope(protocol.ErrCodeNotFound, "message not found"), nil
+	}
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, message.RoomID)
+	if err != nil {
+		a.logger.Error("failed to check room membership", "error", err, "user", user.ID, "room", message.RoomID)
+		return errorEnvelope(protocol.ErrCodeInternal, "failed to check access"), nil
+	}
+	if !isMember {
+		return errorEnvelope(protocol.ErrCodeNotMember, "not a member of this room"), nil
+	}
+
+	// Fetch one extra reactor to determine if there are more
+	reactors, err := db.ListReactors(ctx, a.db, req.MessageID, req.Emoji, req.Cursor, reactorsPageSize+1)
+	if err != nil {
+		a.logger.Error("failed to list reactors", "error", err, "message", req.MessageID)
+		return errorEnvelope(protocol.ErrCodeInternal, "failed to list reactors"), nil
+	}
+
+	hasMore := len(reactors) > reactorsPageSize
+	if hasMore {
+		reactors = reactors[:reactorsPageSize]
+	}
+
+	userIDs := make([]string, len(reactors))
+	for i, r := range reactors {
+		userIDs[i] = r.UserID
+	}
+	nextCursor := ""
+	if hasMore {
+		nextCursor = reactors[len(reactors)-1].CreatedAt
+	}
+
+	return &Envelope{
+		Type: "list_reactors",
+		Data: protocol.ListReactorsResponse{
+			MessageID:  req.MessageID,
+			Emoji:      req.Emoji,
+			UserIDs:    userIDs,
+			HasMore:    hasMore,
+			NextCursor: nextCursor,
+		},
+	}, nil
+}
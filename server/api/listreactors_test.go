@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// seedReactors inserts n reactions on a message from freshly-created users,
+// with distinct timestamps, and returns the reactor IDs in reaction order
+func seedReactors(t *testing.T, api *Api, msgID string, n int) []string {
+	t.Helper()
+	ctx := context.Background()
+	base := time.Now()
+	ids := make([]string, n)
+	for i := 0; i < n; i++ {
+		user := createTestUser(t, api.db, fmt.Sprintf("usr_seed_rct_%04d", i), fmt.Sprintf("seedreactor%d", i))
+		r := models.Reaction{
+			MessageID: msgID,
+			UserID:    user.ID,
+			Emoji:     "👍",
+			CreatedAt: base.Add(time.Duration(i) * time.Millisecond).Format(time.RFC3339Nano),
+		}
+		if err := r.Insert(ctx, api.db); err != nil {
+			t.Fatalf("Failed to insert reaction: %v", err)
+		}
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+// TestHistory_ReactorsCapped tests that inline reaction user IDs are capped
+// while the count stays accurate and the caller's own reaction is still
+// reflected in reacted_by_me
+func TestHistory_ReactorsCapped(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_capviewer1234", "capviewer")
+	room := createTestRoom(t, database, "roo_capremote123", "general", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	msgID := createTestMessageSimple(t, api, user, room.ID, "viral message")
+
+	seedReactors(t, api, msgID, maxInlineReactors+3)
+
+	// The viewer reacts last, so they fall outside the inline cap
+	reqJSON, _ := json.Marshal(protocol.AddReactionRequest{MessageID: msgID, Emoji: "👍"})
+	if _, err := api.AddReaction(user, reqJSON); err != nil {
+		t.Fatalf("AddReaction failed: %v", err)
+	}
+
+	histJSON, _ := json.Marshal(protocol.HistoryRequest{RoomID: room.ID, Limit: 50})
+	response, err := api.HistoryMessage(user, histJSON)
+	if err != nil {
+		t.Fatalf("HistoryMessage failed: %v", err)
+	}
+	historyResp := response.Data.(protocol.HistoryResponse)
+
+	var reaction *protocol.Reaction
+	for _, m := range historyResp.Messages {
+		if m.ID == msgID && len(m.Reactions) > 0 {
+			reaction = &m.Reactions[0]
+		}
+	}
+	if reaction == nil {
+		t.Fatal("Expected a reaction aggregate on the message")
+	}
+
+	if reaction.Count != maxInlineReactors+4 {
+		t.Errorf("Expected count %d, got %d", maxInlineReactors+4, reaction.Count)
+	}
+	if len(reaction.UserIDs) != maxInlineReactors {
+		t.Errorf("Expected %d inline user IDs, got %d", maxInlineReactors, len(reaction.UserIDs))
+	}
+	if !reaction.ReactedByMe {
+		t.Error("Expected reacted_by_me for the viewer despite the cap")
+	}
+}
+
+// TestListReactors_PagesThroughAll tests that list_reactors pages through the
+// full reactor list
+func TestListReactors_PagesThroughAll(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_lrviewer12345", "lrviewer")
+	room := createTestRoom(t, database, "roo_lrroom123456", "general", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	msgID := createTestMessageSimple(t, api, user, room.ID, "very popular")
+
+	want := seedReactors(t, api, msgID, reactorsPageSize+5)
+
+	var got []string
+	cursor := ""
+	for {
+		reqJSON, _ := json.Marshal(protocol.ListReactorsRequest{MessageID: msgID, Emoji: "👍", Cursor: cursor})
+		response, err := api.ListReactors(user, reqJSON)
+		if err != nil {
+			t.Fatalf("ListReactors failed: %v", err)
+		}
+		resp, ok := response.Data.(protocol.ListReactorsResponse)
+		if !ok {
+			t.Fatalf("Expected ListReactorsResponse, got %T", response.Data)
+		}
+		got = append(got, resp.UserIDs...)
+		if !resp.HasMore {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d reactors, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected reactor %d to be %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+// TestListReactors_NotMember tests that non-members can't enumerate reactors
+func TestListReactors_NotMember(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	member := createTestUser(t, database, "usr_lrmember12345", "lrmember")
+	outsider := createTestUser(t, database, "usr_lroutsider123", "lroutsider")
+	room := createTestRoom(t, database, "roo_lrprivate123", "private", false)
+	addUserToRoom(t, database, member.ID, room.ID)
+	msgID := createTestMessageSimple(t, api, member, room.ID, "secret")
+
+	reqJSON, _ := json.Marshal(protocol.ListReactorsRequest{MessageID: msgID, Emoji: "👍"})
+	response, err := api.ListReactors(outsider, reqJSON)
+	if err != nil {
+		t.Fatalf("ListReactors failed: %v", err)
+	}
+	if code := errorEnvelopeCode(t, response); code != protocol.ErrCodeNotMember {
+		t.Errorf("Expected code %q, got %q", protocol.ErrCodeNotMember, code)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
@@ -10,38 +11,52 @@ import (
 )
 
 // ListRooms handles a request from the client to list public rooms.
-// Returns all public rooms along with membership status for the user.
-// Optionally filters by a search query.
+// Returns a page of public rooms along with membership status, member
+// counts, and last-activity timestamps for the user. Optionally filters by a
+// search query and sorts by name, member count, or recent activity; the
+// default name sort paginates via next_cursor.
 func (a *Api) ListRooms(user *models.User, msg json.RawMessage) (*Envelope, error) {
 	var req protocol.ListRoomsRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		return nil, err
 	}
 
+	switch req.SortBy {
+	case "", "name", "members", "activity":
+	default:
+		a.logger.Error("invalid sort_by in list_rooms request", "sort_by", req.SortBy)
+		return nil, fmt.Errorf("invalid sort_by %q", req.SortBy)
+	}
+
 	ctx := context.Background()
 
-	rooms, membership, err := db.ListPublicRoomsWithMembership(ctx, a.db, user.ID, req.Query)
+	listings, nextCursor, err := db.ListPublicRoomsWithMembership(ctx, a.db, user.ID, req.Query, req.SortBy, req.Cursor, req.Limit)
 	if err != nil {
 		a.logger.Error("failed to list public rooms", "error", err)
 		return nil, err
 	}
 
 	// Convert to protocol types
-	protoRooms := make([]*protocol.Room, len(rooms))
-	for i, r := range rooms {
+	protoRooms := make([]*protocol.Room, len(listings))
+	membership := make([]bool, len(listings))
+	for i, l := range listings {
 		protoRooms[i] = &protocol.Room{
-			ID:        r.ID,
-			Name:      r.Name,
-			RoomType:  r.RoomType,
-			IsPrivate: r.IsPrivate != 0,
+			ID:           l.Room.ID,
+			Name:         l.Room.Name,
+			RoomType:     l.Room.RoomType,
+			IsPrivate:    l.Room.IsPrivate != 0,
+			MemberCount:  l.MemberCount,
+			LastActivity: l.LastActivity,
 		}
+		membership[i] = l.IsMember
 	}
 
 	return &Envelope{
 		Type: "list_rooms",
 		Data: protocol.ListRoomsResponse{
-			Rooms:    protoRooms,
-			IsMember: membership,
+			Rooms:      protoRooms,
+			IsMember:   membership,
+			NextCursor: nextCursor,
 		},
 	}, nil
 }
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"fmt"
+)
+
+// SetReadOnly toggles read-only maintenance mode. While set, write
+// handlers return ErrMaintenance and reads continue to work.
+func (a *Api) SetReadOnly(on bool) {
+	a.readOnly.Store(on)
+}
+
+// ReadOnly reports whether the server is in read-only maintenance mode
+func (a *Api) ReadOnly() bool {
+	return a.readOnly.Load()
+}
+
+// checkWritable rejects a write operation while the server is in
+// read-only maintenance mode. Every mutating handler calls this first.
+func (a *Api) checkWritable() error {
+	if a.readOnly.Load() {
+		return fmt.Errorf("writes are disabled during maintenance: %w", ErrMaintenance)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestMaintenance_WritesRejected tests that write operations return
+// ErrMaintenance while read-only mode is on
+func TestMaintenance_WritesRejected(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_maint_000001", "maint-writer")
+	room := createTestRoom(t, database, "roo_maint_000001", "maint-room", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	api.SetReadOnly(true)
+
+	if _, err := sendMessage(t, api, user, room.ID, "hello?"); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("Expected ErrMaintenance sending a message, got %v", err)
+	}
+
+	createJSON, _ := json.Marshal(protocol.CreateRoomRequest{Name: "maint-new-room"})
+	if _, err := api.CreateRoom(user, createJSON); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("Expected ErrMaintenance creating a room, got %v", err)
+	}
+
+	joinJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: room.ID})
+	if _, err := api.JoinRoom(user, joinJSON); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("Expected ErrMaintenance joining a room, got %v", err)
+	}
+
+	// The error envelope carries the maintenance code so clients can
+	// show a banner rather than a generic failure
+	envelope := api.ErrorMessage(ErrMaintenance, "writes are disabled during maintenance")
+	if res, ok := envelope.Data.(*protocol.ErrorResponse); !ok || res.Code != protocol.ErrCodeMaintenance {
+		t.Errorf("Expected a %q error code, got %+v", protocol.ErrCodeMaintenance, envelope.Data)
+	}
+}
+
+// TestMaintenance_ReadsSucceed tests that read operations still work in
+// read-only mode
+func TestMaintenance_ReadsSucceed(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_maint_000002", "maint-reader")
+	room := createTestRoom(t, database, "roo_maint_000002", "maint-reads", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	if _, err := sendMessage(t, api, user, room.ID, "written before maintenance"); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	api.SetReadOnly(true)
+
+	histJSON, _ := json.Marshal(protocol.HistoryRequest{RoomID: room.ID})
+	envelope, err := api.HistoryMessage(user, histJSON)
+	if err != nil {
+		t.Fatalf("Expected history to work in read-only mode, got %v", err)
+	}
+	history, ok := envelope.Data.(protocol.HistoryResponse)
+	if !ok {
+		t.Fatalf("Expected a HistoryResponse, got %T", envelope.Data)
+	}
+	if len(history.Messages) != 1 {
+		t.Errorf("Expected 1 message in history, got %d", len(history.Messages))
+	}
+
+	infoJSON, _ := json.Marshal(protocol.RoomInfoRequest{RoomID: room.ID})
+	if _, err := api.RoomInfo(user, infoJSON); err != nil {
+		t.Errorf("Expected room_info to work in read-only mode, got %v", err)
+	}
+}
+
+// TestMaintenance_ToggleRestoresWrites tests that turning read-only mode
+// back off restores write operations
+func TestMaintenance_ToggleRestoresWrites(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_maint_000003", "maint-toggler")
+	room := createTestRoom(t, database, "roo_maint_000003", "maint-toggle", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	api.SetReadOnly(true)
+	if _, err := sendMessage(t, api, user, room.ID, "blocked"); !errors.Is(err, ErrMaintenance) {
+		t.Fatalf("Expected ErrMaintenance while read-only, got %v", err)
+	}
+
+	api.SetReadOnly(false)
+	if _, err := sendMessage(t, api, user, room.ID, "back to normal"); err != nil {
+		t.Errorf("Expected writes to work after toggling back, got %v", err)
+	}
+}
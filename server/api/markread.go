@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// MarkRead records the user's read position in a room. There is no success
+// response; the position shows up as the unread boundary in later history
+// requests.
+func (a *Api) MarkRead(user *models.User, msg json.RawMessage) error {
+	var req protocol.MarkReadRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for mark_read request", "error", err)
+		return err
+	}
+
+	if req.RoomID == "" || req.MessageID == "" {
+		return fmt.Errorf("room_id and message_id are required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, req.RoomID)
+	if err != nil {
+		a.logger.Error("failed to check room membership", "error", err, "user", user.ID, "room", req.RoomID)
+		return err
+	}
+	if !isMember {
+		return fmt.Errorf("user is not a member of room %s: %w", req.RoomID, ErrNotMember)
+	}
+
+	// The message must exist and belong to the room being marked
+	message, err := models.MessageByID(ctx, a.db, req.MessageID)
+	if err != nil {
+		return fmt.Errorf("message %s not found: %w", req.MessageID, ErrValidation)
+	}
+	if message.RoomID != req.RoomID {
+		return fmt.Errorf("message %s is not in room %s: %w", req.MessageID, req.RoomID, ErrValidation)
+	}
+
+	return db.SetLastRead(ctx, a.db, user.ID, req.RoomID, req.MessageID)
+}
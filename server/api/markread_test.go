@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestMarkRead_RecordsPosition tests that mark_read stores the user's read
+// position
+func TestMarkRead_RecordsPosition(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	msg := createTestMessage(t, database, "msg_read12345678", room.ID, user.ID, "hello", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.MarkReadRequest{RoomID: room.ID, MessageID: msg.ID})
+	if err := api.MarkRead(user, reqJSON); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+
+	lastRead, err := db.GetLastRead(context.Background(), database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("GetLastRead failed: %v", err)
+	}
+	if lastRead != msg.ID {
+		t.Errorf("Expected last read %s, got %s", msg.ID, lastRead)
+	}
+}
+
+// TestMarkRead_WrongRoom tests that a message can't be marked read against a
+// room it doesn't belong to
+func TestMarkRead_WrongRoom(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", false)
+	other := createTestRoom(t, database, "roo_other1234567", "other", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	addUserToRoom(t, database, user.ID, other.ID)
+	msg := createTestMessage(t, database, "msg_read12345678", other.ID, user.ID, "hello", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.MarkReadRequest{RoomID: room.ID, MessageID: msg.ID})
+	if err := api.MarkRead(user, reqJSON); err == nil {
+		t.Fatal("Expected MarkRead to fail for a message in a different room")
+	}
+}
+
+// TestHistory_UnreadBoundaryInPage tests that the unread boundary is set when
+// the user's last-read message falls within the returned page
+func TestHistory_UnreadBoundaryInPage(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	now := time.Now()
+	createTestMessage(t, database, "msg_bound1111111", room.ID, user.ID, "first", now.Add(-3*time.Minute))
+	boundary := createTestMessage(t, database, "msg_bound2222222", room.ID, user.ID, "second", now.Add(-2*time.Minute))
+	createTestMessage(t, database, "msg_bound3333333", room.ID, user.ID, "third", now)
+
+	if err := db.SetLastRead(context.Background(), database, user.ID, room.ID, boundary.ID); err != nil {
+		t.Fatalf("SetLastRead failed: %v", err)
+	}
+
+	reqJSON, _ := json.Marshal(protocol.HistoryRequest{RoomID: room.ID, Limit: 50})
+	response, err := api.HistoryMessage(user, reqJSON)
+	if err != nil {
+		t.Fatalf("HistoryMessage failed: %v", err)
+	}
+
+	historyResp, ok := response.Data.(protocol.HistoryResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.HistoryResponse data type, got %T", response.Data)
+	}
+	if historyResp.UnreadBoundaryID != boundary.ID {
+		t.Errorf("Expected unread boundary %s, got %q", boundary.ID, historyResp.UnreadBoundaryID)
+	}
+}
+
+// TestHistory_UnreadBoundaryOmitted tests that the boundary is omitted when
+// the whole page has been read and when nothing has been read at all
+func TestHistory_UnreadBoundaryOmitted(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	now := time.Now()
+	createTestMessage(t, database, "msg_bound1111111", room.ID, user.ID, "first", now.Add(-1*time.Minute))
+	newest := createTestMessage(t, database, "msg_bound2222222", room.ID, user.ID, "second", now)
+
+	reqJSON, _ := json.Marshal(protocol.HistoryRequest{RoomID: room.ID, Limit: 50})
+
+	// No read position recorded: the page is fully unread
+	response, err := api.HistoryMessage(user, reqJSON)
+	if err != nil {
+		t.Fatalf("HistoryMessage failed: %v", err)
+	}
+	if resp := response.Data.(protocol.HistoryResponse); resp.UnreadBoundaryID != "" {
+		t.Errorf("Expected no unread boundary for fully-unread page, got %q", resp.UnreadBoundaryID)
+	}
+
+	// Read up to the newest message: the page is fully read
+	if err := db.SetLastRead(context.Background(), database, user.ID, room.ID, newest.ID); err != nil {
+		t.Fatalf("SetLastRead failed: %v", err)
+	}
+	response, err = api.HistoryMessage(user, reqJSON)
+	if err != nil {
+		t.Fatalf("HistoryMessage failed: %v", err)
+	}
+	if resp := response.Data.(protocol.HistoryResponse); resp.UnreadBoundaryID != "" {
+		t.Errorf("Expected no unread boundary for fully-read page, got %q", resp.UnreadBoundaryID)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/llimllib/hatchat/server/db"
@@ -17,10 +18,25 @@ type MessageResponse struct {
 	Message []byte
 }
 
+// SlowModeError is returned when a user posts again before the room's
+// slow-mode interval has elapsed. It carries the remaining wait so the client
+// can show it to the user.
+type SlowModeError struct {
+	RemainingSeconds int
+}
+
+func (e *SlowModeError) Error() string {
+	return fmt.Sprintf("slow mode is on: wait %d more seconds before posting", e.RemainingSeconds)
+}
+
 // MessageMessage accepts a message from a user that has yet to be unmarshaled,
 // writes it to the database and returns a MessageResponse with the message
 // JSON and room ID for routing
 func (a *Api) MessageMessage(user *models.User, msg json.RawMessage) (*MessageResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.SendMessageRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		a.logger.Error("invalid json", "error", err)
@@ -30,7 +46,16 @@ func (a *Api) MessageMessage(user *models.User, msg json.RawMessage) (*MessageRe
 	// if the message is empty or there's no room, error out
 	if len(req.Body) < 1 || len(req.RoomID) < 1 {
 		a.logger.Error("invalid message", "msg", string(msg))
-		return nil, fmt.Errorf("invalid message <%s> <%s>", req.Body, req.RoomID)
+		return nil, fmt.Errorf("invalid message <%s> <%s>: %w", req.Body, req.RoomID, ErrValidation)
+	}
+
+	// When the client supplied an idempotency ID and we already handled it,
+	// return the message the first send created instead of posting again
+	if req.ClientMsgID != "" {
+		if cached := a.dedupe.get(dedupeKey(user.ID, req.RoomID, req.ClientMsgID)); cached != nil {
+			a.logger.Info("deduplicated resent message", "user", user.ID, "room", req.RoomID, "client_msg_id", req.ClientMsgID)
+			return cached, nil
+		}
 	}
 
 	ctx := context.Background()
@@ -43,7 +68,7 @@ func (a *Api) MessageMessage(user *models.User, msg json.RawMessage) (*MessageRe
 	}
 	if !isMember {
 		a.logger.Warn("user attempted to send message to room they are not a member of", "user", user.ID, "room", req.RoomID)
-		return nil, fmt.Errorf("user is not a member of room %s", req.RoomID)
+		return nil, fmt.Errorf("user is not a member of room %s: %w", req.RoomID, ErrNotMember)
 	}
 
 	room, err := models.RoomByID(ctx, a.db, req.RoomID)
@@ -52,12 +77,103 @@ func (a *Api) MessageMessage(user *models.User, msg json.RawMessage) (*MessageRe
 		return nil, err
 	}
 
+	// Announcement-only rooms accept posts from owners and admins alone;
+	// members can still read and react
+	if room.PostPolicy == db.PostPolicyAdmins {
+		role, err := db.GetMemberRole(ctx, a.db, user.ID, room.ID)
+		if err != nil {
+			a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", room.ID)
+			return nil, err
+		}
+		if role != db.RoleOwner && role != db.RoleAdmin {
+			a.logger.Warn("member attempted to post in an announcement-only room", "user", user.ID, "room", room.ID)
+			return nil, fmt.Errorf("only owners and admins may post in this room: %w", ErrValidation)
+		}
+	}
+
+	// Enforce slow mode: regular members may only post once per interval;
+	// owners and admins are exempt
+	if room.SlowModeSeconds > 0 {
+		role, err := db.GetMemberRole(ctx, a.db, user.ID, room.ID)
+		if err != nil {
+			a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", room.ID)
+			return nil, err
+		}
+		if role == db.RoleMember {
+			lastPost, err := db.GetLastUserMessageTime(ctx, a.db, user.ID, room.ID)
+			if err != nil {
+				a.logger.Error("failed to get last message time", "error", err, "user", user.ID, "room", room.ID)
+				return nil, err
+			}
+			if lastPost != "" {
+				lastPostTime, err := time.Parse(time.RFC3339Nano, lastPost)
+				if err != nil {
+					a.logger.Error("failed to parse last message time", "error", err, "created_at", lastPost)
+					return nil, err
+				}
+				interval := time.Duration(room.SlowModeSeconds) * time.Second
+				if elapsed := time.Since(lastPostTime); elapsed < interval {
+					remaining := int(math.Ceil((interval - elapsed).Seconds()))
+					return nil, &SlowModeError{RemainingSeconds: remaining}
+				}
+			}
+		}
+	}
+
+	// Run the body through the room's content filter before anything is
+	// stored: reject rules fail the send, mask rules replace matches so the
+	// original text is never persisted
+	body, err := a.applyRoomFilters(ctx, room.ID, req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate attachments before inserting the message: each must exist, be
+	// owned by the sender, and not already be linked to another message
+	attachments := make([]*models.Attachment, 0, len(req.AttachmentIDs))
+	for _, attID := range req.AttachmentIDs {
+		att, err := models.AttachmentByID(ctx, a.db, attID)
+		if err != nil {
+			a.logger.Error("unable to find attachment", "error", err, "attachment", attID)
+			return nil, fmt.Errorf("attachment %s not found", attID)
+		}
+		if att.UserID != user.ID {
+			a.logger.Warn("user attempted to attach someone else's upload", "user", user.ID, "attachment", attID)
+			return nil, fmt.Errorf("attachment %s not found", attID)
+		}
+		if att.MessageID.Valid && att.MessageID.String != "" {
+			a.logger.Warn("attachment already linked to a message", "attachment", attID, "message", att.MessageID.String)
+			return nil, fmt.Errorf("attachment %s is already attached to a message", attID)
+		}
+		attachments = append(attachments, att)
+	}
+
+	// Snapshot the quoted message before inserting, if one was named: the
+	// quote embeds the author and an excerpt so it survives the original
+	// being deleted later
+	var quote *protocol.QuotedMessage
+	if req.QuoteMessageID != "" {
+		quote, err = a.quoteSnapshot(ctx, room.ID, req.QuoteMessageID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Encrypt the body at rest when a cipher is configured; broadcasts and
+	// responses always carry the plaintext
+	storedBody, encKeyID, err := a.db.Cipher.EncryptBody(body)
+	if err != nil {
+		a.logger.Error("unable to encrypt message body", "error", err)
+		return nil, err
+	}
+
 	now := time.Now().Format(time.RFC3339Nano)
 	dbMessage := models.Message{
 		ID:         models.GenerateMessageID(),
 		RoomID:     room.ID,
 		UserID:     user.ID,
-		Body:       req.Body,
+		Body:       storedBody,
+		EncKeyID:   encKeyID,
 		CreatedAt:  now,
 		ModifiedAt: now,
 	}
@@ -66,6 +182,13 @@ func (a *Api) MessageMessage(user *models.User, msg json.RawMessage) (*MessageRe
 		return nil, err
 	}
 
+	if quote != nil {
+		if err := db.InsertMessageQuote(ctx, a.db, dbMessage.ID, quote); err != nil {
+			a.logger.Error("unable to insert message quote", "error", err, "message", dbMessage.ID)
+			return nil, err
+		}
+	}
+
 	// Update room's last_message_at for DM ordering
 	room.LastMessageAt.String = now
 	room.LastMessageAt.Valid = true
@@ -74,15 +197,47 @@ func (a *Api) MessageMessage(user *models.User, msg json.RawMessage) (*MessageRe
 		a.logger.Error("failed to update room last_message_at", "error", err, "room", room.ID)
 	}
 
+	// A new message makes a hidden DM reappear in every participant's
+	// sidebar
+	if room.RoomType == "dm" {
+		if err := db.UnhideDMForRoom(ctx, a.db, room.ID); err != nil {
+			// Log but don't fail - the message was already sent
+			a.logger.Error("failed to unhide DM", "error", err, "room", room.ID)
+		}
+	}
+
+	// Link the attachments to the message they now belong to
+	protocolAttachments := make([]protocol.Attachment, 0, len(attachments))
+	for _, att := range attachments {
+		att.MessageID.String = dbMessage.ID
+		att.MessageID.Valid = true
+		if err := att.Update(ctx, a.db); err != nil {
+			a.logger.Error("unable to link attachment", "error", err, "attachment", att.ID, "message", dbMessage.ID)
+			return nil, err
+		}
+		protocolAttachments = append(protocolAttachments, protocol.Attachment{
+			ID:           att.ID,
+			URL:          att.URL,
+			Filename:     att.Filename,
+			ContentType:  att.ContentType,
+			Size:         att.Size,
+			ThumbnailURL: att.ThumbnailURL,
+		})
+	}
+
 	// Create broadcast message with full message details using protocol.Message
 	broadcastMsg := protocol.Message{
 		ID:         dbMessage.ID,
-		Body:       dbMessage.Body,
+		Body:       body,
 		RoomID:     dbMessage.RoomID,
 		UserID:     dbMessage.UserID,
 		Username:   user.Username,
 		CreatedAt:  dbMessage.CreatedAt,
 		ModifiedAt: dbMessage.ModifiedAt,
+		Quote:      quote,
+	}
+	if len(protocolAttachments) > 0 {
+		broadcastMsg.Attachments = protocolAttachments
 	}
 
 	msgBytes, err := json.Marshal(&Envelope{
@@ -93,8 +248,12 @@ func (a *Api) MessageMessage(user *models.User, msg json.RawMessage) (*MessageRe
 		return nil, err
 	}
 
-	return &MessageResponse{
+	response := &MessageResponse{
 		RoomID:  room.ID,
 		Message: msgBytes,
-	}, nil
+	}
+	if req.ClientMsgID != "" {
+		a.dedupe.put(dedupeKey(user.ID, req.RoomID, req.ClientMsgID), response)
+	}
+	return response, nil
 }
@@ -9,15 +9,30 @@ import (
 	"github.com/llimllib/hatchat/server/protocol"
 )
 
-// GetMessageContext handles a request to get a message and its room for permalink navigation
+const (
+	defaultContextLimit = 25
+	maxContextLimit     = 100
+)
+
+// GetMessageContext handles a request to get a message and its surrounding
+// messages for permalink navigation
 func (a *Api) GetMessageContext(user *models.User, msg json.RawMessage) (Envelope, error) {
 	var req protocol.GetMessageContextRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
-		return *ErrorResponse("invalid get_message_context request"), nil
+		return *errorEnvelope(protocol.ErrCodeValidation, "invalid get_message_context request"), nil
 	}
 
 	if req.MessageID == "" {
-		return *ErrorResponse("message_id is required"), nil
+		return *errorEnvelope(protocol.ErrCodeValidation, "message_id is required"), nil
+	}
+
+	// Window size on each side of the target
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultContextLimit
+	}
+	if limit > maxContextLimit {
+		limit = maxContextLimit
 	}
 
 	ctx := context.Background()
@@ -26,17 +41,17 @@ func (a *Api) GetMessageContext(user *models.User, msg json.RawMessage) (Envelop
 	message, err := a.getMessageByID(ctx, req.MessageID)
 	if err != nil {
 		a.logger.Error("failed to get message", "error", err, "message_id", req.MessageID)
-		return *ErrorResponse("message not found"), nil
+		return *errorEnvelope(protocol.ErrCodeNotFound, "message not found"), nil
 	}
 
 	// Check if user has access to the room
 	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, message.RoomID)
 	if err != nil {
 		a.logger.Error("failed to check room membership", "error", err)
-		return *ErrorResponse("failed to check access"), nil
+		return *errorEnvelope(protocol.ErrCodeInternal, "failed to check access"), nil
 	}
 	if !isMember {
-		return *ErrorResponse("you don't have access to this message"), nil
+		return *errorEnvelope(protocol.ErrCodeNotMember, "you don't have access to this message"), nil
 	}
 
 	// Convert to protocol.Message
@@ -49,6 +64,7 @@ func (a *Api) GetMessageContext(user *models.User, msg json.RawMessage) (Envelop
 		CreatedAt:  message.CreatedAt,
 		ModifiedAt: message.ModifiedAt,
 		DeletedAt:  message.DeletedAt,
+		IsSystem:   message.IsSystem != 0,
 	}
 
 	// Handle deleted messages
@@ -56,15 +72,113 @@ func (a *Api) GetMessageContext(user *models.User, msg json.RawMessage) (Envelop
 		protoMessage.Body = ""
 	}
 
+	// Fetch one extra message on each side to determine if more exist
+	beforeRows, err := db.GetMessagesBefore(ctx, a.db, message.RoomID, message.CreatedAt, limit+1)
+	if err != nil {
+		a.logger.Error("failed to get messages before", "error", err, "message_id", req.MessageID)
+		return *errorEnvelope(protocol.ErrCodeInternal, "failed to get message context"), nil
+	}
+	hasMoreBefore := len(beforeRows) > limit
+	if hasMoreBefore {
+		beforeRows = beforeRows[1:] // beforeRows is chronological; drop the oldest
+	}
+
+	afterRows, err := db.GetMessagesAfter(ctx, a.db, message.RoomID, message.CreatedAt, limit+1)
+	if err != nil {
+		a.logger.Error("failed to get messages after", "error", err, "message_id", req.MessageID)
+		return *errorEnvelope(protocol.ErrCodeInternal, "failed to get message context"), nil
+	}
+	hasMoreAfter := len(afterRows) > limit
+	if hasMoreAfter {
+		afterRows = afterRows[:limit]
+	}
+
+	before := contextMessages(beforeRows)
+	after := contextMessages(afterRows)
+
+	// Batch-load reaction aggregates for the whole window in one query
+	messageIDs := make([]string, 0, len(before)+len(after)+1)
+	messageIDs = append(messageIDs, protoMessage.ID)
+	for _, m := range before {
+		messageIDs = append(messageIDs, m.ID)
+	}
+	for _, m := range after {
+		messageIDs = append(messageIDs, m.ID)
+	}
+	reactionsMap, err := db.GetReactionsForMessages(ctx, a.db, messageIDs)
+	if err != nil {
+		a.logger.Error("failed to get reactions", "error", err)
+		// Don't fail the whole request — just continue without reactions
+		reactionsMap = make(map[string][]protocol.Reaction)
+	}
+	markReactedByMe(reactionsMap, user.ID)
+	capReactionUserIDs(reactionsMap)
+	protoMessage.Reactions = reactionsMap[protoMessage.ID]
+	for _, m := range before {
+		m.Reactions = reactionsMap[m.ID]
+	}
+	for _, m := range after {
+		m.Reactions = reactionsMap[m.ID]
+	}
+
+	// Seen-by lists for the same window; nil for rooms over the member
+	// threshold, and a nil map lookup leaves SeenBy empty
+	seenByMap := a.seenByForMessages(ctx, message.RoomID, messageIDs)
+	protoMessage.SeenBy = seenByMap[protoMessage.ID]
+	for _, m := range before {
+		m.SeenBy = seenByMap[m.ID]
+	}
+	for _, m := range after {
+		m.SeenBy = seenByMap[m.ID]
+	}
+
+	// Cursors so the client can keep scrolling in either direction
+	var beforeCursor, afterCursor string
+	if hasMoreBefore && len(before) > 0 {
+		beforeCursor = before[0].CreatedAt
+	}
+	if hasMoreAfter && len(after) > 0 {
+		afterCursor = after[len(after)-1].CreatedAt
+	}
+
 	return Envelope{
 		Type: "get_message_context",
 		Data: protocol.GetMessageContextResponse{
-			Message: protoMessage,
-			RoomID:  message.RoomID,
+			Message:       protoMessage,
+			RoomID:        message.RoomID,
+			Before:        before,
+			After:         after,
+			HasMoreBefore: hasMoreBefore,
+			HasMoreAfter:  hasMoreAfter,
+			BeforeCursor:  beforeCursor,
+			AfterCursor:   afterCursor,
 		},
 	}, nil
 }
 
+// contextMessages converts db rows to protocol messages, blanking the bodies
+// of soft-deleted messages
+func contextMessages(rows []*db.RoomMessage) []*protocol.Message {
+	messages := make([]*protocol.Message, len(rows))
+	for i, m := range rows {
+		messages[i] = &protocol.Message{
+			ID:         m.ID,
+			RoomID:     m.RoomID,
+			UserID:     m.UserID,
+			Username:   m.Username,
+			Body:       m.Body,
+			CreatedAt:  m.CreatedAt,
+			ModifiedAt: m.ModifiedAt,
+			DeletedAt:  m.DeletedAt,
+			IsSystem:   m.IsSystem != 0,
+		}
+		if m.DeletedAt != "" {
+			messages[i].Body = ""
+		}
+	}
+	return messages
+}
+
 // MessageWithUsername is a message with the author's username
 type MessageWithUsername struct {
 	ID         string
@@ -75,12 +189,13 @@ type MessageWithUsername struct {
 	CreatedAt  string
 	ModifiedAt string
 	DeletedAt  string
+	IsSystem   int64
 }
 
 // getMessageByID fetches a single message by ID with the author's username
 func (a *Api) getMessageByID(ctx context.Context, messageID string) (*MessageWithUsername, error) {
 	query := `
-		SELECT m.id, m.room_id, m.user_id, u.username, m.body, m.created_at, m.modified_at, COALESCE(m.deleted_at, '') as deleted_at
+		SELECT m.id, m.room_id, m.user_id, u.username, m.body, m.created_at, m.modified_at, COALESCE(m.deleted_at, '') as deleted_at, m.is_system
 		FROM messages m
 		JOIN users u ON m.user_id = u.id
 		WHERE m.id = $1
@@ -96,6 +211,7 @@ func (a *Api) getMessageByID(ctx context.Context, messageID string) (*MessageWit
 		&msg.CreatedAt,
 		&msg.ModifiedAt,
 		&msg.DeletedAt,
+		&msg.IsSystem,
 	)
 	if err != nil {
 		return nil, err
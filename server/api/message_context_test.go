@@ -3,11 +3,13 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
 )
@@ -273,3 +275,247 @@ func TestGetMessageContext_EmptyMessageID(t *testing.T) {
 		t.Errorf("expected error response for empty message_id, got %s", resp.Type)
 	}
 }
+
+// setupContextWindow creates a user, room, and n sequential messages with
+// IDs msg_context00000i, one second apart, returning the user and room
+func setupContextWindow(t *testing.T, testDB *db.DB, api *Api, n int) (*models.User, *models.Room) {
+	t.Helper()
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:         "usr_test123456789a",
+		Username:   "alice",
+		Password:   "hash",
+		LastRoom:   "roo_general1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = user.Insert(ctx, testDB)
+
+	room := &models.Room{
+		ID:        "roo_general1234",
+		Name:      "general",
+		RoomType:  "channel",
+		IsPrivate: 0,
+		IsDefault: 1,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = room.Insert(ctx, testDB)
+
+	_, _ = testDB.ExecContext(ctx, "INSERT INTO rooms_members (user_id, room_id) VALUES ($1, $2)", user.ID, room.ID)
+
+	base := time.Now().Add(-time.Duration(n) * time.Minute)
+	for i := 0; i < n; i++ {
+		ts := base.Add(time.Duration(i) * time.Second).Format(time.RFC3339Nano)
+		msg := &models.Message{
+			ID:         fmt.Sprintf("msg_context%05d", i),
+			RoomID:     room.ID,
+			UserID:     user.ID,
+			Body:       fmt.Sprintf("message %d", i),
+			CreatedAt:  ts,
+			ModifiedAt: ts,
+		}
+		if err := msg.Insert(ctx, testDB); err != nil {
+			t.Fatalf("failed to insert message %d: %v", i, err)
+		}
+	}
+	return user, room
+}
+
+func TestGetMessageContext_WindowMiddle(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(testDB, logger)
+	user, _ := setupContextWindow(t, testDB, api, 10)
+
+	// Target message 5 with a window of 3 each side
+	req := protocol.GetMessageContextRequest{MessageID: "msg_context00005", Limit: 3}
+	reqData, _ := json.Marshal(req)
+	resp, err := api.GetMessageContext(user, reqData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	contextResp, ok := resp.Data.(protocol.GetMessageContextResponse)
+	if !ok {
+		t.Fatalf("expected GetMessageContextResponse, got %T", resp.Data)
+	}
+
+	if len(contextResp.Before) != 3 || len(contextResp.After) != 3 {
+		t.Fatalf("expected 3 messages each side, got %d before and %d after",
+			len(contextResp.Before), len(contextResp.After))
+	}
+	// Before and after are chronological around the target
+	for i, want := range []string{"msg_context00002", "msg_context00003", "msg_context00004"} {
+		if contextResp.Before[i].ID != want {
+			t.Errorf("before[%d]: expected %s, got %s", i, want, contextResp.Before[i].ID)
+		}
+	}
+	for i, want := range []string{"msg_context00006", "msg_context00007", "msg_context00008"} {
+		if contextResp.After[i].ID != want {
+			t.Errorf("after[%d]: expected %s, got %s", i, want, contextResp.After[i].ID)
+		}
+	}
+	if !contextResp.HasMoreBefore || !contextResp.HasMoreAfter {
+		t.Errorf("expected more messages on both sides, got before=%v after=%v",
+			contextResp.HasMoreBefore, contextResp.HasMoreAfter)
+	}
+	if contextResp.BeforeCursor != contextResp.Before[0].CreatedAt {
+		t.Errorf("expected before cursor %q, got %q", contextResp.Before[0].CreatedAt, contextResp.BeforeCursor)
+	}
+	if contextResp.AfterCursor != contextResp.After[2].CreatedAt {
+		t.Errorf("expected after cursor %q, got %q", contextResp.After[2].CreatedAt, contextResp.AfterCursor)
+	}
+}
+
+func TestGetMessageContext_WindowNearStart(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(testDB, logger)
+	user, _ := setupContextWindow(t, testDB, api, 10)
+
+	// Target message 1: only one message exists before it
+	req := protocol.GetMessageContextRequest{MessageID: "msg_context00001", Limit: 3}
+	reqData, _ := json.Marshal(req)
+	resp, err := api.GetMessageContext(user, reqData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	contextResp, ok := resp.Data.(protocol.GetMessageContextResponse)
+	if !ok {
+		t.Fatalf("expected GetMessageContextResponse, got %T", resp.Data)
+	}
+
+	if len(contextResp.Before) != 1 || contextResp.Before[0].ID != "msg_context00000" {
+		t.Fatalf("expected truncated before window [msg_context00000], got %d messages", len(contextResp.Before))
+	}
+	if contextResp.HasMoreBefore {
+		t.Error("expected no more messages before the start of history")
+	}
+	if contextResp.BeforeCursor != "" {
+		t.Errorf("expected empty before cursor at start of history, got %q", contextResp.BeforeCursor)
+	}
+	if len(contextResp.After) != 3 || !contextResp.HasMoreAfter {
+		t.Errorf("expected a full after window with more available, got %d messages (has_more=%v)",
+			len(contextResp.After), contextResp.HasMoreAfter)
+	}
+}
+
+func TestGetMessageContext_PrivateRoomEnforced(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(testDB, logger)
+
+	member := &models.User{
+		ID:         "usr_member12345678",
+		Username:   "member",
+		Password:   "hash",
+		LastRoom:   "roo_private1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = member.Insert(ctx, testDB)
+	outsider := &models.User{
+		ID:         "usr_outsider123456",
+		Username:   "outsider",
+		Password:   "hash",
+		LastRoom:   "roo_private1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = outsider.Insert(ctx, testDB)
+
+	room := &models.Room{
+		ID:        "roo_private1234",
+		Name:      "secret",
+		RoomType:  "channel",
+		IsPrivate: 1,
+		IsDefault: 0,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = room.Insert(ctx, testDB)
+	_, _ = testDB.ExecContext(ctx, "INSERT INTO rooms_members (user_id, room_id) VALUES ($1, $2)", member.ID, room.ID)
+
+	msg := &models.Message{
+		ID:         "msg_private12345",
+		RoomID:     room.ID,
+		UserID:     member.ID,
+		Body:       "confidential",
+		CreatedAt:  time.Now().Format(time.RFC3339Nano),
+		ModifiedAt: time.Now().Format(time.RFC3339Nano),
+	}
+	_ = msg.Insert(ctx, testDB)
+
+	req := protocol.GetMessageContextRequest{MessageID: msg.ID}
+	reqData, _ := json.Marshal(req)
+	resp, err := api.GetMessageContext(outsider, reqData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Type != "error" {
+		t.Errorf("expected error response for non-member of private room, got %s", resp.Type)
+	}
+}
+
+func TestGetMessageContext_IncludesReactions(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(testDB, logger)
+
+	user, _ := setupContextWindow(t, testDB, api, 5)
+
+	// React to the target message and to a neighbor
+	for _, id := range []string{"msg_context00003", "msg_context00002"} {
+		reactReq, _ := json.Marshal(protocol.AddReactionRequest{MessageID: id, Emoji: "🎉"})
+		if _, err := api.AddReaction(user, reactReq); err != nil {
+			t.Fatalf("failed to add reaction: %v", err)
+		}
+	}
+
+	req := protocol.GetMessageContextRequest{MessageID: "msg_context00003", Limit: 2}
+	reqData, _ := json.Marshal(req)
+
+	resp, err := api.GetMessageContext(user, reqData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctxResp, ok := resp.Data.(protocol.GetMessageContextResponse)
+	if !ok {
+		t.Fatalf("expected GetMessageContextResponse, got %T", resp.Data)
+	}
+
+	// The target message carries its reaction with reacted_by_me set
+	if len(ctxResp.Message.Reactions) != 1 {
+		t.Fatalf("expected 1 reaction on target, got %d", len(ctxResp.Message.Reactions))
+	}
+	if ctxResp.Message.Reactions[0].Emoji != "🎉" {
+		t.Errorf("expected 🎉 reaction, got %s", ctxResp.Message.Reactions[0].Emoji)
+	}
+	if !ctxResp.Message.Reactions[0].ReactedByMe {
+		t.Error("expected reacted_by_me on the target's reaction")
+	}
+
+	// The reacted neighbor carries its reaction too
+	found := false
+	for _, m := range ctxResp.Before {
+		if m.ID == "msg_context00002" {
+			found = true
+			if len(m.Reactions) != 1 {
+				t.Errorf("expected 1 reaction on neighbor, got %d", len(m.Reactions))
+			}
+		}
+	}
+	if !found {
+		t.Error("expected msg_context00002 in the before window")
+	}
+}
@@ -34,10 +34,12 @@ func testDB(t *testing.T) *db.DB {
 			avatar TEXT,
 			last_room TEXT NOT NULL,
 			created_at TEXT NOT NULL,
-			modified_at TEXT NOT NULL
+			modified_at TEXT NOT NULL,
+			last_active_at TEXT NOT NULL DEFAULT ''
 		) STRICT;
 
 		CREATE UNIQUE INDEX IF NOT EXISTS users_username ON users(username);
+		CREATE UNIQUE INDEX IF NOT EXISTS users_username_nocase ON users(username COLLATE NOCASE);
 
 		CREATE TABLE IF NOT EXISTS rooms(
 			id TEXT PRIMARY KEY NOT NULL,
@@ -46,7 +48,20 @@ func testDB(t *testing.T) *db.DB {
 			is_private INTEGER NOT NULL,
 			is_default INTEGER NOT NULL,
 			created_at TEXT NOT NULL,
-			last_message_at TEXT
+			last_message_at TEXT,
+			slow_mode_seconds INTEGER NOT NULL DEFAULT 0,
+			retention_days INTEGER NOT NULL DEFAULT 0,
+			topic TEXT NOT NULL DEFAULT '',
+			join_policy TEXT NOT NULL DEFAULT 'open',
+			post_policy TEXT NOT NULL DEFAULT 'all',
+			announce_pins INTEGER NOT NULL DEFAULT 1
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS room_join_requests(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, room_id)
 		) STRICT;
 
 		CREATE UNIQUE INDEX IF NOT EXISTS rooms_name ON rooms(name) WHERE room_type = 'channel' AND name != '';
@@ -54,6 +69,8 @@ func testDB(t *testing.T) *db.DB {
 		CREATE TABLE IF NOT EXISTS rooms_members(
 			user_id TEXT REFERENCES users(id) NOT NULL,
 			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			role TEXT NOT NULL DEFAULT 'member',
+			muted INTEGER NOT NULL DEFAULT 0,
 			PRIMARY KEY (user_id, room_id)
 		) STRICT;
 
@@ -62,9 +79,123 @@ func testDB(t *testing.T) *db.DB {
 			room_id TEXT REFERENCES rooms(id) NOT NULL,
 			user_id TEXT REFERENCES users(id) NOT NULL,
 			body TEXT NOT NULL,
+			seq INTEGER NOT NULL DEFAULT 0,
 			created_at TEXT NOT NULL,
 			modified_at TEXT NOT NULL,
-			deleted_at TEXT
+			deleted_at TEXT,
+			enc_key_id TEXT NOT NULL DEFAULT '',
+			is_system INTEGER NOT NULL DEFAULT 0
+		) STRICT;
+
+		CREATE TRIGGER IF NOT EXISTS messages_assign_seq AFTER INSERT ON messages
+		WHEN NEW.seq = 0
+		BEGIN
+			UPDATE messages SET seq = (SELECT IFNULL(MAX(seq), 0) + 1 FROM messages) WHERE id = NEW.id;
+		END;
+
+		CREATE TABLE IF NOT EXISTS room_reads(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			last_read_message_id TEXT REFERENCES messages(id) NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, room_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS message_forwards(
+			message_id TEXT PRIMARY KEY REFERENCES messages(id) NOT NULL,
+			source_message_id TEXT REFERENCES messages(id) NOT NULL,
+			source_room_id TEXT REFERENCES rooms(id) NOT NULL,
+			source_user_id TEXT REFERENCES users(id) NOT NULL
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS attachments(
+			id TEXT PRIMARY KEY NOT NULL,
+			message_id TEXT REFERENCES messages(id),
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			url TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			created_at TEXT NOT NULL,
+			thumbnail_url TEXT NOT NULL DEFAULT ''
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS message_quotes(
+			message_id TEXT PRIMARY KEY REFERENCES messages(id) NOT NULL,
+			quoted_message_id TEXT NOT NULL,
+			quoted_user_id TEXT NOT NULL,
+			quoted_username TEXT NOT NULL,
+			excerpt TEXT NOT NULL,
+			quoted_created_at TEXT NOT NULL
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS starred_messages(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			message_id TEXT REFERENCES messages(id) NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, message_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS pinned_messages(
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			message_id TEXT REFERENCES messages(id) NOT NULL,
+			pinned_by TEXT REFERENCES users(id) NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (room_id, message_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS favorite_rooms(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			position INTEGER NOT NULL,
+			PRIMARY KEY (user_id, room_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS hidden_dms(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, room_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS dm_labels(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			label TEXT NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, room_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS message_reports(
+			message_id TEXT REFERENCES messages(id) NOT NULL,
+			reporter_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'open',
+			created_at TEXT NOT NULL,
+			resolved_by TEXT,
+			resolved_at TEXT,
+			PRIMARY KEY (message_id, reporter_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS dm_keys(
+			dm_key TEXT PRIMARY KEY NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			created_at TEXT NOT NULL
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS user_prefs(
+			user_id TEXT PRIMARY KEY REFERENCES users(id) NOT NULL,
+			prefs TEXT NOT NULL,
+			modified_at TEXT NOT NULL
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS room_filters(
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			pattern TEXT NOT NULL,
+			action TEXT NOT NULL DEFAULT 'reject',
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (room_id, pattern)
 		) STRICT;
 
 		CREATE TABLE IF NOT EXISTS reactions(
@@ -76,6 +207,15 @@ func testDB(t *testing.T) *db.DB {
 		) STRICT;
 
 		CREATE INDEX IF NOT EXISTS reactions_message ON reactions(message_id);
+
+		CREATE TABLE IF NOT EXISTS audit_log(
+			id INTEGER PRIMARY KEY,
+			actor_id TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target_id TEXT NOT NULL DEFAULT '',
+			room_id TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL
+		) STRICT;
 	`
 	_, err = database.ExecContext(context.Background(), schema)
 	if err != nil {
@@ -125,12 +265,14 @@ func createTestRoomWithPrivate(t *testing.T, database *db.DB, id, name string, i
 		isPrivateInt = models.TRUE
 	}
 	room := &models.Room{
-		ID:        id,
-		Name:      name,
-		RoomType:  "channel",
-		IsPrivate: isPrivateInt,
-		IsDefault: isDefaultInt,
-		CreatedAt: now,
+		ID:           id,
+		Name:         name,
+		RoomType:     "channel",
+		IsPrivate:    isPrivateInt,
+		IsDefault:    isDefaultInt,
+		CreatedAt:    now,
+		PostPolicy:   db.PostPolicyAll,
+		AnnouncePins: models.TRUE,
 	}
 	err := room.Insert(context.Background(), database)
 	if err != nil {
@@ -145,11 +287,14 @@ func addUserToRoom(t *testing.T, database *db.DB, userID, roomID string) {
 	membership := &models.RoomsMember{
 		UserID: userID,
 		RoomID: roomID,
+		Role:   db.RoleMember,
 	}
 	err := membership.Insert(context.Background(), database)
 	if err != nil {
 		t.Fatalf("Failed to add user to room: %v", err)
 	}
+	// direct inserts bypass the invalidation done by db.AddRoomMember
+	database.InvalidateMembership(userID, roomID)
 }
 
 // TestMessageMessage_ValidMember tests that a room member can send a message
@@ -442,6 +587,8 @@ func TestMessageMessage_MembershipRevokedDuringSession(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to remove user from room: %v", err)
 	}
+	// direct deletes bypass the invalidation done by db.LeaveRoom
+	database.InvalidateMembership(user.ID, room.ID)
 
 	// Second message should fail
 	msgData = protocol.SendMessageRequest{
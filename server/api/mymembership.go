@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// MyMembership handles a request for the caller's own membership and role
+// in a room, so clients can render permission-gated UI without fetching the
+// whole member list. One role query answers both questions; rooms the
+// caller isn't in — private or nonexistent alike — get a bare is_member
+// false so the response leaks nothing about them.
+func (a *Api) MyMembership(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.MyMembershipRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid my_membership json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return errorEnvelope(protocol.ErrCodeValidation, "room_id is required"), nil
+	}
+
+	role, err := db.GetMemberRole(context.Background(), a.db, user.ID, req.RoomID)
+	if err != nil {
+		a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", req.RoomID)
+		return nil, err
+	}
+
+	return &Envelope{
+		Type: "my_membership",
+		Data: protocol.MyMembershipResponse{
+			RoomID:   req.RoomID,
+			IsMember: role != "",
+			Role:     role,
+		},
+	}, nil
+}
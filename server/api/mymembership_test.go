@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// myMembership is a test helper to call MyMembership and unpack the response
+func myMembership(t *testing.T, api *Api, user *models.User, roomID string) protocol.MyMembershipResponse {
+	t.Helper()
+	msg, err := json.Marshal(protocol.MyMembershipRequest{RoomID: roomID})
+	if err != nil {
+		t.Fatalf("Failed to marshal my_membership request: %v", err)
+	}
+	env, err := api.MyMembership(user, msg)
+	if err != nil {
+		t.Fatalf("MyMembership failed: %v", err)
+	}
+	resp, ok := env.Data.(protocol.MyMembershipResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.MyMembershipResponse data type, got %T", env.Data)
+	}
+	return resp
+}
+
+// TestMyMembership tests the member-with-role, non-member, and private-room
+// non-member responses
+func TestMyMembership(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	member := createTestUser(t, database, "usr_mymem0000001", "mymemmember")
+	outsider := createTestUser(t, database, "usr_mymem0000002", "mymemoutsider")
+	room := createTestRoom(t, database, "roo_mymem0000001", "mymem-public", false)
+	private := createTestRoomWithPrivate(t, database, "roo_mymem0000002", "mymem-private", false, true)
+	addUserToRoom(t, database, member.ID, room.ID)
+	addUserToRoom(t, database, member.ID, private.ID)
+	setMemberRole(t, api, member.ID, private.ID, "admin")
+
+	// A member sees their membership and role
+	resp := myMembership(t, api, member, room.ID)
+	if !resp.IsMember || resp.Role != "member" {
+		t.Errorf("Expected member with role 'member', got %+v", resp)
+	}
+	resp = myMembership(t, api, member, private.ID)
+	if !resp.IsMember || resp.Role != "admin" {
+		t.Errorf("Expected member with role 'admin', got %+v", resp)
+	}
+
+	// A non-member gets is_member false with no role
+	resp = myMembership(t, api, outsider, room.ID)
+	if resp.IsMember || resp.Role != "" {
+		t.Errorf("Expected non-member response, got %+v", resp)
+	}
+
+	// A private room the caller isn't in looks exactly like any other
+	// non-membership — nothing about the room leaks
+	resp = myMembership(t, api, outsider, private.ID)
+	if resp.IsMember || resp.Role != "" {
+		t.Errorf("Expected bare non-member response for a private room, got %+v", resp)
+	}
+	if resp.RoomID != private.ID {
+		t.Errorf("Expected room_id %s echoed back, got %s", private.ID, resp.RoomID)
+	}
+}
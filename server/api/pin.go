@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// PinMessageResponse contains the broadcast data and room ID for routing.
+// Messages holds the message_pinned broadcast and, when the room has pin
+// announcements enabled, the system message announcing the pin.
+type PinMessageResponse struct {
+	RoomID   string
+	Messages [][]byte
+}
+
+// PinMessage handles a request to pin a message in its room. Any room
+// member may pin; pinning an already-pinned message is a no-op. Broadcasts
+// a message_pinned message to the room, and when the room has pin
+// announcements enabled, also posts a system message into the room.
+func (a *Api) PinMessage(user *models.User, msg json.RawMessage) (*PinMessageResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.PinMessageRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for pin_message", "error", err)
+		return nil, err
+	}
+
+	if req.MessageID == "" {
+		return nil, fmt.Errorf("message_id is required")
+	}
+
+	ctx := context.Background()
+
+	// Look up the message
+	message, err := models.MessageByID(ctx, a.db, req.MessageID)
+	if err != nil {
+		a.logger.Error("message not found", "error", err, "message_id", req.MessageID)
+		return nil, fmt.Errorf("message not found")
+	}
+	if message.DeletedAt.Valid && message.DeletedAt.String != "" {
+		return nil, fmt.Errorf("cannot pin a deleted message")
+	}
+
+	// Verify room membership
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, message.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotMember
+	}
+
+	pinned, err := db.PinMessage(ctx, a.db, message.RoomID, message.ID, user.ID)
+	if err != nil {
+		a.logger.Error("failed to pin message", "error", err, "message", message.ID, "room", message.RoomID)
+		return nil, err
+	}
+
+	response := &PinMessageResponse{RoomID: message.RoomID}
+
+	// Already pinned: nothing changed, nothing to broadcast
+	if !pinned {
+		return response, nil
+	}
+
+	a.logger.Info("message pinned", "message", message.ID, "room", message.RoomID, "by", user.ID)
+
+	pinBytes, err := json.Marshal(&Envelope{
+		Type: "message_pinned",
+		Data: protocol.MessagePinned{
+			RoomID:    message.RoomID,
+			MessageID: message.ID,
+			UserID:    user.ID,
+			Username:  user.Username,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Messages = append(response.Messages, pinBytes)
+
+	room, err := models.RoomByID(ctx, a.db, message.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", message.RoomID)
+		return nil, err
+	}
+	if room.AnnouncePins != 0 {
+		announcement, err := a.postSystemMessage(ctx, room, user, fmt.Sprintf("%s pinned a message", user.Username))
+		if err != nil {
+			return nil, err
+		}
+		response.Messages = append(response.Messages, announcement)
+	}
+
+	return response, nil
+}
+
+// postSystemMessage inserts a server-generated announcement into a room and
+// returns the marshaled "message" broadcast for it. System messages carry
+// the acting user's ID for attribution, but cannot be edited or deleted and
+// are excluded from search.
+func (a *Api) postSystemMessage(ctx context.Context, room *models.Room, user *models.User, body string) ([]byte, error) {
+	storedBody, encKeyID, err := a.db.Cipher.EncryptBody(body)
+	if err != nil {
+		a.logger.Error("unable to encrypt message body", "error", err)
+		return nil, err
+	}
+
+	now := time.Now().Format(time.RFC3339Nano)
+	dbMessage := models.Message{
+		ID:         models.GenerateMessageID(),
+		RoomID:     room.ID,
+		UserID:     user.ID,
+		Body:       storedBody,
+		EncKeyID:   encKeyID,
+		CreatedAt:  now,
+		ModifiedAt: now,
+		IsSystem:   models.TRUE,
+	}
+	if err := dbMessage.Insert(ctx, a.db); err != nil {
+		a.logger.Error("unable to insert system message", "error", err)
+		return nil, err
+	}
+
+	return json.Marshal(&Envelope{
+		Type: "message",
+		Data: protocol.Message{
+			ID:         dbMessage.ID,
+			Body:       body,
+			RoomID:     dbMessage.RoomID,
+			UserID:     dbMessage.UserID,
+			Username:   user.Username,
+			CreatedAt:  dbMessage.CreatedAt,
+			ModifiedAt: dbMessage.ModifiedAt,
+			IsSystem:   true,
+		},
+	})
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// pinMessage marshals and sends a pin_message request, returning the result
+func pinMessage(t *testing.T, api *Api, user *models.User, messageID string) (*PinMessageResponse, error) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.PinMessageRequest{MessageID: messageID})
+	return api.PinMessage(user, reqJSON)
+}
+
+// unmarshalBroadcast decodes a marshaled broadcast envelope carrying a
+// protocol.Message
+func unmarshalBroadcast(t *testing.T, raw []byte) (string, protocol.Message) {
+	t.Helper()
+	var env struct {
+		Type string           `json:"type"`
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatalf("failed to unmarshal broadcast: %v", err)
+	}
+	return env.Type, env.Data
+}
+
+// TestPinMessage_AnnouncementCreated tests that pinning a message in a room
+// with pin announcements enabled posts a system message into the room
+func TestPinMessage_AnnouncementCreated(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_pin_00000001", "pinner")
+	room := createTestRoom(t, database, "roo_pin_00000001", "pin-room", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	res, err := sendMessage(t, api, user, room.ID, "pin me")
+	if err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	_, sent := unmarshalBroadcast(t, res.Message)
+
+	pinRes, err := pinMessage(t, api, user, sent.ID)
+	if err != nil {
+		t.Fatalf("PinMessage failed: %v", err)
+	}
+	if len(pinRes.Messages) != 2 {
+		t.Fatalf("Expected a pin broadcast and an announcement, got %d messages", len(pinRes.Messages))
+	}
+
+	pinType, _ := unmarshalBroadcast(t, pinRes.Messages[0])
+	if pinType != "message_pinned" {
+		t.Errorf("Expected a message_pinned broadcast, got %q", pinType)
+	}
+
+	annType, announcement := unmarshalBroadcast(t, pinRes.Messages[1])
+	if annType != "message" {
+		t.Errorf("Expected a message broadcast for the announcement, got %q", annType)
+	}
+	if !announcement.IsSystem {
+		t.Error("Expected the announcement to be a system message")
+	}
+	if announcement.Body != "pinner pinned a message" {
+		t.Errorf("Expected announcement body %q, got %q", "pinner pinned a message", announcement.Body)
+	}
+
+	// The announcement is persisted as a system message
+	var isSystem int64
+	row := database.QueryRowContext(context.Background(),
+		"SELECT is_system FROM messages WHERE id = ?", announcement.ID)
+	if err := row.Scan(&isSystem); err != nil {
+		t.Fatalf("Failed to read announcement: %v", err)
+	}
+	if isSystem != 1 {
+		t.Errorf("Expected the stored announcement to have is_system = 1, got %d", isSystem)
+	}
+
+	// Pinning again is a no-op: no new broadcasts, no new announcement
+	rePinRes, err := pinMessage(t, api, user, sent.ID)
+	if err != nil {
+		t.Fatalf("Repeat PinMessage failed: %v", err)
+	}
+	if len(rePinRes.Messages) != 0 {
+		t.Errorf("Expected no broadcasts for an already-pinned message, got %d", len(rePinRes.Messages))
+	}
+}
+
+// TestPinMessage_AnnouncementDisabled tests that disabling pin announcements
+// suppresses the system message but still broadcasts the pin
+func TestPinMessage_AnnouncementDisabled(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_pin_00000002", "quietpinner")
+	room := createTestRoom(t, database, "roo_pin_00000002", "quiet-room", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	setMemberRole(t, api, user.ID, room.ID, "owner")
+
+	reqJSON, _ := json.Marshal(protocol.SetPinAnnouncementsRequest{RoomID: room.ID, Enabled: false})
+	if _, err := api.SetPinAnnouncements(user, reqJSON); err != nil {
+		t.Fatalf("SetPinAnnouncements failed: %v", err)
+	}
+
+	res, err := sendMessage(t, api, user, room.ID, "pin me quietly")
+	if err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	_, sent := unmarshalBroadcast(t, res.Message)
+
+	pinRes, err := pinMessage(t, api, user, sent.ID)
+	if err != nil {
+		t.Fatalf("PinMessage failed: %v", err)
+	}
+	if len(pinRes.Messages) != 1 {
+		t.Fatalf("Expected only the pin broadcast, got %d messages", len(pinRes.Messages))
+	}
+	pinType, _ := unmarshalBroadcast(t, pinRes.Messages[0])
+	if pinType != "message_pinned" {
+		t.Errorf("Expected a message_pinned broadcast, got %q", pinType)
+	}
+
+	var count int
+	row := database.QueryRowContext(context.Background(),
+		"SELECT COUNT(*) FROM messages WHERE room_id = ? AND is_system = 1", room.ID)
+	if err := row.Scan(&count); err != nil {
+		t.Fatalf("Failed to count system messages: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no system messages with announcements disabled, got %d", count)
+	}
+}
+
+// TestPinMessage_SystemMessageNotEditable tests that system messages cannot
+// be edited or deleted, even by the user they are attributed to
+func TestPinMessage_SystemMessageNotEditable(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_pin_00000003", "sysedit")
+	room := createTestRoom(t, database, "roo_pin_00000003", "sysedit-room", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	res, err := sendMessage(t, api, user, room.ID, "pin me")
+	if err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	_, sent := unmarshalBroadcast(t, res.Message)
+
+	pinRes, err := pinMessage(t, api, user, sent.ID)
+	if err != nil {
+		t.Fatalf("PinMessage failed: %v", err)
+	}
+	if len(pinRes.Messages) != 2 {
+		t.Fatalf("Expected a pin broadcast and an announcement, got %d messages", len(pinRes.Messages))
+	}
+	_, announcement := unmarshalBroadcast(t, pinRes.Messages[1])
+
+	// The announcement is attributed to the pinner, but even they cannot
+	// edit it
+	editJSON, _ := json.Marshal(protocol.EditMessageRequest{MessageID: announcement.ID, Body: "rewritten"})
+	if _, err := api.EditMessage(user, editJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected editing a system message to fail with ErrValidation, got %v", err)
+	}
+
+	deleteJSON, _ := json.Marshal(protocol.DeleteMessageRequest{MessageID: announcement.ID})
+	if _, err := api.DeleteMessage(user, deleteJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected deleting a system message to fail with ErrValidation, got %v", err)
+	}
+}
+
+// TestPinMessage_SystemMessagesExcludedFromSearch tests that system messages
+// never appear in search results
+func TestPinMessage_SystemMessagesExcludedFromSearch(t *testing.T) {
+	database := setupSearchTestDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_pin_00000004", "searcher")
+	room := createTestRoom(t, database, "roo_pin_00000004", "search-room", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	now := time.Now().Format(time.RFC3339Nano)
+	regular := &models.Message{
+		ID:         "msg_pin_regular1",
+		RoomID:     room.ID,
+		UserID:     user.ID,
+		Body:       "searcher pinned a message",
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+	if err := regular.Insert(ctx, database); err != nil {
+		t.Fatalf("failed to insert message: %v", err)
+	}
+	system := &models.Message{
+		ID:         "msg_pin_system01",
+		RoomID:     room.ID,
+		UserID:     user.ID,
+		Body:       "searcher pinned a message",
+		CreatedAt:  now,
+		ModifiedAt: now,
+		IsSystem:   models.TRUE,
+	}
+	if err := system.Insert(ctx, database); err != nil {
+		t.Fatalf("failed to insert system message: %v", err)
+	}
+
+	reqJSON, _ := json.Marshal(protocol.SearchRequest{Query: "pinned"})
+	resp, err := api.Search(user, reqJSON)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	searchResp, ok := resp.Data.(protocol.SearchResponse)
+	if !ok {
+		t.Fatalf("Expected a SearchResponse, got %T", resp.Data)
+	}
+	if len(searchResp.Results) != 1 {
+		t.Fatalf("Expected only the regular message in search results, got %d", len(searchResp.Results))
+	}
+	if searchResp.Results[0].MessageID != regular.ID {
+		t.Errorf("Expected result %s, got %s", regular.ID, searchResp.Results[0].MessageID)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// SetPinAnnouncementsResponse contains the broadcast data and room ID for routing
+type SetPinAnnouncementsResponse struct {
+	RoomID  string
+	Message []byte
+}
+
+// SetPinAnnouncements handles a request to enable or disable system-message
+// announcements when a message is pinned in a room. Only room owners and
+// admins may change it. Broadcasts a room_settings_updated message to the
+// room.
+func (a *Api) SetPinAnnouncements(user *models.User, msg json.RawMessage) (*SetPinAnnouncementsResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.SetPinAnnouncementsRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for set_pin_announcements", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required")
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found")
+	}
+
+	// Only owners and admins may change pin announcements
+	role, err := db.GetMemberRole(ctx, a.db, user.ID, room.ID)
+	if err != nil {
+		a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", room.ID)
+		return nil, err
+	}
+	if role != db.RoleOwner && role != db.RoleAdmin {
+		a.logger.Warn("user attempted to set pin announcements without permission", "user", user.ID, "room", room.ID, "role", role)
+		return nil, fmt.Errorf("only room owners and admins can change pin announcements")
+	}
+
+	room.AnnouncePins = models.FALSE
+	if req.Enabled {
+		room.AnnouncePins = models.TRUE
+	}
+	if err := room.Update(ctx, a.db); err != nil {
+		a.logger.Error("failed to update room pin announcements", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	a.logger.Info("pin announcements updated", "room", room.ID, "enabled", req.Enabled, "by", user.ID)
+
+	msgBytes, err := json.Marshal(&Envelope{
+		Type: "room_settings_updated",
+		Data: protocol.RoomSettingsUpdated{
+			RoomID:          room.ID,
+			SlowModeSeconds: room.SlowModeSeconds,
+			RetentionDays:   room.RetentionDays,
+			Topic:           room.Topic,
+			PostPolicy:      room.PostPolicy,
+			AnnouncePins:    room.AnnouncePins != 0,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetPinAnnouncementsResponse{
+		RoomID:  room.ID,
+		Message: msgBytes,
+	}, nil
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// Ping answers an application-level connectivity check by echoing the nonce
+// back immediately. It touches no state, so any authenticated user may ping
+// regardless of room membership.
+func (a *Api) Ping(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.PingRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for ping", "error", err)
+		return nil, err
+	}
+
+	return &Envelope{
+		Type: "pong",
+		Data: protocol.PongResponse{Nonce: req.Nonce},
+	}, nil
+}
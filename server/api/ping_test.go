@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestPing_EchoesNonce tests that a ping is answered with a pong carrying the
+// same nonce, without requiring membership in any room
+func TestPing_EchoesNonce(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	// The user belongs to no rooms; ping must work anyway
+	user := createTestUser(t, database, "usr_ping_user001", "pinguser")
+
+	reqJSON, _ := json.Marshal(protocol.PingRequest{Nonce: "abc123"})
+	response, err := api.Ping(user, reqJSON)
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if response.Type != "pong" {
+		t.Errorf("Expected envelope type 'pong', got '%s'", response.Type)
+	}
+	pong, ok := response.Data.(protocol.PongResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.PongResponse data type, got %T", response.Data)
+	}
+	if pong.Nonce != "abc123" {
+		t.Errorf("Expected nonce 'abc123', got '%s'", pong.Nonce)
+	}
+}
+
+// TestPing_EmptyNonce tests that a ping with no nonce still gets a pong
+func TestPing_EmptyNonce(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_ping_user002", "pinguser2")
+
+	response, err := api.Ping(user, json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	pong, ok := response.Data.(protocol.PongResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.PongResponse data type, got %T", response.Data)
+	}
+	if pong.Nonce != "" {
+		t.Errorf("Expected empty nonce, got '%s'", pong.Nonce)
+	}
+}
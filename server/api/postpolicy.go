@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// SetPostPolicyResponse contains the broadcast data and room ID for routing
+type SetPostPolicyResponse struct {
+	RoomID  string
+	Message []byte
+}
+
+// SetPostPolicy handles a request to change who may post in a room. Only
+// room owners and admins may change it; the 'admins' policy makes the room
+// announcement-only. Broadcasts a room_settings_updated message to the room.
+func (a *Api) SetPostPolicy(user *models.User, msg json.RawMessage) (*SetPostPolicyResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.SetPostPolicyRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for set_post_policy", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required")
+	}
+	if req.Policy != db.PostPolicyAll && req.Policy != db.PostPolicyAdmins {
+		return nil, fmt.Errorf("post policy must be %q or %q: %w", db.PostPolicyAll, db.PostPolicyAdmins, ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found")
+	}
+
+	// Only owners and admins may change the post policy
+	role, err := db.GetMemberRole(ctx, a.db, user.ID, room.ID)
+	if err != nil {
+		a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", room.ID)
+		return nil, err
+	}
+	if role != db.RoleOwner && role != db.RoleAdmin {
+		a.logger.Warn("user attempted to set post policy without permission", "user", user.ID, "room", room.ID, "role", role)
+		return nil, fmt.Errorf("only room owners and admins can change the post policy")
+	}
+
+	room.PostPolicy = req.Policy
+	if err := room.Update(ctx, a.db); err != nil {
+		a.logger.Error("failed to update room post policy", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	a.logger.Info("post policy updated", "room", room.ID, "policy", req.Policy, "by", user.ID)
+
+	msgBytes, err := json.Marshal(&Envelope{
+		Type: "room_settings_updated",
+		Data: protocol.RoomSettingsUpdated{
+			RoomID:          room.ID,
+			SlowModeSeconds: room.SlowModeSeconds,
+			RetentionDays:   room.RetentionDays,
+			Topic:           room.Topic,
+			PostPolicy:      room.PostPolicy,
+			AnnouncePins:    room.AnnouncePins != 0,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetPostPolicyResponse{
+		RoomID:  room.ID,
+		Message: msgBytes,
+	}, nil
+}
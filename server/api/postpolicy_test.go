@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// setPostPolicy marshals and sends a set_post_policy request
+func setPostPolicy(t *testing.T, api *Api, user *models.User, roomID, policy string) (*SetPostPolicyResponse, error) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.SetPostPolicyRequest{RoomID: roomID, Policy: policy})
+	return api.SetPostPolicy(user, reqJSON)
+}
+
+// TestPostPolicy_AnnouncementMode tests that when a room's post policy is
+// 'admins', members cannot post while admins can, and members can still
+// react and read history
+func TestPostPolicy_AnnouncementMode(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_ppol_0000001", "ppol-owner")
+	admin := createTestUser(t, database, "usr_ppol_0000002", "ppol-admin")
+	member := createTestUser(t, database, "usr_ppol_0000003", "ppol-member")
+	room := createTestRoom(t, database, "roo_ppol_0000001", "announcements", false)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	addUserToRoom(t, database, admin.ID, room.ID)
+	addUserToRoom(t, database, member.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+	setMemberRole(t, api, admin.ID, room.ID, db.RoleAdmin)
+
+	res, err := setPostPolicy(t, api, owner, room.ID, db.PostPolicyAdmins)
+	if err != nil {
+		t.Fatalf("SetPostPolicy failed: %v", err)
+	}
+	var env struct {
+		Type string                       `json:"type"`
+		Data protocol.RoomSettingsUpdated `json:"data"`
+	}
+	if err := json.Unmarshal(res.Message, &env); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast: %v", err)
+	}
+	if env.Type != "room_settings_updated" {
+		t.Errorf("expected type 'room_settings_updated', got %s", env.Type)
+	}
+	if env.Data.PostPolicy != db.PostPolicyAdmins {
+		t.Errorf("expected post policy %q, got %q", db.PostPolicyAdmins, env.Data.PostPolicy)
+	}
+
+	// A member's post is rejected
+	if _, err := sendMessage(t, api, member, room.ID, "members can't post"); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error for a member post, got %v", err)
+	}
+
+	// Posts from the admin and the owner succeed
+	adminRes, err := sendMessage(t, api, admin, room.ID, "admin announcement")
+	if err != nil {
+		t.Fatalf("expected the admin's post to succeed, got %v", err)
+	}
+	if _, err := sendMessage(t, api, owner, room.ID, "owner announcement"); err != nil {
+		t.Fatalf("expected the owner's post to succeed, got %v", err)
+	}
+
+	// The member can still react to the admin's message
+	_, adminMsg := unmarshalBroadcast(t, adminRes.Message)
+	reactJSON, _ := json.Marshal(protocol.AddReactionRequest{MessageID: adminMsg.ID, Emoji: "📣"})
+	if _, err := api.AddReaction(member, reactJSON); err != nil {
+		t.Errorf("expected the member to be able to react, got %v", err)
+	}
+
+	// ...and still read the room's history
+	histJSON, _ := json.Marshal(protocol.HistoryRequest{RoomID: room.ID})
+	hist, err := api.HistoryMessage(member, histJSON)
+	if err != nil {
+		t.Fatalf("expected the member to be able to read history, got %v", err)
+	}
+	if len(hist.Data.(protocol.HistoryResponse).Messages) != 2 {
+		t.Errorf("expected 2 messages of history, got %+v", hist.Data)
+	}
+
+	// Switching back to 'all' lets the member post again
+	if _, err := setPostPolicy(t, api, owner, room.ID, db.PostPolicyAll); err != nil {
+		t.Fatalf("SetPostPolicy failed: %v", err)
+	}
+	if _, err := sendMessage(t, api, member, room.ID, "members can post again"); err != nil {
+		t.Errorf("expected the member's post to succeed after reverting, got %v", err)
+	}
+}
+
+// TestPostPolicy_RequiresAdmin tests that regular members cannot change the
+// post policy
+func TestPostPolicy_RequiresAdmin(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	member := createTestUser(t, database, "usr_ppol_0000004", "ppol-plain")
+	room := createTestRoom(t, database, "roo_ppol_0000002", "locked", false)
+	addUserToRoom(t, database, member.ID, room.ID)
+
+	if _, err := setPostPolicy(t, api, member, room.ID, db.PostPolicyAdmins); err == nil {
+		t.Error("expected an error when a member sets the post policy")
+	}
+}
+
+// TestPostPolicy_InvalidPolicy tests that unknown policy values are rejected
+func TestPostPolicy_InvalidPolicy(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_ppol_0000005", "ppol-owner2")
+	room := createTestRoom(t, database, "roo_ppol_0000003", "strict", false)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+
+	if _, err := setPostPolicy(t, api, owner, room.ID, "nobody"); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error for an unknown policy, got %v", err)
+	}
+}
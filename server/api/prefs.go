@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxPrefsBytes caps the stored preference blob; preferences are small UI
+// toggles, not general storage
+const maxPrefsBytes = 4096
+
+// allowedPrefKeys are the top-level preference names clients may store.
+// Values are opaque to the server, so new options within a key need no
+// server change; new keys do.
+var allowedPrefKeys = map[string]bool{
+	"theme":        true,
+	"clock_24h":    true,
+	"compact_mode": true,
+}
+
+// GetPrefs handles a request for the caller's stored display preferences
+func (a *Api) GetPrefs(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	prefs, err := a.loadPrefs(context.Background(), user.ID)
+	if err != nil {
+		a.logger.Error("failed to load prefs", "error", err, "user", user.ID)
+		return nil, err
+	}
+	return &Envelope{
+		Type: "get_prefs",
+		Data: protocol.PrefsResponse{Prefs: prefs},
+	}, nil
+}
+
+// SetPrefs handles a request to replace the caller's display preferences.
+// Top-level keys must be in the allowlist and the whole blob must fit the
+// size cap; values are stored as-is.
+func (a *Api) SetPrefs(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.SetPrefsRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid set_prefs json", "error", err)
+		return nil, err
+	}
+	if req.Prefs == nil {
+		return nil, fmt.Errorf("prefs is required: %w", ErrValidation)
+	}
+	for key := range req.Prefs {
+		if !allowedPrefKeys[key] {
+			return nil, fmt.Errorf("unknown preference %q: %w", key, ErrValidation)
+		}
+	}
+
+	blob, err := json.Marshal(req.Prefs)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) > maxPrefsBytes {
+		return nil, fmt.Errorf("preferences exceed %d bytes: %w", maxPrefsBytes, ErrValidation)
+	}
+
+	ctx := context.Background()
+	if err := db.SetUserPrefs(ctx, a.db, user.ID, string(blob), time.Now().Format(time.RFC3339)); err != nil {
+		a.logger.Error("failed to store prefs", "error", err, "user", user.ID)
+		return nil, err
+	}
+
+	return &Envelope{
+		Type: "set_prefs",
+		Data: protocol.PrefsResponse{Prefs: req.Prefs},
+	}, nil
+}
+
+// loadPrefs fetches and decodes a user's stored preferences; users who
+// never saved any get an empty map
+func (a *Api) loadPrefs(ctx context.Context, userID string) (map[string]any, error) {
+	blob, err := db.GetUserPrefs(ctx, a.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := map[string]any{}
+	if blob != "" {
+		if err := json.Unmarshal([]byte(blob), &prefs); err != nil {
+			return nil, err
+		}
+	}
+	return prefs, nil
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// setPrefs stores preferences for a user and returns the response envelope
+func setPrefs(t *testing.T, api *Api, user *models.User, prefs map[string]any) (*Envelope, error) {
+	t.Helper()
+	reqJSON, err := json.Marshal(protocol.SetPrefsRequest{Prefs: prefs})
+	if err != nil {
+		t.Fatalf("Failed to marshal set_prefs request: %v", err)
+	}
+	return api.SetPrefs(user, reqJSON)
+}
+
+// getPrefs fetches a user's stored preferences
+func getPrefs(t *testing.T, api *Api, user *models.User) map[string]any {
+	t.Helper()
+	res, err := api.GetPrefs(user, nil)
+	if err != nil {
+		t.Fatalf("GetPrefs failed: %v", err)
+	}
+	data, ok := res.Data.(protocol.PrefsResponse)
+	if !ok {
+		t.Fatalf("Expected PrefsResponse, got %T", res.Data)
+	}
+	return data.Prefs
+}
+
+// TestPrefs_RoundTrip tests that stored preferences come back through
+// get_prefs and init
+func TestPrefs_RoundTrip(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_prefs0000001", "prefsuser")
+	createTestRoom(t, database, "roo_prefs0000001", "prefs-room", true)
+
+	// A user who never saved prefs gets an empty map
+	if prefs := getPrefs(t, api, user); len(prefs) != 0 {
+		t.Errorf("Expected no prefs for a fresh user, got %v", prefs)
+	}
+
+	res, err := setPrefs(t, api, user, map[string]any{
+		"theme":        "dark",
+		"clock_24h":    true,
+		"compact_mode": false,
+	})
+	if err != nil {
+		t.Fatalf("SetPrefs failed: %v", err)
+	}
+	if res.Type != "set_prefs" {
+		t.Errorf("Expected type 'set_prefs', got %q", res.Type)
+	}
+
+	prefs := getPrefs(t, api, user)
+	if prefs["theme"] != "dark" {
+		t.Errorf("Expected theme 'dark', got %v", prefs["theme"])
+	}
+	if prefs["clock_24h"] != true {
+		t.Errorf("Expected clock_24h true, got %v", prefs["clock_24h"])
+	}
+
+	// Overwriting replaces the whole blob
+	if _, err := setPrefs(t, api, user, map[string]any{"theme": "light"}); err != nil {
+		t.Fatalf("SetPrefs failed: %v", err)
+	}
+	prefs = getPrefs(t, api, user)
+	if prefs["theme"] != "light" {
+		t.Errorf("Expected theme 'light', got %v", prefs["theme"])
+	}
+	if _, ok := prefs["clock_24h"]; ok {
+		t.Error("Expected clock_24h to be gone after the overwrite")
+	}
+
+	// Init carries the stored prefs so clients skip a round trip
+	initRes, err := api.InitMessage(user, nil)
+	if err != nil {
+		t.Fatalf("InitMessage failed: %v", err)
+	}
+	initData, ok := initRes.Envelope.Data.(protocol.InitResponse)
+	if !ok {
+		t.Fatalf("Expected InitResponse, got %T", initRes.Envelope.Data)
+	}
+	if initData.Prefs["theme"] != "light" {
+		t.Errorf("Expected init prefs theme 'light', got %v", initData.Prefs["theme"])
+	}
+}
+
+// TestPrefs_Validation tests that unknown keys and oversized blobs are
+// rejected
+func TestPrefs_Validation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_prefs0000011", "prefsvalidate")
+
+	if _, err := setPrefs(t, api, user, map[string]any{"favorite_color": "red"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an unknown key, got %v", err)
+	}
+
+	oversized := map[string]any{"theme": strings.Repeat("x", maxPrefsBytes)}
+	if _, err := setPrefs(t, api, user, oversized); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an oversized blob, got %v", err)
+	}
+
+	// Neither attempt stored anything
+	if prefs := getPrefs(t, api, user); len(prefs) != 0 {
+		t.Errorf("Expected no stored prefs after rejected writes, got %v", prefs)
+	}
+}
+
+// TestPrefs_IsolatedPerUser tests that one user's preferences never leak
+// into another's
+func TestPrefs_IsolatedPerUser(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_prefs0000021", "prefsalice")
+	bob := createTestUser(t, database, "usr_prefs0000022", "prefsbob")
+
+	if _, err := setPrefs(t, api, alice, map[string]any{"theme": "dark"}); err != nil {
+		t.Fatalf("SetPrefs failed: %v", err)
+	}
+	if _, err := setPrefs(t, api, bob, map[string]any{"theme": "light"}); err != nil {
+		t.Fatalf("SetPrefs failed: %v", err)
+	}
+
+	if prefs := getPrefs(t, api, alice); prefs["theme"] != "dark" {
+		t.Errorf("Expected alice's theme 'dark', got %v", prefs["theme"])
+	}
+	if prefs := getPrefs(t, api, bob); prefs["theme"] != "light" {
+		t.Errorf("Expected bob's theme 'light', got %v", prefs["theme"])
+	}
+}
@@ -2,13 +2,19 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
 )
 
+// maxProfileBatch caps how many profiles one get_profiles call may fetch
+const maxProfileBatch = 100
+
 // GetProfile handles a request to get a user's profile.
 func (a *Api) GetProfile(user *models.User, msg json.RawMessage) (*Envelope, error) {
 	var req protocol.GetProfileRequest
@@ -17,7 +23,7 @@ func (a *Api) GetProfile(user *models.User, msg json.RawMessage) (*Envelope, err
 	}
 
 	if req.UserID == "" {
-		return ErrorResponse("user_id is required"), nil
+		return errorEnvelope(protocol.ErrCodeValidation, "user_id is required"), nil
 	}
 
 	ctx := context.Background()
@@ -26,7 +32,7 @@ func (a *Api) GetProfile(user *models.User, msg json.RawMessage) (*Envelope, err
 	targetUser, err := models.UserByID(ctx, a.db, req.UserID)
 	if err != nil {
 		a.logger.Error("failed to get user", "error", err, "user_id", req.UserID)
-		return ErrorResponse("user not found"), nil
+		return errorEnvelope(protocol.ErrCodeNotFound, "user not found"), nil
 	}
 
 	return &Envelope{
@@ -39,6 +45,60 @@ func (a *Api) GetProfile(user *models.User, msg json.RawMessage) (*Envelope, err
 				Status:      targetUser.Status,
 				Avatar:      targetUser.Avatar.String,
 			},
+			LastActiveAt: targetUser.LastActiveAt,
+		},
+	}, nil
+}
+
+// GetProfiles handles a request to fetch several users' profiles in one
+// call. Repeated IDs are deduplicated and unknown IDs are silently skipped.
+func (a *Api) GetProfiles(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.GetProfilesRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return nil, err
+	}
+
+	if len(req.UserIDs) == 0 {
+		return errorEnvelope(protocol.ErrCodeValidation, "user_ids is required"), nil
+	}
+
+	// Deduplicate; member lists and reaction tooltips repeat IDs freely
+	seen := make(map[string]bool)
+	ids := make([]string, 0, len(req.UserIDs))
+	for _, id := range req.UserIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) > maxProfileBatch {
+		return errorEnvelope(protocol.ErrCodeValidation, fmt.Sprintf("at most %d user_ids per request", maxProfileBatch)), nil
+	}
+
+	ctx := context.Background()
+
+	dbUsers, err := db.GetUsersByIDs(ctx, a.db, ids)
+	if err != nil {
+		a.logger.Error("failed to get users by ids", "error", err)
+		return nil, err
+	}
+
+	users := make([]protocol.User, len(dbUsers))
+	for i, u := range dbUsers {
+		users[i] = protocol.User{
+			ID:          u.ID,
+			Username:    u.Username,
+			DisplayName: u.DisplayName,
+			Status:      u.Status,
+			Avatar:      u.Avatar,
+		}
+	}
+
+	return &Envelope{
+		Type: "get_profiles",
+		Data: protocol.GetProfilesResponse{
+			Users: users,
 		},
 	}, nil
 }
@@ -55,11 +115,26 @@ func (a *Api) UpdateProfile(user *models.User, msg json.RawMessage) (*Envelope,
 	// Update fields if provided
 	updated := false
 	if req.DisplayName != nil {
-		user.DisplayName = *req.DisplayName
+		name := sanitizeProfileText(*req.DisplayName)
+		if len(name) > maxDisplayNameLength {
+			return nil, fmt.Errorf("display name exceeds the %d byte limit: %w", maxDisplayNameLength, ErrValidation)
+		}
+		user.DisplayName = name
 		updated = true
 	}
 	if req.Status != nil {
-		user.Status = *req.Status
+		status := sanitizeProfileText(*req.Status)
+		if len(status) > maxStatusLength {
+			return nil, fmt.Errorf("status exceeds the %d byte limit: %w", maxStatusLength, ErrValidation)
+		}
+		user.Status = status
+		updated = true
+	}
+	if req.Avatar != nil {
+		if err := a.validateAvatarURL(*req.Avatar); err != nil {
+			return nil, err
+		}
+		user.Avatar = sql.NullString{String: *req.Avatar, Valid: *req.Avatar != ""}
 		updated = true
 	}
 
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// getProfiles is a helper that runs GetProfiles and returns the response data
+func getProfiles(t *testing.T, api *Api, user *models.User, userIDs []string) protocol.GetProfilesResponse {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.GetProfilesRequest{UserIDs: userIDs})
+	response, err := api.GetProfiles(user, reqJSON)
+	if err != nil {
+		t.Fatalf("GetProfiles failed: %v", err)
+	}
+	resp, ok := response.Data.(protocol.GetProfilesResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.GetProfilesResponse data type, got %T", response.Data)
+	}
+	return resp
+}
+
+// TestGetProfiles_Batch tests that several profiles come back in one call
+func TestGetProfiles_Batch(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	requester := createTestUser(t, database, "usr_profs_req001", "profsrequester")
+	alice := createTestUser(t, database, "usr_profs_ali001", "profsalice")
+	bob := createTestUser(t, database, "usr_profs_bob001", "profsbob")
+
+	resp := getProfiles(t, api, requester, []string{bob.ID, alice.ID})
+	if len(resp.Users) != 2 {
+		t.Fatalf("expected 2 profiles, got %d", len(resp.Users))
+	}
+	// Results are ordered by username
+	if resp.Users[0].Username != "profsalice" || resp.Users[1].Username != "profsbob" {
+		t.Errorf("expected alice then bob, got %q then %q", resp.Users[0].Username, resp.Users[1].Username)
+	}
+}
+
+// TestGetProfiles_DeduplicatesRepeats tests that repeated IDs return one
+// profile each
+func TestGetProfiles_DeduplicatesRepeats(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	requester := createTestUser(t, database, "usr_profs_req002", "profsrequester2")
+	alice := createTestUser(t, database, "usr_profs_ali002", "profsalice2")
+
+	resp := getProfiles(t, api, requester, []string{alice.ID, alice.ID, alice.ID})
+	if len(resp.Users) != 1 {
+		t.Errorf("expected repeated IDs to be deduplicated to 1 profile, got %d", len(resp.Users))
+	}
+}
+
+// TestGetProfiles_SkipsUnknown tests that unknown IDs are silently skipped
+func TestGetProfiles_SkipsUnknown(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	requester := createTestUser(t, database, "usr_profs_req003", "profsrequester3")
+	alice := createTestUser(t, database, "usr_profs_ali003", "profsalice3")
+
+	resp := getProfiles(t, api, requester, []string{alice.ID, "usr_profs_nobody"})
+	if len(resp.Users) != 1 {
+		t.Fatalf("expected unknown IDs to be skipped, got %d profiles", len(resp.Users))
+	}
+	if resp.Users[0].ID != alice.ID {
+		t.Errorf("expected %s, got %s", alice.ID, resp.Users[0].ID)
+	}
+}
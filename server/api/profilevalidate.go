@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Caps on free-text profile fields, measured in bytes like message bodies
+const (
+	maxDisplayNameLength = 100
+	maxStatusLength      = 200
+)
+
+// avatarHostsFromEnv reads AVATAR_ALLOWED_HOSTS, a comma-separated list of
+// hosts avatar URLs may point at. When unset, any http(s) host is allowed;
+// relative paths (this server's own uploads) are always allowed either way.
+func avatarHostsFromEnv(logger *slog.Logger) []string {
+	val := os.Getenv("AVATAR_ALLOWED_HOSTS")
+	if val == "" {
+		return nil
+	}
+	var hosts []string
+	for _, h := range strings.Split(val, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		hosts = append(hosts, h)
+	}
+	logger.Info("avatar host allowlist configured", "hosts", hosts)
+	return hosts
+}
+
+// validateAvatarURL rejects avatar URLs that aren't http(s) or that point at
+// a host outside the configured allowlist. An empty string (clearing the
+// avatar) and relative paths under this server are always fine.
+func (a *Api) validateAvatarURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("avatar must be a valid URL: %w", ErrValidation)
+	}
+	// A relative path resolves to this server, e.g. an uploaded file
+	if u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/") {
+		return nil
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("avatar URL must be http or https: %w", ErrValidation)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("avatar URL must include a host: %w", ErrValidation)
+	}
+	if len(a.avatarHosts) > 0 {
+		host := strings.ToLower(u.Hostname())
+		for _, allowed := range a.avatarHosts {
+			if host == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("avatar host %q is not allowed on this server: %w", host, ErrValidation)
+	}
+	return nil
+}
+
+// sanitizeProfileText trims surrounding whitespace and strips control
+// characters, which have no place in single-line profile fields
+func sanitizeProfileText(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s))
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxQuoteExcerpt caps the number of characters snapshotted from a quoted
+// message's body
+const maxQuoteExcerpt = 200
+
+// quoteSnapshot builds the stored snapshot of a quoted message. The quoted
+// message must be in the room the new message is being sent to (which the
+// caller has already verified the sender can post in) and not deleted.
+func (a *Api) quoteSnapshot(ctx context.Context, roomID, quoteMessageID string) (*protocol.QuotedMessage, error) {
+	quoted, err := models.MessageByID(ctx, a.db, quoteMessageID)
+	if err != nil {
+		a.logger.Error("unable to find quoted message", "error", err, "message", quoteMessageID)
+		return nil, fmt.Errorf("quoted message not found: %w", ErrValidation)
+	}
+	if quoted.RoomID != roomID {
+		a.logger.Warn("user attempted to quote a message from another room", "message", quoteMessageID, "room", roomID)
+		return nil, fmt.Errorf("quoted message is not in this room: %w", ErrValidation)
+	}
+	if quoted.DeletedAt.Valid && quoted.DeletedAt.String != "" {
+		return nil, fmt.Errorf("cannot quote a deleted message: %w", ErrValidation)
+	}
+
+	body, err := a.db.Cipher.DecryptBody(quoted.Body, quoted.EncKeyID)
+	if err != nil {
+		a.logger.Error("unable to decrypt quoted message", "error", err, "message", quoteMessageID)
+		return nil, err
+	}
+	author, err := models.UserByID(ctx, a.db, quoted.UserID)
+	if err != nil {
+		a.logger.Error("unable to find quoted message author", "error", err, "user", quoted.UserID)
+		return nil, err
+	}
+
+	excerpt := body
+	if runes := []rune(excerpt); len(runes) > maxQuoteExcerpt {
+		excerpt = string(runes[:maxQuoteExcerpt]) + "…"
+	}
+
+	return &protocol.QuotedMessage{
+		MessageID: quoted.ID,
+		UserID:    quoted.UserID,
+		Username:  author.Username,
+		Excerpt:   excerpt,
+		CreatedAt: quoted.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// sendQuotingMessage sends a message quoting another and returns the
+// broadcast protocol.Message
+func sendQuotingMessage(t *testing.T, api *Api, user *models.User, roomID, body, quoteID string) protocol.Message {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.SendMessageRequest{
+		Body:           body,
+		RoomID:         roomID,
+		QuoteMessageID: quoteID,
+	})
+	res, err := api.MessageMessage(user, reqJSON)
+	if err != nil {
+		t.Fatalf("Failed to send quoting message: %v", err)
+	}
+	var envelope struct {
+		Type string           `json:"type"`
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(res.Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast: %v", err)
+	}
+	return envelope.Data
+}
+
+// TestQuote_SnapshotSurvivesDeletion tests that the quoted excerpt is
+// snapshotted at send time and still rendered after the original message
+// is deleted
+func TestQuote_SnapshotSurvivesDeletion(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	author := createTestUser(t, database, "usr_quote_auth01", "quote-author")
+	quoter := createTestUser(t, database, "usr_quote_quot01", "quote-quoter")
+	room := createTestRoom(t, database, "roo_quote_000001", "quote-room", false)
+	addUserToRoom(t, database, author.ID, room.ID)
+	addUserToRoom(t, database, quoter.ID, room.ID)
+
+	original, err := sendMessage(t, api, author, room.ID, "the original hot take")
+	if err != nil {
+		t.Fatalf("Failed to send original message: %v", err)
+	}
+	var originalEnvelope struct {
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(original.Message, &originalEnvelope); err != nil {
+		t.Fatalf("Failed to unmarshal original broadcast: %v", err)
+	}
+	originalID := originalEnvelope.Data.ID
+
+	sent := sendQuotingMessage(t, api, quoter, room.ID, "quoting this", originalID)
+	if sent.Quote == nil {
+		t.Fatal("Expected the broadcast message to carry a quote")
+	}
+	if sent.Quote.MessageID != originalID ||
+		sent.Quote.Username != "quote-author" ||
+		sent.Quote.Excerpt != "the original hot take" {
+		t.Errorf("Unexpected quote snapshot: %+v", sent.Quote)
+	}
+
+	// Delete the original; the snapshot must survive
+	delJSON, _ := json.Marshal(protocol.DeleteMessageRequest{MessageID: originalID})
+	if _, err := api.DeleteMessage(author, delJSON); err != nil {
+		t.Fatalf("Failed to delete original message: %v", err)
+	}
+
+	histJSON, _ := json.Marshal(protocol.HistoryRequest{RoomID: room.ID})
+	envelope, err := api.HistoryMessage(quoter, histJSON)
+	if err != nil {
+		t.Fatalf("Failed to fetch history: %v", err)
+	}
+	history, ok := envelope.Data.(protocol.HistoryResponse)
+	if !ok {
+		t.Fatalf("Expected HistoryResponse, got %T", envelope.Data)
+	}
+	found := false
+	for _, m := range history.Messages {
+		if m.ID == sent.ID {
+			found = true
+			if m.Quote == nil || m.Quote.Excerpt != "the original hot take" {
+				t.Errorf("Expected the quote to survive deletion of the original, got %+v", m.Quote)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected the quoting message in history")
+	}
+}
+
+// TestQuote_CrossRoomRejected tests that a message in another room cannot
+// be quoted
+func TestQuote_CrossRoomRejected(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_quote_user02", "quote-crossr")
+	room1 := createTestRoom(t, database, "roo_quote_000002", "quote-one", false)
+	room2 := createTestRoom(t, database, "roo_quote_000003", "quote-two", false)
+	addUserToRoom(t, database, user.ID, room1.ID)
+	addUserToRoom(t, database, user.ID, room2.ID)
+
+	original, err := sendMessage(t, api, user, room1.ID, "lives in room one")
+	if err != nil {
+		t.Fatalf("Failed to send original message: %v", err)
+	}
+	var originalEnvelope struct {
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(original.Message, &originalEnvelope); err != nil {
+		t.Fatalf("Failed to unmarshal original broadcast: %v", err)
+	}
+
+	reqJSON, _ := json.Marshal(protocol.SendMessageRequest{
+		Body:           "quoting across rooms",
+		RoomID:         room2.ID,
+		QuoteMessageID: originalEnvelope.Data.ID,
+	})
+	_, err = api.MessageMessage(user, reqJSON)
+	if err == nil {
+		t.Fatal("Expected quoting a message from another room to be rejected")
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+}
+
+// TestQuote_ExcerptTruncated tests that long quoted bodies are truncated
+// to the excerpt cap
+func TestQuote_ExcerptTruncated(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_quote_user03", "quote-longpost")
+	room := createTestRoom(t, database, "roo_quote_000004", "quote-long", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	longBody := strings.Repeat("a", maxQuoteExcerpt+50)
+	original, err := sendMessage(t, api, user, room.ID, longBody)
+	if err != nil {
+		t.Fatalf("Failed to send original message: %v", err)
+	}
+	var originalEnvelope struct {
+		Data protocol.Message `json:"data"`
+	}
+	if err := json.Unmarshal(original.Message, &originalEnvelope); err != nil {
+		t.Fatalf("Failed to unmarshal original broadcast: %v", err)
+	}
+
+	sent := sendQuotingMessage(t, api, user, room.ID, "tldr", originalEnvelope.Data.ID)
+	if sent.Quote == nil {
+		t.Fatal("Expected the broadcast message to carry a quote")
+	}
+	want := strings.Repeat("a", maxQuoteExcerpt) + "…"
+	if sent.Quote.Excerpt != want {
+		t.Errorf("Expected a truncated excerpt of %d characters, got %d", maxQuoteExcerpt+1, len([]rune(sent.Quote.Excerpt)))
+	}
+}
@@ -1,11 +1,17 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"sync"
 	"testing"
 
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
 )
 
@@ -188,3 +194,160 @@ func TestRemoveReaction_Idempotent(t *testing.T) {
 		t.Fatalf("RemoveReaction should be idempotent but failed: %v", err)
 	}
 }
+
+// TestReaction_ConcurrentAddRemove fires many simultaneous adds, then many
+// simultaneous removes, for one message and verifies the final count and
+// user list are exactly correct
+func TestReaction_ConcurrentAddRemove(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_rconc_owner01", "rconc-owner")
+	room := createTestRoom(t, database, "roo_rconc_000001", "general", true)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	msgID := createTestMessageSimple(t, api, owner, room.ID, "pile on")
+
+	const reactors = 16
+	users := make([]*models.User, reactors)
+	for i := range users {
+		users[i] = createTestUser(t, database, fmt.Sprintf("usr_rconc_%07d", i), fmt.Sprintf("rconc-%d", i))
+		addUserToRoom(t, database, users[i].ID, room.ID)
+	}
+
+	addJSON, _ := json.Marshal(protocol.AddReactionRequest{MessageID: msgID, Emoji: "👍"})
+	removeJSON, _ := json.Marshal(protocol.RemoveReactionRequest{MessageID: msgID, Emoji: "👍"})
+
+	// Every reactor adds at the same time
+	var wg sync.WaitGroup
+	errs := make(chan error, reactors)
+	for _, u := range users {
+		wg.Add(1)
+		go func(u *models.User) {
+			defer wg.Done()
+			if _, err := api.AddReaction(u, addJSON); err != nil {
+				errs <- err
+			}
+		}(u)
+	}
+	wg.Wait()
+
+	// The second half removes at the same time, racing a second round of
+	// adds from the first half (which are idempotent no-ops)
+	for i, u := range users {
+		wg.Add(1)
+		go func(i int, u *models.User) {
+			defer wg.Done()
+			var err error
+			if i < reactors/2 {
+				_, err = api.AddReaction(u, addJSON)
+			} else {
+				_, err = api.RemoveReaction(u, removeJSON)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}(i, u)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent reaction failed: %v", err)
+	}
+
+	reactions, err := db.GetReactionsForMessages(context.Background(), database, []string{msgID})
+	if err != nil {
+		t.Fatalf("GetReactionsForMessages failed: %v", err)
+	}
+	if len(reactions[msgID]) != 1 {
+		t.Fatalf("expected one emoji on the message, got %+v", reactions[msgID])
+	}
+	reaction := reactions[msgID][0]
+	if reaction.Count != reactors/2 {
+		t.Errorf("expected a final count of %d, got %d", reactors/2, reaction.Count)
+	}
+	if len(reaction.UserIDs) != reactors/2 {
+		t.Fatalf("expected %d reacting users, got %v", reactors/2, reaction.UserIDs)
+	}
+	remaining := make(map[string]bool, len(reaction.UserIDs))
+	for _, id := range reaction.UserIDs {
+		remaining[id] = true
+	}
+	for _, u := range users[:reactors/2] {
+		if !remaining[u.ID] {
+			t.Errorf("expected %s in the reacting user list, got %v", u.ID, reaction.UserIDs)
+		}
+	}
+
+	// One more add sees a count that includes every surviving reaction
+	res, err := api.AddReaction(owner, addJSON)
+	if err != nil {
+		t.Fatalf("AddReaction failed: %v", err)
+	}
+	var env struct {
+		Type string                   `json:"type"`
+		Data protocol.ReactionUpdated `json:"data"`
+	}
+	if err := json.Unmarshal(res.Message, &env); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast: %v", err)
+	}
+	if env.Data.Count != reactors/2+1 {
+		t.Errorf("expected a broadcast count of %d, got %d", reactors/2+1, env.Data.Count)
+	}
+}
+
+// TestReaction_EmptyEmoji tests that both reaction handlers reject an empty
+// emoji with a validation error
+func TestReaction_EmptyEmoji(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_reactemp12345", "emptyreactor")
+	room := createTestRoom(t, database, "roo_reactemp1234", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	msgID := createTestMessageSimple(t, api, user, room.ID, "react with nothing")
+
+	addJSON, _ := json.Marshal(protocol.AddReactionRequest{MessageID: msgID, Emoji: ""})
+	if _, err := api.AddReaction(user, addJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error from AddReaction, got %v", err)
+	}
+
+	removeJSON, _ := json.Marshal(protocol.RemoveReactionRequest{MessageID: msgID, Emoji: ""})
+	if _, err := api.RemoveReaction(user, removeJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error from RemoveReaction, got %v", err)
+	}
+}
+
+// TestRemoveReaction_NonMember tests that removal attempts on messages in
+// rooms the caller can't access are rejected without producing a broadcast
+func TestRemoveReaction_NonMember(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	member := createTestUser(t, database, "usr_rreactmem1234", "rmember")
+	nonMember := createTestUser(t, database, "usr_rreactnon1234", "rnonmember")
+	room := createTestRoom(t, database, "roo_rreactnon123", "general", true)
+	addUserToRoom(t, database, member.ID, room.ID)
+	// nonMember is NOT added to the room
+
+	msgID := createTestMessageSimple(t, api, member, room.ID, "can't unreact this")
+
+	req := protocol.RemoveReactionRequest{MessageID: msgID, Emoji: "👍"}
+	reqJSON, _ := json.Marshal(req)
+	res, err := api.RemoveReaction(nonMember, reqJSON)
+	if !errors.Is(err, ErrNotMember) {
+		t.Errorf("expected ErrNotMember, got %v", err)
+	}
+	if res != nil {
+		t.Errorf("expected no broadcast for a non-member removal, got %+v", res)
+	}
+}
@@ -13,6 +13,10 @@ import (
 // RemoveReaction handles a request to remove an emoji reaction from a message.
 // Users can only remove their own reactions.
 func (a *Api) RemoveReaction(user *models.User, msg json.RawMessage) (*ReactionResponse, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	var req protocol.RemoveReactionRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
 		a.logger.Error("invalid json for remove_reaction", "error", err)
@@ -20,7 +24,7 @@ func (a *Api) RemoveReaction(user *models.User, msg json.RawMessage) (*ReactionR
 	}
 
 	if req.MessageID == "" || req.Emoji == "" {
-		return nil, fmt.Errorf("message_id and emoji are required")
+		return nil, fmt.Errorf("message_id and emoji are required: %w", ErrValidation)
 	}
 
 	ctx := context.Background()
@@ -32,25 +36,24 @@ func (a *Api) RemoveReaction(user *models.User, msg json.RawMessage) (*ReactionR
 		return nil, fmt.Errorf("message not found")
 	}
 
-	// Verify room membership
+	// Verify room membership before touching the reaction or emitting a
+	// broadcast, so removal attempts can't confirm that a message exists
+	// in a room the caller isn't in
 	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, message.RoomID)
 	if err != nil {
 		return nil, err
 	}
 	if !isMember {
-		return nil, fmt.Errorf("user is not a member of the room")
+		return nil, ErrNotMember
 	}
 
-	// Look up and delete the reaction
-	reaction, err := models.ReactionByMessageIDUserIDEmoji(ctx, a.db, req.MessageID, user.ID, req.Emoji)
+	// Delete the reaction and count in one transaction; removing a
+	// reaction that doesn't exist is an idempotent success and the count
+	// is never stale under concurrent reactions
+	count, err := db.RemoveReaction(ctx, a.db, req.MessageID, user.ID, req.Emoji)
 	if err != nil {
-		// Reaction doesn't exist - treat as idempotent success
-		a.logger.Debug("reaction not found for removal", "message_id", req.MessageID, "user", user.ID, "emoji", req.Emoji)
-	} else {
-		if err = reaction.Delete(ctx, a.db); err != nil {
-			a.logger.Error("failed to remove reaction", "error", err)
-			return nil, err
-		}
+		a.logger.Error("failed to remove reaction", "error", err)
+		return nil, err
 	}
 
 	// Build broadcast
@@ -58,8 +61,10 @@ func (a *Api) RemoveReaction(user *models.User, msg json.RawMessage) (*ReactionR
 		MessageID: req.MessageID,
 		RoomID:    message.RoomID,
 		UserID:    user.ID,
+		Username:  user.Username,
 		Emoji:     req.Emoji,
 		Action:    "remove",
+		Count:     count,
 	}
 
 	msgBytes, err := json.Marshal(&Envelope{
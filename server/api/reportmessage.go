@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxReportReasonLength caps the free-text reason on a message report
+const maxReportReasonLength = 500
+
+// ReportMessageResult contains the report_message response envelope and a
+// message_reported notice for each of the room's owners and admins
+type ReportMessageResult struct {
+	Envelope *Envelope
+	Notices  []AddedMember
+}
+
+// ReportMessage handles a member flagging a message for the room's
+// moderators. Reporting a message the user has already reported is
+// idempotent: the report stands and no one is re-notified.
+func (a *Api) ReportMessage(user *models.User, msg json.RawMessage) (*ReportMessageResult, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.ReportMessageRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid report_message json", "error", err)
+		return nil, err
+	}
+
+	if req.MessageID == "" {
+		return nil, fmt.Errorf("message_id is required: %w", ErrValidation)
+	}
+	if len(req.Reason) > maxReportReasonLength {
+		return nil, fmt.Errorf("reason exceeds the %d byte limit: %w", maxReportReasonLength, ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	message, err := models.MessageByID(ctx, a.db, req.MessageID)
+	if err != nil {
+		a.logger.Error("message not found", "error", err, "message_id", req.MessageID)
+		return nil, fmt.Errorf("message not found: %w", ErrValidation)
+	}
+
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, message.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotMember
+	}
+
+	filed, err := db.InsertMessageReport(ctx, a.db, message.ID, user.ID, message.RoomID, req.Reason)
+	if err != nil {
+		a.logger.Error("failed to insert message report", "error", err, "message", message.ID, "reporter", user.ID)
+		return nil, err
+	}
+
+	// Only notify moderators the first time; a duplicate report just
+	// re-confirms it to the reporter
+	var notices []AddedMember
+	if filed {
+		admins, err := db.RoomAdmins(ctx, a.db, message.RoomID)
+		if err != nil {
+			a.logger.Error("failed to get room admins", "error", err, "room", message.RoomID)
+			return nil, err
+		}
+		noticeBytes, err := json.Marshal(&Envelope{
+			Type: "message_reported",
+			Data: protocol.MessageReported{
+				RoomID:           message.RoomID,
+				MessageID:        message.ID,
+				ReporterID:       user.ID,
+				ReporterUsername: user.Username,
+				Reason:           req.Reason,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, adminID := range admins {
+			// A moderator reporting a message doesn't need to be told
+			// about their own report
+			if adminID == user.ID {
+				continue
+			}
+			notices = append(notices, AddedMember{UserID: adminID, Message: noticeBytes})
+		}
+		a.logger.Info("message reported", "message", message.ID, "room", message.RoomID, "reporter", user.ID, "admins_notified", len(notices))
+	}
+
+	return &ReportMessageResult{
+		Envelope: &Envelope{
+			Type: "report_message",
+			Data: protocol.ReportMessageResponse{
+				MessageID: message.ID,
+			},
+		},
+		Notices: notices,
+	}, nil
+}
+
+// ListReports handles an owner or admin listing a room's message reports,
+// optionally limited to one status
+func (a *Api) ListReports(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.ListReportsRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid list_reports json", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required: %w", ErrValidation)
+	}
+	switch req.Status {
+	case "", db.ReportOpen, db.ReportResolved, db.ReportDismissed:
+	default:
+		return nil, fmt.Errorf("status must be open, resolved, or dismissed: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found: %w", ErrValidation)
+	}
+	if err := a.requireRoomAdmin(ctx, user, room.ID); err != nil {
+		return nil, err
+	}
+
+	dbReports, err := db.ListMessageReports(ctx, a.db, room.ID, req.Status)
+	if err != nil {
+		a.logger.Error("failed to list message reports", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	reports := make([]protocol.ReportInfo, 0, len(dbReports))
+	for _, r := range dbReports {
+		reports = append(reports, protocol.ReportInfo{
+			MessageID:        r.MessageID,
+			ReporterID:       r.ReporterID,
+			ReporterUsername: r.ReporterUsername,
+			Reason:           r.Reason,
+			Status:           r.Status,
+			CreatedAt:        r.CreatedAt,
+		})
+	}
+
+	return &Envelope{
+		Type: "list_reports",
+		Data: protocol.ListReportsResponse{
+			RoomID:  room.ID,
+			Reports: reports,
+		},
+	}, nil
+}
+
+// ResolveReport handles an owner or admin resolving or dismissing all of a
+// message's open reports
+func (a *Api) ResolveReport(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.ResolveReportRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid resolve_report json", "error", err)
+		return nil, err
+	}
+
+	if req.MessageID == "" {
+		return nil, fmt.Errorf("message_id is required: %w", ErrValidation)
+	}
+	if req.Status != db.ReportResolved && req.Status != db.ReportDismissed {
+		return nil, fmt.Errorf("status must be resolved or dismissed: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	message, err := models.MessageByID(ctx, a.db, req.MessageID)
+	if err != nil {
+		a.logger.Error("message not found", "error", err, "message_id", req.MessageID)
+		return nil, fmt.Errorf("message not found: %w", ErrValidation)
+	}
+	if err := a.requireRoomAdmin(ctx, user, message.RoomID); err != nil {
+		return nil, err
+	}
+
+	updated, err := db.ResolveMessageReports(ctx, a.db, message.ID, req.Status, user.ID)
+	if err != nil {
+		a.logger.Error("failed to resolve message reports", "error", err, "message", message.ID)
+		return nil, err
+	}
+
+	a.logger.Info("message reports handled", "message", message.ID, "status", req.Status, "updated", updated, "by", user.ID)
+
+	return &Envelope{
+		Type: "resolve_report",
+		Data: protocol.ResolveReportResponse{
+			MessageID: message.ID,
+			Status:    req.Status,
+			Updated:   int(updated),
+		},
+	}, nil
+}
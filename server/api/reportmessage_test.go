@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// reportMessage marshals and sends a report_message request
+func reportMessage(t *testing.T, api *Api, user *models.User, messageID, reason string) *ReportMessageResult {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.ReportMessageRequest{MessageID: messageID, Reason: reason})
+	res, err := api.ReportMessage(user, reqJSON)
+	if err != nil {
+		t.Fatalf("ReportMessage failed: %v", err)
+	}
+	if res.Envelope.Type != "report_message" {
+		t.Errorf("Expected type 'report_message', got '%s'", res.Envelope.Type)
+	}
+	return res
+}
+
+// listReports marshals and sends a list_reports request, returning the
+// response data
+func listReports(t *testing.T, api *Api, user *models.User, roomID, status string) protocol.ListReportsResponse {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.ListReportsRequest{RoomID: roomID, Status: status})
+	response, err := api.ListReports(user, reqJSON)
+	if err != nil {
+		t.Fatalf("ListReports failed: %v", err)
+	}
+	resp, ok := response.Data.(protocol.ListReportsResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.ListReportsResponse data type, got %T", response.Data)
+	}
+	return resp
+}
+
+// TestReportMessage_RecordsAndNotifies tests that a report is recorded,
+// listable by an admin, and that the room's admins are notified
+func TestReportMessage_RecordsAndNotifies(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_report000001", "reportowner")
+	reporter := createTestUser(t, database, "usr_report000002", "reporter")
+	room := createTestRoom(t, database, "roo_report000001", "report-room", true)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	addUserToRoom(t, database, reporter.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+
+	msgID := createTestMessageSimple(t, api, owner, room.ID, "questionable content")
+
+	res := reportMessage(t, api, reporter, msgID, "spam")
+
+	// The room's owner is notified, but not the reporter
+	if len(res.Notices) != 1 {
+		t.Fatalf("Expected 1 admin notice, got %d", len(res.Notices))
+	}
+	if res.Notices[0].UserID != owner.ID {
+		t.Errorf("Expected the notice to go to the owner, got %s", res.Notices[0].UserID)
+	}
+	var envelope struct {
+		Type string                   `json:"type"`
+		Data protocol.MessageReported `json:"data"`
+	}
+	if err := json.Unmarshal(res.Notices[0].Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal notice: %v", err)
+	}
+	if envelope.Type != "message_reported" {
+		t.Errorf("Expected notice type 'message_reported', got '%s'", envelope.Type)
+	}
+	if envelope.Data.MessageID != msgID || envelope.Data.ReporterID != reporter.ID {
+		t.Errorf("Unexpected notice data: %+v", envelope.Data)
+	}
+
+	// The report shows up in the admin's list, open
+	reports := listReports(t, api, owner, room.ID, "")
+	if len(reports.Reports) != 1 {
+		t.Fatalf("Expected 1 report, got %d", len(reports.Reports))
+	}
+	r := reports.Reports[0]
+	if r.MessageID != msgID || r.ReporterID != reporter.ID || r.Reason != "spam" || r.Status != db.ReportOpen {
+		t.Errorf("Unexpected report: %+v", r)
+	}
+}
+
+// TestReportMessage_DuplicateIdempotent tests that re-reporting a message
+// succeeds without a second report or notification
+func TestReportMessage_DuplicateIdempotent(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_report000011", "reportdupown")
+	reporter := createTestUser(t, database, "usr_report000012", "reportduper")
+	room := createTestRoom(t, database, "roo_report000011", "report-dup-room", true)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	addUserToRoom(t, database, reporter.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+
+	msgID := createTestMessageSimple(t, api, owner, room.ID, "still questionable")
+
+	first := reportMessage(t, api, reporter, msgID, "spam")
+	if len(first.Notices) != 1 {
+		t.Fatalf("Expected 1 notice for the first report, got %d", len(first.Notices))
+	}
+
+	second := reportMessage(t, api, reporter, msgID, "spam again")
+	if len(second.Notices) != 0 {
+		t.Errorf("Expected no notices for a duplicate report, got %d", len(second.Notices))
+	}
+
+	reports := listReports(t, api, owner, room.ID, "")
+	if len(reports.Reports) != 1 {
+		t.Errorf("Expected 1 report after a duplicate, got %d", len(reports.Reports))
+	}
+}
+
+// TestReportMessage_RequiresMembership tests that only room members may
+// report a message
+func TestReportMessage_RequiresMembership(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_report000021", "reportmemown")
+	outsider := createTestUser(t, database, "usr_report000022", "reportoutsider")
+	room := createTestRoom(t, database, "roo_report000021", "report-mem-room", true)
+	addUserToRoom(t, database, owner.ID, room.ID)
+
+	msgID := createTestMessageSimple(t, api, owner, room.ID, "members only")
+
+	reqJSON, _ := json.Marshal(protocol.ReportMessageRequest{MessageID: msgID, Reason: "spam"})
+	if _, err := api.ReportMessage(outsider, reqJSON); !errors.Is(err, ErrNotMember) {
+		t.Errorf("Expected ErrNotMember for a non-member report, got %v", err)
+	}
+}
+
+// TestResolveReport_AdminOnly tests resolving reports and the status filter
+func TestResolveReport_AdminOnly(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	owner := createTestUser(t, database, "usr_report000031", "reportresown")
+	reporter := createTestUser(t, database, "usr_report000032", "reportresrep")
+	room := createTestRoom(t, database, "roo_report000031", "report-res-room", true)
+	addUserToRoom(t, database, owner.ID, room.ID)
+	addUserToRoom(t, database, reporter.ID, room.ID)
+	setMemberRole(t, api, owner.ID, room.ID, db.RoleOwner)
+
+	msgID := createTestMessageSimple(t, api, owner, room.ID, "to be resolved")
+	reportMessage(t, api, reporter, msgID, "spam")
+
+	// A regular member may not resolve reports
+	reqJSON, _ := json.Marshal(protocol.ResolveReportRequest{MessageID: msgID, Status: db.ReportResolved})
+	if _, err := api.ResolveReport(reporter, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for a non-admin resolve, got %v", err)
+	}
+
+	response, err := api.ResolveReport(owner, reqJSON)
+	if err != nil {
+		t.Fatalf("ResolveReport failed: %v", err)
+	}
+	resolved, ok := response.Data.(protocol.ResolveReportResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.ResolveReportResponse data type, got %T", response.Data)
+	}
+	if resolved.Updated != 1 {
+		t.Errorf("Expected 1 report updated, got %d", resolved.Updated)
+	}
+
+	// No open reports remain; the resolved one shows under its status
+	if open := listReports(t, api, owner, room.ID, db.ReportOpen); len(open.Reports) != 0 {
+		t.Errorf("Expected 0 open reports, got %d", len(open.Reports))
+	}
+	done := listReports(t, api, owner, room.ID, db.ReportResolved)
+	if len(done.Reports) != 1 || done.Reports[0].Status != db.ReportResolved {
+		t.Errorf("Expected 1 resolved report, got %+v", done.Reports)
+	}
+}
@@ -3,13 +3,38 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
+	"os"
+	"strconv"
 
 	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
 )
 
-// RoomInfo handles a request from the client to get room details and members.
+// defaultRoomInfoMembers caps how many members a room_info response carries;
+// clients page through the rest with list_members
+const defaultRoomInfoMembers = 100
+
+// roomInfoMembersFromEnv reads ROOM_INFO_MEMBER_LIMIT, falling back to the
+// default when it is unset or invalid
+func roomInfoMembersFromEnv(logger *slog.Logger) int {
+	val := os.Getenv("ROOM_INFO_MEMBER_LIMIT")
+	if val == "" {
+		return defaultRoomInfoMembers
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		logger.Warn("invalid ROOM_INFO_MEMBER_LIMIT, using default", "value", val, "default", defaultRoomInfoMembers)
+		return defaultRoomInfoMembers
+	}
+	return n
+}
+
+// RoomInfo handles a request from the client to get room details and the
+// first page of members. The member count is always the room's full total;
+// next_cursor continues the member list through list_members when the room
+// is bigger than one page.
 func (a *Api) RoomInfo(user *models.User, msg json.RawMessage) (*Envelope, error) {
 	var req protocol.RoomInfoRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
@@ -17,7 +42,7 @@ func (a *Api) RoomInfo(user *models.User, msg json.RawMessage) (*Envelope, error
 	}
 
 	if req.RoomID == "" {
-		return ErrorResponse("room_id is required"), nil
+		return errorEnvelope(protocol.ErrCodeValidation, "room_id is required"), nil
 	}
 
 	ctx := context.Background()
@@ -29,14 +54,14 @@ func (a *Api) RoomInfo(user *models.User, msg json.RawMessage) (*Envelope, error
 		return nil, err
 	}
 	if !isMember {
-		return ErrorResponse("not a member of this room"), nil
+		return errorEnvelope(protocol.ErrCodeNotMember, "not a member of this room"), nil
 	}
 
 	// Get room info
-	info, err := db.GetRoomInfo(ctx, a.db, req.RoomID)
+	info, err := db.GetRoomInfo(ctx, a.db, req.RoomID, a.roomInfoMembers)
 	if err != nil {
 		a.logger.Error("failed to get room info", "error", err, "room_id", req.RoomID)
-		return ErrorResponse("room not found"), nil
+		return errorEnvelope(protocol.ErrCodeNotFound, "room not found"), nil
 	}
 
 	// Convert members to protocol type
@@ -58,9 +83,11 @@ func (a *Api) RoomInfo(user *models.User, msg json.RawMessage) (*Envelope, error
 				Name:      info.Room.Name,
 				RoomType:  info.Room.RoomType,
 				IsPrivate: info.Room.IsPrivate != 0,
+				Topic:     info.Room.Topic,
 			},
 			MemberCount: info.MemberCount,
 			Members:     members,
+			NextCursor:  info.NextCursor,
 			CreatedAt:   info.Room.CreatedAt,
 		},
 	}, nil
@@ -1,10 +1,12 @@
 package api
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"testing"
 
+	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
 )
 
@@ -155,3 +157,44 @@ func TestRoomInfo_PrivateRoom(t *testing.T) {
 		t.Error("Expected room to be private")
 	}
 }
+
+// TestRoomInfo_MemberPageCap tests that the member list is capped at the
+// configured page size while member_count stays the room's full total
+func TestRoomInfo_MemberPageCap(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.roomInfoMembers = 2
+
+	room := createTestRoom(t, database, "roo_infocap00001", "crowded-channel", false)
+	var first *models.User
+	for i := 0; i < 5; i++ {
+		user := createTestUser(t, database, fmt.Sprintf("usr_infocap0000%d", i), fmt.Sprintf("capuser%d", i))
+		addUserToRoom(t, database, user.ID, room.ID)
+		if first == nil {
+			first = user
+		}
+	}
+
+	response, err := api.RoomInfo(first, []byte(`{"room_id": "roo_infocap00001"}`))
+	if err != nil {
+		t.Fatalf("RoomInfo failed: %v", err)
+	}
+
+	data, ok := response.Data.(protocol.RoomInfoResponse)
+	if !ok {
+		t.Fatalf("Expected RoomInfoResponse, got %T", response.Data)
+	}
+
+	if data.MemberCount != 5 {
+		t.Errorf("Expected member count 5 independent of the page, got %d", data.MemberCount)
+	}
+	if len(data.Members) != 2 {
+		t.Errorf("Expected 2 members in the page, got %d", len(data.Members))
+	}
+	if data.NextCursor == "" {
+		t.Error("Expected a next cursor for the remaining members")
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/llimllib/hatchat/server/db"
+)
+
+// defaultMaxRoomsPerUser caps how many channels a user may be a member of.
+// It's deliberately generous; the cap exists to stop abuse, not to constrain
+// normal use. DMs never count against it.
+const defaultMaxRoomsPerUser = 500
+
+// maxRoomsPerUserFromEnv reads MAX_ROOMS_PER_USER, falling back to the
+// default when it is unset or invalid
+func maxRoomsPerUserFromEnv(logger *slog.Logger) int {
+	val := os.Getenv("MAX_ROOMS_PER_USER")
+	if val == "" {
+		return defaultMaxRoomsPerUser
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		logger.Warn("invalid MAX_ROOMS_PER_USER, using default", "value", val, "default", defaultMaxRoomsPerUser)
+		return defaultMaxRoomsPerUser
+	}
+	return n
+}
+
+// checkRoomLimit returns a validation error when joining or creating one
+// more channel would put the user over the room cap
+func (a *Api) checkRoomLimit(ctx context.Context, userID string) error {
+	count, err := db.CountUserRooms(ctx, a.db, userID)
+	if err != nil {
+		a.logger.Error("failed to count user rooms", "error", err, "user", userID)
+		return err
+	}
+	if count >= a.maxRoomsPerUser {
+		a.logger.Warn("user hit the room limit", "user", userID, "count", count, "limit", a.maxRoomsPerUser)
+		return fmt.Errorf("you are a member of the maximum number of rooms (%d): %w", a.maxRoomsPerUser, ErrValidation)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// createTestDM creates a DM room in the database for testing
+func createTestDM(t *testing.T, database *db.DB, id string) *models.Room {
+	t.Helper()
+	now := time.Now().Format(time.RFC3339)
+	room := &models.Room{
+		ID:        id,
+		Name:      "",
+		RoomType:  "dm",
+		IsPrivate: models.TRUE,
+		IsDefault: models.FALSE,
+		CreatedAt: now,
+	}
+	if err := room.Insert(context.Background(), database); err != nil {
+		t.Fatalf("Failed to create test DM: %v", err)
+	}
+	return room
+}
+
+// TestRoomLimit_JoinRejected tests that joining a room past the cap is
+// rejected with a validation error
+func TestRoomLimit_JoinRejected(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.maxRoomsPerUser = 2
+
+	user := createTestUser(t, database, "usr_rlimit_join1", "roomlimiter")
+	room1 := createTestRoom(t, database, "roo_rlimit_00001", "rlimit-one", false)
+	room2 := createTestRoom(t, database, "roo_rlimit_00002", "rlimit-two", false)
+	room3 := createTestRoom(t, database, "roo_rlimit_00003", "rlimit-three", false)
+	addUserToRoom(t, database, user.ID, room1.ID)
+	addUserToRoom(t, database, user.ID, room2.ID)
+
+	reqJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: room3.ID})
+	_, err := api.JoinRoom(user, reqJSON)
+	if err == nil {
+		t.Fatal("Expected joining past the room cap to be rejected")
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+
+	// Re-joining a room the user is already in is unaffected by the cap
+	reqJSON, _ = json.Marshal(protocol.JoinRoomRequest{RoomID: room1.ID})
+	if _, err := api.JoinRoom(user, reqJSON); err != nil {
+		t.Errorf("Expected switching to an existing room to succeed at the cap, got %v", err)
+	}
+}
+
+// TestRoomLimit_CreateRejected tests that creating a room past the cap is
+// rejected
+func TestRoomLimit_CreateRejected(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.maxRoomsPerUser = 1
+
+	user := createTestUser(t, database, "usr_rlimit_make1", "roommaker")
+	room := createTestRoom(t, database, "roo_rlimit_00004", "rlimit-four", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	reqJSON, _ := json.Marshal(protocol.CreateRoomRequest{Name: "rlimit-five"})
+	_, err := api.CreateRoom(user, reqJSON)
+	if err == nil {
+		t.Fatal("Expected creating a room past the cap to be rejected")
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+}
+
+// TestRoomLimit_DMsDontCount tests that DM memberships don't count toward
+// the room cap
+func TestRoomLimit_DMsDontCount(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.maxRoomsPerUser = 2
+
+	user := createTestUser(t, database, "usr_rlimit_dms01", "dmhaver")
+	room := createTestRoom(t, database, "roo_rlimit_00006", "rlimit-six", false)
+	dm1 := createTestDM(t, database, "roo_rlimit_dm001")
+	dm2 := createTestDM(t, database, "roo_rlimit_dm002")
+	addUserToRoom(t, database, user.ID, room.ID)
+	addUserToRoom(t, database, user.ID, dm1.ID)
+	addUserToRoom(t, database, user.ID, dm2.ID)
+
+	// One channel and two DMs: still one channel slot left
+	target := createTestRoom(t, database, "roo_rlimit_00007", "rlimit-seven", false)
+	reqJSON, _ := json.Marshal(protocol.JoinRoomRequest{RoomID: target.ID})
+	if _, err := api.JoinRoom(user, reqJSON); err != nil {
+		t.Errorf("Expected join to succeed with DMs not counting toward the cap, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 
+	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
 	"github.com/llimllib/hatchat/server/protocol"
 )
@@ -13,13 +14,19 @@ import (
 func (a *Api) Search(user *models.User, msg json.RawMessage) (Envelope, error) {
 	var req protocol.SearchRequest
 	if err := json.Unmarshal(msg, &req); err != nil {
-		return *ErrorResponse("invalid search request"), nil
+		return *errorEnvelope(protocol.ErrCodeValidation, "invalid search request"), nil
 	}
 
 	// Validate query
 	query := strings.TrimSpace(req.Query)
 	if query == "" {
-		return *ErrorResponse("search query cannot be empty"), nil
+		return *errorEnvelope(protocol.ErrCodeValidation, "search query cannot be empty"), nil
+	}
+
+	// The FTS index only ever holds ciphertext while encryption at rest is
+	// enabled, so search cannot return useful results
+	if a.db.Cipher.Enabled() {
+		return *errorEnvelope(protocol.ErrCodeValidation, "search is unavailable while message encryption at rest is enabled"), nil
 	}
 
 	ctx := context.Background()
@@ -33,10 +40,11 @@ func (a *Api) Search(user *models.User, msg json.RawMessage) (Envelope, error) {
 		req.UserID,
 		req.Cursor,
 		req.Limit,
+		req.SnippetTokens,
 	)
 	if err != nil {
 		a.logger.Error("search failed", "error", err, "user_id", user.ID, "query", query)
-		return *ErrorResponse("search failed"), nil
+		return *errorEnvelope(protocol.ErrCodeInternal, "search failed"), nil
 	}
 
 	// Return empty array instead of nil for consistency
@@ -44,6 +52,8 @@ func (a *Api) Search(user *models.User, msg json.RawMessage) (Envelope, error) {
 		results = []protocol.SearchResult{}
 	}
 
+	a.attachSearchMetadata(ctx, user.ID, results)
+
 	return Envelope{
 		Type: "search",
 		Data: protocol.SearchResponse{
@@ -53,3 +63,31 @@ func (a *Api) Search(user *models.User, msg json.RawMessage) (Envelope, error) {
 		},
 	}, nil
 }
+
+// attachSearchMetadata batch-loads attachment and reaction aggregates for
+// the matching messages and fills them into the results in place. Failures
+// are logged and the results continue without the metadata rather than
+// failing the whole request.
+func (a *Api) attachSearchMetadata(ctx context.Context, userID string, results []protocol.SearchResult) {
+	messageIDs := make([]string, len(results))
+	for i, r := range results {
+		messageIDs[i] = r.MessageID
+	}
+	attachmentsMap, err := db.GetAttachmentsForMessages(ctx, a.db, messageIDs)
+	if err != nil {
+		a.logger.Error("failed to get attachments", "error", err)
+		attachmentsMap = make(map[string][]protocol.Attachment)
+	}
+	reactionsMap, err := db.GetReactionsForMessages(ctx, a.db, messageIDs)
+	if err != nil {
+		a.logger.Error("failed to get reactions", "error", err)
+		reactionsMap = make(map[string][]protocol.Reaction)
+	}
+	markReactedByMe(reactionsMap, userID)
+	capReactionUserIDs(reactionsMap)
+
+	for i := range results {
+		results[i].Attachments = attachmentsMap[results[i].MessageID]
+		results[i].Reactions = reactionsMap[results[i].MessageID]
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// defaultSearchStreamBatch is how many results each streamed batch carries
+// when the client doesn't ask for a specific size
+const defaultSearchStreamBatch = 50
+
+// SearchStream runs a search to exhaustion, calling emit once per batch of
+// results as the FTS cursor advances and once more with a final
+// search_stream_done marker. Membership scoping is identical to Search. The
+// stream stops early, without a done marker, when ctx is cancelled or emit
+// returns an error, so the transport can abandon a stream the client no
+// longer wants.
+func (a *Api) SearchStream(ctx context.Context, user *models.User, msg json.RawMessage, emit func(*Envelope) error) error {
+	var req protocol.SearchStreamRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for search_stream", "error", err)
+		return emit(errorEnvelope(protocol.ErrCodeValidation, "invalid search_stream request"))
+	}
+
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return emit(errorEnvelope(protocol.ErrCodeValidation, "search query cannot be empty"))
+	}
+
+	// The FTS index only ever holds ciphertext while encryption at rest is
+	// enabled, so search cannot return useful results
+	if a.db.Cipher.Enabled() {
+		return emit(errorEnvelope(protocol.ErrCodeValidation, "search is unavailable while message encryption at rest is enabled"))
+	}
+
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultSearchStreamBatch
+	}
+
+	cursor := ""
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		results, nextCursor, err := a.db.SearchMessages(
+			ctx,
+			user.ID,
+			query,
+			req.RoomID,
+			req.UserID,
+			cursor,
+			batchSize,
+			0, // default snippet window
+		)
+		if err != nil {
+			a.logger.Error("streaming search failed", "error", err, "user_id", user.ID, "query", query)
+			return emit(errorEnvelope(protocol.ErrCodeInternal, "search failed"))
+		}
+
+		if len(results) > 0 {
+			a.attachSearchMetadata(ctx, user.ID, results)
+			total += len(results)
+			if err := emit(&Envelope{
+				Type: "search_stream_batch",
+				Data: protocol.SearchStreamBatch{Results: results},
+			}); err != nil {
+				return err
+			}
+		}
+
+		if nextCursor == "" {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	return emit(&Envelope{
+		Type: "search_stream_done",
+		Data: protocol.SearchStreamDone{Total: total},
+	})
+}
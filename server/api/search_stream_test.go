@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// setupStreamSearchData creates a user, a room they belong to, and n
+// messages matching the query word "streamable"
+func setupStreamSearchData(t *testing.T, api *Api, n int) *models.User {
+	t.Helper()
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:         "usr_test123456789a",
+		Username:   "alice",
+		Password:   "hash",
+		LastRoom:   "roo_general1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = user.Insert(ctx, api.db)
+
+	room := &models.Room{
+		ID:        "roo_general1234",
+		Name:      "general",
+		RoomType:  "channel",
+		IsPrivate: 0,
+		IsDefault: 1,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = room.Insert(ctx, api.db)
+
+	_, _ = api.db.ExecContext(ctx, "INSERT INTO rooms_members (user_id, room_id) VALUES ($1, $2)", user.ID, room.ID)
+
+	for i := 0; i < n; i++ {
+		msg := &models.Message{
+			ID:         fmt.Sprintf("msg_stream%07d", i),
+			RoomID:     room.ID,
+			UserID:     user.ID,
+			Body:       fmt.Sprintf("streamable message %d", i),
+			CreatedAt:  time.Now().Add(time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+			ModifiedAt: time.Now().Format(time.RFC3339Nano),
+		}
+		_ = msg.Insert(ctx, api.db)
+	}
+	return user
+}
+
+func TestSearchStream_AllMatchesInOrder(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(testDB, logger)
+	user := setupStreamSearchData(t, api, 5)
+
+	// The non-streaming search with a large limit defines the expected order
+	pageReq, _ := json.Marshal(protocol.SearchRequest{Query: "streamable", Limit: 100})
+	pageResp, err := api.Search(user, pageReq)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	expected := pageResp.Data.(protocol.SearchResponse).Results
+	if len(expected) != 5 {
+		t.Fatalf("expected 5 matches, got %d", len(expected))
+	}
+
+	reqData, _ := json.Marshal(protocol.SearchStreamRequest{Query: "streamable", BatchSize: 2})
+	var envelopes []*Envelope
+	err = api.SearchStream(context.Background(), user, reqData, func(env *Envelope) error {
+		envelopes = append(envelopes, env)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SearchStream failed: %v", err)
+	}
+
+	// 5 matches in batches of 2 means batches of 2, 2, 1, then a done marker
+	if len(envelopes) != 4 {
+		t.Fatalf("expected 4 envelopes, got %d", len(envelopes))
+	}
+	var streamed []protocol.SearchResult
+	for i, env := range envelopes[:3] {
+		if env.Type != "search_stream_batch" {
+			t.Fatalf("expected envelope %d to be a batch, got %s", i, env.Type)
+		}
+		streamed = append(streamed, env.Data.(protocol.SearchStreamBatch).Results...)
+	}
+	done := envelopes[3]
+	if done.Type != "search_stream_done" {
+		t.Fatalf("expected a done marker, got %s", done.Type)
+	}
+	if total := done.Data.(protocol.SearchStreamDone).Total; total != 5 {
+		t.Errorf("expected a total of 5, got %d", total)
+	}
+
+	if len(streamed) != len(expected) {
+		t.Fatalf("expected %d streamed results, got %d", len(expected), len(streamed))
+	}
+	for i := range expected {
+		if streamed[i].MessageID != expected[i].MessageID {
+			t.Errorf("result %d: expected %s, got %s", i, expected[i].MessageID, streamed[i].MessageID)
+		}
+	}
+}
+
+func TestSearchStream_StopsOnCancellation(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(testDB, logger)
+	user := setupStreamSearchData(t, api, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reqData, _ := json.Marshal(protocol.SearchStreamRequest{Query: "streamable", BatchSize: 2})
+	batches := 0
+	err := api.SearchStream(ctx, user, reqData, func(env *Envelope) error {
+		if env.Type == "search_stream_done" {
+			t.Error("expected no done marker after cancellation")
+		}
+		batches++
+		// Cancel as soon as the first batch arrives, as the transport
+		// does when the client sends search_stream_cancel
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if batches != 1 {
+		t.Errorf("expected the stream to stop after 1 batch, got %d", batches)
+	}
+}
+
+func TestSearchStream_EmptyQuery(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(testDB, logger)
+	user := &models.User{ID: "usr_test123456789a"}
+
+	reqData, _ := json.Marshal(protocol.SearchStreamRequest{Query: "  "})
+	var envelopes []*Envelope
+	err := api.SearchStream(context.Background(), user, reqData, func(env *Envelope) error {
+		envelopes = append(envelopes, env)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SearchStream failed: %v", err)
+	}
+	if len(envelopes) != 1 || envelopes[0].Type != "error" {
+		t.Errorf("expected a single error envelope, got %+v", envelopes)
+	}
+}
@@ -26,6 +26,17 @@ func setupSearchTestDB(t *testing.T) *db.DB {
 	dropSchema := `
 		DROP TABLE IF EXISTS messages_fts;
 		DROP TABLE IF EXISTS reactions;
+		DROP TABLE IF EXISTS audit_log;
+		DROP TABLE IF EXISTS pinned_messages;
+		DROP TABLE IF EXISTS favorite_rooms;
+		DROP TABLE IF EXISTS hidden_dms;
+		DROP TABLE IF EXISTS dm_labels;
+		DROP TABLE IF EXISTS message_reports;
+		DROP TABLE IF EXISTS dm_keys;
+		DROP TABLE IF EXISTS user_prefs;
+		DROP TABLE IF EXISTS starred_messages;
+		DROP TABLE IF EXISTS message_forwards;
+		DROP TABLE IF EXISTS room_reads;
 		DROP TABLE IF EXISTS messages;
 		DROP TABLE IF EXISTS rooms_members;
 		DROP TABLE IF EXISTS sessions;
@@ -568,3 +579,73 @@ func TestSearch_Pagination(t *testing.T) {
 		t.Errorf("expected no next cursor on last page")
 	}
 }
+
+func TestSearch_IncludesReactions(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(testDB, logger)
+
+	user := &models.User{
+		ID:         "usr_test123456789a",
+		Username:   "alice",
+		Password:   "hash",
+		LastRoom:   "roo_general1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = user.Insert(ctx, testDB)
+
+	room := &models.Room{
+		ID:        "roo_general1234",
+		Name:      "general",
+		RoomType:  "channel",
+		IsPrivate: 0,
+		IsDefault: 1,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = room.Insert(ctx, testDB)
+
+	_, _ = testDB.ExecContext(ctx, "INSERT INTO rooms_members (user_id, room_id) VALUES ($1, $2)", user.ID, room.ID)
+
+	msg := &models.Message{
+		ID:         "msg_reacted1234",
+		RoomID:     room.ID,
+		UserID:     user.ID,
+		Body:       "A very reactable message",
+		CreatedAt:  time.Now().Format(time.RFC3339Nano),
+		ModifiedAt: time.Now().Format(time.RFC3339Nano),
+	}
+	_ = msg.Insert(ctx, testDB)
+
+	// React to the message as the searching user
+	reactReq, _ := json.Marshal(protocol.AddReactionRequest{MessageID: msg.ID, Emoji: "👍"})
+	if _, err := api.AddReaction(user, reactReq); err != nil {
+		t.Fatalf("failed to add reaction: %v", err)
+	}
+
+	req := protocol.SearchRequest{Query: "reactable"}
+	reqData, _ := json.Marshal(req)
+
+	resp, err := api.Search(user, reqData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	searchResp := resp.Data.(protocol.SearchResponse)
+
+	if len(searchResp.Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(searchResp.Results))
+	}
+	reactions := searchResp.Results[0].Reactions
+	if len(reactions) != 1 {
+		t.Fatalf("expected 1 reaction on result, got %d", len(reactions))
+	}
+	if reactions[0].Emoji != "👍" || reactions[0].Count != 1 {
+		t.Errorf("expected 👍 with count 1, got %s with count %d", reactions[0].Emoji, reactions[0].Count)
+	}
+	if !reactions[0].ReactedByMe {
+		t.Error("expected reacted_by_me to be set for the searching user")
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// maxSeenByMembers caps the room size for which per-message seen-by lists
+// are computed. The lists are most useful in DMs and small rooms, and their
+// cost grows with membership, so larger rooms omit them entirely.
+const maxSeenByMembers = 25
+
+// seenByForMessages batch-loads the seen-by lists for a page of messages,
+// or nil when the room is over the member threshold. Errors are logged and
+// degrade to no seen-by data rather than failing the caller.
+func (a *Api) seenByForMessages(ctx context.Context, roomID string, messageIDs []string) map[string][]string {
+	memberCount, err := models.RoomMemberCountByRoomID(ctx, a.db, roomID)
+	if err != nil {
+		a.logger.Error("failed to get room member count", "error", err, "room", roomID)
+		return nil
+	}
+	// Count comes back as a string from SQLite
+	count, err := strconv.Atoi(memberCount.Count)
+	if err != nil {
+		a.logger.Error("failed to parse room member count", "error", err, "count", memberCount.Count)
+		return nil
+	}
+	if count > maxSeenByMembers {
+		return nil
+	}
+
+	seenByMap, err := db.GetSeenByForMessages(ctx, a.db, roomID, messageIDs)
+	if err != nil {
+		a.logger.Error("failed to get seen-by lists", "error", err, "room", roomID)
+		return nil
+	}
+	return seenByMap
+}
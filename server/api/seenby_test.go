@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// markRead records a read position via the mark_read handler
+func markRead(t *testing.T, api *Api, user *models.User, roomID, messageID string) {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.MarkReadRequest{RoomID: roomID, MessageID: messageID})
+	if err := api.MarkRead(user, reqJSON); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+}
+
+// TestSeenBy_ReflectsReadReceipts tests that seen-by lists on history
+// messages follow the members' read positions in a small room
+func TestSeenBy_ReflectsReadReceipts(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_seen_0000001", "seenalice")
+	bob := createTestUser(t, database, "usr_seen_0000002", "seenbob")
+	carol := createTestUser(t, database, "usr_seen_0000003", "seencarol")
+	room := createTestRoom(t, database, "roo_seen_0000001", "seen-small", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+	addUserToRoom(t, database, bob.ID, room.ID)
+	addUserToRoom(t, database, carol.ID, room.ID)
+
+	now := time.Now()
+	createTestMessage(t, database, "msg_seen0000001", room.ID, alice.ID, "first", now.Add(-2*time.Minute))
+	createTestMessage(t, database, "msg_seen0000002", room.ID, alice.ID, "second", now.Add(-1*time.Minute))
+	createTestMessage(t, database, "msg_seen0000003", room.ID, alice.ID, "third", now)
+
+	// Bob has read through the second message, Carol through the third;
+	// Alice has no read position recorded at all
+	markRead(t, api, bob, room.ID, "msg_seen0000002")
+	markRead(t, api, carol, room.ID, "msg_seen0000003")
+
+	reqJSON, _ := json.Marshal(protocol.HistoryRequest{RoomID: room.ID, Limit: 50})
+	response, err := api.HistoryMessage(alice, reqJSON)
+	if err != nil {
+		t.Fatalf("HistoryMessage failed: %v", err)
+	}
+	historyResp, ok := response.Data.(protocol.HistoryResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.HistoryResponse data type, got %T", response.Data)
+	}
+
+	seenBy := make(map[string][]string)
+	for _, m := range historyResp.Messages {
+		seenBy[m.ID] = m.SeenBy
+	}
+
+	// Both read positions are at or past the first two messages; only
+	// Carol's reaches the third
+	expected := map[string][]string{
+		"msg_seen0000001": {bob.ID, carol.ID},
+		"msg_seen0000002": {bob.ID, carol.ID},
+		"msg_seen0000003": {carol.ID},
+	}
+	for msgID, want := range expected {
+		got := seenBy[msgID]
+		if len(got) != len(want) {
+			t.Errorf("Expected %s seen by %v, got %v", msgID, want, got)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Expected %s seen by %v, got %v", msgID, want, got)
+				break
+			}
+		}
+	}
+}
+
+// TestSeenBy_MessageContext tests that the permalink context window carries
+// the same seen-by lists as history
+func TestSeenBy_MessageContext(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_seen_0000011", "seenctxalice")
+	bob := createTestUser(t, database, "usr_seen_0000012", "seenctxbob")
+	room := createTestRoom(t, database, "roo_seen_0000011", "seen-context", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+	addUserToRoom(t, database, bob.ID, room.ID)
+
+	now := time.Now()
+	createTestMessage(t, database, "msg_seenctx0001", room.ID, alice.ID, "earlier", now.Add(-time.Minute))
+	createTestMessage(t, database, "msg_seenctx0002", room.ID, alice.ID, "target", now)
+
+	markRead(t, api, bob, room.ID, "msg_seenctx0001")
+
+	reqJSON, _ := json.Marshal(protocol.GetMessageContextRequest{MessageID: "msg_seenctx0002"})
+	response, err := api.GetMessageContext(alice, reqJSON)
+	if err != nil {
+		t.Fatalf("GetMessageContext failed: %v", err)
+	}
+	ctxResp, ok := response.Data.(protocol.GetMessageContextResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.GetMessageContextResponse data type, got %T", response.Data)
+	}
+
+	// Bob's position covers the earlier message but not the target
+	if len(ctxResp.Message.SeenBy) != 0 {
+		t.Errorf("Expected target message seen by nobody, got %v", ctxResp.Message.SeenBy)
+	}
+	if len(ctxResp.Before) != 1 {
+		t.Fatalf("Expected 1 before message, got %d", len(ctxResp.Before))
+	}
+	if len(ctxResp.Before[0].SeenBy) != 1 || ctxResp.Before[0].SeenBy[0] != bob.ID {
+		t.Errorf("Expected earlier message seen by %s, got %v", bob.ID, ctxResp.Before[0].SeenBy)
+	}
+}
+
+// TestSeenBy_OmittedForLargeRooms tests that rooms over the member
+// threshold skip seen-by lists entirely
+func TestSeenBy_OmittedForLargeRooms(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_seen_0000021", "seenbigalice")
+	room := createTestRoom(t, database, "roo_seen_0000021", "seen-large", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+
+	// Push membership past the threshold
+	var readers []*models.User
+	for i := 0; i < maxSeenByMembers; i++ {
+		reader := createTestUser(t, database,
+			fmt.Sprintf("usr_seenbig%05d", i), fmt.Sprintf("seenbig%d", i))
+		addUserToRoom(t, database, reader.ID, room.ID)
+		readers = append(readers, reader)
+	}
+
+	createTestMessage(t, database, "msg_seenbig0001", room.ID, alice.ID, "hello crowd", time.Now())
+	markRead(t, api, readers[0], room.ID, "msg_seenbig0001")
+
+	reqJSON, _ := json.Marshal(protocol.HistoryRequest{RoomID: room.ID, Limit: 50})
+	response, err := api.HistoryMessage(alice, reqJSON)
+	if err != nil {
+		t.Fatalf("HistoryMessage failed: %v", err)
+	}
+	historyResp, ok := response.Data.(protocol.HistoryResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.HistoryResponse data type, got %T", response.Data)
+	}
+
+	if len(historyResp.Messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(historyResp.Messages))
+	}
+	if len(historyResp.Messages[0].SeenBy) != 0 {
+		t.Errorf("Expected no seen-by list in a large room, got %v", historyResp.Messages[0].SeenBy)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// SetUsernameResult contains the set_username envelope plus a
+// profile_updated broadcast for the renamed user's peers
+type SetUsernameResult struct {
+	Envelope *Envelope
+	// Broadcast is a marshalled profile_updated envelope to deliver to every
+	// online peer of the renamed user, or nil when nothing changed
+	Broadcast []byte
+}
+
+// SetUsername handles a request to change the current user's username,
+// applying the same validation as registration.
+//
+// A rename only updates the user row: messages store user_id and join
+// against users when fetched, so mention resolution and any re-fetched
+// history resolve against the current username. Message payloads already
+// delivered to clients keep the author username they were sent with (as do
+// quote snapshots, which capture the quoted author at quote time by
+// design); the profile_updated broadcast lets live clients re-render author
+// labels wherever they use the live user record.
+func (a *Api) SetUsername(user *models.User, msg json.RawMessage) (*SetUsernameResult, error) {
+	if err := a.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var req protocol.SetUsernameRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid set_username json", "error", err)
+		return nil, err
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return nil, fmt.Errorf("username is required: %w", ErrValidation)
+	}
+	if err := ValidateUsername(username); err != nil {
+		return nil, fmt.Errorf("%s: %w", err.Error(), ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	// A no-op rename succeeds without touching the row or waking peers
+	if username == user.Username {
+		return &SetUsernameResult{Envelope: setUsernameEnvelope(user)}, nil
+	}
+
+	// The taken check is case-insensitive, so changing only the case of your
+	// own username has to be allowed through explicitly
+	if !strings.EqualFold(username, user.Username) {
+		taken, err := db.UsernameTaken(ctx, a.db, username)
+		if err != nil {
+			a.logger.Error("unable to check username", "error", err, "username", username)
+			return nil, err
+		}
+		if taken {
+			return nil, fmt.Errorf("that username is already taken: %w", ErrValidation)
+		}
+	}
+
+	oldUsername := user.Username
+	user.Username = username
+	user.ModifiedAt = time.Now().Format(time.RFC3339)
+	if err := user.Update(ctx, a.db); err != nil {
+		a.logger.Error("failed to update username", "error", err, "user_id", user.ID)
+		user.Username = oldUsername
+		return nil, err
+	}
+	a.logger.Info("username changed", "user_id", user.ID, "from", oldUsername, "to", username)
+
+	broadcast, err := json.Marshal(&Envelope{
+		Type: "profile_updated",
+		Data: protocol.ProfileUpdated{
+			User: protocol.User{
+				ID:          user.ID,
+				Username:    user.Username,
+				DisplayName: user.DisplayName,
+				Status:      user.Status,
+				Avatar:      user.Avatar.String,
+			},
+		},
+	})
+	if err != nil {
+		a.logger.Error("failed to marshal profile_updated", "error", err, "user_id", user.ID)
+		return nil, err
+	}
+
+	return &SetUsernameResult{
+		Envelope:  setUsernameEnvelope(user),
+		Broadcast: broadcast,
+	}, nil
+}
+
+// setUsernameEnvelope builds the set_username response for a user
+func setUsernameEnvelope(user *models.User) *Envelope {
+	return &Envelope{
+		Type: "set_username",
+		Data: protocol.SetUsernameResponse{
+			User: protocol.User{
+				ID:          user.ID,
+				Username:    user.Username,
+				DisplayName: user.DisplayName,
+				Status:      user.Status,
+				Avatar:      user.Avatar.String,
+			},
+		},
+	}
+}
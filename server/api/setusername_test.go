@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// setUsername is a test helper to call SetUsername with a marshalled request
+func setUsername(t *testing.T, api *Api, user *models.User, username string) (*SetUsernameResult, error) {
+	t.Helper()
+	msg, err := json.Marshal(protocol.SetUsernameRequest{Username: username})
+	if err != nil {
+		t.Fatalf("Failed to marshal set_username request: %v", err)
+	}
+	return api.SetUsername(user, msg)
+}
+
+// autocompleteUsernames is a test helper returning the usernames suggested
+// for a prefix, as seen by user
+func autocompleteUsernames(t *testing.T, api *Api, user *models.User, prefix string) []string {
+	t.Helper()
+	msg, err := json.Marshal(protocol.AutocompleteRequest{Kind: "user", Prefix: prefix})
+	if err != nil {
+		t.Fatalf("Failed to marshal autocomplete request: %v", err)
+	}
+	env, err := api.Autocomplete(user, msg)
+	if err != nil {
+		t.Fatalf("Autocomplete failed: %v", err)
+	}
+	resp, ok := env.Data.(protocol.AutocompleteResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.AutocompleteResponse data type, got %T", env.Data)
+	}
+	usernames := make([]string, len(resp.Items))
+	for i, item := range resp.Items {
+		usernames[i] = item.Value
+	}
+	return usernames
+}
+
+// TestSetUsername_Validation tests that renames are held to the same rules
+// as registration
+func TestSetUsername_Validation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_setuname0001", "renameme")
+
+	for _, bad := range []string{"", "a", "has spaces", "bad!chars", "waaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaytoolong"} {
+		if _, err := setUsername(t, api, user, bad); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrValidation for username %q, got %v", bad, err)
+		}
+	}
+	if user.Username != "renameme" {
+		t.Errorf("Expected username to be unchanged after rejected renames, got '%s'", user.Username)
+	}
+}
+
+// TestSetUsername_Uniqueness tests that a rename can't take another user's
+// name, even with different casing, but may change the case of your own
+func TestSetUsername_Uniqueness(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_setuname0011", "unqalice")
+	createTestUser(t, database, "usr_setuname0012", "unqbob")
+
+	if _, err := setUsername(t, api, alice, "unqbob"); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for a taken username, got %v", err)
+	}
+	if _, err := setUsername(t, api, alice, "UnqBob"); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for a taken username with different case, got %v", err)
+	}
+
+	// Re-casing your own username is not a collision
+	if _, err := setUsername(t, api, alice, "UnqAlice"); err != nil {
+		t.Fatalf("Expected re-casing own username to succeed, got %v", err)
+	}
+	stored, err := models.UserByID(context.Background(), database, alice.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch user: %v", err)
+	}
+	if stored.Username != "UnqAlice" {
+		t.Errorf("Expected stored username 'UnqAlice', got '%s'", stored.Username)
+	}
+}
+
+// TestSetUsername_RenameUpdatesMentionResolution tests that a rename
+// persists, notifies peers, and that mention autocomplete resolves against
+// the new username while the old one stops matching
+func TestSetUsername_RenameUpdatesMentionResolution(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_setuname0021", "oldhandle")
+	bob := createTestUser(t, database, "usr_setuname0022", "mentioner")
+
+	result, err := setUsername(t, api, alice, "newhandle")
+	if err != nil {
+		t.Fatalf("SetUsername failed: %v", err)
+	}
+
+	resp, ok := result.Envelope.Data.(protocol.SetUsernameResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.SetUsernameResponse data type, got %T", result.Envelope.Data)
+	}
+	if resp.User.Username != "newhandle" {
+		t.Errorf("Expected response username 'newhandle', got '%s'", resp.User.Username)
+	}
+
+	// Peers get a profile_updated broadcast carrying the new username
+	if result.Broadcast == nil {
+		t.Fatal("Expected a profile_updated broadcast")
+	}
+	var broadcast struct {
+		Type string                  `json:"type"`
+		Data protocol.ProfileUpdated `json:"data"`
+	}
+	if err := json.Unmarshal(result.Broadcast, &broadcast); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast: %v", err)
+	}
+	if broadcast.Type != "profile_updated" {
+		t.Errorf("Expected broadcast type 'profile_updated', got '%s'", broadcast.Type)
+	}
+	if broadcast.Data.User.ID != alice.ID || broadcast.Data.User.Username != "newhandle" {
+		t.Errorf("Expected broadcast for %s with username 'newhandle', got %+v", alice.ID, broadcast.Data.User)
+	}
+
+	// Mention autocomplete resolves the new username and not the old one
+	if got := autocompleteUsernames(t, api, bob, "newhandle"); len(got) != 1 || got[0] != "newhandle" {
+		t.Errorf("Expected autocomplete for 'newhandle' to return it, got %v", got)
+	}
+	if got := autocompleteUsernames(t, api, bob, "oldhandle"); len(got) != 0 {
+		t.Errorf("Expected no autocomplete matches for the old username, got %v", got)
+	}
+
+	// A no-op rename succeeds and wakes nobody
+	result, err = setUsername(t, api, alice, "newhandle")
+	if err != nil {
+		t.Fatalf("No-op rename failed: %v", err)
+	}
+	if result.Broadcast != nil {
+		t.Error("Expected no broadcast for a no-op rename")
+	}
+}
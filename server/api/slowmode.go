@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// SetSlowModeResponse contains the broadcast data and room ID for routing
+type SetSlowModeResponse struct {
+	RoomID  string
+	Message []byte
+}
+
+// SetSlowMode handles a request to change a room's slow-mode interval.
+// Only room owners and admins may change it. Broadcasts a
+// room_settings_updated message to the room.
+func (a *Api) SetSlowMode(user *models.User, msg json.RawMessage) (*SetSlowModeResponse, error) {
+	var req protocol.SetSlowModeRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for set_slow_mode", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required")
+	}
+	if req.Seconds < 0 {
+		return nil, fmt.Errorf("seconds must not be negative")
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found")
+	}
+
+	// Only owners and admins may change slow mode
+	role, err := db.GetMemberRole(ctx, a.db, user.ID, room.ID)
+	if err != nil {
+		a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", room.ID)
+		return nil, err
+	}
+	if role != db.RoleOwner && role != db.RoleAdmin {
+		a.logger.Warn("user attempted to set slow mode without permission", "user", user.ID, "room", room.ID, "role", role)
+		return nil, fmt.Errorf("only room owners and admins can change slow mode")
+	}
+
+	room.SlowModeSeconds = req.Seconds
+	if err := room.Update(ctx, a.db); err != nil {
+		a.logger.Error("failed to update room slow mode", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	a.logger.Info("slow mode updated", "room", room.ID, "seconds", req.Seconds, "by", user.ID)
+
+	// Audit failures must never fail the action itself
+	if err := db.InsertAuditEntry(ctx, a.db, user.ID, db.AuditSlowModeChange, room.ID, room.ID); err != nil {
+		a.logger.Error("failed to write audit entry", "error", err, "action", db.AuditSlowModeChange, "actor", user.ID, "room", room.ID)
+	}
+
+	msgBytes, err := json.Marshal(&Envelope{
+		Type: "room_settings_updated",
+		Data: protocol.RoomSettingsUpdated{
+			RoomID:          room.ID,
+			SlowModeSeconds: room.SlowModeSeconds,
+			RetentionDays:   room.RetentionDays,
+			Topic:           room.Topic,
+			PostPolicy:      room.PostPolicy,
+			AnnouncePins:    room.AnnouncePins != 0,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetSlowModeResponse{
+		RoomID:  room.ID,
+		Message: msgBytes,
+	}, nil
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// setSlowMode sets a room's slow-mode interval directly in the database
+func setSlowMode(t *testing.T, room *models.Room, api *Api, seconds int) {
+	t.Helper()
+	room.SlowModeSeconds = seconds
+	if err := room.Update(context.Background(), api.db); err != nil {
+		t.Fatalf("Failed to set slow mode: %v", err)
+	}
+}
+
+// setMemberRole changes a user's role in a room
+func setMemberRole(t *testing.T, api *Api, userID, roomID, role string) {
+	t.Helper()
+	_, err := api.db.ExecContext(context.Background(),
+		"UPDATE rooms_members SET role = ? WHERE user_id = ? AND room_id = ?", role, userID, roomID)
+	if err != nil {
+		t.Fatalf("Failed to set member role: %v", err)
+	}
+}
+
+// sendMessage marshals and sends a message, returning the result
+func sendMessage(t *testing.T, api *Api, user *models.User, roomID, body string) (*MessageResponse, error) {
+	t.Helper()
+	msgJSON, _ := json.Marshal(protocol.SendMessageRequest{Body: body, RoomID: roomID})
+	return api.MessageMessage(user, msgJSON)
+}
+
+// TestSlowMode_SecondPostRejected tests that a member's second post within the
+// slow-mode interval is rejected with a SlowModeError
+func TestSlowMode_SecondPostRejected(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+	setSlowMode(t, room, api, 60)
+
+	// First post succeeds
+	if _, err := sendMessage(t, api, user, room.ID, "first"); err != nil {
+		t.Fatalf("First message should succeed: %v", err)
+	}
+
+	// Second post within the interval is rejected
+	response, err := sendMessage(t, api, user, room.ID, "second")
+	if err == nil {
+		t.Fatal("Expected error for second post within slow-mode interval, got nil")
+	}
+	if response != nil {
+		t.Error("Expected nil response for rejected post")
+	}
+
+	var slowErr *SlowModeError
+	if !errors.As(err, &slowErr) {
+		t.Fatalf("Expected SlowModeError, got %T: %v", err, err)
+	}
+	if slowErr.RemainingSeconds <= 0 || slowErr.RemainingSeconds > 60 {
+		t.Errorf("Expected remaining wait in (0, 60], got %d", slowErr.RemainingSeconds)
+	}
+}
+
+// TestSlowMode_AllowedAfterInterval tests that a post succeeds once the
+// slow-mode interval has elapsed
+func TestSlowMode_AllowedAfterInterval(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+	setSlowMode(t, room, api, 1)
+
+	// Insert a message posted before the interval, rather than sleeping
+	past := time.Now().Add(-2 * time.Second).Format(time.RFC3339Nano)
+	oldMsg := &models.Message{
+		ID:         "msg_slowmode001",
+		RoomID:     room.ID,
+		UserID:     user.ID,
+		Body:       "an older message",
+		CreatedAt:  past,
+		ModifiedAt: past,
+	}
+	if err := oldMsg.Insert(context.Background(), database); err != nil {
+		t.Fatalf("Failed to insert old message: %v", err)
+	}
+
+	if _, err := sendMessage(t, api, user, room.ID, "after the interval"); err != nil {
+		t.Errorf("Expected post after interval to succeed, got: %v", err)
+	}
+}
+
+// TestSlowMode_AdminsAndOwnersExempt tests that slow mode does not apply to
+// room admins or owners
+func TestSlowMode_AdminsAndOwnersExempt(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	cases := []struct {
+		role   string
+		userID string
+		roomID string
+	}{
+		{"admin", "usr_admin00000001", "roo_admin0000001"},
+		{"owner", "usr_owner00000001", "roo_owner0000001"},
+	}
+	for _, tc := range cases {
+		role := tc.role
+		user := createTestUser(t, database, tc.userID, role+"user")
+		room := createTestRoom(t, database, tc.roomID, role+"-room", false)
+		addUserToRoom(t, database, user.ID, room.ID)
+		setMemberRole(t, api, user.ID, room.ID, role)
+		setSlowMode(t, room, api, 60)
+
+		if _, err := sendMessage(t, api, user, room.ID, "first"); err != nil {
+			t.Fatalf("[%s] First message should succeed: %v", role, err)
+		}
+		if _, err := sendMessage(t, api, user, room.ID, "second"); err != nil {
+			t.Errorf("[%s] Expected rapid second post to succeed for exempt role, got: %v", role, err)
+		}
+	}
+}
+
+// TestSetSlowMode_RequiresOwnerOrAdmin tests that regular members cannot
+// change a room's slow mode
+func TestSetSlowMode_RequiresOwnerOrAdmin(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	msgJSON, _ := json.Marshal(protocol.SetSlowModeRequest{RoomID: room.ID, Seconds: 30})
+	if _, err := api.SetSlowMode(user, msgJSON); err == nil {
+		t.Error("Expected error when a regular member sets slow mode, got nil")
+	}
+}
+
+// TestSetSlowMode_OwnerBroadcastsUpdate tests that an owner can change slow
+// mode and that the change is broadcast as room_settings_updated
+func TestSetSlowMode_OwnerBroadcastsUpdate(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_test12345678", "general", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+	setMemberRole(t, api, user.ID, room.ID, "owner")
+
+	msgJSON, _ := json.Marshal(protocol.SetSlowModeRequest{RoomID: room.ID, Seconds: 30})
+	res, err := api.SetSlowMode(user, msgJSON)
+	if err != nil {
+		t.Fatalf("SetSlowMode failed: %v", err)
+	}
+	if res.RoomID != room.ID {
+		t.Errorf("Expected room ID %s, got %s", room.ID, res.RoomID)
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(res.Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast envelope: %v", err)
+	}
+	if envelope.Type != "room_settings_updated" {
+		t.Errorf("Expected envelope type 'room_settings_updated', got '%s'", envelope.Type)
+	}
+
+	// Verify the value was persisted
+	updated, err := models.RoomByID(context.Background(), database, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to reload room: %v", err)
+	}
+	if updated.SlowModeSeconds != 30 {
+		t.Errorf("Expected slow_mode_seconds 30, got %d", updated.SlowModeSeconds)
+	}
+}
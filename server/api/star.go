@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// StarMessage privately saves a message for the user. Starring an
+// already-starred message is a no-op; there is no success response.
+func (a *Api) StarMessage(user *models.User, msg json.RawMessage) error {
+	var req protocol.StarMessageRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for star_message request", "error", err)
+		return err
+	}
+
+	if req.MessageID == "" {
+		return fmt.Errorf("message_id is required: %w", ErrValidation)
+	}
+
+	ctx := context.Background()
+
+	// The message must exist and be readable by the user
+	message, err := models.MessageByID(ctx, a.db, req.MessageID)
+	if err != nil {
+		return fmt.Errorf("message %s not found: %w", req.MessageID, ErrValidation)
+	}
+	isMember, err := db.IsRoomMember(ctx, a.db, user.ID, message.RoomID)
+	if err != nil {
+		a.logger.Error("failed to check room membership", "error", err, "user", user.ID, "room", message.RoomID)
+		return err
+	}
+	if !isMember {
+		return fmt.Errorf("user is not a member of room %s: %w", message.RoomID, ErrNotMember)
+	}
+
+	return db.StarMessage(ctx, a.db, user.ID, req.MessageID)
+}
+
+// UnstarMessage removes the user's star from a message. Unstarring a message
+// that isn't starred is a no-op, so no access check is needed.
+func (a *Api) UnstarMessage(user *models.User, msg json.RawMessage) error {
+	var req protocol.UnstarMessageRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for unstar_message request", "error", err)
+		return err
+	}
+
+	if req.MessageID == "" {
+		return fmt.Errorf("message_id is required: %w", ErrValidation)
+	}
+
+	return db.UnstarMessage(context.Background(), a.db, user.ID, req.MessageID)
+}
+
+// ListStarred returns the user's starred messages, newest star first.
+// Messages in rooms the user can no longer access are omitted.
+func (a *Api) ListStarred(user *models.User, msg json.RawMessage) (*Envelope, error) {
+	var req protocol.ListStarredRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for list_starred request", "error", err)
+		return nil, err
+	}
+
+	starred, err := db.GetStarredMessages(context.Background(), a.db, user.ID)
+	if err != nil {
+		a.logger.Error("failed to get starred messages", "error", err, "user", user.ID)
+		return nil, err
+	}
+
+	return &Envelope{
+		Type: "list_starred",
+		Data: protocol.ListStarredResponse{
+			Messages: starred,
+		},
+	}, nil
+}
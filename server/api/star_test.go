@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// listStarred is a helper that runs ListStarred and returns the response data
+func listStarred(t *testing.T, api *Api, user *models.User) protocol.ListStarredResponse {
+	t.Helper()
+	response, err := api.ListStarred(user, json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("ListStarred failed: %v", err)
+	}
+	resp, ok := response.Data.(protocol.ListStarredResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.ListStarredResponse data type, got %T", response.Data)
+	}
+	return resp
+}
+
+// TestStar_Idempotent tests that starring twice records one star and
+// unstarring twice is a harmless no-op
+func TestStar_Idempotent(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_star_idem_01", "staridem")
+	room := createTestRoom(t, database, "roo_star_idem_01", "star-idem", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	msg := createTestMessage(t, database, "msg_star_idem_01", room.ID, user.ID, "keep this", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.StarMessageRequest{MessageID: msg.ID})
+	if err := api.StarMessage(user, reqJSON); err != nil {
+		t.Fatalf("StarMessage failed: %v", err)
+	}
+	if err := api.StarMessage(user, reqJSON); err != nil {
+		t.Fatalf("repeat StarMessage failed: %v", err)
+	}
+
+	if resp := listStarred(t, api, user); len(resp.Messages) != 1 {
+		t.Errorf("expected 1 starred message after double star, got %d", len(resp.Messages))
+	}
+
+	unstarJSON, _ := json.Marshal(protocol.UnstarMessageRequest{MessageID: msg.ID})
+	if err := api.UnstarMessage(user, unstarJSON); err != nil {
+		t.Fatalf("UnstarMessage failed: %v", err)
+	}
+	if err := api.UnstarMessage(user, unstarJSON); err != nil {
+		t.Fatalf("repeat UnstarMessage failed: %v", err)
+	}
+
+	if resp := listStarred(t, api, user); len(resp.Messages) != 0 {
+		t.Errorf("expected 0 starred messages after unstar, got %d", len(resp.Messages))
+	}
+}
+
+// TestListStarred tests that starred messages come back newest star first
+// with room context
+func TestListStarred(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_star_list_01", "starlister")
+	room := createTestRoom(t, database, "roo_star_list_01", "star-list", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	first := createTestMessage(t, database, "msg_star_list_01", room.ID, user.ID, "first", time.Now())
+	second := createTestMessage(t, database, "msg_star_list_02", room.ID, user.ID, "second", time.Now())
+
+	// Star with distinct timestamps so the order is unambiguous
+	for _, id := range []string{first.ID, second.ID} {
+		reqJSON, _ := json.Marshal(protocol.StarMessageRequest{MessageID: id})
+		if err := api.StarMessage(user, reqJSON); err != nil {
+			t.Fatalf("StarMessage failed: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	resp := listStarred(t, api, user)
+	if len(resp.Messages) != 2 {
+		t.Fatalf("expected 2 starred messages, got %d", len(resp.Messages))
+	}
+	if resp.Messages[0].Message.ID != second.ID {
+		t.Errorf("expected the most recently starred message first, got %s", resp.Messages[0].Message.ID)
+	}
+	if resp.Messages[0].RoomName != "star-list" {
+		t.Errorf("expected room context star-list, got %q", resp.Messages[0].RoomName)
+	}
+	if resp.Messages[0].StarredAt == "" {
+		t.Error("expected starred_at to be set")
+	}
+}
+
+// TestListStarred_HidesInaccessible tests that a starred message disappears
+// from the listing when the user loses access to its room
+func TestListStarred_HidesInaccessible(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_star_gone_01", "stargone")
+	room := createTestRoom(t, database, "roo_star_gone_01", "star-gone", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	msg := createTestMessage(t, database, "msg_star_gone_01", room.ID, user.ID, "fleeting", time.Now())
+
+	reqJSON, _ := json.Marshal(protocol.StarMessageRequest{MessageID: msg.ID})
+	if err := api.StarMessage(user, reqJSON); err != nil {
+		t.Fatalf("StarMessage failed: %v", err)
+	}
+	if resp := listStarred(t, api, user); len(resp.Messages) != 1 {
+		t.Fatalf("expected 1 starred message before leaving, got %d", len(resp.Messages))
+	}
+
+	if _, err := db.LeaveRoom(context.Background(), database, user.ID, room.ID); err != nil {
+		t.Fatalf("LeaveRoom failed: %v", err)
+	}
+
+	if resp := listStarred(t, api, user); len(resp.Messages) != 0 {
+		t.Errorf("expected starred message in an inaccessible room to be hidden, got %d", len(resp.Messages))
+	}
+}
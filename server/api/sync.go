@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// SyncResult contains the sync envelope and the current room ID for client
+// tracking
+type SyncResult struct {
+	Envelope    *Envelope
+	CurrentRoom string
+}
+
+// Sync assembles everything a fresh client load needs in one response: the
+// user, rooms, DMs, unread counts, online users, and the first page of the
+// current room's history. The transport supplies onlineUserIDs since
+// connection state lives in the hub, not the api layer. When the request
+// carries a Since timestamp, unread counts cover only messages posted after
+// it; otherwise they are measured against the user's read positions.
+func (a *Api) Sync(user *models.User, msg json.RawMessage, onlineUserIDs []string) (*SyncResult, error) {
+	var req protocol.SyncRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for sync request", "error", err)
+		return nil, err
+	}
+	if req.Since != "" {
+		if _, err := time.Parse(time.RFC3339Nano, req.Since); err != nil {
+			a.logger.Error("invalid since in sync request", "since", req.Since, "error", err)
+			return nil, fmt.Errorf("since must be an RFC3339 timestamp")
+		}
+	}
+
+	ctx := context.Background()
+
+	// The rooms, DMs, and current-room sections are exactly what init
+	// returns, so reuse it rather than duplicating the assembly
+	initRes, err := a.InitMessage(user, msg)
+	if err != nil {
+		return nil, err
+	}
+	initData, ok := initRes.Envelope.Data.(protocol.InitResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected init data type %T", initRes.Envelope.Data)
+	}
+
+	var activity []*db.RoomActivity
+	if req.Since != "" {
+		activity, err = db.GetRoomActivitySince(ctx, a.db, user.ID, req.Since)
+	} else {
+		activity, err = db.GetUnreadCounts(ctx, a.db, user.ID)
+	}
+	if err != nil {
+		a.logger.Error("failed to get unread counts", "error", err, "user", user.ID)
+		return nil, err
+	}
+	unread := make([]protocol.RoomUnread, len(activity))
+	for i, room := range activity {
+		unread[i] = protocol.RoomUnread{
+			RoomID:      room.RoomID,
+			RoomName:    room.RoomName,
+			UnreadCount: room.Count,
+		}
+	}
+
+	history, err := a.historyPage(ctx, user, initRes.CurrentRoom, "", defaultHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyncResult{
+		Envelope: &Envelope{
+			Type: "sync",
+			Data: protocol.SyncResponse{
+				User:          initData.User,
+				Rooms:         initData.Rooms,
+				DMs:           initData.DMs,
+				CurrentRoom:   initRes.CurrentRoom,
+				Unread:        unread,
+				OnlineUserIDs: onlineUserIDs,
+				History:       history,
+			},
+		},
+		CurrentRoom: initRes.CurrentRoom,
+	}, nil
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// syncRequest marshals and sends a sync request, returning the response data
+func syncRequest(t *testing.T, api *Api, user *models.User, since string, online []string) protocol.SyncResponse {
+	t.Helper()
+	reqJSON, _ := json.Marshal(protocol.SyncRequest{Since: since})
+	res, err := api.Sync(user, reqJSON, online)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	data, ok := res.Envelope.Data.(protocol.SyncResponse)
+	if !ok {
+		t.Fatalf("Expected a SyncResponse, got %T", res.Envelope.Data)
+	}
+	if res.CurrentRoom != data.CurrentRoom {
+		t.Errorf("Expected the result's current room %q to match the response %q", res.CurrentRoom, data.CurrentRoom)
+	}
+	return data
+}
+
+// TestSync_ContainsAllSections tests that a sync response carries the user,
+// rooms, DMs, unread counts, online users, and current-room history
+// consistently in one round-trip
+func TestSync_ContainsAllSections(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_sync_0000001", "sync-alice")
+	bob := createTestUser(t, database, "usr_sync_0000002", "sync-bob")
+	room := createTestRoom(t, database, "roo_sync_0000001", "sync-room", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+	addUserToRoom(t, database, bob.ID, room.ID)
+
+	dm := &models.Room{
+		ID:        "roo_sync_dm00001",
+		Name:      "",
+		RoomType:  "dm",
+		IsPrivate: models.TRUE,
+		IsDefault: models.FALSE,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := dm.Insert(context.Background(), database); err != nil {
+		t.Fatalf("Failed to insert DM room: %v", err)
+	}
+	addUserToRoom(t, database, alice.ID, dm.ID)
+	addUserToRoom(t, database, bob.ID, dm.ID)
+
+	if _, err := sendMessage(t, api, bob, room.ID, "first"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	res, err := sendMessage(t, api, bob, room.ID, "second")
+	if err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	_, latest := unmarshalBroadcast(t, res.Message)
+
+	alice.LastRoom = room.ID
+	online := []string{alice.ID, bob.ID}
+	sync := syncRequest(t, api, alice, "", online)
+
+	if sync.User.ID != alice.ID {
+		t.Errorf("Expected user %s, got %s", alice.ID, sync.User.ID)
+	}
+	if sync.CurrentRoom != room.ID {
+		t.Errorf("Expected current room %s, got %s", room.ID, sync.CurrentRoom)
+	}
+
+	foundRoom := false
+	for _, r := range sync.Rooms {
+		if r.ID == room.ID {
+			foundRoom = true
+		}
+	}
+	if !foundRoom {
+		t.Error("Expected the channel room in the rooms section")
+	}
+
+	foundDM := false
+	for _, r := range sync.DMs {
+		if r.ID == dm.ID {
+			foundDM = true
+		}
+	}
+	if !foundDM {
+		t.Error("Expected the DM in the dms section")
+	}
+
+	if len(sync.Unread) != 1 || sync.Unread[0].RoomID != room.ID || sync.Unread[0].UnreadCount != 2 {
+		t.Errorf("Expected 2 unread messages in %s, got %+v", room.ID, sync.Unread)
+	}
+	if len(sync.OnlineUserIDs) != 2 {
+		t.Errorf("Expected 2 online users, got %v", sync.OnlineUserIDs)
+	}
+	if sync.History == nil || len(sync.History.Messages) != 2 {
+		t.Fatalf("Expected 2 messages of current-room history, got %+v", sync.History)
+	}
+	if sync.History.Messages[0].ID != latest.ID {
+		t.Errorf("Expected the newest message first in history, got %s", sync.History.Messages[0].ID)
+	}
+
+	// Once alice reads to the end, the unread section empties
+	markJSON, _ := json.Marshal(protocol.MarkReadRequest{RoomID: room.ID, MessageID: latest.ID})
+	if err := api.MarkRead(alice, markJSON); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+	sync = syncRequest(t, api, alice, "", online)
+	if len(sync.Unread) != 0 {
+		t.Errorf("Expected no unread rooms after reading, got %+v", sync.Unread)
+	}
+}
+
+// TestSync_SinceNarrowsUnread tests that a Since timestamp narrows the
+// unread section to messages posted after it
+func TestSync_SinceNarrowsUnread(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	alice := createTestUser(t, database, "usr_sync_0000003", "sync-carol")
+	bob := createTestUser(t, database, "usr_sync_0000004", "sync-dave")
+	room := createTestRoom(t, database, "roo_sync_0000002", "sync-since", false)
+	addUserToRoom(t, database, alice.ID, room.ID)
+	addUserToRoom(t, database, bob.ID, room.ID)
+
+	if _, err := sendMessage(t, api, bob, room.ID, "before the cutoff"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	since := time.Now().Format(time.RFC3339Nano)
+	time.Sleep(5 * time.Millisecond)
+	if _, err := sendMessage(t, api, bob, room.ID, "after the cutoff"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	alice.LastRoom = room.ID
+
+	sync := syncRequest(t, api, alice, "", nil)
+	if len(sync.Unread) != 1 || sync.Unread[0].UnreadCount != 2 {
+		t.Errorf("Expected 2 unread messages without since, got %+v", sync.Unread)
+	}
+
+	sync = syncRequest(t, api, alice, since, nil)
+	if len(sync.Unread) != 1 || sync.Unread[0].UnreadCount != 1 {
+		t.Errorf("Expected 1 unread message with since, got %+v", sync.Unread)
+	}
+}
+
+// TestSync_InvalidSince tests that a malformed since timestamp is rejected
+func TestSync_InvalidSince(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_sync_0000005", "sync-erin")
+	room := createTestRoom(t, database, "roo_sync_0000003", "sync-bad", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	user.LastRoom = room.ID
+
+	reqJSON, _ := json.Marshal(protocol.SyncRequest{Since: "not-a-timestamp"})
+	if _, err := api.Sync(user, reqJSON, nil); err == nil {
+		t.Error("Expected an error for a malformed since timestamp")
+	}
+}
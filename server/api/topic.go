@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maxTopicLength caps room topics at a single header-sized line
+const maxTopicLength = 250
+
+// SetRoomTopicResponse contains the broadcast data and room ID for routing
+type SetRoomTopicResponse struct {
+	RoomID  string
+	Message []byte
+}
+
+// SetRoomTopic handles a request to change a room's topic. Only room owners
+// and admins may change it. Broadcasts a room_settings_updated message to
+// the room.
+func (a *Api) SetRoomTopic(user *models.User, msg json.RawMessage) (*SetRoomTopicResponse, error) {
+	var req protocol.SetRoomTopicRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		a.logger.Error("invalid json for set_room_topic", "error", err)
+		return nil, err
+	}
+
+	if req.RoomID == "" {
+		return nil, fmt.Errorf("room_id is required")
+	}
+	topic := strings.TrimSpace(req.Topic)
+	if len(topic) > maxTopicLength {
+		return nil, fmt.Errorf("topic must be at most %d characters", maxTopicLength)
+	}
+
+	ctx := context.Background()
+
+	room, err := models.RoomByID(ctx, a.db, req.RoomID)
+	if err != nil {
+		a.logger.Error("room not found", "error", err, "room", req.RoomID)
+		return nil, fmt.Errorf("room not found")
+	}
+
+	// Only owners and admins may change the topic
+	role, err := db.GetMemberRole(ctx, a.db, user.ID, room.ID)
+	if err != nil {
+		a.logger.Error("failed to get member role", "error", err, "user", user.ID, "room", room.ID)
+		return nil, err
+	}
+	if role != db.RoleOwner && role != db.RoleAdmin {
+		a.logger.Warn("user attempted to set room topic without permission", "user", user.ID, "room", room.ID, "role", role)
+		return nil, fmt.Errorf("only room owners and admins can change the topic")
+	}
+
+	room.Topic = topic
+	if err := room.Update(ctx, a.db); err != nil {
+		a.logger.Error("failed to update room topic", "error", err, "room", room.ID)
+		return nil, err
+	}
+
+	a.logger.Info("room topic updated", "room", room.ID, "by", user.ID)
+
+	// Audit failures must never fail the action itself
+	if err := db.InsertAuditEntry(ctx, a.db, user.ID, db.AuditTopicChange, room.ID, room.ID); err != nil {
+		a.logger.Error("failed to write audit entry", "error", err, "action", db.AuditTopicChange, "actor", user.ID, "room", room.ID)
+	}
+
+	msgBytes, err := json.Marshal(&Envelope{
+		Type: "room_settings_updated",
+		Data: protocol.RoomSettingsUpdated{
+			RoomID:          room.ID,
+			SlowModeSeconds: room.SlowModeSeconds,
+			RetentionDays:   room.RetentionDays,
+			Topic:           room.Topic,
+			PostPolicy:      room.PostPolicy,
+			AnnouncePins:    room.AnnouncePins != 0,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetRoomTopicResponse{
+		RoomID:  room.ID,
+		Message: msgBytes,
+	}, nil
+}
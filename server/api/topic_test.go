@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestSetRoomTopic_OwnerBroadcastsUpdate tests that an owner can set the
+// topic and that the change is broadcast as room_settings_updated
+func TestSetRoomTopic_OwnerBroadcastsUpdate(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_topic_own001", "topicowner")
+	room := createTestRoom(t, database, "roo_topic_set001", "topicroom", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	setMemberRole(t, api, user.ID, room.ID, "owner")
+
+	msgJSON, _ := json.Marshal(protocol.SetRoomTopicRequest{RoomID: room.ID, Topic: "all things gophers"})
+	res, err := api.SetRoomTopic(user, msgJSON)
+	if err != nil {
+		t.Fatalf("SetRoomTopic failed: %v", err)
+	}
+	if res.RoomID != room.ID {
+		t.Errorf("Expected room ID %s, got %s", room.ID, res.RoomID)
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(res.Message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal broadcast envelope: %v", err)
+	}
+	if envelope.Type != "room_settings_updated" {
+		t.Errorf("Expected envelope type 'room_settings_updated', got '%s'", envelope.Type)
+	}
+	data, _ := json.Marshal(envelope.Data)
+	var updated protocol.RoomSettingsUpdated
+	if err := json.Unmarshal(data, &updated); err != nil {
+		t.Fatalf("Failed to unmarshal room_settings_updated: %v", err)
+	}
+	if updated.Topic != "all things gophers" {
+		t.Errorf("Expected broadcast topic 'all things gophers', got '%s'", updated.Topic)
+	}
+
+	// Verify the value was persisted
+	reloaded, err := models.RoomByID(context.Background(), database, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to reload room: %v", err)
+	}
+	if reloaded.Topic != "all things gophers" {
+		t.Errorf("Expected topic 'all things gophers', got '%s'", reloaded.Topic)
+	}
+}
+
+// TestSetRoomTopic_LengthCapped tests that an over-long topic is rejected
+func TestSetRoomTopic_LengthCapped(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_topic_own002", "topicowner2")
+	room := createTestRoom(t, database, "roo_topic_set002", "topicroom2", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	setMemberRole(t, api, user.ID, room.ID, "owner")
+
+	msgJSON, _ := json.Marshal(protocol.SetRoomTopicRequest{
+		RoomID: room.ID,
+		Topic:  strings.Repeat("x", maxTopicLength+1),
+	})
+	if _, err := api.SetRoomTopic(user, msgJSON); err == nil {
+		t.Error("Expected error for an over-long topic, got nil")
+	}
+}
+
+// TestSetRoomTopic_RequiresOwnerOrAdmin tests that a regular member cannot
+// change the topic
+func TestSetRoomTopic_RequiresOwnerOrAdmin(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_topic_mem001", "topicmember")
+	room := createTestRoom(t, database, "roo_topic_set003", "topicroom3", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	msgJSON, _ := json.Marshal(protocol.SetRoomTopicRequest{RoomID: room.ID, Topic: "nope"})
+	if _, err := api.SetRoomTopic(user, msgJSON); err == nil {
+		t.Error("Expected error when a regular member sets the topic, got nil")
+	}
+}
+
+// TestSetRoomTopic_RoundTripsThroughRoomInfo tests that a set topic comes
+// back in the room_info response
+func TestSetRoomTopic_RoundTripsThroughRoomInfo(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_topic_own003", "topicowner3")
+	room := createTestRoom(t, database, "roo_topic_set004", "topicroom4", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	setMemberRole(t, api, user.ID, room.ID, "admin")
+
+	msgJSON, _ := json.Marshal(protocol.SetRoomTopicRequest{RoomID: room.ID, Topic: "weekly standup notes"})
+	if _, err := api.SetRoomTopic(user, msgJSON); err != nil {
+		t.Fatalf("SetRoomTopic failed: %v", err)
+	}
+
+	infoJSON, _ := json.Marshal(protocol.RoomInfoRequest{RoomID: room.ID})
+	response, err := api.RoomInfo(user, infoJSON)
+	if err != nil {
+		t.Fatalf("RoomInfo failed: %v", err)
+	}
+	info, ok := response.Data.(protocol.RoomInfoResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.RoomInfoResponse data type, got %T", response.Data)
+	}
+	if info.Room.Topic != "weekly standup notes" {
+		t.Errorf("Expected topic 'weekly standup notes', got '%s'", info.Room.Topic)
+	}
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// updateProfile marshals and sends an update_profile request, returning the
+// response data
+func updateProfile(t *testing.T, api *Api, user *models.User, req protocol.UpdateProfileRequest) protocol.UpdateProfileResponse {
+	t.Helper()
+	reqJSON, _ := json.Marshal(req)
+	response, err := api.UpdateProfile(user, reqJSON)
+	if err != nil {
+		t.Fatalf("UpdateProfile failed: %v", err)
+	}
+	resp, ok := response.Data.(protocol.UpdateProfileResponse)
+	if !ok {
+		t.Fatalf("Expected protocol.UpdateProfileResponse data type, got %T", response.Data)
+	}
+	return resp
+}
+
+// strPtr returns a pointer to s, for optional request fields
+func strPtr(s string) *string { return &s }
+
+// TestUpdateProfile_Valid tests a full valid profile update, including
+// control-character stripping
+func TestUpdateProfile_Valid(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_updprof00001", "updprofvalid")
+
+	resp := updateProfile(t, api, user, protocol.UpdateProfileRequest{
+		DisplayName: strPtr("  Alice\x00 Example "),
+		Status:      strPtr("out to\x1b lunch"),
+		Avatar:      strPtr("https://example.com/avatars/alice.png"),
+	})
+
+	if resp.User.DisplayName != "Alice Example" {
+		t.Errorf("Expected display name 'Alice Example', got '%s'", resp.User.DisplayName)
+	}
+	if resp.User.Status != "out to lunch" {
+		t.Errorf("Expected status 'out to lunch', got '%s'", resp.User.Status)
+	}
+	if resp.User.Avatar != "https://example.com/avatars/alice.png" {
+		t.Errorf("Expected avatar to be set, got '%s'", resp.User.Avatar)
+	}
+
+	// A relative path (this server's own upload) is always allowed
+	resp = updateProfile(t, api, user, protocol.UpdateProfileRequest{
+		Avatar: strPtr("/files/fil_abc123/avatar.png"),
+	})
+	if resp.User.Avatar != "/files/fil_abc123/avatar.png" {
+		t.Errorf("Expected relative avatar path to be accepted, got '%s'", resp.User.Avatar)
+	}
+}
+
+// TestUpdateProfile_BadAvatarScheme tests that non-http(s) avatar URLs are
+// rejected
+func TestUpdateProfile_BadAvatarScheme(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_updprof00011", "updprofscheme")
+
+	for _, avatar := range []string{
+		"javascript:alert(1)",
+		"data:image/png;base64,AAAA",
+		"ftp://example.com/a.png",
+	} {
+		reqJSON, _ := json.Marshal(protocol.UpdateProfileRequest{Avatar: strPtr(avatar)})
+		if _, err := api.UpdateProfile(user, reqJSON); !errors.Is(err, ErrValidation) {
+			t.Errorf("Expected ErrValidation for avatar '%s', got %v", avatar, err)
+		}
+	}
+}
+
+// TestUpdateProfile_HostAllowlist tests that a configured allowlist
+// restricts avatar hosts
+func TestUpdateProfile_HostAllowlist(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.avatarHosts = []string{"cdn.example.com"}
+
+	user := createTestUser(t, database, "usr_updprof00021", "updprofhosts")
+
+	updateProfile(t, api, user, protocol.UpdateProfileRequest{
+		Avatar: strPtr("https://cdn.example.com/alice.png"),
+	})
+
+	reqJSON, _ := json.Marshal(protocol.UpdateProfileRequest{
+		Avatar: strPtr("https://tracker.example.net/pixel.png"),
+	})
+	if _, err := api.UpdateProfile(user, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an off-list avatar host, got %v", err)
+	}
+}
+
+// TestUpdateProfile_LengthBounds tests the display name and status caps
+func TestUpdateProfile_LengthBounds(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_updprof00031", "updproflength")
+
+	reqJSON, _ := json.Marshal(protocol.UpdateProfileRequest{
+		Status: strPtr(strings.Repeat("x", maxStatusLength+1)),
+	})
+	if _, err := api.UpdateProfile(user, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an over-long status, got %v", err)
+	}
+
+	reqJSON, _ = json.Marshal(protocol.UpdateProfileRequest{
+		DisplayName: strPtr(strings.Repeat("x", maxDisplayNameLength+1)),
+	})
+	if _, err := api.UpdateProfile(user, reqJSON); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation for an over-long display name, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Username constraints: usernames appear in @mentions, so the allowed
+// character set must not contain whitespace or mention-breaking punctuation
+const (
+	usernameMinLen = 2
+	usernameMaxLen = 32
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidateUsername checks a trimmed username against the allowed character
+// set and length bounds. Registration and set_username share these rules so
+// a renamed user is always reachable by mention. The returned error message
+// is safe to show to the user.
+func ValidateUsername(username string) error {
+	if len(username) < usernameMinLen {
+		return fmt.Errorf("username must be at least %d characters", usernameMinLen)
+	}
+	if len(username) > usernameMaxLen {
+		return fmt.Errorf("username must be %d characters or less", usernameMaxLen)
+	}
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("username may only contain letters, numbers, underscores, and dashes")
+	}
+	return nil
+}
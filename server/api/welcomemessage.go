@@ -0,0 +1,21 @@
+package api
+
+import (
+	"log/slog"
+	"os"
+)
+
+// welcomeMessageFromEnv reads ROOM_WELCOME_MESSAGE ("true" posts a system
+// message announcing who created each new room), falling back to off when it
+// is unset or invalid
+func welcomeMessageFromEnv(logger *slog.Logger) bool {
+	switch val := os.Getenv("ROOM_WELCOME_MESSAGE"); val {
+	case "", "false":
+		return false
+	case "true":
+		return true
+	default:
+		logger.Warn("invalid ROOM_WELCOME_MESSAGE, using default", "value", val, "default", false)
+		return false
+	}
+}
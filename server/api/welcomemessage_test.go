@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// countRoomMessages returns the number of messages stored in a room
+func countRoomMessages(t *testing.T, api *Api, roomID string) int {
+	t.Helper()
+	var count int
+	row := api.db.QueryRowContext(context.Background(),
+		"SELECT count(*) FROM messages WHERE room_id = ?", roomID)
+	if err := row.Scan(&count); err != nil {
+		t.Fatalf("Failed to count messages: %v", err)
+	}
+	return count
+}
+
+// TestCreateRoomWelcomeMessage tests that with the feature enabled, room
+// creation posts exactly one system message attributed to the creator, and
+// that the message can't be edited or deleted
+func TestCreateRoomWelcomeMessage(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+	api.welcomeMessage = true
+
+	user := createTestUser(t, database, "usr_welcome00001", "welcomer")
+
+	reqJSON, _ := json.Marshal(protocol.CreateRoomRequest{Name: "welcome-room"})
+	res, err := api.CreateRoom(user, reqJSON)
+	if err != nil {
+		t.Fatalf("CreateRoom failed: %v", err)
+	}
+
+	if res.Broadcast == nil {
+		t.Fatal("Expected a welcome broadcast")
+	}
+	msgType, welcome := unmarshalBroadcast(t, res.Broadcast)
+	if msgType != "message" {
+		t.Errorf("Expected a message broadcast, got %q", msgType)
+	}
+	if !welcome.IsSystem {
+		t.Error("Expected the welcome message to be a system message")
+	}
+	if welcome.UserID != user.ID {
+		t.Errorf("Expected the welcome message to be attributed to %s, got %s", user.ID, welcome.UserID)
+	}
+	if welcome.RoomID != res.RoomID {
+		t.Errorf("Expected the welcome message in room %s, got %s", res.RoomID, welcome.RoomID)
+	}
+	if welcome.Body != "Room created by welcomer" {
+		t.Errorf("Expected body %q, got %q", "Room created by welcomer", welcome.Body)
+	}
+
+	// The welcome message is the only one in the new room
+	if count := countRoomMessages(t, api, res.RoomID); count != 1 {
+		t.Errorf("Expected exactly 1 message in the new room, got %d", count)
+	}
+
+	// Not even the attributed creator can edit or delete it
+	editJSON, _ := json.Marshal(protocol.EditMessageRequest{MessageID: welcome.ID, Body: "rewritten"})
+	if _, err := api.EditMessage(user, editJSON); err == nil {
+		t.Error("Expected editing the welcome message to fail")
+	}
+	delJSON, _ := json.Marshal(protocol.DeleteMessageRequest{MessageID: welcome.ID})
+	if _, err := api.DeleteMessage(user, delJSON); err == nil {
+		t.Error("Expected deleting the welcome message to fail")
+	}
+}
+
+// TestCreateRoomWelcomeMessage_Disabled tests that the feature is off by
+// default and new rooms start empty
+func TestCreateRoomWelcomeMessage_Disabled(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewApi(database, logger)
+
+	user := createTestUser(t, database, "usr_welcome00002", "quietmaker")
+
+	reqJSON, _ := json.Marshal(protocol.CreateRoomRequest{Name: "quiet-room"})
+	res, err := api.CreateRoom(user, reqJSON)
+	if err != nil {
+		t.Fatalf("CreateRoom failed: %v", err)
+	}
+	if res.Broadcast != nil {
+		t.Error("Expected no welcome broadcast with the feature disabled")
+	}
+	if count := countRoomMessages(t, api, res.RoomID); count != 0 {
+		t.Errorf("Expected an empty room, got %d messages", count)
+	}
+}
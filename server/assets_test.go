@@ -0,0 +1,94 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEmbeddedAssetsServed tests that the home template and static files are
+// served from the embedded asset FS
+func TestEmbeddedAssetsServed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	resp, err := http.Get(ts.server.URL + "/")
+	if err != nil {
+		t.Fatalf("Failed to fetch home page: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for home page, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(strings.ToLower(string(body)), "<html") {
+		t.Error("Expected home page to contain HTML")
+	}
+
+	resp, err = http.Get(ts.server.URL + "/static/style.css")
+	if err != nil {
+		t.Fatalf("Failed to fetch static file: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for static file, got %d", resp.StatusCode)
+	}
+	if len(body) == 0 {
+		t.Error("Expected non-empty static file")
+	}
+}
+
+// TestDiskAssetsOverride tests that switching the asset FS to a directory on
+// disk serves that directory's files instead of the embedded copies
+func TestDiskAssetsOverride(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	// Build a temp directory shaped like the repository root
+	tmp := t.TempDir()
+	for dir, file := range map[string]string{
+		"template": "home.html",
+		"static":   "style.css",
+	} {
+		if err := os.MkdirAll(filepath.Join(tmp, dir), 0o755); err != nil {
+			t.Fatalf("Failed to create %s dir: %v", dir, err)
+		}
+		content := "disk override " + file
+		if err := os.WriteFile(filepath.Join(tmp, dir, file), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+	ts.chatServer.assets = os.DirFS(tmp)
+
+	resp, err := http.Get(ts.server.URL + "/")
+	if err != nil {
+		t.Fatalf("Failed to fetch home page: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "disk override home.html" {
+		t.Errorf("Expected home page from disk, got %q", string(body))
+	}
+
+	resp, err = http.Get(ts.server.URL + "/static/style.css")
+	if err != nil {
+		t.Fatalf("Failed to fetch static file: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "disk override style.css" {
+		t.Errorf("Expected static file from disk, got %q", string(body))
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/middleware"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// adminUsersFromEnv parses ADMIN_USERS, a comma-separated list of usernames
+// permitted to call the admin endpoints. An empty value means no one can.
+func adminUsersFromEnv() map[string]bool {
+	admins := make(map[string]bool)
+	for _, name := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			admins[name] = true
+		}
+	}
+	return admins
+}
+
+// auditEntryResponse is one audit row as returned by the admin audit endpoint
+type auditEntryResponse struct {
+	ID        int64  `json:"id"`
+	ActorID   string `json:"actor_id"`
+	Action    string `json:"action"`
+	TargetID  string `json:"target_id,omitempty"`
+	RoomID    string `json:"room_id,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// auditListResponse is the response for listing audit entries
+type auditListResponse struct {
+	Entries    []auditEntryResponse `json:"entries"`
+	NextCursor int64                `json:"next_cursor,omitempty"`
+}
+
+// auditAPI handles GET /api/v1/admin/audit for users named in ADMIN_USERS.
+// Entries come back newest first and may be narrowed with the actor, room,
+// since, and until query parameters; page with limit and before.
+func (h *ChatServer) auditAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeAuthJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "GET required"})
+		return
+	}
+
+	user, err := models.UserByID(r.Context(), h.db, middleware.GetUserID(r.Context()))
+	if err != nil {
+		h.logger.Error("unable to find user for audit request", "err", err)
+		h.writeAuthJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+		return
+	}
+	if !h.adminUsers[user.Username] {
+		h.logger.Warn("non-admin attempted to read the audit log", "user", user.ID)
+		h.writeAuthJSON(w, http.StatusForbidden, map[string]string{"error": "Forbidden"})
+		return
+	}
+
+	q := r.URL.Query()
+	filter := db.AuditFilter{
+		ActorID: q.Get("actor"),
+		RoomID:  q.Get("room"),
+		Since:   q.Get("since"),
+		Until:   q.Get("until"),
+		Limit:   defaultAuditPageSize,
+	}
+	if val := q.Get("limit"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n <= 0 {
+			h.writeAuthJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+		if n > maxAuditPageSize {
+			n = maxAuditPageSize
+		}
+		filter.Limit = n
+	}
+	if val := q.Get("before"); val != "" {
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || n <= 0 {
+			h.writeAuthJSON(w, http.StatusBadRequest, map[string]string{"error": "before must be a positive integer"})
+			return
+		}
+		filter.Before = n
+	}
+
+	entries, err := db.ListAuditEntries(r.Context(), h.db, filter)
+	if err != nil {
+		h.logger.Error("failed to list audit entries", "err", err)
+		h.writeAuthJSON(w, http.StatusInternalServerError, map[string]string{"error": "unable to list audit entries"})
+		return
+	}
+
+	response := auditListResponse{Entries: make([]auditEntryResponse, len(entries))}
+	for i, e := range entries {
+		response.Entries[i] = auditEntryResponse{
+			ID:        e.ID,
+			ActorID:   e.ActorID,
+			Action:    e.Action,
+			TargetID:  e.TargetID,
+			RoomID:    e.RoomID,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+	// A full page may have older entries behind it
+	if len(entries) == filter.Limit {
+		response.NextCursor = entries[len(entries)-1].ID
+	}
+	h.writeAuthJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/middleware"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// TestAuditAPIAdminOnly tests that only users named in ADMIN_USERS can read
+// the audit log
+func TestAuditAPIAdminOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testDB, err := db.NewDB("file::memory:?cache=shared", logger)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() { _ = testDB.Close() }()
+	if err := testDB.RunSQLFile("../schema.sql"); err != nil {
+		t.Fatalf("Failed to run schema: %v", err)
+	}
+
+	chatServer := &ChatServer{
+		db:         testDB,
+		logger:     logger,
+		adminUsers: map[string]bool{"auditadmin": true},
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	admin := &models.User{ID: "usr_audit_admin1", Username: "auditadmin", Password: "x", CreatedAt: now, ModifiedAt: now}
+	regular := &models.User{ID: "usr_audit_plain1", Username: "auditplain", Password: "x", CreatedAt: now, ModifiedAt: now}
+	for _, u := range []*models.User{admin, regular} {
+		if err := u.Insert(context.Background(), testDB); err != nil {
+			t.Fatalf("Failed to insert user: %v", err)
+		}
+	}
+
+	// auditAPI runs behind AuthMiddleware, which stores the user ID in the
+	// request context; inject it directly here
+	request := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit", nil)
+		ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
+		w := httptest.NewRecorder()
+		chatServer.auditAPI(w, req.WithContext(ctx))
+		return w
+	}
+
+	if w := request(regular.ID); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin, got %d", w.Code)
+	}
+	if w := request(admin.ID); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for admin, got %d", w.Code)
+	}
+}
@@ -0,0 +1,379 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/llimllib/hatchat/server/api"
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// authFailure is an auth error with an HTTP status and a message that is
+// safe to show to the user
+type authFailure struct {
+	status int
+	msg    string
+}
+
+func (f *authFailure) Error() string { return f.msg }
+
+// redirectWithError redirects to the home page with a user-facing error
+// message that the home page can render
+func (h *ChatServer) redirectWithError(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, "/?error="+url.QueryEscape(msg), http.StatusFound)
+}
+
+// wantsJSON reports whether a client asked for an API-style response rather
+// than a browser redirect
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// authError conveys an auth failure to the client: JSON with a proper status
+// code for API clients, or a redirect with an error message the home page
+// can render for browsers
+func (h *ChatServer) authError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	if wantsJSON(r) {
+		h.writeAuthJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	h.redirectWithError(w, r, msg)
+}
+
+// writeAuthJSON writes a JSON response for the auth endpoints
+func (h *ChatServer) writeAuthJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("failed to encode auth response", "err", err)
+	}
+}
+
+// handleAuthFailure writes an auth error, unwrapping authFailure for its
+// status and message and treating anything else as an internal error
+func (h *ChatServer) handleAuthFailure(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	var fail *authFailure
+	if errors.As(err, &fail) {
+		h.authError(w, r, fail.status, fail.msg)
+		return
+	}
+	h.authError(w, r, http.StatusInternalServerError, fallback)
+}
+
+// createAccount validates registration input and creates a new user in the
+// default room, consuming the invite code when one is required. Failures are
+// returned as *authFailure with an appropriate HTTP status.
+func (h *ChatServer) createAccount(ctx context.Context, username, password, inviteCode string) (*models.User, error) {
+	if h.registrationMode == RegistrationClosed {
+		h.logger.Debug("registration is closed")
+		return nil, &authFailure{http.StatusForbidden, "registration is closed"}
+	}
+
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, &authFailure{http.StatusBadRequest, "username is required"}
+	}
+	if err := api.ValidateUsername(username); err != nil {
+		h.logger.Debug("invalid username", "username", username, "err", err)
+		return nil, &authFailure{http.StatusBadRequest, err.Error()}
+	}
+	taken, err := db.UsernameTaken(ctx, h.db, username)
+	if err != nil {
+		h.logger.Error("unable to check username", "username", username, "err", err)
+		return nil, err
+	}
+	if taken {
+		h.logger.Debug("username taken", "username", username)
+		return nil, &authFailure{http.StatusConflict, "that username is already taken"}
+	}
+	if password == "" {
+		return nil, &authFailure{http.StatusBadRequest, "password is required"}
+	}
+	if fail := h.checkPasswordStrength(password); fail != nil {
+		h.logger.Debug("weak password rejected", "username", username)
+		return nil, fail
+	}
+
+	// In invite mode, a valid unused signup invite code is required; it is
+	// consumed atomically just before the user row is created
+	var invite *models.SignupInvite
+	if h.registrationMode == RegistrationInvite {
+		if inviteCode == "" {
+			h.logger.Debug("missing invite code")
+			return nil, &authFailure{http.StatusBadRequest, "an invite code is required"}
+		}
+		invite, err = models.SignupInviteByCode(ctx, h.db, inviteCode)
+		if err != nil {
+			h.logger.Debug("invalid invite code", "code", inviteCode)
+			return nil, &authFailure{http.StatusBadRequest, "invalid invite code"}
+		}
+	}
+
+	// A bcryptCost below bcrypt.MinCost (e.g. the zero value) falls back to
+	// bcrypt.DefaultCost inside GenerateFromPassword
+	encPass, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	if err != nil {
+		h.logger.Error("unable to encrypt pass", "err", err)
+		return nil, err
+	}
+
+	// Users automatically get inserted into the default room
+	room, err := models.GetDefaultRoom(ctx, h.db)
+	if err != nil {
+		h.logger.Error("unable to get default room", "err", err)
+		return nil, err
+	}
+
+	// Consume the invite before creating the user. The consume is a single
+	// conditional UPDATE, so when several registrations race on the same
+	// single-use code only one of them gets past this point.
+	if invite != nil {
+		won, err := db.ConsumeSignupInvite(ctx, h.db, invite.Code, time.Now().Format(time.RFC3339))
+		if err != nil {
+			h.logger.Error("unable to consume invite", "code", invite.Code, "err", err)
+			return nil, err
+		}
+		if !won {
+			h.logger.Debug("invite code already used", "code", invite.Code)
+			return nil, &authFailure{http.StatusBadRequest, "that invite code has already been used"}
+		}
+	}
+
+	user := &models.User{
+		ID:         models.GenerateUserID(),
+		Username:   username,
+		Password:   string(encPass),
+		LastRoom:   room.ID,
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := user.Insert(ctx, h.db); err != nil {
+		// The unique index is the last line of defense against a register race
+		h.logger.Debug("unable to insert user", "err", err)
+		h.releaseInvite(ctx, invite)
+		return nil, &authFailure{http.StatusConflict, "that username is already taken"}
+	}
+
+	roomm := &models.RoomsMember{
+		UserID: user.ID,
+		RoomID: room.ID,
+		Role:   db.RoleMember,
+	}
+	if err := roomm.Insert(ctx, h.db); err != nil {
+		h.logger.Error("unable to add user to room", "uid", user.ID, "roomid", room.ID, "err", err)
+		return nil, err
+	}
+
+	// Any configured welcome rooms are joined on top of the default room
+	h.joinWelcomeRooms(ctx, user.ID, room.ID)
+
+	h.logger.Debug("inserted user", "username", username)
+	return user, nil
+}
+
+// releaseInvite returns a consumed invite code to the pool when registration
+// fails after the code was won, so the failure doesn't burn the invite.
+// Best-effort: a release failure is logged, not surfaced.
+func (h *ChatServer) releaseInvite(ctx context.Context, invite *models.SignupInvite) {
+	if invite == nil {
+		return
+	}
+	if err := db.ReleaseSignupInvite(ctx, h.db, invite.Code); err != nil {
+		h.logger.Error("unable to release invite", "code", invite.Code, "err", err)
+	}
+}
+
+// joinWelcomeRooms adds a user to every configured welcome room by name.
+// Joins are idempotent upserts, so running it again for an existing member
+// changes nothing, and failures are logged rather than fatal: a
+// misconfigured room name shouldn't block registration.
+func (h *ChatServer) joinWelcomeRooms(ctx context.Context, userID, defaultRoomID string) {
+	for _, name := range h.welcomeRooms {
+		room, err := models.RoomByName(ctx, h.db, name)
+		if err != nil {
+			h.logger.Warn("welcome room not found", "room", name)
+			continue
+		}
+		// The default room membership was already created
+		if room.ID == defaultRoomID {
+			continue
+		}
+		roomm := &models.RoomsMember{
+			UserID: userID,
+			RoomID: room.ID,
+			Role:   db.RoleMember,
+		}
+		if err := roomm.Upsert(ctx, h.db); err != nil {
+			h.logger.Error("unable to add user to welcome room", "uid", userID, "roomid", room.ID, "err", err)
+		}
+	}
+}
+
+// authenticate verifies credentials and creates a session, returning the
+// user and the session cookie to set. Failures are returned as *authFailure.
+func (h *ChatServer) authenticate(ctx context.Context, username, password string) (*models.User, *http.Cookie, error) {
+	if username == "" {
+		return nil, nil, &authFailure{http.StatusBadRequest, "username is required"}
+	}
+	if password == "" {
+		return nil, nil, &authFailure{http.StatusBadRequest, "password is required"}
+	}
+
+	// Don't reveal whether the username or the password was wrong. The
+	// lookup ignores case so the typed casing of a username doesn't matter.
+	user, err := db.UserByUsernameNoCase(ctx, h.db, username)
+	if err != nil {
+		h.logger.Debug("Unable to find user", "user", username)
+		return nil, nil, &authFailure{http.StatusUnauthorized, "invalid username or password"}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		h.logger.Debug("wrong password")
+		return nil, nil, &authFailure{http.StatusUnauthorized, "invalid username or password"}
+	}
+
+	h.logger.Debug("login succeeded")
+	sid := models.GenerateSessionID()
+	session := models.Session{
+		ID:        sid,
+		UserID:    user.ID,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := session.Insert(ctx, h.db); err != nil {
+		h.logger.Error("session insert error", "err", err)
+		return nil, nil, err
+	}
+
+	cookie := &http.Cookie{
+		Name:     h.sessionKey,
+		Value:    sid,
+		Expires:  time.Now().Add(24 * time.Hour),
+		HttpOnly: true, // Client-side scripts cannot access the cookie
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	}
+	return user, cookie, nil
+}
+
+// register handles the HTML registration form
+func (h *ChatServer) register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.logger.Debug("wrong method")
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	_, err := h.createAccount(r.Context(), r.FormValue("username"), r.FormValue("password"), r.FormValue("invite_code"))
+	if err != nil {
+		h.handleAuthFailure(w, r, err, "unable to create account")
+		return
+	}
+
+	// XXX: consider the user logged in, set a session, and redirect to chat?
+	// currently this makes you go back and log in after registering
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// login handles the HTML login form
+func (h *ChatServer) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.logger.Debug("wrong method")
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	user, cookie, err := h.authenticate(r.Context(), r.FormValue("username"), r.FormValue("password"))
+	if err != nil {
+		h.handleAuthFailure(w, r, err, "unable to create session")
+		return
+	}
+
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, "/chat/"+user.LastRoom, http.StatusFound)
+}
+
+// authUserResponse is the user object returned by the JSON auth endpoints
+type authUserResponse struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	CreatedAt string `json:"created_at"`
+}
+
+// authRequest is the JSON body accepted by the auth endpoints
+type authRequest struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	InviteCode string `json:"invite_code,omitempty"`
+}
+
+// registerAPI handles POST /api/v1/auth/register with a JSON body
+func (h *ChatServer) registerAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeAuthJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "POST required"})
+		return
+	}
+
+	var req authRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeAuthJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	user, err := h.createAccount(r.Context(), req.Username, req.Password, req.InviteCode)
+	if err != nil {
+		var fail *authFailure
+		if errors.As(err, &fail) {
+			h.writeAuthJSON(w, fail.status, map[string]string{"error": fail.msg})
+			return
+		}
+		h.writeAuthJSON(w, http.StatusInternalServerError, map[string]string{"error": "unable to create account"})
+		return
+	}
+
+	h.writeAuthJSON(w, http.StatusCreated, authUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+	})
+}
+
+// loginAPI handles POST /api/v1/auth/login with a JSON body. On success it
+// sets the session cookie and returns the user.
+func (h *ChatServer) loginAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeAuthJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "POST required"})
+		return
+	}
+
+	var req authRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeAuthJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	user, cookie, err := h.authenticate(r.Context(), req.Username, req.Password)
+	if err != nil {
+		var fail *authFailure
+		if errors.As(err, &fail) {
+			h.writeAuthJSON(w, fail.status, map[string]string{"error": fail.msg})
+			return
+		}
+		h.writeAuthJSON(w, http.StatusInternalServerError, map[string]string{"error": "unable to create session"})
+		return
+	}
+
+	http.SetCookie(w, cookie)
+	h.writeAuthJSON(w, http.StatusOK, authUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+	})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultRetryAfterSeconds is the reconnect backoff hint attached to
+// overload and maintenance close frames when RETRY_AFTER_SECONDS is unset
+const defaultRetryAfterSeconds = 30
+
+// retryAfterFromEnv reads the reconnect backoff hint, in seconds, from the
+// RETRY_AFTER_SECONDS environment variable. The value rides in the close
+// frame when the server sheds a connection, so clients wait instead of
+// stampeding the server with immediate reconnects. Invalid values fall
+// back to the default.
+func retryAfterFromEnv(logger *slog.Logger) int {
+	val := os.Getenv("RETRY_AFTER_SECONDS")
+	if val == "" {
+		return defaultRetryAfterSeconds
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		logger.Warn("ignoring invalid retry-after", "name", "RETRY_AFTER_SECONDS", "value", val)
+		return defaultRetryAfterSeconds
+	}
+	return n
+}
+
+// backoffClosePayload is the structured reason carried in a shedding close
+// frame. It has to stay terse: a close payload is capped at 123 bytes
+// after the status code.
+type backoffClosePayload struct {
+	Reason     string `json:"reason"`
+	RetryAfter int    `json:"retry_after"` // seconds to wait before reconnecting
+}
+
+// backoffCloseMessage formats a close frame whose payload tells the client
+// why it was disconnected and how many seconds to wait before reconnecting
+func backoffCloseMessage(code int, reason string, retryAfter int) []byte {
+	payload, err := json.Marshal(backoffClosePayload{Reason: reason, RetryAfter: retryAfter})
+	if err != nil {
+		return websocket.FormatCloseMessage(code, reason)
+	}
+	return websocket.FormatCloseMessage(code, string(payload))
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// readCloseError reads from clientConn until the peer's close frame
+// arrives and returns it
+func readCloseError(t *testing.T, clientConn *websocket.Conn) *websocket.CloseError {
+	t.Helper()
+	_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := clientConn.ReadMessage(); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				t.Fatalf("Expected a close error, got %v", err)
+			}
+			return closeErr
+		}
+	}
+}
+
+// TestOverloadCloseCarriesRetryAfter tests that a connection dropped for a
+// full send buffer gets a close frame with a retry_after backoff hint
+func TestOverloadCloseCarriesRetryAfter(t *testing.T) {
+	clientConn, serverConn := wsPair(t)
+
+	c := &Client{
+		hub:    &Hub{retryAfter: 7},
+		conn:   serverConn,
+		send:   make(chan []byte),
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		user:   &models.User{ID: "usr_backoff00001"},
+	}
+
+	// Simulate the run loop shedding the connection: it marks the client
+	// overloaded and closes the send channel, and writePump turns that
+	// into the close frame
+	c.overloaded.Store(true)
+	close(c.send)
+	go c.writePump()
+
+	closeErr := readCloseError(t, clientConn)
+	if closeErr.Code != closeOverloaded {
+		t.Errorf("Expected close code %d, got %d", closeOverloaded, closeErr.Code)
+	}
+	var payload backoffClosePayload
+	if err := json.Unmarshal([]byte(closeErr.Text), &payload); err != nil {
+		t.Fatalf("Failed to unmarshal close payload %q: %v", closeErr.Text, err)
+	}
+	if payload.Reason != "overloaded" {
+		t.Errorf("Expected reason 'overloaded', got %q", payload.Reason)
+	}
+	if payload.RetryAfter != 7 {
+		t.Errorf("Expected retry_after 7, got %d", payload.RetryAfter)
+	}
+}
+
+// TestMaintenanceCloseCarriesRetryAfter tests that draining the hub for
+// maintenance closes each connection with a retry_after backoff hint
+func TestMaintenanceCloseCarriesRetryAfter(t *testing.T) {
+	clientConn, serverConn := wsPair(t)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := newHub(nil, logger)
+	hub.retryAfter = 12
+	go hub.run()
+
+	c := &Client{
+		hub:    hub,
+		conn:   serverConn,
+		send:   make(chan []byte, 8),
+		logger: logger,
+		user:   &models.User{ID: "usr_backoff00002"},
+	}
+	hub.register <- c
+
+	hub.disconnectAll(backoffCloseMessage(closeMaintenance, "maintenance", hub.retryAfter))
+
+	closeErr := readCloseError(t, clientConn)
+	if closeErr.Code != closeMaintenance {
+		t.Errorf("Expected close code %d, got %d", closeMaintenance, closeErr.Code)
+	}
+	var payload backoffClosePayload
+	if err := json.Unmarshal([]byte(closeErr.Text), &payload); err != nil {
+		t.Fatalf("Failed to unmarshal close payload %q: %v", closeErr.Text, err)
+	}
+	if payload.Reason != "maintenance" {
+		t.Errorf("Expected reason 'maintenance', got %q", payload.Reason)
+	}
+	if payload.RetryAfter != 12 {
+		t.Errorf("Expected retry_after 12, got %d", payload.RetryAfter)
+	}
+}
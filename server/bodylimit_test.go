@@ -0,0 +1,56 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/middleware"
+)
+
+// TestBodyLimitRejectsOversizedJSON tests that a JSON request body larger
+// than the configured limit is rejected with a 413
+func TestBodyLimitRejectsOversizedJSON(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	// Pad the password field until the body exceeds the default limit
+	padding := bytes.Repeat([]byte("x"), middleware.DefaultMaxBodyBytes+1)
+	body := fmt.Sprintf(`{"username": "bigbody", "password": %q}`, padding)
+
+	resp, err := http.Post(ts.server.URL+"/api/v1/auth/register", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to post oversized body: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for oversized body, got %d", resp.StatusCode)
+	}
+}
+
+// TestBodyLimitAllowsNormalJSON tests that an ordinary-sized JSON body
+// passes through the limit and is handled normally
+func TestBodyLimitAllowsNormalJSON(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	body := `{"username": "normalbody", "password": "password123"}`
+	resp, err := http.Post(ts.server.URL+"/api/v1/auth/register", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to post body: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected 201 for normal body, got %d", resp.StatusCode)
+	}
+}
@@ -1,15 +1,23 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/llimllib/hatchat/server/api"
 	"github.com/llimllib/hatchat/server/middleware"
 	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
 )
 
 const (
@@ -24,13 +32,38 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 16384
+
+	// Hard ceiling on inbound frames. Frames between maxMessageSize and
+	// maxFrameSize get an error envelope and the connection stays open;
+	// anything larger trips gorilla's read limit, which poisons the read
+	// side, so the connection is torn down.
+	maxFrameSize = 4 * maxMessageSize
 )
 
 var newline = []byte{'\n'}
 
+// closeUnsupportedVersion is the close code sent when the client requests
+// only protocol versions the server doesn't speak
+const closeUnsupportedVersion = 4000
+
+// closeIdleTimeout is the close code sent when a connection goes longer
+// than the hub's idle timeout without an application message
+const closeIdleTimeout = 4001
+
+// closeOverloaded is the close code sent when a connection is dropped
+// because its send buffer overflowed; the payload carries a retry_after
+// hint so reconnects don't stampede an already-loaded server
+const closeOverloaded = 4002
+
+// closeMaintenance is the close code sent when an operator disconnects
+// all clients while enabling maintenance mode; the payload carries a
+// retry_after hint
+const closeMaintenance = 4003
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    protocol.SupportedSubprotocols,
 }
 
 // Client is a middleman between the websocket connection and the hub.
@@ -49,25 +82,86 @@ type Client struct {
 	// client to say who they are
 	user *models.User
 
-	// The room the client is currently viewing. Messages will only be sent to
-	// clients viewing the same room.
+	// The room the client is currently viewing, which drives read-receipt
+	// semantics. Live event routing is driven by subscriptions, not by
+	// this field.
 	currentRoom string
 
+	// The rooms this connection receives live events for, typically all of
+	// the user's memberships. Owned by the hub's run loop once the client
+	// is registered; the read goroutine changes it through the hub's
+	// subscribe/resubscribe channels.
+	subscriptions map[string]bool
+
+	// The subprotocol negotiated during the websocket upgrade; empty when the
+	// client did not request one
+	subprotocol string
+
+	// The remote IP the connection arrived from, used to release its
+	// connection-limit slot on disconnect
+	ip string
+
+	// Validates inbound payloads against the generated protocol schema;
+	// nil when strict validation is disabled
+	validator *schemaValidator
+
+	// The unix-nanosecond wall time of the most recently sent ping; zero
+	// before the first ping. Written by writePump, read by the pong
+	// handler on the read goroutine.
+	pingSentAt atomic.Int64
+
+	// The most recently measured ping/pong round trip, in nanoseconds
+	latencyNanos atomic.Int64
+
+	// The unix-nanosecond wall time of the most recent inbound application
+	// message; pongs don't count. Written by readPump, read by writePump's
+	// idle-timeout check.
+	lastActivity atomic.Int64
+
+	// Set by the hub's run loop when it drops the connection because the
+	// send buffer overflowed, so writePump can tell an overload close from
+	// a normal unregister and attach a backoff hint.
+	overloaded atomic.Bool
+
+	// Cancels the connection's in-flight streaming search, if any. Owned
+	// by the read goroutine.
+	searchCancel context.CancelFunc
+
 	api *api.Api
 }
 
-// TODO: handle panics gracefully; rn a panic in here kills the whole app
+// must panics on error; recoverPump catches these panics in the pump
+// goroutines and turns them into a connection teardown
 func must(e error) {
 	if e != nil {
 		panic(e)
 	}
 }
 
-func mustV[T any](value T, err error) T {
-	if err != nil {
-		panic(err)
+// recoverPump recovers a panic escaping one of the connection's pump
+// goroutines — including the api dispatch, which runs on the read
+// goroutine — and closes the connection so the other pump and the hub
+// clean the client up. One misbehaving connection must not take down the
+// process; this is the websocket counterpart of
+// middleware.RecoverMiddleware.
+func (c *Client) recoverPump(pump string) {
+	if err := recover(); err != nil {
+		c.logger.Error("panic in connection handler", "pump", pump, "err", err, "user", c.user.ID)
+		_ = c.conn.Close()
+	}
+}
+
+// roomIDs flattens channel and DM room lists into the IDs a connection
+// should subscribe to
+func roomIDs(rooms, dms []*protocol.Room) []string {
+	ids := make([]string, 0, len(rooms)+len(dms))
+	for _, r := range rooms {
+		ids = append(ids, r.ID)
+	}
+	for _, r := range dms {
+		ids = append(ids, r.ID)
 	}
-	return value
+	return ids
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -76,24 +170,45 @@ func mustV[T any](value T, err error) T {
 // ensures that there is at most one reader on a connection by executing all
 // reads from this goroutine.
 func (c *Client) readPump() {
+	defer c.recoverPump("readPump")
 	defer func() {
+		if c.searchCancel != nil {
+			c.searchCancel()
+		}
 		c.hub.unregister <- c
 		_ = c.conn.Close()
 	}()
-	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadLimit(maxFrameSize)
+	// Connecting counts as application activity for the idle timeout
+	c.lastActivity.Store(time.Now().UnixNano())
 	must(c.conn.SetReadDeadline(time.Now().Add(pongWait)))
 	c.conn.SetPongHandler(func(string) error {
+		c.recordPong()
 		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	})
 	for {
 		_, message, err := c.conn.ReadMessage()
+		if err == nil {
+			c.lastActivity.Store(time.Now().UnixNano())
+		}
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				c.logger.Warn("client exceeded the frame size limit", "user", c.user.ID, "limit", maxFrameSize)
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				c.logger.Error("", "err", err)
 			}
 			break
 		}
 
+		// A single oversized paste shouldn't kill the session: reject the
+		// message but keep the connection alive
+		if len(message) > maxMessageSize {
+			c.logger.Warn("rejecting oversized message", "user", c.user.ID, "size", len(message), "limit", maxMessageSize)
+			must(c.conn.WriteJSON(c.api.ErrorMessage(api.ErrValidation,
+				fmt.Sprintf("message exceeds the %d byte limit", maxMessageSize))))
+			continue
+		}
+
 		t := time.Now()
 
 		var msg json.RawMessage
@@ -103,6 +218,25 @@ func (c *Client) readPump() {
 			return
 		}
 
+		// In strict mode, reject payloads that don't match the generated
+		// schema for their type before they reach a handler
+		if problems := c.validator.validate(env.Type, msg); len(problems) > 0 {
+			c.logger.Warn("message failed schema validation", "user", c.user.ID, "type", env.Type, "problems", problems)
+			must(c.conn.WriteJSON(&api.Envelope{
+				Type: "error",
+				Data: &protocol.ErrorResponse{
+					Code:    protocol.ErrCodeValidation,
+					Message: fmt.Sprintf("invalid %s message", env.Type),
+					Fields:  problems,
+				},
+			}))
+			continue
+		}
+
+		// Any application message counts as activity for the last-seen
+		// timestamp; the call throttles its own writes
+		c.api.TouchUserActivity(c.user)
+
 		switch env.Type {
 		case "init":
 			res, err := c.api.InitMessage(c.user, msg)
@@ -111,19 +245,38 @@ func (c *Client) readPump() {
 				return
 			}
 
-			// Set the client's current room for message routing
+			// Set the client's current room for read receipts
 			c.currentRoom = res.CurrentRoom
 
+			// Stamp the negotiated protocol version; it's a transport
+			// concern, so the api layer doesn't know it
+			if init, ok := res.Envelope.Data.(protocol.InitResponse); ok {
+				init.ProtocolVersion = c.subprotocol
+				res.Envelope.Data = init
+
+				// Subscribe to live events for every room the user
+				// belongs to, not just the one they're viewing
+				c.hub.setSubscriptions(c, roomIDs(init.Rooms, init.DMs))
+			}
+
 			err = c.conn.WriteJSON(res.Envelope)
 			if err != nil {
 				c.logger.Error("failed to write init json", "error", err)
 				return
 			}
+		case "ping":
+			res, err := c.api.Ping(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle ping", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to handle ping")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
 		case "history":
 			res, err := c.api.HistoryMessage(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle history request", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to fetch history")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to fetch history")))
 			} else {
 				err = c.conn.WriteJSON(res)
 				if err != nil {
@@ -131,13 +284,243 @@ func (c *Client) readPump() {
 					return
 				}
 			}
+		case "forward_message":
+			res, err := c.api.ForwardMessage(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle forward_message", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to forward message")))
+			} else {
+				// Broadcast into the target room without switching the
+				// forwarder's current room
+				c.hub.broadcast <- RoomMessage{
+					RoomID:  res.RoomID,
+					Message: res.Message,
+				}
+			}
+		case "list_reactors":
+			res, err := c.api.ListReactors(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle list_reactors", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to list reactors")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write list_reactors json", "error", err)
+					return
+				}
+			}
+		case "mark_read":
+			if err := c.api.MarkRead(c.user, msg); err != nil {
+				c.logger.Error("failed to handle mark_read", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to mark read")))
+			}
+		case "star_message":
+			if err := c.api.StarMessage(c.user, msg); err != nil {
+				c.logger.Error("failed to handle star_message", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to star message")))
+			}
+		case "unstar_message":
+			if err := c.api.UnstarMessage(c.user, msg); err != nil {
+				c.logger.Error("failed to handle unstar_message", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to unstar message")))
+			}
+		case "sync":
+			res, err := c.api.Sync(c.user, msg, c.hub.onlineUserIDs())
+			if err != nil {
+				c.logger.Error("failed to handle sync", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to sync")))
+			} else {
+				// Like init, sync establishes the client's current room
+				// and refreshes its membership subscriptions
+				c.currentRoom = res.CurrentRoom
+				if sync, ok := res.Envelope.Data.(protocol.SyncResponse); ok {
+					c.hub.setSubscriptions(c, roomIDs(sync.Rooms, sync.DMs))
+				}
+				err = c.conn.WriteJSON(res.Envelope)
+				if err != nil {
+					c.logger.Error("failed to write sync json", "error", err)
+					return
+				}
+			}
+		case "get_unread":
+			res, err := c.api.GetUnread(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle get_unread", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to get unread counts")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "get_prefs":
+			res, err := c.api.GetPrefs(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle get_prefs", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to get preferences")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "set_prefs":
+			res, err := c.api.SetPrefs(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle set_prefs", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to set preferences")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "get_messages":
+			res, err := c.api.GetMessages(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle get_messages", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to get messages")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "pin_message":
+			res, err := c.api.PinMessage(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle pin_message", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to pin message")))
+			} else {
+				for _, m := range res.Messages {
+					c.hub.broadcast <- RoomMessage{
+						RoomID:  res.RoomID,
+						Message: m,
+					}
+				}
+			}
+		case "set_pin_announcements":
+			res, err := c.api.SetPinAnnouncements(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle set_pin_announcements", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to set pin announcements")))
+			} else {
+				c.hub.broadcast <- RoomMessage{
+					RoomID:  res.RoomID,
+					Message: res.Message,
+				}
+			}
+		case "list_starred":
+			res, err := c.api.ListStarred(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle list_starred", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to list starred messages")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write list_starred json", "error", err)
+					return
+				}
+			}
+		case "favorite_room":
+			res, err := c.api.FavoriteRoom(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle favorite_room", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to update favorites")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "reorder_favorites":
+			res, err := c.api.ReorderFavorites(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle reorder_favorites", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to reorder favorites")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "get_reactions":
+			res, err := c.api.GetReactions(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle get_reactions", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to get reactions")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "hide_dm":
+			res, err := c.api.HideDM(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle hide_dm", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to hide DM")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "set_dm_label":
+			res, err := c.api.SetDMLabel(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle set_dm_label", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to set DM label")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "report_message":
+			res, err := c.api.ReportMessage(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle report_message", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to report message")))
+			} else {
+				// Tell the room's moderators when a new report was filed
+				for _, notice := range res.Notices {
+					c.hub.sendToUser(notice.UserID, notice.Message)
+				}
+				must(c.conn.WriteJSON(res.Envelope))
+			}
+		case "list_reports":
+			res, err := c.api.ListReports(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle list_reports", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to list reports")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "list_members":
+			res, err := c.api.ListMembers(c.user, msg, c.hub.onlineUserIDs())
+			if err != nil {
+				c.logger.Error("failed to handle list_members", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to list members")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "resolve_report":
+			res, err := c.api.ResolveReport(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle resolve_report", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to resolve report")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "my_membership":
+			res, err := c.api.MyMembership(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle my_membership", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to check membership")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
 		case "message":
+			// The room's aggregate rate cap runs before the handler so a
+			// flooded room sheds load without a database write per
+			// rejected message
+			var peek struct {
+				RoomID string `json:"room_id"`
+			}
+			if err := json.Unmarshal(msg, &peek); err == nil && peek.RoomID != "" {
+				if !c.hub.allowRoomMessage(peek.RoomID, c.user.ID) {
+					must(c.conn.WriteJSON(c.api.ErrorMessage(api.ErrRateLimited, "this room is receiving messages too quickly; try again in a moment")))
+					break
+				}
+			}
 			res, err := c.api.MessageMessage(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle message", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to handle message")))
+				// Slow-mode rejections carry the remaining wait, which is
+				// safe (and useful) to show to the user
+				var slowErr *api.SlowModeError
+				if errors.As(err, &slowErr) {
+					must(c.conn.WriteJSON(c.api.ErrorMessage(err, slowErr.Error())))
+				} else {
+					must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to handle message")))
+				}
 			} else {
-				// Update the client's current room and broadcast to room members only
+				// Update the client's current room and broadcast to the
+				// room's subscribers
 				c.currentRoom = res.RoomID
 				c.hub.broadcast <- RoomMessage{
 					RoomID:  res.RoomID,
@@ -148,10 +531,20 @@ func (c *Client) readPump() {
 			res, err := c.api.JoinRoom(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle join_room", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to join room")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to join room")))
 			} else {
-				// Update the client's current room
-				c.currentRoom = res.RoomID
+				// Tell the room's admins when a join request was filed;
+				// the requester isn't a member yet so their current room
+				// doesn't change
+				for _, notice := range res.AdminNotices {
+					c.hub.sendToUser(notice.UserID, notice.Message)
+				}
+				if !res.Pending {
+					// Update the client's current room and start
+					// receiving the new room's live events
+					c.currentRoom = res.RoomID
+					c.hub.subscribeRoom(c, res.RoomID)
+				}
 				err = c.conn.WriteJSON(res.Envelope)
 				if err != nil {
 					c.logger.Error("failed to write join_room json", "error", err)
@@ -162,21 +555,30 @@ func (c *Client) readPump() {
 			res, err := c.api.CreateRoom(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle create_room", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to create room")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to create room")))
 			} else {
 				// Update the client's current room to the new room
 				c.currentRoom = res.RoomID
+				c.hub.subscribeRoom(c, res.RoomID)
 				err = c.conn.WriteJSON(res.Envelope)
 				if err != nil {
 					c.logger.Error("failed to write create_room json", "error", err)
 					return
 				}
+				// Deliver the welcome system message, if the instance
+				// posts one
+				if res.Broadcast != nil {
+					c.hub.broadcast <- RoomMessage{
+						RoomID:  res.RoomID,
+						Message: res.Broadcast,
+					}
+				}
 			}
 		case "list_rooms":
 			res, err := c.api.ListRooms(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle list_rooms", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to list rooms")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to list rooms")))
 			} else {
 				err = c.conn.WriteJSON(res)
 				if err != nil {
@@ -188,8 +590,13 @@ func (c *Client) readPump() {
 			res, err := c.api.LeaveRoom(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle leave_room", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to leave room")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to leave room")))
 			} else {
+				// Stop receiving the room's live events once the leave
+				// succeeds; failures come back as error envelopes
+				if left, ok := res.Data.(protocol.LeaveRoomResponse); ok {
+					c.hub.unsubscribeRoom(c, left.RoomID)
+				}
 				err = c.conn.WriteJSON(res)
 				if err != nil {
 					c.logger.Error("failed to write leave_room json", "error", err)
@@ -200,7 +607,7 @@ func (c *Client) readPump() {
 			res, err := c.api.RoomInfo(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle room_info", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to get room info")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to get room info")))
 			} else {
 				err = c.conn.WriteJSON(res)
 				if err != nil {
@@ -212,21 +619,87 @@ func (c *Client) readPump() {
 			res, err := c.api.CreateDM(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle create_dm", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to create DM")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to create DM")))
 			} else {
 				// Update the client's current room to the DM
 				c.currentRoom = res.RoomID
+				c.hub.subscribeRoom(c, res.RoomID)
 				err = c.conn.WriteJSON(res.Envelope)
 				if err != nil {
 					c.logger.Error("failed to write create_dm json", "error", err)
 					return
 				}
 			}
+		case "add_members":
+			res, err := c.api.AddMembers(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle add_members", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to add members")))
+			} else {
+				// Announce each new member to the room, and directly to
+				// the new member themself since they aren't viewing the
+				// room yet
+				for _, added := range res.Added {
+					c.hub.broadcast <- RoomMessage{
+						RoomID:  res.RoomID,
+						Message: added.Message,
+					}
+					c.hub.sendToUser(added.UserID, added.Message)
+				}
+				err = c.conn.WriteJSON(res.Envelope)
+				if err != nil {
+					c.logger.Error("failed to write add_members json", "error", err)
+					return
+				}
+			}
+		case "approve_join_request":
+			res, err := c.api.ApproveJoinRequest(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle approve_join_request", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to approve join request")))
+			} else {
+				// Announce the new member to the room, and directly to
+				// them since they aren't viewing the room yet
+				c.hub.broadcast <- RoomMessage{
+					RoomID:  res.RoomID,
+					Message: res.Added.Message,
+				}
+				c.hub.sendToUser(res.Added.UserID, res.Added.Message)
+				err = c.conn.WriteJSON(res.Envelope)
+				if err != nil {
+					c.logger.Error("failed to write approve_join_request json", "error", err)
+					return
+				}
+			}
+		case "deny_join_request":
+			res, err := c.api.DenyJoinRequest(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle deny_join_request", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to deny join request")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write deny_join_request json", "error", err)
+					return
+				}
+			}
+		case "list_join_requests":
+			res, err := c.api.ListJoinRequests(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle list_join_requests", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to list join requests")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write list_join_requests json", "error", err)
+					return
+				}
+			}
 		case "list_users":
 			res, err := c.api.ListUsers(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle list_users", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to list users")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to list users")))
 			} else {
 				err = c.conn.WriteJSON(res)
 				if err != nil {
@@ -234,11 +707,23 @@ func (c *Client) readPump() {
 					return
 				}
 			}
+		case "autocomplete":
+			res, err := c.api.Autocomplete(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle autocomplete", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to autocomplete")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write autocomplete json", "error", err)
+					return
+				}
+			}
 		case "get_profile":
 			res, err := c.api.GetProfile(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle get_profile", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to get profile")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to get profile")))
 			} else {
 				err = c.conn.WriteJSON(res)
 				if err != nil {
@@ -246,11 +731,23 @@ func (c *Client) readPump() {
 					return
 				}
 			}
+		case "get_profiles":
+			res, err := c.api.GetProfiles(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle get_profiles", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to get profiles")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write get_profiles json", "error", err)
+					return
+				}
+			}
 		case "update_profile":
 			res, err := c.api.UpdateProfile(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle update_profile", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to update profile")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to update profile")))
 			} else {
 				err = c.conn.WriteJSON(res)
 				if err != nil {
@@ -258,63 +755,182 @@ func (c *Client) readPump() {
 					return
 				}
 			}
+		case "set_username":
+			res, err := c.api.SetUsername(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle set_username", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to set username")))
+			} else {
+				// Peers re-render author labels from the live user record
+				if res.Broadcast != nil {
+					c.hub.broadcastToUserPeers(c.user.ID, res.Broadcast)
+				}
+				must(c.conn.WriteJSON(res.Envelope))
+			}
 		case "edit_message":
 			res, err := c.api.EditMessage(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle edit_message", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to edit message")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to edit message")))
+			} else {
+				// Edits reach every member's connections, not just tabs
+				// currently viewing the room
+				c.hub.broadcastToRoomMembers(res.RoomID, res.Message)
+			}
+		case "delete_message":
+			res, err := c.api.DeleteMessage(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle delete_message", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to delete message")))
+			} else {
+				c.hub.broadcastToRoomMembers(res.RoomID, res.Message)
+			}
+		case "add_reaction":
+			res, err := c.api.AddReaction(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle add_reaction", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to add reaction")))
+			} else {
+				c.hub.broadcastToRoomMembers(res.RoomID, res.Message)
+			}
+		case "remove_reaction":
+			res, err := c.api.RemoveReaction(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle remove_reaction", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to remove reaction")))
+			} else {
+				c.hub.broadcastToRoomMembers(res.RoomID, res.Message)
+			}
+		case "set_slow_mode":
+			res, err := c.api.SetSlowMode(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle set_slow_mode", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to set slow mode")))
 			} else {
 				c.hub.broadcast <- RoomMessage{
 					RoomID:  res.RoomID,
 					Message: res.Message,
 				}
 			}
-		case "delete_message":
-			res, err := c.api.DeleteMessage(c.user, msg)
+		case "set_retention":
+			res, err := c.api.SetRetention(c.user, msg)
 			if err != nil {
-				c.logger.Error("failed to handle delete_message", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to delete message")))
+				c.logger.Error("failed to handle set_retention", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to set retention")))
 			} else {
 				c.hub.broadcast <- RoomMessage{
 					RoomID:  res.RoomID,
 					Message: res.Message,
 				}
 			}
-		case "add_reaction":
-			res, err := c.api.AddReaction(c.user, msg)
+		case "set_post_policy":
+			res, err := c.api.SetPostPolicy(c.user, msg)
 			if err != nil {
-				c.logger.Error("failed to handle add_reaction", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to add reaction")))
+				c.logger.Error("failed to handle set_post_policy", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to set post policy")))
 			} else {
 				c.hub.broadcast <- RoomMessage{
 					RoomID:  res.RoomID,
 					Message: res.Message,
 				}
 			}
-		case "remove_reaction":
-			res, err := c.api.RemoveReaction(c.user, msg)
+		case "set_room_topic":
+			res, err := c.api.SetRoomTopic(c.user, msg)
 			if err != nil {
-				c.logger.Error("failed to handle remove_reaction", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to remove reaction")))
+				c.logger.Error("failed to handle set_room_topic", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to set room topic")))
 			} else {
 				c.hub.broadcast <- RoomMessage{
 					RoomID:  res.RoomID,
 					Message: res.Message,
 				}
 			}
+		case "add_room_filter":
+			res, err := c.api.AddRoomFilter(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle add_room_filter", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to add room filter")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write add_room_filter json", "error", err)
+					return
+				}
+			}
+		case "remove_room_filter":
+			res, err := c.api.RemoveRoomFilter(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle remove_room_filter", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to remove room filter")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write remove_room_filter json", "error", err)
+					return
+				}
+			}
+		case "list_room_filters":
+			res, err := c.api.ListRoomFilters(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle list_room_filters", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to list room filters")))
+			} else {
+				err = c.conn.WriteJSON(res)
+				if err != nil {
+					c.logger.Error("failed to write list_room_filters json", "error", err)
+					return
+				}
+			}
 		case "search":
 			res, err := c.api.Search(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle search", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("search failed")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "search failed")))
 			} else {
 				must(c.conn.WriteJSON(res))
 			}
+		case "search_stream":
+			// Only one stream per connection; a new request replaces any
+			// stream still running
+			if c.searchCancel != nil {
+				c.searchCancel()
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			c.searchCancel = cancel
+			// The stream pages the FTS cursor in its own goroutine so the
+			// read loop stays free to process a cancellation; batches are
+			// delivered through the hub, which owns the send channel
+			go func() {
+				defer cancel()
+				err := c.api.SearchStream(ctx, c.user, msg, func(env *api.Envelope) error {
+					data, err := json.Marshal(env)
+					if err != nil {
+						return err
+					}
+					return c.hub.sendToClient(ctx, c, data)
+				})
+				if err != nil && !errors.Is(err, context.Canceled) {
+					c.logger.Error("failed to handle search_stream", "error", err, "msg", msg)
+				}
+			}()
+		case "search_stream_cancel":
+			if c.searchCancel != nil {
+				c.searchCancel()
+				c.searchCancel = nil
+			}
 		case "get_message_context":
 			res, err := c.api.GetMessageContext(c.user, msg)
 			if err != nil {
 				c.logger.Error("failed to handle get_message_context", "error", err, "msg", msg)
-				must(c.conn.WriteJSON(c.api.ErrorMessage("failed to get message context")))
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to get message context")))
+			} else {
+				must(c.conn.WriteJSON(res))
+			}
+		case "catch_up":
+			res, err := c.api.CatchUp(c.user, msg)
+			if err != nil {
+				c.logger.Error("failed to handle catch_up", "error", err, "msg", msg)
+				must(c.conn.WriteJSON(c.api.ErrorMessage(err, "failed to catch up")))
 			} else {
 				must(c.conn.WriteJSON(res))
 			}
@@ -330,7 +946,15 @@ func (c *Client) readPump() {
 // application ensures that there is at most one writer to a connection by
 // executing all writes from this goroutine.
 func (c *Client) writePump() {
-	ticker := time.NewTicker(pingPeriod)
+	defer c.recoverPump("writePump")
+	// Check idleness at least twice per window so timeouts shorter than
+	// the ping period don't wait out a full one; pinging faster than
+	// usual as a side effect is harmless
+	period := pingPeriod
+	if c.hub.idleTimeout > 0 && c.hub.idleTimeout/2 < period {
+		period = c.hub.idleTimeout / 2
+	}
+	ticker := time.NewTicker(period)
 	defer func() {
 		ticker.Stop()
 		_ = c.conn.Close()
@@ -338,10 +962,19 @@ func (c *Client) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			must(c.conn.SetWriteDeadline(time.Now().Add(writeWait)))
+			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+				c.logger.Debug("failed to set write deadline", "error", err)
+				return
+			}
 			if !ok {
-				// The hub closed the channel.
-				if err := c.conn.WriteMessage(websocket.CloseMessage, []byte{}); err != nil {
+				// The hub closed the channel. If it did so because this
+				// connection couldn't keep up, tell the client how long to
+				// back off before reconnecting.
+				msg := []byte{}
+				if c.overloaded.Load() {
+					msg = backoffCloseMessage(closeOverloaded, "overloaded", c.hub.retryAfter)
+				}
+				if err := c.conn.WriteMessage(websocket.CloseMessage, msg); err != nil {
 					c.logger.Debug("Unable to send close message. Is this WriteMessage call necessary?", "err", err)
 				}
 				return
@@ -351,20 +984,27 @@ func (c *Client) writePump() {
 			if err != nil {
 				return
 			}
-			mustV(w.Write(message))
-
-			// Add queued chat messages to the current websocket message.
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				mustV(w.Write(newline))
-				mustV(w.Write(<-c.send))
-			}
-
-			if err := w.Close(); err != nil {
+			if err := c.writeBatch(w, message); err != nil {
+				// Returning closes the connection, which makes readPump
+				// exit and unregister the client; other connections are
+				// unaffected
+				c.logger.Debug("write failed, closing connection", "error", err, "user", c.user.ID)
 				return
 			}
 		case <-ticker.C:
-			must(c.conn.SetWriteDeadline(time.Now().Add(writeWait)))
+			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+				c.logger.Debug("failed to set write deadline", "error", err)
+				return
+			}
+			// The ping/pong below only proves the TCP path is alive; this
+			// closes connections that are healthy but application-idle
+			if c.idleExceeded() {
+				c.logger.Info("closing idle connection", "user", c.user.ID, "timeout", c.hub.idleTimeout)
+				msg := websocket.FormatCloseMessage(closeIdleTimeout, "idle timeout")
+				_ = c.conn.WriteMessage(websocket.CloseMessage, msg)
+				return
+			}
+			c.pingSentAt.Store(time.Now().UnixNano())
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -372,32 +1012,122 @@ func (c *Client) writePump() {
 	}
 }
 
+// writeBatch writes message and any currently queued messages to w as a
+// single newline-delimited websocket frame, then closes the writer. On a
+// write error the writer is still closed and the rest of the queue is
+// abandoned; the caller tears the connection down, so later messages
+// couldn't be delivered anyway.
+func (c *Client) writeBatch(w io.WriteCloser, message []byte) error {
+	if _, err := w.Write(message); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	// Add queued chat messages to the current websocket message.
+	n := len(c.send)
+	for i := 0; i < n; i++ {
+		queued := <-c.send
+		if _, err := w.Write(newline); err != nil {
+			_ = w.Close()
+			return err
+		}
+		if _, err := w.Write(queued); err != nil {
+			_ = w.Close()
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// idleExceeded reports whether the connection has gone longer than the
+// hub's idle timeout without an inbound application message. Always false
+// when the timeout is disabled or the read pump hasn't started yet.
+func (c *Client) idleExceeded() bool {
+	if c.hub.idleTimeout <= 0 {
+		return false
+	}
+	last := c.lastActivity.Load()
+	if last == 0 {
+		return false
+	}
+	return time.Since(time.Unix(0, last)) > c.hub.idleTimeout
+}
+
 // serveWs handles websocket requests from the peer.
-func serveWs(hub *Hub, api *api.Api, w http.ResponseWriter, r *http.Request) {
+func serveWs(hub *Hub, api *api.Api, validator *schemaValidator, w http.ResponseWriter, r *http.Request) {
 	userid := middleware.GetUserID(r.Context())
 	user, err := models.UserByID(r.Context(), hub.db, userid)
 	if err != nil {
 		hub.logger.Error("Unable to find user", "userid", userid)
 	}
 
+	ip := clientIP(r)
+	if !hub.limiter.acquire(user.ID, ip) {
+		hub.logger.Warn("connection limit exceeded", "userid", userid, "ip", ip)
+		// The standard header carries the same backoff hint the close
+		// frames do, since this rejection happens before the upgrade
+		w.Header().Set("Retry-After", strconv.Itoa(hub.retryAfter))
+		http.Error(w, "Too many connections", http.StatusTooManyRequests)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		hub.logger.Error("Unable to upgrade connection", "err", err)
+		hub.limiter.release(user.ID, ip)
+		return
+	}
+
+	if rejectUnsupportedSubprotocol(hub.logger, conn, r) {
+		hub.limiter.release(user.ID, ip)
 		return
 	}
 
 	client := &Client{
-		hub:    hub,
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		logger: hub.logger,
-		user:   user,
-		api:    api,
+		hub:           hub,
+		conn:          conn,
+		send:          make(chan []byte, 256),
+		logger:        hub.logger,
+		user:          user,
+		subprotocol:   conn.Subprotocol(),
+		ip:            ip,
+		api:           api,
+		validator:     validator,
+		subscriptions: make(map[string]bool),
 	}
 	client.hub.register <- client
 
+	// Connecting counts as activity for the last-seen timestamp
+	api.TouchUserActivity(user)
+
 	// Allow collection of memory referenced by the caller by doing all work in
 	// new goroutines.
 	go client.writePump()
 	go client.readPump()
 }
+
+// clientIP extracts the remote IP from a request, without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rejectUnsupportedSubprotocol closes conn when the client advertised
+// protocol versions via Sec-WebSocket-Protocol and the server supports none
+// of them; the handshake then completed without a subprotocol. Clients that
+// send no subprotocol at all are accepted as-is. Reports whether the
+// connection was rejected.
+func rejectUnsupportedSubprotocol(logger *slog.Logger, conn *websocket.Conn, r *http.Request) bool {
+	if len(websocket.Subprotocols(r)) == 0 || conn.Subprotocol() != "" {
+		return false
+	}
+	logger.Warn("unsupported websocket protocol version", "requested", websocket.Subprotocols(r))
+	msg := websocket.FormatCloseMessage(closeUnsupportedVersion, "unsupported protocol version")
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+	_ = conn.Close()
+	return true
+}
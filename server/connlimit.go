@@ -0,0 +1,86 @@
+package server
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Default WebSocket connection caps. A user legitimately runs a handful of
+// tabs and devices; an IP may front a small office NAT, so it gets more room.
+const (
+	defaultMaxConnsPerUser = 5
+	defaultMaxConnsPerIP   = 20
+)
+
+// connLimiter caps concurrent WebSocket connections per user and per IP so a
+// single client can't exhaust the hub
+type connLimiter struct {
+	mu         sync.Mutex
+	maxPerUser int
+	maxPerIP   int
+	users      map[string]int
+	ips        map[string]int
+}
+
+func newConnLimiter(maxPerUser, maxPerIP int) *connLimiter {
+	return &connLimiter{
+		maxPerUser: maxPerUser,
+		maxPerIP:   maxPerIP,
+		users:      make(map[string]int),
+		ips:        make(map[string]int),
+	}
+}
+
+// newConnLimiterFromEnv builds a connLimiter with limits from the
+// MAX_CONNS_PER_USER and MAX_CONNS_PER_IP environment variables, falling back
+// to the defaults when they are unset or invalid
+func newConnLimiterFromEnv(logger *slog.Logger) *connLimiter {
+	return newConnLimiter(
+		connLimitFromEnv(logger, "MAX_CONNS_PER_USER", defaultMaxConnsPerUser),
+		connLimitFromEnv(logger, "MAX_CONNS_PER_IP", defaultMaxConnsPerIP),
+	)
+}
+
+func connLimitFromEnv(logger *slog.Logger, name string, fallback int) int {
+	val := os.Getenv(name)
+	if val == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		logger.Warn("ignoring invalid connection limit", "name", name, "value", val)
+		return fallback
+	}
+	return n
+}
+
+// acquire reserves a connection slot for the user and IP, reporting whether
+// both were under their limits. On failure nothing is reserved.
+func (l *connLimiter) acquire(userID, ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.users[userID] >= l.maxPerUser || l.ips[ip] >= l.maxPerIP {
+		return false
+	}
+	l.users[userID]++
+	l.ips[ip]++
+	return true
+}
+
+// release frees a slot previously reserved with acquire
+func (l *connLimiter) release(userID, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.users[userID] <= 1 {
+		delete(l.users, userID)
+	} else {
+		l.users[userID]--
+	}
+	if l.ips[ip] <= 1 {
+		delete(l.ips, ip)
+	} else {
+		l.ips[ip]--
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestConnLimiter tests the per-user and per-IP accounting directly
+func TestConnLimiter(t *testing.T) {
+	limiter := newConnLimiter(2, 3)
+
+	if !limiter.acquire("user1", "1.2.3.4") {
+		t.Fatal("Expected first connection to be allowed")
+	}
+	if !limiter.acquire("user1", "1.2.3.4") {
+		t.Fatal("Expected second connection to be allowed")
+	}
+	if limiter.acquire("user1", "1.2.3.4") {
+		t.Error("Expected third connection for user1 to be refused")
+	}
+
+	// A different user from the same IP has one slot left
+	if !limiter.acquire("user2", "1.2.3.4") {
+		t.Fatal("Expected user2's first connection to be allowed")
+	}
+	if limiter.acquire("user3", "1.2.3.4") {
+		t.Error("Expected fourth connection from the IP to be refused")
+	}
+
+	// Releasing frees slots for both the user and the IP
+	limiter.release("user1", "1.2.3.4")
+	if !limiter.acquire("user3", "1.2.3.4") {
+		t.Error("Expected a connection to be allowed after release")
+	}
+}
+
+// TestConnectionLimitPerUser tests that a user's sixth concurrent WebSocket
+// connection is refused and that closing one frees a slot
+func TestConnectionLimitPerUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	httpClient := ts.createUser("limituser", "password123")
+
+	conns := make([]*websocket.Conn, 0, defaultMaxConnsPerUser)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+	for i := 0; i < defaultMaxConnsPerUser; i++ {
+		conn, err := dialWebSocket(ts, httpClient, nil)
+		if err != nil {
+			t.Fatalf("Failed to open connection %d: %v", i+1, err)
+		}
+		conns = append(conns, conn)
+	}
+
+	if _, err := dialWebSocket(ts, httpClient, nil); err == nil {
+		t.Fatal("Expected connection over the limit to be refused")
+	}
+
+	// Closing a connection frees its slot once the hub unregisters it
+	_ = conns[0].Close()
+	conns = conns[1:]
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := dialWebSocket(ts, httpClient, nil)
+		if err == nil {
+			conns = append(conns, conn)
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a connection after closing one, got %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
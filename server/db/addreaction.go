@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+)
+
+// AddReaction records a user's emoji reaction to a message and returns the
+// total number of reactions with that emoji on the message afterwards. The
+// insert and the count run in one transaction, so under concurrent
+// reactions every caller sees a count that was true at the moment its own
+// write committed. Duplicate reactions hit the (message_id, user_id, emoji)
+// primary key and are a no-op.
+func AddReaction(ctx context.Context, db *DB, messageID, userID, emoji, createdAt string) (int, error) {
+	const insertstr = `INSERT INTO reactions (message_id, user_id, emoji, created_at) ` +
+		`VALUES ($1, $2, $3, $4) ` +
+		`ON CONFLICT (message_id, user_id, emoji) DO NOTHING`
+	const countstr = `SELECT COUNT(*) FROM reactions ` +
+		`WHERE message_id = $1 AND emoji = $2`
+	db.logger.Debug("querying", "query", insertstr, "args", []any{messageID, userID, emoji, createdAt})
+
+	tx, err := db.WriteDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, insertstr, messageID, userID, emoji, createdAt); err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, countstr, messageID, emoji).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
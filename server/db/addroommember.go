@@ -6,9 +6,15 @@ import (
 	"github.com/llimllib/hatchat/server/models"
 )
 
-// AddRoomMember adds a user as a member of a room.
+// AddRoomMember adds a user as a regular member of a room.
 // Returns true if the user was added, false if they were already a member.
 func AddRoomMember(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
+	return AddRoomMemberWithRole(ctx, db, userID, roomID, RoleMember)
+}
+
+// AddRoomMemberWithRole adds a user as a member of a room with the given role.
+// Returns true if the user was added, false if they were already a member.
+func AddRoomMemberWithRole(ctx context.Context, db *DB, userID, roomID, role string) (bool, error) {
 	// Check if already a member
 	isMember, err := IsRoomMember(ctx, db, userID, roomID)
 	if err != nil {
@@ -22,10 +28,12 @@ func AddRoomMember(ctx context.Context, db *DB, userID, roomID string) (bool, er
 	member := &models.RoomsMember{
 		UserID: userID,
 		RoomID: roomID,
+		Role:   role,
 	}
 	if err := member.Insert(ctx, db); err != nil {
 		return false, err
 	}
+	db.members.invalidate(userID, roomID)
 
 	return true, nil
 }
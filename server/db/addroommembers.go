@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+)
+
+// AddRoomMembers adds several users as regular members of a room in a
+// single transaction, skipping users who are already members. It returns
+// the IDs of the users that were actually added. If any insert fails the
+// whole batch is rolled back.
+func AddRoomMembers(ctx context.Context, db *DB, roomID string, userIDs []string) ([]string, error) {
+	const sqlstr = `INSERT INTO rooms_members (user_id, room_id, role) ` +
+		`VALUES ($1, $2, $3) ` +
+		`ON CONFLICT (user_id, room_id) DO NOTHING`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, userIDs})
+
+	tx, err := db.WriteDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	added := []string{}
+	for _, userID := range userIDs {
+		res, err := tx.ExecContext(ctx, sqlstr, userID, roomID, RoleMember)
+		if err != nil {
+			return nil, err
+		}
+		// zero rows affected means the user was already a member
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			added = append(added, userID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	for _, userID := range added {
+		db.members.invalidate(userID, roomID)
+	}
+	return added, nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Audit actions recorded in the audit_log table
+const (
+	AuditRoomCreate      = "room_create"
+	AuditMessageDelete   = "message_delete"
+	AuditMessagePurge    = "message_purge"
+	AuditSlowModeChange  = "slow_mode_change"
+	AuditRetentionChange = "retention_change"
+	AuditTopicChange     = "topic_change"
+
+	AuditMaintenanceToggle = "maintenance_toggle"
+	AuditAnnounce          = "announce"
+)
+
+// AuditSystemActor is the actor recorded for actions taken by the server
+// itself rather than a user, like retention purges
+const AuditSystemActor = "system"
+
+// AuditEntry is one row of the audit trail
+type AuditEntry struct {
+	ID        int64
+	ActorID   string
+	Action    string
+	TargetID  string
+	RoomID    string
+	CreatedAt string
+}
+
+// AuditFilter narrows an audit log listing; zero values apply no filter.
+// Since and Until are RFC3339 bounds on created_at, inclusive and exclusive
+// respectively. Before returns entries older than the given entry ID for
+// pagination.
+type AuditFilter struct {
+	ActorID string
+	RoomID  string
+	Since   string
+	Until   string
+	Before  int64
+	Limit   int
+}
+
+// InsertAuditEntry records an action in the audit log. Callers must not fail
+// the underlying action when the audit write errors; log loudly and continue.
+func InsertAuditEntry(ctx context.Context, db *DB, actorID, action, targetID, roomID string) error {
+	const sqlstr = `INSERT INTO audit_log (actor_id, action, target_id, room_id, created_at) ` +
+		`VALUES ($1, $2, $3, $4, $5)`
+	createdAt := time.Now().Format(time.RFC3339Nano)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{actorID, action, targetID, roomID, createdAt})
+	_, err := db.ExecContext(ctx, sqlstr, actorID, action, targetID, roomID, createdAt)
+	return err
+}
+
+// ListAuditEntries returns audit entries matching the filter, newest first
+func ListAuditEntries(ctx context.Context, db *DB, filter AuditFilter) ([]AuditEntry, error) {
+	sqlstr := `SELECT id, actor_id, action, target_id, room_id, created_at ` +
+		`FROM audit_log WHERE 1=1`
+	var args []any
+	if filter.ActorID != "" {
+		sqlstr += fmt.Sprintf(` AND actor_id = $%d`, len(args)+1)
+		args = append(args, filter.ActorID)
+	}
+	if filter.RoomID != "" {
+		sqlstr += fmt.Sprintf(` AND room_id = $%d`, len(args)+1)
+		args = append(args, filter.RoomID)
+	}
+	if filter.Since != "" {
+		sqlstr += fmt.Sprintf(` AND created_at >= $%d`, len(args)+1)
+		args = append(args, filter.Since)
+	}
+	if filter.Until != "" {
+		sqlstr += fmt.Sprintf(` AND created_at < $%d`, len(args)+1)
+		args = append(args, filter.Until)
+	}
+	if filter.Before > 0 {
+		sqlstr += fmt.Sprintf(` AND id < $%d`, len(args)+1)
+		args = append(args, filter.Before)
+	}
+	sqlstr += fmt.Sprintf(` ORDER BY id DESC LIMIT $%d`, len(args)+1)
+	args = append(args, filter.Limit)
+
+	db.logger.Debug("querying", "query", sqlstr, "args", args)
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &e.TargetID, &e.RoomID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// insertAuditAt inserts an audit entry with an explicit timestamp so tests
+// can exercise date filtering deterministically
+func insertAuditAt(t *testing.T, database *DB, actorID, action, targetID, roomID string, createdAt time.Time) {
+	t.Helper()
+	const sqlstr = `INSERT INTO audit_log (actor_id, action, target_id, room_id, created_at) ` +
+		`VALUES ($1, $2, $3, $4, $5)`
+	_, err := database.ExecContext(context.Background(), sqlstr, actorID, action, targetID, roomID, createdAt.Format(time.RFC3339Nano))
+	if err != nil {
+		t.Fatalf("Failed to insert audit entry: %v", err)
+	}
+}
+
+func TestListAuditEntries_Filters(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	base := time.Now()
+
+	insertAuditAt(t, database, "usr_al_actor_01", AuditRoomCreate, "roo_al_room_001", "roo_al_room_001", base.Add(-2*time.Hour))
+	insertAuditAt(t, database, "usr_al_actor_02", AuditMessageDelete, "msg_al_msg_0001", "roo_al_room_001", base.Add(-1*time.Hour))
+	insertAuditAt(t, database, "usr_al_actor_01", AuditMessageDelete, "msg_al_msg_0002", "roo_al_room_002", base)
+
+	// Filter by actor
+	entries, err := ListAuditEntries(ctx, database, AuditFilter{ActorID: "usr_al_actor_01", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries for actor, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.ActorID != "usr_al_actor_01" {
+			t.Errorf("expected actor usr_al_actor_01, got %s", e.ActorID)
+		}
+	}
+
+	// Filter by room
+	entries, err = ListAuditEntries(ctx, database, AuditFilter{RoomID: "roo_al_room_001", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries for room, got %d", len(entries))
+	}
+
+	// Filter by date window around the middle entry
+	entries, err = ListAuditEntries(ctx, database, AuditFilter{
+		ActorID: "usr_al_actor_02",
+		Since:   base.Add(-90 * time.Minute).Format(time.RFC3339Nano),
+		Until:   base.Add(-30 * time.Minute).Format(time.RFC3339Nano),
+		Limit:   10,
+	})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry in date window, got %d", len(entries))
+	}
+	if entries[0].Action != AuditMessageDelete || entries[0].TargetID != "msg_al_msg_0001" {
+		t.Errorf("expected the middle entry, got %+v", entries[0])
+	}
+}
+
+func TestListAuditEntries_Pagination(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		insertAuditAt(t, database, "usr_al_pager_01", AuditRoomCreate, "", "", base.Add(time.Duration(i)*time.Second))
+	}
+
+	// Page through two at a time, newest first
+	var got []int64
+	var before int64
+	for {
+		entries, err := ListAuditEntries(ctx, database, AuditFilter{ActorID: "usr_al_pager_01", Before: before, Limit: 2})
+		if err != nil {
+			t.Fatalf("ListAuditEntries failed: %v", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+		for _, e := range entries {
+			got = append(got, e.ID)
+		}
+		before = entries[len(entries)-1].ID
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] >= got[i-1] {
+			t.Errorf("expected descending IDs, got %v", got)
+		}
+	}
+}
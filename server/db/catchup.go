@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+)
+
+// RoomActivity is a per-room count of new messages for the catch-up feed
+type RoomActivity struct {
+	RoomID   string
+	RoomName string
+	Count    int
+}
+
+// GetRoomActivitySince counts messages posted after the given time in each of
+// the user's unmuted rooms, most recently active first. Rooms with no new
+// messages are omitted.
+func GetRoomActivitySince(ctx context.Context, db *DB, userID, since string) ([]*RoomActivity, error) {
+	const sqlstr = `SELECT m.room_id, r.name, COUNT(*) AS unread ` +
+		`FROM messages m ` +
+		`JOIN rooms r ON m.room_id = r.id ` +
+		`WHERE m.room_id IN (` +
+		`SELECT room_id FROM rooms_members WHERE user_id = $1 AND muted = 0` +
+		`) ` +
+		`AND m.created_at > $2 ` +
+		`AND m.deleted_at IS NULL ` +
+		`GROUP BY m.room_id ` +
+		`ORDER BY MAX(m.created_at) DESC`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, since})
+
+	rows, err := db.QueryContext(ctx, sqlstr, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []*RoomActivity
+	for rows.Next() {
+		var a RoomActivity
+		if err := rows.Scan(&a.RoomID, &a.RoomName, &a.Count); err != nil {
+			return nil, err
+		}
+		activity = append(activity, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// GetRecentRoomMessagesSince returns the most recent messages in a room
+// posted after the given time, in chronological order, for catch-up previews.
+func GetRecentRoomMessagesSince(ctx context.Context, db *DB, roomID, since string, limit int) ([]*RoomMessage, error) {
+	const sqlstr = `SELECT ` + messageContextColumns + ` ` +
+		`FROM messages m ` +
+		`JOIN users u ON m.user_id = u.id ` +
+		`WHERE m.room_id = $1 AND m.created_at > $2 AND m.deleted_at IS NULL ` +
+		`ORDER BY m.created_at DESC ` +
+		`LIMIT $3`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, since, limit})
+	messages, err := scanRoomMessages(ctx, db, sqlstr, roomID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	// Reverse from newest-first to chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+)
+
+// CountUserRooms returns the number of channels the user is a member of.
+// DMs are excluded; they never count toward the room limit.
+func CountUserRooms(ctx context.Context, db *DB, userID string) (int, error) {
+	const sqlstr = `SELECT count(*) FROM rooms_members rm ` +
+		`JOIN rooms r ON r.id = rm.room_id ` +
+		`WHERE rm.user_id = $1 AND r.room_type != 'dm'`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID})
+	var n int
+	if err := db.QueryRowContext(ctx, sqlstr, userID).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
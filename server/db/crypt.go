@@ -0,0 +1,121 @@
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// MessageCipher encrypts message bodies at rest with AES-256-GCM for
+// deployments that can't store plaintext on disk. Each row records the ID of
+// the key that encrypted it, so keys can be rotated by adding a new key at
+// the front of the list and keeping old keys around for reads.
+//
+// Tradeoff: the full-text search index would store plaintext tokens, so
+// search is disabled while encryption is enabled. The FTS triggers still fire
+// but only ever index ciphertext.
+type MessageCipher struct {
+	activeKeyID string
+	keys        map[string]cipher.AEAD
+}
+
+// ParseMessageCipher parses a key list of the form
+// "<key_id>:<64 hex chars>[,<key_id>:<64 hex chars>...]". The first key is
+// used to encrypt new messages; the rest are kept for decrypting rows
+// written before a rotation.
+func ParseMessageCipher(spec string) (*MessageCipher, error) {
+	mc := &MessageCipher{keys: make(map[string]cipher.AEAD)}
+	for i, part := range strings.Split(spec, ",") {
+		id, hexKey, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("key %d: expected <key_id>:<hex key>", i+1)
+		}
+		raw, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", id, err)
+		}
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("key %q: must be 32 bytes (64 hex characters), got %d bytes", id, len(raw))
+		}
+		if _, ok := mc.keys[id]; ok {
+			return nil, fmt.Errorf("duplicate key ID %q", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", id, err)
+		}
+		mc.keys[id] = aead
+		if i == 0 {
+			mc.activeKeyID = id
+		}
+	}
+	return mc, nil
+}
+
+// Enabled reports whether encryption is configured. Safe on a nil receiver so
+// callers don't have to check whether a cipher was set.
+func (c *MessageCipher) Enabled() bool {
+	return c != nil && len(c.keys) > 0
+}
+
+// ActiveKeyID returns the ID of the key used to encrypt new messages
+func (c *MessageCipher) ActiveKeyID() string {
+	if c == nil {
+		return ""
+	}
+	return c.activeKeyID
+}
+
+// EncryptBody encrypts a message body with the active key, returning the
+// ciphertext and the key ID to store alongside it. When no cipher is
+// configured the body passes through unchanged with an empty key ID.
+func (c *MessageCipher) EncryptBody(body string) (string, string, error) {
+	if !c.Enabled() {
+		return body, "", nil
+	}
+	aead := c.keys[c.activeKeyID]
+	// The nonce is prepended to the sealed ciphertext
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(body), nil)
+	return base64.StdEncoding.EncodeToString(sealed), c.activeKeyID, nil
+}
+
+// DecryptBody decrypts a stored message body. An empty key ID marks a row
+// written before encryption was enabled and passes through unchanged. A key
+// ID with no matching key, or a body the key can't authenticate, is an error:
+// decryption failures must surface loudly rather than render garbage.
+func (c *MessageCipher) DecryptBody(body, keyID string) (string, error) {
+	if keyID == "" {
+		return body, nil
+	}
+	if !c.Enabled() {
+		return "", fmt.Errorf("message encrypted with key %q but no encryption keys are configured", keyID)
+	}
+	aead, ok := c.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("message encrypted with unknown key %q", keyID)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", fmt.Errorf("decode encrypted body: %v", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("encrypted body shorter than nonce")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt message body with key %q: %v", keyID, err)
+	}
+	return string(plain), nil
+}
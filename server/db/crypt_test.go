@@ -0,0 +1,137 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testKeyA = "k1:" + "0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a"
+	testKeyB = "k2:" + "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b"
+)
+
+// TestParseMessageCipher_Errors tests that malformed key specs are rejected
+func TestParseMessageCipher_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		spec string
+	}{
+		{"missing separator", "justakeywithnocolon"},
+		{"empty key ID", ":" + strings.Repeat("0a", 32)},
+		{"not hex", "k1:zzzz"},
+		{"too short", "k1:" + strings.Repeat("0a", 16)},
+		{"duplicate key ID", testKeyA + "," + testKeyA},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := ParseMessageCipher(c.spec); err == nil {
+				t.Errorf("expected error for spec %q, got nil", c.spec)
+			}
+		})
+	}
+}
+
+// TestMessageCipher_RoundTrip tests that an encrypted body is not plaintext
+// and decrypts back to the original
+func TestMessageCipher_RoundTrip(t *testing.T) {
+	cipher, err := ParseMessageCipher(testKeyA)
+	if err != nil {
+		t.Fatalf("ParseMessageCipher failed: %v", err)
+	}
+
+	stored, keyID, err := cipher.EncryptBody("attack at dawn")
+	if err != nil {
+		t.Fatalf("EncryptBody failed: %v", err)
+	}
+	if keyID != "k1" {
+		t.Errorf("expected key ID k1, got %q", keyID)
+	}
+	if strings.Contains(stored, "attack") {
+		t.Errorf("stored body contains plaintext: %q", stored)
+	}
+
+	plain, err := cipher.DecryptBody(stored, keyID)
+	if err != nil {
+		t.Fatalf("DecryptBody failed: %v", err)
+	}
+	if plain != "attack at dawn" {
+		t.Errorf("expected round-tripped plaintext, got %q", plain)
+	}
+}
+
+// TestMessageCipher_KeyRotation tests that a rotated cipher still decrypts
+// rows written under the old key and encrypts new rows with the new one
+func TestMessageCipher_KeyRotation(t *testing.T) {
+	oldCipher, err := ParseMessageCipher(testKeyA)
+	if err != nil {
+		t.Fatalf("ParseMessageCipher failed: %v", err)
+	}
+	stored, keyID, err := oldCipher.EncryptBody("pre-rotation")
+	if err != nil {
+		t.Fatalf("EncryptBody failed: %v", err)
+	}
+
+	// k2 is now active; k1 is retained for reads
+	rotated, err := ParseMessageCipher(testKeyB + "," + testKeyA)
+	if err != nil {
+		t.Fatalf("ParseMessageCipher failed: %v", err)
+	}
+	if rotated.ActiveKeyID() != "k2" {
+		t.Errorf("expected active key k2, got %q", rotated.ActiveKeyID())
+	}
+	plain, err := rotated.DecryptBody(stored, keyID)
+	if err != nil {
+		t.Fatalf("DecryptBody with retained key failed: %v", err)
+	}
+	if plain != "pre-rotation" {
+		t.Errorf("expected 'pre-rotation', got %q", plain)
+	}
+	if _, newKeyID, err := rotated.EncryptBody("post-rotation"); err != nil || newKeyID != "k2" {
+		t.Errorf("expected new writes under k2, got key %q err %v", newKeyID, err)
+	}
+}
+
+// TestMessageCipher_WrongKeyFailsLoudly tests that decryption with the wrong
+// key material or an unknown key ID returns an error instead of garbage
+func TestMessageCipher_WrongKeyFailsLoudly(t *testing.T) {
+	right, err := ParseMessageCipher(testKeyA)
+	if err != nil {
+		t.Fatalf("ParseMessageCipher failed: %v", err)
+	}
+	stored, keyID, err := right.EncryptBody("secret")
+	if err != nil {
+		t.Fatalf("EncryptBody failed: %v", err)
+	}
+
+	// Same key ID, different key material
+	wrong, err := ParseMessageCipher("k1:" + strings.Repeat("0c", 32))
+	if err != nil {
+		t.Fatalf("ParseMessageCipher failed: %v", err)
+	}
+	if _, err := wrong.DecryptBody(stored, keyID); err == nil {
+		t.Error("expected decryption with the wrong key to fail, got nil")
+	}
+
+	// Unknown key ID
+	if _, err := right.DecryptBody(stored, "k9"); err == nil {
+		t.Error("expected decryption with an unknown key ID to fail, got nil")
+	}
+}
+
+// TestMessageCipher_NilPassesThrough tests that an unconfigured cipher stores
+// and reads plaintext unchanged
+func TestMessageCipher_NilPassesThrough(t *testing.T) {
+	var cipher *MessageCipher
+	stored, keyID, err := cipher.EncryptBody("plain")
+	if err != nil || stored != "plain" || keyID != "" {
+		t.Errorf("expected plaintext pass-through, got %q key %q err %v", stored, keyID, err)
+	}
+	plain, err := cipher.DecryptBody("plain", "")
+	if err != nil || plain != "plain" {
+		t.Errorf("expected plaintext pass-through, got %q err %v", plain, err)
+	}
+	// An encrypted row with no keys configured must error, not render garbage
+	if _, err := cipher.DecryptBody("ciphertext", "k1"); err == nil {
+		t.Error("expected error decrypting with no keys configured, got nil")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -17,7 +18,14 @@ import (
 type DB struct {
 	ReadDB  *sql.DB
 	WriteDB *sql.DB
+	// Cipher encrypts message bodies at rest when set; nil stores plaintext
+	Cipher *MessageCipher
+	// members caches room membership checks; see membershipcache.go
+	members *membershipCache
 	logger  *slog.Logger
+	// ftsAvailable is false when the SQLite build lacks FTS5; the schema
+	// then skips the FTS index and search falls back to LIKE matching
+	ftsAvailable bool
 }
 
 func NewDB(dbUrl string, logger *slog.Logger) (*DB, error) {
@@ -68,10 +76,23 @@ func NewDB(dbUrl string, logger *slog.Logger) (*DB, error) {
 	readDB.SetMaxOpenConns(max(4, runtime.NumCPU()))
 	setSQLitePragmas(readDB)
 
+	// Some SQLite builds ship without the FTS5 extension. Detect that here
+	// so startup and search degrade instead of failing hard (see RunSQLFile
+	// and SearchMessages)
+	ftsAvailable := true
+	if err := readDB.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&ftsAvailable); err != nil {
+		ftsAvailable = false
+	}
+	if !ftsAvailable {
+		logger.Warn("SQLite was built without FTS5; message search is degraded to unranked LIKE matching")
+	}
+
 	return &DB{
-		ReadDB:  readDB,
-		WriteDB: writeDB,
-		logger:  logger,
+		ReadDB:       readDB,
+		WriteDB:      writeDB,
+		members:      newMembershipCache(),
+		logger:       logger,
+		ftsAvailable: ftsAvailable,
 	}, nil
 }
 
@@ -143,10 +164,49 @@ func (db *DB) RunSQLFile(filePath string) error {
 		return err
 	}
 
-	_, err = db.ExecContext(context.Background(), string(sqlfile))
+	sqlText := string(sqlfile)
+	// Without FTS5 the virtual table and its sync triggers can't be
+	// created; strip them so the rest of the schema still applies
+	if !db.ftsAvailable {
+		sqlText = stripFTSStatements(sqlText)
+	}
+
+	_, err = db.ExecContext(context.Background(), sqlText)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// stripFTSStatements removes statements that reference the messages_fts
+// virtual table. Statements are separated line-wise on semicolons, with
+// trigger bodies (which contain their own semicolons) kept together by
+// tracking their BEGIN...END block. Comment lines travel with the
+// statement that follows them.
+func stripFTSStatements(sqlText string) string {
+	var kept []string
+	var stmt []string
+	inTrigger := false
+	for _, line := range strings.Split(sqlText, "\n") {
+		stmt = append(stmt, line)
+		trimmed := strings.ToUpper(strings.TrimSpace(line))
+		if strings.HasPrefix(trimmed, "CREATE TRIGGER") {
+			inTrigger = true
+		}
+		done := strings.HasSuffix(trimmed, ";")
+		if inTrigger {
+			done = trimmed == "END;"
+		}
+		if done {
+			block := strings.Join(stmt, "\n")
+			if !strings.Contains(block, "messages_fts") {
+				kept = append(kept, block)
+			}
+			stmt = nil
+			inTrigger = false
+		}
+	}
+	kept = append(kept, strings.Join(stmt, "\n"))
+	return strings.Join(kept, "\n")
+}
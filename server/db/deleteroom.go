@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// DeleteRoom removes a room and everything in it — messages and the rows
+// referencing them, pins, reads, filters, join requests, DM bookkeeping and
+// memberships — refusing the default room with ErrDefaultRoom. Every
+// room-deletion path goes through here so the default-room guard can't be
+// forgotten. Attachments outlive their messages as unlinked uploads, and
+// users whose last_room pointed at the room are moved to the default room.
+// Returns the set of user IDs who were members, so callers can notify them
+// after the room is gone.
+func DeleteRoom(ctx context.Context, db *DB, roomID string) (map[string]bool, error) {
+	room, err := models.RoomByID(ctx, db, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room.IsDefault != 0 {
+		return nil, ErrDefaultRoom
+	}
+
+	// Capture the membership before the rows go away
+	members, err := GetRoomMemberIDs(ctx, db, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.WriteDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Rows referencing the room's messages go first, then the messages, then
+	// the rows referencing the room itself, so the foreign keys never block
+	// the delete. The FTS index is cleaned up by the messages_fts_delete
+	// trigger.
+	for _, sqlstr := range []string{
+		`UPDATE users SET last_room = COALESCE((SELECT id FROM rooms WHERE is_default = 1 LIMIT 1), '') WHERE last_room = $1`,
+		`DELETE FROM reactions WHERE message_id IN (SELECT id FROM messages WHERE room_id = $1)`,
+		`UPDATE attachments SET message_id = NULL WHERE message_id IN (SELECT id FROM messages WHERE room_id = $1)`,
+		`DELETE FROM starred_messages WHERE message_id IN (SELECT id FROM messages WHERE room_id = $1)`,
+		`DELETE FROM message_quotes WHERE message_id IN (SELECT id FROM messages WHERE room_id = $1)`,
+		`DELETE FROM message_forwards WHERE source_room_id = $1 OR message_id IN (SELECT id FROM messages WHERE room_id = $1)`,
+		`DELETE FROM message_reports WHERE room_id = $1 OR message_id IN (SELECT id FROM messages WHERE room_id = $1)`,
+		`DELETE FROM room_reads WHERE room_id = $1 OR last_read_message_id IN (SELECT id FROM messages WHERE room_id = $1)`,
+		`DELETE FROM pinned_messages WHERE room_id = $1`,
+		`DELETE FROM messages WHERE room_id = $1`,
+		`DELETE FROM room_filters WHERE room_id = $1`,
+		`DELETE FROM room_join_requests WHERE room_id = $1`,
+		`DELETE FROM hidden_dms WHERE room_id = $1`,
+		`DELETE FROM dm_labels WHERE room_id = $1`,
+		`DELETE FROM dm_keys WHERE room_id = $1`,
+		`DELETE FROM favorite_rooms WHERE room_id = $1`,
+		`DELETE FROM rooms_members WHERE room_id = $1`,
+		`DELETE FROM rooms WHERE id = $1`,
+	} {
+		db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID})
+		if _, err := tx.ExecContext(ctx, sqlstr, roomID); err != nil {
+			return nil, fmt.Errorf("deleting room %s: %w", roomID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	// The memberships just deleted may be cached on the hot path
+	db.members.invalidateRoom(roomID)
+	return members, nil
+}
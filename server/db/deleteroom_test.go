@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// TestDeleteRoom_RemovesRoomAndMemberships tests that a normal room and its
+// membership rows are deleted together
+func TestDeleteRoom_RemovesRoomAndMemberships(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user := createTestUser(t, database, "usr_delroom00001", "delroomuser")
+	room := createTestRoom(t, database, "roo_delroom00001", "delroom-channel", false)
+	deflt := createTestRoomWithDefault(t, database, "roo_delroom00002", "delroom-main", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	// The user was last viewing the doomed room
+	if _, err := database.ExecContext(ctx,
+		"UPDATE users SET last_room = ? WHERE id = ?", room.ID, user.ID); err != nil {
+		t.Fatalf("Failed to set last_room: %v", err)
+	}
+
+	members, err := DeleteRoom(ctx, database, room.ID)
+	if err != nil {
+		t.Fatalf("DeleteRoom failed: %v", err)
+	}
+	if !members[user.ID] {
+		t.Errorf("Expected the removed member set to contain %s, got %v", user.ID, members)
+	}
+
+	if _, err := models.RoomByID(ctx, database, room.ID); err == nil {
+		t.Error("Expected the room to be gone")
+	}
+	isMember, err := IsRoomMember(ctx, database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("IsRoomMember failed: %v", err)
+	}
+	if isMember {
+		t.Error("Expected the membership to be gone")
+	}
+
+	// The user's active room fell back to the default room
+	updated, err := models.UserByID(ctx, database, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch user: %v", err)
+	}
+	if updated.LastRoom != deflt.ID {
+		t.Errorf("Expected last_room to fall back to %s, got %q", deflt.ID, updated.LastRoom)
+	}
+}
+
+// TestDeleteRoom_RemovesRoomContent tests that a room whose messages have
+// been reacted to, starred, pinned, quoted, forwarded, reported and read —
+// plus filters and join requests on the room itself — deletes cleanly
+// instead of tripping a foreign key
+func TestDeleteRoom_RemovesRoomContent(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user := createTestUser(t, database, "usr_delroom00021", "delroomcontent")
+	room := createTestRoom(t, database, "roo_delroom00021", "delroom-content", false)
+	other := createTestRoom(t, database, "roo_delroom00022", "delroom-other", false)
+	addUserToRoom(t, database, user.ID, room.ID)
+	addUserToRoom(t, database, user.ID, other.ID)
+
+	insertMessageAt(t, database, "msg_delroom00001", room.ID, user.ID, "doomed", time.Hour)
+	insertMessageAt(t, database, "msg_delroom00002", other.ID, user.ID, "forwarded copy", time.Hour)
+
+	now := time.Now().Format(time.RFC3339)
+	for _, ins := range []struct {
+		sqlstr string
+		args   []any
+	}{
+		{`INSERT INTO reactions (message_id, user_id, emoji, created_at) VALUES ($1, $2, '👍', $3)`,
+			[]any{"msg_delroom00001", user.ID, now}},
+		{`INSERT INTO room_reads (user_id, room_id, last_read_message_id, updated_at) VALUES ($1, $2, $3, $4)`,
+			[]any{user.ID, room.ID, "msg_delroom00001", now}},
+		{`INSERT INTO starred_messages (user_id, message_id, created_at) VALUES ($1, $2, $3)`,
+			[]any{user.ID, "msg_delroom00001", now}},
+		{`INSERT INTO pinned_messages (room_id, message_id, pinned_by, created_at) VALUES ($1, $2, $3, $4)`,
+			[]any{room.ID, "msg_delroom00001", user.ID, now}},
+		{`INSERT INTO message_quotes (message_id, quoted_message_id, quoted_user_id, quoted_username, excerpt, quoted_created_at) VALUES ($1, 'msg_gonealready1', $2, $3, 'hello', $4)`,
+			[]any{"msg_delroom00001", user.ID, user.Username, now}},
+		// A message in another room forwarded from the doomed room
+		{`INSERT INTO message_forwards (message_id, source_message_id, source_room_id, source_user_id) VALUES ($1, $2, $3, $4)`,
+			[]any{"msg_delroom00002", "msg_delroom00001", room.ID, user.ID}},
+		{`INSERT INTO message_reports (message_id, reporter_id, room_id, created_at) VALUES ($1, $2, $3, $4)`,
+			[]any{"msg_delroom00001", user.ID, room.ID, now}},
+		{`INSERT INTO attachments (id, message_id, user_id, url, filename, content_type, size, created_at) VALUES ($1, $2, $3, '/uploads/x.png', 'x.png', 'image/png', 1, $4)`,
+			[]any{"att_delroom00001", "msg_delroom00001", user.ID, now}},
+		{`INSERT INTO room_filters (room_id, pattern, created_at) VALUES ($1, 'spam', $2)`,
+			[]any{room.ID, now}},
+		{`INSERT INTO room_join_requests (user_id, room_id, created_at) VALUES ($1, $2, $3)`,
+			[]any{user.ID, room.ID, now}},
+	} {
+		if _, err := database.ExecContext(ctx, ins.sqlstr, ins.args...); err != nil {
+			t.Fatalf("Failed to insert content: %v", err)
+		}
+	}
+
+	if _, err := DeleteRoom(ctx, database, room.ID); err != nil {
+		t.Fatalf("DeleteRoom failed: %v", err)
+	}
+
+	if _, err := models.MessageByID(ctx, database, "msg_delroom00001"); err == nil {
+		t.Error("Expected the room's message to be gone")
+	}
+	if _, err := models.MessageByID(ctx, database, "msg_delroom00002"); err != nil {
+		t.Errorf("Expected the other room's message to be kept: %v", err)
+	}
+
+	// Every row referencing the room or its messages is gone
+	for _, q := range []string{
+		`SELECT count(*) FROM reactions WHERE message_id = 'msg_delroom00001'`,
+		`SELECT count(*) FROM room_reads WHERE room_id = 'roo_delroom00021'`,
+		`SELECT count(*) FROM starred_messages WHERE message_id = 'msg_delroom00001'`,
+		`SELECT count(*) FROM pinned_messages WHERE room_id = 'roo_delroom00021'`,
+		`SELECT count(*) FROM message_quotes WHERE message_id = 'msg_delroom00001'`,
+		`SELECT count(*) FROM message_forwards WHERE source_room_id = 'roo_delroom00021'`,
+		`SELECT count(*) FROM message_reports WHERE room_id = 'roo_delroom00021'`,
+		`SELECT count(*) FROM room_filters WHERE room_id = 'roo_delroom00021'`,
+		`SELECT count(*) FROM room_join_requests WHERE room_id = 'roo_delroom00021'`,
+	} {
+		var n int
+		if err := database.QueryRowContext(ctx, q).Scan(&n); err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("Expected no rows for %s, got %d", q, n)
+		}
+	}
+
+	// The upload survives as an unlinked attachment
+	var linked int
+	if err := database.QueryRowContext(ctx,
+		`SELECT count(*) FROM attachments WHERE id = 'att_delroom00001' AND message_id IS NULL`).Scan(&linked); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if linked != 1 {
+		t.Error("Expected the attachment to be kept with its message unlinked")
+	}
+}
+
+// TestDeleteRoom_DefaultRoomRefused tests that the default room is refused
+// with the same error the leave path returns
+func TestDeleteRoom_DefaultRoomRefused(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user := createTestUser(t, database, "usr_delroom00011", "delroomdefault")
+	room := createTestRoomWithDefault(t, database, "roo_delroom00011", "delroom-default", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+
+	if _, err := DeleteRoom(ctx, database, room.ID); !errors.Is(err, ErrDefaultRoom) {
+		t.Fatalf("DeleteRoom should return ErrDefaultRoom for the default room, got %v", err)
+	}
+
+	// The room and its memberships are untouched
+	if _, err := models.RoomByID(ctx, database, room.ID); err != nil {
+		t.Errorf("Expected the default room to still exist: %v", err)
+	}
+	isMember, err := IsRoomMember(ctx, database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("IsRoomMember failed: %v", err)
+	}
+	if !isMember {
+		t.Error("Expected the membership to be untouched")
+	}
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DMKey returns the deterministic key for a DM's member set: the hex
+// SHA-256 of the sorted member IDs. The same members always yield the same
+// key regardless of order.
+func DMKey(memberIDs []string) string {
+	ids := append([]string(nil), memberIDs...)
+	sort.Strings(ids)
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClaimDMKey records roomID as the DM room for key. Returns true if the
+// claim won, false if another room already holds the key (the concurrent
+// creation race; look the winner up with RoomIDByDMKey).
+func ClaimDMKey(ctx context.Context, db *DB, key, roomID string) (bool, error) {
+	const sqlstr = `INSERT INTO dm_keys (dm_key, room_id, created_at) ` +
+		`VALUES ($1, $2, $3) ` +
+		`ON CONFLICT (dm_key) DO NOTHING`
+	createdAt := time.Now().Format(time.RFC3339)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{key, roomID, createdAt})
+	res, err := db.ExecContext(ctx, sqlstr, key, roomID, createdAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RoomIDByDMKey returns the room holding a DM key, or "" when no room has
+// claimed it.
+func RoomIDByDMKey(ctx context.Context, db *DB, key string) (string, error) {
+	const sqlstr = `SELECT room_id FROM dm_keys WHERE dm_key = $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{key})
+	var roomID string
+	err := db.QueryRowContext(ctx, sqlstr, key).Scan(&roomID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return roomID, nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// SetDMLabel stores a user's display label for a DM, replacing any earlier
+// label. Labels are per-user; other participants never see them.
+func SetDMLabel(ctx context.Context, db *DB, userID, roomID, label string) error {
+	const sqlstr = `INSERT INTO dm_labels (user_id, room_id, label, updated_at) ` +
+		`VALUES ($1, $2, $3, $4) ` +
+		`ON CONFLICT (user_id, room_id) DO UPDATE SET ` +
+		`label = EXCLUDED.label, ` +
+		`updated_at = EXCLUDED.updated_at`
+	updatedAt := time.Now().Format(time.RFC3339)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID, label, updatedAt})
+	_, err := db.ExecContext(ctx, sqlstr, userID, roomID, label, updatedAt)
+	return err
+}
+
+// ClearDMLabel removes a user's label for a DM, reverting its display to
+// the derived name. Clearing a DM with no label is a no-op.
+func ClearDMLabel(ctx context.Context, db *DB, userID, roomID string) error {
+	const sqlstr = `DELETE FROM dm_labels ` +
+		`WHERE user_id = $1 AND room_id = $2`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID})
+	_, err := db.ExecContext(ctx, sqlstr, userID, roomID)
+	return err
+}
+
+// GetDMLabels returns a user's DM labels keyed by room ID
+func GetDMLabels(ctx context.Context, db *DB, userID string) (map[string]string, error) {
+	const sqlstr = `SELECT room_id, label FROM dm_labels ` +
+		`WHERE user_id = $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID})
+
+	rows, err := db.QueryContext(ctx, sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make(map[string]string)
+	for rows.Next() {
+		var roomID, label string
+		if err := rows.Scan(&roomID, &label); err != nil {
+			return nil, err
+		}
+		labels[roomID] = label
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
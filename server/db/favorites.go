@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetFavoriteRooms returns the IDs of the rooms a user has favorited, in
+// their chosen order
+func GetFavoriteRooms(ctx context.Context, db *DB, userID string) ([]string, error) {
+	const sqlstr = `SELECT room_id FROM favorite_rooms ` +
+		`WHERE user_id = $1 ` +
+		`ORDER BY position`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID})
+
+	rows, err := db.QueryContext(ctx, sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roomIDs []string
+	for rows.Next() {
+		var roomID string
+		if err := rows.Scan(&roomID); err != nil {
+			return nil, err
+		}
+		roomIDs = append(roomIDs, roomID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return roomIDs, nil
+}
+
+// AddFavoriteRoom appends a room to the end of a user's favorites. Returns
+// true if the room was added, false if it was already a favorite.
+func AddFavoriteRoom(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
+	const sqlstr = `INSERT INTO favorite_rooms (user_id, room_id, position) ` +
+		`VALUES ($1, $2, (SELECT COALESCE(MAX(position) + 1, 0) FROM favorite_rooms WHERE user_id = $1)) ` +
+		`ON CONFLICT (user_id, room_id) DO NOTHING`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID})
+
+	res, err := db.ExecContext(ctx, sqlstr, userID, roomID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RemoveFavoriteRoom removes a room from a user's favorites. Returns true
+// if the room was removed, false if it wasn't a favorite.
+func RemoveFavoriteRoom(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
+	const sqlstr = `DELETE FROM favorite_rooms ` +
+		`WHERE user_id = $1 AND room_id = $2`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID})
+
+	res, err := db.ExecContext(ctx, sqlstr, userID, roomID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ReorderFavoriteRooms rewrites the positions of a user's favorites to
+// match the order of roomIDs in a single transaction. Every room ID must
+// already be a favorite or the whole reorder is rolled back.
+func ReorderFavoriteRooms(ctx context.Context, db *DB, userID string, roomIDs []string) error {
+	const sqlstr = `UPDATE favorite_rooms SET position = $1 ` +
+		`WHERE user_id = $2 AND room_id = $3`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomIDs})
+
+	tx, err := db.WriteDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, roomID := range roomIDs {
+		res, err := tx.ExecContext(ctx, sqlstr, i, userID, roomID)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("room %s is not a favorite", roomID)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// HideDM marks a DM hidden from one user's sidebar. Returns true if the DM
+// was hidden, false if it was already hidden. The shared room and its
+// messages are untouched.
+func HideDM(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
+	const sqlstr = `INSERT INTO hidden_dms (user_id, room_id, created_at) ` +
+		`VALUES ($1, $2, $3) ` +
+		`ON CONFLICT (user_id, room_id) DO NOTHING`
+	createdAt := time.Now().Format(time.RFC3339)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID, createdAt})
+
+	res, err := db.ExecContext(ctx, sqlstr, userID, roomID, createdAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetHiddenDMs returns the set of DM room IDs a user has hidden from their
+// sidebar
+func GetHiddenDMs(ctx context.Context, db *DB, userID string) (map[string]bool, error) {
+	const sqlstr = `SELECT room_id FROM hidden_dms ` +
+		`WHERE user_id = $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID})
+
+	rows, err := db.QueryContext(ctx, sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hidden := make(map[string]bool)
+	for rows.Next() {
+		var roomID string
+		if err := rows.Scan(&roomID); err != nil {
+			return nil, err
+		}
+		hidden[roomID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return hidden, nil
+}
+
+// UnhideDMForRoom clears the hidden flag for every participant of a DM,
+// called when a new message arrives in it
+func UnhideDMForRoom(ctx context.Context, db *DB, roomID string) error {
+	const sqlstr = `DELETE FROM hidden_dms ` +
+		`WHERE room_id = $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID})
+	_, err := db.ExecContext(ctx, sqlstr, roomID)
+	return err
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// insertMessagesChunk is how many rows go into one multi-row INSERT. Nine
+// columns per row keeps a chunk comfortably under SQLite's default 999
+// bound-variable limit.
+const insertMessagesChunk = 100
+
+// InsertMessages inserts many messages in a single transaction using
+// multi-row statements, for bulk import and bursty bots where one
+// round-trip per row is too slow. Bodies are stored as given, so callers
+// encrypt them first when a cipher is configured; the FTS index is kept in
+// sync by the insert trigger. If any row fails the whole batch is rolled
+// back and the error names the offending chunk.
+func InsertMessages(ctx context.Context, db *DB, messages []*models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	db.logger.Debug("inserting message batch", "count", len(messages))
+
+	tx, err := db.WriteDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for start := 0; start < len(messages); start += insertMessagesChunk {
+		end := start + insertMessagesChunk
+		if end > len(messages) {
+			end = len(messages)
+		}
+		chunk := messages[start:end]
+
+		rows := make([]string, len(chunk))
+		args := make([]any, 0, len(chunk)*9)
+		for i, m := range chunk {
+			base := i * 9
+			rows[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9)
+			args = append(args, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.EncKeyID, m.IsSystem)
+		}
+
+		sqlstr := `INSERT INTO messages (` +
+			`id, room_id, user_id, body, created_at, modified_at, deleted_at, enc_key_id, is_system` +
+			`) VALUES ` + strings.Join(rows, ", ")
+		if _, err := tx.ExecContext(ctx, sqlstr, args...); err != nil {
+			return fmt.Errorf("inserting messages %d-%d of %d: %w", start+1, end, len(messages), err)
+		}
+	}
+
+	return tx.Commit()
+}
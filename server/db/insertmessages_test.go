@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// setupBatchTestDB creates a test database and a user and room for batch
+// message inserts
+func setupBatchTestDB(t *testing.T) (*DB, *models.User, *models.Room) {
+	t.Helper()
+	testDB := setupSearchTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:         "usr_batch1234567",
+		Username:   "batcher",
+		Password:   "hash",
+		LastRoom:   "roo_batch1234567",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := user.Insert(ctx, testDB); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	room := &models.Room{
+		ID:        "roo_batch1234567",
+		Name:      "batch",
+		RoomType:  "channel",
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := room.Insert(ctx, testDB); err != nil {
+		t.Fatalf("failed to insert room: %v", err)
+	}
+
+	// Search results are scoped to rooms the user is a member of
+	if _, err := testDB.ExecContext(ctx, "INSERT INTO rooms_members (user_id, room_id) VALUES ($1, $2)", user.ID, room.ID); err != nil {
+		t.Fatalf("failed to add user to room: %v", err)
+	}
+	return testDB, user, room
+}
+
+// countMessages returns the number of rows in messages
+func countMessages(t *testing.T, testDB *DB) int {
+	t.Helper()
+	var n int
+	row := testDB.QueryRowContext(context.Background(), "SELECT count(*) FROM messages")
+	if err := row.Scan(&n); err != nil {
+		t.Fatalf("failed to count messages: %v", err)
+	}
+	return n
+}
+
+func TestInsertMessages_PersistsAllRows(t *testing.T) {
+	testDB, user, room := setupBatchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+	now := time.Now().Format(time.RFC3339Nano)
+
+	// More rows than one chunk, to cover the chunk boundary
+	batch := make([]*models.Message, insertMessagesChunk+5)
+	for i := range batch {
+		batch[i] = &models.Message{
+			ID:         fmt.Sprintf("msg_batch%07d", i),
+			RoomID:     room.ID,
+			UserID:     user.ID,
+			Body:       fmt.Sprintf("imported xylophone message %d", i),
+			CreatedAt:  now,
+			ModifiedAt: now,
+		}
+	}
+
+	if err := InsertMessages(ctx, testDB, batch); err != nil {
+		t.Fatalf("InsertMessages failed: %v", err)
+	}
+
+	if n := countMessages(t, testDB); n != len(batch) {
+		t.Errorf("expected %d messages, got %d", len(batch), n)
+	}
+
+	// The insert trigger must have indexed the batch in FTS
+	results, _, err := testDB.SearchMessages(ctx, user.ID, "xylophone", "", "", "", 20, 0)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 20 {
+		t.Errorf("expected a full page of 20 FTS results, got %d", len(results))
+	}
+}
+
+func TestInsertMessages_RollsBackOnConstraintViolation(t *testing.T) {
+	testDB, user, room := setupBatchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+	now := time.Now().Format(time.RFC3339Nano)
+
+	// The last row duplicates the first row's primary key
+	batch := []*models.Message{
+		{ID: "msg_batchdupe001", RoomID: room.ID, UserID: user.ID, Body: "one", CreatedAt: now, ModifiedAt: now},
+		{ID: "msg_batchdupe002", RoomID: room.ID, UserID: user.ID, Body: "two", CreatedAt: now, ModifiedAt: now},
+		{ID: "msg_batchdupe001", RoomID: room.ID, UserID: user.ID, Body: "three", CreatedAt: now, ModifiedAt: now},
+	}
+
+	if err := InsertMessages(ctx, testDB, batch); err == nil {
+		t.Fatal("expected an error for a duplicate message ID")
+	}
+
+	// The whole batch rolls back, including the rows before the bad one
+	if n := countMessages(t, testDB); n != 0 {
+		t.Errorf("expected 0 messages after rollback, got %d", n)
+	}
+}
+
+func TestInsertMessages_EmptyBatch(t *testing.T) {
+	testDB, _, _ := setupBatchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	if err := InsertMessages(context.Background(), testDB, nil); err != nil {
+		t.Fatalf("expected an empty batch to be a no-op, got %v", err)
+	}
+}
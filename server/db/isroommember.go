@@ -4,8 +4,12 @@ import (
 	"context"
 )
 
-// IsRoomMember checks if a user is a member of a specific room.
+// IsRoomMember checks if a user is a member of a specific room. Results
+// are cached; membership mutations invalidate the affected pairs.
 func IsRoomMember(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
+	if isMember, ok := db.members.get(userID, roomID); ok {
+		return isMember, nil
+	}
 	const sqlstr = `SELECT EXISTS(` +
 		`SELECT 1 FROM rooms_members ` +
 		`WHERE user_id = $1 AND room_id = $2` +
@@ -15,5 +19,6 @@ func IsRoomMember(ctx context.Context, db *DB, userID, roomID string) (bool, err
 	if err := db.QueryRowContext(ctx, sqlstr, userID, roomID).Scan(&isMember); err != nil {
 		return false, err
 	}
+	db.members.put(userID, roomID, isMember)
 	return isMember, nil
 }
@@ -11,7 +11,7 @@ import (
 )
 
 // testDB creates a new in-memory database with the schema loaded
-func testDB(t *testing.T) *DB {
+func testDB(t testing.TB) *DB {
 	t.Helper()
 	dbPath := "file::memory:?cache=shared"
 	database, err := NewDB(dbPath, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
@@ -21,7 +21,19 @@ func testDB(t *testing.T) *DB {
 
 	// Drop existing tables to ensure a clean slate (in case other tests created different schemas)
 	dropSchema := `
+		DROP TABLE IF EXISTS messages_fts;
+		DROP TABLE IF EXISTS audit_log;
 		DROP TABLE IF EXISTS reactions;
+		DROP TABLE IF EXISTS pinned_messages;
+		DROP TABLE IF EXISTS favorite_rooms;
+		DROP TABLE IF EXISTS hidden_dms;
+		DROP TABLE IF EXISTS dm_labels;
+		DROP TABLE IF EXISTS message_reports;
+		DROP TABLE IF EXISTS dm_keys;
+		DROP TABLE IF EXISTS user_prefs;
+		DROP TABLE IF EXISTS starred_messages;
+		DROP TABLE IF EXISTS message_forwards;
+		DROP TABLE IF EXISTS room_reads;
 		DROP TABLE IF EXISTS messages;
 		DROP TABLE IF EXISTS rooms_members;
 		DROP TABLE IF EXISTS sessions;
@@ -46,10 +58,12 @@ func testDB(t *testing.T) *DB {
 			avatar TEXT,
 			last_room TEXT NOT NULL,
 			created_at TEXT NOT NULL,
-			modified_at TEXT NOT NULL
+			modified_at TEXT NOT NULL,
+			last_active_at TEXT NOT NULL DEFAULT ''
 		) STRICT;
 
 		CREATE UNIQUE INDEX IF NOT EXISTS users_username ON users(username);
+		CREATE UNIQUE INDEX IF NOT EXISTS users_username_nocase ON users(username COLLATE NOCASE);
 
 		CREATE TABLE IF NOT EXISTS rooms(
 			id TEXT PRIMARY KEY NOT NULL,
@@ -58,7 +72,20 @@ func testDB(t *testing.T) *DB {
 			is_private INTEGER NOT NULL,
 			is_default INTEGER NOT NULL,
 			created_at TEXT NOT NULL,
-			last_message_at TEXT
+			last_message_at TEXT,
+			slow_mode_seconds INTEGER NOT NULL DEFAULT 0,
+			retention_days INTEGER NOT NULL DEFAULT 0,
+			topic TEXT NOT NULL DEFAULT '',
+			join_policy TEXT NOT NULL DEFAULT 'open',
+			post_policy TEXT NOT NULL DEFAULT 'all',
+			announce_pins INTEGER NOT NULL DEFAULT 1
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS room_join_requests(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, room_id)
 		) STRICT;
 
 		CREATE UNIQUE INDEX IF NOT EXISTS rooms_name ON rooms(name) WHERE room_type = 'channel' AND name != '';
@@ -66,17 +93,135 @@ func testDB(t *testing.T) *DB {
 		CREATE TABLE IF NOT EXISTS rooms_members(
 			user_id TEXT REFERENCES users(id) NOT NULL,
 			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			role TEXT NOT NULL DEFAULT 'member',
+			muted INTEGER NOT NULL DEFAULT 0,
 			PRIMARY KEY (user_id, room_id)
 		) STRICT;
 
+		CREATE INDEX IF NOT EXISTS rooms_members_room ON rooms_members(room_id);
+
 		CREATE TABLE IF NOT EXISTS messages(
 			id TEXT PRIMARY KEY NOT NULL,
 			room_id TEXT REFERENCES rooms(id) NOT NULL,
 			user_id TEXT REFERENCES users(id) NOT NULL,
 			body TEXT NOT NULL,
+			seq INTEGER NOT NULL DEFAULT 0,
 			created_at TEXT NOT NULL,
 			modified_at TEXT NOT NULL,
-			deleted_at TEXT
+			deleted_at TEXT,
+			enc_key_id TEXT NOT NULL DEFAULT '',
+			is_system INTEGER NOT NULL DEFAULT 0
+		) STRICT;
+
+		CREATE TRIGGER IF NOT EXISTS messages_assign_seq AFTER INSERT ON messages
+		WHEN NEW.seq = 0
+		BEGIN
+			UPDATE messages SET seq = (SELECT IFNULL(MAX(seq), 0) + 1 FROM messages) WHERE id = NEW.id;
+		END;
+
+		CREATE TABLE IF NOT EXISTS room_reads(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			last_read_message_id TEXT REFERENCES messages(id) NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, room_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS message_forwards(
+			message_id TEXT PRIMARY KEY REFERENCES messages(id) NOT NULL,
+			source_message_id TEXT REFERENCES messages(id) NOT NULL,
+			source_room_id TEXT REFERENCES rooms(id) NOT NULL,
+			source_user_id TEXT REFERENCES users(id) NOT NULL
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS attachments(
+			id TEXT PRIMARY KEY NOT NULL,
+			message_id TEXT REFERENCES messages(id),
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			url TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			created_at TEXT NOT NULL,
+			thumbnail_url TEXT NOT NULL DEFAULT ''
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS message_quotes(
+			message_id TEXT PRIMARY KEY REFERENCES messages(id) NOT NULL,
+			quoted_message_id TEXT NOT NULL,
+			quoted_user_id TEXT NOT NULL,
+			quoted_username TEXT NOT NULL,
+			excerpt TEXT NOT NULL,
+			quoted_created_at TEXT NOT NULL
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS starred_messages(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			message_id TEXT REFERENCES messages(id) NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, message_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS pinned_messages(
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			message_id TEXT REFERENCES messages(id) NOT NULL,
+			pinned_by TEXT REFERENCES users(id) NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (room_id, message_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS favorite_rooms(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			position INTEGER NOT NULL,
+			PRIMARY KEY (user_id, room_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS hidden_dms(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, room_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS dm_labels(
+			user_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			label TEXT NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, room_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS message_reports(
+			message_id TEXT REFERENCES messages(id) NOT NULL,
+			reporter_id TEXT REFERENCES users(id) NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'open',
+			created_at TEXT NOT NULL,
+			resolved_by TEXT,
+			resolved_at TEXT,
+			PRIMARY KEY (message_id, reporter_id)
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS dm_keys(
+			dm_key TEXT PRIMARY KEY NOT NULL,
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			created_at TEXT NOT NULL
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS user_prefs(
+			user_id TEXT PRIMARY KEY REFERENCES users(id) NOT NULL,
+			prefs TEXT NOT NULL,
+			modified_at TEXT NOT NULL
+		) STRICT;
+
+		CREATE TABLE IF NOT EXISTS room_filters(
+			room_id TEXT REFERENCES rooms(id) NOT NULL,
+			pattern TEXT NOT NULL,
+			action TEXT NOT NULL DEFAULT 'reject',
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (room_id, pattern)
 		) STRICT;
 
 		CREATE TABLE IF NOT EXISTS reactions(
@@ -89,6 +234,17 @@ func testDB(t *testing.T) *DB {
 
 		CREATE INDEX IF NOT EXISTS reactions_message ON reactions(message_id);
 
+		CREATE TABLE IF NOT EXISTS audit_log(
+			id INTEGER PRIMARY KEY,
+			actor_id TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target_id TEXT NOT NULL DEFAULT '',
+			room_id TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL
+		) STRICT;
+
+		CREATE INDEX IF NOT EXISTS messages_room_seq ON messages(room_id, seq DESC);
+
 		CREATE INDEX IF NOT EXISTS messages_room_created ON messages(room_id, created_at DESC);
 	`
 	_, err = database.ExecContext(context.Background(), schema)
@@ -96,11 +252,39 @@ func testDB(t *testing.T) *DB {
 		t.Fatalf("Failed to create schema: %v", err)
 	}
 
+	// The FTS index and its sync triggers exist only when the SQLite build
+	// includes FTS5, matching how RunSQLFile treats schema.sql
+	if database.ftsAvailable {
+		ftsSchema := `
+			CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+				body,
+				content='messages',
+				content_rowid='rowid'
+			);
+
+			CREATE TRIGGER IF NOT EXISTS messages_fts_insert AFTER INSERT ON messages BEGIN
+				INSERT INTO messages_fts(rowid, body) VALUES (NEW.rowid, NEW.body);
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS messages_fts_update AFTER UPDATE ON messages BEGIN
+				INSERT INTO messages_fts(messages_fts, rowid, body) VALUES('delete', OLD.rowid, OLD.body);
+				INSERT INTO messages_fts(rowid, body) VALUES (NEW.rowid, NEW.body);
+			END;
+
+			CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON messages BEGIN
+				INSERT INTO messages_fts(messages_fts, rowid, body) VALUES('delete', OLD.rowid, OLD.body);
+			END;
+		`
+		if _, err := database.ExecContext(context.Background(), ftsSchema); err != nil {
+			t.Fatalf("Failed to create FTS schema: %v", err)
+		}
+	}
+
 	return database
 }
 
 // createTestUser creates a user in the database for testing
-func createTestUser(t *testing.T, database *DB, id, username string) *models.User {
+func createTestUser(t testing.TB, database *DB, id, username string) *models.User {
 	t.Helper()
 	now := time.Now().Format(time.RFC3339)
 	user := &models.User{
@@ -121,7 +305,7 @@ func createTestUser(t *testing.T, database *DB, id, username string) *models.Use
 }
 
 // createTestRoom creates a room in the database for testing
-func createTestRoom(t *testing.T, database *DB, id, name string, isDefault bool) *models.Room {
+func createTestRoom(t testing.TB, database *DB, id, name string, isDefault bool) *models.Room {
 	t.Helper()
 	now := time.Now().Format(time.RFC3339)
 	isDefaultInt := models.FALSE
@@ -144,7 +328,7 @@ func createTestRoom(t *testing.T, database *DB, id, name string, isDefault bool)
 }
 
 // createTestRoomWithPrivate creates a room in the database for testing with explicit private flag
-func createTestRoomWithPrivate(t *testing.T, database *DB, id, name string, isDefault, isPrivate bool) *models.Room {
+func createTestRoomWithPrivate(t testing.TB, database *DB, id, name string, isDefault, isPrivate bool) *models.Room {
 	t.Helper()
 	now := time.Now().Format(time.RFC3339)
 	isDefaultInt := models.FALSE
@@ -171,16 +355,19 @@ func createTestRoomWithPrivate(t *testing.T, database *DB, id, name string, isDe
 }
 
 // addUserToRoom adds a user to a room
-func addUserToRoom(t *testing.T, database *DB, userID, roomID string) {
+func addUserToRoom(t testing.TB, database *DB, userID, roomID string) {
 	t.Helper()
 	membership := &models.RoomsMember{
 		UserID: userID,
 		RoomID: roomID,
+		Role:   RoleMember,
 	}
 	err := membership.Insert(context.Background(), database)
 	if err != nil {
 		t.Fatalf("Failed to add user to room: %v", err)
 	}
+	// direct inserts bypass the invalidation done by AddRoomMember
+	database.InvalidateMembership(userID, roomID)
 }
 
 // TestIsRoomMember_UserIsMember tests that IsRoomMember returns true for members
@@ -348,6 +535,8 @@ func TestIsRoomMember_AfterRemoval(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to remove membership: %v", err)
 	}
+	// direct deletes bypass the invalidation done by LeaveRoom
+	database.InvalidateMembership(user.ID, room.ID)
 
 	// Verify no longer a member
 	isMember, err = IsRoomMember(context.Background(), database, user.ID, room.ID)
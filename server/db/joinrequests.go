@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Join policy values for the rooms.join_policy column
+const (
+	JoinPolicyOpen    = "open"    // anyone may join
+	JoinPolicyRequest = "request" // joining requires approval by an owner or admin
+	JoinPolicyInvite  = "invite"  // members must be added by an existing member
+)
+
+// JoinRequest is one pending request to join a room, with the requesting
+// user's name fields for display.
+type JoinRequest struct {
+	UserID      string
+	Username    string
+	DisplayName string
+	CreatedAt   string
+}
+
+// InsertJoinRequest records a pending request by a user to join a room.
+// Returns true if the request was recorded, false if one was already
+// pending.
+func InsertJoinRequest(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
+	pending, err := HasJoinRequest(ctx, db, userID, roomID)
+	if err != nil {
+		return false, err
+	}
+	if pending {
+		return false, nil
+	}
+
+	const sqlstr = `INSERT INTO room_join_requests (user_id, room_id, created_at) ` +
+		`VALUES ($1, $2, $3)`
+	createdAt := time.Now().Format(time.RFC3339)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID, createdAt})
+	if _, err := db.ExecContext(ctx, sqlstr, userID, roomID, createdAt); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// HasJoinRequest reports whether a user has a pending request to join a
+// room.
+func HasJoinRequest(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
+	const sqlstr = `SELECT EXISTS(` +
+		`SELECT 1 FROM room_join_requests WHERE user_id = $1 AND room_id = $2` +
+		`) AS pending`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID})
+	var pending bool
+	if err := db.QueryRowContext(ctx, sqlstr, userID, roomID).Scan(&pending); err != nil {
+		return false, err
+	}
+	return pending, nil
+}
+
+// DeleteJoinRequest removes a user's pending request to join a room.
+// Returns true if a request was removed, false if none was pending.
+func DeleteJoinRequest(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
+	const sqlstr = `DELETE FROM room_join_requests ` +
+		`WHERE user_id = $1 AND room_id = $2`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID})
+	res, err := db.ExecContext(ctx, sqlstr, userID, roomID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ListJoinRequests returns a room's pending join requests, oldest first.
+func ListJoinRequests(ctx context.Context, db *DB, roomID string) ([]JoinRequest, error) {
+	const sqlstr = `SELECT r.user_id, u.username, u.display_name, r.created_at ` +
+		`FROM room_join_requests r ` +
+		`JOIN users u ON u.id = r.user_id ` +
+		`WHERE r.room_id = $1 ` +
+		`ORDER BY r.created_at, r.user_id`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID})
+	rows, err := db.QueryContext(ctx, sqlstr, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []JoinRequest
+	for rows.Next() {
+		var r JoinRequest
+		if err := rows.Scan(&r.UserID, &r.Username, &r.DisplayName, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+	return requests, rows.Err()
+}
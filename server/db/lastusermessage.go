@@ -0,0 +1,20 @@
+package db
+
+import (
+	"context"
+)
+
+// GetLastUserMessageTime returns the created_at timestamp of the most recent
+// message a user posted in a room, or an empty string if they have not posted.
+// Soft-deleted messages still count: deleting a post doesn't reset slow mode.
+func GetLastUserMessageTime(ctx context.Context, db *DB, userID, roomID string) (string, error) {
+	const sqlstr = `SELECT COALESCE(MAX(created_at), '') AS created_at ` +
+		`FROM messages ` +
+		`WHERE user_id = $1 AND room_id = $2`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID})
+	var createdAt string
+	if err := db.QueryRowContext(ctx, sqlstr, userID, roomID).Scan(&createdAt); err != nil {
+		return "", err
+	}
+	return createdAt, nil
+}
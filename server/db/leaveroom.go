@@ -2,13 +2,20 @@ package db
 
 import (
 	"context"
+	"errors"
 
 	"github.com/llimllib/hatchat/server/models"
 )
 
+// ErrDefaultRoom is returned by LeaveRoom and DeleteRoom for rooms flagged
+// is_default: every user is a member of the default room, so it can be
+// neither left nor deleted. The guard lives here so every caller — websocket
+// handlers, REST handlers, or anything added later — refuses consistently.
+var ErrDefaultRoom = errors.New("the default room cannot be left or deleted")
+
 // LeaveRoom removes a user from a room's membership.
 // Returns true if the user was removed, false if they weren't a member.
-// This will not remove a user from the default room.
+// Leaving the default room returns ErrDefaultRoom.
 func LeaveRoom(ctx context.Context, db *DB, userID, roomID string) (bool, error) {
 	// Check if they are a member
 	member, err := models.RoomsMemberByUserIDRoomID(ctx, db, userID, roomID)
@@ -23,13 +30,20 @@ func LeaveRoom(ctx context.Context, db *DB, userID, roomID string) (bool, error)
 		return false, err
 	}
 	if room.IsDefault != 0 {
-		return false, nil
+		return false, ErrDefaultRoom
 	}
 
 	// Delete the membership
 	if err := member.Delete(ctx, db); err != nil {
 		return false, err
 	}
+	db.members.invalidate(userID, roomID)
+
+	// Leaving a room also drops it from the user's favorites
+	const unfavorite = `DELETE FROM favorite_rooms WHERE user_id = $1 AND room_id = $2`
+	if _, err := db.ExecContext(ctx, unfavorite, userID, roomID); err != nil {
+		return false, err
+	}
 
 	return true, nil
 }
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/llimllib/hatchat/server/models"
@@ -75,8 +76,8 @@ func TestLeaveRoom_DefaultRoom(t *testing.T) {
 	addUserToRoom(t, database, user.ID, room.ID)
 
 	left, err := LeaveRoom(ctx, database, user.ID, room.ID)
-	if err != nil {
-		t.Fatalf("LeaveRoom failed: %v", err)
+	if !errors.Is(err, ErrDefaultRoom) {
+		t.Fatalf("LeaveRoom should return ErrDefaultRoom for the default room, got %v", err)
 	}
 	if left {
 		t.Error("LeaveRoom should return false for default room")
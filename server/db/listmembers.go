@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+)
+
+// Bounds on list_members page sizes
+const (
+	defaultListMembersLimit = 100
+	maxListMembersLimit     = 500
+)
+
+// MemberListing is one room member in a paginated member list, with the
+// last-active time the api layer derives presence from.
+type MemberListing struct {
+	RoomMember
+	LastActiveAt string
+}
+
+// ListRoomMembers returns one page of a room's members sorted by username,
+// using the previous page's last username as the keyset cursor. At most
+// limit members come back (clamped to maxListMembersLimit; 0 means the
+// default); the second return value is the cursor for the next page, empty
+// on the last page.
+func ListRoomMembers(ctx context.Context, db *DB, roomID, cursor string, limit int) ([]MemberListing, string, error) {
+	if limit <= 0 {
+		limit = defaultListMembersLimit
+	}
+	if limit > maxListMembersLimit {
+		limit = maxListMembersLimit
+	}
+
+	const sqlstr = `SELECT u.id, u.username, u.display_name, COALESCE(u.avatar, '') AS avatar, u.last_active_at ` +
+		`FROM users u ` +
+		`JOIN rooms_members rm ON rm.user_id = u.id ` +
+		`WHERE rm.room_id = $1 AND u.username > $2 ` +
+		`ORDER BY u.username ` +
+		`LIMIT $3`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, cursor, limit + 1})
+	rows, err := db.QueryContext(ctx, sqlstr, roomID, cursor, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var members []MemberListing
+	for rows.Next() {
+		var m MemberListing
+		if err := rows.Scan(&m.ID, &m.Username, &m.DisplayName, &m.Avatar, &m.LastActiveAt); err != nil {
+			return nil, "", err
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	// The extra row only tells us another page exists; it isn't returned
+	nextCursor := ""
+	if len(members) > limit {
+		members = members[:limit]
+		nextCursor = members[len(members)-1].Username
+	}
+	return members, nextCursor, nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reactor is one user's reaction to a message, with the created_at that
+// serves as the pagination cursor.
+type Reactor struct {
+	UserID    string
+	CreatedAt string
+}
+
+// ListReactors pages through the users who reacted to a message with an
+// emoji, in reaction order. Pass the previous page's last CreatedAt as cursor
+// to fetch the next page, or an empty cursor for the first.
+func ListReactors(ctx context.Context, db *DB, messageID, emoji, cursor string, limit int) ([]Reactor, error) {
+	sqlstr := `SELECT user_id, created_at FROM reactions ` +
+		`WHERE message_id = $1 AND emoji = $2`
+	args := []any{messageID, emoji}
+	if cursor != "" {
+		sqlstr += ` AND created_at > $3`
+		args = append(args, cursor)
+	}
+	sqlstr += fmt.Sprintf(` ORDER BY created_at, user_id LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
+
+	db.logger.Debug("querying", "query", sqlstr, "args", args)
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reactors []Reactor
+	for rows.Next() {
+		var r Reactor
+		if err := rows.Scan(&r.UserID, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		reactors = append(reactors, r)
+	}
+	return reactors, rows.Err()
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+func TestListReactors_Pages(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	author := createTestUser(t, database, "usr_lr_author_01", "author")
+	room := createTestRoom(t, database, "roo_lr_room_001", "general", false)
+	msg := createTestMessageForReactions(t, database, "msg_lr_msg_0001", room.ID, author.ID, "popular")
+
+	// Five reactors with distinct timestamps so the cursor is unambiguous
+	base := time.Now()
+	var want []string
+	for i := 0; i < 5; i++ {
+		user := createTestUser(t, database, fmt.Sprintf("usr_lr_react_%03d", i), fmt.Sprintf("reactor%d", i))
+		r := models.Reaction{
+			MessageID: msg.ID,
+			UserID:    user.ID,
+			Emoji:     "👍",
+			CreatedAt: base.Add(time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+		}
+		if err := r.Insert(ctx, database); err != nil {
+			t.Fatalf("Failed to insert reaction: %v", err)
+		}
+		want = append(want, user.ID)
+	}
+
+	// Page through two at a time
+	var got []string
+	cursor := ""
+	for {
+		reactors, err := ListReactors(ctx, database, msg.ID, "👍", cursor, 2)
+		if err != nil {
+			t.Fatalf("ListReactors failed: %v", err)
+		}
+		if len(reactors) == 0 {
+			break
+		}
+		for _, r := range reactors {
+			got = append(got, r.UserID)
+		}
+		cursor = reactors[len(reactors)-1].CreatedAt
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d reactors, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected reactor %d to be %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestListReactors_FiltersEmoji(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user := createTestUser(t, database, "usr_lr_emoji_01", "emojiuser")
+	room := createTestRoom(t, database, "roo_lr_room_002", "general", false)
+	msg := createTestMessageForReactions(t, database, "msg_lr_msg_0002", room.ID, user.ID, "mixed")
+
+	now := time.Now().Format(time.RFC3339Nano)
+	for _, emoji := range []string{"👍", "❤️"} {
+		r := models.Reaction{MessageID: msg.ID, UserID: user.ID, Emoji: emoji, CreatedAt: now}
+		if err := r.Insert(ctx, database); err != nil {
+			t.Fatalf("Failed to insert reaction: %v", err)
+		}
+	}
+
+	reactors, err := ListReactors(ctx, database, msg.ID, "👍", "", 10)
+	if err != nil {
+		t.Fatalf("ListReactors failed: %v", err)
+	}
+	if len(reactors) != 1 {
+		t.Errorf("expected 1 reactor for 👍, got %d", len(reactors))
+	}
+}
@@ -2,10 +2,18 @@ package db
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/llimllib/hatchat/server/models"
 )
 
+// Page size bounds for public room discovery; an instance with thousands of
+// public channels shouldn't return them all in one payload
+const (
+	defaultListRoomsLimit = 100
+	maxListRoomsLimit     = 500
+)
+
 // ListPublicRooms returns all public (non-private) channel rooms (not DMs).
 func ListPublicRooms(ctx context.Context, db *DB) ([]*models.Room, error) {
 	const sqlstr = `SELECT ` +
@@ -36,54 +44,132 @@ func ListPublicRooms(ctx context.Context, db *DB) ([]*models.Room, error) {
 	return rooms, nil
 }
 
-// ListPublicRoomsWithMembership returns all public channel rooms along with whether the user is a member.
-// If query is non-empty, it filters rooms by name (case-insensitive contains match).
-// DMs are excluded from this list.
-func ListPublicRoomsWithMembership(ctx context.Context, db *DB, userID string, query string) ([]*models.Room, []bool, error) {
-	var sqlstr string
-	var args []any
-
-	if query == "" {
-		sqlstr = `SELECT ` +
-			`r.id, r.name, r.room_type, r.is_private, r.is_default, r.created_at, r.last_message_at, ` +
-			`CASE WHEN rm.user_id IS NOT NULL THEN 1 ELSE 0 END AS is_member ` +
-			`FROM rooms r ` +
-			`LEFT JOIN rooms_members rm ON r.id = rm.room_id AND rm.user_id = $1 ` +
-			`WHERE r.is_private = 0 AND r.room_type = 'channel' ` +
-			`ORDER BY r.name ASC`
-		args = []any{userID}
-	} else {
-		sqlstr = `SELECT ` +
-			`r.id, r.name, r.room_type, r.is_private, r.is_default, r.created_at, r.last_message_at, ` +
-			`CASE WHEN rm.user_id IS NOT NULL THEN 1 ELSE 0 END AS is_member ` +
-			`FROM rooms r ` +
-			`LEFT JOIN rooms_members rm ON r.id = rm.room_id AND rm.user_id = $1 ` +
-			`WHERE r.is_private = 0 AND r.room_type = 'channel' AND r.name LIKE '%' || $2 || '%' COLLATE NOCASE ` +
-			`ORDER BY r.name ASC`
-		args = []any{userID, query}
+// ListChannelRooms returns every channel room, public and private alike,
+// ordered by name. It backs server-wide fan-outs like admin announcements,
+// which must reach private rooms too; DMs are excluded.
+func ListChannelRooms(ctx context.Context, db *DB) ([]*models.Room, error) {
+	const sqlstr = `SELECT ` +
+		`id, name, room_type, is_private, is_default, created_at, last_message_at ` +
+		`FROM rooms ` +
+		`WHERE room_type = 'channel' ` +
+		`ORDER BY name ASC`
+	db.logger.Debug("querying", "query", sqlstr)
+
+	rows, err := db.QueryContext(ctx, sqlstr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []*models.Room
+	for rows.Next() {
+		r := &models.Room{}
+		if err := rows.Scan(&r.ID, &r.Name, &r.RoomType, &r.IsPrivate, &r.IsDefault, &r.CreatedAt, &r.LastMessageAt); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+// RoomListing is a public room annotated with discovery metadata: whether the
+// user is a member, how many members it has, and when it was last active.
+type RoomListing struct {
+	Room         *models.Room
+	IsMember     bool
+	MemberCount  int
+	LastActivity string
+}
+
+// ListPublicRoomsWithMembership returns a page of public channel rooms along
+// with membership, member counts, and last-activity timestamps for the user.
+// Membership comes from a single join against the user's rooms rather than a
+// per-room check. If query is non-empty, it filters rooms by name
+// (case-insensitive contains match). sortBy may be "name" (default),
+// "members" (largest first), or "activity" (most recently active first). DMs
+// are excluded from this list.
+//
+// At most limit rooms come back (clamped to maxListRoomsLimit; 0 means the
+// default page size). The second return value is a keyset cursor over the
+// name ordering: pass it back as cursor to fetch the next page, empty when
+// there are no more rooms. The other sort orders ignore the cursor.
+func ListPublicRoomsWithMembership(ctx context.Context, db *DB, userID, query, sortBy, cursor string, limit int) ([]*RoomListing, string, error) {
+	if limit <= 0 {
+		limit = defaultListRoomsLimit
+	}
+	if limit > maxListRoomsLimit {
+		limit = maxListRoomsLimit
+	}
+	byName := sortBy == "" || sortBy == "name"
+
+	sqlstr := `SELECT ` +
+		`r.id, r.name, r.room_type, r.is_private, r.is_default, r.created_at, r.last_message_at, ` +
+		`CASE WHEN rm.user_id IS NOT NULL THEN 1 ELSE 0 END AS is_member, ` +
+		`(SELECT COUNT(*) FROM rooms_members mc WHERE mc.room_id = r.id) AS member_count, ` +
+		`COALESCE(r.last_message_at, r.created_at) AS last_activity ` +
+		`FROM rooms r ` +
+		`LEFT JOIN rooms_members rm ON r.id = rm.room_id AND rm.user_id = $1 ` +
+		`WHERE r.is_private = 0 AND r.room_type = 'channel' `
+	args := []any{userID}
+
+	if query != "" {
+		sqlstr += fmt.Sprintf(`AND r.name LIKE '%%' || $%d || '%%' COLLATE NOCASE `, len(args)+1)
+		args = append(args, query)
+	}
+
+	// Resume after the last name of the previous page; a keyset predicate
+	// only makes sense under the name ordering
+	if cursor != "" && byName {
+		sqlstr += fmt.Sprintf(`AND r.name > $%d `, len(args)+1)
+		args = append(args, cursor)
+	}
+
+	switch sortBy {
+	case "members":
+		sqlstr += `ORDER BY member_count DESC, r.name ASC`
+	case "activity":
+		sqlstr += `ORDER BY last_activity DESC, r.name ASC`
+	default:
+		sqlstr += `ORDER BY r.name ASC`
 	}
 
+	// Fetch one extra row to learn whether another page exists
+	sqlstr += fmt.Sprintf(` LIMIT $%d`, len(args)+1)
+	args = append(args, limit+1)
+
 	rows, err := db.QueryContext(ctx, sqlstr, args...)
 	if err != nil {
-		return nil, nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
-	var rooms []*models.Room
-	var membership []bool
+	var listings []*RoomListing
 	for rows.Next() {
 		r := &models.Room{}
 		var isMember int
-		if err := rows.Scan(&r.ID, &r.Name, &r.RoomType, &r.IsPrivate, &r.IsDefault, &r.CreatedAt, &r.LastMessageAt, &isMember); err != nil {
-			return nil, nil, err
+		l := &RoomListing{Room: r}
+		if err := rows.Scan(&r.ID, &r.Name, &r.RoomType, &r.IsPrivate, &r.IsDefault, &r.CreatedAt, &r.LastMessageAt, &isMember, &l.MemberCount, &l.LastActivity); err != nil {
+			return nil, "", err
 		}
-		rooms = append(rooms, r)
-		membership = append(membership, isMember == 1)
+		l.IsMember = isMember == 1
+		listings = append(listings, l)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, nil, err
+		return nil, "", err
 	}
 
-	return rooms, membership, nil
+	nextCursor := ""
+	if len(listings) > limit {
+		listings = listings[:limit]
+		if byName {
+			nextCursor = listings[len(listings)-1].Room.Name
+		}
+	}
+	return listings, nextCursor, nil
 }
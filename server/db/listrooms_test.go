@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"testing"
 )
 
@@ -77,15 +78,12 @@ func TestListPublicRoomsWithMembership_Empty(t *testing.T) {
 
 	user := createTestUser(t, database, "usr_test123456789", "testuser")
 
-	rooms, membership, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "")
+	listings, _, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "", "", "", 0)
 	if err != nil {
 		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
 	}
-	if len(rooms) != 0 {
-		t.Errorf("Expected 0 rooms, got %d", len(rooms))
-	}
-	if len(membership) != 0 {
-		t.Errorf("Expected 0 membership flags, got %d", len(membership))
+	if len(listings) != 0 {
+		t.Errorf("Expected 0 rooms, got %d", len(listings))
 	}
 }
 
@@ -102,26 +100,23 @@ func TestListPublicRoomsWithMembership_WithMembership(t *testing.T) {
 	room2 := createTestRoom(t, database, "roo_bbbbbbbbbbbb", "beta-channel", false)
 	addUserToRoom(t, database, user.ID, room1.ID)
 
-	rooms, membership, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "")
+	listings, _, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "", "", "", 0)
 	if err != nil {
 		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
 	}
-	if len(rooms) != 2 {
-		t.Errorf("Expected 2 rooms, got %d", len(rooms))
-	}
-	if len(membership) != 2 {
-		t.Errorf("Expected 2 membership flags, got %d", len(membership))
+	if len(listings) != 2 {
+		t.Errorf("Expected 2 rooms, got %d", len(listings))
 	}
 
 	// Rooms are ordered by name (alpha, beta)
-	for i, room := range rooms {
-		switch room.ID {
+	for _, l := range listings {
+		switch l.Room.ID {
 		case room1.ID:
-			if !membership[i] {
+			if !l.IsMember {
 				t.Error("Expected user to be a member of room1")
 			}
 		case room2.ID:
-			if membership[i] {
+			if l.IsMember {
 				t.Error("Expected user to NOT be a member of room2")
 			}
 		}
@@ -142,39 +137,39 @@ func TestListPublicRoomsWithMembership_SearchFilter(t *testing.T) {
 	createTestRoom(t, database, "roo_cccccccccccc", "random", false)
 
 	// Search for "general" should find 2 rooms
-	rooms, _, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "general")
+	listings, _, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "general", "", "", 0)
 	if err != nil {
 		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
 	}
-	if len(rooms) != 2 {
-		t.Errorf("Expected 2 rooms matching 'general', got %d", len(rooms))
+	if len(listings) != 2 {
+		t.Errorf("Expected 2 rooms matching 'general', got %d", len(listings))
 	}
 
 	// Search for "random" should find 1 room
-	rooms, _, err = ListPublicRoomsWithMembership(ctx, database, user.ID, "random")
+	listings, _, err = ListPublicRoomsWithMembership(ctx, database, user.ID, "random", "", "", 0)
 	if err != nil {
 		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
 	}
-	if len(rooms) != 1 {
-		t.Errorf("Expected 1 room matching 'random', got %d", len(rooms))
+	if len(listings) != 1 {
+		t.Errorf("Expected 1 room matching 'random', got %d", len(listings))
 	}
 
 	// Search for "nonexistent" should find 0 rooms
-	rooms, _, err = ListPublicRoomsWithMembership(ctx, database, user.ID, "nonexistent")
+	listings, _, err = ListPublicRoomsWithMembership(ctx, database, user.ID, "nonexistent", "", "", 0)
 	if err != nil {
 		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
 	}
-	if len(rooms) != 0 {
-		t.Errorf("Expected 0 rooms matching 'nonexistent', got %d", len(rooms))
+	if len(listings) != 0 {
+		t.Errorf("Expected 0 rooms matching 'nonexistent', got %d", len(listings))
 	}
 
 	// Empty search should find all rooms
-	rooms, _, err = ListPublicRoomsWithMembership(ctx, database, user.ID, "")
+	listings, _, err = ListPublicRoomsWithMembership(ctx, database, user.ID, "", "", "", 0)
 	if err != nil {
 		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
 	}
-	if len(rooms) != 3 {
-		t.Errorf("Expected 3 rooms with empty search, got %d", len(rooms))
+	if len(listings) != 3 {
+		t.Errorf("Expected 3 rooms with empty search, got %d", len(listings))
 	}
 }
 
@@ -194,21 +189,254 @@ func TestListPublicRoomsWithMembership_OnlyPublic(t *testing.T) {
 	addUserToRoom(t, database, user.ID, publicRoom.ID)
 	addUserToRoom(t, database, user.ID, privateRoom.ID)
 
-	rooms, membership, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "")
+	listings, _, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "", "", "", 0)
 	if err != nil {
 		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
 	}
 
 	// Only public room should be listed
-	if len(rooms) != 1 {
-		t.Errorf("Expected 1 room, got %d", len(rooms))
+	if len(listings) != 1 {
+		t.Errorf("Expected 1 room, got %d", len(listings))
 	}
-	if len(rooms) > 0 {
-		if rooms[0].ID != publicRoom.ID {
-			t.Errorf("Expected room ID %s, got %s", publicRoom.ID, rooms[0].ID)
+	if len(listings) > 0 {
+		if listings[0].Room.ID != publicRoom.ID {
+			t.Errorf("Expected room ID %s, got %s", publicRoom.ID, listings[0].Room.ID)
 		}
-		if !membership[0] {
+		if !listings[0].IsMember {
 			t.Error("Expected user to be a member of the public room")
 		}
 	}
 }
+
+func TestListPublicRoomsWithMembership_SortByMembers(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user1 := createTestUser(t, database, "usr_test123456789", "user1")
+	user2 := createTestUser(t, database, "usr_test234567891", "user2")
+	user3 := createTestUser(t, database, "usr_test345678912", "user3")
+
+	// "small" has one member, "big" has three, "empty" has none
+	small := createTestRoom(t, database, "roo_small1234567", "small", false)
+	big := createTestRoom(t, database, "roo_big123456789", "big", false)
+	createTestRoom(t, database, "roo_empty1234567", "empty", false)
+	addUserToRoom(t, database, user1.ID, small.ID)
+	addUserToRoom(t, database, user1.ID, big.ID)
+	addUserToRoom(t, database, user2.ID, big.ID)
+	addUserToRoom(t, database, user3.ID, big.ID)
+
+	listings, _, err := ListPublicRoomsWithMembership(ctx, database, user1.ID, "", "members", "", 0)
+	if err != nil {
+		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
+	}
+	if len(listings) != 3 {
+		t.Fatalf("Expected 3 rooms, got %d", len(listings))
+	}
+
+	expectedOrder := []string{"big", "small", "empty"}
+	expectedCounts := []int{3, 1, 0}
+	for i := range expectedOrder {
+		if listings[i].Room.Name != expectedOrder[i] {
+			t.Errorf("Expected room %d to be '%s', got '%s'", i, expectedOrder[i], listings[i].Room.Name)
+		}
+		if listings[i].MemberCount != expectedCounts[i] {
+			t.Errorf("Expected room %d to have %d members, got %d", i, expectedCounts[i], listings[i].MemberCount)
+		}
+	}
+}
+
+func TestListPublicRoomsWithMembership_SortByActivity(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+
+	// "busy" has recent activity, "sleepy" older activity, "silent" none
+	busy := createTestRoom(t, database, "roo_busy12345678", "busy", false)
+	sleepy := createTestRoom(t, database, "roo_sleepy123456", "sleepy", false)
+	createTestRoom(t, database, "roo_silent123456", "silent", false)
+
+	setLastMessage := func(roomID, at string) {
+		if _, err := database.ExecContext(ctx, "UPDATE rooms SET last_message_at = $1, created_at = '2024-01-01T00:00:00Z' WHERE id = $2", at, roomID); err != nil {
+			t.Fatalf("Failed to set last_message_at: %v", err)
+		}
+	}
+	setLastMessage(busy.ID, "2024-06-01T12:00:00Z")
+	setLastMessage(sleepy.ID, "2024-03-01T12:00:00Z")
+	if _, err := database.ExecContext(ctx, "UPDATE rooms SET created_at = '2024-01-01T00:00:00Z' WHERE name = 'silent'"); err != nil {
+		t.Fatalf("Failed to set created_at: %v", err)
+	}
+
+	listings, _, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "", "activity", "", 0)
+	if err != nil {
+		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
+	}
+	if len(listings) != 3 {
+		t.Fatalf("Expected 3 rooms, got %d", len(listings))
+	}
+
+	expectedOrder := []string{"busy", "sleepy", "silent"}
+	for i := range expectedOrder {
+		if listings[i].Room.Name != expectedOrder[i] {
+			t.Errorf("Expected room %d to be '%s', got '%s'", i, expectedOrder[i], listings[i].Room.Name)
+		}
+	}
+	if listings[0].LastActivity != "2024-06-01T12:00:00Z" {
+		t.Errorf("Expected busy room last_activity '2024-06-01T12:00:00Z', got '%s'", listings[0].LastActivity)
+	}
+	// A room with no messages falls back to its creation time
+	if listings[2].LastActivity != "2024-01-01T00:00:00Z" {
+		t.Errorf("Expected silent room last_activity '2024-01-01T00:00:00Z', got '%s'", listings[2].LastActivity)
+	}
+}
+
+func TestListPublicRoomsWithMembership_Pagination(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	names := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	for i, name := range names {
+		createTestRoom(t, database, fmt.Sprintf("roo_page%08d", i), name, false)
+	}
+
+	// Walk the list two rooms at a time and check the pages stitch back
+	// together into the full name-ordered list
+	var paged []string
+	cursor := ""
+	pages := 0
+	for {
+		listings, next, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "", "", cursor, 2)
+		if err != nil {
+			t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
+		}
+		if len(listings) > 2 {
+			t.Fatalf("Expected at most 2 rooms per page, got %d", len(listings))
+		}
+		for _, l := range listings {
+			paged = append(paged, l.Room.Name)
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if pages != 3 {
+		t.Errorf("Expected 3 pages of 5 rooms at limit 2, got %d", pages)
+	}
+	expected := []string{"alpha", "beta", "delta", "epsilon", "gamma"}
+	if len(paged) != len(expected) {
+		t.Fatalf("Expected %d rooms across pages, got %d: %v", len(expected), len(paged), paged)
+	}
+	for i, name := range expected {
+		if paged[i] != name {
+			t.Errorf("Expected room %d to be '%s', got '%s'", i, name, paged[i])
+		}
+	}
+}
+
+func TestListPublicRoomsWithMembership_MatchesPerRoomChecks(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	for i := 0; i < 6; i++ {
+		room := createTestRoom(t, database, fmt.Sprintf("roo_memb%08d", i), fmt.Sprintf("room-%d", i), false)
+		// Membership on every other room
+		if i%2 == 0 {
+			addUserToRoom(t, database, user.ID, room.ID)
+		}
+	}
+
+	listings, _, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "", "", "", 0)
+	if err != nil {
+		t.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
+	}
+	if len(listings) != 6 {
+		t.Fatalf("Expected 6 rooms, got %d", len(listings))
+	}
+
+	// The joined membership column must agree with a per-room check
+	for _, l := range listings {
+		isMember, err := IsRoomMember(ctx, database, user.ID, l.Room.ID)
+		if err != nil {
+			t.Fatalf("IsRoomMember failed: %v", err)
+		}
+		if l.IsMember != isMember {
+			t.Errorf("Room %s: batched membership %v disagrees with per-room check %v", l.Room.ID, l.IsMember, isMember)
+		}
+	}
+}
+
+// BenchmarkListPublicRoomsWithMembership measures the room listing with
+// membership computed by the single joined query
+func BenchmarkListPublicRoomsWithMembership(b *testing.B) {
+	database := testDB(b)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	user := createTestUser(b, database, "usr_bench_list01", "bench-list")
+	for i := 0; i < 200; i++ {
+		room := createTestRoom(b, database, fmt.Sprintf("roo_bench%07d", i), fmt.Sprintf("bench-%03d", i), false)
+		if i%2 == 0 {
+			addUserToRoom(b, database, user.ID, room.ID)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		listings, _, err := ListPublicRoomsWithMembership(ctx, database, user.ID, "", "", "", maxListRoomsLimit)
+		if err != nil {
+			b.Fatalf("ListPublicRoomsWithMembership failed: %v", err)
+		}
+		if len(listings) != 200 {
+			b.Fatalf("Expected 200 rooms, got %d", len(listings))
+		}
+	}
+}
+
+// BenchmarkListPublicRoomsPerRoomMembership measures the N+1 alternative the
+// joined query replaces: list the rooms, then check membership one room at a
+// time. Compare with BenchmarkListPublicRoomsWithMembership.
+func BenchmarkListPublicRoomsPerRoomMembership(b *testing.B) {
+	database := testDB(b)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	user := createTestUser(b, database, "usr_bench_list02", "bench-perroom")
+	for i := 0; i < 200; i++ {
+		room := createTestRoom(b, database, fmt.Sprintf("roo_bench%07d", i), fmt.Sprintf("bench-%03d", i), false)
+		if i%2 == 0 {
+			addUserToRoom(b, database, user.ID, room.ID)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rooms, err := ListPublicRooms(ctx, database)
+		if err != nil {
+			b.Fatalf("ListPublicRooms failed: %v", err)
+		}
+		membership := make([]bool, len(rooms))
+		for j, room := range rooms {
+			// Defeat the membership cache so every check costs a query,
+			// as it would for a cold listing
+			database.InvalidateMembership(user.ID, room.ID)
+			isMember, err := IsRoomMember(ctx, database, user.ID, room.ID)
+			if err != nil {
+				b.Fatalf("IsRoomMember failed: %v", err)
+			}
+			membership[j] = isMember
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+)
+
+// Role values for the rooms_members.role column
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// GetMemberRole returns the role a user holds in a room, or an empty string
+// if they are not a member.
+func GetMemberRole(ctx context.Context, db *DB, userID, roomID string) (string, error) {
+	const sqlstr = `SELECT COALESCE(` +
+		`(SELECT role FROM rooms_members WHERE user_id = $1 AND room_id = $2), ''` +
+		`) AS role`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID})
+	var role string
+	if err := db.QueryRowContext(ctx, sqlstr, userID, roomID).Scan(&role); err != nil {
+		return "", err
+	}
+	return role, nil
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// membershipCacheSize bounds how many (user, room) pairs are held in
+// memory; membershipCacheTTL bounds how stale an entry can get if an
+// invalidation is ever missed
+const (
+	membershipCacheSize = 4096
+	membershipCacheTTL  = 30 * time.Second
+)
+
+// membershipCache is a bounded LRU cache of (user, room) membership
+// results. IsRoomMember runs on the hot path of nearly every request, so
+// repeated checks for the same pair are served from memory instead of
+// hitting SQLite. Every membership mutation must invalidate the affected
+// pairs; AddRoomMember, AddRoomMembers, and LeaveRoom do this themselves,
+// and code that writes rooms_members directly must call
+// DB.InvalidateMembership.
+type membershipCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+}
+
+type membershipEntry struct {
+	key      string
+	isMember bool
+	expires  time.Time
+}
+
+func newMembershipCache() *membershipCache {
+	return &membershipCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func membershipKey(userID, roomID string) string {
+	return userID + "\x00" + roomID
+}
+
+// get returns the cached membership result for a pair, if present and
+// not expired
+func (c *membershipCache) get(userID, roomID string) (isMember, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[membershipKey(userID, roomID)]
+	if !ok {
+		return false, false
+	}
+	entry := el.Value.(*membershipEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, entry.key)
+		return false, false
+	}
+	c.order.MoveToFront(el)
+	return entry.isMember, true
+}
+
+// put records a membership result, evicting the least recently used
+// entry if the cache is full
+func (c *membershipCache) put(userID, roomID string, isMember bool) {
+	key := membershipKey(userID, roomID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*membershipEntry)
+		entry.isMember = isMember
+		entry.expires = time.Now().Add(membershipCacheTTL)
+		c.order.MoveToFront(el)
+		return
+	}
+	if c.order.Len() >= membershipCacheSize {
+		if oldest := c.order.Back(); oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*membershipEntry).key)
+		}
+	}
+	c.entries[key] = c.order.PushFront(&membershipEntry{
+		key:      key,
+		isMember: isMember,
+		expires:  time.Now().Add(membershipCacheTTL),
+	})
+}
+
+// invalidate drops the cached result for a pair
+func (c *membershipCache) invalidate(userID, roomID string) {
+	key := membershipKey(userID, roomID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// invalidateRoom drops every cached pair for a room, for mutations like
+// room deletion that affect all of its members at once
+func (c *membershipCache) invalidateRoom(roomID string) {
+	suffix := "\x00" + roomID
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.entries {
+		if strings.HasSuffix(key, suffix) {
+			c.order.Remove(el)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateMembership drops the cached membership result for a (user,
+// room) pair. It must be called after any write to rooms_members that
+// does not go through AddRoomMember, AddRoomMembers, or LeaveRoom.
+func (db *DB) InvalidateMembership(userID, roomID string) {
+	db.members.invalidate(userID, roomID)
+}
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestMembershipCache_CorrectResults tests that cached checks agree with
+// the database for members and non-members alike
+func TestMembershipCache_CorrectResults(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	member := createTestUser(t, database, "usr_cache_000001", "cache-member")
+	outsider := createTestUser(t, database, "usr_cache_000002", "cache-outsider")
+	room := createTestRoom(t, database, "roo_cache_000001", "cache-room", false)
+	if _, err := AddRoomMember(ctx, database, member.ID, room.ID); err != nil {
+		t.Fatalf("AddRoomMember failed: %v", err)
+	}
+
+	// Repeated checks hit the cache; both must agree with the database
+	for i := 0; i < 3; i++ {
+		isMember, err := IsRoomMember(ctx, database, member.ID, room.ID)
+		if err != nil {
+			t.Fatalf("IsRoomMember failed: %v", err)
+		}
+		if !isMember {
+			t.Error("Expected member to be a member")
+		}
+		isMember, err = IsRoomMember(ctx, database, outsider.ID, room.ID)
+		if err != nil {
+			t.Fatalf("IsRoomMember failed: %v", err)
+		}
+		if isMember {
+			t.Error("Expected outsider not to be a member")
+		}
+	}
+}
+
+// TestMembershipCache_LeaveInvalidates tests that leaving a room
+// invalidates the cached membership immediately
+func TestMembershipCache_LeaveInvalidates(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	user := createTestUser(t, database, "usr_cache_000003", "cache-leaver")
+	room := createTestRoom(t, database, "roo_cache_000002", "cache-leave", false)
+	if _, err := AddRoomMember(ctx, database, user.ID, room.ID); err != nil {
+		t.Fatalf("AddRoomMember failed: %v", err)
+	}
+
+	// Populate the cache with a positive result
+	isMember, err := IsRoomMember(ctx, database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("IsRoomMember failed: %v", err)
+	}
+	if !isMember {
+		t.Fatal("Expected user to be a member before leaving")
+	}
+
+	left, err := LeaveRoom(ctx, database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("LeaveRoom failed: %v", err)
+	}
+	if !left {
+		t.Fatal("Expected LeaveRoom to remove the membership")
+	}
+
+	isMember, err = IsRoomMember(ctx, database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("IsRoomMember failed after leave: %v", err)
+	}
+	if isMember {
+		t.Error("Expected membership check to be false immediately after leaving")
+	}
+}
+
+// TestMembershipCache_ServesFromCache tests that repeated checks are
+// actually served from memory: a write that bypasses invalidation is not
+// observed until InvalidateMembership is called
+func TestMembershipCache_ServesFromCache(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	user := createTestUser(t, database, "usr_cache_000004", "cache-stale")
+	room := createTestRoom(t, database, "roo_cache_000003", "cache-stale", false)
+	if _, err := AddRoomMember(ctx, database, user.ID, room.ID); err != nil {
+		t.Fatalf("AddRoomMember failed: %v", err)
+	}
+
+	isMember, err := IsRoomMember(ctx, database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("IsRoomMember failed: %v", err)
+	}
+	if !isMember {
+		t.Fatal("Expected user to be a member")
+	}
+
+	// Delete the row without telling the cache
+	if _, err := database.ExecContext(ctx,
+		"DELETE FROM rooms_members WHERE user_id = ? AND room_id = ?", user.ID, room.ID); err != nil {
+		t.Fatalf("Failed to delete membership: %v", err)
+	}
+
+	isMember, err = IsRoomMember(ctx, database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("IsRoomMember failed: %v", err)
+	}
+	if !isMember {
+		t.Error("Expected the stale positive result to be served from the cache")
+	}
+
+	database.InvalidateMembership(user.ID, room.ID)
+	isMember, err = IsRoomMember(ctx, database, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("IsRoomMember failed after invalidation: %v", err)
+	}
+	if isMember {
+		t.Error("Expected the check to hit the database after invalidation")
+	}
+}
+
+// TestMembershipCache_Eviction tests that the cache stays bounded by
+// evicting the least recently used entry
+func TestMembershipCache_Eviction(t *testing.T) {
+	cache := newMembershipCache()
+	for i := 0; i < membershipCacheSize+1; i++ {
+		cache.put(fmt.Sprintf("usr_%d", i), "roo_evict_000001", true)
+	}
+
+	if cache.order.Len() != membershipCacheSize {
+		t.Errorf("Expected %d entries after eviction, got %d", membershipCacheSize, cache.order.Len())
+	}
+	if _, ok := cache.get("usr_0", "roo_evict_000001"); ok {
+		t.Error("Expected the oldest entry to have been evicted")
+	}
+	if _, ok := cache.get(fmt.Sprintf("usr_%d", membershipCacheSize), "roo_evict_000001"); !ok {
+		t.Error("Expected the newest entry to still be cached")
+	}
+}
+
+// TestMembershipCache_TTL tests that expired entries are treated as
+// misses
+func TestMembershipCache_TTL(t *testing.T) {
+	cache := newMembershipCache()
+	cache.put("usr_ttl_00000001", "roo_ttl_00000001", true)
+
+	// Backdate the entry past its TTL
+	cache.mu.Lock()
+	el := cache.entries[membershipKey("usr_ttl_00000001", "roo_ttl_00000001")]
+	el.Value.(*membershipEntry).expires = time.Now().Add(-time.Second)
+	cache.mu.Unlock()
+
+	if _, ok := cache.get("usr_ttl_00000001", "roo_ttl_00000001"); ok {
+		t.Error("Expected an expired entry to be a miss")
+	}
+}
+
+// BenchmarkIsRoomMember_CacheHit measures repeated checks for the same
+// pair; compare with BenchmarkIsRoomMember_CacheMiss to see the saved
+// database round-trips
+func BenchmarkIsRoomMember_CacheHit(b *testing.B) {
+	database := testDB(b)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	user := createTestUser(b, database, "usr_bench_cache1", "bench-hit")
+	room := createTestRoom(b, database, "roo_bench_cache1", "bench-hit", false)
+	if _, err := AddRoomMember(ctx, database, user.ID, room.ID); err != nil {
+		b.Fatalf("AddRoomMember failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		isMember, err := IsRoomMember(ctx, database, user.ID, room.ID)
+		if err != nil {
+			b.Fatalf("IsRoomMember failed: %v", err)
+		}
+		if !isMember {
+			b.Fatal("Expected user to be a member")
+		}
+	}
+}
+
+// BenchmarkIsRoomMember_CacheMiss forces every check to hit the database
+// by invalidating between iterations
+func BenchmarkIsRoomMember_CacheMiss(b *testing.B) {
+	database := testDB(b)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	user := createTestUser(b, database, "usr_bench_cache2", "bench-miss")
+	room := createTestRoom(b, database, "roo_bench_cache2", "bench-miss", false)
+	if _, err := AddRoomMember(ctx, database, user.ID, room.ID); err != nil {
+		b.Fatalf("AddRoomMember failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		database.InvalidateMembership(user.ID, room.ID)
+		isMember, err := IsRoomMember(ctx, database, user.ID, room.ID)
+		if err != nil {
+			b.Fatalf("IsRoomMember failed: %v", err)
+		}
+		if !isMember {
+			b.Fatal("Expected user to be a member")
+		}
+	}
+}
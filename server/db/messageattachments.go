@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// GetAttachmentsForMessages batch-loads attachment metadata for a set of
+// messages. The result maps message ID to that message's attachments in
+// upload order.
+func GetAttachmentsForMessages(ctx context.Context, db *DB, messageIDs []string) (map[string][]protocol.Attachment, error) {
+	if len(messageIDs) == 0 {
+		return make(map[string][]protocol.Attachment), nil
+	}
+
+	// Build parameterized IN clause
+	placeholders := make([]string, len(messageIDs))
+	args := make([]interface{}, len(messageIDs))
+	for i, id := range messageIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := `SELECT message_id, id, url, filename, content_type, size, thumbnail_url
+		FROM attachments
+		WHERE message_id IN (` + strings.Join(placeholders, ",") + `)
+		ORDER BY message_id, created_at`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]protocol.Attachment)
+	for rows.Next() {
+		var messageID string
+		var att protocol.Attachment
+		if err := rows.Scan(&messageID, &att.ID, &att.URL, &att.Filename, &att.ContentType, &att.Size, &att.ThumbnailURL); err != nil {
+			return nil, err
+		}
+		result[messageID] = append(result[messageID], att)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
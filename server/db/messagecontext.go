@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+)
+
+const messageContextColumns = `m.id, m.room_id, m.user_id, m.body, m.created_at, m.modified_at, COALESCE(m.deleted_at, '') AS deleted_at, m.enc_key_id, m.is_system, u.username`
+
+// GetMessagesBefore returns up to limit messages in a room strictly older
+// than createdAt, in chronological order (oldest first).
+func GetMessagesBefore(ctx context.Context, db *DB, roomID, createdAt string, limit int) ([]*RoomMessage, error) {
+	const sqlstr = `SELECT ` + messageContextColumns + ` ` +
+		`FROM messages m ` +
+		`JOIN users u ON m.user_id = u.id ` +
+		`WHERE m.room_id = $1 AND m.created_at < $2 ` +
+		`ORDER BY m.created_at DESC ` +
+		`LIMIT $3`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, createdAt, limit})
+	messages, err := scanRoomMessages(ctx, db, sqlstr, roomID, createdAt, limit)
+	if err != nil {
+		return nil, err
+	}
+	// Reverse from newest-first (how the query pages) to chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// GetMessagesAfter returns up to limit messages in a room strictly newer
+// than createdAt, in chronological order (oldest first).
+func GetMessagesAfter(ctx context.Context, db *DB, roomID, createdAt string, limit int) ([]*RoomMessage, error) {
+	const sqlstr = `SELECT ` + messageContextColumns + ` ` +
+		`FROM messages m ` +
+		`JOIN users u ON m.user_id = u.id ` +
+		`WHERE m.room_id = $1 AND m.created_at > $2 ` +
+		`ORDER BY m.created_at ASC ` +
+		`LIMIT $3`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, createdAt, limit})
+	return scanRoomMessages(ctx, db, sqlstr, roomID, createdAt, limit)
+}
+
+// scanRoomMessages runs a message query and scans the rows into RoomMessages
+func scanRoomMessages(ctx context.Context, db *DB, sqlstr string, args ...any) ([]*RoomMessage, error) {
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*RoomMessage
+	for rows.Next() {
+		var m RoomMessage
+		var encKeyID string
+		if err := rows.Scan(&m.ID, &m.RoomID, &m.UserID, &m.Body, &m.CreatedAt, &m.ModifiedAt, &m.DeletedAt, &encKeyID, &m.IsSystem, &m.Username); err != nil {
+			return nil, err
+		}
+		if m.Body, err = db.Cipher.DecryptBody(m.Body, encKeyID); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
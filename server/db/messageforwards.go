@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// InsertMessageForward records that messageID is a forwarded copy of
+// sourceMessageID.
+func InsertMessageForward(ctx context.Context, db *DB, messageID, sourceMessageID, sourceRoomID, sourceUserID string) error {
+	const sqlstr = `INSERT INTO message_forwards ` +
+		`(message_id, source_message_id, source_room_id, source_user_id) ` +
+		`VALUES ($1, $2, $3, $4)`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{messageID, sourceMessageID, sourceRoomID, sourceUserID})
+	_, err := db.ExecContext(ctx, sqlstr, messageID, sourceMessageID, sourceRoomID, sourceUserID)
+	return err
+}
+
+// GetForwardsForMessages batch-loads forward attribution for a set of
+// messages. The result maps forwarded message ID to the original message's
+// attribution; messages that aren't forwards have no entry.
+func GetForwardsForMessages(ctx context.Context, db *DB, messageIDs []string) (map[string]*protocol.ForwardedFrom, error) {
+	result := make(map[string]*protocol.ForwardedFrom)
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	// Build parameterized IN clause
+	placeholders := make([]string, len(messageIDs))
+	args := make([]any, len(messageIDs))
+	for i, id := range messageIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	sqlstr := `SELECT f.message_id, f.source_message_id, f.source_room_id, f.source_user_id, u.username ` +
+		`FROM message_forwards f ` +
+		`JOIN users u ON u.id = f.source_user_id ` +
+		`WHERE f.message_id IN (` + strings.Join(placeholders, ",") + `)`
+
+	db.logger.Debug("querying", "query", sqlstr, "args", args)
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID string
+		var ff protocol.ForwardedFrom
+		if err := rows.Scan(&messageID, &ff.MessageID, &ff.RoomID, &ff.UserID, &ff.Username); err != nil {
+			return nil, err
+		}
+		result[messageID] = &ff
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// InsertMessageQuote records the snapshot of the message quoted inline by
+// messageID. The author and excerpt are denormalized so the quote survives
+// the quoted message being deleted or purged.
+func InsertMessageQuote(ctx context.Context, db *DB, messageID string, quote *protocol.QuotedMessage) error {
+	const sqlstr = `INSERT INTO message_quotes ` +
+		`(message_id, quoted_message_id, quoted_user_id, quoted_username, excerpt, quoted_created_at) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6)`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{messageID, quote.MessageID, quote.UserID, quote.Username, quote.Excerpt, quote.CreatedAt})
+	_, err := db.ExecContext(ctx, sqlstr, messageID, quote.MessageID, quote.UserID, quote.Username, quote.Excerpt, quote.CreatedAt)
+	return err
+}
+
+// GetQuotesForMessages batch-loads quote snapshots for a set of messages.
+// The result maps quoting message ID to the snapshot; messages that don't
+// quote anything have no entry.
+func GetQuotesForMessages(ctx context.Context, db *DB, messageIDs []string) (map[string]*protocol.QuotedMessage, error) {
+	result := make(map[string]*protocol.QuotedMessage)
+	if len(messageIDs) == 0 {
+		return result, nil
+	}
+
+	// Build parameterized IN clause
+	placeholders := make([]string, len(messageIDs))
+	args := make([]any, len(messageIDs))
+	for i, id := range messageIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	sqlstr := `SELECT message_id, quoted_message_id, quoted_user_id, quoted_username, excerpt, quoted_created_at ` +
+		`FROM message_quotes ` +
+		`WHERE message_id IN (` + strings.Join(placeholders, ",") + `)`
+
+	db.logger.Debug("querying", "query", sqlstr, "args", args)
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID string
+		var q protocol.QuotedMessage
+		if err := rows.Scan(&messageID, &q.MessageID, &q.UserID, &q.Username, &q.Excerpt, &q.CreatedAt); err != nil {
+			return nil, err
+		}
+		result[messageID] = &q
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Status values for the message_reports.status column
+const (
+	ReportOpen      = "open"      // awaiting moderator attention
+	ReportResolved  = "resolved"  // a moderator acted on the report
+	ReportDismissed = "dismissed" // a moderator decided no action was needed
+)
+
+// MessageReport is one report in a room, with the reporter's username for
+// display.
+type MessageReport struct {
+	MessageID        string
+	ReporterID       string
+	ReporterUsername string
+	Reason           string
+	Status           string
+	CreatedAt        string
+}
+
+// InsertMessageReport records a user's report of a message. Returns true
+// if the report was recorded, false if the user had already reported the
+// message.
+func InsertMessageReport(ctx context.Context, db *DB, messageID, reporterID, roomID, reason string) (bool, error) {
+	const sqlstr = `INSERT INTO message_reports (message_id, reporter_id, room_id, reason, created_at) ` +
+		`VALUES ($1, $2, $3, $4, $5) ` +
+		`ON CONFLICT (message_id, reporter_id) DO NOTHING`
+	createdAt := time.Now().Format(time.RFC3339)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{messageID, reporterID, roomID, reason, createdAt})
+	res, err := db.ExecContext(ctx, sqlstr, messageID, reporterID, roomID, reason, createdAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ListMessageReports returns a room's message reports, oldest first,
+// optionally limited to one status.
+func ListMessageReports(ctx context.Context, db *DB, roomID, status string) ([]MessageReport, error) {
+	sqlstr := `SELECT r.message_id, r.reporter_id, u.username, r.reason, r.status, r.created_at ` +
+		`FROM message_reports r ` +
+		`JOIN users u ON u.id = r.reporter_id ` +
+		`WHERE r.room_id = $1 `
+	args := []any{roomID}
+	if status != "" {
+		sqlstr += `AND r.status = $2 `
+		args = append(args, status)
+	}
+	sqlstr += `ORDER BY r.created_at, r.message_id, r.reporter_id`
+	db.logger.Debug("querying", "query", sqlstr, "args", args)
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []MessageReport
+	for rows.Next() {
+		var r MessageReport
+		if err := rows.Scan(&r.MessageID, &r.ReporterID, &r.ReporterUsername, &r.Reason, &r.Status, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// ResolveMessageReports marks all of a message's open reports with the
+// given status, recording who handled them. Returns how many reports were
+// updated.
+func ResolveMessageReports(ctx context.Context, db *DB, messageID, status, resolvedBy string) (int64, error) {
+	const sqlstr = `UPDATE message_reports ` +
+		`SET status = $1, resolved_by = $2, resolved_at = $3 ` +
+		`WHERE message_id = $4 AND status = $5`
+	resolvedAt := time.Now().Format(time.RFC3339)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{status, resolvedBy, resolvedAt, messageID, ReportOpen})
+	res, err := db.ExecContext(ctx, sqlstr, status, resolvedBy, resolvedAt, messageID, ReportOpen)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GetMessagesByIDs returns the messages with the given IDs in a single
+// query, joined with usernames and decrypted. IDs that don't exist are
+// simply absent from the result, and the database's row order is
+// unspecified; callers wanting the requested order reorder the result
+// themselves.
+func GetMessagesByIDs(ctx context.Context, db *DB, messageIDs []string) ([]*RoomMessage, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(messageIDs))
+	args := make([]any, len(messageIDs))
+	for i, id := range messageIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	sqlstr := `SELECT m.id, m.room_id, m.user_id, m.body, m.seq, m.created_at, m.modified_at, ` +
+		`COALESCE(m.deleted_at, '') as deleted_at, m.enc_key_id, m.is_system, u.username ` +
+		`FROM messages m ` +
+		`JOIN users u ON m.user_id = u.id ` +
+		`WHERE m.id IN (` + strings.Join(placeholders, ",") + `)`
+
+	db.logger.Debug("querying", "query", sqlstr, "args", args)
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*RoomMessage
+	for rows.Next() {
+		var m RoomMessage
+		var encKeyID string
+		if err := rows.Scan(&m.ID, &m.RoomID, &m.UserID, &m.Body, &m.Seq, &m.CreatedAt, &m.ModifiedAt, &m.DeletedAt, &encKeyID, &m.IsSystem, &m.Username); err != nil {
+			return nil, err
+		}
+		if m.Body, err = db.Cipher.DecryptBody(m.Body, encKeyID); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
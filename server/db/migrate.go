@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// schemaColumn describes a column that was added to a table after its
+// original CREATE TABLE shipped. CREATE TABLE IF NOT EXISTS is a no-op on
+// databases that already have the table, so every later column needs a
+// matching entry here to reach existing installs.
+type schemaColumn struct {
+	table  string
+	column string
+	// the column definition as it appears in schema.sql; ALTER TABLE ADD
+	// COLUMN requires a constant non-NULL default for NOT NULL columns
+	decl string
+}
+
+// schemaColumns lists every column added to a pre-existing table, in the
+// order schema.sql declares them
+var schemaColumns = []schemaColumn{
+	{"users", "last_active_at", `TEXT NOT NULL DEFAULT ''`},
+	{"rooms_members", "role", `TEXT NOT NULL DEFAULT 'member'`},
+	{"rooms_members", "muted", `INTEGER NOT NULL DEFAULT 0`},
+	{"rooms", "slow_mode_seconds", `INTEGER NOT NULL DEFAULT 0`},
+	{"rooms", "retention_days", `INTEGER NOT NULL DEFAULT 0`},
+	{"rooms", "topic", `TEXT NOT NULL DEFAULT ''`},
+	{"rooms", "join_policy", `TEXT NOT NULL DEFAULT 'open'`},
+	{"rooms", "post_policy", `TEXT NOT NULL DEFAULT 'all'`},
+	{"rooms", "announce_pins", `INTEGER NOT NULL DEFAULT 1`},
+	{"messages", "seq", `INTEGER NOT NULL DEFAULT 0`},
+	{"messages", "enc_key_id", `TEXT NOT NULL DEFAULT ''`},
+	{"messages", "is_system", `INTEGER NOT NULL DEFAULT 0`},
+}
+
+// MigrateSchemaColumns adds columns that schema.sql has grown since a
+// database was created. Runs at startup before the schema file so that
+// indexes and triggers over the new columns apply cleanly; it is a no-op on
+// fresh databases (no tables yet) and on databases that are already current.
+func (db *DB) MigrateSchemaColumns(ctx context.Context) error {
+	for _, col := range schemaColumns {
+		exists, err := db.tableExists(ctx, col.table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		present, err := db.columnExists(ctx, col.table, col.column)
+		if err != nil {
+			return err
+		}
+		if present {
+			continue
+		}
+		sqlstr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", col.table, col.column, col.decl)
+		db.logger.Info("adding missing column", "table", col.table, "column", col.column)
+		if _, err := db.ExecContext(ctx, sqlstr); err != nil {
+			return fmt.Errorf("adding %s.%s: %w", col.table, col.column, err)
+		}
+		// Rows that predate the seq column all land on the default of 0,
+		// which the ordering queries treat as unassigned; number them by
+		// the old ordering key. The assignment trigger doesn't exist yet
+		// on this path, so a global ROW_NUMBER can't race an insert.
+		if col.table == "messages" && col.column == "seq" {
+			const backfill = `UPDATE messages SET seq = (` +
+				`SELECT rn FROM (` +
+				`SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS rn FROM messages` +
+				`) numbered WHERE numbered.id = messages.id` +
+				`)`
+			db.logger.Debug("querying", "query", backfill, "args", []any{})
+			if _, err := db.ExecContext(ctx, backfill); err != nil {
+				return fmt.Errorf("backfilling messages.seq: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// tableExists reports whether a table is present in the database
+func (db *DB) tableExists(ctx context.Context, table string) (bool, error) {
+	var n int
+	if err := db.QueryRowContext(ctx,
+		`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = $1`, table,
+	).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// columnExists reports whether a table already has the named column
+func (db *DB) columnExists(ctx context.Context, table, column string) (bool, error) {
+	var n int
+	if err := db.QueryRowContext(ctx,
+		`SELECT count(*) FROM pragma_table_info($1) WHERE name = $2`, table, column,
+	).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+// oldSchema is the original shape of the tables that have since grown
+// columns, as an early install would have created them
+const oldSchema = `
+	CREATE TABLE users(
+		id TEXT PRIMARY KEY NOT NULL,
+		username TEXT NOT NULL,
+		password TEXT NOT NULL,
+		display_name TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT '',
+		active INTEGER,
+		avatar TEXT,
+		last_room TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		modified_at TEXT NOT NULL
+	) STRICT;
+
+	CREATE TABLE rooms(
+		id TEXT PRIMARY KEY NOT NULL,
+		name TEXT NOT NULL,
+		room_type TEXT NOT NULL DEFAULT 'channel',
+		is_private INTEGER NOT NULL,
+		is_default INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		last_message_at TEXT
+	) STRICT;
+
+	CREATE TABLE rooms_members(
+		user_id TEXT REFERENCES users(id) NOT NULL,
+		room_id TEXT REFERENCES rooms(id) NOT NULL,
+		PRIMARY KEY (user_id, room_id)
+	) STRICT;
+
+	CREATE TABLE messages(
+		id TEXT PRIMARY KEY NOT NULL,
+		room_id TEXT REFERENCES rooms(id) NOT NULL,
+		user_id TEXT REFERENCES users(id) NOT NULL,
+		body TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		modified_at TEXT NOT NULL,
+		deleted_at TEXT
+	) STRICT;
+`
+
+// TestMigrateSchemaColumns tests that a database created from the original
+// schema gains the later columns, that the current schema file applies
+// cleanly afterward, and that existing messages get sequence numbers in
+// their old creation order
+func TestMigrateSchemaColumns(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	// Rebuild the tables in their original shape. testDB creates the
+	// current shape, so tear its tables down first.
+	for _, table := range []string{"messages", "rooms_members", "rooms", "users"} {
+		if _, err := database.ExecContext(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+			t.Fatalf("Failed to drop %s: %v", table, err)
+		}
+	}
+	if _, err := database.ExecContext(ctx, oldSchema); err != nil {
+		t.Fatalf("Failed to create the old schema: %v", err)
+	}
+
+	if _, err := database.ExecContext(ctx,
+		`INSERT INTO users (id, username, password, last_room, created_at, modified_at) `+
+			`VALUES ('usr_migrate0001', 'migrator', 'hashedpassword', 'roo_migrate0001', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z')`); err != nil {
+		t.Fatalf("Failed to insert user: %v", err)
+	}
+	if _, err := database.ExecContext(ctx,
+		`INSERT INTO rooms (id, name, is_private, is_default, created_at) `+
+			`VALUES ('roo_migrate0001', 'migrate', 0, 1, '2024-01-01T00:00:00Z')`); err != nil {
+		t.Fatalf("Failed to insert room: %v", err)
+	}
+
+	// Messages inserted in reverse creation order, so backfill order is
+	// visibly driven by created_at rather than insertion order
+	for _, row := range [][]string{
+		{"msg_migrate0003", "2024-01-01T00:03:00Z"},
+		{"msg_migrate0001", "2024-01-01T00:01:00Z"},
+		{"msg_migrate0002", "2024-01-01T00:02:00Z"},
+	} {
+		if _, err := database.ExecContext(ctx,
+			`INSERT INTO messages (id, room_id, user_id, body, created_at, modified_at) `+
+				`VALUES ($1, 'roo_migrate0001', 'usr_migrate0001', 'hi', $2, $2)`,
+			row[0], row[1]); err != nil {
+			t.Fatalf("Failed to insert message %s: %v", row[0], err)
+		}
+	}
+
+	if err := database.MigrateSchemaColumns(ctx); err != nil {
+		t.Fatalf("MigrateSchemaColumns failed: %v", err)
+	}
+
+	for _, col := range schemaColumns {
+		present, err := database.columnExists(ctx, col.table, col.column)
+		if err != nil {
+			t.Fatalf("columnExists failed: %v", err)
+		}
+		if !present {
+			t.Errorf("Expected %s.%s to exist after migration", col.table, col.column)
+		}
+	}
+
+	// The schema file's index and trigger statements over the new columns
+	// must apply on the migrated database
+	if err := database.RunSQLFile("../../schema.sql"); err != nil {
+		t.Fatalf("Expected schema.sql to apply after migration: %v", err)
+	}
+
+	// Backfilled sequence numbers follow created_at order
+	rows, err := database.QueryContext(ctx, "SELECT id FROM messages ORDER BY seq")
+	if err != nil {
+		t.Fatalf("Failed to query messages: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	want := []string{"msg_migrate0001", "msg_migrate0002", "msg_migrate0003"}
+	for i := 0; rows.Next(); i++ {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("Failed to scan message: %v", err)
+		}
+		if i >= len(want) || id != want[i] {
+			t.Errorf("Expected message %d in seq order to be %s, got %s", i, want[i], id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Row iteration failed: %v", err)
+	}
+
+	// Running the migration again is a no-op
+	if err := database.MigrateSchemaColumns(ctx); err != nil {
+		t.Errorf("Expected a second migration run to be a no-op: %v", err)
+	}
+}
+
+// TestMigrateSchemaColumnsFreshDatabase tests that the migration is a no-op
+// before any tables exist
+func TestMigrateSchemaColumnsFreshDatabase(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	// Simulate first boot, where no tables have been created yet
+	for _, table := range []string{"messages", "rooms_members", "rooms", "users"} {
+		if _, err := database.ExecContext(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+			t.Fatalf("Failed to drop %s: %v", table, err)
+		}
+	}
+
+	if err := database.MigrateSchemaColumns(ctx); err != nil {
+		t.Errorf("Expected the migration to be a no-op on a fresh database: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// PinMessage records a pin on a message in a room. Returns true if the pin
+// was newly created; pinning a message that is already pinned is a no-op
+// and returns false.
+func PinMessage(ctx context.Context, db *DB, roomID, messageID, userID string) (bool, error) {
+	const sqlstr = `INSERT INTO pinned_messages (room_id, message_id, pinned_by, created_at) ` +
+		`VALUES ($1, $2, $3, $4) ` +
+		`ON CONFLICT (room_id, message_id) DO NOTHING`
+	createdAt := time.Now().Format(time.RFC3339Nano)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, messageID, userID, createdAt})
+	res, err := db.ExecContext(ctx, sqlstr, roomID, messageID, userID, createdAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,7 @@
+package db
+
+// Post policy values for the rooms.post_policy column
+const (
+	PostPolicyAll    = "all"    // any member may post
+	PostPolicyAdmins = "admins" // announcement mode: only owners and admins may post
+)
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PurgedMessage identifies a message removed by retention so the caller can
+// notify connected clients.
+type PurgedMessage struct {
+	ID     string
+	RoomID string
+}
+
+// PurgeExpiredMessages hard-deletes messages older than their room's
+// retention_days setting and returns what was removed. Rooms with a
+// retention of 0 keep messages forever. The FTS index is cleaned up by the
+// messages_fts_delete trigger.
+func PurgeExpiredMessages(ctx context.Context, db *DB) ([]PurgedMessage, error) {
+	// strftime produces the same lexical format as the RFC3339 timestamps we
+	// store, so string comparison works
+	const selectSQL = `SELECT m.id, m.room_id ` +
+		`FROM messages m ` +
+		`JOIN rooms r ON m.room_id = r.id ` +
+		`WHERE r.retention_days > 0 ` +
+		`AND m.created_at < strftime('%Y-%m-%dT%H:%M:%fZ', 'now', '-' || r.retention_days || ' days')`
+	db.logger.Debug("querying", "query", selectSQL)
+
+	rows, err := db.QueryContext(ctx, selectSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purged []PurgedMessage
+	for rows.Next() {
+		var p PurgedMessage
+		if err := rows.Scan(&p.ID, &p.RoomID); err != nil {
+			return nil, err
+		}
+		purged = append(purged, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(purged) == 0 {
+		return nil, nil
+	}
+
+	// Delete exactly the rows we found; anything posted since the select is
+	// newer and kept for the next pass
+	placeholders := make([]string, len(purged))
+	args := make([]interface{}, len(purged))
+	for i, p := range purged {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = p.ID
+	}
+
+	in := strings.Join(placeholders, ",")
+
+	// Remove dependent rows first so the foreign keys referencing messages
+	// don't block the delete. Attachments outlive their message as unlinked
+	// uploads; every other reference goes with the message. Forward
+	// attributions are removed when either end of them expires, and read
+	// positions pointing at a purged message simply reset.
+	cleanups := []string{
+		`DELETE FROM reactions WHERE message_id IN (` + in + `)`,
+		`UPDATE attachments SET message_id = NULL WHERE message_id IN (` + in + `)`,
+		`DELETE FROM room_reads WHERE last_read_message_id IN (` + in + `)`,
+		`DELETE FROM starred_messages WHERE message_id IN (` + in + `)`,
+		`DELETE FROM pinned_messages WHERE message_id IN (` + in + `)`,
+		`DELETE FROM message_quotes WHERE message_id IN (` + in + `)`,
+		`DELETE FROM message_forwards WHERE message_id IN (` + in + `) OR source_message_id IN (` + in + `)`,
+		`DELETE FROM message_reports WHERE message_id IN (` + in + `)`,
+	}
+	for _, sqlstr := range cleanups {
+		db.logger.Debug("querying", "query", sqlstr, "args", args)
+		if _, err := db.ExecContext(ctx, sqlstr, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	deleteSQL := `DELETE FROM messages WHERE id IN (` + in + `)`
+	db.logger.Debug("querying", "query", deleteSQL, "args", args)
+	if _, err := db.ExecContext(ctx, deleteSQL, args...); err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
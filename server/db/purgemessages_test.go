@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// seedRetentionRoom creates a user and a room with the given retention,
+// returning both
+func seedRetentionRoom(t *testing.T, testDB *DB, retentionDays int) (*models.User, *models.Room) {
+	t.Helper()
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:         "usr_test123456789a",
+		Username:   "alice",
+		Password:   "hash",
+		LastRoom:   "roo_general1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := user.Insert(ctx, testDB); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	room := &models.Room{
+		ID:            "roo_general1234",
+		Name:          "general",
+		RoomType:      "channel",
+		IsPrivate:     0,
+		IsDefault:     1,
+		CreatedAt:     time.Now().Format(time.RFC3339),
+		RetentionDays: retentionDays,
+	}
+	if err := room.Insert(ctx, testDB); err != nil {
+		t.Fatalf("failed to insert room: %v", err)
+	}
+
+	return user, room
+}
+
+// insertMessageAt inserts a message with the given age
+func insertMessageAt(t *testing.T, testDB *DB, id, roomID, userID, body string, age time.Duration) {
+	t.Helper()
+	at := time.Now().Add(-age).UTC().Format(time.RFC3339Nano)
+	msg := &models.Message{
+		ID:         id,
+		RoomID:     roomID,
+		UserID:     userID,
+		Body:       body,
+		CreatedAt:  at,
+		ModifiedAt: at,
+	}
+	if err := msg.Insert(context.Background(), testDB); err != nil {
+		t.Fatalf("failed to insert message: %v", err)
+	}
+}
+
+func TestPurgeExpiredMessages_PurgesOldKeepsNew(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+
+	user, room := seedRetentionRoom(t, testDB, 7)
+	insertMessageAt(t, testDB, "msg_purgeold1234", room.ID, user.ID, "ancient history", 10*24*time.Hour)
+	insertMessageAt(t, testDB, "msg_purgenew1234", room.ID, user.ID, "fresh news", time.Hour)
+
+	purged, err := PurgeExpiredMessages(ctx, testDB)
+	if err != nil {
+		t.Fatalf("PurgeExpiredMessages failed: %v", err)
+	}
+
+	if len(purged) != 1 {
+		t.Fatalf("expected 1 purged message, got %d", len(purged))
+	}
+	if purged[0].ID != "msg_purgeold1234" {
+		t.Errorf("expected msg_purgeold1234 purged, got %s", purged[0].ID)
+	}
+	if purged[0].RoomID != room.ID {
+		t.Errorf("expected room %s, got %s", room.ID, purged[0].RoomID)
+	}
+
+	// The old message is gone, the new one remains
+	if _, err := models.MessageByID(ctx, testDB, "msg_purgeold1234"); err == nil {
+		t.Error("expected old message to be deleted")
+	}
+	if _, err := models.MessageByID(ctx, testDB, "msg_purgenew1234"); err != nil {
+		t.Errorf("expected new message to be kept: %v", err)
+	}
+
+	// The FTS index no longer matches the purged message
+	results, _, err := testDB.SearchMessages(ctx, user.ID, "ancient", "", "", "", 20, 0)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no search results for purged message, got %d", len(results))
+	}
+}
+
+// TestPurgeExpiredMessages_ReferencedMessages tests that a message that has
+// been read, starred, pinned, quoted, forwarded, and reported still purges:
+// every table with a foreign key on messages is cleared first
+func TestPurgeExpiredMessages_ReferencedMessages(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+
+	user, room := seedRetentionRoom(t, testDB, 7)
+	insertMessageAt(t, testDB, "msg_purgeref0001", room.ID, user.ID, "expired original", 10*24*time.Hour)
+	insertMessageAt(t, testDB, "msg_purgeref0002", room.ID, user.ID, "fresh forward", time.Hour)
+
+	now := time.Now().Format(time.RFC3339)
+	for _, ins := range []struct {
+		sqlstr string
+		args   []any
+	}{
+		{`INSERT INTO reactions (message_id, user_id, emoji, created_at) VALUES ($1, $2, '👍', $3)`,
+			[]any{"msg_purgeref0001", user.ID, now}},
+		{`INSERT INTO room_reads (user_id, room_id, last_read_message_id, updated_at) VALUES ($1, $2, $3, $4)`,
+			[]any{user.ID, room.ID, "msg_purgeref0001", now}},
+		{`INSERT INTO starred_messages (user_id, message_id, created_at) VALUES ($1, $2, $3)`,
+			[]any{user.ID, "msg_purgeref0001", now}},
+		{`INSERT INTO pinned_messages (room_id, message_id, pinned_by, created_at) VALUES ($1, $2, $3, $4)`,
+			[]any{room.ID, "msg_purgeref0001", user.ID, now}},
+		{`INSERT INTO message_quotes (message_id, quoted_message_id, quoted_user_id, quoted_username, excerpt, quoted_created_at) VALUES ($1, 'msg_gonealready1', $2, $3, 'hello', $4)`,
+			[]any{"msg_purgeref0001", user.ID, user.Username, now}},
+		{`INSERT INTO message_forwards (message_id, source_message_id, source_room_id, source_user_id) VALUES ($1, $2, $3, $4)`,
+			[]any{"msg_purgeref0002", "msg_purgeref0001", room.ID, user.ID}},
+		{`INSERT INTO message_reports (message_id, reporter_id, room_id, created_at) VALUES ($1, $2, $3, $4)`,
+			[]any{"msg_purgeref0001", user.ID, room.ID, now}},
+	} {
+		if _, err := testDB.ExecContext(ctx, ins.sqlstr, ins.args...); err != nil {
+			t.Fatalf("failed to insert reference: %v", err)
+		}
+	}
+
+	purged, err := PurgeExpiredMessages(ctx, testDB)
+	if err != nil {
+		t.Fatalf("PurgeExpiredMessages failed: %v", err)
+	}
+	if len(purged) != 1 || purged[0].ID != "msg_purgeref0001" {
+		t.Fatalf("expected msg_purgeref0001 purged, got %v", purged)
+	}
+
+	if _, err := models.MessageByID(ctx, testDB, "msg_purgeref0001"); err == nil {
+		t.Error("expected the expired message to be deleted")
+	}
+	if _, err := models.MessageByID(ctx, testDB, "msg_purgeref0002"); err != nil {
+		t.Errorf("expected the fresh message to be kept: %v", err)
+	}
+
+	// Every referencing row is gone
+	for _, q := range []string{
+		`SELECT count(*) FROM reactions WHERE message_id = 'msg_purgeref0001'`,
+		`SELECT count(*) FROM room_reads WHERE last_read_message_id = 'msg_purgeref0001'`,
+		`SELECT count(*) FROM starred_messages WHERE message_id = 'msg_purgeref0001'`,
+		`SELECT count(*) FROM pinned_messages WHERE message_id = 'msg_purgeref0001'`,
+		`SELECT count(*) FROM message_quotes WHERE message_id = 'msg_purgeref0001'`,
+		`SELECT count(*) FROM message_forwards WHERE source_message_id = 'msg_purgeref0001'`,
+		`SELECT count(*) FROM message_reports WHERE message_id = 'msg_purgeref0001'`,
+	} {
+		var n int
+		if err := testDB.QueryRowContext(ctx, q).Scan(&n); err != nil {
+			t.Fatalf("count failed: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("expected no rows for %s, got %d", q, n)
+		}
+	}
+}
+
+func TestPurgeExpiredMessages_ZeroRetentionKeepsForever(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+
+	user, room := seedRetentionRoom(t, testDB, 0)
+	insertMessageAt(t, testDB, "msg_purgeold1234", room.ID, user.ID, "ancient history", 365*24*time.Hour)
+
+	purged, err := PurgeExpiredMessages(ctx, testDB)
+	if err != nil {
+		t.Fatalf("PurgeExpiredMessages failed: %v", err)
+	}
+
+	if len(purged) != 0 {
+		t.Errorf("expected no purged messages with retention 0, got %d", len(purged))
+	}
+	if _, err := models.MessageByID(ctx, testDB, "msg_purgeold1234"); err != nil {
+		t.Errorf("expected message to be kept: %v", err)
+	}
+}
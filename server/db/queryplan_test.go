@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// queryPlan returns the EXPLAIN QUERY PLAN detail lines for a query
+func queryPlan(t *testing.T, database *DB, sqlstr string, args ...any) []string {
+	t.Helper()
+	rows, err := database.QueryContext(context.Background(), "EXPLAIN QUERY PLAN "+sqlstr, args...)
+	if err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	defer rows.Close()
+
+	var details []string
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			t.Fatalf("Failed to scan query plan row: %v", err)
+		}
+		details = append(details, detail)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Failed to read query plan: %v", err)
+	}
+	return details
+}
+
+// TestQueryPlan_IsRoomMember verifies that the membership check is served
+// by the rooms_members primary key rather than a table scan
+func TestQueryPlan_IsRoomMember(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	// The exact query run by IsRoomMember
+	const sqlstr = `SELECT EXISTS(` +
+		`SELECT 1 FROM rooms_members ` +
+		`WHERE user_id = $1 AND room_id = $2` +
+		`) AS is_member`
+	plan := queryPlan(t, database, sqlstr, "usr_plan_0000001", "roo_plan_0000001")
+
+	sawIndex := false
+	for _, detail := range plan {
+		if strings.Contains(detail, "SCAN rooms_members") {
+			t.Errorf("Expected an index lookup, got a table scan: %s", detail)
+		}
+		if strings.Contains(detail, "SEARCH rooms_members") && strings.Contains(detail, "INDEX") {
+			sawIndex = true
+		}
+	}
+	if !sawIndex {
+		t.Errorf("Expected the plan to search rooms_members by index, got %v", plan)
+	}
+}
+
+// TestQueryPlan_GetRoomMessages verifies that the history query is served
+// by the messages_room_seq index, which also provides the seq DESC ordering
+func TestQueryPlan_GetRoomMessages(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	// The exact query run by GetRoomMessages for a first page
+	const sqlstr = `SELECT m.id, m.room_id, m.user_id, m.body, m.seq, m.created_at, m.modified_at, ` +
+		`COALESCE(m.deleted_at, '') as deleted_at, m.enc_key_id, u.username ` +
+		`FROM messages m ` +
+		`JOIN users u ON m.user_id = u.id ` +
+		`WHERE m.room_id = $1 ORDER BY m.seq DESC LIMIT $2`
+	plan := queryPlan(t, database, sqlstr, "roo_plan_0000002", 50)
+
+	sawIndex := false
+	sawSort := false
+	for _, detail := range plan {
+		if strings.Contains(detail, "SCAN m") || strings.Contains(detail, "SCAN messages") {
+			t.Errorf("Expected an index lookup, got a table scan: %s", detail)
+		}
+		if strings.Contains(detail, "messages_room_seq") {
+			sawIndex = true
+		}
+		if strings.Contains(detail, "USE TEMP B-TREE FOR ORDER BY") {
+			sawSort = true
+		}
+	}
+	if !sawIndex {
+		t.Errorf("Expected the plan to use messages_room_seq, got %v", plan)
+	}
+	if sawSort {
+		t.Errorf("Expected the index to satisfy the ordering, got a sort: %v", plan)
+	}
+}
+
+// TestQueryPlan_RoomMembersByRoomID verifies that room-side membership
+// lookups use the rooms_members_room index rather than scanning the table
+func TestQueryPlan_RoomMembersByRoomID(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	// The shape shared by RoomMembersByRoomID and RoomAdmins
+	const sqlstr = `SELECT user_id FROM rooms_members WHERE room_id = $1`
+	plan := queryPlan(t, database, sqlstr, "roo_plan_0000003")
+
+	sawIndex := false
+	for _, detail := range plan {
+		if strings.Contains(detail, "SCAN rooms_members") {
+			t.Errorf("Expected an index lookup, got a table scan: %s", detail)
+		}
+		if strings.Contains(detail, "rooms_members_room") {
+			sawIndex = true
+		}
+	}
+	if !sawIndex {
+		t.Errorf("Expected the plan to use rooms_members_room, got %v", plan)
+	}
+}
+
+// BenchmarkGetRoomMessages measures a first-page history fetch against a
+// room seeded with a large message backlog
+func BenchmarkGetRoomMessages(b *testing.B) {
+	database := testDB(b)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &models.User{
+		ID:         "usr_bench_000001",
+		Username:   "benchuser",
+		Password:   "hash",
+		LastRoom:   "",
+		CreatedAt:  now.Format(time.RFC3339),
+		ModifiedAt: now.Format(time.RFC3339),
+	}
+	if err := user.Insert(ctx, database); err != nil {
+		b.Fatalf("Failed to create user: %v", err)
+	}
+
+	room := &models.Room{
+		ID:        "roo_bench_000001",
+		Name:      "bench-history",
+		RoomType:  "channel",
+		IsPrivate: models.FALSE,
+		IsDefault: models.FALSE,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	if err := room.Insert(ctx, database); err != nil {
+		b.Fatalf("Failed to create room: %v", err)
+	}
+
+	for i := 0; i < 5000; i++ {
+		msg := &models.Message{
+			ID:         models.GenerateMessageID(),
+			RoomID:     room.ID,
+			UserID:     user.ID,
+			Body:       "benchmark message body",
+			CreatedAt:  now.Format(time.RFC3339),
+			ModifiedAt: now.Format(time.RFC3339),
+		}
+		if err := msg.Insert(ctx, database); err != nil {
+			b.Fatalf("Failed to create message: %v", err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		messages, err := GetRoomMessages(ctx, database, room.ID, "", 50)
+		if err != nil {
+			b.Fatalf("GetRoomMessages failed: %v", err)
+		}
+		if len(messages) != 50 {
+			b.Fatalf("Expected 50 messages, got %d", len(messages))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+)
+
+// RemoveReaction deletes a user's emoji reaction from a message and returns
+// the total number of reactions with that emoji on the message afterwards.
+// Like AddReaction, the delete and the count share a transaction so
+// concurrent callers never broadcast a stale count. Removing a reaction
+// that does not exist is a no-op.
+func RemoveReaction(ctx context.Context, db *DB, messageID, userID, emoji string) (int, error) {
+	const deletestr = `DELETE FROM reactions ` +
+		`WHERE message_id = $1 AND user_id = $2 AND emoji = $3`
+	const countstr = `SELECT COUNT(*) FROM reactions ` +
+		`WHERE message_id = $1 AND emoji = $2`
+	db.logger.Debug("querying", "query", deletestr, "args", []any{messageID, userID, emoji})
+
+	tx, err := db.WriteDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, deletestr, messageID, userID, emoji); err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, countstr, messageID, emoji).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ClearMessageReactions deletes every reaction on a message and returns how
+// many rows were removed. It backs the soft-delete cascade: a deleted message
+// keeps its row, but its reactions must not linger in reaction queries.
+func ClearMessageReactions(ctx context.Context, db *DB, messageID string) (int64, error) {
+	const sqlstr = `DELETE FROM reactions WHERE message_id = $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{messageID})
+
+	res, err := db.ExecContext(ctx, sqlstr, messageID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+)
+
+// RoomAdmins returns the user IDs of a room's owners and admins.
+func RoomAdmins(ctx context.Context, db *DB, roomID string) ([]string, error) {
+	const sqlstr = `SELECT user_id FROM rooms_members ` +
+		`WHERE room_id = $1 AND role IN ($2, $3) ` +
+		`ORDER BY user_id`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, RoleOwner, RoleAdmin})
+	rows, err := db.QueryContext(ctx, sqlstr, roomID, RoleOwner, RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var admins []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		admins = append(admins, id)
+	}
+	return admins, rows.Err()
+}
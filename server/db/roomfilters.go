@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Action values for the room_filters.action column
+const (
+	FilterReject = "reject" // block messages containing the pattern
+	FilterMask   = "mask"   // store the message with matches replaced by asterisks
+)
+
+// RoomFilter is one content filter rule on a room.
+type RoomFilter struct {
+	Pattern   string
+	Action    string
+	CreatedAt string
+}
+
+// InsertRoomFilter adds a content filter rule to a room, replacing any
+// existing rule for the same pattern so an admin can switch a term between
+// reject and mask.
+func InsertRoomFilter(ctx context.Context, db *DB, roomID, pattern, action string) error {
+	const sqlstr = `INSERT INTO room_filters (room_id, pattern, action, created_at) ` +
+		`VALUES ($1, $2, $3, $4)` +
+		` ON CONFLICT (room_id, pattern) DO ` +
+		`UPDATE SET action = EXCLUDED.action`
+	createdAt := time.Now().Format(time.RFC3339)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, pattern, action, createdAt})
+	_, err := db.ExecContext(ctx, sqlstr, roomID, pattern, action, createdAt)
+	return err
+}
+
+// DeleteRoomFilter removes a content filter rule from a room. Returns true
+// if a rule was removed, false if no rule matched the pattern.
+func DeleteRoomFilter(ctx context.Context, db *DB, roomID, pattern string) (bool, error) {
+	const sqlstr = `DELETE FROM room_filters ` +
+		`WHERE room_id = $1 AND pattern = $2`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, pattern})
+	res, err := db.ExecContext(ctx, sqlstr, roomID, pattern)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ListRoomFilters returns a room's content filter rules, oldest first.
+func ListRoomFilters(ctx context.Context, db *DB, roomID string) ([]RoomFilter, error) {
+	const sqlstr = `SELECT pattern, action, created_at FROM room_filters ` +
+		`WHERE room_id = $1 ` +
+		`ORDER BY created_at, pattern`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID})
+	rows, err := db.QueryContext(ctx, sqlstr, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var filters []RoomFilter
+	for rows.Next() {
+		var f RoomFilter
+		if err := rows.Scan(&f.Pattern, &f.Action, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, rows.Err()
+}
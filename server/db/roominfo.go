@@ -14,50 +14,48 @@ type RoomMember struct {
 	Avatar      string
 }
 
-// RoomInfo contains room details and its members
+// RoomInfo contains room details, an accurate member count, and the first
+// page of members
 type RoomInfo struct {
 	Room        *models.Room
 	Members     []RoomMember
 	MemberCount int
+	// NextCursor continues the member list through list_members; empty
+	// when Members already holds everyone
+	NextCursor string
 }
 
-// GetRoomInfo fetches a room and its members
-func GetRoomInfo(ctx context.Context, db *DB, roomID string) (*RoomInfo, error) {
+// GetRoomInfo fetches a room, its total member count, and at most
+// memberLimit members (0 means the list_members default). The count comes
+// from COUNT(*) so it stays accurate no matter how many members the page
+// holds.
+func GetRoomInfo(ctx context.Context, db *DB, roomID string, memberLimit int) (*RoomInfo, error) {
 	// Get the room
 	room, err := models.RoomByID(ctx, db, roomID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the members with a join query
-	const sqlstr = `SELECT u.id, u.username, u.display_name, COALESCE(u.avatar, '') as avatar 
-		FROM users u
-		JOIN rooms_members rm ON rm.user_id = u.id
-		WHERE rm.room_id = $1
-		ORDER BY u.username ASC`
-
-	rows, err := db.QueryContext(ctx, sqlstr, roomID)
-	if err != nil {
+	const countSQL = `SELECT count(*) FROM rooms_members WHERE room_id = $1`
+	db.logger.Debug("querying", "query", countSQL, "args", []any{roomID})
+	var count int
+	if err := db.QueryRowContext(ctx, countSQL, roomID).Scan(&count); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var members []RoomMember
-	for rows.Next() {
-		var m RoomMember
-		if err := rows.Scan(&m.ID, &m.Username, &m.DisplayName, &m.Avatar); err != nil {
-			return nil, err
-		}
-		members = append(members, m)
-	}
 
-	if err := rows.Err(); err != nil {
+	listings, nextCursor, err := ListRoomMembers(ctx, db, roomID, "", memberLimit)
+	if err != nil {
 		return nil, err
 	}
+	members := make([]RoomMember, len(listings))
+	for i, l := range listings {
+		members[i] = l.RoomMember
+	}
 
 	return &RoomInfo{
 		Room:        room,
 		Members:     members,
-		MemberCount: len(members),
+		MemberCount: count,
+		NextCursor:  nextCursor,
 	}, nil
 }
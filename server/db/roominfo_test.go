@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"testing"
 )
 
@@ -17,7 +18,7 @@ func TestGetRoomInfo_Success(t *testing.T) {
 	addUserToRoom(t, database, user1.ID, room.ID)
 	addUserToRoom(t, database, user2.ID, room.ID)
 
-	info, err := GetRoomInfo(ctx, database, room.ID)
+	info, err := GetRoomInfo(ctx, database, room.ID, 0)
 	if err != nil {
 		t.Fatalf("GetRoomInfo failed: %v", err)
 	}
@@ -55,7 +56,7 @@ func TestGetRoomInfo_RoomNotFound(t *testing.T) {
 
 	ctx := context.Background()
 
-	_, err := GetRoomInfo(ctx, database, "roo_nonexistent1")
+	_, err := GetRoomInfo(ctx, database, "roo_nonexistent1", 0)
 	if err == nil {
 		t.Error("GetRoomInfo should fail for non-existent room")
 	}
@@ -69,7 +70,7 @@ func TestGetRoomInfo_NoMembers(t *testing.T) {
 
 	room := createTestRoom(t, database, "roo_test12345678", "test-channel", false)
 
-	info, err := GetRoomInfo(ctx, database, room.ID)
+	info, err := GetRoomInfo(ctx, database, room.ID, 0)
 	if err != nil {
 		t.Fatalf("GetRoomInfo failed: %v", err)
 	}
@@ -82,3 +83,43 @@ func TestGetRoomInfo_NoMembers(t *testing.T) {
 		t.Errorf("Expected 0 members, got %d", len(info.Members))
 	}
 }
+
+func TestGetRoomInfo_MemberCapKeepsCountAccurate(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	room := createTestRoom(t, database, "roo_infocap00001", "crowded", false)
+	for i := 0; i < 5; i++ {
+		user := createTestUser(t, database, fmt.Sprintf("usr_infocap0000%d", i), fmt.Sprintf("capmember%d", i))
+		addUserToRoom(t, database, user.ID, room.ID)
+	}
+
+	info, err := GetRoomInfo(ctx, database, room.ID, 2)
+	if err != nil {
+		t.Fatalf("GetRoomInfo failed: %v", err)
+	}
+
+	if info.MemberCount != 5 {
+		t.Errorf("Expected member count 5 regardless of the page size, got %d", info.MemberCount)
+	}
+	if len(info.Members) != 2 {
+		t.Errorf("Expected 2 members in the page, got %d", len(info.Members))
+	}
+	if info.NextCursor != info.Members[1].Username {
+		t.Errorf("Expected next cursor %q, got %q", info.Members[1].Username, info.NextCursor)
+	}
+
+	// A limit bigger than the room returns everyone and no cursor
+	info, err = GetRoomInfo(ctx, database, room.ID, 50)
+	if err != nil {
+		t.Fatalf("GetRoomInfo failed: %v", err)
+	}
+	if len(info.Members) != 5 {
+		t.Errorf("Expected all 5 members, got %d", len(info.Members))
+	}
+	if info.NextCursor != "" {
+		t.Errorf("Expected no next cursor, got %q", info.NextCursor)
+	}
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+)
+
+// GetRoomMemberIDs returns the set of user IDs belonging to a room.
+func GetRoomMemberIDs(ctx context.Context, db *DB, roomID string) (map[string]bool, error) {
+	const sqlstr = `SELECT user_id FROM rooms_members WHERE room_id = $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID})
+	rows, err := db.QueryContext(ctx, sqlstr, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		members[id] = true
+	}
+	return members, rows.Err()
+}
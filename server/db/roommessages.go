@@ -2,70 +2,104 @@ package db
 
 import (
 	"context"
-
-	"github.com/llimllib/hatchat/server/models"
+	"fmt"
+	"strconv"
 )
 
 // RoomMessage is a unified type for message history responses.
-// It wraps the dbtpl-generated types (RoomMessagesFirstPage and RoomMessagesWithCursor)
-// to provide a single interface for the API layer.
 type RoomMessage struct {
 	ID         string `json:"id"`
 	RoomID     string `json:"room_id"`
 	UserID     string `json:"user_id"`
 	Body       string `json:"body"`
+	Seq        int64  `json:"seq"` // monotonic ordering key, assigned on insert
 	CreatedAt  string `json:"created_at"`
 	ModifiedAt string `json:"modified_at"`
 	DeletedAt  string `json:"deleted_at"` // Empty string if not deleted, RFC3339 timestamp if soft-deleted
+	IsSystem   int64  `json:"is_system"`  // 1 = server-generated announcement (e.g. a pin notice)
 	Username   string `json:"username"`
 }
 
 // GetRoomMessages returns messages for a room with cursor-based pagination.
-// Messages are ordered by created_at DESC (newest first).
-// The cursor is a created_at timestamp - pass empty string for first page.
-// Returns messages older than the cursor.
+// Messages are ordered by seq DESC (newest first); seq is assigned in insert
+// order, so ordering is robust to server clock changes. The cursor is the
+// decimal seq of the oldest message seen - pass empty string for the first
+// page. Returns messages older than the cursor.
 func GetRoomMessages(ctx context.Context, db *DB, roomID string, cursor string, limit int) ([]*RoomMessage, error) {
-	if cursor == "" {
-		// First page - no cursor
-		results, err := models.RoomMessagesFirstPagesByRoomIDLimit(ctx, db, roomID, limit)
+	sqlstr := `SELECT m.id, m.room_id, m.user_id, m.body, m.seq, m.created_at, m.modified_at, ` +
+		`COALESCE(m.deleted_at, '') as deleted_at, m.enc_key_id, m.is_system, u.username ` +
+		`FROM messages m ` +
+		`JOIN users u ON m.user_id = u.id ` +
+		`WHERE m.room_id = $1`
+	args := []any{roomID}
+	if cursor != "" {
+		seq, err := strconv.ParseInt(cursor, 10, 64)
 		if err != nil {
+			return nil, fmt.Errorf("invalid cursor %q: %v", cursor, err)
+		}
+		sqlstr += fmt.Sprintf(" AND m.seq < $%d", len(args)+1)
+		args = append(args, seq)
+	}
+	sqlstr += fmt.Sprintf(" ORDER BY m.seq DESC LIMIT $%d", len(args)+1)
+	args = append(args, limit)
+
+	db.logger.Debug("querying", "query", sqlstr, "args", args)
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*RoomMessage
+	for rows.Next() {
+		var m RoomMessage
+		var encKeyID string
+		if err := rows.Scan(&m.ID, &m.RoomID, &m.UserID, &m.Body, &m.Seq, &m.CreatedAt, &m.ModifiedAt, &m.DeletedAt, &encKeyID, &m.IsSystem, &m.Username); err != nil {
 			return nil, err
 		}
-		// Convert to unified type
-		messages := make([]*RoomMessage, len(results))
-		for i, r := range results {
-			messages[i] = &RoomMessage{
-				ID:         r.ID,
-				RoomID:     r.RoomID,
-				UserID:     r.UserID,
-				Body:       r.Body,
-				CreatedAt:  r.CreatedAt,
-				ModifiedAt: r.ModifiedAt,
-				DeletedAt:  r.DeletedAt,
-				Username:   r.Username,
-			}
+		if m.Body, err = db.Cipher.DecryptBody(m.Body, encKeyID); err != nil {
+			return nil, err
 		}
-		return messages, nil
+		messages = append(messages, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
+	return messages, nil
+}
 
-	// Subsequent pages - use cursor
-	results, err := models.RoomMessagesWithCursorsByRoomIDCursorLimit(ctx, db, roomID, cursor, limit)
+// GetRoomMessagesAscending returns up to limit messages with seq greater
+// than afterSeq in ascending order, for callers that walk a room's history
+// forward in bounded batches. Pass 0 to start from the beginning.
+func GetRoomMessagesAscending(ctx context.Context, db *DB, roomID string, afterSeq int64, limit int) ([]*RoomMessage, error) {
+	const sqlstr = `SELECT m.id, m.room_id, m.user_id, m.body, m.seq, m.created_at, m.modified_at, ` +
+		`COALESCE(m.deleted_at, '') as deleted_at, m.enc_key_id, m.is_system, u.username ` +
+		`FROM messages m ` +
+		`JOIN users u ON m.user_id = u.id ` +
+		`WHERE m.room_id = $1 AND m.seq > $2 ` +
+		`ORDER BY m.seq ASC LIMIT $3`
+
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomID, afterSeq, limit})
+	rows, err := db.QueryContext(ctx, sqlstr, roomID, afterSeq, limit)
 	if err != nil {
 		return nil, err
 	}
-	// Convert to unified type
-	messages := make([]*RoomMessage, len(results))
-	for i, r := range results {
-		messages[i] = &RoomMessage{
-			ID:         r.ID,
-			RoomID:     r.RoomID,
-			UserID:     r.UserID,
-			Body:       r.Body,
-			CreatedAt:  r.CreatedAt,
-			ModifiedAt: r.ModifiedAt,
-			DeletedAt:  r.DeletedAt,
-			Username:   r.Username,
+	defer rows.Close()
+
+	var messages []*RoomMessage
+	for rows.Next() {
+		var m RoomMessage
+		var encKeyID string
+		if err := rows.Scan(&m.ID, &m.RoomID, &m.UserID, &m.Body, &m.Seq, &m.CreatedAt, &m.ModifiedAt, &m.DeletedAt, &encKeyID, &m.IsSystem, &m.Username); err != nil {
+			return nil, err
+		}
+		if m.Body, err = db.Cipher.DecryptBody(m.Body, encKeyID); err != nil {
+			return nil, err
 		}
+		messages = append(messages, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 	return messages, nil
 }
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"strconv"
 	"testing"
 	"time"
 
@@ -144,7 +145,7 @@ func TestGetRoomMessages_Pagination(t *testing.T) {
 	}
 
 	// Second page - use cursor from last message of page 1
-	cursor := page1[2].CreatedAt
+	cursor := strconv.FormatInt(page1[2].Seq, 10)
 	page2, err := GetRoomMessages(ctx, database, room.ID, cursor, 3)
 	if err != nil {
 		t.Fatalf("GetRoomMessages page 2 failed: %v", err)
@@ -294,3 +295,59 @@ func TestGetRoomMessages_RoomIsolation(t *testing.T) {
 		t.Errorf("Wrong message body for room2")
 	}
 }
+
+// TestGetRoomMessages_ClockSkew tests that ordering and pagination follow
+// insert order (seq) even when created_at timestamps run backward, as they
+// can after an NTP correction
+func TestGetRoomMessages_ClockSkew(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	user := createTestUser(t, database, "usr_skew_0000001", "skewuser")
+	room := createTestRoom(t, database, "roo_skew_0000001", "skew", false)
+
+	// Insert in order A..F, but with created_at running backward so a
+	// timestamp sort would reverse them
+	for i := 0; i < 6; i++ {
+		msg := &models.Message{
+			ID:         models.GenerateMessageID(),
+			RoomID:     room.ID,
+			UserID:     user.ID,
+			Body:       "Message " + string(rune('A'+i)),
+			CreatedAt:  now.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339),
+			ModifiedAt: now.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339),
+		}
+		if err := msg.Insert(ctx, database); err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+	}
+
+	// The most recently inserted message comes first despite having the
+	// oldest timestamp
+	page1, err := GetRoomMessages(ctx, database, room.ID, "", 3)
+	if err != nil {
+		t.Fatalf("GetRoomMessages page 1 failed: %v", err)
+	}
+	if len(page1) != 3 {
+		t.Fatalf("Expected 3 messages on page 1, got %d", len(page1))
+	}
+	if page1[0].Body != "Message F" || page1[2].Body != "Message D" {
+		t.Errorf("Expected F, E, D on page 1, got %s, %s, %s", page1[0].Body, page1[1].Body, page1[2].Body)
+	}
+
+	// Pagination continues in insert order with no gaps or repeats
+	cursor := strconv.FormatInt(page1[2].Seq, 10)
+	page2, err := GetRoomMessages(ctx, database, room.ID, cursor, 3)
+	if err != nil {
+		t.Fatalf("GetRoomMessages page 2 failed: %v", err)
+	}
+	if len(page2) != 3 {
+		t.Fatalf("Expected 3 messages on page 2, got %d", len(page2))
+	}
+	if page2[0].Body != "Message C" || page2[2].Body != "Message A" {
+		t.Errorf("Expected C, B, A on page 2, got %s, %s, %s", page2[0].Body, page2[1].Body, page2[2].Body)
+	}
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+)
+
+// GetRoomPeers returns the distinct IDs of every user who shares at least
+// one room with the given user, including the user themself. Each peer
+// appears once no matter how many rooms overlap.
+func GetRoomPeers(ctx context.Context, db *DB, userID string) (map[string]bool, error) {
+	const sqlstr = `SELECT DISTINCT user_id FROM rooms_members ` +
+		`WHERE room_id IN (SELECT room_id FROM rooms_members WHERE user_id = $1)`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID})
+	rows, err := db.QueryContext(ctx, sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	peers := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		peers[id] = true
+	}
+	return peers, rows.Err()
+}
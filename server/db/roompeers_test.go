@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetRoomPeers(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	userA := createTestUser(t, database, "usr_rp_user_a_01", "peera")
+	userB := createTestUser(t, database, "usr_rp_user_b_01", "peerb")
+	userC := createTestUser(t, database, "usr_rp_user_c_01", "peerc")
+
+	// A and B share two rooms; C is off in a third
+	room1 := createTestRoom(t, database, "roo_rp_room_001", "peers-one", false)
+	room2 := createTestRoom(t, database, "roo_rp_room_002", "peers-two", false)
+	room3 := createTestRoom(t, database, "roo_rp_room_003", "peers-three", false)
+	addUserToRoom(t, database, userA.ID, room1.ID)
+	addUserToRoom(t, database, userB.ID, room1.ID)
+	addUserToRoom(t, database, userA.ID, room2.ID)
+	addUserToRoom(t, database, userB.ID, room2.ID)
+	addUserToRoom(t, database, userC.ID, room3.ID)
+
+	peers, err := GetRoomPeers(ctx, database, userA.ID)
+	if err != nil {
+		t.Fatalf("GetRoomPeers failed: %v", err)
+	}
+
+	if len(peers) != 2 {
+		t.Errorf("expected 2 peers (self and B), got %d: %v", len(peers), peers)
+	}
+	if !peers[userA.ID] {
+		t.Error("expected the user to be in their own peer set")
+	}
+	if !peers[userB.ID] {
+		t.Error("expected B, who shares two rooms, to be a peer")
+	}
+	if peers[userC.ID] {
+		t.Error("expected C, who shares no rooms, not to be a peer")
+	}
+}
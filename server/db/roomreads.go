@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GetLastRead returns the ID of the last message the user has read in a
+// room, or an empty string when no read position has been recorded.
+func GetLastRead(ctx context.Context, db *DB, userID, roomID string) (string, error) {
+	const sqlstr = `SELECT last_read_message_id FROM room_reads ` +
+		`WHERE user_id = $1 AND room_id = $2`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID})
+	var messageID string
+	if err := db.QueryRowContext(ctx, sqlstr, userID, roomID).Scan(&messageID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return messageID, nil
+}
+
+// SetLastRead records messageID as the user's read position in a room,
+// replacing any earlier position.
+func SetLastRead(ctx context.Context, db *DB, userID, roomID, messageID string) error {
+	const sqlstr = `INSERT INTO room_reads (user_id, room_id, last_read_message_id, updated_at) ` +
+		`VALUES ($1, $2, $3, $4) ` +
+		`ON CONFLICT (user_id, room_id) DO UPDATE SET ` +
+		`last_read_message_id = EXCLUDED.last_read_message_id, ` +
+		`updated_at = EXCLUDED.updated_at`
+	updatedAt := time.Now().Format(time.RFC3339)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, roomID, messageID, updatedAt})
+	_, err := db.ExecContext(ctx, sqlstr, userID, roomID, messageID, updatedAt)
+	return err
+}
@@ -8,6 +8,13 @@ import (
 	"github.com/llimllib/hatchat/server/protocol"
 )
 
+// Snippet window bounds for search results, in FTS5 tokens; 64 is the
+// largest window snippet() accepts
+const (
+	defaultSnippetTokens = 20
+	maxSnippetTokens     = 64
+)
+
 // SearchMessages performs a full-text search across messages the user has access to.
 // Returns results with snippets showing matched text with ** highlighting.
 func (db *DB) SearchMessages(
@@ -18,6 +25,7 @@ func (db *DB) SearchMessages(
 	filterUserID string, // optional: filter to specific user
 	cursor string, // pagination cursor (offset as string)
 	limit int,
+	snippetTokens int, // tokens of context around the match; 0 = default
 ) ([]protocol.SearchResult, string, error) {
 	if limit <= 0 {
 		limit = 20
@@ -25,6 +33,12 @@ func (db *DB) SearchMessages(
 	if limit > 100 {
 		limit = 100
 	}
+	if snippetTokens <= 0 {
+		snippetTokens = defaultSnippetTokens
+	}
+	if snippetTokens > maxSnippetTokens {
+		snippetTokens = maxSnippetTokens
+	}
 
 	// Parse cursor as offset
 	offset := 0
@@ -34,6 +48,12 @@ func (db *DB) SearchMessages(
 		}
 	}
 
+	// Degraded path for SQLite builds without FTS5: a LIKE scan with the
+	// same scoping, but no ranking or snippet highlighting
+	if !db.ftsAvailable {
+		return db.searchMessagesLike(ctx, userID, query, roomID, filterUserID, offset, limit)
+	}
+
 	// Build the query dynamically based on filters
 	// FTS5 MATCH syntax: we need to escape the query for FTS5
 	ftsQuery := escapeFTS5Query(query)
@@ -41,17 +61,20 @@ func (db *DB) SearchMessages(
 	args := []any{ftsQuery, userID}
 	argIndex := 3
 
-	// Base query with room membership check
-	sql := `
+	// Base query with room membership check. The snippet window is a
+	// clamped integer, so formatting it into the SQL is safe; snippet()
+	// always includes the matched tokens within the window.
+	sql := fmt.Sprintf(`
 		SELECT m.id, m.room_id, r.name, m.user_id, u.username,
-		       snippet(messages_fts, 0, '**', '**', '...', 20) as snippet,
-		       m.created_at
+		       snippet(messages_fts, 0, '**', '**', '...', %d) as snippet,
+		       m.created_at`, snippetTokens) + `
 		FROM messages_fts
 		JOIN messages m ON messages_fts.rowid = m.rowid
 		JOIN rooms r ON m.room_id = r.id
 		JOIN users u ON m.user_id = u.id
 		WHERE messages_fts MATCH $1
 		  AND m.deleted_at IS NULL
+		  AND m.is_system = 0
 		  AND m.room_id IN (SELECT room_id FROM rooms_members WHERE user_id = $2)
 	`
 
@@ -126,3 +149,89 @@ func escapeFTS5Query(query string) string {
 	// Join with spaces - FTS5 will AND them together by default
 	return strings.Join(quoted, " ")
 }
+
+// searchMessagesLike is the degraded search path used when SQLite lacks
+// FTS5. Every word of the query must appear in the message body, with the
+// same membership scoping and deleted/system-message exclusion as the FTS
+// path; the whole body stands in for the snippet since there is no match
+// highlighting.
+func (db *DB) searchMessagesLike(
+	ctx context.Context,
+	userID string,
+	query string,
+	roomID string,
+	filterUserID string,
+	offset int,
+	limit int,
+) ([]protocol.SearchResult, string, error) {
+	args := []any{userID}
+	argIndex := 2
+
+	sql := `
+		SELECT m.id, m.room_id, r.name, m.user_id, u.username,
+		       m.body as snippet,
+		       m.created_at
+		FROM messages m
+		JOIN rooms r ON m.room_id = r.id
+		JOIN users u ON m.user_id = u.id
+		WHERE m.deleted_at IS NULL
+		  AND m.is_system = 0
+		  AND m.room_id IN (SELECT room_id FROM rooms_members WHERE user_id = $1)
+	`
+
+	// AND together a LIKE clause per word, mirroring FTS5's implicit AND
+	for _, word := range strings.Fields(query) {
+		sql += fmt.Sprintf(` AND m.body LIKE $%d ESCAPE '\'`, argIndex)
+		args = append(args, "%"+escapeLikePattern(word)+"%")
+		argIndex++
+	}
+
+	if roomID != "" {
+		sql += fmt.Sprintf(" AND m.room_id = $%d", argIndex)
+		args = append(args, roomID)
+		argIndex++
+	}
+	if filterUserID != "" {
+		sql += fmt.Sprintf(" AND m.user_id = $%d", argIndex)
+		args = append(args, filterUserID)
+		argIndex++
+	}
+
+	sql += fmt.Sprintf(" ORDER BY m.created_at DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+	args = append(args, limit+1, offset) // Fetch one extra to check if there are more
+
+	rows, err := db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("search query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []protocol.SearchResult
+	for rows.Next() {
+		var r protocol.SearchResult
+		if err := rows.Scan(&r.MessageID, &r.RoomID, &r.RoomName, &r.UserID, &r.Username, &r.Snippet, &r.CreatedAt); err != nil {
+			return nil, "", fmt.Errorf("scanning search result: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("iterating search results: %w", err)
+	}
+
+	var nextCursor string
+	if len(results) > limit {
+		results = results[:limit]
+		nextCursor = fmt.Sprintf("%d", offset+limit)
+	}
+
+	return results, nextCursor, nil
+}
+
+// escapeLikePattern escapes LIKE wildcards in a search word so user input
+// matches literally; used with ESCAPE '\'
+func escapeLikePattern(word string) string {
+	word = strings.ReplaceAll(word, `\`, `\\`)
+	word = strings.ReplaceAll(word, `%`, `\%`)
+	word = strings.ReplaceAll(word, `_`, `\_`)
+	return word
+}
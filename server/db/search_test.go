@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,6 +25,10 @@ func setupSearchTestDB(t *testing.T) *DB {
 	dropSchema := `
 		DROP TABLE IF EXISTS messages_fts;
 		DROP TABLE IF EXISTS reactions;
+		DROP TABLE IF EXISTS audit_log;
+		DROP TABLE IF EXISTS starred_messages;
+		DROP TABLE IF EXISTS message_forwards;
+		DROP TABLE IF EXISTS room_reads;
 		DROP TABLE IF EXISTS messages;
 		DROP TABLE IF EXISTS rooms_members;
 		DROP TABLE IF EXISTS sessions;
@@ -95,7 +101,7 @@ func TestSearchMessages_Basic(t *testing.T) {
 	_ = msg2.Insert(ctx, testDB)
 
 	// Search for "world"
-	results, nextCursor, err := testDB.SearchMessages(ctx, user.ID, "world", "", "", "", 20)
+	results, nextCursor, err := testDB.SearchMessages(ctx, user.ID, "world", "", "", "", 20, 0)
 	if err != nil {
 		t.Fatalf("search failed: %v", err)
 	}
@@ -155,7 +161,7 @@ func TestSearchMessages_FTS5Escaping(t *testing.T) {
 	_ = msg.Insert(ctx, testDB)
 
 	// Search with FTS5 operators as literal text - should not cause errors
-	results, _, err := testDB.SearchMessages(ctx, user.ID, "AND OR", "", "", "", 20)
+	results, _, err := testDB.SearchMessages(ctx, user.ID, "AND OR", "", "", "", 20, 0)
 	if err != nil {
 		t.Fatalf("search with operators should not fail: %v", err)
 	}
@@ -186,3 +192,128 @@ func TestEscapeFTS5Query(t *testing.T) {
 		}
 	}
 }
+
+// TestSearchMessages_SnippetWindow tests that a larger snippet window
+// returns more surrounding context and that the match is always present,
+// even when it sits at a message boundary
+func TestSearchMessages_SnippetWindow(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:         "usr_test123456789a",
+		Username:   "alice",
+		Password:   "hash",
+		LastRoom:   "roo_general1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = user.Insert(ctx, testDB)
+
+	room := &models.Room{
+		ID:        "roo_general1234",
+		Name:      "general",
+		RoomType:  "channel",
+		IsPrivate: 0,
+		IsDefault: 1,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = room.Insert(ctx, testDB)
+
+	_, _ = testDB.ExecContext(ctx, "INSERT INTO rooms_members (user_id, room_id) VALUES ($1, $2)", user.ID, room.ID)
+
+	filler := strings.TrimSpace(strings.Repeat("lorem ipsum dolor sit amet ", 10))
+	bodies := []string{
+		filler + " needle " + filler, // match mid-message
+		"needle " + filler,           // match at the start
+		filler + " needle",           // match at the end
+	}
+	for i, body := range bodies {
+		msg := &models.Message{
+			ID:         fmt.Sprintf("msg_snip%08d", i),
+			RoomID:     room.ID,
+			UserID:     user.ID,
+			Body:       body,
+			CreatedAt:  time.Now().Add(time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+			ModifiedAt: time.Now().Format(time.RFC3339Nano),
+		}
+		_ = msg.Insert(ctx, testDB)
+	}
+
+	narrow, _, err := testDB.SearchMessages(ctx, user.ID, "needle", "", "", "", 20, 3)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	wide, _, err := testDB.SearchMessages(ctx, user.ID, "needle", "", "", "", 20, 40)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(narrow) != 3 || len(wide) != 3 {
+		t.Fatalf("expected 3 results from both searches, got %d and %d", len(narrow), len(wide))
+	}
+
+	for i := range narrow {
+		// The highlighted match is present at every window size
+		if !strings.Contains(narrow[i].Snippet, "**needle**") {
+			t.Errorf("expected the narrow snippet to contain the match, got %q", narrow[i].Snippet)
+		}
+		if !strings.Contains(wide[i].Snippet, "**needle**") {
+			t.Errorf("expected the wide snippet to contain the match, got %q", wide[i].Snippet)
+		}
+		// ...and a wider window carries more context
+		if len(wide[i].Snippet) <= len(narrow[i].Snippet) {
+			t.Errorf("expected the wide snippet to be longer than the narrow one, got %q vs %q",
+				wide[i].Snippet, narrow[i].Snippet)
+		}
+	}
+}
+
+// TestSearchMessages_SnippetWindowClamped tests that out-of-range windows
+// fall back to the default and the FTS5 maximum instead of failing
+func TestSearchMessages_SnippetWindowClamped(t *testing.T) {
+	testDB := setupSearchTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:         "usr_test123456789a",
+		Username:   "alice",
+		Password:   "hash",
+		LastRoom:   "roo_general1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = user.Insert(ctx, testDB)
+	room := &models.Room{
+		ID:        "roo_general1234",
+		Name:      "general",
+		RoomType:  "channel",
+		IsPrivate: 0,
+		IsDefault: 1,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = room.Insert(ctx, testDB)
+	_, _ = testDB.ExecContext(ctx, "INSERT INTO rooms_members (user_id, room_id) VALUES ($1, $2)", user.ID, room.ID)
+	msg := &models.Message{
+		ID:         "msg_snipclamp123",
+		RoomID:     room.ID,
+		UserID:     user.ID,
+		Body:       "a needle in a haystack",
+		CreatedAt:  time.Now().Format(time.RFC3339Nano),
+		ModifiedAt: time.Now().Format(time.RFC3339Nano),
+	}
+	_ = msg.Insert(ctx, testDB)
+
+	for _, tokens := range []int{-1, 1000} {
+		results, _, err := testDB.SearchMessages(ctx, user.ID, "needle", "", "", "", 20, tokens)
+		if err != nil {
+			t.Fatalf("search with snippet window %d failed: %v", tokens, err)
+		}
+		if len(results) != 1 || !strings.Contains(results[0].Snippet, "**needle**") {
+			t.Errorf("expected 1 highlighted result for window %d, got %+v", tokens, results)
+		}
+	}
+}
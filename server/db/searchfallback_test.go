@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// setupNoFTSDB creates a test database that simulates a SQLite build
+// without FTS5: the schema is applied with FTS statements stripped and
+// search takes the LIKE fallback path
+func setupNoFTSDB(t *testing.T) *DB {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	testDB, err := NewDB("file::memory:?cache=shared", logger)
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+
+	// Clean up any existing tables
+	dropSchema := `
+		DROP TABLE IF EXISTS messages_fts;
+		DROP TABLE IF EXISTS reactions;
+		DROP TABLE IF EXISTS audit_log;
+		DROP TABLE IF EXISTS pinned_messages;
+		DROP TABLE IF EXISTS starred_messages;
+		DROP TABLE IF EXISTS message_forwards;
+		DROP TABLE IF EXISTS room_reads;
+		DROP TABLE IF EXISTS messages;
+		DROP TABLE IF EXISTS rooms_members;
+		DROP TABLE IF EXISTS sessions;
+		DROP TABLE IF EXISTS rooms;
+		DROP TABLE IF EXISTS users;
+		DROP TRIGGER IF EXISTS messages_fts_insert;
+		DROP TRIGGER IF EXISTS messages_fts_update;
+		DROP TRIGGER IF EXISTS messages_fts_delete;
+	`
+	_, err = testDB.ExecContext(context.Background(), dropSchema)
+	if err != nil {
+		t.Fatalf("failed to drop existing tables: %v", err)
+	}
+
+	// Flip the flag before the schema runs, exactly as NewDB would have
+	// set it on a build without FTS5
+	testDB.ftsAvailable = false
+	if err := testDB.RunSQLFile("../../schema.sql"); err != nil {
+		t.Fatalf("expected the schema to apply without FTS5, got: %v", err)
+	}
+	return testDB
+}
+
+// TestSearchNoFTS_SchemaSkipsFTS tests that applying the schema without
+// FTS5 succeeds and creates neither the virtual table nor its triggers
+func TestSearchNoFTS_SchemaSkipsFTS(t *testing.T) {
+	testDB := setupNoFTSDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+
+	var count int
+	err := testDB.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE name LIKE 'messages_fts%'").Scan(&count)
+	if err != nil {
+		t.Fatalf("failed to query sqlite_master: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected no FTS objects in the schema, found %d", count)
+	}
+
+	// The non-FTS triggers survive the strip
+	err = testDB.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE name = 'messages_assign_seq'").Scan(&count)
+	if err != nil {
+		t.Fatalf("failed to query sqlite_master: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the seq trigger to survive, found %d", count)
+	}
+}
+
+// TestSearchNoFTS_ReturnsMatches tests that the LIKE fallback returns
+// matching messages with the same membership scoping and deleted-message
+// exclusion as the FTS path
+func TestSearchNoFTS_ReturnsMatches(t *testing.T) {
+	testDB := setupNoFTSDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:         "usr_test123456789a",
+		Username:   "alice",
+		Password:   "hash",
+		LastRoom:   "roo_general1234",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		ModifiedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = user.Insert(ctx, testDB)
+
+	room := &models.Room{
+		ID:        "roo_general1234",
+		Name:      "general",
+		RoomType:  "channel",
+		IsPrivate: 0,
+		IsDefault: 1,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = room.Insert(ctx, testDB)
+	private := &models.Room{
+		ID:        "roo_private1234",
+		Name:      "private",
+		RoomType:  "channel",
+		IsPrivate: 1,
+		IsDefault: 0,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	_ = private.Insert(ctx, testDB)
+
+	_, _ = testDB.ExecContext(ctx, "INSERT INTO rooms_members (user_id, room_id) VALUES ($1, $2)", user.ID, room.ID)
+
+	bodies := map[string]string{
+		"msg_nofts1234567": "Hello world",
+		"msg_nofts2345678": "Goodbye world",
+		"msg_nofts3456789": "nothing to see here",
+	}
+	i := 0
+	for id, body := range bodies {
+		msg := &models.Message{
+			ID:         id,
+			RoomID:     room.ID,
+			UserID:     user.ID,
+			Body:       body,
+			CreatedAt:  time.Now().Add(time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+			ModifiedAt: time.Now().Format(time.RFC3339Nano),
+		}
+		_ = msg.Insert(ctx, testDB)
+		i++
+	}
+	// A deleted match and a match in a room the user isn't a member of
+	// must both stay hidden
+	deleted := &models.Message{
+		ID:         "msg_nofts4567890",
+		RoomID:     room.ID,
+		UserID:     user.ID,
+		Body:       "deleted world",
+		CreatedAt:  time.Now().Format(time.RFC3339Nano),
+		ModifiedAt: time.Now().Format(time.RFC3339Nano),
+	}
+	_ = deleted.Insert(ctx, testDB)
+	_, _ = testDB.ExecContext(ctx, "UPDATE messages SET deleted_at = $1 WHERE id = $2",
+		time.Now().Format(time.RFC3339Nano), deleted.ID)
+	hidden := &models.Message{
+		ID:         "msg_nofts5678901",
+		RoomID:     private.ID,
+		UserID:     user.ID,
+		Body:       "secret world",
+		CreatedAt:  time.Now().Format(time.RFC3339Nano),
+		ModifiedAt: time.Now().Format(time.RFC3339Nano),
+	}
+	_ = hidden.Insert(ctx, testDB)
+
+	results, _, err := testDB.SearchMessages(ctx, user.ID, "world", "", "", "", 20, 0)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d: %+v", len(results), results)
+	}
+	for _, r := range results {
+		if r.Snippet != bodies[r.MessageID] {
+			t.Errorf("expected the snippet to be the message body %q, got %q", bodies[r.MessageID], r.Snippet)
+		}
+	}
+
+	// Pagination still works on the fallback path
+	page, cursor, err := testDB.SearchMessages(ctx, user.ID, "world", "", "", "", 1, 0)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(page) != 1 || cursor == "" {
+		t.Fatalf("expected 1 result and a next cursor, got %d and %q", len(page), cursor)
+	}
+	rest, cursor, err := testDB.SearchMessages(ctx, user.ID, "world", "", "", cursor, 1, 0)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(rest) != 1 || rest[0].MessageID == page[0].MessageID {
+		t.Errorf("expected the second page to hold the other match, got %+v", rest)
+	}
+	if cursor != "" {
+		t.Errorf("expected no cursor on the last page, got %q", cursor)
+	}
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GetSeenByForMessages returns, for each of the given messages in a room,
+// the IDs of members whose read position is at or past that message. A
+// member's read position is their room_reads pointer; they have seen a
+// message when the pointed-at message's sequence number is at least the
+// message's own.
+func GetSeenByForMessages(ctx context.Context, db *DB, roomID string, messageIDs []string) (map[string][]string, error) {
+	if len(messageIDs) == 0 {
+		return make(map[string][]string), nil
+	}
+
+	// Build parameterized IN clause; $1 is the room ID
+	placeholders := make([]string, len(messageIDs))
+	args := make([]interface{}, 0, len(messageIDs)+1)
+	args = append(args, roomID)
+	for i, id := range messageIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, id)
+	}
+
+	query := `SELECT m.id, rr.user_id
+		FROM messages m
+		JOIN room_reads rr ON rr.room_id = m.room_id
+		JOIN messages lr ON lr.id = rr.last_read_message_id
+		WHERE m.room_id = $1
+		AND lr.seq >= m.seq
+		AND m.id IN (` + strings.Join(placeholders, ",") + `)
+		ORDER BY m.id, rr.user_id`
+
+	db.logger.Debug("querying", "query", query, "args", args)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]string)
+	for rows.Next() {
+		var messageID, userID string
+		if err := rows.Scan(&messageID, &userID); err != nil {
+			return nil, err
+		}
+		result[messageID] = append(result[messageID], userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
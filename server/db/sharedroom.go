@@ -0,0 +1,23 @@
+package db
+
+import (
+	"context"
+)
+
+// UsersShareChannel checks whether two users are both members of at least
+// one channel. DMs don't count; sharing a DM shouldn't satisfy a
+// shared-room DM policy.
+func UsersShareChannel(ctx context.Context, db *DB, userA, userB string) (bool, error) {
+	const sqlstr = `SELECT EXISTS(` +
+		`SELECT 1 FROM rooms_members a ` +
+		`JOIN rooms_members b ON a.room_id = b.room_id ` +
+		`JOIN rooms r ON r.id = a.room_id ` +
+		`WHERE a.user_id = $1 AND b.user_id = $2 AND r.room_type = 'channel'` +
+		`) AS shares_channel`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userA, userB})
+	var shares bool
+	if err := db.QueryRowContext(ctx, sqlstr, userA, userB).Scan(&shares); err != nil {
+		return false, err
+	}
+	return shares, nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+)
+
+// ConsumeSignupInvite atomically marks an unused invite code as used,
+// reporting whether this caller won it. The unused predicate and the write
+// are a single UPDATE, so concurrent registrations presenting the same
+// code can't each pass a separate check first; exactly one sees true.
+func ConsumeSignupInvite(ctx context.Context, db *DB, code, usedAt string) (bool, error) {
+	const sqlstr = `UPDATE signup_invites SET used_at = $1 ` +
+		`WHERE code = $2 AND used_at IS NULL`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{usedAt, code})
+
+	res, err := db.ExecContext(ctx, sqlstr, usedAt, code)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleaseSignupInvite returns a consumed invite code to the unused pool,
+// for registrations that fail after winning the code
+func ReleaseSignupInvite(ctx context.Context, db *DB, code string) error {
+	const sqlstr = `UPDATE signup_invites SET used_at = NULL WHERE code = $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{code})
+	_, err := db.ExecContext(ctx, sqlstr, code)
+	return err
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// TestConsumeSignupInvite tests that concurrent consumers of a single-use
+// invite code see exactly one winner
+func TestConsumeSignupInvite(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	invite := &models.SignupInvite{
+		Code:      "welcome-race",
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := invite.Insert(ctx, database); err != nil {
+		t.Fatalf("Failed to insert invite: %v", err)
+	}
+
+	const consumers = 10
+	var wg sync.WaitGroup
+	wins := make(chan bool, consumers)
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			won, err := ConsumeSignupInvite(ctx, database, "welcome-race", time.Now().Format(time.RFC3339))
+			if err != nil {
+				t.Errorf("ConsumeSignupInvite failed: %v", err)
+				return
+			}
+			wins <- won
+		}()
+	}
+	wg.Wait()
+	close(wins)
+
+	winners := 0
+	for won := range wins {
+		if won {
+			winners++
+		}
+	}
+	if winners != 1 {
+		t.Errorf("Expected exactly 1 winner, got %d", winners)
+	}
+}
+
+// TestConsumeSignupInviteUnknownCode tests that consuming a code that was
+// never issued reports no win without erroring
+func TestConsumeSignupInviteUnknownCode(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	won, err := ConsumeSignupInvite(context.Background(), database, "no-such-code", time.Now().Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("ConsumeSignupInvite failed: %v", err)
+	}
+	if won {
+		t.Error("Expected an unknown code not to be consumable")
+	}
+}
+
+// TestReleaseSignupInvite tests that a released code can be consumed again
+func TestReleaseSignupInvite(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	invite := &models.SignupInvite{
+		Code:      "welcome-release",
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := invite.Insert(ctx, database); err != nil {
+		t.Fatalf("Failed to insert invite: %v", err)
+	}
+
+	won, err := ConsumeSignupInvite(ctx, database, "welcome-release", time.Now().Format(time.RFC3339))
+	if err != nil || !won {
+		t.Fatalf("Expected the first consume to win, got won=%v err=%v", won, err)
+	}
+	won, err = ConsumeSignupInvite(ctx, database, "welcome-release", time.Now().Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("ConsumeSignupInvite failed: %v", err)
+	}
+	if won {
+		t.Error("Expected a consumed code not to be consumable again")
+	}
+
+	if err := ReleaseSignupInvite(ctx, database, "welcome-release"); err != nil {
+		t.Fatalf("ReleaseSignupInvite failed: %v", err)
+	}
+	won, err = ConsumeSignupInvite(ctx, database, "welcome-release", time.Now().Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("ConsumeSignupInvite failed: %v", err)
+	}
+	if !won {
+		t.Error("Expected a released code to be consumable again")
+	}
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// StarMessage records a private star on a message for a user. Starring a
+// message that is already starred is a no-op.
+func StarMessage(ctx context.Context, db *DB, userID, messageID string) error {
+	const sqlstr = `INSERT INTO starred_messages (user_id, message_id, created_at) ` +
+		`VALUES ($1, $2, $3) ` +
+		`ON CONFLICT (user_id, message_id) DO NOTHING`
+	createdAt := time.Now().Format(time.RFC3339Nano)
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, messageID, createdAt})
+	_, err := db.ExecContext(ctx, sqlstr, userID, messageID, createdAt)
+	return err
+}
+
+// UnstarMessage removes a user's star from a message. Unstarring a message
+// that isn't starred is a no-op.
+func UnstarMessage(ctx context.Context, db *DB, userID, messageID string) error {
+	const sqlstr = `DELETE FROM starred_messages WHERE user_id = $1 AND message_id = $2`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, messageID})
+	_, err := db.ExecContext(ctx, sqlstr, userID, messageID)
+	return err
+}
+
+// GetStarredMessages returns the user's starred messages newest star first,
+// with room context. Deleted messages and messages in rooms the user is no
+// longer a member of are filtered out.
+func GetStarredMessages(ctx context.Context, db *DB, userID string) ([]protocol.StarredMessage, error) {
+	const sqlstr = `SELECT m.id, m.room_id, m.user_id, u.username, m.body, ` +
+		`m.created_at, m.modified_at, m.enc_key_id, r.name, s.created_at ` +
+		`FROM starred_messages s ` +
+		`JOIN messages m ON m.id = s.message_id ` +
+		`JOIN users u ON u.id = m.user_id ` +
+		`JOIN rooms r ON r.id = m.room_id ` +
+		`WHERE s.user_id = $1 ` +
+		`AND m.deleted_at IS NULL ` +
+		`AND m.room_id IN (SELECT room_id FROM rooms_members WHERE user_id = $1) ` +
+		`ORDER BY s.created_at DESC`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID})
+	rows, err := db.QueryContext(ctx, sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var starred []protocol.StarredMessage
+	for rows.Next() {
+		var s protocol.StarredMessage
+		var encKeyID string
+		if err := rows.Scan(&s.Message.ID, &s.Message.RoomID, &s.Message.UserID,
+			&s.Message.Username, &s.Message.Body, &s.Message.CreatedAt,
+			&s.Message.ModifiedAt, &encKeyID, &s.RoomName, &s.StarredAt); err != nil {
+			return nil, err
+		}
+		if s.Message.Body, err = db.Cipher.DecryptBody(s.Message.Body, encKeyID); err != nil {
+			return nil, err
+		}
+		starred = append(starred, s)
+	}
+	return starred, rows.Err()
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+)
+
+// GetUnreadCounts counts messages past the user's read position in each of
+// the user's unmuted rooms, most recently active first. Rooms the user has
+// never read count every message; rooms with nothing unread are omitted.
+func GetUnreadCounts(ctx context.Context, db *DB, userID string) ([]*RoomActivity, error) {
+	const sqlstr = `SELECT m.room_id, r.name, COUNT(*) AS unread ` +
+		`FROM messages m ` +
+		`JOIN rooms r ON m.room_id = r.id ` +
+		`JOIN rooms_members rm ON rm.room_id = m.room_id AND rm.user_id = $1 AND rm.muted = 0 ` +
+		`LEFT JOIN room_reads rr ON rr.room_id = m.room_id AND rr.user_id = $1 ` +
+		`LEFT JOIN messages lr ON lr.id = rr.last_read_message_id ` +
+		`WHERE m.deleted_at IS NULL ` +
+		`AND (lr.seq IS NULL OR m.seq > lr.seq) ` +
+		`GROUP BY m.room_id ` +
+		`ORDER BY MAX(m.seq) DESC`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID})
+
+	rows, err := db.QueryContext(ctx, sqlstr, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var unread []*RoomActivity
+	for rows.Next() {
+		var a RoomActivity
+		if err := rows.Scan(&a.RoomID, &a.RoomName, &a.Count); err != nil {
+			return nil, err
+		}
+		unread = append(unread, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return unread, nil
+}
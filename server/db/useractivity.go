@@ -0,0 +1,14 @@
+package db
+
+import (
+	"context"
+)
+
+// TouchUserActivity stamps a user's last_active_at. Callers are expected
+// to throttle; see api.TouchUserActivity.
+func TouchUserActivity(ctx context.Context, db *DB, userID, lastActiveAt string) error {
+	const sqlstr = `UPDATE users SET last_active_at = $1 WHERE id = $2`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{lastActiveAt, userID})
+	_, err := db.ExecContext(ctx, sqlstr, lastActiveAt, userID)
+	return err
+}
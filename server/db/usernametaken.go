@@ -0,0 +1,21 @@
+package db
+
+import (
+	"context"
+)
+
+// UsernameTaken reports whether a username is already in use, compared
+// case-insensitively. The unique index on users.username is case-sensitive,
+// so this is the check that prevents "Alice" registering alongside "alice".
+func UsernameTaken(ctx context.Context, db *DB, username string) (bool, error) {
+	const sqlstr = `SELECT EXISTS(` +
+		`SELECT 1 FROM users ` +
+		`WHERE username = $1 COLLATE NOCASE` +
+		`) AS taken`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{username})
+	var taken bool
+	if err := db.QueryRowContext(ctx, sqlstr, username).Scan(&taken); err != nil {
+		return false, err
+	}
+	return taken, nil
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// UserByUsernameNoCase retrieves the user whose username matches
+// case-insensitively, for lookups like login and mention resolution where
+// the typed casing shouldn't matter. The nocase unique index guarantees at
+// most one match; the ORDER BY keeps the result deterministic on databases
+// the migration hasn't cleaned up yet.
+func UserByUsernameNoCase(ctx context.Context, db *DB, username string) (*models.User, error) {
+	const sqlstr = `SELECT id FROM users ` +
+		`WHERE username = $1 COLLATE NOCASE ` +
+		`ORDER BY created_at, id LIMIT 1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{username})
+	var id string
+	if err := db.QueryRowContext(ctx, sqlstr, username).Scan(&id); err != nil {
+		return nil, err
+	}
+	return models.UserByID(ctx, db, id)
+}
+
+// MigrateUsernameCollation renames case-variant duplicate usernames —
+// "Alice" alongside "alice" — so the case-insensitive unique index in the
+// schema can apply. The earliest-created user in each group keeps their
+// name; later ones get a numeric suffix and a logged warning. Runs at
+// startup before the schema file, and is a no-op on fresh databases and on
+// databases without duplicates.
+func (db *DB) MigrateUsernameCollation(ctx context.Context) error {
+	// On first boot the users table doesn't exist yet
+	var tables int
+	if err := db.QueryRowContext(ctx,
+		`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'`,
+	).Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	const sqlstr = `SELECT id, username FROM users ` +
+		`WHERE username COLLATE NOCASE IN (` +
+		`SELECT username FROM users GROUP BY username COLLATE NOCASE HAVING count(*) > 1` +
+		`) ` +
+		`ORDER BY username COLLATE NOCASE, created_at, id`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{})
+	rows, err := db.QueryContext(ctx, sqlstr)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type dupe struct{ id, username string }
+	var dupes []dupe
+	for rows.Next() {
+		var d dupe
+		if err := rows.Scan(&d.id, &d.username); err != nil {
+			return err
+		}
+		dupes = append(dupes, d)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	lastKept := ""
+	for _, d := range dupes {
+		// The first user in each fold-group keeps their name
+		if !foldEqual(d.username, lastKept) {
+			lastKept = d.username
+			continue
+		}
+		renamed := ""
+		for n := 2; ; n++ {
+			candidate := fmt.Sprintf("%s_%d", d.username, n)
+			taken, err := UsernameTaken(ctx, db, candidate)
+			if err != nil {
+				return err
+			}
+			if !taken {
+				renamed = candidate
+				break
+			}
+		}
+		if _, err := db.ExecContext(ctx,
+			`UPDATE users SET username = $1 WHERE id = $2`, renamed, d.id); err != nil {
+			return fmt.Errorf("renaming duplicate username %s: %w", d.username, err)
+		}
+		db.logger.Warn("renamed case-variant duplicate username", "user", d.id, "from", d.username, "to", renamed)
+	}
+	return nil
+}
+
+// foldEqual reports whether two usernames are equal ignoring ASCII case,
+// matching SQLite's NOCASE collation
+func foldEqual(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
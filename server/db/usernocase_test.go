@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// TestUserByUsernameNoCase tests that the lookup ignores case and errors
+// for unknown names
+func TestUserByUsernameNoCase(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	user := createTestUser(t, database, "usr_nocase000001", "CaseLookup")
+
+	for _, typed := range []string{"CaseLookup", "caselookup", "CASELOOKUP"} {
+		found, err := UserByUsernameNoCase(ctx, database, typed)
+		if err != nil {
+			t.Fatalf("UserByUsernameNoCase(%q) failed: %v", typed, err)
+		}
+		if found.ID != user.ID {
+			t.Errorf("Expected %q to find %s, got %s", typed, user.ID, found.ID)
+		}
+	}
+
+	if _, err := UserByUsernameNoCase(ctx, database, "nosuchcaseuser"); err == nil {
+		t.Error("Expected an error for an unknown username")
+	}
+}
+
+// TestUsernameCaseInsensitiveUnique tests that the schema rejects a
+// case-variant duplicate of an existing username
+func TestUsernameCaseInsensitiveUnique(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	createTestUser(t, database, "usr_nocase000011", "dupefold")
+
+	now := time.Now().Format(time.RFC3339)
+	dupe := &models.User{
+		ID:         "usr_nocase000012",
+		Username:   "DupeFold",
+		Password:   "hashedpassword",
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+	if err := dupe.Insert(context.Background(), database); err == nil {
+		t.Error("Expected inserting a case-variant duplicate username to fail")
+	}
+}
+
+// TestMigrateUsernameCollation tests that existing case-variant duplicates
+// are renamed deterministically, keeping the earliest-created user's name
+func TestMigrateUsernameCollation(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+
+	// Duplicates can only exist on databases created before the nocase
+	// index; simulate one by dropping it
+	if _, err := database.ExecContext(ctx, "DROP INDEX IF EXISTS users_username_nocase"); err != nil {
+		t.Fatalf("Failed to drop index: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	for i, username := range []string{"MigrAlice", "migralice", "MIGRALICE"} {
+		user := &models.User{
+			ID:         "usr_nocase00002" + string(rune('1'+i)),
+			Username:   username,
+			Password:   "hashedpassword",
+			CreatedAt:  base.Add(time.Duration(i) * time.Minute).Format(time.RFC3339),
+			ModifiedAt: base.Format(time.RFC3339),
+		}
+		if err := user.Insert(ctx, database); err != nil {
+			t.Fatalf("Failed to insert user %q: %v", username, err)
+		}
+	}
+
+	if err := database.MigrateUsernameCollation(ctx); err != nil {
+		t.Fatalf("MigrateUsernameCollation failed: %v", err)
+	}
+
+	// The earliest-created user keeps the name; the later two get suffixes
+	for id, want := range map[string]string{
+		"usr_nocase000021": "MigrAlice",
+		"usr_nocase000022": "migralice_2",
+		"usr_nocase000023": "MIGRALICE_3",
+	} {
+		user, err := models.UserByID(ctx, database, id)
+		if err != nil {
+			t.Fatalf("Failed to fetch user %s: %v", id, err)
+		}
+		if user.Username != want {
+			t.Errorf("Expected %s to be named %q, got %q", id, want, user.Username)
+		}
+	}
+
+	// With the duplicates resolved, the index applies cleanly
+	if _, err := database.ExecContext(ctx,
+		"CREATE UNIQUE INDEX users_username_nocase ON users(username COLLATE NOCASE)"); err != nil {
+		t.Errorf("Expected the nocase index to apply after migration: %v", err)
+	}
+
+	// Running the migration again is a no-op
+	if err := database.MigrateUsernameCollation(ctx); err != nil {
+		t.Errorf("Expected a second migration run to be a no-op: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetUserPrefs returns a user's stored preference JSON, or the empty string
+// when they have never saved any
+func GetUserPrefs(ctx context.Context, db *DB, userID string) (string, error) {
+	const sqlstr = `SELECT prefs FROM user_prefs WHERE user_id = $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID})
+	var prefs string
+	if err := db.QueryRowContext(ctx, sqlstr, userID).Scan(&prefs); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return prefs, nil
+}
+
+// SetUserPrefs stores a user's preference JSON, replacing whatever was
+// there before. Validation of the blob is the caller's concern.
+func SetUserPrefs(ctx context.Context, db *DB, userID, prefs, modifiedAt string) error {
+	const sqlstr = `INSERT INTO user_prefs (user_id, prefs, modified_at) ` +
+		`VALUES ($1, $2, $3) ` +
+		`ON CONFLICT (user_id) DO UPDATE SET prefs = excluded.prefs, modified_at = excluded.modified_at`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{userID, prefs, modifiedAt})
+	_, err := db.ExecContext(ctx, sqlstr, userID, prefs, modifiedAt)
+	return err
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// BatchUser is one row of a batch profile lookup
+type BatchUser struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Status      string `json:"status"`
+	Avatar      string `json:"avatar"`
+}
+
+// GetUsersByIDs fetches the profiles for a set of user IDs in one query.
+// Unknown IDs are silently skipped; results come back ordered by username.
+func GetUsersByIDs(ctx context.Context, db *DB, userIDs []string) ([]*BatchUser, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	// Build parameterized IN clause
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	sqlstr := `SELECT id, username, display_name, status, COALESCE(avatar, '') as avatar ` +
+		`FROM users ` +
+		`WHERE id IN (` + strings.Join(placeholders, ",") + `) ` +
+		`ORDER BY username`
+	db.logger.Debug("querying", "query", sqlstr, "args", args)
+
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*BatchUser
+	for rows.Next() {
+		var u BatchUser
+		if err := rows.Scan(&u.ID, &u.Username, &u.DisplayName, &u.Status, &u.Avatar); err != nil {
+			return nil, err
+		}
+		users = append(users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
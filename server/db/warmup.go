@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+)
+
+// WarmRecentRooms primes the SQLite page cache after a cold start by
+// reading the latest history page of the most recently active rooms and
+// touching the FTS index. Returns how many rooms were warmed. The caller
+// bounds the pass with ctx; a deadline mid-pass stops early without error.
+func WarmRecentRooms(ctx context.Context, db *DB, roomCount, pageSize int) (int, error) {
+	const sqlstr = `SELECT id FROM rooms ` +
+		`ORDER BY COALESCE(last_message_at, created_at) DESC ` +
+		`LIMIT $1`
+	db.logger.Debug("querying", "query", sqlstr, "args", []any{roomCount})
+	rows, err := db.QueryContext(ctx, sqlstr, roomCount)
+	if err != nil {
+		// The time bound expiring is a clean stop, not a failure
+		if ctx.Err() != nil {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer rows.Close()
+
+	var roomIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		roomIDs = append(roomIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	warmed := 0
+	for _, roomID := range roomIDs {
+		if ctx.Err() != nil {
+			return warmed, nil
+		}
+		// The same query the first viewer of the room would run
+		if _, err := GetRoomMessages(ctx, db, roomID, "", pageSize); err != nil {
+			// A deadline hitting mid-query is the time bound working, not
+			// a failure
+			if ctx.Err() != nil {
+				return warmed, nil
+			}
+			return warmed, err
+		}
+		warmed++
+	}
+
+	// One throwaway match walks the FTS index structure into the cache
+	if db.ftsAvailable && ctx.Err() == nil {
+		var n int
+		const ftsstr = `SELECT COUNT(*) FROM messages_fts WHERE messages_fts MATCH 'warmup'`
+		if err := db.QueryRowContext(ctx, ftsstr).Scan(&n); err != nil && ctx.Err() == nil {
+			return warmed, err
+		}
+	}
+
+	return warmed, nil
+}
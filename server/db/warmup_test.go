@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+func TestWarmRecentRooms_SeededDB(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	for i := 0; i < 3; i++ {
+		room := createTestRoom(t, database, fmt.Sprintf("roo_warm%08d", i), fmt.Sprintf("warm-%d", i), i == 0)
+		addUserToRoom(t, database, user.ID, room.ID)
+		for j := 0; j < 5; j++ {
+			msg := &models.Message{
+				ID:         models.GenerateMessageID(),
+				RoomID:     room.ID,
+				UserID:     user.ID,
+				Body:       fmt.Sprintf("warm message %d", j),
+				CreatedAt:  now.Add(time.Duration(j) * time.Minute).Format(time.RFC3339),
+				ModifiedAt: now.Add(time.Duration(j) * time.Minute).Format(time.RFC3339),
+			}
+			if err := msg.Insert(ctx, database); err != nil {
+				t.Fatalf("Failed to create message: %v", err)
+			}
+		}
+	}
+
+	warmed, err := WarmRecentRooms(ctx, database, 10, 50)
+	if err != nil {
+		t.Fatalf("WarmRecentRooms failed: %v", err)
+	}
+	if warmed != 3 {
+		t.Errorf("Expected 3 rooms warmed, got %d", warmed)
+	}
+
+	// The room count bound is honored
+	warmed, err = WarmRecentRooms(ctx, database, 2, 50)
+	if err != nil {
+		t.Fatalf("WarmRecentRooms failed: %v", err)
+	}
+	if warmed != 2 {
+		t.Errorf("Expected 2 rooms warmed at roomCount 2, got %d", warmed)
+	}
+}
+
+func TestWarmRecentRooms_RespectsTimeBound(t *testing.T) {
+	database := testDB(t)
+	defer func() { _ = database.Close() }()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	user := createTestUser(t, database, "usr_test123456789", "testuser")
+	room := createTestRoom(t, database, "roo_warmdeadline", "warm-deadline", true)
+	addUserToRoom(t, database, user.ID, room.ID)
+	msg := &models.Message{
+		ID:         models.GenerateMessageID(),
+		RoomID:     room.ID,
+		UserID:     user.ID,
+		Body:       "warm message",
+		CreatedAt:  now.Format(time.RFC3339),
+		ModifiedAt: now.Format(time.RFC3339),
+	}
+	if err := msg.Insert(ctx, database); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+
+	// An already-expired deadline is the limiting case of the time bound:
+	// the pass must stop promptly without reporting an error
+	expired, cancel := context.WithDeadline(ctx, now.Add(-time.Second))
+	defer cancel()
+
+	start := time.Now()
+	warmed, err := WarmRecentRooms(expired, database, 10, 50)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected an expired warm-up to stop promptly, took %v", elapsed)
+	}
+	if err != nil {
+		t.Fatalf("Expected an expired warm-up to stop without error, got %v", err)
+	}
+	if warmed != 0 {
+		t.Errorf("Expected 0 rooms warmed under an expired deadline, got %d", warmed)
+	}
+}
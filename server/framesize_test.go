@@ -0,0 +1,65 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestOversizedMessageKeepsConnection tests that a message over
+// maxMessageSize gets an error envelope back without tearing down the
+// connection
+func TestOversizedMessageKeepsConnection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	httpClient := ts.createUser("bigpaster", "password123")
+	conn, err := dialWebSocket(ts, httpClient, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Over the message limit but under the frame ceiling
+	oversized := strings.Repeat("a", maxMessageSize+1)
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(oversized)); err != nil {
+		t.Fatalf("Failed to send oversized message: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var env struct {
+		Type string                 `json:"type"`
+		Data protocol.ErrorResponse `json:"data"`
+	}
+	if err := conn.ReadJSON(&env); err != nil {
+		t.Fatalf("Failed to read error response: %v", err)
+	}
+	if env.Type != "error" {
+		t.Errorf("Expected error envelope, got %q", env.Type)
+	}
+	if !strings.Contains(env.Data.Message, "byte limit") {
+		t.Errorf("Expected a size-error message, got %q", env.Data.Message)
+	}
+
+	// The connection is still usable afterwards
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"init","data":{}}`)); err != nil {
+		t.Fatalf("Failed to send init after oversized message: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var initEnv struct {
+		Type string `json:"type"`
+	}
+	if err := conn.ReadJSON(&initEnv); err != nil {
+		t.Fatalf("Expected the connection to stay open, read failed: %v", err)
+	}
+	if initEnv.Type != "init" {
+		t.Errorf("Expected an init response, got %q", initEnv.Type)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// defaultGuestHistoryLimit is how many recent messages the guest history
+// endpoint returns when GUEST_HISTORY_LIMIT is unset
+const defaultGuestHistoryLimit = 50
+
+// maxGuestHistoryLimit caps GUEST_HISTORY_LIMIT so the endpoint can't be
+// configured into serving unbounded pages
+const maxGuestHistoryLimit = 200
+
+// guestMessageResponse is one message as returned by the guest history
+// endpoint; it deliberately omits user IDs and message metadata
+type guestMessageResponse struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+}
+
+// guestHistoryResponse is the response for the guest history endpoint
+type guestHistoryResponse struct {
+	RoomID   string                 `json:"room_id"`
+	RoomName string                 `json:"room_name"`
+	Messages []guestMessageResponse `json:"messages"`
+}
+
+// guestHistoryAPI handles GET /api/v1/guest/history?room=<id>: a read-only,
+// unauthenticated window of the most recent messages in a public channel.
+// It is a 404 unless GUEST_HISTORY=true, and only rooms that are
+// explicitly public channels are served; private rooms and DMs are never
+// exposed. Guests cannot write: the endpoint accepts only GET.
+func (h *ChatServer) guestHistoryAPI(w http.ResponseWriter, r *http.Request) {
+	if !h.guestHistoryEnabled {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		h.writeAuthJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "GET required"})
+		return
+	}
+
+	roomID := r.URL.Query().Get("room")
+	if roomID == "" {
+		h.writeAuthJSON(w, http.StatusBadRequest, map[string]string{"error": "room is required"})
+		return
+	}
+
+	room, err := models.RoomByID(r.Context(), h.db, roomID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	// Only explicitly public channels; a private room or DM is a 404, not
+	// a 403, so the endpoint doesn't leak which room IDs exist
+	if room.RoomType != "channel" || room.IsPrivate != 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	messages, err := db.GetRoomMessages(r.Context(), h.db, room.ID, "", h.guestHistoryLimit)
+	if err != nil {
+		h.logger.Error("failed to fetch guest history", "error", err, "room", room.ID)
+		h.writeAuthJSON(w, http.StatusInternalServerError, map[string]string{"error": "unable to fetch history"})
+		return
+	}
+
+	response := guestHistoryResponse{
+		RoomID:   room.ID,
+		RoomName: room.Name,
+		Messages: make([]guestMessageResponse, 0, len(messages)),
+	}
+	for _, m := range messages {
+		// Soft-deleted messages stay out of the public view
+		if m.DeletedAt != "" {
+			continue
+		}
+		response.Messages = append(response.Messages, guestMessageResponse{
+			ID:        m.ID,
+			Username:  m.Username,
+			Body:      m.Body,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	h.writeAuthJSON(w, http.StatusOK, response)
+}
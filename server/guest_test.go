@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// guestTestServer builds a ChatServer with guest history enabled and a
+// seeded public room, private room, and DM
+func guestTestServer(t *testing.T, limit int) (*ChatServer, *models.Room, *models.Room, *models.Room) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testDB, err := db.NewDB("file::memory:?cache=shared", logger)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+	if err := testDB.RunSQLFile("../schema.sql"); err != nil {
+		t.Fatalf("Failed to run schema: %v", err)
+	}
+
+	chatServer := &ChatServer{
+		db:                  testDB,
+		logger:              logger,
+		guestHistoryEnabled: true,
+		guestHistoryLimit:   limit,
+	}
+
+	ctx := context.Background()
+	now := time.Now().Format(time.RFC3339)
+	user := &models.User{ID: "usr_guest_poster", Username: "guestposter", Password: "x", CreatedAt: now, ModifiedAt: now}
+	if err := user.Insert(ctx, testDB); err != nil {
+		t.Fatalf("Failed to insert user: %v", err)
+	}
+
+	public := &models.Room{ID: "roo_guest_public", Name: "town-square", RoomType: "channel", IsPrivate: models.FALSE, IsDefault: models.FALSE, CreatedAt: now}
+	private := &models.Room{ID: "roo_guest_privat", Name: "backchannel", RoomType: "channel", IsPrivate: models.TRUE, IsDefault: models.FALSE, CreatedAt: now}
+	dm := &models.Room{ID: "roo_guest_dm0001", Name: "", RoomType: "dm", IsPrivate: models.TRUE, IsDefault: models.FALSE, CreatedAt: now}
+	for _, room := range []*models.Room{public, private, dm} {
+		if err := room.Insert(ctx, testDB); err != nil {
+			t.Fatalf("Failed to insert room: %v", err)
+		}
+	}
+
+	for _, room := range []*models.Room{public, private, dm} {
+		for i := 0; i < 5; i++ {
+			msg := &models.Message{
+				ID:         models.GenerateMessageID(),
+				RoomID:     room.ID,
+				UserID:     user.ID,
+				Body:       fmt.Sprintf("%s message %d", room.ID, i),
+				CreatedAt:  now,
+				ModifiedAt: now,
+			}
+			if err := msg.Insert(ctx, testDB); err != nil {
+				t.Fatalf("Failed to insert message: %v", err)
+			}
+		}
+	}
+
+	return chatServer, public, private, dm
+}
+
+// guestRequest performs an unauthenticated request against the guest
+// history handler
+func guestRequest(chatServer *ChatServer, method, roomID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/guest/history?room="+roomID, nil)
+	w := httptest.NewRecorder()
+	chatServer.guestHistoryAPI(w, req)
+	return w
+}
+
+// TestGuestHistoryPublicRoom tests that a guest can read a limited window
+// of a public channel without authentication
+func TestGuestHistoryPublicRoom(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	chatServer, public, _, _ := guestTestServer(t, 3)
+
+	w := guestRequest(chatServer, http.MethodGet, public.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a public room, got %d", w.Code)
+	}
+
+	var response guestHistoryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.RoomID != public.ID {
+		t.Errorf("Expected room %s, got %s", public.ID, response.RoomID)
+	}
+	if len(response.Messages) != 3 {
+		t.Errorf("Expected the window to be limited to 3 messages, got %d", len(response.Messages))
+	}
+	for _, m := range response.Messages {
+		if !strings.HasPrefix(m.Body, public.ID) {
+			t.Errorf("Expected only public room messages, got %q", m.Body)
+		}
+		if m.Username != "guestposter" {
+			t.Errorf("Expected the author's username, got %q", m.Username)
+		}
+	}
+}
+
+// TestGuestHistoryPrivateRoomsHidden tests that private rooms and DMs are
+// never exposed to guests
+func TestGuestHistoryPrivateRoomsHidden(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	chatServer, _, private, dm := guestTestServer(t, 10)
+
+	if w := guestRequest(chatServer, http.MethodGet, private.ID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a private room, got %d", w.Code)
+	}
+	if w := guestRequest(chatServer, http.MethodGet, dm.ID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a DM, got %d", w.Code)
+	}
+	if w := guestRequest(chatServer, http.MethodGet, "roo_no_such_room"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown room, got %d", w.Code)
+	}
+}
+
+// TestGuestHistoryDisabled tests that the endpoint is a 404 when the flag
+// is off
+func TestGuestHistoryDisabled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	chatServer, public, _, _ := guestTestServer(t, 10)
+	chatServer.guestHistoryEnabled = false
+
+	if w := guestRequest(chatServer, http.MethodGet, public.ID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when guest history is disabled, got %d", w.Code)
+	}
+}
+
+// TestGuestHistoryCannotPost tests that guests cannot write through the
+// endpoint
+func TestGuestHistoryCannotPost(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	chatServer, public, _, _ := guestTestServer(t, 10)
+
+	if w := guestRequest(chatServer, http.MethodPost, public.ID); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", w.Code)
+	}
+}
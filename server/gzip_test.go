@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/middleware"
+)
+
+// gzipEcho wraps a handler writing body in the gzip middleware and returns
+// the recorded response to a request with the given Accept-Encoding
+func gzipEcho(t *testing.T, body []byte, contentType, acceptEncoding string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := middleware.GzipMiddleware(middleware.DefaultGzipMinBytes)(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		if _, err := w.Write(body); err != nil {
+			t.Errorf("Failed to write response body: %v", err)
+		}
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rooms", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// TestGzipCompressesLargeResponse tests that a large JSON response is
+// gzipped when the client asks for it and round-trips intact
+func TestGzipCompressesLargeResponse(t *testing.T) {
+	body := []byte(`{"rooms": "` + strings.Repeat("abcdefgh", 1024) + `"}`)
+	rec := gzipEcho(t, body, "application/json", "gzip, deflate")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Expected Content-Encoding gzip, got %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Expected Vary: Accept-Encoding, got %q", got)
+	}
+	if rec.Body.Len() >= len(body) {
+		t.Errorf("Expected the compressed body to be smaller than %d bytes, got %d", len(body), rec.Body.Len())
+	}
+
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to open gzip reader: %v", err)
+	}
+	decompressed, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Failed to decompress body: %v", err)
+	}
+	if !bytes.Equal(decompressed, body) {
+		t.Error("Expected the decompressed body to match the original")
+	}
+}
+
+// TestGzipSkipsSmallResponse tests that a response under the threshold is
+// sent uncompressed
+func TestGzipSkipsSmallResponse(t *testing.T) {
+	body := []byte(`{"ok": true}`)
+	rec := gzipEcho(t, body, "application/json", "gzip")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Expected no Content-Encoding for a small response, got %q", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), body) {
+		t.Errorf("Expected the body to pass through unchanged, got %q", rec.Body.String())
+	}
+}
+
+// TestGzipRespectsClientAndContent tests that clients that didn't ask for
+// gzip and already-compressed content both pass through unchanged
+func TestGzipRespectsClientAndContent(t *testing.T) {
+	big := bytes.Repeat([]byte("x"), middleware.DefaultGzipMinBytes*2)
+
+	rec := gzipEcho(t, big, "application/json", "")
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Expected no Content-Encoding without Accept-Encoding: gzip, got %q", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), big) {
+		t.Error("Expected the body to pass through unchanged without Accept-Encoding")
+	}
+
+	rec = gzipEcho(t, big, "image/png", "gzip")
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Expected image content to be left uncompressed, got %q", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), big) {
+		t.Error("Expected image content to pass through unchanged")
+	}
+}
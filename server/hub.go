@@ -1,8 +1,11 @@
 package server
 
 import (
+	"context"
 	"log/slog"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/llimllib/hatchat/server/db"
 )
 
@@ -12,37 +15,217 @@ type RoomMessage struct {
 	Message []byte
 }
 
+// PeerMessage wraps a message with the set of user IDs it should reach,
+// used for membership-wide events like profile and presence updates
+type PeerMessage struct {
+	UserIDs map[string]bool
+	Message []byte
+}
+
+// ClientMessage wraps a message bound for a single connection, used by
+// streaming replies produced off the read goroutine
+type ClientMessage struct {
+	Client  *Client
+	Message []byte
+}
+
+// subscription toggles a single room in a connection's live-event
+// subscription set
+type subscription struct {
+	Client     *Client
+	RoomID     string
+	Subscribed bool
+}
+
+// clientSubscriptions replaces a connection's live-event subscription set
+// wholesale, used at init/sync when the full membership list is known
+type clientSubscriptions struct {
+	Client *Client
+	Rooms  map[string]bool
+}
+
 // Hub maintains the set of active clients and broadcasts messages to the
 // clients.
 type Hub struct {
 	// Registered clients.
 	clients map[*Client]bool
 
-	// Inbound messages from the clients, scoped to a room.
+	// Inbound messages from the clients, scoped to a room. Delivered to
+	// every connection subscribed to the room, not just those viewing it.
 	broadcast chan RoomMessage
 
+	// Single-room changes to a connection's subscription set, from
+	// joining or leaving a room.
+	subscribe chan subscription
+
+	// Wholesale replacements of a connection's subscription set, from
+	// init and sync.
+	resubscribe chan clientSubscriptions
+
+	// Messages for every connection belonging to a set of users, regardless
+	// of which room they are viewing.
+	peercast chan PeerMessage
+
+	// Messages for every connected client, used for server-wide events
+	// like maintenance mode changes.
+	allcast chan []byte
+
+	// Messages for one specific connection, from goroutines that can't
+	// write to it directly.
+	clientcast chan ClientMessage
+
+	// Snapshot requests for the set of currently connected user IDs.
+	online chan chan []string
+
+	// Close frames to deliver to every connection before disconnecting it,
+	// used when an operator drains the server for maintenance.
+	disconnect chan []byte
+
 	// Register requests from the clients.
 	register chan *Client
 
 	// Unregister requests from clients.
 	unregister chan *Client
 
+	// limiter caps concurrent connections per user and per IP.
+	limiter *connLimiter
+
+	// roomLimiter caps each room's aggregate message rate.
+	roomLimiter *roomRateLimiter
+
+	// idleTimeout closes connections that send no application messages for
+	// the window; zero disables the check. Distinct from the transport
+	// ping/pong, which only detects dead TCP.
+	idleTimeout time.Duration
+
+	// retryAfter is the backoff hint, in seconds, attached to overload and
+	// maintenance close frames so clients don't reconnect in a stampede.
+	retryAfter int
+
 	logger *slog.Logger
 
 	db *db.DB
 }
 
 func newHub(db *db.DB, logger *slog.Logger) *Hub {
+	// The upgrader's origin policy is bound here so it can log through the
+	// server's logger
+	upgrader.CheckOrigin = originChecker(logger, allowedOriginsFromEnv())
 	return &Hub{
-		broadcast:  make(chan RoomMessage),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		clients:    make(map[*Client]bool),
-		logger:     logger,
-		db:         db,
+		broadcast:   make(chan RoomMessage),
+		subscribe:   make(chan subscription),
+		resubscribe: make(chan clientSubscriptions),
+		peercast:    make(chan PeerMessage),
+		allcast:     make(chan []byte),
+		clientcast:  make(chan ClientMessage),
+		online:      make(chan chan []string),
+		disconnect:  make(chan []byte),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		clients:     make(map[*Client]bool),
+		limiter:     newConnLimiterFromEnv(logger),
+		roomLimiter: newRoomRateLimiterFromEnv(logger),
+		idleTimeout: idleTimeoutFromEnv(logger),
+		retryAfter:  retryAfterFromEnv(logger),
+		logger:      logger,
+		db:          db,
+	}
+}
+
+// setSubscriptions replaces the set of rooms client receives live events
+// for, typically all of the user's memberships. The run loop owns each
+// registered client's subscription map, so changes route through it.
+func (h *Hub) setSubscriptions(client *Client, roomIDs []string) {
+	rooms := make(map[string]bool, len(roomIDs))
+	for _, id := range roomIDs {
+		rooms[id] = true
+	}
+	h.resubscribe <- clientSubscriptions{Client: client, Rooms: rooms}
+}
+
+// subscribeRoom adds a room to the set client receives live events for
+func (h *Hub) subscribeRoom(client *Client, roomID string) {
+	h.subscribe <- subscription{Client: client, RoomID: roomID, Subscribed: true}
+}
+
+// unsubscribeRoom removes a room from the set client receives live events
+// for
+func (h *Hub) unsubscribeRoom(client *Client, roomID string) {
+	h.subscribe <- subscription{Client: client, RoomID: roomID, Subscribed: false}
+}
+
+// broadcastToUserPeers delivers msg to every online user who shares at
+// least one room with userID, including the user's own connections. One
+// membership query computes the deduplicated peer set, so a peer in several
+// shared rooms receives exactly one copy per connection.
+func (h *Hub) broadcastToUserPeers(userID string, msg []byte) {
+	peers, err := db.GetRoomPeers(context.Background(), h.db, userID)
+	if err != nil {
+		h.logger.Error("failed to get room peers", "error", err, "user", userID)
+		return
+	}
+	h.peercast <- PeerMessage{UserIDs: peers, Message: msg}
+}
+
+// broadcastToRoomMembers delivers msg to every connection of every member
+// of a room, looked up from the membership table rather than each
+// connection's subscription set. Events that mutate existing messages —
+// edits, deletes, reaction changes — go through here so they reach members
+// whose connections haven't refreshed their subscriptions yet. If the
+// membership lookup fails, fall back to the subscription-scoped broadcast
+// so clients following the room aren't left stale.
+func (h *Hub) broadcastToRoomMembers(roomID string, msg []byte) {
+	members, err := db.GetRoomMemberIDs(context.Background(), h.db, roomID)
+	if err != nil {
+		h.logger.Error("failed to get room members", "error", err, "room", roomID)
+		h.broadcast <- RoomMessage{RoomID: roomID, Message: msg}
+		return
+	}
+	h.peercast <- PeerMessage{UserIDs: members, Message: msg}
+}
+
+// sendToUser delivers msg to all of a single user's connections,
+// regardless of which room they are currently viewing
+func (h *Hub) sendToUser(userID string, msg []byte) {
+	h.peercast <- PeerMessage{UserIDs: map[string]bool{userID: true}, Message: msg}
+}
+
+// broadcastToAll delivers msg to every connected client
+func (h *Hub) broadcastToAll(msg []byte) {
+	h.allcast <- msg
+}
+
+// sendToClient delivers msg to a single connection from off the read
+// goroutine. The run loop checks that the connection is still registered
+// before touching its send channel, so a stream racing a disconnect drops
+// the message instead of panicking on a closed channel. Returns ctx's error
+// if it is cancelled before the hub accepts the message.
+func (h *Hub) sendToClient(ctx context.Context, client *Client, msg []byte) error {
+	select {
+	case h.clientcast <- ClientMessage{Client: client, Message: msg}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
+// disconnectAll sends closeMsg to every connection as a close frame and
+// then closes them, letting each one's pumps and the unregister path clean
+// up. Used when an operator drains the server; the close payload carries a
+// retry_after hint so the drained clients don't all reconnect at once.
+func (h *Hub) disconnectAll(closeMsg []byte) {
+	h.disconnect <- closeMsg
+}
+
+// onlineUserIDs returns the deduplicated set of user IDs with at least one
+// open connection. The snapshot is taken by the run loop, which owns the
+// clients map, so callers never race it.
+func (h *Hub) onlineUserIDs() []string {
+	reply := make(chan []string)
+	h.online <- reply
+	return <-reply
+}
+
 func (h *Hub) run() {
 	for {
 		select {
@@ -52,18 +235,94 @@ func (h *Hub) run() {
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
+				h.limiter.release(client.user.ID, client.ip)
+			}
+		case sub := <-h.subscribe:
+			if !h.clients[sub.Client] {
+				continue
+			}
+			if sub.Subscribed {
+				sub.Client.subscriptions[sub.RoomID] = true
+			} else {
+				delete(sub.Client.subscriptions, sub.RoomID)
+			}
+		case subs := <-h.resubscribe:
+			if h.clients[subs.Client] {
+				subs.Client.subscriptions = subs.Rooms
+			}
+		case reply := <-h.online:
+			seen := make(map[string]bool)
+			ids := []string{}
+			for client := range h.clients {
+				if client.user == nil || seen[client.user.ID] {
+					continue
+				}
+				seen[client.user.ID] = true
+				ids = append(ids, client.user.ID)
+			}
+			reply <- ids
+		case closeMsg := <-h.disconnect:
+			// WriteControl is safe alongside each connection's writePump,
+			// and closing the connection makes its pumps exit and
+			// unregister the client
+			for client := range h.clients {
+				_ = client.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+				_ = client.conn.Close()
+			}
+		case clientMsg := <-h.clientcast:
+			if !h.clients[clientMsg.Client] {
+				continue
+			}
+			select {
+			case clientMsg.Client.send <- clientMsg.Message:
+			default:
+				clientMsg.Client.overloaded.Store(true)
+				close(clientMsg.Client.send)
+				delete(h.clients, clientMsg.Client)
+				h.limiter.release(clientMsg.Client.user.ID, clientMsg.Client.ip)
+			}
+		case msg := <-h.allcast:
+			for client := range h.clients {
+				select {
+				case client.send <- msg:
+				default:
+					client.overloaded.Store(true)
+					close(client.send)
+					delete(h.clients, client)
+					h.limiter.release(client.user.ID, client.ip)
+				}
+			}
+		case peerMsg := <-h.peercast:
+			// Deliver once per connection; a user in several shared rooms
+			// still holds one entry in the clients map per connection
+			for client := range h.clients {
+				if client.user == nil || !peerMsg.UserIDs[client.user.ID] {
+					continue
+				}
+				select {
+				case client.send <- peerMsg.Message:
+				default:
+					client.overloaded.Store(true)
+					close(client.send)
+					delete(h.clients, client)
+					h.limiter.release(client.user.ID, client.ip)
+				}
 			}
 		case roomMsg := <-h.broadcast:
-			// Only send to clients viewing the same room
+			// Fan out to every connection subscribed to the room, whether
+			// or not it is the one the connection is viewing; the current
+			// room only affects read-receipt semantics
 			for client := range h.clients {
-				if client.currentRoom != roomMsg.RoomID {
+				if !client.subscriptions[roomMsg.RoomID] {
 					continue
 				}
 				select {
 				case client.send <- roomMsg.Message:
 				default:
+					client.overloaded.Store(true)
 					close(client.send)
 					delete(h.clients, client)
+					h.limiter.release(client.user.ID, client.ip)
 				}
 			}
 		}
@@ -1,12 +1,18 @@
 package server
 
 import (
+	"context"
+	"io"
+	"log/slog"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
 )
 
-// TestHub_RoomScopedBroadcast tests that messages are only sent to clients in the same room
+// TestHub_RoomScopedBroadcast tests that messages are only sent to clients subscribed to the room
 // SECURITY: This is the critical test for room isolation
 func TestHub_RoomScopedBroadcast(t *testing.T) {
 	hub := &Hub{
@@ -18,19 +24,19 @@ func TestHub_RoomScopedBroadcast(t *testing.T) {
 
 	// Create clients in different rooms
 	clientRoom1a := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
 	}
 	clientRoom1b := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
 	}
 	clientRoom2 := &Client{
-		hub:         hub,
-		currentRoom: "roo_room2345678",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room2345678": true},
+		send:          make(chan []byte, 256),
 	}
 
 	// Register all clients
@@ -91,19 +97,19 @@ func TestHub_MessageIsolationBetweenRooms(t *testing.T) {
 
 	// Create clients in different rooms
 	room1Client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
 	}
 	room2Client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room2345678",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room2345678": true},
+		send:          make(chan []byte, 256),
 	}
 	room3Client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room3456789",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room3456789": true},
+		send:          make(chan []byte, 256),
 	}
 
 	// Register all clients
@@ -163,8 +169,8 @@ func TestHub_MessageIsolationBetweenRooms(t *testing.T) {
 	}
 }
 
-// TestHub_ClientWithNoRoom tests that clients without a room don't receive any messages
-func TestHub_ClientWithNoRoom(t *testing.T) {
+// TestHub_ClientWithNoSubscriptions tests that clients with no subscriptions don't receive any messages
+func TestHub_ClientWithNoSubscriptions(t *testing.T) {
 	hub := &Hub{
 		broadcast:  make(chan RoomMessage),
 		register:   make(chan *Client),
@@ -172,18 +178,18 @@ func TestHub_ClientWithNoRoom(t *testing.T) {
 		clients:    make(map[*Client]bool),
 	}
 
-	// Create a client with no room assigned
+	// Create a client with no subscriptions, as before init completes
 	clientNoRoom := &Client{
-		hub:         hub,
-		currentRoom: "", // No room assigned
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{},
+		send:          make(chan []byte, 256),
 	}
 
-	// Create a client in a room
+	// Create a client subscribed to a room
 	clientWithRoom := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
 	}
 
 	hub.clients[clientNoRoom] = true
@@ -197,22 +203,22 @@ func TestHub_ClientWithNoRoom(t *testing.T) {
 
 	time.Sleep(50 * time.Millisecond)
 
-	// Client with no room should not receive message
+	// Client with no subscriptions should not receive message
 	select {
 	case msg := <-clientNoRoom.send:
-		t.Errorf("Client with no room received message: %s", msg)
+		t.Errorf("Client with no subscriptions received message: %s", msg)
 	default:
 		// Expected
 	}
 
-	// Client with room should receive message
+	// Subscribed client should receive message
 	select {
 	case msg := <-clientWithRoom.send:
 		if string(msg) != string(testMsg) {
-			t.Errorf("Client with room received wrong message: got %s, want %s", msg, testMsg)
+			t.Errorf("Subscribed client received wrong message: got %s, want %s", msg, testMsg)
 		}
 	default:
-		t.Error("Client with room did not receive message")
+		t.Error("Subscribed client did not receive message")
 	}
 }
 
@@ -226,9 +232,9 @@ func TestHub_MessageToNonExistentRoom(t *testing.T) {
 	}
 
 	client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
 	}
 
 	hub.clients[client] = true
@@ -257,14 +263,16 @@ func TestHub_ClientRegistrationAndUnregistration(t *testing.T) {
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		clients:    make(map[*Client]bool),
+		limiter:    newConnLimiter(defaultMaxConnsPerUser, defaultMaxConnsPerIP),
 	}
 
 	go hub.run()
 
 	client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
+		user:          &models.User{ID: "usr_hub_test_001"},
 	}
 
 	// Register client
@@ -311,9 +319,9 @@ func TestHub_MultipleClientsInSameRoom(t *testing.T) {
 	clients := make([]*Client, numClients)
 	for i := 0; i < numClients; i++ {
 		clients[i] = &Client{
-			hub:         hub,
-			currentRoom: "roo_room1234567",
-			send:        make(chan []byte, 256),
+			hub:           hub,
+			subscriptions: map[string]bool{"roo_room1234567": true},
+			send:          make(chan []byte, 256),
 		}
 		hub.clients[clients[i]] = true
 	}
@@ -349,9 +357,9 @@ func TestHub_RapidMessages(t *testing.T) {
 	}
 
 	client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
 	}
 	hub.clients[client] = true
 
@@ -383,25 +391,30 @@ done:
 	}
 }
 
-// TestHub_RoomSwitching tests that a client switching rooms receives the correct messages
-func TestHub_RoomSwitching(t *testing.T) {
+// TestHub_SubscriptionChanges tests that subscribing and unsubscribing
+// changes which rooms' events a client receives, and that the room a client
+// is viewing plays no part in routing
+func TestHub_SubscriptionChanges(t *testing.T) {
 	hub := &Hub{
-		broadcast:  make(chan RoomMessage),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		clients:    make(map[*Client]bool),
+		broadcast:   make(chan RoomMessage),
+		subscribe:   make(chan subscription),
+		resubscribe: make(chan clientSubscriptions),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		clients:     make(map[*Client]bool),
 	}
 
 	client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 256),
+		hub:           hub,
+		currentRoom:   "roo_room1234567",
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
 	}
 	hub.clients[client] = true
 
 	go hub.run()
 
-	// Send message to room1 - client should receive it
+	// Send message to room1 - the subscribed client should receive it
 	room1Msg := []byte(`{"type":"message","data":{"body":"Room 1"}}`)
 	hub.broadcast <- RoomMessage{RoomID: "roo_room1234567", Message: room1Msg}
 	time.Sleep(50 * time.Millisecond)
@@ -415,21 +428,37 @@ func TestHub_RoomSwitching(t *testing.T) {
 		t.Error("Did not receive room1 message")
 	}
 
-	// Switch client to room2
+	// Viewing a different room changes nothing: the client stays
+	// subscribed to room1 and keeps receiving its events
 	client.currentRoom = "roo_room2345678"
+	hub.broadcast <- RoomMessage{RoomID: "roo_room1234567", Message: room1Msg}
+	time.Sleep(50 * time.Millisecond)
+
+	select {
+	case msg := <-client.send:
+		if string(msg) != string(room1Msg) {
+			t.Errorf("Expected room1 message, got: %s", msg)
+		}
+	default:
+		t.Error("Did not receive room1 message after switching the viewed room")
+	}
+
+	// After unsubscribing from room1 and subscribing to room2, only
+	// room2 events arrive
+	hub.unsubscribeRoom(client, "roo_room1234567")
+	hub.subscribeRoom(client, "roo_room2345678")
+	time.Sleep(10 * time.Millisecond)
 
-	// Send message to room1 - client should NOT receive it
 	hub.broadcast <- RoomMessage{RoomID: "roo_room1234567", Message: room1Msg}
 	time.Sleep(50 * time.Millisecond)
 
 	select {
 	case msg := <-client.send:
-		t.Errorf("SECURITY: Client received room1 message after switching to room2: %s", msg)
+		t.Errorf("SECURITY: Client received room1 message after unsubscribing: %s", msg)
 	default:
 		// Expected
 	}
 
-	// Send message to room2 - client should receive it
 	room2Msg := []byte(`{"type":"message","data":{"body":"Room 2"}}`)
 	hub.broadcast <- RoomMessage{RoomID: "roo_room2345678", Message: room2Msg}
 	time.Sleep(50 * time.Millisecond)
@@ -440,7 +469,63 @@ func TestHub_RoomSwitching(t *testing.T) {
 			t.Errorf("Expected room2 message, got: %s", msg)
 		}
 	default:
-		t.Error("Did not receive room2 message after switching rooms")
+		t.Error("Did not receive room2 message after subscribing")
+	}
+}
+
+// TestHub_BackgroundRoomDelivery tests that a client subscribed to several
+// rooms receives events for a room it is not viewing, while a client that
+// never subscribed receives nothing
+func TestHub_BackgroundRoomDelivery(t *testing.T) {
+	hub := &Hub{
+		broadcast:  make(chan RoomMessage),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		clients:    make(map[*Client]bool),
+	}
+
+	// A member of both rooms, currently viewing room1
+	subscriber := &Client{
+		hub:         hub,
+		currentRoom: "roo_room1234567",
+		subscriptions: map[string]bool{
+			"roo_room1234567": true,
+			"roo_room2345678": true,
+		},
+		send: make(chan []byte, 256),
+	}
+	// Viewing room1 as well, but not subscribed to room2
+	nonSubscriber := &Client{
+		hub:           hub,
+		currentRoom:   "roo_room1234567",
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 256),
+	}
+	hub.clients[subscriber] = true
+	hub.clients[nonSubscriber] = true
+
+	go hub.run()
+
+	// An event in room2 reaches the subscriber even though they're
+	// viewing room1
+	room2Msg := []byte(`{"type":"message","data":{"body":"Background room"}}`)
+	hub.broadcast <- RoomMessage{RoomID: "roo_room2345678", Message: room2Msg}
+	time.Sleep(50 * time.Millisecond)
+
+	select {
+	case msg := <-subscriber.send:
+		if string(msg) != string(room2Msg) {
+			t.Errorf("Subscriber received wrong message: %s", msg)
+		}
+	default:
+		t.Error("Subscriber did not receive the background room's event")
+	}
+
+	select {
+	case msg := <-nonSubscriber.send:
+		t.Errorf("SECURITY: Non-subscriber received a room2 message: %s", msg)
+	default:
+		// Expected
 	}
 }
 
@@ -455,14 +540,14 @@ func TestHub_ConcurrentBroadcasts(t *testing.T) {
 
 	// Create clients in two different rooms
 	room1Client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room1234567",
-		send:        make(chan []byte, 1000),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room1234567": true},
+		send:          make(chan []byte, 1000),
 	}
 	room2Client := &Client{
-		hub:         hub,
-		currentRoom: "roo_room2345678",
-		send:        make(chan []byte, 1000),
+		hub:           hub,
+		subscriptions: map[string]bool{"roo_room2345678": true},
+		send:          make(chan []byte, 1000),
 	}
 	hub.clients[room1Client] = true
 	hub.clients[room2Client] = true
@@ -521,3 +606,145 @@ func TestHub_ConcurrentBroadcasts(t *testing.T) {
 		}
 	}
 }
+
+// TestHub_PeerBroadcast tests that a peercast message reaches each targeted
+// user's connection exactly once and skips everyone else
+func TestHub_PeerBroadcast(t *testing.T) {
+	hub := &Hub{
+		broadcast:  make(chan RoomMessage),
+		peercast:   make(chan PeerMessage),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		clients:    make(map[*Client]bool),
+	}
+
+	// The peer views one of the shared rooms; delivery must not depend on
+	// which room anyone is currently looking at
+	peer := &Client{
+		hub:         hub,
+		currentRoom: "roo_room1234567",
+		send:        make(chan []byte, 256),
+		user:        &models.User{ID: "usr_peer_1234567"},
+	}
+	unrelated := &Client{
+		hub:         hub,
+		currentRoom: "roo_room1234567",
+		send:        make(chan []byte, 256),
+		user:        &models.User{ID: "usr_other_123456"},
+	}
+	hub.clients[peer] = true
+	hub.clients[unrelated] = true
+
+	go hub.run()
+
+	testMsg := []byte(`{"type":"profile_updated","data":{}}`)
+	hub.peercast <- PeerMessage{
+		UserIDs: map[string]bool{"usr_peer_1234567": true},
+		Message: testMsg,
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := len(peer.send); got != 1 {
+		t.Errorf("Expected the peer to receive exactly 1 copy, got %d", got)
+	}
+	if got := len(unrelated.send); got != 0 {
+		t.Errorf("Expected the unrelated user to receive nothing, got %d", got)
+	}
+}
+
+// TestHub_MemberBroadcastReachesAllTabs tests that member-scoped broadcasts
+// (edits, deletes, reaction changes) reach every connection of every room
+// member, including a second tab of the same user viewing a different room,
+// and skip non-members
+func TestHub_MemberBroadcastReachesAllTabs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testDB, err := db.NewDB("file::memory:?cache=shared", logger)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() { _ = testDB.Close() }()
+	if err := testDB.RunSQLFile("../schema.sql"); err != nil {
+		t.Fatalf("Failed to run schema: %v", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	member := &models.User{ID: "usr_hub_tabs_001", Username: "hubtabsmember", Password: "x", CreatedAt: now, ModifiedAt: now}
+	outsider := &models.User{ID: "usr_hub_tabs_002", Username: "hubtabsoutside", Password: "x", CreatedAt: now, ModifiedAt: now}
+	for _, u := range []*models.User{member, outsider} {
+		if err := u.Insert(context.Background(), testDB); err != nil {
+			t.Fatalf("Failed to insert user: %v", err)
+		}
+	}
+	room := &models.Room{
+		ID:           "roo_hub_tabs_001",
+		Name:         "hub-tabs",
+		RoomType:     "channel",
+		CreatedAt:    now,
+		PostPolicy:   db.PostPolicyAll,
+		AnnouncePins: models.TRUE,
+	}
+	if err := room.Insert(context.Background(), testDB); err != nil {
+		t.Fatalf("Failed to insert room: %v", err)
+	}
+	membership := &models.RoomsMember{UserID: member.ID, RoomID: room.ID, Role: db.RoleMember}
+	if err := membership.Insert(context.Background(), testDB); err != nil {
+		t.Fatalf("Failed to insert membership: %v", err)
+	}
+
+	hub := &Hub{
+		broadcast:  make(chan RoomMessage),
+		peercast:   make(chan PeerMessage),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		clients:    make(map[*Client]bool),
+		logger:     logger,
+		db:         testDB,
+	}
+
+	// Two tabs of the same member: one viewing the room, one viewing
+	// somewhere else entirely
+	viewingTab := &Client{
+		hub:         hub,
+		currentRoom: room.ID,
+		send:        make(chan []byte, 256),
+		user:        member,
+	}
+	backgroundTab := &Client{
+		hub:         hub,
+		currentRoom: "roo_hub_tabs_002",
+		send:        make(chan []byte, 256),
+		user:        member,
+	}
+	// A non-member viewing the same room ID must not receive the event
+	nonMember := &Client{
+		hub:         hub,
+		currentRoom: room.ID,
+		send:        make(chan []byte, 256),
+		user:        outsider,
+	}
+	hub.clients[viewingTab] = true
+	hub.clients[backgroundTab] = true
+	hub.clients[nonMember] = true
+
+	go hub.run()
+
+	editMsg := []byte(`{"type":"message_edited","data":{"id":"msg_hub_tabs_001"}}`)
+	hub.broadcastToRoomMembers(room.ID, editMsg)
+
+	time.Sleep(50 * time.Millisecond)
+
+	for name, tab := range map[string]*Client{"viewing": viewingTab, "background": backgroundTab} {
+		select {
+		case msg := <-tab.send:
+			if string(msg) != string(editMsg) {
+				t.Errorf("The %s tab received the wrong message: %s", name, msg)
+			}
+		default:
+			t.Errorf("The %s tab did not receive the edit event", name)
+		}
+	}
+	if got := len(nonMember.send); got != 0 {
+		t.Errorf("Expected the non-member to receive nothing, got %d messages", got)
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// idleTimeoutFromEnv reads the application-level idle timeout from the
+// IDLE_TIMEOUT_SECONDS environment variable. Connections that send no
+// application messages for the window get a close frame and are
+// unregistered; the transport ping/pong keeps silent-but-healthy
+// connections alive, so without this an idle tab holds its hub slot
+// forever. Unset or invalid values disable the check.
+func idleTimeoutFromEnv(logger *slog.Logger) time.Duration {
+	val := os.Getenv("IDLE_TIMEOUT_SECONDS")
+	if val == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		logger.Warn("ignoring invalid idle timeout", "name", "IDLE_TIMEOUT_SECONDS", "value", val)
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
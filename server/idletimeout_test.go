@@ -0,0 +1,96 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestIdleTimeout_ClosesIdleConnection tests that a connection sending no
+// application messages past the idle window gets closed by the server
+func TestIdleTimeout_ClosesIdleConnection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+	// Set before any connection so every writePump sees it
+	ts.hub.idleTimeout = 300 * time.Millisecond
+
+	httpClient := ts.createUser("idleuser", "password123")
+	tc := ts.connectWebSocket(httpClient, "idleuser")
+	defer tc.close()
+
+	if _, err := tc.sendInit(); err != nil {
+		t.Fatalf("Failed to send init: %v", err)
+	}
+
+	// Go silent; the server should close the connection once the window
+	// passes. The reader goroutine signals done when the connection dies.
+	select {
+	case <-tc.done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected the idle connection to be closed within the window")
+	}
+}
+
+// TestIdleTimeout_ActiveConnectionStaysOpen tests that a connection sending
+// application messages within the window is not closed
+func TestIdleTimeout_ActiveConnectionStaysOpen(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+	ts.hub.idleTimeout = 500 * time.Millisecond
+
+	httpClient := ts.createUser("busyuser", "password123")
+	tc := ts.connectWebSocket(httpClient, "busyuser")
+	defer tc.close()
+
+	if _, err := tc.sendInit(); err != nil {
+		t.Fatalf("Failed to send init: %v", err)
+	}
+
+	// Keep sending application pings well within the window for several
+	// multiples of it; the connection must survive
+	for i := 0; i < 8; i++ {
+		msg := `{"type":"ping","data":{}}`
+		if err := tc.conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("Failed to send ping %d: %v", i, err)
+		}
+		select {
+		case <-tc.done:
+			t.Fatal("Expected the active connection to stay open")
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// TestIdleTimeout_DisabledKeepsSilentConnection tests that with no timeout
+// configured, a silent connection is left alone
+func TestIdleTimeout_DisabledKeepsSilentConnection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	httpClient := ts.createUser("quietuser", "password123")
+	tc := ts.connectWebSocket(httpClient, "quietuser")
+	defer tc.close()
+
+	if _, err := tc.sendInit(); err != nil {
+		t.Fatalf("Failed to send init: %v", err)
+	}
+
+	select {
+	case <-tc.done:
+		t.Fatal("Expected the silent connection to stay open with the timeout disabled")
+	case <-time.After(time.Second):
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/llimllib/hatchat/server/api"
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// janitorInterval is how often background cleanup runs
+const janitorInterval = time.Hour
+
+// runJanitor periodically purges messages that have outlived their room's
+// retention setting, notifying connected clients of each removal.
+func (h *ChatServer) runJanitor(hub *Hub) {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.purgeExpiredMessages(hub)
+	}
+}
+
+// purgeExpiredMessages runs one retention pass and broadcasts a
+// message_deleted for every purged message so connected clients drop them.
+func (h *ChatServer) purgeExpiredMessages(hub *Hub) {
+	purged, err := db.PurgeExpiredMessages(context.Background(), h.db)
+	if err != nil {
+		h.logger.Error("failed to purge expired messages", "error", err)
+		return
+	}
+	if len(purged) == 0 {
+		return
+	}
+	h.logger.Info("purged expired messages", "count", len(purged))
+
+	for _, p := range purged {
+		// Audit failures must never fail the purge itself
+		if err := db.InsertAuditEntry(context.Background(), h.db, db.AuditSystemActor, db.AuditMessagePurge, p.ID, p.RoomID); err != nil {
+			h.logger.Error("failed to write audit entry", "error", err, "action", db.AuditMessagePurge, "message", p.ID)
+		}
+		msgBytes, err := json.Marshal(&api.Envelope{
+			Type: "message_deleted",
+			Data: protocol.MessageDeleted{
+				MessageID: p.ID,
+				RoomID:    p.RoomID,
+			},
+		})
+		if err != nil {
+			h.logger.Error("failed to marshal message_deleted", "error", err)
+			continue
+		}
+		hub.broadcast <- RoomMessage{
+			RoomID:  p.RoomID,
+			Message: msgBytes,
+		}
+	}
+}
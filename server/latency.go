@@ -0,0 +1,42 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/llimllib/hatchat/server/api"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// recordPong measures the round trip of the most recent ping and pushes a
+// latency_update to the client. Called from the pong handler on the read
+// goroutine; writePump stamps pingSentAt when it sends each ping.
+func (c *Client) recordPong() {
+	sent := c.pingSentAt.Load()
+	if sent == 0 {
+		return
+	}
+	rtt := time.Since(time.Unix(0, sent))
+	c.latencyNanos.Store(int64(rtt))
+
+	msgBytes, err := json.Marshal(&api.Envelope{
+		Type: "latency_update",
+		Data: protocol.LatencyUpdate{LatencyMs: rtt.Milliseconds()},
+	})
+	if err != nil {
+		c.logger.Error("failed to marshal latency_update", "error", err)
+		return
+	}
+	// Never block the read goroutine on a full send buffer; the next pong
+	// will carry a fresher number anyway
+	select {
+	case c.send <- msgBytes:
+	default:
+	}
+}
+
+// Latency returns the connection's most recently measured ping/pong round
+// trip, or zero when no pong has been received yet
+func (c *Client) Latency() time.Duration {
+	return time.Duration(c.latencyNanos.Load())
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// TestRecordPong tests that a pong records the round-trip time and queues a
+// latency_update for the client
+func TestRecordPong(t *testing.T) {
+	client := &Client{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		send:   make(chan []byte, 1),
+	}
+	client.pingSentAt.Store(time.Now().Add(-10 * time.Millisecond).UnixNano())
+
+	client.recordPong()
+
+	if client.Latency() < 10*time.Millisecond {
+		t.Errorf("Expected latency of at least 10ms, got %v", client.Latency())
+	}
+
+	select {
+	case msg := <-client.send:
+		var env struct {
+			Type string                 `json:"type"`
+			Data protocol.LatencyUpdate `json:"data"`
+		}
+		if err := json.Unmarshal(msg, &env); err != nil {
+			t.Fatalf("Failed to unmarshal latency update: %v", err)
+		}
+		if env.Type != "latency_update" {
+			t.Errorf("Expected latency_update envelope, got %q", env.Type)
+		}
+		if env.Data.LatencyMs < 10 {
+			t.Errorf("Expected latency_ms of at least 10, got %d", env.Data.LatencyMs)
+		}
+	default:
+		t.Fatal("Expected a latency_update to be queued")
+	}
+}
+
+// TestRecordPong_BeforeFirstPing tests that a pong arriving before any ping
+// has been sent records nothing
+func TestRecordPong_BeforeFirstPing(t *testing.T) {
+	client := &Client{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		send:   make(chan []byte, 1),
+	}
+
+	client.recordPong()
+
+	if client.Latency() != 0 {
+		t.Errorf("Expected zero latency before the first ping, got %v", client.Latency())
+	}
+	select {
+	case <-client.send:
+		t.Fatal("Expected no latency_update before the first ping")
+	default:
+	}
+}
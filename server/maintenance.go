@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/llimllib/hatchat/server/api"
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/middleware"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// maintenanceResponse reports the current maintenance state
+type maintenanceResponse struct {
+	ReadOnly bool `json:"read_only"`
+}
+
+// maintenanceRequest toggles maintenance mode. With disconnect set, every
+// connection is also closed with a retry_after backoff hint, for
+// maintenance that needs the server quiet.
+type maintenanceRequest struct {
+	ReadOnly   bool `json:"read_only"`
+	Disconnect bool `json:"disconnect,omitempty"`
+}
+
+// maintenanceAPI handles GET and POST /api/v1/admin/maintenance for users
+// named in ADMIN_USERS. GET reports whether read-only mode is on; POST
+// toggles it and broadcasts a server_status event to every connected
+// client so they can show a banner. A POST enabling maintenance may also
+// ask to disconnect all clients; those close frames carry a retry_after
+// hint so the drained clients don't reconnect in a stampede.
+func (h *ChatServer) maintenanceAPI(wsAPI *api.Api, hub *Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := models.UserByID(r.Context(), h.db, middleware.GetUserID(r.Context()))
+		if err != nil {
+			h.logger.Error("unable to find user for maintenance request", "err", err)
+			h.writeAuthJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+			return
+		}
+		if !h.adminUsers[user.Username] {
+			h.logger.Warn("non-admin attempted to toggle maintenance mode", "user", user.ID)
+			h.writeAuthJSON(w, http.StatusForbidden, map[string]string{"error": "Forbidden"})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			h.writeAuthJSON(w, http.StatusOK, maintenanceResponse{ReadOnly: wsAPI.ReadOnly()})
+		case http.MethodPost:
+			var req maintenanceRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				h.writeAuthJSON(w, http.StatusBadRequest, map[string]string{"error": "read_only must be a boolean"})
+				return
+			}
+			wsAPI.SetReadOnly(req.ReadOnly)
+
+			status, err := json.Marshal(&api.Envelope{
+				Type: "server_status",
+				Data: protocol.ServerStatus{ReadOnly: req.ReadOnly},
+			})
+			if err == nil {
+				hub.broadcastToAll(status)
+			}
+
+			if req.ReadOnly && req.Disconnect {
+				hub.disconnectAll(backoffCloseMessage(closeMaintenance, "maintenance", hub.retryAfter))
+			}
+
+			if err := db.InsertAuditEntry(r.Context(), h.db, user.ID, db.AuditMaintenanceToggle, "", ""); err != nil {
+				h.logger.Error("failed to record audit entry", "error", err)
+			}
+
+			h.logger.Info("maintenance mode toggled", "read_only", req.ReadOnly, "by", user.ID)
+			h.writeAuthJSON(w, http.StatusOK, maintenanceResponse{ReadOnly: req.ReadOnly})
+		default:
+			h.writeAuthJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "GET or POST required"})
+		}
+	}
+}
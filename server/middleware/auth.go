@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/models"
@@ -25,13 +26,21 @@ func GetUserID(ctx context.Context) string {
 func AuthMiddleware(db *db.DB, logger *slog.Logger, session_key string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie(session_key)
-			if err != nil {
+			// The session ID comes from the session cookie, or from an
+			// Authorization: Bearer header for bots and other automated
+			// clients that don't hold cookies
+			var token string
+			if cookie, err := r.Cookie(session_key); err == nil {
+				token = cookie.Value
+			} else if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				token = strings.TrimPrefix(auth, "Bearer ")
+			}
+			if token == "" {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
-			session, err := models.SessionByID(context.Background(), db, cookie.Value)
+			session, err := models.SessionByID(context.Background(), db, token)
 			if err != nil {
 				logger.Error("Error finding session", "err", err)
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// Default request body limits. JSON bodies are small; uploads get a larger
+// cap to leave room for attachments and avatars.
+const (
+	DefaultMaxBodyBytes   = 1 << 20  // 1MB
+	DefaultMaxUploadBytes = 10 << 20 // 10MB
+)
+
+// BodyLimitMiddleware returns a middleware function that rejects request
+// bodies larger than maxBytes with a 413. Bodies without a declared length
+// are capped with http.MaxBytesReader so a handler's read fails rather than
+// buffering without bound. A maxBytes of zero or less uses the default limit.
+func BodyLimitMiddleware(maxBytes int64) func(http.HandlerFunc) http.HandlerFunc {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			next(w, r)
+		}
+	}
+}
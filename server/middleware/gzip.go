@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// DefaultGzipMinBytes is the response size below which gzip isn't worth the
+// header overhead and CPU; small JSON responses fit in one packet anyway
+const DefaultGzipMinBytes = 1024
+
+// compressedContentType reports whether a content type is already
+// compressed, so gzipping it would only burn CPU
+func compressedContentType(ct string) bool {
+	for _, prefix := range []string{
+		"image/", "video/", "audio/",
+		"application/zip", "application/gzip",
+	} {
+		if strings.HasPrefix(ct, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GzipMiddleware returns a middleware function that gzips responses when the
+// client sends Accept-Encoding: gzip and the body exceeds minBytes. Writes
+// are buffered until the threshold is crossed, so small responses go out
+// unchanged; already-compressed content (images, or a handler that set its
+// own Content-Encoding) is passed through. A minBytes of zero or less uses
+// the default threshold.
+func GzipMiddleware(minBytes int) func(http.HandlerFunc) http.HandlerFunc {
+	if minBytes <= 0 {
+		minBytes = DefaultGzipMinBytes
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				next(w, r)
+				return
+			}
+			gw := &gzipResponseWriter{ResponseWriter: w, minBytes: minBytes, status: http.StatusOK}
+			next(gw, r)
+			gw.finish()
+		}
+	}
+}
+
+// gzipResponseWriter defers the choice between plain and gzipped output
+// until the handler has written minBytes (compress) or finished (don't).
+// The status line and headers are held back with the buffered body, since
+// Content-Encoding can't be added after WriteHeader reaches the wire.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minBytes int
+	status   int
+	buf      []byte
+	gz       *gzip.Writer
+	// plain is set once the writer decides not to compress
+	plain bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	if w.plain {
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minBytes {
+		w.decide()
+	}
+	return len(b), nil
+}
+
+// decide starts gzipping the buffered body, unless it's already-compressed
+// content, in which case it goes out as-is
+func (w *gzipResponseWriter) decide() {
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" || compressedContentType(header.Get("Content-Type")) {
+		w.flushPlain()
+		return
+	}
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	// The compressed length isn't known; the handler's Content-Length would
+	// be wrong
+	header.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	_, _ = w.gz.Write(w.buf)
+	w.buf = nil
+}
+
+// flushPlain sends the buffered response uncompressed
+func (w *gzipResponseWriter) flushPlain() {
+	w.plain = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+}
+
+// Flush implements http.Flusher so streaming handlers (like the NDJSON
+// archive export) still push partial responses to the client. A handler
+// that flushes is streaming a body of unknown size, so the first Flush
+// commits to compressing rather than waiting for the threshold.
+func (w *gzipResponseWriter) Flush() {
+	if w.gz == nil && !w.plain {
+		w.decide()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish completes the response once the handler returns: responses that
+// never crossed the threshold go out uncompressed, and the gzip stream is
+// closed for those that did
+func (w *gzipResponseWriter) finish() {
+	if w.gz != nil {
+		_ = w.gz.Close()
+		return
+	}
+	if !w.plain {
+		w.flushPlain()
+	}
+}
@@ -35,6 +35,20 @@ func GenerateMessageID() string {
 	return fmt.Sprintf("msg_%s", hex.EncodeToString(b))
 }
 
+// GenerateAttachmentID generates an attachment ID
+func GenerateAttachmentID() string {
+	b := make([]byte, 6)
+	rand.Read(b) //nolint: errcheck
+	return fmt.Sprintf("att_%s", hex.EncodeToString(b))
+}
+
+// GenerateSignupInviteCode generates a signup invite code
+func GenerateSignupInviteCode() string {
+	b := make([]byte, 8)
+	rand.Read(b) //nolint: errcheck
+	return fmt.Sprintf("inv_%s", hex.EncodeToString(b))
+}
+
 func GenerateUserID() string {
 	b := make([]byte, 8)
 	rand.Read(b) //nolint: errcheck
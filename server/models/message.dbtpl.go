@@ -16,6 +16,8 @@ type Message struct {
 	CreatedAt  string         `json:"created_at"`  // created_at
 	ModifiedAt string         `json:"modified_at"` // modified_at
 	DeletedAt  sql.NullString `json:"deleted_at"`  // deleted_at
+	EncKeyID   string         `json:"enc_key_id"`  // enc_key_id
+	IsSystem   int64          `json:"is_system"`   // is_system
 	// xo fields
 	_exists, _deleted bool
 }
@@ -41,13 +43,13 @@ func (m *Message) Insert(ctx context.Context, db DB) error {
 	}
 	// insert (manual)
 	const sqlstr = `INSERT INTO messages (` +
-		`id, room_id, user_id, body, created_at, modified_at, deleted_at` +
+		`id, room_id, user_id, body, created_at, modified_at, deleted_at, enc_key_id, is_system` +
 		`) VALUES (` +
-		`$1, $2, $3, $4, $5, $6, $7` +
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9` +
 		`)`
 	// run
-	logf(sqlstr, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt)
-	if _, err := db.ExecContext(ctx, sqlstr, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt); err != nil {
+	logf(sqlstr, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.EncKeyID, m.IsSystem)
+	if _, err := db.ExecContext(ctx, sqlstr, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.EncKeyID, m.IsSystem); err != nil {
 		return logerror(err)
 	}
 	// set exists
@@ -65,11 +67,11 @@ func (m *Message) Update(ctx context.Context, db DB) error {
 	}
 	// update with primary key
 	const sqlstr = `UPDATE messages SET ` +
-		`room_id = $1, user_id = $2, body = $3, created_at = $4, modified_at = $5, deleted_at = $6 ` +
-		`WHERE id = $7`
+		`room_id = $1, user_id = $2, body = $3, created_at = $4, modified_at = $5, deleted_at = $6, enc_key_id = $7, is_system = $8 ` +
+		`WHERE id = $9`
 	// run
-	logf(sqlstr, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.ID)
-	if _, err := db.ExecContext(ctx, sqlstr, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.ID); err != nil {
+	logf(sqlstr, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.EncKeyID, m.IsSystem, m.ID)
+	if _, err := db.ExecContext(ctx, sqlstr, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.EncKeyID, m.IsSystem, m.ID); err != nil {
 		return logerror(err)
 	}
 	return nil
@@ -91,16 +93,16 @@ func (m *Message) Upsert(ctx context.Context, db DB) error {
 	}
 	// upsert
 	const sqlstr = `INSERT INTO messages (` +
-		`id, room_id, user_id, body, created_at, modified_at, deleted_at` +
+		`id, room_id, user_id, body, created_at, modified_at, deleted_at, enc_key_id, is_system` +
 		`) VALUES (` +
-		`$1, $2, $3, $4, $5, $6, $7` +
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9` +
 		`)` +
 		` ON CONFLICT (id) DO ` +
 		`UPDATE SET ` +
-		`room_id = EXCLUDED.room_id, user_id = EXCLUDED.user_id, body = EXCLUDED.body, created_at = EXCLUDED.created_at, modified_at = EXCLUDED.modified_at, deleted_at = EXCLUDED.deleted_at `
+		`room_id = EXCLUDED.room_id, user_id = EXCLUDED.user_id, body = EXCLUDED.body, created_at = EXCLUDED.created_at, modified_at = EXCLUDED.modified_at, deleted_at = EXCLUDED.deleted_at, enc_key_id = EXCLUDED.enc_key_id, is_system = EXCLUDED.is_system `
 	// run
-	logf(sqlstr, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt)
-	if _, err := db.ExecContext(ctx, sqlstr, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt); err != nil {
+	logf(sqlstr, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.EncKeyID, m.IsSystem)
+	if _, err := db.ExecContext(ctx, sqlstr, m.ID, m.RoomID, m.UserID, m.Body, m.CreatedAt, m.ModifiedAt, m.DeletedAt, m.EncKeyID, m.IsSystem); err != nil {
 		return logerror(err)
 	}
 	// set exists
@@ -135,7 +137,7 @@ func (m *Message) Delete(ctx context.Context, db DB) error {
 func MessagesByRoomIDCreatedAt(ctx context.Context, db DB, roomID, createdAt string) ([]*Message, error) {
 	// query
 	const sqlstr = `SELECT ` +
-		`id, room_id, user_id, body, created_at, modified_at, deleted_at ` +
+		`id, room_id, user_id, body, created_at, modified_at, deleted_at, enc_key_id, is_system ` +
 		`FROM messages ` +
 		`WHERE room_id = $1 AND created_at = $2`
 	// run
@@ -152,7 +154,7 @@ func MessagesByRoomIDCreatedAt(ctx context.Context, db DB, roomID, createdAt str
 			_exists: true,
 		}
 		// scan
-		if err := rows.Scan(&m.ID, &m.RoomID, &m.UserID, &m.Body, &m.CreatedAt, &m.ModifiedAt, &m.DeletedAt); err != nil {
+		if err := rows.Scan(&m.ID, &m.RoomID, &m.UserID, &m.Body, &m.CreatedAt, &m.ModifiedAt, &m.DeletedAt, &m.EncKeyID, &m.IsSystem); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &m)
@@ -169,7 +171,7 @@ func MessagesByRoomIDCreatedAt(ctx context.Context, db DB, roomID, createdAt str
 func MessageByID(ctx context.Context, db DB, id string) (*Message, error) {
 	// query
 	const sqlstr = `SELECT ` +
-		`id, room_id, user_id, body, created_at, modified_at, deleted_at ` +
+		`id, room_id, user_id, body, created_at, modified_at, deleted_at, enc_key_id, is_system ` +
 		`FROM messages ` +
 		`WHERE id = $1`
 	// run
@@ -177,7 +179,7 @@ func MessageByID(ctx context.Context, db DB, id string) (*Message, error) {
 	m := Message{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, id).Scan(&m.ID, &m.RoomID, &m.UserID, &m.Body, &m.CreatedAt, &m.ModifiedAt, &m.DeletedAt); err != nil {
+	if err := db.QueryRowContext(ctx, sqlstr, id).Scan(&m.ID, &m.RoomID, &m.UserID, &m.Body, &m.CreatedAt, &m.ModifiedAt, &m.DeletedAt, &m.EncKeyID, &m.IsSystem); err != nil {
 		return nil, logerror(err)
 	}
 	return &m, nil
@@ -9,13 +9,19 @@ import (
 
 // Room represents a row from 'rooms'.
 type Room struct {
-	ID            string         `json:"id"`              // id
-	Name          string         `json:"name"`            // name
-	RoomType      string         `json:"room_type"`       // room_type
-	IsPrivate     int            `json:"is_private"`      // is_private
-	IsDefault     int            `json:"is_default"`      // is_default
-	CreatedAt     string         `json:"created_at"`      // created_at
-	LastMessageAt sql.NullString `json:"last_message_at"` // last_message_at
+	ID              string         `json:"id"`                // id
+	Name            string         `json:"name"`              // name
+	RoomType        string         `json:"room_type"`         // room_type
+	IsPrivate       int            `json:"is_private"`        // is_private
+	IsDefault       int            `json:"is_default"`        // is_default
+	CreatedAt       string         `json:"created_at"`        // created_at
+	LastMessageAt   sql.NullString `json:"last_message_at"`   // last_message_at
+	SlowModeSeconds int            `json:"slow_mode_seconds"` // slow_mode_seconds
+	RetentionDays   int            `json:"retention_days"`    // retention_days
+	Topic           string         `json:"topic"`             // topic
+	JoinPolicy      string         `json:"join_policy"`       // join_policy
+	PostPolicy      string         `json:"post_policy"`       // post_policy
+	AnnouncePins    int            `json:"announce_pins"`     // announce_pins
 	// xo fields
 	_exists, _deleted bool
 }
@@ -41,13 +47,13 @@ func (r *Room) Insert(ctx context.Context, db DB) error {
 	}
 	// insert (manual)
 	const sqlstr = `INSERT INTO rooms (` +
-		`id, name, room_type, is_private, is_default, created_at, last_message_at` +
+		`id, name, room_type, is_private, is_default, created_at, last_message_at, slow_mode_seconds, retention_days, topic, join_policy, post_policy, announce_pins` +
 		`) VALUES (` +
-		`$1, $2, $3, $4, $5, $6, $7` +
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13` +
 		`)`
 	// run
-	logf(sqlstr, r.ID, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt)
-	if _, err := db.ExecContext(ctx, sqlstr, r.ID, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt); err != nil {
+	logf(sqlstr, r.ID, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt, r.SlowModeSeconds, r.RetentionDays, r.Topic, r.JoinPolicy, r.PostPolicy, r.AnnouncePins)
+	if _, err := db.ExecContext(ctx, sqlstr, r.ID, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt, r.SlowModeSeconds, r.RetentionDays, r.Topic, r.JoinPolicy, r.PostPolicy, r.AnnouncePins); err != nil {
 		return logerror(err)
 	}
 	// set exists
@@ -65,11 +71,11 @@ func (r *Room) Update(ctx context.Context, db DB) error {
 	}
 	// update with primary key
 	const sqlstr = `UPDATE rooms SET ` +
-		`name = $1, room_type = $2, is_private = $3, is_default = $4, created_at = $5, last_message_at = $6 ` +
-		`WHERE id = $7`
+		`name = $1, room_type = $2, is_private = $3, is_default = $4, created_at = $5, last_message_at = $6, slow_mode_seconds = $7, retention_days = $8, topic = $9, join_policy = $10, post_policy = $11, announce_pins = $12 ` +
+		`WHERE id = $13`
 	// run
-	logf(sqlstr, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt, r.ID)
-	if _, err := db.ExecContext(ctx, sqlstr, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt, r.ID); err != nil {
+	logf(sqlstr, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt, r.SlowModeSeconds, r.RetentionDays, r.Topic, r.JoinPolicy, r.PostPolicy, r.AnnouncePins, r.ID)
+	if _, err := db.ExecContext(ctx, sqlstr, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt, r.SlowModeSeconds, r.RetentionDays, r.Topic, r.JoinPolicy, r.PostPolicy, r.AnnouncePins, r.ID); err != nil {
 		return logerror(err)
 	}
 	return nil
@@ -91,16 +97,16 @@ func (r *Room) Upsert(ctx context.Context, db DB) error {
 	}
 	// upsert
 	const sqlstr = `INSERT INTO rooms (` +
-		`id, name, room_type, is_private, is_default, created_at, last_message_at` +
+		`id, name, room_type, is_private, is_default, created_at, last_message_at, slow_mode_seconds, retention_days, topic, join_policy, post_policy, announce_pins` +
 		`) VALUES (` +
-		`$1, $2, $3, $4, $5, $6, $7` +
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13` +
 		`)` +
 		` ON CONFLICT (id) DO ` +
 		`UPDATE SET ` +
-		`name = EXCLUDED.name, room_type = EXCLUDED.room_type, is_private = EXCLUDED.is_private, is_default = EXCLUDED.is_default, created_at = EXCLUDED.created_at, last_message_at = EXCLUDED.last_message_at `
+		`name = EXCLUDED.name, room_type = EXCLUDED.room_type, is_private = EXCLUDED.is_private, is_default = EXCLUDED.is_default, created_at = EXCLUDED.created_at, last_message_at = EXCLUDED.last_message_at, slow_mode_seconds = EXCLUDED.slow_mode_seconds, retention_days = EXCLUDED.retention_days, topic = EXCLUDED.topic, join_policy = EXCLUDED.join_policy, post_policy = EXCLUDED.post_policy, announce_pins = EXCLUDED.announce_pins `
 	// run
-	logf(sqlstr, r.ID, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt)
-	if _, err := db.ExecContext(ctx, sqlstr, r.ID, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt); err != nil {
+	logf(sqlstr, r.ID, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt, r.SlowModeSeconds, r.RetentionDays, r.Topic, r.JoinPolicy, r.PostPolicy, r.AnnouncePins)
+	if _, err := db.ExecContext(ctx, sqlstr, r.ID, r.Name, r.RoomType, r.IsPrivate, r.IsDefault, r.CreatedAt, r.LastMessageAt, r.SlowModeSeconds, r.RetentionDays, r.Topic, r.JoinPolicy, r.PostPolicy, r.AnnouncePins); err != nil {
 		return logerror(err)
 	}
 	// set exists
@@ -135,7 +141,7 @@ func (r *Room) Delete(ctx context.Context, db DB) error {
 func RoomByName(ctx context.Context, db DB, name string) (*Room, error) {
 	// query
 	const sqlstr = `SELECT ` +
-		`id, name, room_type, is_private, is_default, created_at, last_message_at ` +
+		`id, name, room_type, is_private, is_default, created_at, last_message_at, slow_mode_seconds, retention_days, topic, join_policy, post_policy, announce_pins ` +
 		`FROM rooms ` +
 		`WHERE name = $1`
 	// run
@@ -143,7 +149,7 @@ func RoomByName(ctx context.Context, db DB, name string) (*Room, error) {
 	r := Room{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, name).Scan(&r.ID, &r.Name, &r.RoomType, &r.IsPrivate, &r.IsDefault, &r.CreatedAt, &r.LastMessageAt); err != nil {
+	if err := db.QueryRowContext(ctx, sqlstr, name).Scan(&r.ID, &r.Name, &r.RoomType, &r.IsPrivate, &r.IsDefault, &r.CreatedAt, &r.LastMessageAt, &r.SlowModeSeconds, &r.RetentionDays, &r.Topic, &r.JoinPolicy, &r.PostPolicy, &r.AnnouncePins); err != nil {
 		return nil, logerror(err)
 	}
 	return &r, nil
@@ -155,7 +161,7 @@ func RoomByName(ctx context.Context, db DB, name string) (*Room, error) {
 func RoomByID(ctx context.Context, db DB, id string) (*Room, error) {
 	// query
 	const sqlstr = `SELECT ` +
-		`id, name, room_type, is_private, is_default, created_at, last_message_at ` +
+		`id, name, room_type, is_private, is_default, created_at, last_message_at, slow_mode_seconds, retention_days, topic, join_policy, post_policy, announce_pins ` +
 		`FROM rooms ` +
 		`WHERE id = $1`
 	// run
@@ -163,7 +169,7 @@ func RoomByID(ctx context.Context, db DB, id string) (*Room, error) {
 	r := Room{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, id).Scan(&r.ID, &r.Name, &r.RoomType, &r.IsPrivate, &r.IsDefault, &r.CreatedAt, &r.LastMessageAt); err != nil {
+	if err := db.QueryRowContext(ctx, sqlstr, id).Scan(&r.ID, &r.Name, &r.RoomType, &r.IsPrivate, &r.IsDefault, &r.CreatedAt, &r.LastMessageAt, &r.SlowModeSeconds, &r.RetentionDays, &r.Topic, &r.JoinPolicy, &r.PostPolicy, &r.AnnouncePins); err != nil {
 		return nil, logerror(err)
 	}
 	return &r, nil
@@ -10,6 +10,8 @@ import (
 type RoomsMember struct {
 	UserID string `json:"user_id"` // user_id
 	RoomID string `json:"room_id"` // room_id
+	Role   string `json:"role"`    // role
+	Muted  int    `json:"muted"`   // muted
 	// xo fields
 	_exists, _deleted bool
 }
@@ -35,13 +37,13 @@ func (rm *RoomsMember) Insert(ctx context.Context, db DB) error {
 	}
 	// insert (manual)
 	const sqlstr = `INSERT INTO rooms_members (` +
-		`user_id, room_id` +
+		`user_id, room_id, role, muted` +
 		`) VALUES (` +
-		`$1, $2` +
+		`$1, $2, $3, $4` +
 		`)`
 	// run
-	logf(sqlstr, rm.UserID, rm.RoomID)
-	if _, err := db.ExecContext(ctx, sqlstr, rm.UserID, rm.RoomID); err != nil {
+	logf(sqlstr, rm.UserID, rm.RoomID, rm.Role, rm.Muted)
+	if _, err := db.ExecContext(ctx, sqlstr, rm.UserID, rm.RoomID, rm.Role, rm.Muted); err != nil {
 		return logerror(err)
 	}
 	// set exists
@@ -49,7 +51,58 @@ func (rm *RoomsMember) Insert(ctx context.Context, db DB) error {
 	return nil
 }
 
-// ------ NOTE: Update statements omitted due to lack of fields other than primary key ------
+// Update updates a [RoomsMember] in the database.
+func (rm *RoomsMember) Update(ctx context.Context, db DB) error {
+	switch {
+	case !rm._exists: // doesn't exist
+		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
+	case rm._deleted: // deleted
+		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
+	}
+	// update with composite primary key
+	const sqlstr = `UPDATE rooms_members SET ` +
+		`role = $1, muted = $2 ` +
+		`WHERE user_id = $3 AND room_id = $4`
+	// run
+	logf(sqlstr, rm.Role, rm.Muted, rm.UserID, rm.RoomID)
+	if _, err := db.ExecContext(ctx, sqlstr, rm.Role, rm.Muted, rm.UserID, rm.RoomID); err != nil {
+		return logerror(err)
+	}
+	return nil
+}
+
+// Save saves the [RoomsMember] to the database.
+func (rm *RoomsMember) Save(ctx context.Context, db DB) error {
+	if rm.Exists() {
+		return rm.Update(ctx, db)
+	}
+	return rm.Insert(ctx, db)
+}
+
+// Upsert performs an upsert for [RoomsMember].
+func (rm *RoomsMember) Upsert(ctx context.Context, db DB) error {
+	switch {
+	case rm._deleted: // deleted
+		return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+	}
+	// upsert
+	const sqlstr = `INSERT INTO rooms_members (` +
+		`user_id, room_id, role, muted` +
+		`) VALUES (` +
+		`$1, $2, $3, $4` +
+		`)` +
+		` ON CONFLICT (user_id, room_id) DO ` +
+		`UPDATE SET ` +
+		`role = EXCLUDED.role, muted = EXCLUDED.muted `
+	// run
+	logf(sqlstr, rm.UserID, rm.RoomID, rm.Role, rm.Muted)
+	if _, err := db.ExecContext(ctx, sqlstr, rm.UserID, rm.RoomID, rm.Role, rm.Muted); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	rm._exists = true
+	return nil
+}
 
 // Delete deletes the [RoomsMember] from the database.
 func (rm *RoomsMember) Delete(ctx context.Context, db DB) error {
@@ -78,7 +131,7 @@ func (rm *RoomsMember) Delete(ctx context.Context, db DB) error {
 func RoomsMemberByUserIDRoomID(ctx context.Context, db DB, userID, roomID string) (*RoomsMember, error) {
 	// query
 	const sqlstr = `SELECT ` +
-		`user_id, room_id ` +
+		`user_id, room_id, role, muted ` +
 		`FROM rooms_members ` +
 		`WHERE user_id = $1 AND room_id = $2`
 	// run
@@ -86,7 +139,7 @@ func RoomsMemberByUserIDRoomID(ctx context.Context, db DB, userID, roomID string
 	rm := RoomsMember{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, userID, roomID).Scan(&rm.UserID, &rm.RoomID); err != nil {
+	if err := db.QueryRowContext(ctx, sqlstr, userID, roomID).Scan(&rm.UserID, &rm.RoomID, &rm.Role, &rm.Muted); err != nil {
 		return nil, logerror(err)
 	}
 	return &rm, nil
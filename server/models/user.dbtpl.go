@@ -9,16 +9,17 @@ import (
 
 // User represents a row from 'users'.
 type User struct {
-	ID          string         `json:"id"`           // id
-	Username    string         `json:"username"`     // username
-	Password    string         `json:"password"`     // password
-	DisplayName string         `json:"display_name"` // display_name
-	Status      string         `json:"status"`       // status
-	Active      sql.NullInt64  `json:"active"`       // active
-	Avatar      sql.NullString `json:"avatar"`       // avatar
-	LastRoom    string         `json:"last_room"`    // last_room
-	CreatedAt   string         `json:"created_at"`   // created_at
-	ModifiedAt  string         `json:"modified_at"`  // modified_at
+	ID           string         `json:"id"`             // id
+	Username     string         `json:"username"`       // username
+	Password     string         `json:"password"`       // password
+	DisplayName  string         `json:"display_name"`   // display_name
+	Status       string         `json:"status"`         // status
+	Active       sql.NullInt64  `json:"active"`         // active
+	Avatar       sql.NullString `json:"avatar"`         // avatar
+	LastRoom     string         `json:"last_room"`      // last_room
+	CreatedAt    string         `json:"created_at"`     // created_at
+	ModifiedAt   string         `json:"modified_at"`    // modified_at
+	LastActiveAt string         `json:"last_active_at"` // last_active_at
 	// xo fields
 	_exists, _deleted bool
 }
@@ -44,13 +45,13 @@ func (u *User) Insert(ctx context.Context, db DB) error {
 	}
 	// insert (manual)
 	const sqlstr = `INSERT INTO users (` +
-		`id, username, password, display_name, status, active, avatar, last_room, created_at, modified_at` +
+		`id, username, password, display_name, status, active, avatar, last_room, created_at, modified_at, last_active_at` +
 		`) VALUES (` +
-		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10` +
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11` +
 		`)`
 	// run
-	logf(sqlstr, u.ID, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt)
-	if _, err := db.ExecContext(ctx, sqlstr, u.ID, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt); err != nil {
+	logf(sqlstr, u.ID, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt, u.LastActiveAt)
+	if _, err := db.ExecContext(ctx, sqlstr, u.ID, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt, u.LastActiveAt); err != nil {
 		return logerror(err)
 	}
 	// set exists
@@ -68,11 +69,11 @@ func (u *User) Update(ctx context.Context, db DB) error {
 	}
 	// update with primary key
 	const sqlstr = `UPDATE users SET ` +
-		`username = $1, password = $2, display_name = $3, status = $4, active = $5, avatar = $6, last_room = $7, created_at = $8, modified_at = $9 ` +
-		`WHERE id = $10`
+		`username = $1, password = $2, display_name = $3, status = $4, active = $5, avatar = $6, last_room = $7, created_at = $8, modified_at = $9, last_active_at = $10 ` +
+		`WHERE id = $11`
 	// run
-	logf(sqlstr, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt, u.ID)
-	if _, err := db.ExecContext(ctx, sqlstr, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt, u.ID); err != nil {
+	logf(sqlstr, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt, u.LastActiveAt, u.ID)
+	if _, err := db.ExecContext(ctx, sqlstr, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt, u.LastActiveAt, u.ID); err != nil {
 		return logerror(err)
 	}
 	return nil
@@ -94,16 +95,16 @@ func (u *User) Upsert(ctx context.Context, db DB) error {
 	}
 	// upsert
 	const sqlstr = `INSERT INTO users (` +
-		`id, username, password, display_name, status, active, avatar, last_room, created_at, modified_at` +
+		`id, username, password, display_name, status, active, avatar, last_room, created_at, modified_at, last_active_at` +
 		`) VALUES (` +
-		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10` +
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11` +
 		`)` +
 		` ON CONFLICT (id) DO ` +
 		`UPDATE SET ` +
-		`username = EXCLUDED.username, password = EXCLUDED.password, display_name = EXCLUDED.display_name, status = EXCLUDED.status, active = EXCLUDED.active, avatar = EXCLUDED.avatar, last_room = EXCLUDED.last_room, created_at = EXCLUDED.created_at, modified_at = EXCLUDED.modified_at `
+		`username = EXCLUDED.username, password = EXCLUDED.password, display_name = EXCLUDED.display_name, status = EXCLUDED.status, active = EXCLUDED.active, avatar = EXCLUDED.avatar, last_room = EXCLUDED.last_room, created_at = EXCLUDED.created_at, modified_at = EXCLUDED.modified_at, last_active_at = EXCLUDED.last_active_at `
 	// run
-	logf(sqlstr, u.ID, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt)
-	if _, err := db.ExecContext(ctx, sqlstr, u.ID, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt); err != nil {
+	logf(sqlstr, u.ID, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt, u.LastActiveAt)
+	if _, err := db.ExecContext(ctx, sqlstr, u.ID, u.Username, u.Password, u.DisplayName, u.Status, u.Active, u.Avatar, u.LastRoom, u.CreatedAt, u.ModifiedAt, u.LastActiveAt); err != nil {
 		return logerror(err)
 	}
 	// set exists
@@ -138,7 +139,7 @@ func (u *User) Delete(ctx context.Context, db DB) error {
 func UserByID(ctx context.Context, db DB, id string) (*User, error) {
 	// query
 	const sqlstr = `SELECT ` +
-		`id, username, password, display_name, status, active, avatar, last_room, created_at, modified_at ` +
+		`id, username, password, display_name, status, active, avatar, last_room, created_at, modified_at, last_active_at ` +
 		`FROM users ` +
 		`WHERE id = $1`
 	// run
@@ -146,7 +147,7 @@ func UserByID(ctx context.Context, db DB, id string) (*User, error) {
 	u := User{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, id).Scan(&u.ID, &u.Username, &u.Password, &u.DisplayName, &u.Status, &u.Active, &u.Avatar, &u.LastRoom, &u.CreatedAt, &u.ModifiedAt); err != nil {
+	if err := db.QueryRowContext(ctx, sqlstr, id).Scan(&u.ID, &u.Username, &u.Password, &u.DisplayName, &u.Status, &u.Active, &u.Avatar, &u.LastRoom, &u.CreatedAt, &u.ModifiedAt, &u.LastActiveAt); err != nil {
 		return nil, logerror(err)
 	}
 	return &u, nil
@@ -158,7 +159,7 @@ func UserByID(ctx context.Context, db DB, id string) (*User, error) {
 func UserByUsername(ctx context.Context, db DB, username string) (*User, error) {
 	// query
 	const sqlstr = `SELECT ` +
-		`id, username, password, display_name, status, active, avatar, last_room, created_at, modified_at ` +
+		`id, username, password, display_name, status, active, avatar, last_room, created_at, modified_at, last_active_at ` +
 		`FROM users ` +
 		`WHERE username = $1`
 	// run
@@ -166,7 +167,7 @@ func UserByUsername(ctx context.Context, db DB, username string) (*User, error)
 	u := User{
 		_exists: true,
 	}
-	if err := db.QueryRowContext(ctx, sqlstr, username).Scan(&u.ID, &u.Username, &u.Password, &u.DisplayName, &u.Status, &u.Active, &u.Avatar, &u.LastRoom, &u.CreatedAt, &u.ModifiedAt); err != nil {
+	if err := db.QueryRowContext(ctx, sqlstr, username).Scan(&u.ID, &u.Username, &u.Password, &u.DisplayName, &u.Status, &u.Active, &u.Avatar, &u.LastRoom, &u.CreatedAt, &u.ModifiedAt, &u.LastActiveAt); err != nil {
 		return nil, logerror(err)
 	}
 	return &u, nil
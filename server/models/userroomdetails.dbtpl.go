@@ -12,12 +12,13 @@ type UserRoomDetails struct {
 	Name      string `json:"name"`       // name
 	RoomType  string `json:"room_type"`  // room_type
 	IsPrivate int    `json:"is_private"` // is_private
+	Topic     string `json:"topic"`      // topic
 }
 
 // UserRoomDetailsByUserID runs a custom query, returning results as [UserRoomDetails].
 func UserRoomDetailsByUserID(ctx context.Context, db DB, userID string) ([]*UserRoomDetails, error) {
 	// query
-	const sqlstr = `SELECT r.id, r.name, r.room_type, r.is_private ` +
+	const sqlstr = `SELECT r.id, r.name, r.room_type, r.is_private, r.topic ` +
 		`FROM rooms r ` +
 		`JOIN rooms_members rm ON r.id = rm.room_id ` +
 		`WHERE rm.user_id = $1 AND r.room_type = 'channel' ` +
@@ -34,7 +35,7 @@ func UserRoomDetailsByUserID(ctx context.Context, db DB, userID string) ([]*User
 	for rows.Next() {
 		var urd UserRoomDetails
 		// scan
-		if err := rows.Scan(&urd.ID, &urd.Name, &urd.RoomType, &urd.IsPrivate); err != nil {
+		if err := rows.Scan(&urd.ID, &urd.Name, &urd.RoomType, &urd.IsPrivate, &urd.Topic); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &urd)
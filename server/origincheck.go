@@ -0,0 +1,55 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// allowedOriginsFromEnv parses the WS_ALLOWED_ORIGINS environment variable, a
+// comma-separated list of origins (e.g. "https://chat.example.com") that may
+// open WebSocket connections in addition to the server's own host. The single
+// value "*" allows any origin, for development behind proxies.
+func allowedOriginsFromEnv() []string {
+	val := os.Getenv("WS_ALLOWED_ORIGINS")
+	if val == "" {
+		return nil
+	}
+	var origins []string
+	for _, origin := range strings.Split(val, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// originChecker returns a CheckOrigin function for the websocket upgrader
+// that accepts same-origin requests and any origin in allowed, logging
+// rejections. Requests without an Origin header (non-browser clients) are
+// accepted, matching gorilla's default.
+func originChecker(logger *slog.Logger, allowed []string) func(r *http.Request) bool {
+	allowAll := len(allowed) == 1 && allowed[0] == "*"
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if allowAll {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		for _, a := range allowed {
+			if strings.EqualFold(origin, a) {
+				return true
+			}
+		}
+		logger.Warn("rejecting websocket upgrade from disallowed origin", "origin", origin, "host", r.Host)
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestOriginChecker tests the origin allowlist policy for websocket upgrades
+func TestOriginChecker(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	check := originChecker(logger, []string{"https://app.example.com"})
+
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Host = "chat.example.com"
+
+	// Non-browser clients send no Origin header and are accepted
+	if !check(req) {
+		t.Error("Expected request without Origin to be allowed")
+	}
+
+	// Same-origin requests are always accepted
+	req.Header.Set("Origin", "https://chat.example.com")
+	if !check(req) {
+		t.Error("Expected same-origin request to be allowed")
+	}
+
+	// Origins on the allowlist are accepted
+	req.Header.Set("Origin", "https://app.example.com")
+	if !check(req) {
+		t.Error("Expected allowlisted origin to be allowed")
+	}
+
+	// Anything else is rejected
+	req.Header.Set("Origin", "https://evil.example.com")
+	if check(req) {
+		t.Error("Expected unknown origin to be rejected")
+	}
+}
+
+// TestOriginCheckerDevMode tests that a "*" allowlist permits any origin
+func TestOriginCheckerDevMode(t *testing.T) {
+	check := originChecker(slog.New(slog.NewTextHandler(io.Discard, nil)), []string{"*"})
+
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Host = "chat.example.com"
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	if !check(req) {
+		t.Error("Expected dev mode to allow any origin")
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultMinPasswordLength is the minimum password length when
+// MIN_PASSWORD_LENGTH is unset
+const defaultMinPasswordLength = 8
+
+// commonPasswordsFile is a short list of the most frequently used passwords,
+// one per line; rejecting them blocks the bulk of credential-stuffing guesses
+//
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords indexes the embedded list for O(1) lookups
+var commonPasswords = func() map[string]bool {
+	passwords := make(map[string]bool)
+	for _, line := range strings.Split(commonPasswordsFile, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			passwords[line] = true
+		}
+	}
+	return passwords
+}()
+
+// checkPasswordStrength validates a registration password against the
+// server's strength policy, returning nil when it passes
+func (h *ChatServer) checkPasswordStrength(password string) *authFailure {
+	if len(password) < h.minPasswordLength {
+		return &authFailure{http.StatusBadRequest,
+			fmt.Sprintf("password must be at least %d characters", h.minPasswordLength)}
+	}
+	if h.rejectCommonPasswords && commonPasswords[strings.ToLower(password)] {
+		return &authFailure{http.StatusBadRequest,
+			"that password is too commonly used; please choose another"}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// TestPasswordMinLength tests that registration rejects passwords shorter
+// than the configured minimum
+func TestPasswordMinLength(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+	ts.chatServer.minPasswordLength = 10
+
+	form := url.Values{}
+	form.Set("username", "shortpassuser")
+	form.Set("password", "ninechars")
+	postRegister(t, ts, form)
+	if userExists(t, ts, "shortpassuser") {
+		t.Error("Expected a too-short password to be rejected, but user was created")
+	}
+
+	form.Set("password", "tencharsok")
+	postRegister(t, ts, form)
+	if !userExists(t, ts, "shortpassuser") {
+		t.Error("Expected a long-enough password to be accepted")
+	}
+}
+
+// TestPasswordCommonRejected tests that a password from the embedded common
+// list is rejected when the policy is enabled
+func TestPasswordCommonRejected(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+	ts.chatServer.rejectCommonPasswords = true
+
+	form := url.Values{}
+	form.Set("username", "commonpassuser")
+	form.Set("password", "password123")
+	postRegister(t, ts, form)
+	if userExists(t, ts, "commonpassuser") {
+		t.Error("Expected a common password to be rejected, but user was created")
+	}
+
+	// The same password passes with the policy disabled
+	ts.chatServer.rejectCommonPasswords = false
+	postRegister(t, ts, form)
+	if !userExists(t, ts, "commonpassuser") {
+		t.Error("Expected the password to be accepted with the policy disabled")
+	}
+}
+
+// TestPasswordBcryptCost tests that the configured bcrypt cost is applied
+// to new password hashes
+func TestPasswordBcryptCost(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+	ts.chatServer.bcryptCost = bcrypt.MinCost
+
+	form := url.Values{}
+	form.Set("username", "costuser")
+	form.Set("password", "anuncommonpassword")
+	postRegister(t, ts, form)
+
+	user, err := models.UserByUsername(context.Background(), ts.chatServer.db, "costuser")
+	if err != nil {
+		t.Fatalf("Expected registration to succeed: %v", err)
+	}
+	cost, err := bcrypt.Cost([]byte(user.Password))
+	if err != nil {
+		t.Fatalf("Failed to read hash cost: %v", err)
+	}
+	if cost != bcrypt.MinCost {
+		t.Errorf("Expected bcrypt cost %d, got %d", bcrypt.MinCost, cost)
+	}
+}
@@ -21,6 +21,15 @@ type MessageMeta struct {
 	Description string    // Human-readable description
 }
 
+// SubprotocolV1 is the current websocket wire protocol version. Clients
+// advertise the versions they speak via the Sec-WebSocket-Protocol header
+// and the server confirms one during the upgrade, so message shapes can
+// evolve without silently breaking older clients.
+const SubprotocolV1 = "hatchat.v1"
+
+// SupportedSubprotocols lists the versions the server accepts, preferred first
+var SupportedSubprotocols = []string{SubprotocolV1}
+
 // Envelope is the wrapper for all WebSocket messages
 type Envelope struct {
 	Type string `json:"type" jsonschema:"required,description=Message type identifier"`
@@ -42,11 +51,15 @@ type User struct {
 
 // Room represents a chat room or DM
 type Room struct {
-	ID        string       `json:"id" jsonschema:"required,description=Unique room identifier (roo_ prefix),pattern=^roo_[a-f0-9]{12}$"`
-	Name      string       `json:"name" jsonschema:"required,description=Room display name (empty for DMs)"`
-	RoomType  string       `json:"room_type" jsonschema:"required,description=Type of room: 'channel' or 'dm',enum=channel,enum=dm"`
-	IsPrivate bool         `json:"is_private" jsonschema:"required,description=Whether the room is private"`
-	Members   []RoomMember `json:"members,omitempty" jsonschema:"description=Room members (only populated for DMs)"`
+	ID           string       `json:"id" jsonschema:"required,description=Unique room identifier (roo_ prefix),pattern=^roo_[a-f0-9]{12}$"`
+	Name         string       `json:"name" jsonschema:"required,description=Room display name (empty for DMs)"`
+	DisplayName  string       `json:"display_name,omitempty" jsonschema:"description=Server-computed name for DMs relative to the requesting user; empty for channels"`
+	RoomType     string       `json:"room_type" jsonschema:"required,description=Type of room: 'channel' or 'dm',enum=channel,enum=dm"`
+	IsPrivate    bool         `json:"is_private" jsonschema:"required,description=Whether the room is private"`
+	Topic        string       `json:"topic,omitempty" jsonschema:"description=Short room description shown in the header (may be empty)"`
+	Members      []RoomMember `json:"members,omitempty" jsonschema:"description=Room members (only populated for DMs)"`
+	MemberCount  int          `json:"member_count,omitempty" jsonschema:"description=Number of members (only populated for room listings)"`
+	LastActivity string       `json:"last_activity,omitempty" jsonschema:"description=Timestamp of the most recent message or room creation (only populated for room listings)"`
 }
 
 // RoomMember represents a member of a room
@@ -55,26 +68,65 @@ type RoomMember struct {
 	Username    string `json:"username" jsonschema:"required,description=Username"`
 	DisplayName string `json:"display_name" jsonschema:"description=Display name (may be empty)"`
 	Avatar      string `json:"avatar" jsonschema:"description=Avatar URL (may be empty)"`
+	// Presence is only set by list_members; other member lists leave it
+	// empty
+	Presence string `json:"presence,omitempty" jsonschema:"description=Presence flag; set by list_members only,enum=online,enum=away,enum=offline"`
 }
 
 // Message represents a chat message
 type Message struct {
-	ID         string     `json:"id" jsonschema:"required,description=Unique message identifier (msg_ prefix),pattern=^msg_[a-f0-9]{12}$"`
-	RoomID     string     `json:"room_id" jsonschema:"required,description=Room this message belongs to"`
-	UserID     string     `json:"user_id" jsonschema:"required,description=User who sent the message"`
-	Username   string     `json:"username" jsonschema:"required,description=Username of sender (denormalized for convenience)"`
-	Body       string     `json:"body" jsonschema:"required,description=Message content"`
-	CreatedAt  string     `json:"created_at" jsonschema:"required,description=RFC3339Nano timestamp of creation"`
-	ModifiedAt string     `json:"modified_at" jsonschema:"required,description=RFC3339Nano timestamp of last modification"`
-	DeletedAt  string     `json:"deleted_at,omitempty" jsonschema:"description=RFC3339Nano timestamp of deletion (empty if not deleted)"`
-	Reactions  []Reaction `json:"reactions,omitempty" jsonschema:"description=Aggregated emoji reactions on this message"`
+	ID          string       `json:"id" jsonschema:"required,description=Unique message identifier (msg_ prefix),pattern=^msg_[a-f0-9]{12}$"`
+	RoomID      string       `json:"room_id" jsonschema:"required,description=Room this message belongs to"`
+	UserID      string       `json:"user_id" jsonschema:"required,description=User who sent the message"`
+	Username    string       `json:"username" jsonschema:"required,description=Username of sender (denormalized for convenience)"`
+	Body        string       `json:"body" jsonschema:"required,description=Message content"`
+	CreatedAt   string       `json:"created_at" jsonschema:"required,description=RFC3339Nano timestamp of creation"`
+	ModifiedAt  string       `json:"modified_at" jsonschema:"required,description=RFC3339Nano timestamp of last modification"`
+	DeletedAt   string       `json:"deleted_at,omitempty" jsonschema:"description=RFC3339Nano timestamp of deletion (empty if not deleted)"`
+	IsSystem    bool         `json:"is_system,omitempty" jsonschema:"description=True for server-generated announcements (e.g. pin notices); system messages cannot be edited or deleted"`
+	Reactions   []Reaction   `json:"reactions,omitempty" jsonschema:"description=Aggregated emoji reactions on this message"`
+	Attachments []Attachment `json:"attachments,omitempty" jsonschema:"description=Files attached to this message"`
+	SeenBy      []string     `json:"seen_by,omitempty" jsonschema:"description=IDs of members whose read position is at or past this message (only populated for DMs and small rooms)"`
+
+	ForwardedFrom *ForwardedFrom `json:"forwarded_from,omitempty" jsonschema:"description=Attribution for the original message when this is a forwarded copy"`
+	Quote         *QuotedMessage `json:"quote,omitempty" jsonschema:"description=Snapshot of a message quoted inline by this one"`
+}
+
+// ForwardedFrom identifies the original message a forwarded copy came from
+type ForwardedFrom struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=The original message"`
+	RoomID    string `json:"room_id" jsonschema:"required,description=Room the original message was posted in"`
+	UserID    string `json:"user_id" jsonschema:"required,description=Author of the original message"`
+	Username  string `json:"username" jsonschema:"required,description=Username of the original author"`
+}
+
+// QuotedMessage is a snapshot of a message quoted inline by another
+// message. The author, excerpt, and timestamp are copied at send time, so
+// the quote survives even if the original is later deleted or purged.
+type QuotedMessage struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=The quoted message (may no longer exist)"`
+	UserID    string `json:"user_id" jsonschema:"required,description=Author of the quoted message"`
+	Username  string `json:"username" jsonschema:"required,description=Username of the quoted author at quote time"`
+	Excerpt   string `json:"excerpt" jsonschema:"required,description=The start of the quoted message's body at quote time"`
+	CreatedAt string `json:"created_at" jsonschema:"required,description=When the quoted message was sent (RFC3339Nano)"`
+}
+
+// Attachment represents a file attached to a message
+type Attachment struct {
+	ID           string `json:"id" jsonschema:"required,description=Unique attachment identifier (att_ prefix),pattern=^att_[a-f0-9]{12}$"`
+	URL          string `json:"url" jsonschema:"required,description=URL the file can be fetched from"`
+	Filename     string `json:"filename" jsonschema:"required,description=Original filename of the upload"`
+	ContentType  string `json:"content_type" jsonschema:"required,description=MIME type of the file"`
+	Size         int    `json:"size" jsonschema:"required,description=File size in bytes"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty" jsonschema:"description=URL of a downscaled preview for image attachments; empty if none (fall back to url)"`
 }
 
 // Reaction represents an aggregated emoji reaction on a message
 type Reaction struct {
-	Emoji   string   `json:"emoji" jsonschema:"required,description=The emoji character(s)"`
-	Count   int      `json:"count" jsonschema:"required,description=Number of users who reacted with this emoji"`
-	UserIDs []string `json:"user_ids" jsonschema:"required,description=IDs of users who reacted (for highlighting own reactions)"`
+	Emoji       string   `json:"emoji" jsonschema:"required,description=The emoji character(s)"`
+	Count       int      `json:"count" jsonschema:"required,description=Number of users who reacted with this emoji"`
+	UserIDs     []string `json:"user_ids" jsonschema:"required,description=IDs of users who reacted (for highlighting own reactions)"`
+	ReactedByMe bool     `json:"reacted_by_me,omitempty" jsonschema:"description=Whether the requesting user reacted with this emoji"`
 }
 
 // =============================================================================
@@ -88,12 +140,31 @@ type InitRequest struct {
 	// Currently empty, but reserved for future use (e.g., resume token)
 }
 
+// PingRequest is sent by the client for an application-level round-trip
+// check. WebSocket control pings aren't observable from browser JavaScript,
+// so clients behind proxies use this to measure app-layer latency and
+// confirm the handler loop is alive. Touches no state.
+// Direction: client → server
+// Response: PongResponse
+type PingRequest struct {
+	Nonce string `json:"nonce" jsonschema:"description=Opaque value echoed back in the pong (may be empty)"`
+}
+
+// PongResponse is sent by the server in response to PingRequest
+// Direction: server → client
+type PongResponse struct {
+	Nonce string `json:"nonce" jsonschema:"description=The nonce from the ping, unchanged"`
+}
+
 // SendMessageRequest is sent by the client to post a new chat message
 // Direction: client → server
 // Response: Message (broadcast to room)
 type SendMessageRequest struct {
-	Body   string `json:"body" jsonschema:"required,description=Message content,minLength=1"`
-	RoomID string `json:"room_id" jsonschema:"required,description=Target room ID,minLength=1"`
+	Body           string   `json:"body" jsonschema:"required,description=Message content,minLength=1"`
+	RoomID         string   `json:"room_id" jsonschema:"required,description=Target room ID,minLength=1"`
+	AttachmentIDs  []string `json:"attachment_ids,omitempty" jsonschema:"description=IDs of previously-uploaded attachments to link to this message"`
+	ClientMsgID    string   `json:"client_msg_id,omitempty" jsonschema:"description=Optional client-generated ID; resends with the same ID within a short window return the already-created message instead of posting a duplicate"`
+	QuoteMessageID string   `json:"quote_message_id,omitempty" jsonschema:"description=Optional ID of a message in the same room to quote inline; its author and an excerpt are snapshotted into the new message"`
 }
 
 // HistoryRequest is sent by the client to fetch message history
@@ -101,16 +172,174 @@ type SendMessageRequest struct {
 // Response: HistoryResponse
 type HistoryRequest struct {
 	RoomID string `json:"room_id" jsonschema:"required,description=Room to fetch history for"`
-	Cursor string `json:"cursor" jsonschema:"description=Pagination cursor (created_at of oldest message seen)"`
+	Cursor string `json:"cursor" jsonschema:"description=Opaque pagination cursor from a previous response (sequence number of the oldest message seen)"`
 	Limit  int    `json:"limit" jsonschema:"description=Maximum messages to return (default 50; max 100),minimum=1,maximum=100"`
 }
 
+// ForwardMessageRequest is sent by the client to forward an existing message
+// to another room the user is a member of. The server posts a new message in
+// the target room attributed to the forwarder, carrying forwarded_from.
+// Direction: client → server
+type ForwardMessageRequest struct {
+	MessageID    string `json:"message_id" jsonschema:"required,description=Message to forward"`
+	TargetRoomID string `json:"target_room_id" jsonschema:"required,description=Room to forward the message into"`
+}
+
+// ListReactorsRequest is sent by the client to page through the full list of
+// users who reacted to a message with a given emoji; reaction aggregates only
+// embed the first few user IDs inline.
+// Direction: client → server
+// Response: ListReactorsResponse
+type ListReactorsRequest struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message the reaction is on"`
+	Emoji     string `json:"emoji" jsonschema:"required,description=Emoji whose reactors to list"`
+	Cursor    string `json:"cursor" jsonschema:"description=Pagination cursor from a previous response"`
+}
+
+// GetReactionsRequest is sent by the client to fetch the full reaction
+// breakdown for one message, e.g. for a reaction detail popover, without
+// refetching history.
+// Direction: client → server
+// Response: GetReactionsResponse
+type GetReactionsRequest struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message whose reactions to fetch"`
+}
+
+// MarkReadRequest is sent by the client to record its read position in a
+// room. History responses use it to place the unread boundary.
+// Direction: client → server
+type MarkReadRequest struct {
+	RoomID    string `json:"room_id" jsonschema:"required,description=Room the user has read"`
+	MessageID string `json:"message_id" jsonschema:"required,description=The newest message the user has seen"`
+}
+
+// StarMessageRequest is sent by the client to privately save a message.
+// Stars are visible only to the user who made them.
+// Direction: client → server
+type StarMessageRequest struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message to star"`
+}
+
+// UnstarMessageRequest is sent by the client to remove a star. Unstarring a
+// message that isn't starred is a no-op.
+// Direction: client → server
+type UnstarMessageRequest struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message to unstar"`
+}
+
+// ListStarredRequest is sent by the client to fetch its starred messages.
+// Direction: client → server
+// Response: ListStarredResponse
+type ListStarredRequest struct{}
+
+// StarredMessage is one starred message with its room context
+type StarredMessage struct {
+	Message   Message `json:"message" jsonschema:"required,description=The starred message"`
+	RoomName  string  `json:"room_name" jsonschema:"required,description=Name of the room (for display)"`
+	StarredAt string  `json:"starred_at" jsonschema:"required,description=RFC3339 timestamp of when the user starred the message"`
+}
+
+// ListStarredResponse returns the user's starred messages, newest star
+// first. Messages in rooms the user can no longer access are omitted.
+// Direction: server → client
+type ListStarredResponse struct {
+	Messages []StarredMessage `json:"messages" jsonschema:"required,description=The user's starred messages, newest star first"`
+}
+
+// PinMessageRequest is sent by the client to pin a message in its room.
+// Pins are visible to all room members; pinning an already-pinned message
+// is a no-op. When the room has pin announcements enabled, pinning also
+// posts a system message into the room.
+// Direction: client → server
+type PinMessageRequest struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message to pin"`
+}
+
+// MessagePinned is broadcast to room members when a message is pinned
+// Direction: server → client (broadcast)
+type MessagePinned struct {
+	RoomID    string `json:"room_id" jsonschema:"required,description=Room the message was pinned in"`
+	MessageID string `json:"message_id" jsonschema:"required,description=The pinned message"`
+	UserID    string `json:"user_id" jsonschema:"required,description=User who pinned the message"`
+	Username  string `json:"username" jsonschema:"required,description=Username of the pinning user"`
+}
+
+// SetPinAnnouncementsRequest is sent by the client to enable or disable
+// system-message announcements when a message is pinned in a room. Only
+// room owners and admins may change it.
+// Direction: client → server
+// Broadcast: RoomSettingsUpdated to room members
+type SetPinAnnouncementsRequest struct {
+	RoomID  string `json:"room_id" jsonschema:"required,description=Room to configure"`
+	Enabled bool   `json:"enabled" jsonschema:"description=Whether pinning posts a system message into the room"`
+}
+
+// FavoriteRoomRequest is sent by the client to pin a room to the top of
+// their sidebar, or to unpin it. Only rooms the user is a member of may be
+// favorited; new favorites go to the end of the list.
+// Direction: client → server
+// Response: FavoritesUpdated
+type FavoriteRoomRequest struct {
+	RoomID   string `json:"room_id" jsonschema:"required,description=Room to favorite or unfavorite"`
+	Favorite bool   `json:"favorite" jsonschema:"description=True to add the room to favorites; false to remove it"`
+}
+
+// ReorderFavoritesRequest is sent by the client to rearrange their
+// favorite rooms. Every listed room must already be a favorite.
+// Direction: client → server
+// Response: FavoritesUpdated
+type ReorderFavoritesRequest struct {
+	RoomIDs []string `json:"room_ids" jsonschema:"required,description=Favorite room IDs in their new order"`
+}
+
+// FavoritesUpdated carries the user's favorite rooms after a change
+// Direction: server → client
+type FavoritesUpdated struct {
+	Favorites []string `json:"favorites" jsonschema:"required,description=Room IDs the user favorited, in their chosen order"`
+}
+
+// HideDMRequest is sent by the client to remove a DM conversation from
+// their sidebar. Hiding is per-user: the shared room and its messages are
+// untouched, the other participants still see it, and a new message in the
+// DM un-hides it.
+// Direction: client → server
+// Response: HideDMResponse
+type HideDMRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=DM room to hide"`
+}
+
+// HideDMResponse confirms a DM was hidden from the requester's sidebar
+// Direction: server → client
+type HideDMResponse struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=DM room that was hidden"`
+}
+
+// SetDMLabelRequest is sent by the client to give a DM a custom display
+// label, since group DMs otherwise derive an unwieldy name from their
+// member list. Labels are per-user: other participants keep their own
+// view. An empty label clears the custom label and reverts to the derived
+// name.
+// Direction: client → server
+// Response: SetDMLabelResponse
+type SetDMLabelRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=DM room to label"`
+	Label  string `json:"label" jsonschema:"description=Display label; empty clears it,maxLength=100"`
+}
+
+// SetDMLabelResponse confirms a DM label change
+// Direction: server → client
+type SetDMLabelResponse struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=DM room the label applies to"`
+	Label  string `json:"label" jsonschema:"description=The stored label; empty when cleared"`
+}
+
 // JoinRoomRequest is sent by the client to switch to a different room.
 // If the user is not a member of a public room, they will be added as a member.
 // Direction: client → server
 // Response: JoinRoomResponse
 type JoinRoomRequest struct {
-	RoomID string `json:"room_id" jsonschema:"required,description=Room ID to switch to"`
+	RoomID      string `json:"room_id" jsonschema:"required,description=Room ID to switch to"`
+	WithHistory bool   `json:"with_history" jsonschema:"description=When true the response includes the first page of room history so the room renders without a separate history request"`
 }
 
 // CreateRoomRequest is sent by the client to create a new channel room
@@ -128,11 +357,78 @@ type CreateDMRequest struct {
 	UserIDs []string `json:"user_ids" jsonschema:"required,description=User IDs to start DM with (not including self),minItems=1"`
 }
 
+// AddMembersRequest adds several users to a room in one operation.
+// For private rooms, only owners and admins may add members.
+// Direction: client → server
+// Response: AddMembersResponse
+// Broadcast: MemberChanged to room members for each added user
+type AddMembersRequest struct {
+	RoomID  string   `json:"room_id" jsonschema:"required,description=Room to add the users to"`
+	UserIDs []string `json:"user_ids" jsonschema:"required,description=User IDs to add as members,minItems=1"`
+}
+
+// ApproveJoinRequestRequest approves a pending request to join a room
+// whose join policy is 'request'. Only owners and admins may approve.
+// Direction: client → server
+// Response: ApproveJoinRequestResponse
+// Broadcast: MemberChanged to room members; the approved user also receives it directly
+type ApproveJoinRequestRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room the join request was made for"`
+	UserID string `json:"user_id" jsonschema:"required,description=User whose join request is being approved"`
+}
+
+// DenyJoinRequestRequest denies a pending request to join a room whose
+// join policy is 'request'. Only owners and admins may deny.
+// Direction: client → server
+// Response: DenyJoinRequestResponse
+type DenyJoinRequestRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room the join request was made for"`
+	UserID string `json:"user_id" jsonschema:"required,description=User whose join request is being denied"`
+}
+
+// ListJoinRequestsRequest lists the pending join requests for a room.
+// Only owners and admins may list them.
+// Direction: client → server
+// Response: ListJoinRequestsResponse
+type ListJoinRequestsRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room to list pending join requests for"`
+}
+
+// ReportMessageRequest flags a message for the room's moderators. Reporting
+// the same message twice is idempotent.
+// Direction: client → server
+// Response: ReportMessageResponse; MessageReported to room owners and admins
+type ReportMessageRequest struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message being reported"`
+	Reason    string `json:"reason,omitempty" jsonschema:"description=Why the message is being reported,maxLength=500"`
+}
+
+// ListReportsRequest lists a room's message reports.
+// Only owners and admins may list them.
+// Direction: client → server
+// Response: ListReportsResponse
+type ListReportsRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room to list reports for"`
+	Status string `json:"status,omitempty" jsonschema:"description=Limit to reports with this status,enum=open,enum=resolved,enum=dismissed"`
+}
+
+// ResolveReportRequest resolves or dismisses all of a message's open
+// reports. Only owners and admins may resolve them.
+// Direction: client → server
+// Response: ResolveReportResponse
+type ResolveReportRequest struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message whose open reports are being handled"`
+	Status    string `json:"status" jsonschema:"required,description=Outcome for the open reports,enum=resolved,enum=dismissed"`
+}
+
 // ListRoomsRequest is sent by the client to get a list of public rooms
 // Direction: client → server
 // Response: ListRoomsResponse
 type ListRoomsRequest struct {
-	Query string `json:"query" jsonschema:"description=Optional search query to filter rooms by name"`
+	Query  string `json:"query" jsonschema:"description=Optional search query to filter rooms by name"`
+	SortBy string `json:"sort_by,omitempty" jsonschema:"description=Sort order: 'name' (default) 'members' or 'activity',enum=name,enum=members,enum=activity"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"description=Maximum rooms to return (default 100),minimum=1,maximum=500"`
+	Cursor string `json:"cursor,omitempty" jsonschema:"description=Name of the last room from the previous page; only meaningful with the default name sort"`
 }
 
 // ListUsersRequest searches for users (for user picker in DM creation)
@@ -142,6 +438,16 @@ type ListUsersRequest struct {
 	Query string `json:"query" jsonschema:"description=Search query for username (partial match)"`
 }
 
+// AutocompleteRequest asks for inline completion suggestions for the
+// composer's @/#/: pickers. Results are scoped to what the user can
+// access: their rooms, known users, and the registered emoji shortcodes.
+// Direction: client → server
+// Response: AutocompleteResponse
+type AutocompleteRequest struct {
+	Prefix string `json:"prefix" jsonschema:"required,description=The partial text being completed,minLength=1"`
+	Kind   string `json:"kind" jsonschema:"required,description=What to complete,enum=user,enum=room,enum=emoji"`
+}
+
 // LeaveRoomRequest is sent by the client to leave a room
 // Direction: client → server
 // Response: LeaveRoomResponse
@@ -156,6 +462,25 @@ type RoomInfoRequest struct {
 	RoomID string `json:"room_id" jsonschema:"required,description=Room ID to get info for"`
 }
 
+// ListMembersRequest pages through a room's member list with presence
+// flags, for clients that don't need the full room metadata room_info
+// carries. Non-members may list public rooms only.
+// Direction: client → server
+// Response: ListMembersResponse
+type ListMembersRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room to list members of"`
+	Cursor string `json:"cursor,omitempty" jsonschema:"description=Username of the last member from the previous page"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"description=Maximum members to return (default 100),minimum=1,maximum=500"`
+}
+
+// MyMembershipRequest asks for the caller's own membership and role in a
+// room, for rendering permission-gated UI like rename and delete buttons
+// Direction: client → server
+// Response: MyMembershipResponse
+type MyMembershipRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room to check membership of"`
+}
+
 // GetProfileRequest fetches a user's profile
 // Direction: client → server
 // Response: GetProfileResponse
@@ -163,20 +488,59 @@ type GetProfileRequest struct {
 	UserID string `json:"user_id" jsonschema:"required,description=User ID to get profile for"`
 }
 
+// GetProfilesRequest fetches several users' profiles in one call, for
+// rendering member lists and reaction tooltips without a round trip per user
+// Direction: client → server
+// Response: GetProfilesResponse
+type GetProfilesRequest struct {
+	UserIDs []string `json:"user_ids" jsonschema:"required,description=User IDs to fetch (at most 100),minItems=1,maxItems=100"`
+}
+
 // UpdateProfileRequest updates the current user's profile
 // Direction: client → server
 // Response: UpdateProfileResponse
 type UpdateProfileRequest struct {
 	DisplayName *string `json:"display_name,omitempty" jsonschema:"description=New display name (omit to keep current)"`
 	Status      *string `json:"status,omitempty" jsonschema:"description=New status message (omit to keep current)"`
+	Avatar      *string `json:"avatar,omitempty" jsonschema:"description=New avatar URL; empty string clears it (omit to keep current)"`
+}
+
+// SetUsernameRequest changes the current user's username, subject to the
+// same validation as registration. Messages carry the author's username as
+// it was when they were sent; anything re-fetched from the server resolves
+// against the current name.
+// Direction: client → server
+// Response: SetUsernameResponse
+type SetUsernameRequest struct {
+	Username string `json:"username" jsonschema:"required,description=The new username,minLength=2,maxLength=32"`
 }
 
 // EditMessageRequest edits a message's body. Only the message author can edit.
+// Either a full replacement body or a patch against the stored body must be
+// supplied; a patch additionally requires base_modified_at so the server can
+// reject edits built against a stale copy of the message.
 // Direction: client → server
 // Broadcast: MessageEdited to room members
 type EditMessageRequest struct {
 	MessageID string `json:"message_id" jsonschema:"required,description=ID of the message to edit"`
-	Body      string `json:"body" jsonschema:"required,description=New message body,minLength=1"`
+	Body      string `json:"body,omitempty" jsonschema:"description=New message body; required unless patch is set,minLength=1"`
+	// Patch is an ordered list of operations applied to the stored body in
+	// place of a full replacement. Any text past the final operation is
+	// retained unchanged.
+	Patch []PatchOp `json:"patch,omitempty" jsonschema:"description=Operations applied to the stored body in place of a full body"`
+	// BaseModifiedAt is the modified_at value the patch was computed
+	// against. If the stored message has been modified since, the patch is
+	// rejected rather than applied to text the client never saw.
+	BaseModifiedAt string `json:"base_modified_at,omitempty" jsonschema:"description=modified_at of the message version the patch was computed against; required with patch"`
+}
+
+// PatchOp is a single operation in an EditMessageRequest patch. Ops walk the
+// stored body from the start: retain copies count bytes, delete skips count
+// bytes, and insert adds text at the current position.
+type PatchOp struct {
+	Op    string `json:"op" jsonschema:"required,description=One of retain\\, insert\\, or delete"`
+	Count int    `json:"count,omitempty" jsonschema:"description=Byte count for retain and delete ops"`
+	Text  string `json:"text,omitempty" jsonschema:"description=Text to add for insert ops"`
 }
 
 // DeleteMessageRequest soft-deletes a message. Only the message author can delete.
@@ -211,6 +575,94 @@ type SearchRequest struct {
 	UserID string `json:"user_id,omitempty" jsonschema:"description=Filter to messages from specific user"`
 	Cursor string `json:"cursor,omitempty" jsonschema:"description=Pagination cursor for next page"`
 	Limit  int    `json:"limit,omitempty" jsonschema:"description=Max results to return (default 20),minimum=1,maximum=100"`
+
+	// SnippetTokens sets how many tokens of context surround the match in
+	// each snippet; the match itself is always included
+	SnippetTokens int `json:"snippet_tokens,omitempty" jsonschema:"description=Tokens of context around the match in snippets (default 20),minimum=1,maximum=64"`
+}
+
+// SearchStreamRequest starts a streaming search that delivers matches in
+// batches as the FTS cursor advances, ending with a search_stream_done
+// marker. Only one stream runs per connection; a new request replaces any
+// stream still running.
+// Direction: client → server
+// Response: SearchStreamBatch (repeated), then SearchStreamDone
+type SearchStreamRequest struct {
+	Query     string `json:"query" jsonschema:"required,description=Search query text,minLength=1"`
+	RoomID    string `json:"room_id,omitempty" jsonschema:"description=Filter to specific room"`
+	UserID    string `json:"user_id,omitempty" jsonschema:"description=Filter to messages from specific user"`
+	BatchSize int    `json:"batch_size,omitempty" jsonschema:"description=Results per batch (default 50),minimum=1,maximum=100"`
+}
+
+// SearchStreamCancelRequest stops the connection's in-flight streaming
+// search, if any. Batches already queued may still arrive; nothing more is
+// produced after the cancellation is processed.
+// Direction: client → server
+type SearchStreamCancelRequest struct{}
+
+// SetSlowModeRequest sets a room's slow-mode interval. Only room owners and
+// admins may change it; zero disables slow mode.
+// Direction: client → server
+// Broadcast: RoomSettingsUpdated to room members
+type SetSlowModeRequest struct {
+	RoomID  string `json:"room_id" jsonschema:"required,description=Room to update"`
+	Seconds int    `json:"seconds" jsonschema:"required,description=Minimum interval between posts in seconds (0 disables slow mode),minimum=0"`
+}
+
+// SetRetentionRequest sets how long a room keeps messages. Only room owners
+// and admins may change it; zero keeps messages forever.
+// Direction: client → server
+// Broadcast: RoomSettingsUpdated to room members
+type SetRetentionRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room to update"`
+	Days   int    `json:"days" jsonschema:"required,description=Messages older than this many days are purged (0 keeps messages forever),minimum=0"`
+}
+
+// SetPostPolicyRequest sets who may post in a room. Only room owners and
+// admins may change it; 'admins' makes the room announcement-only, where
+// members can still read and react but not post.
+// Direction: client → server
+// Broadcast: RoomSettingsUpdated to room members
+type SetPostPolicyRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room to update"`
+	Policy string `json:"policy" jsonschema:"required,description=Who may post in the room,enum=all,enum=admins"`
+}
+
+// SetRoomTopicRequest is sent by the client to change a room's topic.
+// Only room owners and admins may change it.
+// Direction: client → server
+// Broadcast: RoomSettingsUpdated to room members
+type SetRoomTopicRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room to change the topic of"`
+	Topic  string `json:"topic" jsonschema:"required,description=New topic (empty to clear),maxLength=250"`
+}
+
+// AddRoomFilterRequest adds a content filter rule to a room. Only room
+// owners and admins may manage filters. Matching is case-insensitive and
+// word-boundary aware.
+// Direction: client → server
+// Response: AddRoomFilterResponse
+type AddRoomFilterRequest struct {
+	RoomID  string `json:"room_id" jsonschema:"required,description=Room to add the filter rule to"`
+	Pattern string `json:"pattern" jsonschema:"required,description=Term to match in message bodies,minLength=1,maxLength=80"`
+	Action  string `json:"action" jsonschema:"required,description=What to do with matching messages,enum=reject,enum=mask"`
+}
+
+// RemoveRoomFilterRequest removes a content filter rule from a room.
+// Only room owners and admins may manage filters.
+// Direction: client → server
+// Response: RemoveRoomFilterResponse
+type RemoveRoomFilterRequest struct {
+	RoomID  string `json:"room_id" jsonschema:"required,description=Room to remove the filter rule from"`
+	Pattern string `json:"pattern" jsonschema:"required,description=Term of the rule to remove"`
+}
+
+// ListRoomFiltersRequest lists a room's content filter rules. Only room
+// owners and admins may list them.
+// Direction: client → server
+// Response: ListRoomFiltersResponse
+type ListRoomFiltersRequest struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room to list filter rules for"`
 }
 
 // GetMessageContextRequest fetches a message with surrounding context for permalinks
@@ -218,6 +670,57 @@ type SearchRequest struct {
 // Response: GetMessageContextResponse
 type GetMessageContextRequest struct {
 	MessageID string `json:"message_id" jsonschema:"required,description=ID of the message to get context for"`
+	Limit     int    `json:"limit" jsonschema:"description=Messages to return on each side of the target (default 25; max 100),minimum=1,maximum=100"`
+}
+
+// CatchUpRequest asks for a summary of activity in the user's rooms since a
+// given time, for the "what did I miss" view on reconnect
+// Direction: client → server
+// Response: CatchUpResponse
+type CatchUpRequest struct {
+	Since string `json:"since" jsonschema:"required,description=RFC3339Nano timestamp to summarize activity since"`
+}
+
+// SyncRequest asks for everything a fresh client load needs in one
+// round-trip: the user, rooms, DMs, unread counts, online users, and the
+// first page of the current room's history. The individual init, history,
+// and catch_up messages remain for incremental updates.
+// Direction: client → server
+// Response: SyncResponse
+type SyncRequest struct {
+	Since string `json:"since,omitempty" jsonschema:"description=Optional RFC3339Nano timestamp; when set, unread counts cover only messages posted after it rather than everything past the user's read positions"`
+}
+
+// GetUnreadRequest refreshes the caller's unread counts without a full
+// re-sync, for long-lived clients updating sidebar badges
+// Direction: client → server
+// Response: GetUnreadResponse
+type GetUnreadRequest struct {
+}
+
+// GetPrefsRequest asks for the caller's server-side display preferences
+// Direction: client → server
+// Response: PrefsResponse
+type GetPrefsRequest struct {
+}
+
+// SetPrefsRequest replaces the caller's display preferences, synced across
+// their devices. Top-level keys are limited to a known set and the whole
+// blob is size-capped; values are stored opaquely so clients can evolve
+// their own formats.
+// Direction: client → server
+// Response: PrefsResponse
+type SetPrefsRequest struct {
+	Prefs map[string]any `json:"prefs" jsonschema:"required,description=Preference values keyed by name (theme, clock_24h, compact_mode)"`
+}
+
+// GetMessagesRequest fetches a specific set of messages by ID, for
+// resolving forwarded, quoted, or pinned message references in one
+// round-trip
+// Direction: client → server
+// Response: GetMessagesResponse
+type GetMessagesRequest struct {
+	MessageIDs []string `json:"message_ids" jsonschema:"required,description=IDs of the messages to fetch,minItems=1,maxItems=50"`
 }
 
 // =============================================================================
@@ -227,18 +730,47 @@ type GetMessageContextRequest struct {
 // InitResponse is sent by the server in response to InitRequest
 // Direction: server → client
 type InitResponse struct {
-	User        User    `json:"user" jsonschema:"required,description=The authenticated user"`
-	Rooms       []*Room `json:"rooms" jsonschema:"required,description=Channel rooms the user is a member of"`
-	DMs         []*Room `json:"dms" jsonschema:"required,description=DM rooms the user is a member of (sorted by most recent activity)"`
-	CurrentRoom string  `json:"current_room" jsonschema:"required,description=Room ID to display initially"`
+	User            User    `json:"user" jsonschema:"required,description=The authenticated user"`
+	Rooms           []*Room `json:"rooms" jsonschema:"required,description=Channel rooms the user is a member of"`
+	DMs             []*Room `json:"dms" jsonschema:"required,description=DM rooms the user is a member of (sorted by most recent activity)"`
+	CurrentRoom     string  `json:"current_room" jsonschema:"required,description=Room ID to display initially"`
+	ProtocolVersion string  `json:"protocol_version,omitempty" jsonschema:"description=Negotiated websocket subprotocol (e.g. 'hatchat.v1'; empty when the client did not request one)"`
+
+	// Favorites orders the rooms the user pinned to the top of the sidebar
+	Favorites []string `json:"favorites,omitempty" jsonschema:"description=Room IDs the user favorited, in their chosen order"`
+
+	// Prefs carries the user's stored display preferences so clients
+	// don't need a separate get_prefs round trip at startup
+	Prefs map[string]any `json:"prefs,omitempty" jsonschema:"description=Stored display preference values keyed by name"`
 }
 
 // HistoryResponse is sent by the server in response to HistoryRequest
 // Direction: server → client
 type HistoryResponse struct {
-	Messages   []*Message `json:"messages" jsonschema:"required,description=Messages in chronological order (newest first)"`
-	HasMore    bool       `json:"has_more" jsonschema:"required,description=Whether older messages exist"`
-	NextCursor string     `json:"next_cursor" jsonschema:"required,description=Pass as cursor to fetch older messages"`
+	Messages         []*Message `json:"messages" jsonschema:"required,description=Messages in chronological order (newest first)"`
+	HasMore          bool       `json:"has_more" jsonschema:"required,description=Whether older messages exist"`
+	NextCursor       string     `json:"next_cursor" jsonschema:"required,description=Pass as cursor to fetch older messages"`
+	UnreadBoundaryID string     `json:"unread_boundary_id,omitempty" jsonschema:"description=The user's last read message when it falls within this page; draw the new-messages divider after it"`
+}
+
+// ListReactorsResponse is sent by the server in response to
+// ListReactorsRequest
+// Direction: server → client
+type ListReactorsResponse struct {
+	MessageID  string   `json:"message_id" jsonschema:"required,description=Message the reaction is on"`
+	Emoji      string   `json:"emoji" jsonschema:"required,description=Emoji whose reactors are listed"`
+	UserIDs    []string `json:"user_ids" jsonschema:"required,description=Reactor user IDs in reaction order"`
+	HasMore    bool     `json:"has_more" jsonschema:"required,description=Whether more reactors exist"`
+	NextCursor string   `json:"next_cursor,omitempty" jsonschema:"description=Pass as cursor to fetch the next page"`
+}
+
+// GetReactionsResponse is sent by the server in response to
+// GetReactionsRequest. Unlike the aggregates embedded in history messages,
+// the user ID lists are complete rather than capped.
+// Direction: server → client
+type GetReactionsResponse struct {
+	MessageID string     `json:"message_id" jsonschema:"required,description=Message the reactions are on"`
+	Reactions []Reaction `json:"reactions" jsonschema:"required,description=Aggregated reactions with full user lists (empty when the message has none)"`
 }
 
 // MessageEdited is broadcast to room members when a message is edited
@@ -253,8 +785,9 @@ type MessageEdited struct {
 // MessageDeleted is broadcast to room members when a message is soft-deleted
 // Direction: server → client (broadcast)
 type MessageDeleted struct {
-	MessageID string `json:"message_id" jsonschema:"required,description=ID of the deleted message"`
-	RoomID    string `json:"room_id" jsonschema:"required,description=Room the message belongs to"`
+	MessageID        string `json:"message_id" jsonschema:"required,description=ID of the deleted message"`
+	RoomID           string `json:"room_id" jsonschema:"required,description=Room the message belongs to"`
+	ReactionsCleared bool   `json:"reactions_cleared,omitempty" jsonschema:"description=True when the delete also removed reactions, so clients drop any they are showing"`
 }
 
 // ReactionUpdated is broadcast when a reaction is added or removed
@@ -263,21 +796,183 @@ type ReactionUpdated struct {
 	MessageID string `json:"message_id" jsonschema:"required,description=ID of the message"`
 	RoomID    string `json:"room_id" jsonschema:"required,description=Room the message belongs to"`
 	UserID    string `json:"user_id" jsonschema:"required,description=User who added/removed the reaction"`
+	Username  string `json:"username" jsonschema:"description=Username of the reacting user, so clients can label reactions from bots they haven't seen"`
 	Emoji     string `json:"emoji" jsonschema:"required,description=The emoji character(s)"`
 	Action    string `json:"action" jsonschema:"required,description=Whether the reaction was added or removed,enum=add,enum=remove"`
+	Count     int    `json:"count" jsonschema:"required,description=Total reactions with this emoji on the message after the change"`
+}
+
+// RoomSettingsUpdated is broadcast to room members when a room's settings change
+// Direction: server → client (broadcast)
+type RoomSettingsUpdated struct {
+	RoomID          string `json:"room_id" jsonschema:"required,description=Room whose settings changed"`
+	SlowModeSeconds int    `json:"slow_mode_seconds" jsonschema:"required,description=Current slow-mode interval in seconds (0 = disabled)"`
+	RetentionDays   int    `json:"retention_days" jsonschema:"required,description=Current message retention in days (0 = keep forever)"`
+	Topic           string `json:"topic" jsonschema:"required,description=Current room topic (may be empty)"`
+	PostPolicy      string `json:"post_policy" jsonschema:"required,description=Who may post in the room,enum=all,enum=admins"`
+	AnnouncePins    bool   `json:"announce_pins" jsonschema:"required,description=Whether pinning a message posts a system message into the room"`
+}
+
+// AddMembersResponse reports the outcome of an AddMembersRequest: which
+// users were added, which were skipped because they were already members,
+// and which user IDs did not match any user
+// Direction: server → client
+type AddMembersResponse struct {
+	RoomID         string   `json:"room_id" jsonschema:"required,description=Room the users were added to"`
+	AddedUserIDs   []string `json:"added_user_ids" jsonschema:"required,description=User IDs that were added as members"`
+	SkippedUserIDs []string `json:"skipped_user_ids" jsonschema:"required,description=User IDs skipped because they were already members"`
+	UnknownUserIDs []string `json:"unknown_user_ids" jsonschema:"required,description=User IDs that did not match any user"`
+}
+
+// ApproveJoinRequestResponse confirms that a join request was approved
+// and the user added as a member
+// Direction: server → client
+type ApproveJoinRequestResponse struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room the user was added to"`
+	UserID string `json:"user_id" jsonschema:"required,description=User that was added as a member"`
+}
+
+// DenyJoinRequestResponse confirms that a join request was denied and
+// removed
+// Direction: server → client
+type DenyJoinRequestResponse struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room the join request was made for"`
+	UserID string `json:"user_id" jsonschema:"required,description=User whose join request was denied"`
+}
+
+// JoinRequestInfo is one pending join request in a ListJoinRequestsResponse
+type JoinRequestInfo struct {
+	UserID      string `json:"user_id" jsonschema:"required,description=User asking to join"`
+	Username    string `json:"username" jsonschema:"required,description=Username of the user asking to join"`
+	DisplayName string `json:"display_name" jsonschema:"description=Display name of the user asking to join; empty if unset"`
+	CreatedAt   string `json:"created_at" jsonschema:"required,description=When the request was made (RFC3339)"`
+}
+
+// ListJoinRequestsResponse lists a room's pending join requests, oldest
+// first
+// Direction: server → client
+type ListJoinRequestsResponse struct {
+	RoomID   string            `json:"room_id" jsonschema:"required,description=Room the requests are for"`
+	Requests []JoinRequestInfo `json:"requests" jsonschema:"required,description=Pending join requests, oldest first"`
+}
+
+// JoinRequested is sent to a room's owners and admins when a user asks to
+// join a room whose join policy is 'request'
+// Direction: server → client
+type JoinRequested struct {
+	RoomID   string `json:"room_id" jsonschema:"required,description=Room the user asked to join"`
+	RoomName string `json:"room_name" jsonschema:"required,description=Name of the room the user asked to join"`
+	UserID   string `json:"user_id" jsonschema:"required,description=User asking to join"`
+	Username string `json:"username" jsonschema:"required,description=Username of the user asking to join"`
+}
+
+// ReportMessageResponse confirms a report was recorded (or was already on
+// file from the same reporter)
+// Direction: server → client
+type ReportMessageResponse struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message that was reported"`
+}
+
+// ReportInfo is one message report in a ListReportsResponse
+type ReportInfo struct {
+	MessageID        string `json:"message_id" jsonschema:"required,description=Message that was reported"`
+	ReporterID       string `json:"reporter_id" jsonschema:"required,description=User who filed the report"`
+	ReporterUsername string `json:"reporter_username" jsonschema:"required,description=Username of the user who filed the report"`
+	Reason           string `json:"reason" jsonschema:"description=Why the message was reported; may be empty"`
+	Status           string `json:"status" jsonschema:"required,description=Report status,enum=open,enum=resolved,enum=dismissed"`
+	CreatedAt        string `json:"created_at" jsonschema:"required,description=When the report was filed (RFC3339)"`
 }
 
+// ListReportsResponse lists a room's message reports, oldest first
+// Direction: server → client
+type ListReportsResponse struct {
+	RoomID  string       `json:"room_id" jsonschema:"required,description=Room the reports are for"`
+	Reports []ReportInfo `json:"reports" jsonschema:"required,description=Message reports, oldest first"`
+}
+
+// ResolveReportResponse confirms a message's open reports were handled
+// Direction: server → client
+type ResolveReportResponse struct {
+	MessageID string `json:"message_id" jsonschema:"required,description=Message whose reports were handled"`
+	Status    string `json:"status" jsonschema:"required,description=Outcome applied to the open reports"`
+	Updated   int    `json:"updated" jsonschema:"required,description=How many open reports were updated"`
+}
+
+// MessageReported is sent to a room's owners and admins when a member
+// reports a message
+// Direction: server → client
+type MessageReported struct {
+	RoomID           string `json:"room_id" jsonschema:"required,description=Room the reported message is in"`
+	MessageID        string `json:"message_id" jsonschema:"required,description=Message that was reported"`
+	ReporterID       string `json:"reporter_id" jsonschema:"required,description=User who filed the report"`
+	ReporterUsername string `json:"reporter_username" jsonschema:"required,description=Username of the user who filed the report"`
+	Reason           string `json:"reason" jsonschema:"description=Why the message was reported; may be empty"`
+}
+
+// RoomFilterRule is one content filter rule in a ListRoomFiltersResponse
+type RoomFilterRule struct {
+	Pattern   string `json:"pattern" jsonschema:"required,description=Term matched in message bodies"`
+	Action    string `json:"action" jsonschema:"required,description=What happens to matching messages,enum=reject,enum=mask"`
+	CreatedAt string `json:"created_at" jsonschema:"required,description=When the rule was added (RFC3339)"`
+}
+
+// AddRoomFilterResponse confirms a content filter rule was added
+// Direction: server → client
+type AddRoomFilterResponse struct {
+	RoomID  string `json:"room_id" jsonschema:"required,description=Room the rule was added to"`
+	Pattern string `json:"pattern" jsonschema:"required,description=Term the rule matches"`
+	Action  string `json:"action" jsonschema:"required,description=What happens to matching messages"`
+}
+
+// RemoveRoomFilterResponse confirms a content filter rule was removed
+// Direction: server → client
+type RemoveRoomFilterResponse struct {
+	RoomID  string `json:"room_id" jsonschema:"required,description=Room the rule was removed from"`
+	Pattern string `json:"pattern" jsonschema:"required,description=Term of the removed rule"`
+}
+
+// ListRoomFiltersResponse lists a room's content filter rules
+// Direction: server → client
+type ListRoomFiltersResponse struct {
+	RoomID  string           `json:"room_id" jsonschema:"required,description=Room the rules apply to"`
+	Filters []RoomFilterRule `json:"filters" jsonschema:"required,description=The room's filter rules"`
+}
+
+// MemberChanged is broadcast to room members when a user is added to a
+// room. The added user also receives it directly on all their connections.
+// Direction: server → client (broadcast)
+type MemberChanged struct {
+	RoomID string     `json:"room_id" jsonschema:"required,description=Room whose membership changed"`
+	Member RoomMember `json:"member" jsonschema:"required,description=The member that was added"`
+	Change string     `json:"change" jsonschema:"required,description=What happened to the member,enum=added"`
+}
+
+// Stable machine-readable error codes carried by ErrorResponse.Code, so
+// clients can branch on the kind of failure without parsing the message
+const (
+	ErrCodeValidation  = "validation"   // the request was malformed or missing fields
+	ErrCodeNotFound    = "not_found"    // the referenced entity does not exist
+	ErrCodeNotMember   = "not_member"   // the user is not a member of the room
+	ErrCodeRateLimited = "rate_limited" // slow mode or another limit rejected the request
+	ErrCodeMaintenance = "maintenance"  // the server is in read-only maintenance mode
+	ErrCodeInternal    = "internal"     // the server failed to process the request
+)
+
 // ErrorResponse is sent by the server when an error occurs
 // Direction: server → client
 type ErrorResponse struct {
-	Message string `json:"message" jsonschema:"required,description=Human-readable error message"`
+	Code    string   `json:"code,omitempty" jsonschema:"description=Stable machine-readable error code"`
+	Message string   `json:"message" jsonschema:"required,description=Human-readable error message"`
+	Fields  []string `json:"fields,omitempty" jsonschema:"description=Per-field validation problems (only set for schema validation failures)"`
 }
 
 // JoinRoomResponse is sent by the server in response to JoinRoomRequest
 // Direction: server → client
 type JoinRoomResponse struct {
-	Room   Room `json:"room" jsonschema:"required,description=The room that was joined"`
-	Joined bool `json:"joined" jsonschema:"required,description=True if user was added as a new member (vs already being a member)"`
+	Room    Room             `json:"room" jsonschema:"required,description=The room that was joined"`
+	Joined  bool             `json:"joined" jsonschema:"required,description=True if user was added as a new member (vs already being a member)"`
+	History *HistoryResponse `json:"history,omitempty" jsonschema:"description=First page of room history, including the pagination cursor (only present when with_history was set)"`
+	Pending bool             `json:"pending,omitempty" jsonschema:"description=True when the room requires approval to join and a pending join request was filed instead of adding the user"`
 }
 
 // CreateRoomResponse is sent by the server in response to CreateRoomRequest
@@ -296,8 +991,9 @@ type CreateDMResponse struct {
 // ListRoomsResponse is sent by the server in response to ListRoomsRequest
 // Direction: server → client
 type ListRoomsResponse struct {
-	Rooms    []*Room `json:"rooms" jsonschema:"required,description=List of public rooms"`
-	IsMember []bool  `json:"is_member" jsonschema:"required,description=Whether the user is a member of each room (parallel array)"`
+	Rooms      []*Room `json:"rooms" jsonschema:"required,description=List of public rooms"`
+	IsMember   []bool  `json:"is_member" jsonschema:"required,description=Whether the user is a member of each room (parallel array)"`
+	NextCursor string  `json:"next_cursor,omitempty" jsonschema:"description=Pass as cursor to fetch the next page of the name sort"`
 }
 
 // ListUsersResponse is sent by the server in response to ListUsersRequest
@@ -306,25 +1002,78 @@ type ListUsersResponse struct {
 	Users []User `json:"users" jsonschema:"required,description=List of matching users"`
 }
 
+// AutocompleteItem is one completion suggestion
+type AutocompleteItem struct {
+	ID    string `json:"id,omitempty" jsonschema:"description=User or room ID (empty for emoji)"`
+	Value string `json:"value" jsonschema:"required,description=The text to insert (username, room name, or emoji character)"`
+	Label string `json:"label,omitempty" jsonschema:"description=Display label (e.g. a display name or emoji shortcode)"`
+}
+
+// AutocompleteResponse returns ranked completion suggestions; prefix
+// matches rank above substring matches and results are capped at 10
+// Direction: server → client
+type AutocompleteResponse struct {
+	Kind  string             `json:"kind" jsonschema:"required,description=The kind that was completed,enum=user,enum=room,enum=emoji"`
+	Items []AutocompleteItem `json:"items" jsonschema:"required,description=Ranked suggestions (at most 10)"`
+}
+
 // LeaveRoomResponse is sent by the server in response to LeaveRoomRequest
 // Direction: server → client
 type LeaveRoomResponse struct {
 	RoomID string `json:"room_id" jsonschema:"required,description=Room ID that was left"`
 }
 
+// RoomDeleted is broadcast to a room's members when the room is deleted,
+// so clients can drop it from their sidebar and, if they were viewing it,
+// fall back to the default room
+// Direction: server → client
+type RoomDeleted struct {
+	RoomID string `json:"room_id" jsonschema:"required,description=Room ID that was deleted"`
+}
+
 // RoomInfoResponse is sent by the server in response to RoomInfoRequest
 // Direction: server → client
 type RoomInfoResponse struct {
 	Room        Room         `json:"room" jsonschema:"required,description=Room details"`
-	MemberCount int          `json:"member_count" jsonschema:"required,description=Number of members in the room"`
-	Members     []RoomMember `json:"members" jsonschema:"required,description=List of room members"`
+	MemberCount int          `json:"member_count" jsonschema:"required,description=Total number of members in the room, independent of how many are listed"`
+	Members     []RoomMember `json:"members" jsonschema:"required,description=First page of room members, sorted by username"`
+	NextCursor  string       `json:"next_cursor,omitempty" jsonschema:"description=Cursor to fetch further members through list_members; empty when members holds everyone"`
 	CreatedAt   string       `json:"created_at" jsonschema:"required,description=RFC3339 timestamp of when the room was created"`
 }
 
+// ListMembersResponse is one page of a room's member list, sorted by
+// username, with each member's presence flag set
+// Direction: server → client
+type ListMembersResponse struct {
+	RoomID     string       `json:"room_id" jsonschema:"required,description=Room the members belong to"`
+	Members    []RoomMember `json:"members" jsonschema:"required,description=One page of members, sorted by username"`
+	NextCursor string       `json:"next_cursor,omitempty" jsonschema:"description=Cursor for the next page; empty when this is the last page"`
+}
+
+// MyMembershipResponse is sent by the server in response to
+// MyMembershipRequest. For rooms the caller isn't a member of — including
+// private rooms and rooms that don't exist — it reports is_member false and
+// nothing else.
+// Direction: server → client
+type MyMembershipResponse struct {
+	RoomID   string `json:"room_id" jsonschema:"required,description=Room the membership was checked against"`
+	IsMember bool   `json:"is_member" jsonschema:"required,description=Whether the caller is a member of the room"`
+	Role     string `json:"role,omitempty" jsonschema:"description=The caller's role in the room; empty when not a member,enum=owner,enum=admin,enum=member"`
+}
+
 // GetProfileResponse is sent by the server in response to GetProfileRequest
 // Direction: server → client
 type GetProfileResponse struct {
-	User User `json:"user" jsonschema:"required,description=User profile data"`
+	User         User   `json:"user" jsonschema:"required,description=User profile data"`
+	LastActiveAt string `json:"last_active_at,omitempty" jsonschema:"description=RFC3339 time the user last connected or sent a message; empty if never recorded"`
+}
+
+// GetProfilesResponse is sent by the server in response to
+// GetProfilesRequest. Unknown IDs are silently skipped, so the result may
+// contain fewer users than were requested.
+// Direction: server → client
+type GetProfilesResponse struct {
+	Users []User `json:"users" jsonschema:"required,description=Profiles of the found users, ordered by username"`
 }
 
 // UpdateProfileResponse is sent by the server in response to UpdateProfileRequest
@@ -333,6 +1082,20 @@ type UpdateProfileResponse struct {
 	User User `json:"user" jsonschema:"required,description=Updated user profile"`
 }
 
+// SetUsernameResponse is sent by the server in response to SetUsernameRequest
+// Direction: server → client
+type SetUsernameResponse struct {
+	User User `json:"user" jsonschema:"required,description=The user with the new username"`
+}
+
+// ProfileUpdated notifies a user's peers that their profile record changed —
+// for example a username change — so clients re-render author labels wherever
+// they use the live user record
+// Direction: server → client
+type ProfileUpdated struct {
+	User User `json:"user" jsonschema:"required,description=The updated user record"`
+}
+
 // SearchResponse returns matching messages with snippets
 // Direction: server → client
 type SearchResponse struct {
@@ -343,20 +1106,115 @@ type SearchResponse struct {
 
 // SearchResult is a single search hit with context snippet
 type SearchResult struct {
-	MessageID string `json:"message_id" jsonschema:"required,description=ID of the matching message"`
-	RoomID    string `json:"room_id" jsonschema:"required,description=Room the message belongs to"`
-	RoomName  string `json:"room_name" jsonschema:"required,description=Name of the room (for display)"`
-	UserID    string `json:"user_id" jsonschema:"required,description=Author of the message"`
-	Username  string `json:"username" jsonschema:"required,description=Username of the author"`
-	Snippet   string `json:"snippet" jsonschema:"required,description=Message excerpt with **highlighted** matches"`
-	CreatedAt string `json:"created_at" jsonschema:"required,description=RFC3339Nano timestamp of the message"`
+	MessageID   string       `json:"message_id" jsonschema:"required,description=ID of the matching message"`
+	RoomID      string       `json:"room_id" jsonschema:"required,description=Room the message belongs to"`
+	RoomName    string       `json:"room_name" jsonschema:"required,description=Name of the room (for display)"`
+	UserID      string       `json:"user_id" jsonschema:"required,description=Author of the message"`
+	Username    string       `json:"username" jsonschema:"required,description=Username of the author"`
+	Snippet     string       `json:"snippet" jsonschema:"required,description=Message excerpt with **highlighted** matches"`
+	CreatedAt   string       `json:"created_at" jsonschema:"required,description=RFC3339Nano timestamp of the message"`
+	Attachments []Attachment `json:"attachments,omitempty" jsonschema:"description=Files attached to the matching message"`
+	Reactions   []Reaction   `json:"reactions,omitempty" jsonschema:"description=Aggregated emoji reactions on the matching message"`
 }
 
-// GetMessageContextResponse returns a message and its room for permalink navigation
+// SearchStreamBatch carries one batch of streaming search results
+// Direction: server → client
+type SearchStreamBatch struct {
+	Results []SearchResult `json:"results" jsonschema:"required,description=Matching messages with snippets"`
+}
+
+// SearchStreamDone marks the end of a streaming search
+// Direction: server → client
+type SearchStreamDone struct {
+	Total int `json:"total" jsonschema:"required,description=Number of results delivered across all batches"`
+}
+
+// GetMessageContextResponse returns a message with its surrounding messages
+// for permalink navigation
 // Direction: server → client
 type GetMessageContextResponse struct {
-	Message Message `json:"message" jsonschema:"required,description=The requested message"`
-	RoomID  string  `json:"room_id" jsonschema:"required,description=Room the message belongs to"`
+	Message       Message    `json:"message" jsonschema:"required,description=The requested message"`
+	RoomID        string     `json:"room_id" jsonschema:"required,description=Room the message belongs to"`
+	Before        []*Message `json:"before,omitempty" jsonschema:"description=Messages preceding the target in chronological order"`
+	After         []*Message `json:"after,omitempty" jsonschema:"description=Messages following the target in chronological order"`
+	HasMoreBefore bool       `json:"has_more_before" jsonschema:"description=Whether older messages exist beyond the returned window"`
+	HasMoreAfter  bool       `json:"has_more_after" jsonschema:"description=Whether newer messages exist beyond the returned window"`
+	BeforeCursor  string     `json:"before_cursor,omitempty" jsonschema:"description=Pagination cursor (created_at of the oldest returned message) for scrolling further back"`
+	AfterCursor   string     `json:"after_cursor,omitempty" jsonschema:"description=Pagination cursor (created_at of the newest returned message) for scrolling further forward"`
+}
+
+// RoomCatchUp summarizes activity in one room since the requested time
+type RoomCatchUp struct {
+	RoomID      string     `json:"room_id" jsonschema:"required,description=Room with new activity"`
+	RoomName    string     `json:"room_name" jsonschema:"required,description=Name of the room (for display)"`
+	UnreadCount int        `json:"unread_count" jsonschema:"required,description=Number of messages posted since the requested time"`
+	Previews    []*Message `json:"previews" jsonschema:"required,description=The most recent new messages in chronological order"`
+}
+
+// CatchUpResponse summarizes activity across the user's rooms. Rooms with no
+// new messages and muted rooms are omitted.
+// Direction: server → client
+type CatchUpResponse struct {
+	Rooms []RoomCatchUp `json:"rooms" jsonschema:"required,description=Rooms with new activity, most recently active first"`
+}
+
+// RoomUnread is a per-room unread count in a sync response
+type RoomUnread struct {
+	RoomID      string `json:"room_id" jsonschema:"required,description=Room with unread messages"`
+	RoomName    string `json:"room_name" jsonschema:"required,description=Name of the room (for display)"`
+	UnreadCount int    `json:"unread_count" jsonschema:"required,description=Number of unread messages in the room"`
+}
+
+// SyncResponse bundles everything a fresh client load needs. Rooms with no
+// unread messages and muted rooms are omitted from the unread section.
+// Direction: server → client
+type SyncResponse struct {
+	User          User             `json:"user" jsonschema:"required,description=The authenticated user"`
+	Rooms         []*Room          `json:"rooms" jsonschema:"required,description=Channel rooms the user is a member of"`
+	DMs           []*Room          `json:"dms" jsonschema:"required,description=DM rooms the user is a member of (sorted by most recent activity)"`
+	CurrentRoom   string           `json:"current_room" jsonschema:"required,description=Room ID to display initially"`
+	Unread        []RoomUnread     `json:"unread" jsonschema:"required,description=Per-room unread counts, most recently active first"`
+	OnlineUserIDs []string         `json:"online_user_ids" jsonschema:"required,description=IDs of users with at least one open connection"`
+	History       *HistoryResponse `json:"history" jsonschema:"required,description=The latest page of the current room's history"`
+}
+
+// GetUnreadResponse is sent by the server in response to GetUnreadRequest.
+// Counts are measured against the user's read positions; muted rooms and
+// rooms with nothing unread are omitted.
+// Direction: server → client
+type GetUnreadResponse struct {
+	Unread map[string]int `json:"unread" jsonschema:"required,description=Unread message count per room ID"`
+	Total  int            `json:"total" jsonschema:"required,description=Sum of the per-room unread counts"`
+}
+
+// PrefsResponse is sent by the server in response to GetPrefsRequest and
+// SetPrefsRequest, carrying the caller's stored display preferences
+// Direction: server → client
+type PrefsResponse struct {
+	Prefs map[string]any `json:"prefs" jsonschema:"required,description=Stored preference values keyed by name; empty when none are saved"`
+}
+
+// GetMessagesResponse is sent by the server in response to
+// GetMessagesRequest. Messages come back in request order; IDs that don't
+// exist or sit in rooms the caller can't access are omitted, and deleted
+// messages come back as placeholders with an empty body and deleted_at set.
+// Direction: server → client
+type GetMessagesResponse struct {
+	Messages []*Message `json:"messages" jsonschema:"required,description=The requested messages, in request order"`
+}
+
+// LatencyUpdate reports the most recently measured round-trip time for the
+// connection, taken from the ping/pong keepalive cycle.
+// Direction: server → client
+type LatencyUpdate struct {
+	LatencyMs int64 `json:"latency_ms" jsonschema:"required,description=Round-trip time of the last ping/pong in milliseconds"`
+}
+
+// ServerStatus is broadcast to every connected client when an operator
+// toggles read-only maintenance mode, so clients can show a banner
+// Direction: server → client (broadcast)
+type ServerStatus struct {
+	ReadOnly bool `json:"read_only" jsonschema:"required,description=Whether the server is currently rejecting write operations"`
 }
 
 // =============================================================================
@@ -375,6 +1233,16 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with user info, rooms, DMs, and current room",
 	},
+	{
+		Type:        "ping",
+		Direction:   ClientToServer,
+		Description: "Application-level connectivity check; echoed back as pong",
+	},
+	{
+		Type:        "pong",
+		Direction:   ServerToClient,
+		Description: "Echo of a ping's nonce",
+	},
 	{
 		Type:        "message",
 		Direction:   ClientToServer,
@@ -395,6 +1263,106 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with paginated message history",
 	},
+	{
+		Type:        "mark_read",
+		Direction:   ClientToServer,
+		Description: "Record the user's read position in a room",
+	},
+	{
+		Type:        "forward_message",
+		Direction:   ClientToServer,
+		Description: "Forward an existing message to another room",
+	},
+	{
+		Type:        "list_reactors",
+		Direction:   ClientToServer,
+		Description: "Page through the full list of users who reacted with an emoji",
+	},
+	{
+		Type:        "star_message",
+		Direction:   ClientToServer,
+		Description: "Privately save a message for later",
+	},
+	{
+		Type:        "unstar_message",
+		Direction:   ClientToServer,
+		Description: "Remove a previously saved star",
+	},
+	{
+		Type:        "list_starred",
+		Direction:   ClientToServer,
+		Description: "Request the user's starred messages",
+	},
+	{
+		Type:        "pin_message",
+		Direction:   ClientToServer,
+		Description: "Pin a message in its room",
+	},
+	{
+		Type:        "set_pin_announcements",
+		Direction:   ClientToServer,
+		Description: "Enable or disable system-message announcements for pins in a room",
+	},
+	{
+		Type:        "favorite_room",
+		Direction:   ClientToServer,
+		Description: "Add or remove a room from the user's sidebar favorites",
+	},
+	{
+		Type:        "reorder_favorites",
+		Direction:   ClientToServer,
+		Description: "Rearrange the user's favorite rooms",
+	},
+	{
+		Type:        "favorites_updated",
+		Direction:   ServerToClient,
+		Description: "Response with the user's favorite rooms after a change",
+	},
+	{
+		Type:        "hide_dm",
+		Direction:   ClientToServer,
+		Description: "Remove a DM conversation from the user's sidebar",
+	},
+	{
+		Type:        "hide_dm",
+		Direction:   ServerToClient,
+		Description: "Response confirming a DM was hidden",
+	},
+	{
+		Type:        "set_dm_label",
+		Direction:   ClientToServer,
+		Description: "Set or clear the user's custom display label for a DM",
+	},
+	{
+		Type:        "set_dm_label",
+		Direction:   ServerToClient,
+		Description: "Response confirming a DM label change",
+	},
+	{
+		Type:        "message_pinned",
+		Direction:   ServerToClient,
+		Description: "Broadcast to room members when a message is pinned",
+	},
+	{
+		Type:        "list_starred",
+		Direction:   ServerToClient,
+		Description: "Response with the user's starred messages, newest star first",
+	},
+	{
+		Type:        "list_reactors",
+		Direction:   ServerToClient,
+		Description: "Response with a page of reactor user IDs",
+	},
+	{
+		Type:        "get_reactions",
+		Direction:   ClientToServer,
+		Description: "Fetch the full reaction breakdown for one message",
+	},
+	{
+		Type:        "get_reactions",
+		Direction:   ServerToClient,
+		Description: "Response with a message's aggregated reactions",
+	},
 	{
 		Type:        "error",
 		Direction:   ServerToClient,
@@ -430,6 +1398,86 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with the DM room (new or existing)",
 	},
+	{
+		Type:        "add_members",
+		Direction:   ClientToServer,
+		Description: "Add several users to a room at once (owners and admins only for private rooms)",
+	},
+	{
+		Type:        "add_members",
+		Direction:   ServerToClient,
+		Description: "Response reporting added, skipped, and unknown user IDs",
+	},
+	{
+		Type:        "approve_join_request",
+		Direction:   ClientToServer,
+		Description: "Approve a pending join request (owners and admins only)",
+	},
+	{
+		Type:        "approve_join_request",
+		Direction:   ServerToClient,
+		Description: "Response confirming the user was added as a member",
+	},
+	{
+		Type:        "deny_join_request",
+		Direction:   ClientToServer,
+		Description: "Deny a pending join request (owners and admins only)",
+	},
+	{
+		Type:        "deny_join_request",
+		Direction:   ServerToClient,
+		Description: "Response confirming the join request was removed",
+	},
+	{
+		Type:        "list_join_requests",
+		Direction:   ClientToServer,
+		Description: "List a room's pending join requests (owners and admins only)",
+	},
+	{
+		Type:        "list_join_requests",
+		Direction:   ServerToClient,
+		Description: "Response with the room's pending join requests",
+	},
+	{
+		Type:        "join_requested",
+		Direction:   ServerToClient,
+		Description: "Sent to a room's owners and admins when a user asks to join a request-to-join room",
+	},
+	{
+		Type:        "report_message",
+		Direction:   ClientToServer,
+		Description: "Flag a message for the room's moderators",
+	},
+	{
+		Type:        "report_message",
+		Direction:   ServerToClient,
+		Description: "Response confirming the report was recorded",
+	},
+	{
+		Type:        "message_reported",
+		Direction:   ServerToClient,
+		Description: "Sent to a room's owners and admins when a member reports a message",
+	},
+	{
+		Type:        "list_reports",
+		Direction:   ClientToServer,
+		Description: "List a room's message reports (owners and admins only)",
+	},
+	{
+		Type:        "list_reports",
+		Direction:   ServerToClient,
+		Description: "Response with the room's message reports",
+	},
+	{
+		Type:        "resolve_report",
+		Direction:   ClientToServer,
+		Description: "Resolve or dismiss a message's open reports (owners and admins only)",
+	},
+	{
+		Type:        "resolve_report",
+		Direction:   ServerToClient,
+		Description: "Response confirming the reports were handled",
+	},
 	{
 		Type:        "list_rooms",
 		Direction:   ClientToServer,
@@ -450,6 +1498,16 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with matching users",
 	},
+	{
+		Type:        "autocomplete",
+		Direction:   ClientToServer,
+		Description: "Request inline completion suggestions for the @/#/: pickers",
+	},
+	{
+		Type:        "autocomplete",
+		Direction:   ServerToClient,
+		Description: "Response with ranked completion suggestions",
+	},
 	{
 		Type:        "leave_room",
 		Direction:   ClientToServer,
@@ -460,6 +1518,11 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response confirming room leave",
 	},
+	{
+		Type:        "room_deleted",
+		Direction:   ServerToClient,
+		Description: "Broadcast to members when a room is deleted",
+	},
 	{
 		Type:        "room_info",
 		Direction:   ClientToServer,
@@ -470,6 +1533,26 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with room details and members",
 	},
+	{
+		Type:        "list_members",
+		Direction:   ClientToServer,
+		Description: "Page through a room's member list with presence flags",
+	},
+	{
+		Type:        "list_members",
+		Direction:   ServerToClient,
+		Description: "Response with one page of members and a cursor for the next",
+	},
+	{
+		Type:        "my_membership",
+		Direction:   ClientToServer,
+		Description: "Ask for the caller's own membership and role in a room",
+	},
+	{
+		Type:        "my_membership",
+		Direction:   ServerToClient,
+		Description: "Response with the caller's membership status and role",
+	},
 	{
 		Type:        "get_profile",
 		Direction:   ClientToServer,
@@ -480,6 +1563,16 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with user profile data",
 	},
+	{
+		Type:        "get_profiles",
+		Direction:   ClientToServer,
+		Description: "Request several users' profiles in one call",
+	},
+	{
+		Type:        "get_profiles",
+		Direction:   ServerToClient,
+		Description: "Response with the found profiles (unknown IDs skipped)",
+	},
 	{
 		Type:        "update_profile",
 		Direction:   ClientToServer,
@@ -490,6 +1583,21 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with updated profile",
 	},
+	{
+		Type:        "set_username",
+		Direction:   ClientToServer,
+		Description: "Change current user's username",
+	},
+	{
+		Type:        "set_username",
+		Direction:   ServerToClient,
+		Description: "Response with the renamed user",
+	},
+	{
+		Type:        "profile_updated",
+		Direction:   ServerToClient,
+		Description: "Broadcast to peers when a user's profile record changes",
+	},
 	{
 		Type:        "edit_message",
 		Direction:   ClientToServer,
@@ -525,6 +1633,66 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Broadcast when a reaction is added or removed",
 	},
+	{
+		Type:        "set_slow_mode",
+		Direction:   ClientToServer,
+		Description: "Set a room's slow-mode interval (owners and admins only)",
+	},
+	{
+		Type:        "set_retention",
+		Direction:   ClientToServer,
+		Description: "Set a room's message retention in days (owners and admins only)",
+	},
+	{
+		Type:        "set_post_policy",
+		Direction:   ClientToServer,
+		Description: "Set who may post in a room (owners and admins only)",
+	},
+	{
+		Type:        "set_room_topic",
+		Direction:   ClientToServer,
+		Description: "Set a room's topic (owners and admins only)",
+	},
+	{
+		Type:        "add_room_filter",
+		Direction:   ClientToServer,
+		Description: "Add a content filter rule to a room (owners and admins only)",
+	},
+	{
+		Type:        "add_room_filter",
+		Direction:   ServerToClient,
+		Description: "Response confirming the filter rule was added",
+	},
+	{
+		Type:        "remove_room_filter",
+		Direction:   ClientToServer,
+		Description: "Remove a content filter rule from a room (owners and admins only)",
+	},
+	{
+		Type:        "remove_room_filter",
+		Direction:   ServerToClient,
+		Description: "Response confirming the filter rule was removed",
+	},
+	{
+		Type:        "list_room_filters",
+		Direction:   ClientToServer,
+		Description: "List a room's content filter rules (owners and admins only)",
+	},
+	{
+		Type:        "list_room_filters",
+		Direction:   ServerToClient,
+		Description: "Response with the room's content filter rules",
+	},
+	{
+		Type:        "room_settings_updated",
+		Direction:   ServerToClient,
+		Description: "Broadcast when a room's settings change",
+	},
+	{
+		Type:        "member_changed",
+		Direction:   ServerToClient,
+		Description: "Broadcast when a user is added to a room",
+	},
 	{
 		Type:        "search",
 		Direction:   ClientToServer,
@@ -535,6 +1703,26 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with matching messages and snippets",
 	},
+	{
+		Type:        "search_stream",
+		Direction:   ClientToServer,
+		Description: "Start a streaming search that delivers matches in batches",
+	},
+	{
+		Type:        "search_stream_cancel",
+		Direction:   ClientToServer,
+		Description: "Stop the connection's in-flight streaming search",
+	},
+	{
+		Type:        "search_stream_batch",
+		Direction:   ServerToClient,
+		Description: "One batch of streaming search results",
+	},
+	{
+		Type:        "search_stream_done",
+		Direction:   ServerToClient,
+		Description: "Marks the end of a streaming search",
+	},
 	{
 		Type:        "get_message_context",
 		Direction:   ClientToServer,
@@ -545,4 +1733,74 @@ var MessageTypes = []MessageMeta{
 		Direction:   ServerToClient,
 		Description: "Response with message and room ID",
 	},
+	{
+		Type:        "catch_up",
+		Direction:   ClientToServer,
+		Description: "Request a summary of activity since a given time",
+	},
+	{
+		Type:        "catch_up",
+		Direction:   ServerToClient,
+		Description: "Response with per-room unread counts and message previews",
+	},
+	{
+		Type:        "sync",
+		Direction:   ClientToServer,
+		Description: "Request everything a fresh client load needs in one round-trip",
+	},
+	{
+		Type:        "sync",
+		Direction:   ServerToClient,
+		Description: "Response with the user, rooms, DMs, unread counts, online users, and current-room history",
+	},
+	{
+		Type:        "get_unread",
+		Direction:   ClientToServer,
+		Description: "Refresh unread counts without a full re-sync",
+	},
+	{
+		Type:        "get_unread",
+		Direction:   ServerToClient,
+		Description: "Response with per-room unread counts and their total",
+	},
+	{
+		Type:        "get_prefs",
+		Direction:   ClientToServer,
+		Description: "Fetch the user's stored display preferences",
+	},
+	{
+		Type:        "get_prefs",
+		Direction:   ServerToClient,
+		Description: "Response with the stored display preferences",
+	},
+	{
+		Type:        "set_prefs",
+		Direction:   ClientToServer,
+		Description: "Replace the user's stored display preferences",
+	},
+	{
+		Type:        "set_prefs",
+		Direction:   ServerToClient,
+		Description: "Response confirming the stored display preferences",
+	},
+	{
+		Type:        "get_messages",
+		Direction:   ClientToServer,
+		Description: "Fetch a specific set of messages by ID",
+	},
+	{
+		Type:        "get_messages",
+		Direction:   ServerToClient,
+		Description: "Response with the requested messages in request order",
+	},
+	{
+		Type:        "latency_update",
+		Direction:   ServerToClient,
+		Description: "Periodic report of the connection's measured round-trip time",
+	},
+	{
+		Type:        "server_status",
+		Direction:   ServerToClient,
+		Description: "Broadcast when an operator toggles read-only maintenance mode",
+	},
 }
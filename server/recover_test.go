@@ -0,0 +1,76 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// wsPair returns a connected client/server websocket pair over a real
+// upgrade, so server-side connection behavior can be exercised directly
+func wsPair(t *testing.T) (clientConn, serverConn *websocket.Conn) {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	serverConns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	clientConn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { _ = clientConn.Close() })
+
+	return clientConn, <-serverConns
+}
+
+// TestRecoverPump_ClosesConnection tests that a panic in a pump goroutine
+// is recovered and tears down only that connection instead of crashing the
+// process
+func TestRecoverPump_ClosesConnection(t *testing.T) {
+	clientConn, serverConn := wsPair(t)
+
+	c := &Client{
+		conn:   serverConn,
+		send:   make(chan []byte, 8),
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		user:   &models.User{ID: "usr_panic0000001"},
+	}
+
+	// Simulate a handler panicking on the read goroutine, the way must()
+	// does on an unexpected error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer c.recoverPump("readPump")
+		panic("handler blew up")
+	}()
+
+	// The goroutine ends cleanly instead of crashing the process
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the panicking goroutine to finish")
+	}
+
+	// The connection was closed, so the peer's read fails
+	_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := clientConn.ReadMessage(); err == nil {
+		t.Error("Expected the connection to be closed after the panic")
+	}
+}
@@ -0,0 +1,441 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// postRegister submits the registration form without following redirects
+func postRegister(t *testing.T, ts *testServer, form url.Values) {
+	t.Helper()
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.PostForm(ts.server.URL+"/register", form)
+	if err != nil {
+		t.Fatalf("Failed to post registration form: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+// userExists reports whether a user with the given username exists
+func userExists(t *testing.T, ts *testServer, username string) bool {
+	t.Helper()
+	_, err := models.UserByUsername(context.Background(), ts.chatServer.db, username)
+	return err == nil
+}
+
+// TestRegistrationClosed tests that closed mode rejects all registrations
+func TestRegistrationClosed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+	ts.chatServer.registrationMode = RegistrationClosed
+
+	form := url.Values{}
+	form.Set("username", "closeduser")
+	form.Set("password", "password123")
+	postRegister(t, ts, form)
+
+	if userExists(t, ts, "closeduser") {
+		t.Error("Expected registration to be rejected in closed mode, but user was created")
+	}
+}
+
+// TestRegistrationInviteMode tests that invite mode requires a valid unused
+// code and consumes it on success
+func TestRegistrationInviteMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+	ts.chatServer.registrationMode = RegistrationInvite
+
+	// Registration without a code is rejected
+	form := url.Values{}
+	form.Set("username", "inviteuser")
+	form.Set("password", "password123")
+	postRegister(t, ts, form)
+	if userExists(t, ts, "inviteuser") {
+		t.Error("Expected registration without an invite code to be rejected")
+	}
+
+	// Registration with an unknown code is rejected
+	form.Set("invite_code", "inv_doesnotexist")
+	postRegister(t, ts, form)
+	if userExists(t, ts, "inviteuser") {
+		t.Error("Expected registration with an unknown invite code to be rejected")
+	}
+
+	// Registration with a valid code succeeds and consumes the code
+	invite := &models.SignupInvite{
+		Code:      models.GenerateSignupInviteCode(),
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := invite.Insert(context.Background(), ts.chatServer.db); err != nil {
+		t.Fatalf("Failed to insert invite: %v", err)
+	}
+
+	form.Set("invite_code", invite.Code)
+	postRegister(t, ts, form)
+	if !userExists(t, ts, "inviteuser") {
+		t.Fatal("Expected registration with a valid invite code to succeed")
+	}
+
+	used, err := models.SignupInviteByCode(context.Background(), ts.chatServer.db, invite.Code)
+	if err != nil {
+		t.Fatalf("Failed to reload invite: %v", err)
+	}
+	if !used.UsedAt.Valid || used.UsedAt.String == "" {
+		t.Error("Expected invite code to be marked used after registration")
+	}
+
+	// The consumed code cannot be reused
+	form.Set("username", "seconduser")
+	postRegister(t, ts, form)
+	if userExists(t, ts, "seconduser") {
+		t.Error("Expected registration with a consumed invite code to be rejected")
+	}
+}
+
+// postAuthJSON submits an auth form with an Accept: application/json header
+// and returns the response status and redirect location (if any)
+func postAuthJSON(t *testing.T, ts *testServer, path string, form url.Values) int {
+	t.Helper()
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequest(http.MethodPost, ts.server.URL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to post auth form: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return resp.StatusCode
+}
+
+// TestAuthFailureResponses tests that each auth failure mode yields a
+// distinguishable status code for API clients, and that a missing user no
+// longer crashes the login handler
+func TestAuthFailureResponses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	// Login for a nonexistent user must not panic and must return 401
+	form := url.Values{}
+	form.Set("username", "ghost")
+	form.Set("password", "password123")
+	if status := postAuthJSON(t, ts, "/login", form); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for unknown user, got %d", status)
+	}
+
+	// Missing fields return 400
+	empty := url.Values{}
+	empty.Set("username", "someone")
+	if status := postAuthJSON(t, ts, "/login", empty); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for missing password, got %d", status)
+	}
+	if status := postAuthJSON(t, ts, "/register", empty); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for missing password, got %d", status)
+	}
+
+	// Register a user, then check wrong-password and duplicate-user failures
+	form = url.Values{}
+	form.Set("username", "feedbackuser")
+	form.Set("password", "password123")
+	postRegister(t, ts, form)
+	if !userExists(t, ts, "feedbackuser") {
+		t.Fatal("Failed to register test user")
+	}
+
+	if status := postAuthJSON(t, ts, "/register", form); status != http.StatusConflict {
+		t.Errorf("Expected 409 for duplicate registration, got %d", status)
+	}
+
+	form.Set("password", "wrongpassword")
+	if status := postAuthJSON(t, ts, "/login", form); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for wrong password, got %d", status)
+	}
+
+	// Browser clients get redirected with an error message they can render
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.PostForm(ts.server.URL+"/register", form)
+	if err != nil {
+		t.Fatalf("Failed to post registration form: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected 302 for browser client, got %d", resp.StatusCode)
+	}
+	location := resp.Header.Get("Location")
+	if !strings.Contains(location, "error=") {
+		t.Errorf("Expected redirect location to carry an error message, got %q", location)
+	}
+}
+
+// TestRegistrationUsernameValidation tests that usernames are validated and
+// normalized on registration
+func TestRegistrationUsernameValidation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	// Invalid characters are rejected
+	for _, username := range []string{"bad user", "bad!user", "name@domain", "x"} {
+		form := url.Values{}
+		form.Set("username", username)
+		form.Set("password", "password123")
+		postRegister(t, ts, form)
+		if userExists(t, ts, username) {
+			t.Errorf("Expected registration with username %q to be rejected", username)
+		}
+	}
+
+	// A valid username is accepted, with surrounding whitespace trimmed
+	form := url.Values{}
+	form.Set("username", "  good_user-1  ")
+	form.Set("password", "password123")
+	postRegister(t, ts, form)
+	if !userExists(t, ts, "good_user-1") {
+		t.Error("Expected registration with a valid username to succeed and be trimmed")
+	}
+
+	// A duplicate differing only by case is rejected
+	form.Set("username", "GOOD_User-1")
+	postRegister(t, ts, form)
+	if userExists(t, ts, "GOOD_User-1") {
+		t.Error("Expected registration differing only by case to be rejected")
+	}
+}
+
+// postJSON posts a JSON body to the given path and returns the response; the
+// caller is responsible for closing the body
+func postJSON(t *testing.T, ts *testServer, path string, body any) *http.Response {
+	t.Helper()
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("Failed to marshal request body: %v", err)
+	}
+	resp, err := http.Post(ts.server.URL+path, "application/json", bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("Failed to post JSON: %v", err)
+	}
+	return resp
+}
+
+// TestAuthAPI tests the JSON register and login endpoints
+func TestAuthAPI(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	// Registration returns 201 and the new user
+	resp := postJSON(t, ts, "/api/v1/auth/register", map[string]string{
+		"username": "apiuser",
+		"password": "password123",
+	})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 from register, got %d", resp.StatusCode)
+	}
+	var registered struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+		t.Fatalf("Failed to decode register response: %v", err)
+	}
+	if registered.Username != "apiuser" || registered.ID == "" {
+		t.Errorf("Unexpected register response: %+v", registered)
+	}
+
+	// Duplicate registration returns 409
+	resp = postJSON(t, ts, "/api/v1/auth/register", map[string]string{
+		"username": "apiuser",
+		"password": "password123",
+	})
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for duplicate registration, got %d", resp.StatusCode)
+	}
+
+	// Login returns 200 with a session cookie and the user
+	resp = postJSON(t, ts, "/api/v1/auth/login", map[string]string{
+		"username": "apiuser",
+		"password": "password123",
+	})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 from login, got %d", resp.StatusCode)
+	}
+	var loggedIn struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&loggedIn); err != nil {
+		t.Fatalf("Failed to decode login response: %v", err)
+	}
+	if loggedIn.ID != registered.ID {
+		t.Errorf("Expected login to return user %q, got %q", registered.ID, loggedIn.ID)
+	}
+	var sessionCookie *http.Cookie
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == ts.chatServer.sessionKey {
+			sessionCookie = cookie
+		}
+	}
+	if sessionCookie == nil || sessionCookie.Value == "" {
+		t.Fatal("Expected login to set a session cookie")
+	}
+
+	// Wrong credentials return 401
+	resp = postJSON(t, ts, "/api/v1/auth/login", map[string]string{
+		"username": "apiuser",
+		"password": "wrongpassword",
+	})
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for wrong credentials, got %d", resp.StatusCode)
+	}
+
+	// A garbage body returns 400
+	garbage, err := http.Post(ts.server.URL+"/api/v1/auth/login", "application/json", strings.NewReader("not json"))
+	if err != nil {
+		t.Fatalf("Failed to post garbage body: %v", err)
+	}
+	_ = garbage.Body.Close()
+	if garbage.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid JSON body, got %d", garbage.StatusCode)
+	}
+}
+
+// TestRegistrationOpenDefault tests that the default (open) mode accepts
+// registrations with no invite code, as before
+func TestRegistrationOpenDefault(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	form := url.Values{}
+	form.Set("username", "openuser")
+	form.Set("password", "password123")
+	postRegister(t, ts, form)
+
+	if !userExists(t, ts, "openuser") {
+		t.Error("Expected registration to succeed in open mode")
+	}
+}
+
+// TestRegistrationWelcomeRooms tests that new users are auto-joined to
+// every configured welcome room while last_room stays the default room
+func TestRegistrationWelcomeRooms(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	ctx := context.Background()
+	for _, name := range []string{"welcome-one", "welcome-two"} {
+		room := models.Room{
+			ID:        models.GenerateRoomID(),
+			Name:      name,
+			RoomType:  "channel",
+			IsPrivate: models.FALSE,
+			IsDefault: models.FALSE,
+			CreatedAt: time.Now().Format(time.RFC3339),
+		}
+		if err := room.Insert(ctx, ts.chatServer.db); err != nil {
+			t.Fatalf("Failed to create welcome room %s: %v", name, err)
+		}
+	}
+	// An unknown name is skipped without blocking registration
+	ts.chatServer.welcomeRooms = []string{"welcome-one", "welcome-two", "no-such-room"}
+
+	form := url.Values{}
+	form.Set("username", "welcomeuser")
+	form.Set("password", "password123")
+	postRegister(t, ts, form)
+
+	user, err := models.UserByUsername(ctx, ts.chatServer.db, "welcomeuser")
+	if err != nil {
+		t.Fatalf("Expected registration to succeed: %v", err)
+	}
+
+	defaultRoom, err := models.GetDefaultRoom(ctx, ts.chatServer.db)
+	if err != nil {
+		t.Fatalf("Failed to get default room: %v", err)
+	}
+	if user.LastRoom != defaultRoom.ID {
+		t.Errorf("Expected last_room %s (the default room), got %s", defaultRoom.ID, user.LastRoom)
+	}
+
+	for _, name := range []string{"welcome-one", "welcome-two"} {
+		room, err := models.RoomByName(ctx, ts.chatServer.db, name)
+		if err != nil {
+			t.Fatalf("Failed to look up %s: %v", name, err)
+		}
+		isMember, err := db.IsRoomMember(ctx, ts.chatServer.db, user.ID, room.ID)
+		if err != nil {
+			t.Fatalf("Failed to check membership of %s: %v", name, err)
+		}
+		if !isMember {
+			t.Errorf("Expected new user to be a member of %s", name)
+		}
+	}
+
+	// Running the join again is idempotent
+	ts.chatServer.joinWelcomeRooms(ctx, user.ID, defaultRoom.ID)
+	room, err := models.RoomByName(ctx, ts.chatServer.db, "welcome-one")
+	if err != nil {
+		t.Fatalf("Failed to look up welcome-one: %v", err)
+	}
+	isMember, err := db.IsRoomMember(ctx, ts.chatServer.db, user.ID, room.ID)
+	if err != nil {
+		t.Fatalf("Failed to recheck membership: %v", err)
+	}
+	if !isMember {
+		t.Error("Expected membership to survive a repeated welcome join")
+	}
+}
@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/middleware"
+)
+
+// defaultArchiveBatch is how many messages each internal fetch pulls while
+// streaming an archive; it bounds memory no matter how big the room is
+const defaultArchiveBatch = 500
+
+// ArchiveRoom streams a room's full message history as newline-delimited
+// JSON in ascending seq order. The history is walked in fixed-size keyset
+// batches, so memory stays constant however large the room is. A since_seq
+// query parameter skips messages already archived, for incremental runs.
+// GET /api/v1/rooms/{id}/archive?since_seq=N
+func (a *API) ArchiveRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "GET required")
+		return
+	}
+
+	roomID := extractRoomIDWithSuffix(r.URL.Path, "/archive")
+	if roomID == "" {
+		a.writeError(w, http.StatusBadRequest, "invalid_request", "Room ID is required")
+		return
+	}
+
+	var sinceSeq int64
+	if val := r.URL.Query().Get("since_seq"); val != "" {
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || n < 0 {
+			a.writeError(w, http.StatusBadRequest, "invalid_request", "since_seq must be a non-negative integer")
+			return
+		}
+		sinceSeq = n
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	ctx := r.Context()
+
+	isMember, err := db.IsRoomMember(ctx, a.db, userID, roomID)
+	if err != nil {
+		a.logger.Error("failed to check room membership", "error", err)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to archive room")
+		return
+	}
+	if !isMember {
+		a.writeError(w, http.StatusForbidden, "forbidden", "You are not a member of this room")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	batch := a.archiveBatch
+	if batch <= 0 {
+		batch = defaultArchiveBatch
+	}
+	enc := json.NewEncoder(w)
+	afterSeq := sinceSeq
+	for {
+		messages, err := db.GetRoomMessagesAscending(ctx, a.db, roomID, afterSeq, batch)
+		if err != nil {
+			// The status line is already out; all we can do is stop
+			a.logger.Error("failed to fetch archive batch", "error", err, "room", roomID, "after_seq", afterSeq)
+			return
+		}
+		for _, m := range messages {
+			if err := enc.Encode(m); err != nil {
+				a.logger.Debug("archive client went away", "error", err, "room", roomID)
+				return
+			}
+			afterSeq = m.Seq
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if len(messages) < batch {
+			return
+		}
+	}
+}
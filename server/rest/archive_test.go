@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/llimllib/hatchat/server/db"
+)
+
+// archiveLines runs an archive request and decodes each NDJSON line
+func archiveLines(t *testing.T, api *API, userID, path string) (*httptest.ResponseRecorder, []*db.RoomMessage) {
+	t.Helper()
+	req := makeRequest(t, http.MethodGet, path, nil, userID)
+	rr := httptest.NewRecorder()
+
+	api.ArchiveRoom(rr, req)
+
+	if rr.Code != http.StatusOK {
+		return rr, nil
+	}
+	var messages []*db.RoomMessage
+	scanner := bufio.NewScanner(bytes.NewReader(rr.Body.Bytes()))
+	for scanner.Scan() {
+		var m db.RoomMessage
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatalf("failed to decode archive line %q: %v", scanner.Text(), err)
+		}
+		messages = append(messages, &m)
+	}
+	return rr, messages
+}
+
+func TestArchiveRoom(t *testing.T) {
+	testDB := setupTestDB(t)
+	api := NewAPI(testDB, nil)
+	// A batch smaller than the room proves the stream is fetched in
+	// bounded pieces rather than one unbounded query
+	api.archiveBatch = 2
+
+	user := createTestUser(t, testDB, "alice")
+	room := createTestRoom(t, testDB, "archive-me", false)
+	addUserToRoom(t, testDB, user.ID, room.ID)
+
+	for i := 0; i < 7; i++ {
+		createTestMessage(t, testDB, room.ID, user.ID, fmt.Sprintf("message %d", i))
+	}
+
+	rr, messages := archiveLines(t, api, user.ID, "/api/v1/rooms/"+room.ID+"/archive")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected application/x-ndjson, got %q", ct)
+	}
+
+	if len(messages) != 7 {
+		t.Fatalf("expected 7 messages, got %d", len(messages))
+	}
+	for i, m := range messages {
+		if m.Body != fmt.Sprintf("message %d", i) {
+			t.Errorf("expected message %d in order, got %q", i, m.Body)
+		}
+		if i > 0 && m.Seq <= messages[i-1].Seq {
+			t.Errorf("expected ascending seq, got %d after %d", m.Seq, messages[i-1].Seq)
+		}
+	}
+}
+
+func TestArchiveRoomSinceSeq(t *testing.T) {
+	testDB := setupTestDB(t)
+	api := NewAPI(testDB, nil)
+
+	user := createTestUser(t, testDB, "alice")
+	room := createTestRoom(t, testDB, "incremental", false)
+	addUserToRoom(t, testDB, user.ID, room.ID)
+
+	for i := 0; i < 5; i++ {
+		createTestMessage(t, testDB, room.ID, user.ID, fmt.Sprintf("message %d", i))
+	}
+
+	// Archive everything once to learn the midpoint seq
+	_, all := archiveLines(t, api, user.ID, "/api/v1/rooms/"+room.ID+"/archive")
+	if len(all) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(all))
+	}
+	midpoint := all[2].Seq
+
+	rr, newer := archiveLines(t, api, user.ID,
+		fmt.Sprintf("/api/v1/rooms/%s/archive?since_seq=%d", room.ID, midpoint))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(newer) != 2 {
+		t.Fatalf("expected 2 messages after seq %d, got %d", midpoint, len(newer))
+	}
+	for _, m := range newer {
+		if m.Seq <= midpoint {
+			t.Errorf("expected only messages newer than seq %d, got %d", midpoint, m.Seq)
+		}
+	}
+
+	// An invalid since_seq is rejected before any streaming starts
+	req := makeRequest(t, http.MethodGet, "/api/v1/rooms/"+room.ID+"/archive?since_seq=banana", nil, user.ID)
+	rr = httptest.NewRecorder()
+	api.ArchiveRoom(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a bad since_seq, got %d", rr.Code)
+	}
+}
+
+func TestArchiveRoomNotMember(t *testing.T) {
+	testDB := setupTestDB(t)
+	api := NewAPI(testDB, nil)
+
+	member := createTestUser(t, testDB, "alice")
+	outsider := createTestUser(t, testDB, "bob")
+	room := createTestRoom(t, testDB, "private-archive", false)
+	addUserToRoom(t, testDB, member.ID, room.ID)
+	createTestMessage(t, testDB, room.ID, member.ID, "secret")
+
+	req := makeRequest(t, http.MethodGet, "/api/v1/rooms/"+room.ID+"/archive", nil, outsider.ID)
+	rr := httptest.NewRecorder()
+
+	api.ArchiveRoom(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a non-member, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/llimllib/hatchat/server/api"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// ReactionRequest is the body for adding or removing a reaction
+type ReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// Helper to extract the message ID from paths like
+// /api/v1/messages/{id}/reactions
+func extractMessageIDWithSuffix(path string, suffix string) string {
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), suffix)
+	parts := strings.Split(path, "/")
+	if len(parts) < 5 {
+		return ""
+	}
+	// Path is /api/v1/messages/{id}
+	return parts[len(parts)-1]
+}
+
+// ReactionsHandler adds or removes a reaction on a message.
+// POST /api/v1/messages/{id}/reactions adds, DELETE removes.
+//
+// Together with bearer-token authentication this is how bots and incoming
+// hooks react to messages (e.g. a CI bot adding a checkmark). Room
+// membership is enforced exactly as for interactive users, and the
+// reaction_updated broadcast carries the bot's user ID and username.
+func (a *API) ReactionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		a.writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "POST or DELETE required")
+		return
+	}
+
+	messageID := extractMessageIDWithSuffix(r.URL.Path, "/reactions")
+	if messageID == "" {
+		a.writeError(w, http.StatusBadRequest, "invalid_request", "Message ID is required")
+		return
+	}
+
+	var body ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Emoji == "" {
+		a.writeError(w, http.StatusBadRequest, "invalid_request", "Emoji is required")
+		return
+	}
+
+	user, err := a.getUser(r)
+	if err != nil {
+		a.logger.Error("failed to get user", "error", err)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get user")
+		return
+	}
+
+	var res *api.ReactionResponse
+	if r.Method == http.MethodPost {
+		raw, _ := json.Marshal(protocol.AddReactionRequest{MessageID: messageID, Emoji: body.Emoji})
+		res, err = a.chat.AddReaction(user, raw)
+	} else {
+		raw, _ := json.Marshal(protocol.RemoveReactionRequest{MessageID: messageID, Emoji: body.Emoji})
+		res, err = a.chat.RemoveReaction(user, raw)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, api.ErrNotMember):
+			a.writeError(w, http.StatusForbidden, "forbidden", "Not a member of this room")
+		case strings.Contains(err.Error(), "not found"):
+			a.writeError(w, http.StatusNotFound, "not_found", "Message not found")
+		default:
+			a.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		}
+		return
+	}
+
+	if a.Broadcast != nil {
+		a.Broadcast(res.RoomID, res.Message)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// createTestMessage inserts a message into a room
+func createTestMessage(t *testing.T, testDB *db.DB, roomID, userID, body string) *models.Message {
+	t.Helper()
+	now := time.Now().Format(time.RFC3339Nano)
+	message := &models.Message{
+		ID:         models.GenerateMessageID(),
+		RoomID:     roomID,
+		UserID:     userID,
+		Body:       body,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+	if err := message.Insert(context.Background(), testDB); err != nil {
+		t.Fatalf("failed to create message: %v", err)
+	}
+	return message
+}
+
+// countReactions returns the number of reactions a user has on a message
+func countReactions(t *testing.T, testDB *db.DB, messageID, userID string) int {
+	t.Helper()
+	var n int
+	row := testDB.QueryRowContext(context.Background(),
+		"SELECT count(*) FROM reactions WHERE message_id = ? AND user_id = ?", messageID, userID)
+	if err := row.Scan(&n); err != nil {
+		t.Fatalf("failed to count reactions: %v", err)
+	}
+	return n
+}
+
+// TestBotAddReaction tests that a bot can react in a room it belongs to and
+// that the broadcast carries the bot's identity
+func TestBotAddReaction(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+
+	var broadcastRoom string
+	var broadcastMsg []byte
+	api.Broadcast = func(roomID string, message []byte) {
+		broadcastRoom = roomID
+		broadcastMsg = message
+	}
+
+	author := createTestUser(t, testDB, "author")
+	bot := createTestUser(t, testDB, "ci-bot")
+	room := createTestRoom(t, testDB, "builds", false)
+	addUserToRoom(t, testDB, author.ID, room.ID)
+	addUserToRoom(t, testDB, bot.ID, room.ID)
+	message := createTestMessage(t, testDB, room.ID, author.ID, "build #42 passed")
+
+	req := makeRequest(t, http.MethodPost, "/api/v1/messages/"+message.ID+"/reactions",
+		ReactionRequest{Emoji: "✅"}, bot.ID)
+	rr := httptest.NewRecorder()
+	api.ReactionsHandler(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if countReactions(t, testDB, message.ID, bot.ID) != 1 {
+		t.Error("expected the bot's reaction to be recorded")
+	}
+
+	if broadcastRoom != room.ID {
+		t.Errorf("expected broadcast to room %s, got %s", room.ID, broadcastRoom)
+	}
+	var envelope struct {
+		Type string                   `json:"type"`
+		Data protocol.ReactionUpdated `json:"data"`
+	}
+	if err := json.Unmarshal(broadcastMsg, &envelope); err != nil {
+		t.Fatalf("failed to unmarshal broadcast: %v", err)
+	}
+	if envelope.Type != "reaction_updated" {
+		t.Errorf("expected type 'reaction_updated', got '%s'", envelope.Type)
+	}
+	if envelope.Data.UserID != bot.ID || envelope.Data.Username != "ci-bot" {
+		t.Errorf("expected broadcast from %s/ci-bot, got %s/%s",
+			bot.ID, envelope.Data.UserID, envelope.Data.Username)
+	}
+}
+
+// TestBotRemoveReaction tests that a bot can remove its own reaction
+func TestBotRemoveReaction(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+
+	bot := createTestUser(t, testDB, "ci-bot")
+	room := createTestRoom(t, testDB, "builds", false)
+	addUserToRoom(t, testDB, bot.ID, room.ID)
+	message := createTestMessage(t, testDB, room.ID, bot.ID, "deploying")
+
+	addReq := makeRequest(t, http.MethodPost, "/api/v1/messages/"+message.ID+"/reactions",
+		ReactionRequest{Emoji: "🚀"}, bot.ID)
+	api.ReactionsHandler(httptest.NewRecorder(), addReq)
+
+	delReq := makeRequest(t, http.MethodDelete, "/api/v1/messages/"+message.ID+"/reactions",
+		ReactionRequest{Emoji: "🚀"}, bot.ID)
+	rr := httptest.NewRecorder()
+	api.ReactionsHandler(rr, delReq)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if countReactions(t, testDB, message.ID, bot.ID) != 0 {
+		t.Error("expected the bot's reaction to be removed")
+	}
+}
+
+// TestBotReactionRejectedWhenNotMember tests that a bot cannot react in a
+// room it doesn't belong to
+func TestBotReactionRejectedWhenNotMember(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+
+	author := createTestUser(t, testDB, "author")
+	bot := createTestUser(t, testDB, "outsider-bot")
+	room := createTestRoom(t, testDB, "private-builds", true)
+	addUserToRoom(t, testDB, author.ID, room.ID)
+	message := createTestMessage(t, testDB, room.ID, author.ID, "secret build")
+
+	req := makeRequest(t, http.MethodPost, "/api/v1/messages/"+message.ID+"/reactions",
+		ReactionRequest{Emoji: "✅"}, bot.ID)
+	rr := httptest.NewRecorder()
+	api.ReactionsHandler(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if countReactions(t, testDB, message.ID, bot.ID) != 0 {
+		t.Error("expected no reaction to be recorded")
+	}
+}
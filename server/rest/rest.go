@@ -6,30 +6,77 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/llimllib/hatchat/server/api"
 	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/middleware"
 	"github.com/llimllib/hatchat/server/models"
+	"github.com/llimllib/hatchat/server/protocol"
 )
 
 // API provides REST API handlers
 type API struct {
 	db     *db.DB
 	logger *slog.Logger
+
+	// chat reuses the websocket handler logic for REST endpoints that
+	// mirror websocket messages (reactions)
+	chat *api.Api
+
+	// Broadcast forwards room broadcasts to the websocket hub when set, so
+	// actions taken over REST reach connected clients
+	Broadcast func(roomID string, message []byte)
+
+	// NotifyUsers delivers a message to every connection of a set of users
+	// when set, for events like room deletion that must reach members who
+	// aren't subscribed to the room anymore
+	NotifyUsers func(userIDs map[string]bool, message []byte)
+
+	// uploadDir is the directory uploaded files are stored in; served
+	// back to clients under /uploads/
+	uploadDir string
+
+	// maximum dimensions for generated image thumbnails
+	thumbMaxWidth  int
+	thumbMaxHeight int
+
+	// uploadTypes is the allowlist of content types uploads may have
+	uploadTypes map[string]bool
+
+	// maxUploadBytes caps the size of a single uploaded file; the server
+	// plumbs the configured MAX_UPLOAD_BYTES in through SetMaxUploadBytes
+	// so the handler's own reader limit matches the route's body limit
+	maxUploadBytes int64
+
+	// archiveBatch is how many messages each archive-stream fetch pulls;
+	// zero means the default
+	archiveBatch int
 }
 
 // NewAPI creates a new REST API handler
 func NewAPI(db *db.DB, logger *slog.Logger) *API {
 	return &API{
-		db:     db,
-		logger: logger,
+		db:             db,
+		logger:         logger,
+		chat:           api.NewApi(db, logger),
+		uploadDir:      "uploads",
+		thumbMaxWidth:  defaultThumbMaxWidth,
+		thumbMaxHeight: defaultThumbMaxHeight,
+		uploadTypes:    uploadTypesFromEnv(),
+		maxUploadBytes: middleware.DefaultMaxUploadBytes,
 	}
 }
 
+// SetMaxUploadBytes overrides the default cap on a single uploaded file
+func (a *API) SetMaxUploadBytes(n int64) {
+	a.maxUploadBytes = n
+}
+
 // Response types for REST API
 
 // UserResponse represents a user in API responses (excludes sensitive fields)
@@ -233,12 +280,14 @@ func (a *API) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	}
 
 	room := &models.Room{
-		ID:        models.GenerateRoomID(),
-		Name:      name,
-		RoomType:  "channel",
-		IsPrivate: isPrivate,
-		IsDefault: models.FALSE,
-		CreatedAt: time.Now().Format(time.RFC3339),
+		ID:           models.GenerateRoomID(),
+		Name:         name,
+		RoomType:     "channel",
+		IsPrivate:    isPrivate,
+		IsDefault:    models.FALSE,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+		PostPolicy:   db.PostPolicyAll,
+		AnnouncePins: models.TRUE,
 	}
 
 	if err := room.Insert(ctx, a.db); err != nil {
@@ -251,6 +300,7 @@ func (a *API) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	membership := &models.RoomsMember{
 		RoomID: room.ID,
 		UserID: userID,
+		Role:   db.RoleOwner,
 	}
 	if err := membership.Insert(ctx, a.db); err != nil {
 		a.logger.Error("failed to add creator to room", "error", err)
@@ -288,7 +338,7 @@ func (a *API) GetRoom(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Check if the room exists
-	info, err := db.GetRoomInfo(ctx, a.db, roomID)
+	info, err := db.GetRoomInfo(ctx, a.db, roomID, 0)
 	if err != nil {
 		a.logger.Debug("room not found", "room_id", roomID, "error", err)
 		a.writeError(w, http.StatusNotFound, "not_found", "Room not found")
@@ -410,21 +460,18 @@ func (a *API) LeaveRoom(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r.Context())
 	ctx := r.Context()
 
-	// Check if this is the default room
-	room, err := models.RoomByID(ctx, a.db, roomID)
-	if err != nil {
+	if _, err := models.RoomByID(ctx, a.db, roomID); err != nil {
 		a.logger.Debug("room not found", "room_id", roomID, "error", err)
 		a.writeError(w, http.StatusNotFound, "not_found", "Room not found")
 		return
 	}
 
-	if room.IsDefault != 0 {
+	// Leave the room; the db layer owns the default-room guard
+	_, err := db.LeaveRoom(ctx, a.db, userID, roomID)
+	if errors.Is(err, db.ErrDefaultRoom) {
 		a.writeError(w, http.StatusBadRequest, "invalid_request", "Cannot leave the default room")
 		return
 	}
-
-	// Leave the room
-	_, err = db.LeaveRoom(ctx, a.db, userID, roomID)
 	if err != nil {
 		a.logger.Error("failed to leave room", "error", err)
 		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to leave room")
@@ -434,6 +481,59 @@ func (a *API) LeaveRoom(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// DeleteRoom deletes a room entirely; only the room's owner may do this
+// DELETE /api/v1/rooms/{id}
+func (a *API) DeleteRoom(w http.ResponseWriter, r *http.Request) {
+	roomID := extractRoomID(r.URL.Path)
+	if roomID == "" {
+		a.writeError(w, http.StatusBadRequest, "invalid_request", "Room ID is required")
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	ctx := r.Context()
+
+	role, err := db.GetMemberRole(ctx, a.db, userID, roomID)
+	if err != nil {
+		a.logger.Error("failed to check member role", "error", err)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to delete room")
+		return
+	}
+	if role != db.RoleOwner {
+		a.writeError(w, http.StatusForbidden, "forbidden", "Only the room owner can delete a room")
+		return
+	}
+
+	// Delete the room; the db layer owns the default-room guard
+	members, err := db.DeleteRoom(ctx, a.db, roomID)
+	if errors.Is(err, db.ErrDefaultRoom) {
+		a.writeError(w, http.StatusBadRequest, "invalid_request", "Cannot delete the default room")
+		return
+	}
+	if err != nil {
+		a.logger.Error("failed to delete room", "error", err)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to delete room")
+		return
+	}
+
+	// Tell every former member the room is gone, whether or not their
+	// connections were subscribed to it; clients viewing the room fall
+	// back to the default room
+	if a.NotifyUsers != nil {
+		msg, err := json.Marshal(&api.Envelope{
+			Type: "room_deleted",
+			Data: protocol.RoomDeleted{RoomID: roomID},
+		})
+		if err != nil {
+			a.logger.Error("failed to marshal room_deleted", "error", err)
+		} else {
+			a.NotifyUsers(members, msg)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // Helper to extract room ID from paths like /api/v1/rooms/{id}
 func extractRoomID(path string) string {
 	parts := strings.Split(strings.TrimSuffix(path, "/"), "/")
@@ -479,7 +579,17 @@ func (a *API) RoomsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// /api/v1/rooms/{id}/archive
+	if strings.HasSuffix(path, "/archive") {
+		a.ArchiveRoom(w, r)
+		return
+	}
+
 	// /api/v1/rooms/{id}
+	if r.Method == http.MethodDelete {
+		a.DeleteRoom(w, r)
+		return
+	}
 	a.GetRoom(w, r)
 }
 
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,6 +28,23 @@ func setupTestDB(t *testing.T) *db.DB {
 
 	// Drop existing tables to ensure a clean slate (shared in-memory db)
 	dropSchema := `
+		DROP TABLE IF EXISTS attachments;
+		DROP TABLE IF EXISTS audit_log;
+		DROP TABLE IF EXISTS pinned_messages;
+		DROP TABLE IF EXISTS favorite_rooms;
+		DROP TABLE IF EXISTS hidden_dms;
+		DROP TABLE IF EXISTS dm_labels;
+		DROP TABLE IF EXISTS message_reports;
+		DROP TABLE IF EXISTS dm_keys;
+		DROP TABLE IF EXISTS user_prefs;
+		DROP TABLE IF EXISTS starred_messages;
+		DROP TABLE IF EXISTS message_quotes;
+		DROP TABLE IF EXISTS message_forwards;
+		DROP TABLE IF EXISTS room_filters;
+		DROP TABLE IF EXISTS room_join_requests;
+		DROP TABLE IF EXISTS room_reads;
+		DROP TABLE IF EXISTS reactions;
+		DROP TABLE IF EXISTS signup_invites;
 		DROP TABLE IF EXISTS messages;
 		DROP TABLE IF EXISTS rooms_members;
 		DROP TABLE IF EXISTS sessions;
@@ -90,6 +108,7 @@ func addUserToRoom(t *testing.T, testDB *db.DB, userID, roomID string) {
 	member := &models.RoomsMember{
 		UserID: userID,
 		RoomID: roomID,
+		Role:   db.RoleMember,
 	}
 	if err := member.Insert(context.Background(), testDB); err != nil {
 		t.Fatalf("failed to add user to room: %v", err)
@@ -468,3 +487,92 @@ func TestGetUserNotFound(t *testing.T) {
 		t.Errorf("expected status 404, got %d: %s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestDeleteRoom(t *testing.T) {
+	testDB := setupTestDB(t)
+	api := NewAPI(testDB, nil)
+	owner := createTestUser(t, testDB, "alice")
+	viewer := createTestUser(t, testDB, "bob")
+	room := createTestRoom(t, testDB, "doomed", false)
+
+	// A default room for last_room to fall back to
+	deflt := &models.Room{
+		ID:        models.GenerateRoomID(),
+		Name:      "main",
+		IsPrivate: models.FALSE,
+		IsDefault: models.TRUE,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := deflt.Insert(context.Background(), testDB); err != nil {
+		t.Fatalf("failed to create default room: %v", err)
+	}
+
+	ownerMember := &models.RoomsMember{UserID: owner.ID, RoomID: room.ID, Role: db.RoleOwner}
+	if err := ownerMember.Insert(context.Background(), testDB); err != nil {
+		t.Fatalf("failed to add owner to room: %v", err)
+	}
+	addUserToRoom(t, testDB, viewer.ID, room.ID)
+
+	// The viewer was last looking at the doomed room
+	if _, err := testDB.ExecContext(context.Background(),
+		"UPDATE users SET last_room = ? WHERE id = ?", room.ID, viewer.ID); err != nil {
+		t.Fatalf("failed to set last_room: %v", err)
+	}
+
+	var notified map[string]bool
+	var payload []byte
+	api.NotifyUsers = func(userIDs map[string]bool, message []byte) {
+		notified = userIDs
+		payload = message
+	}
+
+	req := makeRequest(t, http.MethodDelete, "/api/v1/rooms/"+room.ID, nil, owner.ID)
+	rr := httptest.NewRecorder()
+
+	api.DeleteRoom(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if _, err := models.RoomByID(context.Background(), testDB, room.ID); err == nil {
+		t.Error("expected the room to be gone")
+	}
+
+	// Every member got the room_deleted event
+	if !notified[owner.ID] || !notified[viewer.ID] {
+		t.Errorf("expected both members to be notified, got %v", notified)
+	}
+	if !strings.Contains(string(payload), "room_deleted") || !strings.Contains(string(payload), room.ID) {
+		t.Errorf("expected a room_deleted event for the room, got %s", payload)
+	}
+
+	// The viewer's active room fell back to the default room
+	updated, err := models.UserByID(context.Background(), testDB, viewer.ID)
+	if err != nil {
+		t.Fatalf("failed to fetch viewer: %v", err)
+	}
+	if updated.LastRoom != deflt.ID {
+		t.Errorf("expected last_room to fall back to %s, got %q", deflt.ID, updated.LastRoom)
+	}
+}
+
+func TestDeleteRoomNotOwner(t *testing.T) {
+	testDB := setupTestDB(t)
+	api := NewAPI(testDB, nil)
+	user := createTestUser(t, testDB, "alice")
+	room := createTestRoom(t, testDB, "sturdy", false)
+	addUserToRoom(t, testDB, user.ID, room.ID)
+
+	req := makeRequest(t, http.MethodDelete, "/api/v1/rooms/"+room.ID, nil, user.ID)
+	rr := httptest.NewRecorder()
+
+	api.DeleteRoom(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for non-owner, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := models.RoomByID(context.Background(), testDB, room.ID); err != nil {
+		t.Errorf("expected the room to survive: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultUploadTypes is the default allowlist of upload content types,
+// overridden with the UPLOAD_ALLOWED_TYPES environment variable
+var defaultUploadTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"text/plain",
+}
+
+// sniffableTypes are content types http.DetectContentType recognizes from
+// their leading bytes; a file declared as one of these that doesn't carry
+// the matching signature is disguised as something it isn't
+var sniffableTypes = map[string]bool{
+	"image/png":          true,
+	"image/jpeg":         true,
+	"image/gif":          true,
+	"image/webp":         true,
+	"image/bmp":          true,
+	"application/pdf":    true,
+	"application/zip":    true,
+	"application/x-gzip": true,
+}
+
+// uploadTypesFromEnv reads UPLOAD_ALLOWED_TYPES, a comma-separated list of
+// content types uploads may have, falling back to the default list when it
+// is unset
+func uploadTypesFromEnv() map[string]bool {
+	types := defaultUploadTypes
+	if val := os.Getenv("UPLOAD_ALLOWED_TYPES"); val != "" {
+		types = strings.Split(val, ",")
+	}
+	allowed := make(map[string]bool)
+	for _, t := range types {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			allowed[t] = true
+		}
+	}
+	return allowed
+}
+
+// sniffUploadType determines an upload's content type from its leading
+// bytes and rejects files whose declared Content-Type disagrees with what
+// they actually contain. When detection is inconclusive (plain text or
+// arbitrary bytes) the declared type is taken at its word; the allowlist
+// still bounds what gets stored.
+func sniffUploadType(head []byte, declared string) (string, error) {
+	detected, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	detected = strings.TrimSpace(detected)
+	declared = strings.ToLower(strings.TrimSpace(declared))
+	if base, _, found := strings.Cut(declared, ";"); found {
+		declared = strings.TrimSpace(base)
+	}
+
+	if declared == "" || declared == "application/octet-stream" {
+		return detected, nil
+	}
+	// A declared type the sniffer knows must actually carry its signature
+	if sniffableTypes[declared] && detected != declared {
+		return "", fmt.Errorf("file declared as %s but detected as %s", declared, detected)
+	}
+	// Content with a recognizable signature must not hide behind an
+	// unrelated declared type
+	if sniffableTypes[detected] && detected != declared {
+		return "", fmt.Errorf("file declared as %s but detected as %s", declared, detected)
+	}
+	return declared, nil
+}
+
+// validateImageFile decodes a stored upload's image header with the real
+// decoders, catching crafted files that carry an image signature but aren't
+// decodable images
+func validateImageFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, _, err = image.DecodeConfig(f)
+	return err
+}
@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+
+	// Register decoders for the image types we thumbnail
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Default maximum thumbnail dimensions; the image is scaled to fit within
+// this box while preserving its aspect ratio
+const (
+	defaultThumbMaxWidth  = 400
+	defaultThumbMaxHeight = 400
+)
+
+// isImageType reports whether a content type is one we can thumbnail
+func isImageType(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif":
+		return true
+	}
+	return false
+}
+
+// generateThumbnail decodes a stored upload, scales it to fit the configured
+// maximum dimensions, writes the result alongside the original, and records
+// its URL on the attachment row. It runs in the background after an image
+// upload; any failure just leaves the attachment without a thumbnail, and
+// clients fall back to the original URL.
+func (a *API) generateThumbnail(attID, storedName string) {
+	src, err := os.Open(filepath.Join(a.uploadDir, storedName))
+	if err != nil {
+		a.logger.Error("thumbnail: unable to open upload", "error", err, "attachment", attID)
+		return
+	}
+	defer src.Close()
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		a.logger.Error("thumbnail: unable to decode image", "error", err, "attachment", attID)
+		return
+	}
+
+	scaled := scaleImage(img, a.thumbMaxWidth, a.thumbMaxHeight)
+	if scaled == nil {
+		// The original already fits within the thumbnail box
+		return
+	}
+
+	thumbName := attID + "_thumb.png"
+	dst, err := os.Create(filepath.Join(a.uploadDir, thumbName))
+	if err != nil {
+		a.logger.Error("thumbnail: unable to create file", "error", err, "attachment", attID)
+		return
+	}
+	defer dst.Close()
+	if err := png.Encode(dst, scaled); err != nil {
+		a.logger.Error("thumbnail: unable to encode", "error", err, "attachment", attID)
+		return
+	}
+
+	// Targeted update so a concurrent message link is never clobbered
+	const sqlstr = `UPDATE attachments SET thumbnail_url = $1 WHERE id = $2`
+	if _, err := a.db.ExecContext(context.Background(), sqlstr, "/uploads/"+thumbName, attID); err != nil {
+		a.logger.Error("thumbnail: unable to record URL", "error", err, "attachment", attID)
+	}
+}
+
+// scaleImage downsamples src to fit within maxW x maxH, preserving aspect
+// ratio, using nearest-neighbor sampling. Returns nil if the image already
+// fits (no thumbnail needed).
+func scaleImage(src image.Image, maxW, maxH int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	scale := math.Min(float64(maxW)/float64(w), float64(maxH)/float64(h))
+	if scale >= 1 {
+		return nil
+	}
+
+	tw := int(float64(w) * scale)
+	th := int(float64(h) * scale)
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		for x := 0; x < tw; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*w/tw, b.Min.Y+y*h/th))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// UploadResponse represents an uploaded file in API responses. The ID can be
+// passed as an attachment_id on a subsequent send message request to attach
+// the file to a message.
+type UploadResponse struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// UploadHandler accepts a multipart file upload, stores it in the upload
+// directory, and records it as an unlinked attachment owned by the uploader
+// POST /api/v1/uploads
+func (a *API) UploadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "POST required")
+		return
+	}
+
+	user, err := a.getUser(r)
+	if err != nil {
+		a.logger.Error("failed to get user", "error", err)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get user")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, a.maxUploadBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		a.writeError(w, http.StatusBadRequest, "invalid_request", "A 'file' form field is required")
+		return
+	}
+	defer file.Close()
+
+	// Sniff the real content type from the leading bytes; the declared
+	// Content-Type is client-controlled and can't be trusted on its own
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		a.logger.Error("failed to read upload", "error", err)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to store upload")
+		return
+	}
+	head = head[:n]
+
+	contentType, err := sniffUploadType(head, header.Header.Get("Content-Type"))
+	if err != nil {
+		a.logger.Warn("rejecting mismatched upload", "error", err, "filename", header.Filename, "user", user.ID)
+		a.writeError(w, http.StatusBadRequest, "invalid_request", "File content does not match its declared type")
+		return
+	}
+	if !a.uploadTypes[contentType] {
+		a.writeError(w, http.StatusUnsupportedMediaType, "unsupported_type", "Uploads of type "+contentType+" are not allowed")
+		return
+	}
+
+	if err := os.MkdirAll(a.uploadDir, 0o755); err != nil {
+		a.logger.Error("failed to create upload dir", "error", err, "dir", a.uploadDir)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to store upload")
+		return
+	}
+
+	// Store under a generated name; the original filename is only kept as
+	// metadata so it can never influence the path on disk
+	id := models.GenerateAttachmentID()
+	stored := id + filepath.Ext(header.Filename)
+	dst, err := os.Create(filepath.Join(a.uploadDir, stored))
+	if err != nil {
+		a.logger.Error("failed to create upload file", "error", err, "file", stored)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to store upload")
+		return
+	}
+	defer dst.Close()
+
+	size, err := dst.ReadFrom(io.MultiReader(bytes.NewReader(head), file))
+	if err != nil {
+		a.logger.Error("failed to write upload", "error", err, "file", stored)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to store upload")
+		return
+	}
+
+	// Image uploads must survive the real decoders, not just carry the
+	// right signature
+	if isImageType(contentType) {
+		if err := validateImageFile(filepath.Join(a.uploadDir, stored)); err != nil {
+			a.logger.Warn("rejecting undecodable image upload", "error", err, "filename", header.Filename, "user", user.ID)
+			_ = os.Remove(filepath.Join(a.uploadDir, stored))
+			a.writeError(w, http.StatusBadRequest, "invalid_request", "File is not a valid image")
+			return
+		}
+	}
+
+	att := models.Attachment{
+		ID:          id,
+		UserID:      user.ID,
+		URL:         "/uploads/" + stored,
+		Filename:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        int(size),
+		CreatedAt:   time.Now().Format(time.RFC3339),
+	}
+	if err := att.Insert(r.Context(), a.db); err != nil {
+		a.logger.Error("failed to insert attachment", "error", err, "attachment", id)
+		a.writeError(w, http.StatusInternalServerError, "internal_error", "Failed to store upload")
+		return
+	}
+
+	// Thumbnail image uploads in the background; non-image types are simply
+	// never thumbnailed
+	if isImageType(contentType) {
+		go a.generateThumbnail(att.ID, stored)
+	}
+
+	a.writeJSON(w, http.StatusCreated, UploadResponse{
+		ID:          att.ID,
+		URL:         att.URL,
+		Filename:    att.Filename,
+		ContentType: att.ContentType,
+		Size:        att.Size,
+	})
+}
@@ -0,0 +1,274 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/middleware"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// uploadFile posts a multipart upload as the given user and returns the
+// response recorder
+func uploadFile(t *testing.T, api *API, userID, filename, contentType string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		t.Fatalf("failed to create multipart part: %v", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatalf("failed to write multipart content: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close multipart writer: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/api/v1/uploads", &buf)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	api.UploadHandler(rr, req)
+	return rr
+}
+
+// encodePNG encodes a solid-color PNG of the given size
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 50, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// waitForThumbnail polls until the attachment has a thumbnail URL or the
+// deadline passes, returning the final value
+func waitForThumbnail(t *testing.T, api *API, attID string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		att, err := models.AttachmentByID(context.Background(), api.db, attID)
+		if err != nil {
+			t.Fatalf("failed to load attachment: %v", err)
+		}
+		if att.ThumbnailURL != "" {
+			return att.ThumbnailURL
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return ""
+}
+
+func TestUploadImageGeneratesThumbnail(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+	api.uploadDir = t.TempDir()
+	user := createTestUser(t, testDB, "alice")
+
+	rr := uploadFile(t, api, user.ID, "big.png", "image/png", encodePNG(t, 800, 600))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response UploadResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	thumbURL := waitForThumbnail(t, api, response.ID)
+	if thumbURL == "" {
+		t.Fatal("expected a thumbnail URL to be recorded")
+	}
+
+	// An 800x600 image in a 400x400 box scales to 400x300
+	thumbFile, err := os.Open(filepath.Join(api.uploadDir, filepath.Base(thumbURL)))
+	if err != nil {
+		t.Fatalf("failed to open thumbnail: %v", err)
+	}
+	defer thumbFile.Close()
+	thumb, _, err := image.Decode(thumbFile)
+	if err != nil {
+		t.Fatalf("failed to decode thumbnail: %v", err)
+	}
+	bounds := thumb.Bounds()
+	if bounds.Dx() != 400 || bounds.Dy() != 300 {
+		t.Errorf("expected 400x300 thumbnail, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestUploadSmallImageSkipsThumbnail(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+	api.uploadDir = t.TempDir()
+	user := createTestUser(t, testDB, "alice")
+
+	// Already fits within the thumbnail box, so no thumbnail is generated
+	rr := uploadFile(t, api, user.ID, "small.png", "image/png", encodePNG(t, 100, 80))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response UploadResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	// Run the generator synchronously so there's no window for it to still
+	// be in flight when we assert
+	api.generateThumbnail(response.ID, filepath.Base(response.URL))
+	att, err := models.AttachmentByID(context.Background(), testDB, response.ID)
+	if err != nil {
+		t.Fatalf("failed to load attachment: %v", err)
+	}
+	if att.ThumbnailURL != "" {
+		t.Errorf("expected no thumbnail for a small image, got %q", att.ThumbnailURL)
+	}
+}
+
+func TestUploadNonImageNoThumbnail(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+	api.uploadDir = t.TempDir()
+	user := createTestUser(t, testDB, "alice")
+
+	rr := uploadFile(t, api, user.ID, "notes.txt", "text/plain", []byte("not an image"))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response UploadResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.ContentType != "text/plain" {
+		t.Errorf("expected text/plain content type, got %q", response.ContentType)
+	}
+
+	// The file is stored even though it can't be thumbnailed
+	if _, err := os.Stat(filepath.Join(api.uploadDir, filepath.Base(response.URL))); err != nil {
+		t.Errorf("expected uploaded file on disk: %v", err)
+	}
+
+	att, err := models.AttachmentByID(context.Background(), testDB, response.ID)
+	if err != nil {
+		t.Fatalf("failed to load attachment: %v", err)
+	}
+	if att.ThumbnailURL != "" {
+		t.Errorf("expected no thumbnail for a non-image upload, got %q", att.ThumbnailURL)
+	}
+}
+
+func TestUploadSpoofedContentTypeRejected(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+	api.uploadDir = t.TempDir()
+	user := createTestUser(t, testDB, "alice")
+
+	// Real PNG bytes hiding behind a plain-text declaration; the sniffer
+	// sees the PNG signature and refuses the mismatch
+	rr := uploadFile(t, api, user.ID, "notes.txt", "text/plain", encodePNG(t, 10, 10))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a spoofed content type, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Nothing was stored
+	entries, err := os.ReadDir(api.uploadDir)
+	if err != nil {
+		t.Fatalf("failed to read upload dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no stored files, got %d", len(entries))
+	}
+}
+
+func TestUploadRenamedNonImageRejected(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+	api.uploadDir = t.TempDir()
+	user := createTestUser(t, testDB, "alice")
+
+	// An executable renamed to .png and declared as an image carries no
+	// PNG signature, so the declared type can't be honored
+	exe := append([]byte("MZ\x90\x00\x03"), bytes.Repeat([]byte{0}, 64)...)
+	rr := uploadFile(t, api, user.ID, "photo.png", "image/png", exe)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a renamed non-image, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestUploadLegitimateImagePasses(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+	api.uploadDir = t.TempDir()
+	user := createTestUser(t, testDB, "alice")
+
+	rr := uploadFile(t, api, user.ID, "real.png", "image/png", encodePNG(t, 20, 20))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response UploadResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.ContentType != "image/png" {
+		t.Errorf("expected image/png content type, got %q", response.ContentType)
+	}
+}
+
+func TestUploadTypeAllowlist(t *testing.T) {
+	testDB := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	api := NewAPI(testDB, logger)
+	api.uploadDir = t.TempDir()
+	user := createTestUser(t, testDB, "alice")
+
+	// A well-formed zip isn't in the default allowlist
+	zip := append([]byte("PK\x03\x04"), bytes.Repeat([]byte{0}, 32)...)
+	rr := uploadFile(t, api, user.ID, "archive.zip", "application/zip", zip)
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected status 415 for a disallowed type, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Widening the allowlist admits it
+	api.uploadTypes["application/zip"] = true
+	rr = uploadFile(t, api, user.ID, "archive.zip", "application/zip", zip)
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected status 201 once the type is allowed, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
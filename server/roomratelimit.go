@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+)
+
+// roomRateLimiter caps the aggregate message rate of each room with a token
+// bucket, so one hot room can't overwhelm the hub and database no matter how
+// many members are posting. This is independent of per-user limits and slow
+// mode, which throttle individual senders.
+type roomRateLimiter struct {
+	mu sync.Mutex
+	// rate is tokens added per second
+	rate float64
+	// capacity is the bucket size: rate, clamped to at least one token so a
+	// fractional rate means "burst of one, slow refill" rather than a bucket
+	// that can never hold a whole message. A quiet room can absorb at most a
+	// one-second burst
+	capacity float64
+	// exemptAdmins skips the cap for room owners and admins
+	exemptAdmins bool
+	buckets      map[string]*roomBucket
+	// now is swappable so tests can drive refill without sleeping
+	now func() time.Time
+}
+
+type roomBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRoomRateLimiter(rate float64, exemptAdmins bool) *roomRateLimiter {
+	capacity := rate
+	if rate > 0 && capacity < 1 {
+		capacity = 1
+	}
+	return &roomRateLimiter{
+		rate:         rate,
+		capacity:     capacity,
+		exemptAdmins: exemptAdmins,
+		buckets:      make(map[string]*roomBucket),
+		now:          time.Now,
+	}
+}
+
+// newRoomRateLimiterFromEnv builds a roomRateLimiter from the
+// ROOM_RATE_LIMIT_PER_SEC environment variable (messages per second across
+// all of a room's members; unset or invalid disables the cap) and
+// ROOM_RATE_EXEMPT_ADMINS ("true" lets owners and admins post past the cap).
+func newRoomRateLimiterFromEnv(logger *slog.Logger) *roomRateLimiter {
+	rate := 0.0
+	if val := os.Getenv("ROOM_RATE_LIMIT_PER_SEC"); val != "" {
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil || n <= 0 {
+			logger.Warn("ignoring invalid room rate limit", "name", "ROOM_RATE_LIMIT_PER_SEC", "value", val)
+		} else {
+			rate = n
+		}
+	}
+	return newRoomRateLimiter(rate, os.Getenv("ROOM_RATE_EXEMPT_ADMINS") == "true")
+}
+
+// allow consumes one token from the room's bucket, reporting whether the
+// message fits under the room's aggregate rate cap
+func (l *roomRateLimiter) allow(roomID string) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b, ok := l.buckets[roomID]
+	if !ok {
+		b = &roomBucket{tokens: l.capacity, last: now}
+		l.buckets[roomID] = b
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * l.rate
+		if b.tokens > l.capacity {
+			b.tokens = l.capacity
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// allowRoomMessage reports whether a message to roomID fits under the room's
+// aggregate rate cap. When admins are exempt, a role lookup failure falls
+// back to the cap rather than letting the message through.
+func (h *Hub) allowRoomMessage(roomID, userID string) bool {
+	if h.roomLimiter.rate <= 0 {
+		return true
+	}
+	if h.roomLimiter.exemptAdmins {
+		role, err := db.GetMemberRole(context.Background(), h.db, userID, roomID)
+		if err != nil {
+			h.logger.Error("failed to get member role for room rate cap", "error", err, "user", userID, "room", roomID)
+		} else if role == db.RoleOwner || role == db.RoleAdmin {
+			return true
+		}
+	}
+	return h.roomLimiter.allow(roomID)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+	"github.com/llimllib/hatchat/server/models"
+)
+
+// TestRoomRateLimiter_FloodTripsCap tests that a flood of messages into one
+// room trips its aggregate cap regardless of who sent them, that a quiet
+// room is unaffected, and that the bucket refills over time
+func TestRoomRateLimiter_FloodTripsCap(t *testing.T) {
+	limiter := newRoomRateLimiter(3, false)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	// Three messages in the same instant — from any mix of users — drain
+	// the bucket, and the fourth is refused
+	for i := 0; i < 3; i++ {
+		if !limiter.allow("roo_hot") {
+			t.Fatalf("Expected message %d to be allowed", i+1)
+		}
+	}
+	if limiter.allow("roo_hot") {
+		t.Error("Expected the fourth message in one second to be refused")
+	}
+
+	// A quiet room has its own bucket and is unaffected by the hot one
+	if !limiter.allow("roo_quiet") {
+		t.Error("Expected a quiet room to be unaffected by the hot room's cap")
+	}
+
+	// Half a second refills half the bucket: one token
+	now = now.Add(500 * time.Millisecond)
+	if !limiter.allow("roo_hot") {
+		t.Error("Expected a message to be allowed after the bucket refilled")
+	}
+	if limiter.allow("roo_hot") {
+		t.Error("Expected only one token after half a second at 3/sec")
+	}
+}
+
+// TestRoomRateLimiter_FractionalRate tests that a rate below one message per
+// second means "burst of one, slow refill" rather than refusing everything
+func TestRoomRateLimiter_FractionalRate(t *testing.T) {
+	limiter := newRoomRateLimiter(0.5, false)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	if !limiter.allow("roo_slow") {
+		t.Fatal("Expected the first message to be allowed at a fractional rate")
+	}
+	if limiter.allow("roo_slow") {
+		t.Error("Expected the second immediate message to be refused")
+	}
+
+	// One second refills half a token — not yet a whole message
+	now = now.Add(time.Second)
+	if limiter.allow("roo_slow") {
+		t.Error("Expected half a token after one second at 0.5/sec")
+	}
+	// A second more completes the token
+	now = now.Add(time.Second)
+	if !limiter.allow("roo_slow") {
+		t.Error("Expected a message to be allowed once a full token refilled")
+	}
+}
+
+// TestRoomRateLimiter_Disabled tests that a zero rate disables the cap
+func TestRoomRateLimiter_Disabled(t *testing.T) {
+	limiter := newRoomRateLimiter(0, false)
+	for i := 0; i < 100; i++ {
+		if !limiter.allow("roo_any") {
+			t.Fatal("Expected every message to be allowed with the cap disabled")
+		}
+	}
+}
+
+// TestRoomRateLimiter_AdminExempt tests that owners and admins post past the
+// cap when ROOM_RATE_EXEMPT_ADMINS is on, while members are still held to it
+func TestRoomRateLimiter_AdminExempt(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testDB, err := db.NewDB("file::memory:?cache=shared", logger)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer func() { _ = testDB.Close() }()
+	if err := testDB.RunSQLFile("../schema.sql"); err != nil {
+		t.Fatalf("Failed to run schema: %v", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	owner := &models.User{ID: "usr_roomrate_001", Username: "roomrateowner", Password: "x", CreatedAt: now, ModifiedAt: now}
+	member := &models.User{ID: "usr_roomrate_002", Username: "roomratemember", Password: "x", CreatedAt: now, ModifiedAt: now}
+	for _, u := range []*models.User{owner, member} {
+		if err := u.Insert(context.Background(), testDB); err != nil {
+			t.Fatalf("Failed to insert user: %v", err)
+		}
+	}
+	room := &models.Room{
+		ID:           "roo_roomrate_001",
+		Name:         "roomrate",
+		RoomType:     "channel",
+		CreatedAt:    now,
+		PostPolicy:   db.PostPolicyAll,
+		AnnouncePins: models.TRUE,
+	}
+	if err := room.Insert(context.Background(), testDB); err != nil {
+		t.Fatalf("Failed to insert room: %v", err)
+	}
+	for _, m := range []*models.RoomsMember{
+		{UserID: owner.ID, RoomID: room.ID, Role: db.RoleOwner},
+		{UserID: member.ID, RoomID: room.ID, Role: db.RoleMember},
+	} {
+		if err := m.Insert(context.Background(), testDB); err != nil {
+			t.Fatalf("Failed to insert membership: %v", err)
+		}
+	}
+
+	hub := &Hub{
+		roomLimiter: newRoomRateLimiter(1, true),
+		logger:      logger,
+		db:          testDB,
+	}
+
+	// The member's first message drains the one-token bucket and the second
+	// is refused; the owner posts past the cap
+	if !hub.allowRoomMessage(room.ID, member.ID) {
+		t.Fatal("Expected the member's first message to be allowed")
+	}
+	if hub.allowRoomMessage(room.ID, member.ID) {
+		t.Error("Expected the member's second message to be refused")
+	}
+	if !hub.allowRoomMessage(room.ID, owner.ID) {
+		t.Error("Expected the owner to be exempt from the room cap")
+	}
+
+	// Without the exemption the owner is held to the cap like anyone else
+	hub.roomLimiter = newRoomRateLimiter(1, false)
+	if !hub.allowRoomMessage(room.ID, owner.ID) {
+		t.Fatal("Expected the owner's first message to be allowed")
+	}
+	if hub.allowRoomMessage(room.ID, owner.ID) {
+		t.Error("Expected the owner to be refused without the exemption")
+	}
+}
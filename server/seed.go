@@ -103,6 +103,7 @@ func seedDevUsers(database *db.DB, logger *slog.Logger) error {
 		roomMember := &models.RoomsMember{
 			UserID: user.ID,
 			RoomID: room.ID,
+			Role:   db.RoleMember,
 		}
 		if err := roomMember.Insert(ctx, database); err != nil {
 			return fmt.Errorf("add user %s to default room: %w", u.username, err)
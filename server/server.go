@@ -3,14 +3,18 @@ package server
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lmittmann/tint"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/llimllib/hatchat"
 	"github.com/llimllib/hatchat/server/api"
 	"github.com/llimllib/hatchat/server/db"
 	"github.com/llimllib/hatchat/server/middleware"
@@ -25,18 +29,95 @@ func fatal(logger *slog.Logger, message string, err error, args ...any) {
 	panic(message)
 }
 
+// Registration modes control who can create an account
+const (
+	RegistrationOpen   = "open"   // anyone can register
+	RegistrationInvite = "invite" // registration requires a signup invite code
+	RegistrationClosed = "closed" // registration is disabled
+)
+
 type ChatServer struct {
 	db         *db.DB
 	logger     *slog.Logger
 	sessionKey string
+
+	// registrationMode is one of the Registration* constants; an empty value
+	// is treated as RegistrationOpen
+	registrationMode string
+
+	// contentSecurityPolicy overrides the default CSP when non-empty
+	contentSecurityPolicy string
+
+	// secureCookies marks the session cookie Secure; disable for local
+	// HTTP development
+	secureCookies bool
+
+	// assets holds the templates and static files; the embedded copies by
+	// default, or the working directory after UseDiskAssets
+	assets fs.FS
+
+	// maxBodyBytes caps JSON request bodies; maxUploadBytes caps the
+	// multipart upload endpoint
+	maxBodyBytes   int64
+	maxUploadBytes int64
+
+	// adminUsers are the usernames allowed to call the admin endpoints
+	adminUsers map[string]bool
+
+	// bcryptCost is the work factor for password hashing; zero falls back
+	// to bcrypt.DefaultCost
+	bcryptCost int
+
+	// minPasswordLength and rejectCommonPasswords form the password
+	// strength policy applied at registration
+	minPasswordLength     int
+	rejectCommonPasswords bool
+
+	// validator rejects websocket payloads that don't match the generated
+	// protocol schema; nil when strict validation is off
+	validator *schemaValidator
+
+	// guestHistoryEnabled serves a read-only window of public channels to
+	// unauthenticated visitors; guestHistoryLimit caps how many recent
+	// messages it returns
+	guestHistoryEnabled bool
+	guestHistoryLimit   int
+
+	// warmupEnabled primes the page cache with recently active rooms'
+	// history on startup; off by default
+	warmupEnabled bool
+
+	// welcomeRooms are names of extra channels every new account joins at
+	// registration, so onboarding lands users in more than one room. The
+	// singular default room still provides the initial last_room.
+	welcomeRooms []string
 }
 
 func NewChatServer(level string, dbLocation string) (*ChatServer, error) {
 	logger := initLog(level)
+
+	// Optional encryption of message bodies at rest. Keys are comma-separated
+	// "<key_id>:<64 hex chars>" pairs, newest first; old keys stay in the list
+	// so rows written before a rotation still decrypt. Enabling encryption
+	// disables full-text search, since the FTS index would store plaintext.
+	var msgCipher *db.MessageCipher
+	if spec := os.Getenv("MESSAGE_ENCRYPTION_KEYS"); spec != "" {
+		var err error
+		msgCipher, err = db.ParseMessageCipher(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MESSAGE_ENCRYPTION_KEYS: %w", err)
+		}
+	}
+
 	db, err := initDb(dbLocation, logger)
 	if err != nil {
 		return nil, err
 	}
+	if msgCipher != nil {
+		db.Cipher = msgCipher
+		logger.Info("message encryption at rest enabled", "active_key", msgCipher.ActiveKeyID())
+		logger.Warn("full-text search is disabled while message encryption is enabled")
+	}
 
 	// Seed development users if env var is set
 	if os.Getenv("SEED_DEVELOPMENT_DB") != "" {
@@ -49,141 +130,182 @@ func NewChatServer(level string, dbLocation string) (*ChatServer, error) {
 		}
 	}
 
-	return &ChatServer{
-		db:         db,
-		logger:     logger,
-		sessionKey: "hatchat-session-key",
-	}, nil
-}
-
-func (h *ChatServer) serveChat(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, "template/chat.html")
-}
-
-func (h *ChatServer) serveHome(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, "template/home.html")
-}
-
-func (h *ChatServer) register(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.logger.Debug("wrong method")
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+	// Registration mode comes from the environment so operators can run
+	// private instances without a config file
+	registrationMode := os.Getenv("REGISTRATION_MODE")
+	switch registrationMode {
+	case "", RegistrationOpen, RegistrationInvite, RegistrationClosed:
+	default:
+		return nil, fmt.Errorf("invalid REGISTRATION_MODE %q: must be one of open, invite, closed", registrationMode)
 	}
 
-	// TODO: add a message (where?) to display as a toast
-	user := r.FormValue("username")
-	if user == "" {
-		h.logger.Debug("missing username")
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+	// Session cookies are Secure by default; set COOKIE_SECURE=false when
+	// developing over plain HTTP
+	secureCookies := true
+	switch os.Getenv("COOKIE_SECURE") {
+	case "", "true":
+	case "false":
+		secureCookies = false
+	default:
+		return nil, fmt.Errorf("invalid COOKIE_SECURE %q: must be true or false", os.Getenv("COOKIE_SECURE"))
 	}
-	pass := r.FormValue("password")
-	if pass == "" {
-		h.logger.Debug("missing password")
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+
+	// Body size limits may be raised or lowered from the environment; the
+	// defaults suit typical chat traffic
+	maxBodyBytes, err := bodyLimitFromEnv("MAX_BODY_BYTES", middleware.DefaultMaxBodyBytes)
+	if err != nil {
+		return nil, err
 	}
-	encPass, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	maxUploadBytes, err := bodyLimitFromEnv("MAX_UPLOAD_BYTES", middleware.DefaultMaxUploadBytes)
 	if err != nil {
-		h.logger.Debug("unable to encrypt pass", "err", err)
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+		return nil, err
 	}
 
-	uid := models.GenerateUserID()
-
-	// Users automatically get inserted into the default room
-	room, err := models.GetDefaultRoom(context.Background(), h.db)
-	if err != nil {
-		h.logger.Error("unable to get default room", "err", err)
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+	// The bcrypt cost may be tuned for the host hardware; higher is slower
+	// and stronger
+	bcryptCost := bcrypt.DefaultCost
+	if val := os.Getenv("BCRYPT_COST"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < bcrypt.MinCost || n > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid BCRYPT_COST %q: must be an integer between %d and %d", val, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		bcryptCost = n
 	}
 
-	userp := &models.User{
-		ID:         uid,
-		Username:   user,
-		Password:   string(encPass),
-		LastRoom:   room.ID,
-		CreatedAt:  time.Now().Format(time.RFC3339),
-		ModifiedAt: time.Now().Format(time.RFC3339),
+	// Password strength policy
+	minPasswordLength := defaultMinPasswordLength
+	if val := os.Getenv("MIN_PASSWORD_LENGTH"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid MIN_PASSWORD_LENGTH %q: must be a positive integer", val)
+		}
+		minPasswordLength = n
 	}
-	err = userp.Insert(r.Context(), h.db)
-	if err != nil {
-		h.logger.Debug("unable to insert user", "err", err)
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+	rejectCommonPasswords := true
+	switch os.Getenv("REJECT_COMMON_PASSWORDS") {
+	case "", "true":
+	case "false":
+		rejectCommonPasswords = false
+	default:
+		return nil, fmt.Errorf("invalid REJECT_COMMON_PASSWORDS %q: must be true or false", os.Getenv("REJECT_COMMON_PASSWORDS"))
+	}
+
+	// Strict mode validates every inbound websocket payload against the
+	// generated JSON Schema before dispatch; off by default since it walks
+	// each payload a second time
+	var validator *schemaValidator
+	switch os.Getenv("STRICT_SCHEMA_VALIDATION") {
+	case "", "false":
+	case "true":
+		validator, err = newSchemaValidator("schema/protocol.json")
+		if err != nil {
+			return nil, fmt.Errorf("STRICT_SCHEMA_VALIDATION: %w", err)
+		}
+		logger.Info("strict schema validation enabled")
+	default:
+		return nil, fmt.Errorf("invalid STRICT_SCHEMA_VALIDATION %q: must be true or false", os.Getenv("STRICT_SCHEMA_VALIDATION"))
 	}
 
-	roomm := &models.RoomsMember{
-		UserID: uid,
-		RoomID: room.ID,
+	// Guest history serves the most recent messages of public channels
+	// without a login; off by default
+	guestHistoryEnabled := false
+	switch os.Getenv("GUEST_HISTORY") {
+	case "", "false":
+	case "true":
+		guestHistoryEnabled = true
+		logger.Info("guest history enabled")
+	default:
+		return nil, fmt.Errorf("invalid GUEST_HISTORY %q: must be true or false", os.Getenv("GUEST_HISTORY"))
 	}
-	if err = roomm.Insert(r.Context(), h.db); err != nil {
-		h.logger.Error("unable to add user to room", "uid", uid, "roomid", room.ID, "err", err)
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+	// The startup cache warm-up reads recent rooms' history so the first
+	// real queries after a cold start don't pay for an empty page cache
+	warmupEnabled := false
+	switch os.Getenv("CACHE_WARMUP") {
+	case "", "false":
+	case "true":
+		warmupEnabled = true
+	default:
+		return nil, fmt.Errorf("invalid CACHE_WARMUP %q: must be true or false", os.Getenv("CACHE_WARMUP"))
 	}
 
-	h.logger.Debug("inserted user", "username", r.FormValue("username"))
-	// XXX: consider the user logged in, set a session, and redirect to chat?
-	// currently this makes you go back and log in after registering
-	http.Redirect(w, r, "/", http.StatusFound)
-}
+	// Welcome rooms are a comma-separated list of room names; unknown names
+	// are skipped (with a warning) at registration time rather than here,
+	// since rooms may be created after the server starts
+	var welcomeRooms []string
+	for _, name := range strings.Split(os.Getenv("WELCOME_ROOMS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			welcomeRooms = append(welcomeRooms, name)
+		}
+	}
 
-func (h *ChatServer) login(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		h.logger.Debug("wrong method")
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+	guestHistoryLimit := defaultGuestHistoryLimit
+	if val := os.Getenv("GUEST_HISTORY_LIMIT"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 || n > maxGuestHistoryLimit {
+			return nil, fmt.Errorf("invalid GUEST_HISTORY_LIMIT %q: must be an integer between 1 and %d", val, maxGuestHistoryLimit)
+		}
+		guestHistoryLimit = n
 	}
 
-	username := r.FormValue("username")
-	if username == "" {
-		h.logger.Debug("missing username")
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+	return &ChatServer{
+		db:                    db,
+		logger:                logger,
+		sessionKey:            "hatchat-session-key",
+		registrationMode:      registrationMode,
+		contentSecurityPolicy: os.Getenv("CONTENT_SECURITY_POLICY"),
+		secureCookies:         secureCookies,
+		assets:                hatchat.Assets,
+		maxBodyBytes:          maxBodyBytes,
+		maxUploadBytes:        maxUploadBytes,
+		adminUsers:            adminUsersFromEnv(),
+		bcryptCost:            bcryptCost,
+		minPasswordLength:     minPasswordLength,
+		rejectCommonPasswords: rejectCommonPasswords,
+		validator:             validator,
+		guestHistoryEnabled:   guestHistoryEnabled,
+		guestHistoryLimit:     guestHistoryLimit,
+		warmupEnabled:         warmupEnabled,
+		welcomeRooms:          welcomeRooms,
+	}, nil
+}
+
+// bodyLimitFromEnv reads a body size limit in bytes from the named
+// environment variable, falling back to fallback when it is unset
+func bodyLimitFromEnv(name string, fallback int64) (int64, error) {
+	val := os.Getenv(name)
+	if val == "" {
+		return fallback, nil
 	}
-	pass := r.FormValue("password")
-	if pass == "" {
-		h.logger.Debug("missing password")
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive integer", name, val)
 	}
+	return n, nil
+}
 
-	user, err := models.UserByUsername(r.Context(), h.db, username)
-	if err != nil {
-		h.logger.Debug("Unable to find user", "user", username)
-		http.Redirect(w, r, "/", http.StatusFound)
-		return
-	}
+// UseDiskAssets serves templates and static files from the working directory
+// instead of the embedded copies, so edits show up without a rebuild
+func (h *ChatServer) UseDiskAssets() {
+	h.assets = os.DirFS(".")
+}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(pass)); err == nil {
-		h.logger.Debug("login succeeded")
+func (h *ChatServer) serveChat(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, h.assets, "template/chat.html")
+}
 
-		sid := models.GenerateSessionID()
-		session := models.Session{
-			ID:        sid,
-			UserID:    user.ID,
-			CreatedAt: time.Now().Format(time.RFC3339),
-		}
-		if err := session.Insert(r.Context(), h.db); err != nil {
-			fatal(h.logger, "session insert error", err)
-		}
+func (h *ChatServer) serveHome(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, h.assets, "template/home.html")
+}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     h.sessionKey,
-			Value:    sid,
-			Expires:  time.Now().Add(24 * time.Hour),
-			HttpOnly: true, // Client-side scripts cannot access the cookie
-		})
-
-		http.Redirect(w, r, fmt.Sprintf("/chat/%s", user.LastRoom), http.StatusFound)
-	} else {
-		h.logger.Debug("wrong password")
-		http.Redirect(w, r, "/", http.StatusFound)
+// serveStatic serves the static assets from the configured asset FS
+func (h *ChatServer) serveStatic(w http.ResponseWriter, r *http.Request) {
+	staticFS, err := fs.Sub(h.assets, "static")
+	if err != nil {
+		h.logger.Error("unable to open static assets", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
 	}
+	http.StripPrefix("/static/", http.FileServerFS(staticFS)).ServeHTTP(w, r)
 }
 
 // create a logger with the given log level
@@ -200,18 +322,30 @@ func initLog(level string) *slog.Logger {
 }
 
 func initDb(location string, logger *slog.Logger) (*db.DB, error) {
-	db, err := db.NewDB(location, logger)
+	database, err := db.NewDB(location, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.RunSQLFile("schema.sql")
+	// Case-variant duplicate usernames must be resolved before the schema
+	// file creates the case-insensitive unique index over them
+	if err := database.MigrateUsernameCollation(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Columns that schema.sql has grown since the database was created must
+	// be added before the schema file creates indexes and triggers over them
+	if err := database.MigrateSchemaColumns(context.Background()); err != nil {
+		return nil, err
+	}
+
+	err = database.RunSQLFile("schema.sql")
 	if err != nil {
 		return nil, err
 	}
 
 	// If there are no rooms, create a default room
-	row := db.QueryRowContext(context.Background(), "SELECT count(*) FROM rooms")
+	row := database.QueryRowContext(context.Background(), "SELECT count(*) FROM rooms")
 	var n int
 	err = row.Scan(&n)
 	if err != nil {
@@ -220,26 +354,36 @@ func initDb(location string, logger *slog.Logger) (*db.DB, error) {
 
 	if n == 0 {
 		room := models.Room{
-			ID:        models.GenerateRoomID(),
-			Name:      "main",
-			RoomType:  "channel",
-			IsPrivate: models.FALSE,
-			IsDefault: models.TRUE,
-			CreatedAt: time.Now().Format(time.RFC3339),
+			ID:           models.GenerateRoomID(),
+			Name:         "main",
+			RoomType:     "channel",
+			IsPrivate:    models.FALSE,
+			IsDefault:    models.TRUE,
+			CreatedAt:    time.Now().Format(time.RFC3339),
+			PostPolicy:   db.PostPolicyAll,
+			AnnouncePins: models.TRUE,
 		}
-		if err := room.Insert(context.Background(), db); err != nil {
+		if err := room.Insert(context.Background(), database); err != nil {
 			return nil, err
 		}
 	}
 
-	return db, nil
+	return database, nil
 }
 
 func (h *ChatServer) middleware(route string, handler http.HandlerFunc) http.HandlerFunc {
+	return h.middlewareWithLimit(route, h.maxBodyBytes, handler)
+}
+
+// middlewareWithLimit is the standard middleware chain with an explicit body
+// size limit, for routes like uploads that accept larger bodies
+func (h *ChatServer) middlewareWithLimit(route string, maxBytes int64, handler http.HandlerFunc) http.HandlerFunc {
 	requestID := middleware.RequestIDMiddleware(h.logger)
 	logReq := middleware.RequestLogMiddleware(h.logger)(route)
 	panicHandler := middleware.RecoverMiddleware(h.logger)
-	return panicHandler(requestID(logReq(handler)))
+	secHeaders := middleware.SecurityHeadersMiddleware(h.contentSecurityPolicy)
+	bodyLimit := middleware.BodyLimitMiddleware(maxBytes)
+	return panicHandler(requestID(logReq(secHeaders(bodyLimit(handler)))))
 }
 
 func (h *ChatServer) Run(addr string) {
@@ -247,28 +391,59 @@ func (h *ChatServer) Run(addr string) {
 
 	hub := newHub(h.db, h.logger)
 	go hub.run()
+	go h.runJanitor(hub)
 
 	wsAPI := api.NewApi(h.db, h.logger)
 	restAPI := rest.NewAPI(h.db, h.logger)
+	// The handler's own upload reader must honor the configured limit, not
+	// just the route's body-limit middleware
+	restAPI.SetMaxUploadBytes(h.maxUploadBytes)
+	// Actions taken over REST (bot reactions) broadcast through the hub so
+	// connected clients see them live
+	restAPI.Broadcast = func(roomID string, message []byte) {
+		hub.broadcast <- RoomMessage{RoomID: roomID, Message: message}
+	}
+	// Room deletion must reach members whose connections aren't subscribed
+	// to the room, so it fans out by user rather than by room
+	restAPI.NotifyUsers = func(userIDs map[string]bool, message []byte) {
+		hub.peercast <- PeerMessage{UserIDs: userIDs, Message: message}
+	}
 
 	authRequired := middleware.AuthMiddleware(h.db, h.logger, h.sessionKey)
+	// Large REST responses (room lists, message pages, search results) are
+	// gzipped when the client asks for it; small ones go out as-is
+	gzipped := middleware.GzipMiddleware(middleware.DefaultGzipMinBytes)
 
-	staticHandler := http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))).ServeHTTP
-	http.HandleFunc("/static/", h.middleware("/static", staticHandler))
+	http.HandleFunc("/static/", h.middleware("/static", h.serveStatic))
 	http.HandleFunc("/chat/", h.middleware("/chat/", authRequired(h.serveChat)))
 	http.HandleFunc("/search", h.middleware("/search", authRequired(h.serveChat)))
 	http.HandleFunc("/register", h.middleware("/register", h.register))
 	http.HandleFunc("/login", h.middleware("/login", h.login))
+	http.HandleFunc("/api/v1/auth/register", h.middleware("/api/v1/auth/register", gzipped(h.registerAPI)))
+	http.HandleFunc("/api/v1/auth/login", h.middleware("/api/v1/auth/login", gzipped(h.loginAPI)))
 	http.HandleFunc("/ws", h.middleware("/ws", authRequired(func(w http.ResponseWriter, r *http.Request) {
-		serveWs(hub, wsAPI, w, r)
+		serveWs(hub, wsAPI, h.validator, w, r)
 	})))
 
 	// REST API routes
-	http.HandleFunc("/api/v1/me", h.middleware("/api/v1/me", authRequired(restAPI.MeHandler)))
-	http.HandleFunc("/api/v1/me/", h.middleware("/api/v1/me/", authRequired(restAPI.MeHandler)))
-	http.HandleFunc("/api/v1/rooms", h.middleware("/api/v1/rooms", authRequired(restAPI.RoomsHandler)))
-	http.HandleFunc("/api/v1/rooms/", h.middleware("/api/v1/rooms/", authRequired(restAPI.RoomsHandler)))
-	http.HandleFunc("/api/v1/users/", h.middleware("/api/v1/users/", authRequired(restAPI.GetUser)))
+	http.HandleFunc("/api/v1/me", h.middleware("/api/v1/me", gzipped(authRequired(restAPI.MeHandler))))
+	http.HandleFunc("/api/v1/me/", h.middleware("/api/v1/me/", gzipped(authRequired(restAPI.MeHandler))))
+	http.HandleFunc("/api/v1/rooms", h.middleware("/api/v1/rooms", gzipped(authRequired(restAPI.RoomsHandler))))
+	http.HandleFunc("/api/v1/rooms/", h.middleware("/api/v1/rooms/", gzipped(authRequired(restAPI.RoomsHandler))))
+	http.HandleFunc("/api/v1/users/", h.middleware("/api/v1/users/", gzipped(authRequired(restAPI.GetUser))))
+	http.HandleFunc("/api/v1/messages/", h.middleware("/api/v1/messages/", gzipped(authRequired(restAPI.ReactionsHandler))))
+	http.HandleFunc("/api/v1/uploads", h.middlewareWithLimit("/api/v1/uploads", h.maxUploadBytes, gzipped(authRequired(restAPI.UploadHandler))))
+	http.HandleFunc("/api/v1/admin/audit", h.middleware("/api/v1/admin/audit", gzipped(authRequired(h.auditAPI))))
+	http.HandleFunc("/api/v1/admin/maintenance", h.middleware("/api/v1/admin/maintenance", gzipped(authRequired(h.maintenanceAPI(wsAPI, hub)))))
+	http.HandleFunc("/api/v1/admin/announce", h.middleware("/api/v1/admin/announce", gzipped(authRequired(h.announceAPI(wsAPI, hub)))))
+
+	// Deliberately unauthenticated; the handler is a 404 unless
+	// GUEST_HISTORY=true and only serves public channels
+	http.HandleFunc("/api/v1/guest/history", h.middleware("/api/v1/guest/history", h.guestHistoryAPI))
+
+	// Uploaded files are stored on disk and served back as static files
+	uploadsHandler := http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads/"))).ServeHTTP
+	http.HandleFunc("/uploads/", h.middleware("/uploads", authRequired(uploadsHandler)))
 
 	http.HandleFunc("/", h.middleware("/", h.serveHome))
 
@@ -277,6 +452,11 @@ func (h *ChatServer) Run(addr string) {
 		ReadHeaderTimeout: 3 * time.Second,
 	}
 	h.logger.Info("listening", "addr", addr)
+	// The warm-up runs concurrently with (never ahead of) serving: the
+	// listener comes up while the goroutine reads recent rooms' history
+	if h.warmupEnabled {
+		go h.warmCaches()
+	}
 	err := server.ListenAndServe()
 	if err != nil {
 		fatal(h.logger, "ListenAndServe", err)
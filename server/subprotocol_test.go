@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/llimllib/hatchat/server/protocol"
+)
+
+// dialWebSocket connects to the test server's /ws endpoint with the given
+// subprotocols, returning the connection and the dial error
+func dialWebSocket(ts *testServer, httpClient *http.Client, subprotocols []string) (*websocket.Conn, error) {
+	wsURL := "ws" + strings.TrimPrefix(ts.server.URL, "http") + "/ws"
+
+	serverURL, _ := url.Parse(ts.server.URL)
+	header := http.Header{}
+	for _, cookie := range httpClient.Jar.Cookies(serverURL) {
+		header.Add("Cookie", cookie.String())
+	}
+
+	dialer := websocket.Dialer{Subprotocols: subprotocols}
+	conn, resp, err := dialer.Dial(wsURL, header)
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	return conn, err
+}
+
+// TestSubprotocolNegotiation_Supported tests that a client advertising the
+// current protocol version gets it confirmed in the upgrade and echoed in the
+// init response
+func TestSubprotocolNegotiation_Supported(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	httpClient := ts.createUser("alice", "password123")
+	conn, err := dialWebSocket(ts, httpClient, []string{protocol.SubprotocolV1})
+	if err != nil {
+		t.Fatalf("Failed to connect with supported subprotocol: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if conn.Subprotocol() != protocol.SubprotocolV1 {
+		t.Errorf("Expected negotiated subprotocol %q, got %q", protocol.SubprotocolV1, conn.Subprotocol())
+	}
+
+	// The init response carries the negotiated version
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"init","data":{}}`)); err != nil {
+		t.Fatalf("Failed to send init: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var env struct {
+		Type string                `json:"type"`
+		Data protocol.InitResponse `json:"data"`
+	}
+	if err := conn.ReadJSON(&env); err != nil {
+		t.Fatalf("Failed to read init response: %v", err)
+	}
+	if env.Data.ProtocolVersion != protocol.SubprotocolV1 {
+		t.Errorf("Expected protocol_version %q in init response, got %q", protocol.SubprotocolV1, env.Data.ProtocolVersion)
+	}
+}
+
+// TestSubprotocolNegotiation_Unsupported tests that a client advertising only
+// unknown protocol versions is closed with the unsupported-version close code
+func TestSubprotocolNegotiation_Unsupported(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	httpClient := ts.createUser("alice", "password123")
+	conn, err := dialWebSocket(ts, httpClient, []string{"hatchat.v99"})
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if err == nil {
+		t.Fatal("Expected the connection to be closed, got a message")
+	}
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("Expected a close error, got %v", err)
+	}
+	if closeErr.Code != closeUnsupportedVersion {
+		t.Errorf("Expected close code %d, got %d", closeUnsupportedVersion, closeErr.Code)
+	}
+}
+
+// TestSubprotocolNegotiation_NoneRequested tests that clients that don't send
+// a Sec-WebSocket-Protocol header still connect (backwards compatibility)
+func TestSubprotocolNegotiation_NoneRequested(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	httpClient := ts.createUser("alice", "password123")
+	conn, err := dialWebSocket(ts, httpClient, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect without subprotocol: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if conn.Subprotocol() != "" {
+		t.Errorf("Expected no negotiated subprotocol, got %q", conn.Subprotocol())
+	}
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// requestSchemas maps each client → server envelope type to the name of its
+// request struct in the generated JSON Schema. Every entry must have a
+// definition in the schema; newSchemaValidator refuses to start without one,
+// so a stale schema can't silently turn validation off for a type.
+var requestSchemas = map[string]string{
+	"init":                  "InitRequest",
+	"ping":                  "PingRequest",
+	"message":               "SendMessageRequest",
+	"history":               "HistoryRequest",
+	"forward_message":       "ForwardMessageRequest",
+	"list_reactors":         "ListReactorsRequest",
+	"get_reactions":         "GetReactionsRequest",
+	"mark_read":             "MarkReadRequest",
+	"star_message":          "StarMessageRequest",
+	"unstar_message":        "UnstarMessageRequest",
+	"list_starred":          "ListStarredRequest",
+	"favorite_room":         "FavoriteRoomRequest",
+	"reorder_favorites":     "ReorderFavoritesRequest",
+	"hide_dm":               "HideDMRequest",
+	"set_dm_label":          "SetDMLabelRequest",
+	"pin_message":           "PinMessageRequest",
+	"set_pin_announcements": "SetPinAnnouncementsRequest",
+	"join_room":             "JoinRoomRequest",
+	"create_room":           "CreateRoomRequest",
+	"list_rooms":            "ListRoomsRequest",
+	"leave_room":            "LeaveRoomRequest",
+	"room_info":             "RoomInfoRequest",
+	"list_members":          "ListMembersRequest",
+	"my_membership":         "MyMembershipRequest",
+	"create_dm":             "CreateDMRequest",
+	"add_members":           "AddMembersRequest",
+	"approve_join_request":  "ApproveJoinRequestRequest",
+	"deny_join_request":     "DenyJoinRequestRequest",
+	"list_join_requests":    "ListJoinRequestsRequest",
+	"report_message":        "ReportMessageRequest",
+	"list_reports":          "ListReportsRequest",
+	"resolve_report":        "ResolveReportRequest",
+	"list_users":            "ListUsersRequest",
+	"autocomplete":          "AutocompleteRequest",
+	"get_profile":           "GetProfileRequest",
+	"get_profiles":          "GetProfilesRequest",
+	"update_profile":        "UpdateProfileRequest",
+	"set_username":          "SetUsernameRequest",
+	"edit_message":          "EditMessageRequest",
+	"delete_message":        "DeleteMessageRequest",
+	"add_reaction":          "AddReactionRequest",
+	"remove_reaction":       "RemoveReactionRequest",
+	"set_slow_mode":         "SetSlowModeRequest",
+	"set_retention":         "SetRetentionRequest",
+	"set_post_policy":       "SetPostPolicyRequest",
+	"set_room_topic":        "SetRoomTopicRequest",
+	"add_room_filter":       "AddRoomFilterRequest",
+	"remove_room_filter":    "RemoveRoomFilterRequest",
+	"list_room_filters":     "ListRoomFiltersRequest",
+	"search":                "SearchRequest",
+	"search_stream":         "SearchStreamRequest",
+	"search_stream_cancel":  "SearchStreamCancelRequest",
+	"get_message_context":   "GetMessageContextRequest",
+	"catch_up":              "CatchUpRequest",
+	"sync":                  "SyncRequest",
+	"get_unread":            "GetUnreadRequest",
+	"get_prefs":             "GetPrefsRequest",
+	"set_prefs":             "SetPrefsRequest",
+	"get_messages":          "GetMessagesRequest",
+}
+
+// schemaValidator validates inbound websocket payloads against the JSON
+// Schema that tools/schemagen generates from the protocol package. It
+// understands the subset of keywords the generator emits: object properties
+// and required, string/integer/number/boolean/array types, min/max lengths,
+// min/max items, enum, pattern, and $defs references.
+//
+// Validation is opt-in (STRICT_SCHEMA_VALIDATION) since it walks every
+// payload a second time; handlers still do their own checking either way.
+type schemaValidator struct {
+	defs map[string]any
+}
+
+// newSchemaValidator loads the generated schema from the given path
+func newSchemaValidator(path string) (*schemaValidator, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema struct {
+		Defs map[string]any `json:"$defs"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("parse %s: %v", path, err)
+	}
+	if len(schema.Defs) == 0 {
+		return nil, fmt.Errorf("no $defs found in %s", path)
+	}
+	// A request type without a definition would validate as a no-op, so an
+	// out-of-date schema is a startup error rather than a silent gap
+	var missing []string
+	for _, defName := range requestSchemas {
+		if _, ok := schema.Defs[defName]; !ok {
+			missing = append(missing, defName)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%s has no definition for %s; regenerate with: go run ./tools/schemagen > %s",
+			path, strings.Join(missing, ", "), path)
+	}
+	return &schemaValidator{defs: schema.Defs}, nil
+}
+
+// validate checks an envelope's data payload against the schema for its type.
+// It returns one entry per failing field, or nil when the payload is valid or
+// the type is unknown. Safe on a nil receiver so lenient mode doesn't need a
+// separate code path.
+func (v *schemaValidator) validate(msgType string, data json.RawMessage) []string {
+	if v == nil {
+		return nil
+	}
+	defName, ok := requestSchemas[msgType]
+	if !ok {
+		return nil
+	}
+	def, ok := v.defs[defName].(map[string]any)
+	if !ok {
+		return nil
+	}
+	// Handlers treat a missing data field as an empty object
+	if len(data) == 0 {
+		data = json.RawMessage("{}")
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []string{"data: not valid JSON"}
+	}
+	var problems []string
+	v.check(def, value, "", &problems)
+	return problems
+}
+
+// fieldPath joins a parent path and a field name for error reporting
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// check validates a value against one schema node, appending a problem
+// description per failing field
+func (v *schemaValidator) check(schema map[string]any, value any, path string, problems *[]string) {
+	// Resolve references into $defs
+	if ref, ok := schema["$ref"].(string); ok {
+		name := regexp.MustCompile(`^#/\$defs/`).ReplaceAllString(ref, "")
+		if target, ok := v.defs[name].(map[string]any); ok {
+			schema = target
+		} else {
+			return
+		}
+	}
+
+	label := path
+	if label == "" {
+		label = "data"
+	}
+
+	schemaType, _ := schema["type"].(string)
+	switch schemaType {
+	case "object", "":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: must be an object", label))
+			return
+		}
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := obj[name]; !present {
+					*problems = append(*problems, fmt.Sprintf("%s: required field is missing", fieldPath(path, name)))
+				}
+			}
+		}
+		if properties, ok := schema["properties"].(map[string]any); ok {
+			for name, sub := range properties {
+				subSchema, ok := sub.(map[string]any)
+				if !ok {
+					continue
+				}
+				if fieldValue, present := obj[name]; present {
+					v.check(subSchema, fieldValue, fieldPath(path, name), problems)
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: must be an array", label))
+			return
+		}
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(items)) < minItems {
+			*problems = append(*problems, fmt.Sprintf("%s: must have at least %d items", label, int(minItems)))
+		}
+		if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(items)) > maxItems {
+			*problems = append(*problems, fmt.Sprintf("%s: must have at most %d items", label, int(maxItems)))
+		}
+		if itemSchema, ok := schema["items"].(map[string]any); ok {
+			for i, item := range items {
+				v.check(itemSchema, item, fmt.Sprintf("%s[%d]", label, i), problems)
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: must be a string", label))
+			return
+		}
+		length := len([]rune(s))
+		if minLength, ok := schema["minLength"].(float64); ok && float64(length) < minLength {
+			*problems = append(*problems, fmt.Sprintf("%s: must be at least %d characters", label, int(minLength)))
+		}
+		if maxLength, ok := schema["maxLength"].(float64); ok && float64(length) > maxLength {
+			*problems = append(*problems, fmt.Sprintf("%s: must be at most %d characters", label, int(maxLength)))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(s) {
+				*problems = append(*problems, fmt.Sprintf("%s: must match %s", label, pattern))
+			}
+		}
+		if enum, ok := schema["enum"].([]any); ok {
+			found := false
+			for _, e := range enum {
+				if e == value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				*problems = append(*problems, fmt.Sprintf("%s: must be one of the allowed values", label))
+			}
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			*problems = append(*problems, fmt.Sprintf("%s: must be an integer", label))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: must be a number", label))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: must be a boolean", label))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadTestValidator loads the generated schema from the repository root
+func loadTestValidator(t *testing.T) *schemaValidator {
+	t.Helper()
+	v, err := newSchemaValidator("../schema/protocol.json")
+	if err != nil {
+		t.Fatalf("newSchemaValidator failed: %v", err)
+	}
+	return v
+}
+
+// TestValidate_MissingRequiredField tests that a message missing a required
+// field is rejected with the failing field named
+func TestValidate_MissingRequiredField(t *testing.T) {
+	v := loadTestValidator(t)
+
+	problems := v.validate("message", json.RawMessage(`{"room_id": "roo_123456789012"}`))
+	if len(problems) == 0 {
+		t.Fatal("expected a validation problem for missing body, got none")
+	}
+	found := false
+	for _, p := range problems {
+		if strings.HasPrefix(p, "body:") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a problem naming the body field, got %v", problems)
+	}
+}
+
+// TestValidate_ValidMessageAccepted tests that a well-formed message passes
+func TestValidate_ValidMessageAccepted(t *testing.T) {
+	v := loadTestValidator(t)
+
+	problems := v.validate("message", json.RawMessage(`{"body": "hello", "room_id": "roo_123456789012"}`))
+	if len(problems) != 0 {
+		t.Errorf("expected no problems for a valid message, got %v", problems)
+	}
+}
+
+// TestValidate_MinLengthEnforced tests that string length constraints from
+// the schema are applied
+func TestValidate_MinLengthEnforced(t *testing.T) {
+	v := loadTestValidator(t)
+
+	problems := v.validate("message", json.RawMessage(`{"body": "", "room_id": "roo_123456789012"}`))
+	if len(problems) == 0 {
+		t.Error("expected a validation problem for an empty body, got none")
+	}
+}
+
+// TestValidate_WrongTypeRejected tests that a field of the wrong JSON type
+// is rejected with the failing field named
+func TestValidate_WrongTypeRejected(t *testing.T) {
+	v := loadTestValidator(t)
+
+	problems := v.validate("message", json.RawMessage(`{"body": 42, "room_id": "roo_123456789012"}`))
+	found := false
+	for _, p := range problems {
+		if strings.HasPrefix(p, "body:") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a problem naming the body field, got %v", problems)
+	}
+}
+
+// TestValidate_UnknownTypesSkipped tests that envelope types without a
+// schema definition pass through so handlers can reject them themselves
+func TestValidate_UnknownTypesSkipped(t *testing.T) {
+	v := loadTestValidator(t)
+
+	if problems := v.validate("no_such_type", json.RawMessage(`{}`)); len(problems) != 0 {
+		t.Errorf("expected unknown envelope types to be skipped, got %v", problems)
+	}
+}
+
+// TestValidate_LenientModeIsNilSafe tests that with validation disabled (a
+// nil validator, as in lenient mode) any payload passes through to handlers
+func TestValidate_LenientModeIsNilSafe(t *testing.T) {
+	var v *schemaValidator
+	if problems := v.validate("message", json.RawMessage(`{"room_id": "roo_123456789012"}`)); problems != nil {
+		t.Errorf("expected nil validator to accept everything, got %v", problems)
+	}
+}
+
+// TestNewSchemaValidator_IncompleteSchema tests that a schema missing a
+// definition for a known request type is refused at load time, naming the
+// missing type, instead of silently validating that type as a no-op
+func TestNewSchemaValidator_IncompleteSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "protocol.json")
+	stale := `{"$defs": {"InitRequest": {"type": "object"}}}`
+	if err := os.WriteFile(path, []byte(stale), 0o644); err != nil {
+		t.Fatalf("Failed to write stale schema: %v", err)
+	}
+
+	_, err := newSchemaValidator(path)
+	if err == nil {
+		t.Fatal("expected an error for a schema missing request definitions")
+	}
+	if !strings.Contains(err.Error(), "SendMessageRequest") {
+		t.Errorf("expected the error to name the missing type, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/llimllib/hatchat/server/db"
+)
+
+// Cache warm-up bounds: how many recently active rooms to prime, how large
+// a history page to read per room, and how long the whole pass may take
+const (
+	warmupRoomCount = 20
+	warmupPageSize  = 50
+	warmupTimeout   = 10 * time.Second
+)
+
+// warmCaches primes the OS and SQLite page caches after a cold start by
+// reading the most recently active rooms' latest history pages and the FTS
+// index. It is best-effort and time-bounded: errors are logged, never
+// fatal, and the pass stops once warmupTimeout elapses. Run it in a
+// goroutine so it never blocks serving.
+func (h *ChatServer) warmCaches() {
+	ctx, cancel := context.WithTimeout(context.Background(), warmupTimeout)
+	defer cancel()
+
+	start := time.Now()
+	warmed, err := db.WarmRecentRooms(ctx, h.db, warmupRoomCount, warmupPageSize)
+	if err != nil {
+		h.logger.Error("cache warm-up failed", "error", err)
+		return
+	}
+	h.logger.Info("cache warm-up finished", "rooms", warmed, "elapsed", time.Since(start))
+}
@@ -0,0 +1,80 @@
+// Package webhook signs and verifies outgoing webhook deliveries.
+//
+// Each delivery carries two headers:
+//
+//	X-Hatchat-Timestamp: the unix time (seconds) the delivery was signed
+//	X-Hatchat-Signature: v1=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// The timestamp is part of the signed payload, so a receiver that checks
+// it against its own clock (Verify does this with a tolerance window) is
+// protected against replayed deliveries.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Header names for signed deliveries
+const (
+	SignatureHeader = "X-Hatchat-Signature"
+	TimestampHeader = "X-Hatchat-Timestamp"
+)
+
+// Tolerance is how far a delivery's timestamp may drift from the
+// receiver's clock, in either direction, before Verify rejects it as a
+// possible replay
+const Tolerance = 5 * time.Minute
+
+// Verification failures, distinguishable so receivers can log replays
+// separately from forgeries
+var (
+	ErrBadTimestamp   = errors.New("webhook: malformed timestamp")
+	ErrStaleTimestamp = errors.New("webhook: timestamp outside tolerance window")
+	ErrBadSignature   = errors.New("webhook: signature mismatch")
+)
+
+// Sign computes the signature header value for a delivery of body at
+// timestamp. The timestamp header value is the same timestamp formatted
+// with Timestamp.
+func Sign(secret string, body []byte, timestamp time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Timestamp formats a delivery timestamp for the timestamp header
+func Timestamp(timestamp time.Time) string {
+	return strconv.FormatInt(timestamp.Unix(), 10)
+}
+
+// Verify authenticates a delivery from its body and the two header values.
+// It rejects timestamps further than Tolerance from the current time
+// before checking the signature, and compares signatures in constant time.
+func Verify(secret string, body []byte, timestampHeader, signature string) error {
+	seconds, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrBadTimestamp
+	}
+	sent := time.Unix(seconds, 0)
+
+	drift := time.Since(sent)
+	if drift < 0 {
+		drift = -drift
+	}
+	if drift > Tolerance {
+		return ErrStaleTimestamp
+	}
+
+	expected := Sign(secret, body, sent)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrBadSignature
+	}
+	return nil
+}
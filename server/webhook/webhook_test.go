@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerify_SignedPayload(t *testing.T) {
+	secret := "whsec_test_secret"
+	body := []byte(`{"type":"message","body":"hello"}`)
+	now := time.Now()
+
+	sig := Sign(secret, body, now)
+	if err := Verify(secret, body, Timestamp(now), sig); err != nil {
+		t.Errorf("Expected a correctly signed payload to verify, got %v", err)
+	}
+}
+
+func TestVerify_TamperedBody(t *testing.T) {
+	secret := "whsec_test_secret"
+	body := []byte(`{"type":"message","body":"hello"}`)
+	now := time.Now()
+
+	sig := Sign(secret, body, now)
+	tampered := []byte(`{"type":"message","body":"hell0"}`)
+	if err := Verify(secret, tampered, Timestamp(now), sig); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Expected ErrBadSignature for a tampered body, got %v", err)
+	}
+}
+
+func TestVerify_WrongSecret(t *testing.T) {
+	body := []byte(`{"type":"message"}`)
+	now := time.Now()
+
+	sig := Sign("whsec_right", body, now)
+	if err := Verify("whsec_wrong", body, Timestamp(now), sig); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Expected ErrBadSignature for the wrong secret, got %v", err)
+	}
+}
+
+func TestVerify_StaleTimestamp(t *testing.T) {
+	secret := "whsec_test_secret"
+	body := []byte(`{"type":"message"}`)
+	stale := time.Now().Add(-Tolerance - time.Minute)
+
+	// Correctly signed, but too old: a replayed delivery
+	sig := Sign(secret, body, stale)
+	if err := Verify(secret, body, Timestamp(stale), sig); !errors.Is(err, ErrStaleTimestamp) {
+		t.Errorf("Expected ErrStaleTimestamp for a replayed delivery, got %v", err)
+	}
+}
+
+func TestVerify_MalformedTimestamp(t *testing.T) {
+	secret := "whsec_test_secret"
+	body := []byte(`{"type":"message"}`)
+
+	sig := Sign(secret, body, time.Now())
+	if err := Verify(secret, body, "not-a-unix-time", sig); !errors.Is(err, ErrBadTimestamp) {
+		t.Errorf("Expected ErrBadTimestamp for a malformed timestamp, got %v", err)
+	}
+}
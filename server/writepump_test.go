@@ -0,0 +1,144 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingWriter fails after allowing a set number of writes, standing in
+// for a websocket writer whose connection has died mid-frame
+type failingWriter struct {
+	writesLeft int
+	written    []byte
+	closed     bool
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.writesLeft <= 0 {
+		return 0, fmt.Errorf("connection reset")
+	}
+	f.writesLeft--
+	f.written = append(f.written, p...)
+	return len(p), nil
+}
+
+func (f *failingWriter) Close() error {
+	f.closed = true
+	return nil
+}
+
+// TestWriteBatch_CoalescesQueue tests that queued messages are written as
+// one newline-delimited frame and the writer is closed
+func TestWriteBatch_CoalescesQueue(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	c := &Client{send: make(chan []byte, 8), logger: logger}
+	c.send <- []byte("second")
+	c.send <- []byte("third")
+
+	w := &failingWriter{writesLeft: 100}
+	if err := c.writeBatch(w, []byte("first")); err != nil {
+		t.Fatalf("writeBatch failed: %v", err)
+	}
+	if got := string(w.written); got != "first\nsecond\nthird" {
+		t.Errorf("Expected coalesced frame %q, got %q", "first\nsecond\nthird", got)
+	}
+	if !w.closed {
+		t.Error("Expected the writer to be closed")
+	}
+	if len(c.send) != 0 {
+		t.Errorf("Expected the queue to be drained, %d messages left", len(c.send))
+	}
+}
+
+// TestWriteBatch_FailingWriter tests that a write error is returned rather
+// than panicking, and the writer is closed no matter where the failure hits
+func TestWriteBatch_FailingWriter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tc := range []struct {
+		name       string
+		writesLeft int
+	}{
+		{"first write fails", 0},
+		{"fails mid-batch", 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Client{send: make(chan []byte, 8), logger: logger}
+			c.send <- []byte("queued one")
+			c.send <- []byte("queued two")
+
+			w := &failingWriter{writesLeft: tc.writesLeft}
+			if err := c.writeBatch(w, []byte("first")); err == nil {
+				t.Error("Expected writeBatch to return the write error")
+			}
+			if !w.closed {
+				t.Error("Expected the writer to be closed after a failed write")
+			}
+		})
+	}
+}
+
+// TestWritePump_FailedWriteCleansUp tests that writes to a dead connection
+// unregister that client without taking down the server or other
+// connections
+func TestWritePump_FailedWriteCleansUp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ts := newTestServer(t)
+	defer ts.close()
+
+	broken := ts.createUser("brokenpipe", "password123")
+	watcher := ts.createUser("watcher", "password123")
+
+	bc := ts.connectWebSocket(broken, "brokenpipe")
+	defer bc.close()
+	wc := ts.connectWebSocket(watcher, "watcher")
+	defer wc.close()
+
+	if _, err := bc.sendInit(); err != nil {
+		t.Fatalf("Failed to init broken client: %v", err)
+	}
+	wInit, err := wc.sendInit()
+	if err != nil {
+		t.Fatalf("Failed to init watcher: %v", err)
+	}
+	initData, ok := wInit.Data.(map[string]interface{})
+	if !ok {
+		t.Fatal("Failed to parse init data")
+	}
+	roomID := initData["current_room"].(string)
+
+	// Sever the TCP connection without a websocket close handshake, so
+	// the server's next writes to this client fail
+	_ = bc.conn.UnderlyingConn().Close()
+	<-bc.done
+
+	// Drive broadcasts at the dead connection until the hub unregisters
+	// it; the watcher receiving its own messages throughout proves the
+	// server and other connections survive the failed writes
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := wc.sendMessage("still alive", roomID); err != nil {
+			t.Fatalf("Watcher failed to send: %v", err)
+		}
+		msg, err := wc.waitForMessage(2 * time.Second)
+		if err != nil {
+			t.Fatalf("Watcher didn't receive its own message: %v", err)
+		}
+		if !strings.Contains(string(msg), "still alive") {
+			t.Fatalf("Watcher received wrong message: %s", msg)
+		}
+		if len(ts.hub.onlineUserIDs()) == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the broken connection to be unregistered")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
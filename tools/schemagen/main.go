@@ -1,19 +1,59 @@
 // schemagen generates JSON Schema from the protocol package types.
 //
 // Usage: go run ./tools/schemagen > schema/protocol.json
+//
+// With -check it regenerates in memory and exits non-zero if the result
+// differs from the committed schema, printing the diff instead of writing
+// anything, so CI can detect schema drift:
+//
+//	go run ./tools/schemagen -check
 package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 
 	"github.com/invopop/jsonschema"
 	"github.com/llimllib/hatchat/server/protocol"
 )
 
 func main() {
+	check := flag.Bool("check", false, "verify the on-disk schema matches the protocol types instead of printing the schema")
+	schemaPath := flag.String("schema", "schema/protocol.json", "path to the committed schema, used by -check")
+	flag.Parse()
+
+	generated, err := generate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error marshaling schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !*check {
+		fmt.Println(string(generated))
+		return
+	}
+
+	current, err := os.ReadFile(*schemaPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading %s: %v\n", *schemaPath, err)
+		os.Exit(1)
+	}
+	diff := diffLines(string(current), string(generated)+"\n")
+	if len(diff) > 0 {
+		fmt.Fprintf(os.Stderr, "%s is out of date; regenerate with: go run ./tools/schemagen > %s\n", *schemaPath, *schemaPath)
+		for _, line := range diff {
+			fmt.Fprintln(os.Stderr, line)
+		}
+		os.Exit(1)
+	}
+}
+
+// generate reflects the protocol types into the combined schema document
+func generate() ([]byte, error) {
 	// Use a single reflector so refs work properly
 	r := jsonschema.Reflector{
 		DoNotReference:             false, // Allow $refs
@@ -29,42 +69,132 @@ func main() {
 		protocol.Message{},
 		protocol.RoomMember{},
 		protocol.InitRequest{},
+		protocol.PingRequest{},
 		protocol.SendMessageRequest{},
 		protocol.HistoryRequest{},
+		protocol.MarkReadRequest{},
+		protocol.ForwardMessageRequest{},
+		protocol.ListReactorsRequest{},
+		protocol.GetReactionsRequest{},
+		protocol.StarMessageRequest{},
+		protocol.UnstarMessageRequest{},
+		protocol.ListStarredRequest{},
+		protocol.FavoriteRoomRequest{},
+		protocol.ReorderFavoritesRequest{},
+		protocol.HideDMRequest{},
+		protocol.SetDMLabelRequest{},
+		protocol.PinMessageRequest{},
+		protocol.SetPinAnnouncementsRequest{},
+		protocol.SyncRequest{},
 		protocol.JoinRoomRequest{},
 		protocol.CreateRoomRequest{},
 		protocol.CreateDMRequest{},
+		protocol.AddMembersRequest{},
+		protocol.ApproveJoinRequestRequest{},
+		protocol.DenyJoinRequestRequest{},
+		protocol.ListJoinRequestsRequest{},
+		protocol.ReportMessageRequest{},
+		protocol.ListReportsRequest{},
+		protocol.ResolveReportRequest{},
 		protocol.ListRoomsRequest{},
 		protocol.ListUsersRequest{},
+		protocol.AutocompleteRequest{},
 		protocol.LeaveRoomRequest{},
 		protocol.RoomInfoRequest{},
+		protocol.ListMembersRequest{},
+		protocol.MyMembershipRequest{},
 		protocol.GetProfileRequest{},
+		protocol.GetProfilesRequest{},
 		protocol.UpdateProfileRequest{},
+		protocol.SetUsernameRequest{},
+		protocol.GetUnreadRequest{},
+		protocol.GetPrefsRequest{},
+		protocol.SetPrefsRequest{},
+		protocol.GetMessagesRequest{},
 		protocol.InitResponse{},
+		protocol.PongResponse{},
 		protocol.HistoryResponse{},
+		protocol.ListReactorsResponse{},
+		protocol.GetReactionsResponse{},
+		protocol.ListStarredResponse{},
+		protocol.StarredMessage{},
 		protocol.JoinRoomResponse{},
 		protocol.CreateRoomResponse{},
 		protocol.CreateDMResponse{},
+		protocol.HideDMResponse{},
+		protocol.SetDMLabelResponse{},
 		protocol.ListRoomsResponse{},
 		protocol.ListUsersResponse{},
+		protocol.AutocompleteResponse{},
+		protocol.AutocompleteItem{},
 		protocol.LeaveRoomResponse{},
+		protocol.RoomDeleted{},
 		protocol.RoomInfoResponse{},
+		protocol.ListMembersResponse{},
+		protocol.MyMembershipResponse{},
 		protocol.GetProfileResponse{},
+		protocol.GetProfilesResponse{},
 		protocol.UpdateProfileResponse{},
+		protocol.SetUsernameResponse{},
+		protocol.ProfileUpdated{},
 		protocol.ErrorResponse{},
 		protocol.Reaction{},
+		protocol.Attachment{},
+		protocol.ForwardedFrom{},
+		protocol.QuotedMessage{},
 		protocol.EditMessageRequest{},
+		protocol.PatchOp{},
 		protocol.DeleteMessageRequest{},
 		protocol.AddReactionRequest{},
 		protocol.RemoveReactionRequest{},
 		protocol.MessageEdited{},
 		protocol.MessageDeleted{},
 		protocol.ReactionUpdated{},
+		protocol.SetSlowModeRequest{},
+		protocol.SetRetentionRequest{},
+		protocol.SetPostPolicyRequest{},
+		protocol.SetRoomTopicRequest{},
+		protocol.AddRoomFilterRequest{},
+		protocol.RemoveRoomFilterRequest{},
+		protocol.ListRoomFiltersRequest{},
+		protocol.RoomSettingsUpdated{},
+		protocol.AddRoomFilterResponse{},
+		protocol.RemoveRoomFilterResponse{},
+		protocol.ListRoomFiltersResponse{},
+		protocol.RoomFilterRule{},
+		protocol.AddMembersResponse{},
+		protocol.ApproveJoinRequestResponse{},
+		protocol.DenyJoinRequestResponse{},
+		protocol.ListJoinRequestsResponse{},
+		protocol.JoinRequestInfo{},
+		protocol.JoinRequested{},
+		protocol.MemberChanged{},
+		protocol.ReportMessageResponse{},
+		protocol.ReportInfo{},
+		protocol.ListReportsResponse{},
+		protocol.ResolveReportResponse{},
+		protocol.MessageReported{},
 		protocol.SearchRequest{},
 		protocol.SearchResponse{},
 		protocol.SearchResult{},
+		protocol.SearchStreamRequest{},
+		protocol.SearchStreamCancelRequest{},
+		protocol.SearchStreamBatch{},
+		protocol.SearchStreamDone{},
 		protocol.GetMessageContextRequest{},
 		protocol.GetMessageContextResponse{},
+		protocol.CatchUpRequest{},
+		protocol.CatchUpResponse{},
+		protocol.RoomCatchUp{},
+		protocol.LatencyUpdate{},
+		protocol.ServerStatus{},
+		protocol.MessagePinned{},
+		protocol.FavoritesUpdated{},
+		protocol.SyncResponse{},
+		protocol.RoomUnread{},
+		protocol.GetUnreadResponse{},
+		protocol.PrefsResponse{},
+		protocol.GetMessagesResponse{},
 		protocol.Envelope{},
 	}
 
@@ -127,11 +257,36 @@ func main() {
 		"anyOf":       anyOf,
 	}
 
-	data, err := json.MarshalIndent(combined, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error marshaling schema: %v\n", err)
-		os.Exit(1)
-	}
+	return json.MarshalIndent(combined, "", "  ")
+}
 
-	fmt.Println(string(data))
+// maxDiffLines caps how much of a schema mismatch -check prints
+const maxDiffLines = 50
+
+// diffLines compares two schema documents line by line and returns the
+// mismatches, prefixed with the line number and -/+ markers. An empty
+// result means the documents are identical.
+func diffLines(want, got string) []string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var diff []string
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		if len(diff) >= maxDiffLines {
+			diff = append(diff, "... (diff truncated)")
+			break
+		}
+		diff = append(diff, fmt.Sprintf("%d: -%s", i+1, w), fmt.Sprintf("%d: +%s", i+1, g))
+	}
+	return diff
 }
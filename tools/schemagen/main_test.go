@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheck_UpToDateSchemaPasses tests that a freshly regenerated schema
+// compares clean against itself
+func TestCheck_UpToDateSchemaPasses(t *testing.T) {
+	generated, err := generate()
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	schemaPath := filepath.Join(t.TempDir(), "protocol.json")
+	if err := os.WriteFile(schemaPath, append(generated, '\n'), 0o644); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+
+	current, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	if diff := diffLines(string(current), string(generated)+"\n"); len(diff) > 0 {
+		t.Errorf("expected no diff for an up-to-date schema, got %d lines:\n%s", len(diff), strings.Join(diff, "\n"))
+	}
+}
+
+// TestCheck_DriftFailsUntilRegenerated tests that a schema written before a
+// protocol change fails the check, and passes again once regenerated
+func TestCheck_DriftFailsUntilRegenerated(t *testing.T) {
+	generated, err := generate()
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	// A committed schema from before a type changed: same document with one
+	// property renamed
+	stale := strings.Replace(string(generated), `"message_id"`, `"msg_id"`, 1)
+	if stale == string(generated) {
+		t.Fatal("expected the generated schema to contain a message_id property")
+	}
+	schemaPath := filepath.Join(t.TempDir(), "protocol.json")
+	if err := os.WriteFile(schemaPath, []byte(stale+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+
+	current, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	diff := diffLines(string(current), string(generated)+"\n")
+	if len(diff) == 0 {
+		t.Fatal("expected a diff for a drifted schema")
+	}
+
+	// Regenerating the file brings the check back to green
+	if err := os.WriteFile(schemaPath, append(generated, '\n'), 0o644); err != nil {
+		t.Fatalf("failed to regenerate schema: %v", err)
+	}
+	current, err = os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	if diff := diffLines(string(current), string(generated)+"\n"); len(diff) > 0 {
+		t.Errorf("expected no diff after regenerating, got %d lines", len(diff))
+	}
+}
+
+// TestDiffLines_Truncation tests that long diffs are capped
+func TestDiffLines_Truncation(t *testing.T) {
+	want := strings.Repeat("a\n", maxDiffLines*2)
+	got := strings.Repeat("b\n", maxDiffLines*2)
+	diff := diffLines(want, got)
+	if len(diff) == 0 {
+		t.Fatal("expected a diff")
+	}
+	last := diff[len(diff)-1]
+	if !strings.Contains(last, "truncated") {
+		t.Errorf("expected the diff to be truncated, last line: %s", last)
+	}
+}